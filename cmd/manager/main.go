@@ -21,11 +21,14 @@ import (
 
 	"github.com/Juniper/contrail-operator/pkg/apis"
 	"github.com/Juniper/contrail-operator/pkg/apis/contrail/v1alpha1"
+	"github.com/Juniper/contrail-operator/pkg/certificates"
 	"github.com/Juniper/contrail-operator/pkg/controller"
 	"github.com/Juniper/contrail-operator/pkg/controller/contrailcni"
 	"github.com/Juniper/contrail-operator/pkg/controller/kubemanager"
 	"github.com/Juniper/contrail-operator/pkg/k8s"
+	"github.com/Juniper/contrail-operator/pkg/metrics"
 	"github.com/Juniper/contrail-operator/pkg/openshift"
+	"github.com/Juniper/contrail-operator/pkg/webhook"
 )
 
 var log = logf.Log.WithName("cmd")
@@ -45,6 +48,20 @@ func main() {
 	// controller-runtime).
 	pflag.CommandLine.AddGoFlagSet(flag.CommandLine)
 
+	metricsBindAddress := pflag.String("metrics-bind-address", ":8383", "Address the Prometheus metrics endpoint binds to, \"0\" disables it")
+	enableWebhooks := pflag.Bool("enable-webhooks", false, "Serve defaulting and validating admission webhooks for contrail resources")
+	webhookPort := pflag.Int("webhook-port", 9443, "Port of the admission webhooks server")
+	webhookCertDir := pflag.String("webhook-cert-dir", "/tmp/k8s-webhook-server/serving-certs", "Directory with tls.crt and tls.key of the admission webhooks server")
+	pflag.DurationVar(&certificates.CACertValidityPeriod, "ca-certificate-validity", certificates.CACertValidityPeriod, "Validity period of the contrail-signer CA certificate")
+	pflag.DurationVar(&certificates.CertValidityPeriod, "certificate-validity", certificates.CertValidityPeriod, "Validity period of certificates issued for pods")
+	pflag.Float64Var(&certificates.RenewalThreshold, "certificate-renewal-threshold", certificates.RenewalThreshold, "Part of the certificate lifetime after which it is renewed")
+	certificateIssuer := pflag.String("certificate-issuer", string(certificates.CertificateIssuer), "Issuer of certificates of pods: \"ca\" (CA generated by the operator), \"external-ca\" or \"cert-manager\"")
+	pflag.StringVar(&certificates.ExternalCASecretName, "external-ca-secret", certificates.ExternalCASecretName, "Secret with the CA (tls.crt, tls.key and optionally ca.crt) used by the external-ca and cert-manager issuers")
+	pflag.StringVar(&certificates.CertManagerIssuerName, "cert-manager-issuer", certificates.CertManagerIssuerName, "Name of the cert-manager issuer of certificates")
+	pflag.StringVar(&certificates.CertManagerIssuerKind, "cert-manager-issuer-kind", certificates.CertManagerIssuerKind, "Kind of the cert-manager issuer of certificates, Issuer or ClusterIssuer")
+	pflag.BoolVar(&certificates.PublishCRL, "publish-crl", certificates.PublishCRL, "Revoke certificates of IPs which no longer belong to pods and publish the revocation list in the csr-signer-ca ConfigMap")
+	pflag.DurationVar(&certificates.CARolloverOverlap, "ca-rollover-overlap", certificates.CARolloverOverlap, "How long a new CA certificate is published next to the old one before it signs certificates")
+
 	pflag.Parse()
 
 	// Use a zap logr.Logger implementation. If none of the zap
@@ -59,6 +76,11 @@ func main() {
 
 	printVersion()
 
+	if err := certificates.UseIssuer(certificates.IssuerType(*certificateIssuer)); err != nil {
+		log.Error(err, "Invalid --certificate-issuer")
+		os.Exit(1)
+	}
+
 	namespace, err := k8sutil.GetWatchNamespace()
 	if err != nil {
 		log.Error(err, "Failed to get watch namespace")
@@ -75,10 +97,12 @@ func main() {
 	// Create a new Cmd to provide shared dependencies and start components.
 	mgr, err := manager.New(cfg, manager.Options{
 		Namespace:               namespace,
-		MetricsBindAddress:      "0",
+		MetricsBindAddress:      *metricsBindAddress,
 		LeaderElection:          true,
 		LeaderElectionID:        "contrail-manager-lock",
 		LeaderElectionNamespace: namespace,
+		Port:                    *webhookPort,
+		CertDir:                 *webhookCertDir,
 	})
 	if err != nil {
 		log.Error(err, "")
@@ -136,6 +160,19 @@ func main() {
 		os.Exit(1)
 	}
 
+	if err := metrics.AddToManager(mgr, namespace); err != nil {
+		log.Error(err, "")
+		os.Exit(1)
+	}
+
+	if *enableWebhooks {
+		log.Info("Registering admission webhooks.")
+		if err := webhook.AddToManager(mgr); err != nil {
+			log.Error(err, "")
+			os.Exit(1)
+		}
+	}
+
 	log.Info("Starting the Cmd.")
 
 	// Start the Cmd
@@ -13,6 +13,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
@@ -112,6 +113,22 @@ type PodConfiguration struct {
 	// zero and not specified. Defaults to 1.
 	// +optional
 	Replicas *int32 `json:"replicas,omitempty" protobuf:"varint,1,opt,name=replicas"`
+	// CertificateKey selects private keys of certificates issued for pods.
+	// +optional
+	CertificateKey *CertificateKey `json:"certificateKey,omitempty"`
+}
+
+// CertificateKey selects the algorithm and size of private keys of certificates.
+// +k8s:openapi-gen=true
+type CertificateKey struct {
+	// Algorithm of the key, RSA by default.
+	// +kubebuilder:validation:Enum=RSA;ECDSA
+	// +optional
+	Algorithm string `json:"algorithm,omitempty"`
+	// Size is the length of RSA keys in bits (2048 by default) or the size of
+	// the ECDSA curve: 256 (default), 384 or 521.
+	// +optional
+	Size int `json:"size,omitempty"`
 }
 
 //GetReplicas is used to get number of desired pods.
@@ -583,7 +600,7 @@ func UpdateSTS(sts *appsv1.StatefulSet, instanceType string, request reconcile.R
 }
 
 // SetInstanceActive sets the instance to active.
-func SetInstanceActive(client client.Client, activeStatus *bool, sts *appsv1.StatefulSet, request reconcile.Request, object runtime.Object) error {
+func SetInstanceActive(client client.Client, activeStatus *bool, conditions *[]Condition, sts *appsv1.StatefulSet, request reconcile.Request, object runtime.Object) error {
 	if err := client.Get(context.TODO(), types.NamespacedName{Name: sts.Name, Namespace: request.Namespace},
 		sts); err != nil {
 		return err
@@ -594,6 +611,13 @@ func SetInstanceActive(client client.Client, activeStatus *bool, sts *appsv1.Sta
 	}
 
 	*activeStatus = active
+	accessor, err := meta.Accessor(object)
+	if err != nil {
+		return err
+	}
+	if err := SetWorkloadConditions(client, conditions, accessor.GetGeneration(), sts.Namespace, sts.Spec.Selector, *sts.Spec.Replicas, sts.Status.ReadyReplicas); err != nil {
+		return err
+	}
 	if err := client.Status().Update(context.TODO(), object); err != nil {
 		return err
 	}
@@ -808,6 +832,7 @@ func NewKeystoneClusterConfiguration(name string, namespace string, client clien
 type WebUIClusterConfiguration struct {
 	AdminUsername string
 	AdminPassword string
+	AdminProject  string
 }
 
 // CommandClusterConfiguration defines all configuration knobs used to write the config file.
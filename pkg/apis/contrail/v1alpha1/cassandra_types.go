@@ -64,6 +64,36 @@ type CassandraStatus struct {
 	Nodes     map[string]string    `json:"nodes,omitempty"`
 	Ports     CassandraStatusPorts `json:"ports,omitempty"`
 	ClusterIP string               `json:"clusterIP,omitempty"`
+	// +optional
+	// +patchMergeKey=type
+	// +patchStrategy=merge
+	Conditions []Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+	// Decommission reports progress of removing the node of the highest pod
+	// from the cluster when replicas are reduced.
+	Decommission *CassandraDecommissionStatus `json:"decommission,omitempty"`
+	// RemovingNodes are host IDs of dead nodes being removed from the cluster.
+	RemovingNodes []string `json:"removingNodes,omitempty"`
+}
+
+// CassandraDecommissionPhase is the phase of decommissioning a Cassandra node.
+type CassandraDecommissionPhase string
+
+const (
+	// CassandraDecommissioning means the node streams its data to other nodes.
+	CassandraDecommissioning CassandraDecommissionPhase = "Decommissioning"
+	// CassandraDecommissioned means the node left the cluster and its pod and
+	// data are being removed.
+	CassandraDecommissioned CassandraDecommissionPhase = "Decommissioned"
+)
+
+// CassandraDecommissionStatus defines the status of a node being decommissioned.
+type CassandraDecommissionStatus struct {
+	// Pod is the name of the pod running the node.
+	Pod   string                     `json:"pod"`
+	Phase CassandraDecommissionPhase `json:"phase"`
+	// Message describes data streams of the node or why decommission failed.
+	Message   string       `json:"message,omitempty"`
+	StartTime *metav1.Time `json:"startTime,omitempty"`
 }
 
 // CassandraStatusPorts defines the status of the ports of the cassandra object.
@@ -226,6 +256,9 @@ func (c *Cassandra) SetInstanceActive(client client.Client, activeStatus *bool,
 	if sts.Status.ReadyReplicas >= acceptableReadyReplicaCnt {
 		*activeStatus = true
 	}
+	if err := SetWorkloadConditions(client, &c.Status.Conditions, c.Generation, sts.Namespace, sts.Spec.Selector, *sts.Spec.Replicas, sts.Status.ReadyReplicas); err != nil {
+		return err
+	}
 
 	return client.Status().Update(context.TODO(), c)
 }
@@ -0,0 +1,115 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CassandraBackupSpec defines the desired state of CassandraBackup
+// +k8s:openapi-gen=true
+type CassandraBackupSpec struct {
+	// CassandraInstance is the name of the Cassandra to back up.
+	CassandraInstance string `json:"cassandraInstance"`
+	// Keyspaces to back up. All keyspaces except system ones are backed up when empty.
+	// +optional
+	Keyspaces []string `json:"keyspaces,omitempty"`
+	// Target is the object storage the backup is uploaded to.
+	Target BackupTarget `json:"target"`
+}
+
+// BackupTarget is an object storage backups are uploaded to.
+// Exactly one of Swift and S3 has to be set.
+// +k8s:openapi-gen=true
+type BackupTarget struct {
+	// +optional
+	Swift *SwiftBackupTarget `json:"swift,omitempty"`
+	// +optional
+	S3 *S3BackupTarget `json:"s3,omitempty"`
+}
+
+// SwiftBackupTarget points to a container of the Swift deployed by the operator.
+// +k8s:openapi-gen=true
+type SwiftBackupTarget struct {
+	SwiftInstance string `json:"swiftInstance"`
+	Container     string `json:"container"`
+}
+
+// S3BackupTarget points to a bucket of an S3 compatible object storage.
+// +k8s:openapi-gen=true
+type S3BackupTarget struct {
+	// Endpoint is the URL of the storage, e.g. https://s3.eu-west-1.amazonaws.com.
+	Endpoint string `json:"endpoint"`
+	// +optional
+	Region string `json:"region,omitempty"`
+	Bucket string `json:"bucket"`
+	// CredentialsSecretName is the name of the secret with accessKey and secretKey keys.
+	CredentialsSecretName string `json:"credentialsSecretName"`
+}
+
+// BackupPhase is a stage of a backup or a restore.
+type BackupPhase string
+
+const (
+	BackupPending   BackupPhase = "Pending"
+	BackupRunning   BackupPhase = "Running"
+	BackupCompleted BackupPhase = "Completed"
+	BackupFailed    BackupPhase = "Failed"
+)
+
+// CassandraBackupStatus defines the observed state of CassandraBackup
+// +k8s:openapi-gen=true
+type CassandraBackupStatus struct {
+	// +optional
+	Phase BackupPhase `json:"phase,omitempty"`
+	// Path is the prefix of objects of the backup in the target.
+	// +optional
+	Path string `json:"path,omitempty"`
+	// Archives lists uploaded snapshots of Cassandra nodes.
+	// +optional
+	Archives []string `json:"archives,omitempty"`
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+	// +optional
+	Message string `json:"message,omitempty"`
+	// +optional
+	// +patchMergeKey=type
+	// +patchStrategy=merge
+	Conditions []Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// CassandraBackup is the Schema for the cassandrabackups API
+// +k8s:openapi-gen=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:path=cassandrabackups,scope=Namespaced
+// +kubebuilder:printcolumn:name="Cassandra",type=string,JSONPath=`.spec.cassandraInstance`
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Path",type=string,JSONPath=`.status.path`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+type CassandraBackup struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   CassandraBackupSpec   `json:"spec,omitempty"`
+	Status CassandraBackupStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// CassandraBackupList contains a list of CassandraBackup
+type CassandraBackupList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []CassandraBackup `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&CassandraBackup{}, &CassandraBackupList{})
+}
+
+// Finished returns true when the backup has completed or failed.
+func (b *CassandraBackup) Finished() bool {
+	return b.Status.Phase == BackupCompleted || b.Status.Phase == BackupFailed
+}
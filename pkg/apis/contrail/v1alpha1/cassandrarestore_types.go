@@ -0,0 +1,78 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CassandraRestoreSpec defines the desired state of CassandraRestore
+// +k8s:openapi-gen=true
+type CassandraRestoreSpec struct {
+	// CassandraInstance is the name of the Cassandra the backup is loaded into.
+	// It may be a fresh cluster with a different number of nodes than the backed up one.
+	CassandraInstance string `json:"cassandraInstance"`
+	// BackupName is the name of the CassandraBackup to restore.
+	// +optional
+	BackupName string `json:"backupName,omitempty"`
+	// Target and Path point to a backup without CassandraBackup in the cluster,
+	// e.g. when the cluster is rebuilt from scratch. Used when BackupName is empty.
+	// +optional
+	Target *BackupTarget `json:"target,omitempty"`
+	// +optional
+	Path string `json:"path,omitempty"`
+}
+
+// CassandraRestoreStatus defines the observed state of CassandraRestore
+// +k8s:openapi-gen=true
+type CassandraRestoreStatus struct {
+	// +optional
+	Phase BackupPhase `json:"phase,omitempty"`
+	// RestoredArchives lists snapshots already loaded into Cassandra.
+	// +optional
+	RestoredArchives []string `json:"restoredArchives,omitempty"`
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+	// +optional
+	Message string `json:"message,omitempty"`
+	// +optional
+	// +patchMergeKey=type
+	// +patchStrategy=merge
+	Conditions []Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// CassandraRestore is the Schema for the cassandrarestores API
+// +k8s:openapi-gen=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:path=cassandrarestores,scope=Namespaced
+// +kubebuilder:printcolumn:name="Cassandra",type=string,JSONPath=`.spec.cassandraInstance`
+// +kubebuilder:printcolumn:name="Backup",type=string,JSONPath=`.spec.backupName`
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+type CassandraRestore struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   CassandraRestoreSpec   `json:"spec,omitempty"`
+	Status CassandraRestoreStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// CassandraRestoreList contains a list of CassandraRestore
+type CassandraRestoreList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []CassandraRestore `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&CassandraRestore{}, &CassandraRestoreList{})
+}
+
+// Finished returns true when the restore has completed or failed.
+func (r *CassandraRestore) Finished() bool {
+	return r.Status.Phase == BackupCompleted || r.Status.Phase == BackupFailed
+}
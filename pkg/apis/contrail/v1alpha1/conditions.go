@@ -0,0 +1,227 @@
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ConditionType is a type of condition of a resource.
+type ConditionType string
+
+// These are condition types set on every resource.
+const (
+	// ConditionReady is true when all replicas of the resource are ready.
+	ConditionReady ConditionType = "Ready"
+	// ConditionProgressing is true when the resource is being rolled out
+	// or waits for its dependencies.
+	ConditionProgressing ConditionType = "Progressing"
+	// ConditionDegraded is true when pods of the resource fail to start.
+	ConditionDegraded ConditionType = "Degraded"
+	// ConditionDependenciesReady is true when all resources the resource
+	// depends on are active.
+	ConditionDependenciesReady ConditionType = "DependenciesReady"
+)
+
+// These are reasons of the conditions.
+const (
+	ReasonReplicasReady          = "ReplicasReady"
+	ReasonReplicasNotReady       = "ReplicasNotReady"
+	ReasonDependenciesReady      = "DependenciesReady"
+	ReasonDependencyNotReady     = "DependencyNotReady"
+	ReasonWaitingForDependencies = "WaitingForDependencies"
+	ReasonAsExpected             = "AsExpected"
+	ReasonResourcesReady         = "ResourcesReady"
+	ReasonResourcesNotReady      = "ResourcesNotReady"
+	ReasonExternalServiceReady   = "ExternalServiceReady"
+	ReasonServicesReady          = "ServicesReady"
+	ReasonServicesNotReady       = "ServicesNotReady"
+	ReasonServicesDegraded       = "ServicesDegraded"
+	ReasonRestoring              = "Restoring"
+)
+
+// ConditionStatus is used to indicate state of condition.
+type ConditionStatus string
+
+// These are valid condition statuses. "ConditionTrue" means a resource is in the condition.
+// "ConditionFalse" means a resource is not in the condition. "ConditionUnknown" means
+// the operator can't decide if a resource is in the condition or not.
+const (
+	ConditionTrue    ConditionStatus = "True"
+	ConditionFalse   ConditionStatus = "False"
+	ConditionUnknown ConditionStatus = "Unknown"
+)
+
+// Condition describes the state of a resource at a certain point.
+// It follows conventions of metav1.Condition.
+// +k8s:openapi-gen=true
+type Condition struct {
+	// Type of the condition.
+	Type ConditionType `json:"type"`
+	// Status of the condition, one of True, False or Unknown.
+	Status ConditionStatus `json:"status"`
+	// ObservedGeneration is the generation of the resource
+	// the condition was set for.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// LastTransitionTime is the last time the status of the condition changed.
+	// +optional
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+	// Reason is a CamelCase reason of the last transition.
+	// +optional
+	Reason string `json:"reason,omitempty"`
+	// Message is a human readable description of the last transition.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// podFailureReasons are reasons of waiting containers which
+// will not get ready without a change of the resource.
+var podFailureReasons = map[string]bool{
+	"CrashLoopBackOff":           true,
+	"ImagePullBackOff":           true,
+	"ErrImagePull":               true,
+	"InvalidImageName":           true,
+	"CreateContainerConfigError": true,
+	"CreateContainerError":       true,
+}
+
+// SetCondition adds the condition or replaces the existing condition of the same type.
+// LastTransitionTime is changed only when the status of the condition changes.
+func SetCondition(conditions *[]Condition, condition Condition) {
+	for i := range *conditions {
+		existing := &(*conditions)[i]
+		if existing.Type != condition.Type {
+			continue
+		}
+		if existing.Status == condition.Status {
+			condition.LastTransitionTime = existing.LastTransitionTime
+		} else if condition.LastTransitionTime.IsZero() {
+			condition.LastTransitionTime = metav1.Now()
+		}
+		*existing = condition
+		return
+	}
+	if condition.LastTransitionTime.IsZero() {
+		condition.LastTransitionTime = metav1.Now()
+	}
+	*conditions = append(*conditions, condition)
+}
+
+// FindCondition returns the condition of the given type or nil if it is not set.
+func FindCondition(conditions []Condition, conditionType ConditionType) *Condition {
+	for i := range conditions {
+		if conditions[i].Type == conditionType {
+			return &conditions[i]
+		}
+	}
+	return nil
+}
+
+// IsConditionTrue returns true when the condition of the given type is set to True.
+func IsConditionTrue(conditions []Condition, conditionType ConditionType) bool {
+	c := FindCondition(conditions, conditionType)
+	return c != nil && c.Status == ConditionTrue
+}
+
+// SetDependencyNotReady sets conditions of a resource which
+// waits for the dependency of the given kind and name.
+func SetDependencyNotReady(conditions *[]Condition, generation int64, kind, name string) {
+	message := dependencyMessage(kind, name)
+	SetCondition(conditions, Condition{Type: ConditionDependenciesReady, Status: ConditionFalse, ObservedGeneration: generation, Reason: ReasonDependencyNotReady, Message: message})
+	SetCondition(conditions, Condition{Type: ConditionReady, Status: ConditionFalse, ObservedGeneration: generation, Reason: ReasonWaitingForDependencies, Message: message})
+	SetCondition(conditions, Condition{Type: ConditionProgressing, Status: ConditionTrue, ObservedGeneration: generation, Reason: ReasonWaitingForDependencies, Message: message})
+}
+
+// WaitForDependency sets conditions of the object with SetDependencyNotReady
+// and updates status of the object. The DependencyNotReady event is recorded
+// when the object starts waiting for the dependency. The recorder may be nil.
+func WaitForDependency(c client.Client, recorder record.EventRecorder, object runtime.Object, conditions *[]Condition, kind, name string) error {
+	accessor, err := meta.Accessor(object)
+	if err != nil {
+		return err
+	}
+	previous := FindCondition(*conditions, ConditionDependenciesReady)
+	waiting := previous != nil && previous.Status == ConditionFalse && previous.Message == dependencyMessage(kind, name)
+	SetDependencyNotReady(conditions, accessor.GetGeneration(), kind, name)
+	if recorder != nil && !waiting {
+		recorder.Eventf(object, corev1.EventTypeNormal, ReasonDependencyNotReady, "Waiting for %s %s", kind, name)
+	}
+	return c.Status().Update(context.TODO(), object)
+}
+
+// SetReplicasConditions sets conditions of a resource which runs the desired number
+// of replicas. It is called once dependencies of the resource are ready, so it also
+// sets DependenciesReady. Pods are checked for containers which fail to start.
+func SetReplicasConditions(conditions *[]Condition, generation int64, desired, ready int32, pods []corev1.Pod) {
+	SetCondition(conditions, Condition{Type: ConditionDependenciesReady, Status: ConditionTrue, ObservedGeneration: generation, Reason: ReasonDependenciesReady})
+	message := fmt.Sprintf("%d of %d replicas ready", ready, desired)
+	failureReason, failureMessage := podsFailure(pods)
+	switch {
+	case ready >= desired:
+		SetCondition(conditions, Condition{Type: ConditionReady, Status: ConditionTrue, ObservedGeneration: generation, Reason: ReasonReplicasReady, Message: message})
+		SetCondition(conditions, Condition{Type: ConditionProgressing, Status: ConditionFalse, ObservedGeneration: generation, Reason: ReasonReplicasReady, Message: message})
+	case failureReason != "":
+		SetCondition(conditions, Condition{Type: ConditionReady, Status: ConditionFalse, ObservedGeneration: generation, Reason: failureReason, Message: message})
+		SetCondition(conditions, Condition{Type: ConditionProgressing, Status: ConditionFalse, ObservedGeneration: generation, Reason: failureReason, Message: failureMessage})
+	default:
+		SetCondition(conditions, Condition{Type: ConditionReady, Status: ConditionFalse, ObservedGeneration: generation, Reason: ReasonReplicasNotReady, Message: message})
+		SetCondition(conditions, Condition{Type: ConditionProgressing, Status: ConditionTrue, ObservedGeneration: generation, Reason: ReasonReplicasNotReady, Message: message})
+	}
+	if failureReason != "" {
+		SetCondition(conditions, Condition{Type: ConditionDegraded, Status: ConditionTrue, ObservedGeneration: generation, Reason: failureReason, Message: failureMessage})
+	} else {
+		SetCondition(conditions, Condition{Type: ConditionDegraded, Status: ConditionFalse, ObservedGeneration: generation, Reason: ReasonAsExpected})
+	}
+}
+
+// SetActiveConditions sets conditions of a resource which does not run pods on its own,
+// e.g. a resource which is active when resources created for it are active.
+func SetActiveConditions(conditions *[]Condition, generation int64, active bool, reason, message string) {
+	SetCondition(conditions, Condition{Type: ConditionDependenciesReady, Status: ConditionTrue, ObservedGeneration: generation, Reason: ReasonDependenciesReady})
+	SetCondition(conditions, Condition{Type: ConditionDegraded, Status: ConditionFalse, ObservedGeneration: generation, Reason: ReasonAsExpected})
+	if active {
+		SetCondition(conditions, Condition{Type: ConditionReady, Status: ConditionTrue, ObservedGeneration: generation, Reason: reason, Message: message})
+		SetCondition(conditions, Condition{Type: ConditionProgressing, Status: ConditionFalse, ObservedGeneration: generation, Reason: reason, Message: message})
+	} else {
+		SetCondition(conditions, Condition{Type: ConditionReady, Status: ConditionFalse, ObservedGeneration: generation, Reason: reason, Message: message})
+		SetCondition(conditions, Condition{Type: ConditionProgressing, Status: ConditionTrue, ObservedGeneration: generation, Reason: reason, Message: message})
+	}
+}
+
+// SetWorkloadConditions lists pods matching the selector and
+// sets conditions of the resource with SetReplicasConditions.
+func SetWorkloadConditions(c client.Client, conditions *[]Condition, generation int64, namespace string, selector *metav1.LabelSelector, desired, ready int32) error {
+	var pods []corev1.Pod
+	if selector != nil {
+		podList := &corev1.PodList{}
+		if err := c.List(context.TODO(), podList, client.InNamespace(namespace), client.MatchingLabels(selector.MatchLabels)); err != nil {
+			return err
+		}
+		pods = podList.Items
+	}
+	SetReplicasConditions(conditions, generation, desired, ready, pods)
+	return nil
+}
+
+func dependencyMessage(kind, name string) string {
+	return fmt.Sprintf("waiting for %s %s", kind, name)
+}
+
+func podsFailure(pods []corev1.Pod) (reason, message string) {
+	for _, pod := range pods {
+		statuses := append(append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+		for _, status := range statuses {
+			if waiting := status.State.Waiting; waiting != nil && podFailureReasons[waiting.Reason] {
+				return waiting.Reason, fmt.Sprintf("container %s of pod %s: %s", status.Name, pod.Name, waiting.Message)
+			}
+		}
+	}
+	return "", ""
+}
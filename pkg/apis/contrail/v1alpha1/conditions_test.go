@@ -0,0 +1,155 @@
+package v1alpha1_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	contrail "github.com/Juniper/contrail-operator/pkg/apis/contrail/v1alpha1"
+)
+
+func TestSetCondition(t *testing.T) {
+	past := meta.NewTime(time.Now().Add(-time.Hour).Truncate(time.Second))
+
+	t.Run("should keep transition time when status does not change", func(t *testing.T) {
+		conditions := []contrail.Condition{{Type: contrail.ConditionReady, Status: contrail.ConditionFalse, LastTransitionTime: past, Reason: "A"}}
+		// when
+		contrail.SetCondition(&conditions, contrail.Condition{Type: contrail.ConditionReady, Status: contrail.ConditionFalse, Reason: "B"})
+		// then
+		require.Len(t, conditions, 1)
+		assert.Equal(t, past, conditions[0].LastTransitionTime)
+		assert.Equal(t, "B", conditions[0].Reason)
+	})
+
+	t.Run("should change transition time when status changes", func(t *testing.T) {
+		conditions := []contrail.Condition{{Type: contrail.ConditionReady, Status: contrail.ConditionFalse, LastTransitionTime: past}}
+		// when
+		contrail.SetCondition(&conditions, contrail.Condition{Type: contrail.ConditionReady, Status: contrail.ConditionTrue})
+		// then
+		require.Len(t, conditions, 1)
+		assert.True(t, conditions[0].LastTransitionTime.After(past.Time))
+		assert.True(t, contrail.IsConditionTrue(conditions, contrail.ConditionReady))
+	})
+
+	t.Run("should add condition of a new type", func(t *testing.T) {
+		conditions := []contrail.Condition{{Type: contrail.ConditionReady, Status: contrail.ConditionFalse}}
+		// when
+		contrail.SetCondition(&conditions, contrail.Condition{Type: contrail.ConditionDegraded, Status: contrail.ConditionFalse})
+		// then
+		assert.Len(t, conditions, 2)
+		assert.NotNil(t, contrail.FindCondition(conditions, contrail.ConditionDegraded))
+		assert.Nil(t, contrail.FindCondition(conditions, contrail.ConditionProgressing))
+	})
+}
+
+func TestSetReplicasConditions(t *testing.T) {
+	tests := []struct {
+		name                string
+		desired, ready      int32
+		pods                []core.Pod
+		expectedReady       contrail.ConditionStatus
+		expectedProgressing contrail.ConditionStatus
+		expectedDegraded    contrail.ConditionStatus
+		expectedReason      string
+	}{
+		{
+			name:    "all replicas ready",
+			desired: 3, ready: 3,
+			expectedReady: contrail.ConditionTrue, expectedProgressing: contrail.ConditionFalse, expectedDegraded: contrail.ConditionFalse,
+			expectedReason: contrail.ReasonReplicasReady,
+		},
+		{
+			name:    "replicas are starting",
+			desired: 3, ready: 1,
+			pods:          []core.Pod{newPod("pod-1", core.ContainerStateWaiting{Reason: "ContainerCreating"})},
+			expectedReady: contrail.ConditionFalse, expectedProgressing: contrail.ConditionTrue, expectedDegraded: contrail.ConditionFalse,
+			expectedReason: contrail.ReasonReplicasNotReady,
+		},
+		{
+			name:    "replica is crash looping",
+			desired: 3, ready: 2,
+			pods:          []core.Pod{newPod("pod-1", core.ContainerStateWaiting{Reason: "CrashLoopBackOff", Message: "back-off"})},
+			expectedReady: contrail.ConditionFalse, expectedProgressing: contrail.ConditionFalse, expectedDegraded: contrail.ConditionTrue,
+			expectedReason: "CrashLoopBackOff",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var conditions []contrail.Condition
+			// when
+			contrail.SetReplicasConditions(&conditions, 4, test.desired, test.ready, test.pods)
+			// then
+			ready := contrail.FindCondition(conditions, contrail.ConditionReady)
+			require.NotNil(t, ready)
+			assert.Equal(t, test.expectedReady, ready.Status)
+			assert.Equal(t, test.expectedReason, ready.Reason)
+			assert.Equal(t, int64(4), ready.ObservedGeneration)
+			assert.Equal(t, test.expectedProgressing, contrail.FindCondition(conditions, contrail.ConditionProgressing).Status)
+			assert.Equal(t, test.expectedDegraded, contrail.FindCondition(conditions, contrail.ConditionDegraded).Status)
+			assert.True(t, contrail.IsConditionTrue(conditions, contrail.ConditionDependenciesReady))
+		})
+	}
+}
+
+func TestSetDependencyNotReady(t *testing.T) {
+	var conditions []contrail.Condition
+	contrail.SetReplicasConditions(&conditions, 1, 1, 1, nil)
+	// when
+	contrail.SetDependencyNotReady(&conditions, 2, "Zookeeper", "zookeeper1")
+	// then
+	dependencies := contrail.FindCondition(conditions, contrail.ConditionDependenciesReady)
+	require.NotNil(t, dependencies)
+	assert.Equal(t, contrail.ConditionFalse, dependencies.Status)
+	assert.Equal(t, contrail.ReasonDependencyNotReady, dependencies.Reason)
+	assert.Equal(t, "waiting for Zookeeper zookeeper1", dependencies.Message)
+	assert.False(t, contrail.IsConditionTrue(conditions, contrail.ConditionReady))
+	assert.True(t, contrail.IsConditionTrue(conditions, contrail.ConditionProgressing))
+	assert.False(t, contrail.IsConditionTrue(conditions, contrail.ConditionDegraded))
+}
+
+func TestWaitForDependency(t *testing.T) {
+	scheme, err := contrail.SchemeBuilder.Build()
+	require.NoError(t, err)
+	config := &contrail.Config{ObjectMeta: meta.ObjectMeta{Name: "config1", Namespace: "default"}}
+	cl := fake.NewFakeClientWithScheme(scheme, config)
+	recorder := record.NewFakeRecorder(10)
+	// when
+	require.NoError(t, contrail.WaitForDependency(cl, recorder, config, &config.Status.Conditions, "Cassandra", "cassandra1"))
+	require.NoError(t, contrail.WaitForDependency(cl, recorder, config, &config.Status.Conditions, "Cassandra", "cassandra1"))
+	require.NoError(t, contrail.WaitForDependency(cl, recorder, config, &config.Status.Conditions, "Zookeeper", "zookeeper1"))
+	// then
+	require.Len(t, recorder.Events, 2)
+	assert.Equal(t, "Normal DependencyNotReady Waiting for Cassandra cassandra1", <-recorder.Events)
+	assert.Equal(t, "Normal DependencyNotReady Waiting for Zookeeper zookeeper1", <-recorder.Events)
+	assert.False(t, contrail.IsConditionTrue(config.Status.Conditions, contrail.ConditionDependenciesReady))
+}
+
+func TestInstanceNameOfCluster(t *testing.T) {
+	scheme, err := contrail.SchemeBuilder.Build()
+	require.NoError(t, err)
+	clusterLabels := map[string]string{"contrail_cluster": "cluster1"}
+	cl := fake.NewFakeClientWithScheme(scheme,
+		&contrail.Rabbitmq{ObjectMeta: meta.ObjectMeta{Name: "rabbitmq1", Namespace: "default", Labels: clusterLabels}},
+		&contrail.Config{ObjectMeta: meta.ObjectMeta{Name: "config1", Namespace: "default", Labels: clusterLabels}},
+	)
+	// then
+	assert.Equal(t, "rabbitmq1", (&contrail.Rabbitmq{}).InstanceName("cluster1", "default", cl))
+	assert.Equal(t, "config1", (&contrail.Config{}).InstanceName("cluster1", "default", cl))
+	assert.Equal(t, "contrail_cluster=cluster2", (&contrail.Rabbitmq{}).InstanceName("cluster2", "default", cl))
+	assert.Equal(t, "contrail_cluster=", (&contrail.Config{}).InstanceName("", "default", cl))
+}
+
+func newPod(name string, waiting core.ContainerStateWaiting) core.Pod {
+	return core.Pod{
+		ObjectMeta: meta.ObjectMeta{Name: name},
+		Status: core.PodStatus{
+			ContainerStatuses: []core.ContainerStatus{{Name: "service", State: core.ContainerState{Waiting: &waiting}}},
+		},
+	}
+}
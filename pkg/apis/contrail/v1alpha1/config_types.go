@@ -114,6 +114,10 @@ type ConfigStatus struct {
 	ConfigChanged *bool                             `json:"configChanged,omitempty"`
 	ServiceStatus map[string]ConfigServiceStatusMap `json:"serviceStatus,omitempty"`
 	Endpoint      string                            `json:"endpoint,omitempty"`
+	// +optional
+	// +patchMergeKey=type
+	// +patchStrategy=merge
+	Conditions []Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
 }
 
 type ConfigServiceStatusMap map[string]ConfigServiceStatus
@@ -372,6 +376,7 @@ func (c *Config) InstanceConfiguration(request reconcile.Request,
 		configtemplates.ConfigKeystoneAuthConf.Execute(&configKeystoneAuthConfBuffer, struct {
 			AdminUsername             string
 			AdminPassword             string
+			AdminProject              string
 			KeystoneAddress           string
 			KeystonePort              int
 			KeystoneAuthProtocol      string
@@ -382,6 +387,7 @@ func (c *Config) InstanceConfiguration(request reconcile.Request,
 		}{
 			AdminUsername:             configAuth.AdminUsername,
 			AdminPassword:             configAuth.AdminPassword,
+			AdminProject:              configAuth.AdminProject,
 			KeystoneAddress:           configAuth.Address,
 			KeystonePort:              configAuth.Port,
 			KeystoneAuthProtocol:      configAuth.AuthProtocol,
@@ -596,6 +602,7 @@ func (c *Config) InstanceConfiguration(request reconcile.Request,
 type ConfigAuthParameters struct {
 	AdminUsername     string
 	AdminPassword     string
+	AdminProject      string
 	Address           string
 	Port              int
 	Region            string
@@ -606,7 +613,8 @@ type ConfigAuthParameters struct {
 
 func (c *Config) AuthParameters(client client.Client) (*ConfigAuthParameters, error) {
 	w := &ConfigAuthParameters{
-		AdminUsername: "admin",
+		AdminUsername: KeystoneAuthAdminUser,
+		AdminProject:  KeystoneAuthAdminTenant,
 	}
 	adminPasswordSecretName := c.Spec.ServiceConfiguration.KeystoneSecretName
 	adminPasswordSecret := &corev1.Secret{}
@@ -624,11 +632,14 @@ func (c *Config) AuthParameters(client client.Client) (*ConfigAuthParameters, er
 		if keystone.Status.Endpoint == "" {
 			return nil, fmt.Errorf("%q Status.Endpoint empty", keystoneInstanceName)
 		}
-		w.Port = keystone.Spec.ServiceConfiguration.ListenPort
-		w.Region = keystone.Spec.ServiceConfiguration.Region
-		w.AuthProtocol = keystone.Spec.ServiceConfiguration.AuthProtocol
-		w.UserDomainName = keystone.Spec.ServiceConfiguration.UserDomainName
-		w.ProjectDomainName = keystone.Spec.ServiceConfiguration.ProjectDomainName
+		keystoneConfiguration := keystone.ConfigurationParameters()
+		w.AdminUsername = keystoneConfiguration.AdminUsername
+		w.AdminProject = keystoneConfiguration.AdminProject
+		w.Port = keystoneConfiguration.ListenPort
+		w.Region = keystoneConfiguration.Region
+		w.AuthProtocol = keystoneConfiguration.AuthProtocol
+		w.UserDomainName = keystoneConfiguration.UserDomainName
+		w.ProjectDomainName = keystoneConfiguration.ProjectDomainName
 		w.Address = keystone.Status.Endpoint
 	}
 
@@ -704,14 +715,19 @@ func (c *Config) SetInstanceActive(client client.Client, activeStatus *bool, sts
 	}
 
 	*activeStatus = false
+	replicas := int32(1)
 	acceptableReadyReplicaCnt := int32(1)
 	if sts.Spec.Replicas != nil {
+		replicas = *sts.Spec.Replicas
 		acceptableReadyReplicaCnt = *sts.Spec.Replicas/2 + 1
 	}
 
 	if sts.Status.ReadyReplicas >= acceptableReadyReplicaCnt {
 		*activeStatus = true
 	}
+	if err := SetWorkloadConditions(client, &c.Status.Conditions, c.Generation, sts.Namespace, sts.Spec.Selector, replicas, sts.Status.ReadyReplicas); err != nil {
+		return err
+	}
 
 	if err := client.Status().Update(context.TODO(), c); err != nil {
 		return err
@@ -777,6 +793,18 @@ func (c *Config) ManageNodeStatus(podNameIPMap map[string]string, client client.
 	return nil
 }
 
+// InstanceName returns the name of the Config of the cluster checked by IsActive,
+// or the label selector of the cluster when there is no such Config.
+func (c *Config) InstanceName(name string, namespace string, myclient client.Client) string {
+	labelSelector := labels.SelectorFromSet(map[string]string{"contrail_cluster": name})
+	listOps := &client.ListOptions{Namespace: namespace, LabelSelector: labelSelector}
+	list := &ConfigList{}
+	if err := myclient.List(context.TODO(), list, listOps); err != nil || len(list.Items) == 0 {
+		return labelSelector.String()
+	}
+	return list.Items[0].Name
+}
+
 // IsActive returns true if instance is active
 func (c *Config) IsActive(name string, namespace string, myclient client.Client) bool {
 	labelSelector := labels.SelectorFromSet(map[string]string{"contrail_cluster": name})
@@ -156,6 +156,9 @@ func (c *ContrailCNI) SetInstanceActive(client client.Client, activeStatus *bool
 	}
 
 	*activeStatus = active
+	if err := SetWorkloadConditions(client, &c.Status.Conditions, c.Generation, job.Namespace, job.Spec.Selector, *job.Spec.Completions, job.Status.Succeeded); err != nil {
+		return err
+	}
 	return client.Status().Update(context.TODO(), c)
 }
 
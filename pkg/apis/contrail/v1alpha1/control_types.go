@@ -69,6 +69,10 @@ type ControlStatus struct {
 	Nodes         map[string]string               `json:"nodes,omitempty"`
 	Ports         ControlStatusPorts              `json:"ports,omitempty"`
 	ServiceStatus map[string]ControlServiceStatus `json:"serviceStatus,omitempty"`
+	// +optional
+	// +patchMergeKey=type
+	// +patchStrategy=merge
+	Conditions []Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
 }
 
 // +k8s:openapi-gen=true
@@ -423,7 +427,7 @@ func (c *Control) PodsCertSubjects(podList *corev1.PodList) []certificates.Certi
 
 // SetInstanceActive sets the Cassandra instance to active.
 func (c *Control) SetInstanceActive(client client.Client, activeStatus *bool, sts *appsv1.StatefulSet, request reconcile.Request) error {
-	return SetInstanceActive(client, activeStatus, sts, request, c)
+	return SetInstanceActive(client, activeStatus, &c.Status.Conditions, sts, request, c)
 }
 
 func (c *Control) ManageNodeStatus(podNameIPMap map[string]string,
@@ -9,11 +9,66 @@ type FernetKeyManagerSpec struct {
 	TokenExpiration         int `json:"tokenExpiration"`
 	TokenAllowExpiredWindow int `json:"tokenAllowExpiredWindow"`
 	RotationInterval        int `json:"rotationFrequency"`
+	// RotationRequest rotates keys once when it is changed, e.g. to the current time,
+	// without waiting for the rotation interval to pass.
+	// +optional
+	RotationRequest string `json:"rotationRequest,omitempty"`
+	// CredentialKeys enables rotation of Keystone credential keys.
+	// +optional
+	CredentialKeys *CredentialKeysRotation `json:"credentialKeys,omitempty"`
+}
+
+// CredentialKeysRotation defines rotation of Keystone credential keys. After the staged
+// key is promoted, credentials are re-encrypted with the new primary key by a Job running
+// keystone-manage credential_migrate and only then previous keys are dropped.
+type CredentialKeysRotation struct {
+	// SecretName is the name of the secret with the credential keys repository.
+	SecretName string `json:"secretName"`
+	// RotationInterval is the number of seconds between rotations.
+	RotationInterval int `json:"rotationInterval"`
+	// Image of the migration Job.
+	Image string `json:"image"`
+	// Command of the migration Job, the entrypoint of the image by default.
+	// +optional
+	Command []string `json:"command,omitempty"`
+	// ConfigMapName is the name of the ConfigMap with Kolla configuration of the
+	// migration Job, which is mounted at /var/lib/kolla/config_files.
+	ConfigMapName string `json:"configMapName"`
 }
 
 // FernetKeyManagerStatus defines the observed state of FernetKeyManager
 type FernetKeyManagerStatus struct {
 	SecretName string `json:"secretName"`
+	// LastRotationTime is the time keys were last rotated.
+	// +optional
+	LastRotationTime *metav1.Time `json:"lastRotationTime,omitempty"`
+	// PrimaryKeyIndex is the index of the current primary key.
+	// +optional
+	PrimaryKeyIndex int `json:"primaryKeyIndex,omitempty"`
+	// ObservedRotationRequest is the RotationRequest keys were last rotated for.
+	// +optional
+	ObservedRotationRequest string `json:"observedRotationRequest,omitempty"`
+	// CredentialKeys is the state of rotation of credential keys.
+	// +optional
+	CredentialKeys *CredentialKeysStatus `json:"credentialKeys,omitempty"`
+	// +optional
+	// +patchMergeKey=type
+	// +patchStrategy=merge
+	Conditions []Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+}
+
+// CredentialKeysStatus is the state of rotation of credential keys.
+type CredentialKeysStatus struct {
+	// LastRotationTime is the time keys were last rotated.
+	// +optional
+	LastRotationTime *metav1.Time `json:"lastRotationTime,omitempty"`
+	// PrimaryKeyIndex is the index of the current primary key.
+	// +optional
+	PrimaryKeyIndex int `json:"primaryKeyIndex,omitempty"`
+	// Migrating is set after rotation until credentials are re-encrypted with the
+	// primary key and previous keys are dropped.
+	// +optional
+	Migrating bool `json:"migrating,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
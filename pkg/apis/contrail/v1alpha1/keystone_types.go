@@ -21,7 +21,10 @@ type KeystoneSpec struct {
 // KeystoneConfiguration is the Spec for the keystone API.
 // +k8s:openapi-gen=true
 type KeystoneConfiguration struct {
-	MemcachedInstance  string       `json:"memcachedInstance,omitempty"`
+	MemcachedInstance string `json:"memcachedInstance,omitempty"`
+	// MemcachedInstances are additional Memcached instances, which form a pool of
+	// token caches shared by all keystone replicas together with MemcachedInstance.
+	MemcachedInstances []string     `json:"memcachedInstances,omitempty"`
 	ListenPort         int          `json:"listenPort,omitempty"`
 	PostgresInstance   string       `json:"postgresInstance,omitempty"`
 	Containers         []*Container `json:"containers,omitempty"`
@@ -42,6 +45,73 @@ type KeystoneConfiguration struct {
 	// Default is 60 sec.
 	// +kubebuilder:validation:Minimum=1
 	ExternalAddressRetrySec int `json:"externalAddressRetrySec,omitempty"`
+	// AdminUsername is the name of the admin user, admin by default. Its password
+	// is read from the KeystoneSecretName secret.
+	AdminUsername string `json:"adminUsername,omitempty"`
+	// AdminProject is the project of the admin user, admin by default.
+	AdminProject string `json:"adminProject,omitempty"`
+	// CABundleConfigMapName is the name of the ConfigMap with PEM encoded CA certificates
+	// of the external keystone under the ca-bundle.crt key. They are trusted by the
+	// operator and by pods in addition to the CA of the operator.
+	CABundleConfigMapName string `json:"caBundleConfigMapName,omitempty"`
+	// LDAPDomains configures identity domains backed by LDAP or Active Directory.
+	// Domains are created by the operator and their users and groups are read
+	// from the directory, while projects and roles stay in the keystone database.
+	LDAPDomains []KeystoneLDAPDomain `json:"ldapDomains,omitempty"`
+}
+
+// KeystoneLDAPDomain is the configuration of a single LDAP backed identity domain.
+// +k8s:openapi-gen=true
+type KeystoneLDAPDomain struct {
+	// Name of the keystone domain. Its configuration is rendered into
+	// domains/keystone.<name>.conf.
+	Name string `json:"name"`
+	// URL of the directory server, e.g. ldaps://ad.example.com:636.
+	URL string `json:"url"`
+	// BindSecretName is the name of the Secret with the bind DN under the user
+	// key and its password under the password key.
+	BindSecretName string `json:"bindSecretName"`
+	// UseStartTLS upgrades ldap:// connections to TLS.
+	UseStartTLS bool `json:"useStartTLS,omitempty"`
+	// CABundleConfigMapName is the name of the ConfigMap with PEM encoded CA
+	// certificates of the directory server under the ca-bundle.crt key.
+	CABundleConfigMapName string                    `json:"caBundleConfigMapName,omitempty"`
+	Users                 KeystoneLDAPUsers         `json:"users"`
+	Groups                *KeystoneLDAPGroups       `json:"groups,omitempty"`
+	GroupRoleMappings     []KeystoneLDAPRoleMapping `json:"groupRoleMappings,omitempty"`
+}
+
+// KeystoneLDAPUsers describes where and how users are stored in the directory.
+// Unset attributes use the keystone defaults.
+// +k8s:openapi-gen=true
+type KeystoneLDAPUsers struct {
+	TreeDN        string `json:"treeDN"`
+	Filter        string `json:"filter,omitempty"`
+	ObjectClass   string `json:"objectClass,omitempty"`
+	IDAttribute   string `json:"idAttribute,omitempty"`
+	NameAttribute string `json:"nameAttribute,omitempty"`
+	MailAttribute string `json:"mailAttribute,omitempty"`
+}
+
+// KeystoneLDAPGroups describes where and how groups are stored in the directory.
+// Unset attributes use the keystone defaults.
+// +k8s:openapi-gen=true
+type KeystoneLDAPGroups struct {
+	TreeDN          string `json:"treeDN"`
+	Filter          string `json:"filter,omitempty"`
+	ObjectClass     string `json:"objectClass,omitempty"`
+	IDAttribute     string `json:"idAttribute,omitempty"`
+	NameAttribute   string `json:"nameAttribute,omitempty"`
+	MemberAttribute string `json:"memberAttribute,omitempty"`
+}
+
+// KeystoneLDAPRoleMapping grants a role on a project to members of a directory group.
+// +k8s:openapi-gen=true
+type KeystoneLDAPRoleMapping struct {
+	Group string `json:"group"`
+	Role  string `json:"role"`
+	// Project is the name of the project in the admin project domain.
+	Project string `json:"project"`
 }
 
 // KeystoneStatus defines the observed state of Keystone
@@ -57,6 +127,27 @@ type KeystoneStatus struct {
 	// Set to true when keystone service is not
 	// directly managed by controller.
 	External bool `json:"external,omitempty"`
+	// ReadyReplicas is the number of keystone pods which issue and validate tokens.
+	ReadyReplicas int32 `json:"readyReplicas,omitempty"`
+	// Replicas reports health of every keystone pod.
+	Replicas []KeystoneReplicaStatus `json:"replicas,omitempty"`
+	// +optional
+	// +patchMergeKey=type
+	// +patchStrategy=merge
+	Conditions []Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+}
+
+// KeystoneConditionIdentityRegistered is true when the identity service endpoints
+// and LDAP domains of the Keystone are registered through its API.
+const KeystoneConditionIdentityRegistered ConditionType = "IdentityRegistered"
+
+// KeystoneReplicaStatus is the health of a single keystone pod.
+// +k8s:openapi-gen=true
+type KeystoneReplicaStatus struct {
+	Name string `json:"name"`
+	IP   string `json:"ip,omitempty"`
+	// Ready is true when the pod issues and validates tokens.
+	Ready bool `json:"ready"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -136,6 +227,29 @@ func (k *Keystone) IsActive(name string, namespace string, client client.Client)
 	return instance.Status.Active
 }
 
+// IsAvailable returns true if keystone serves tokens. Keystone deployed by the operator
+// stays available while at least one of its replicas is ready after it became active,
+// so that clients do not wait for all replicas during rolling updates.
+func (k *Keystone) IsAvailable() bool {
+	if k.Status.Active {
+		return true
+	}
+	return !k.Status.External && k.Status.Port != 0 && k.Status.ReadyReplicas > 0
+}
+
+// MemcachedInstanceNames returns names of all Memcached instances used by keystone.
+func (k *Keystone) MemcachedInstanceNames() []string {
+	var names []string
+	seen := map[string]bool{}
+	for _, name := range append([]string{k.Spec.ServiceConfiguration.MemcachedInstance}, k.Spec.ServiceConfiguration.MemcachedInstances...) {
+		if name != "" && !seen[name] {
+			names = append(names, name)
+			seen[name] = true
+		}
+	}
+	return names
+}
+
 // ConfigurationParameters sets the default for the configuration parameters.
 func (k *Keystone) ConfigurationParameters() KeystoneConfiguration {
 	keystoneConfiguration := KeystoneConfiguration{}
@@ -179,5 +293,17 @@ func (k *Keystone) ConfigurationParameters() KeystoneConfiguration {
 	} else {
 		keystoneConfiguration.ExternalAddressRetrySec = k.Spec.ServiceConfiguration.ExternalAddressRetrySec
 	}
+	if k.Spec.ServiceConfiguration.AdminUsername == "" {
+		keystoneConfiguration.AdminUsername = KeystoneAuthAdminUser
+	} else {
+		keystoneConfiguration.AdminUsername = k.Spec.ServiceConfiguration.AdminUsername
+	}
+	if k.Spec.ServiceConfiguration.AdminProject == "" {
+		keystoneConfiguration.AdminProject = KeystoneAuthAdminTenant
+	} else {
+		keystoneConfiguration.AdminProject = k.Spec.ServiceConfiguration.AdminProject
+	}
+	keystoneConfiguration.ExternalAddress = k.Spec.ServiceConfiguration.ExternalAddress
+	keystoneConfiguration.CABundleConfigMapName = k.Spec.ServiceConfiguration.CABundleConfigMapName
 	return keystoneConfiguration
 }
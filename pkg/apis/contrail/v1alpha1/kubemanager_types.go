@@ -50,6 +50,10 @@ type KubemanagerStatus struct {
 	Active        *bool             `json:"active,omitempty"`
 	Nodes         map[string]string `json:"nodes,omitempty"`
 	ConfigChanged *bool             `json:"configChanged,omitempty"`
+	// +optional
+	// +patchMergeKey=type
+	// +patchStrategy=merge
+	Conditions []Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
 }
 
 // KubemanagerServiceConfiguration is the Spec for the kubemanagers API.
@@ -374,7 +378,7 @@ func (c *Kubemanager) PodsCertSubjects(podList *corev1.PodList) []certificates.C
 
 // SetInstanceActive sets the Kubemanager instance to active.
 func (c *Kubemanager) SetInstanceActive(client client.Client, activeStatus *bool, sts *appsv1.StatefulSet, request reconcile.Request) error {
-	return SetInstanceActive(client, activeStatus, sts, request, c)
+	return SetInstanceActive(client, activeStatus, &c.Status.Conditions, sts, request, c)
 }
 
 func (c *Kubemanager) ManageNodeStatus(podNameIPMap map[string]string, client client.Client) error {
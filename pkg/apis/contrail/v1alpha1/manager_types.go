@@ -2,6 +2,7 @@ package v1alpha1
 
 import (
 	"context"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -18,8 +19,69 @@ type ManagerSpec struct {
 	CommonConfiguration ManagerConfiguration `json:"commonConfiguration,omitempty"`
 	Services            Services             `json:"services,omitempty"`
 	KeystoneSecretName  string               `json:"keystoneSecretName,omitempty"`
+	// DataRetentionPolicy defines whether persistent data of services is kept
+	// (Retain) or wiped and deleted (Delete) when the Manager is deleted.
+	// Defaults to Retain.
+	// +kubebuilder:validation:Enum=Retain;Delete
+	// +optional
+	DataRetentionPolicy DataRetentionPolicy `json:"dataRetentionPolicy,omitempty"`
+	// Upgrade defines how changed images of services are rolled out.
+	// +optional
+	Upgrade *ManagerUpgrade `json:"upgrade,omitempty"`
+	// Containers overrides images of containers run by the Manager itself,
+	// i.e. the wipe container of jobs removing data of services.
+	// +optional
+	Containers []*Container `json:"containers,omitempty"`
+}
+
+// ManagerUpgradeStrategy defines how changed images of services are rolled out.
+type ManagerUpgradeStrategy string
+
+const (
+	// ManagerUpgradeParallel rolls out changed images of all services at once.
+	ManagerUpgradeParallel ManagerUpgradeStrategy = "Parallel"
+	// ManagerUpgradeOrdered rolls out changed images one service at a time in the
+	// order of data stores, config, control and vrouter, waiting for each service
+	// to be active and healthy before the next one is upgraded.
+	ManagerUpgradeOrdered ManagerUpgradeStrategy = "Ordered"
+)
+
+// ManagerUpgrade defines how changed images of services are rolled out.
+// +k8s:openapi-gen=true
+type ManagerUpgrade struct {
+	// Strategy of the upgrade. Defaults to Parallel.
+	// +kubebuilder:validation:Enum=Parallel;Ordered
+	// +optional
+	Strategy ManagerUpgradeStrategy `json:"strategy,omitempty"`
+	// Paused stops the ordered upgrade before its next step.
+	// +optional
+	Paused bool `json:"paused,omitempty"`
+	// StepTimeoutSeconds is how long a service may take to become active and
+	// healthy before the upgrade is paused. Defaults to 1800.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	StepTimeoutSeconds *int32 `json:"stepTimeoutSeconds,omitempty"`
+}
+
+// StepTimeout returns the timeout of a step of the ordered upgrade.
+func (u *ManagerUpgrade) StepTimeout() time.Duration {
+	if u.StepTimeoutSeconds == nil {
+		return 30 * time.Minute
+	}
+	return time.Duration(*u.StepTimeoutSeconds) * time.Second
 }
 
+// DataRetentionPolicy defines what happens with persistent data
+// of services when the Manager is deleted.
+type DataRetentionPolicy string
+
+const (
+	// DataRetentionPolicyRetain keeps persistent volumes of services.
+	DataRetentionPolicyRetain DataRetentionPolicy = "Retain"
+	// DataRetentionPolicyDelete wipes and deletes persistent volumes of services.
+	DataRetentionPolicyDelete DataRetentionPolicy = "Delete"
+)
+
 // Services defines the desired state of Services.
 // +k8s:openapi-gen=true
 type Services struct {
@@ -209,6 +271,10 @@ type ManagerConfiguration struct {
 	// If specified, the pod's tolerations.
 	// +optional
 	Tolerations []corev1.Toleration `json:"tolerations,omitempty" protobuf:"bytes,22,opt,name=tolerations"`
+	// CertificateKey selects private keys of the CA and of certificates issued
+	// for pods of services which do not select their own.
+	// +optional
+	CertificateKey *CertificateKey `json:"certificateKey,omitempty"`
 }
 
 // ManagerStatus defines the observed state of Manager.
@@ -235,38 +301,69 @@ type ManagerStatus struct {
 	Contrailmonitor  *ServiceStatus   `json:"contrailmonitor,omitempty"`
 	ContrailCNIs     []*ServiceStatus `json:"contrailCNIs,omitempty"`
 	Replicas         int32            `json:"replicas,omitempty"`
+	// Upgrade records steps of the ordered upgrade of services.
+	Upgrade *ManagerUpgradeStatus `json:"upgrade,omitempty"`
 	// +optional
 	// +patchMergeKey=type
 	// +patchStrategy=merge
-	Conditions []ManagerCondition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+	Conditions []Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
 }
 
-// ManagerConditionType is used to represent condition of manager.
-type ManagerConditionType string
+// ManagerUpgradePhase is the phase of the ordered upgrade of services.
+type ManagerUpgradePhase string
 
-// These are valid conditions of manager.
 const (
-	ManagerReady ManagerConditionType = "Ready"
+	// ManagerUpgrading means services are upgraded one at a time.
+	ManagerUpgrading ManagerUpgradePhase = "Upgrading"
+	// ManagerUpgradePaused means the upgrade was paused by the user or after
+	// a failed step. An upgrade paused after a failure resumes once the spec
+	// of the Manager changes, e.g. when images are fixed.
+	ManagerUpgradePaused ManagerUpgradePhase = "Paused"
+	// ManagerUpgradeCompleted means all services run their intended images.
+	ManagerUpgradeCompleted ManagerUpgradePhase = "Completed"
 )
 
-// ConditionStatus is used to indicate state of condition.
-type ConditionStatus string
+// ManagerUpgradeStepState is the state of upgrading a single service.
+type ManagerUpgradeStepState string
 
-// These are valid condition statuses. "ConditionTrue" means a resource is in the condition.
-// "ConditionFalse" means a resource is not in the condition.
 const (
-	ConditionTrue  ConditionStatus = "True"
-	ConditionFalse ConditionStatus = "False"
+	// UpgradeStepPending means the service waits for previous steps and keeps its images.
+	UpgradeStepPending ManagerUpgradeStepState = "Pending"
+	// UpgradeStepUpgrading means the service rolls out its intended images.
+	UpgradeStepUpgrading ManagerUpgradeStepState = "Upgrading"
+	// UpgradeStepCompleted means the service is active and healthy with its intended images.
+	UpgradeStepCompleted ManagerUpgradeStepState = "Completed"
+	// UpgradeStepFailed means the service did not become active and healthy.
+	UpgradeStepFailed ManagerUpgradeStepState = "Failed"
 )
 
-// ManagerCondition is used to represent cluster condition
-type ManagerCondition struct {
-	// Type of manager condition.
-	Type ManagerConditionType `json:"type"`
-	// Status of the condition, one of True or False.
-	Status ConditionStatus `json:"status"`
+// ManagerUpgradeStatus defines the observed state of the ordered upgrade.
+// +k8s:openapi-gen=true
+type ManagerUpgradeStatus struct {
+	Phase   ManagerUpgradePhase `json:"phase,omitempty"`
+	Message string              `json:"message,omitempty"`
+	// PausedGeneration is the generation of the Manager for which the
+	// upgrade was paused after a failed step.
+	PausedGeneration int64                `json:"pausedGeneration,omitempty"`
+	Steps            []ManagerUpgradeStep `json:"steps,omitempty"`
 }
 
+// ManagerUpgradeStep records upgrading of a single service.
+// +k8s:openapi-gen=true
+type ManagerUpgradeStep struct {
+	Kind           string                  `json:"kind"`
+	Name           string                  `json:"name"`
+	State          ManagerUpgradeStepState `json:"state"`
+	Message        string                  `json:"message,omitempty"`
+	StartTime      *metav1.Time            `json:"startTime,omitempty"`
+	CompletionTime *metav1.Time            `json:"completionTime,omitempty"`
+}
+
+// These are valid conditions of manager.
+const (
+	ManagerReady = ConditionReady
+)
+
 // CrdStatus tracks status of CRD.
 // +k8s:openapi-gen=true
 type CrdStatus struct {
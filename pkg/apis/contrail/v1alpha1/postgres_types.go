@@ -14,6 +14,55 @@ type PostgresConfiguration struct {
 	ReplicationPassSecretName string       `json:"replicationPassSecretName,omitempty"`
 	Containers                []*Container `json:"containers,omitempty"`
 	Storage                   Storage      `json:"storage,omitempty"`
+	// Backup schedules backups of the databases.
+	Backup *PostgresBackup `json:"backup,omitempty"`
+	// Restore bootstraps the cluster from a base backup. It is used only
+	// when the cluster is created.
+	Restore *PostgresRestore `json:"restore,omitempty"`
+}
+
+// PostgresBackup configures scheduled backups of the Postgres. Backups are
+// stored in the <name>-postgres-backup claim, which is kept when the Postgres
+// is deleted. Logical backups are made to dump/, physical ones to base/ and
+// the write-ahead log of the leader is streamed to wal/.
+// +k8s:openapi-gen=true
+type PostgresBackup struct {
+	// DumpSchedule is the cron schedule of logical backups.
+	DumpSchedule string `json:"dumpSchedule,omitempty"`
+	// BaseBackupSchedule is the cron schedule of physical backups. When it is
+	// set, the write-ahead log is archived for point-in-time recovery.
+	BaseBackupSchedule string                  `json:"baseBackupSchedule,omitempty"`
+	Retention          PostgresBackupRetention `json:"retention,omitempty"`
+	// Storage is the size of the claim and, for local storage, the path on the host.
+	Storage Storage `json:"storage,omitempty"`
+	// StorageClassName of the claim. Local volumes are created for the default
+	// local-storage class; they should be pinned to a single node with NodeSelector.
+	StorageClassName string            `json:"storageClassName,omitempty"`
+	NodeSelector     map[string]string `json:"nodeSelector,omitempty"`
+}
+
+// PostgresBackupRetention limits the number and age of kept backups.
+// By default 7 dumps and 3 base backups are kept.
+// +k8s:openapi-gen=true
+type PostgresBackupRetention struct {
+	Dumps       *int32 `json:"dumps,omitempty"`
+	BaseBackups *int32 `json:"baseBackups,omitempty"`
+	// MaxAgeDays removes older backups besides the latest one of each kind.
+	MaxAgeDays int32 `json:"maxAgeDays,omitempty"`
+}
+
+// PostgresRestore points to a base backup and, optionally, a point in time
+// the new cluster is recovered to with the archived write-ahead log.
+// +k8s:openapi-gen=true
+type PostgresRestore struct {
+	// BackupClaimName is the claim with backups, e.g. <postgres>-postgres-backup
+	// of the cluster the backups were made of.
+	BackupClaimName string `json:"backupClaimName"`
+	// BaseBackup is the name of the base backup directory, the latest one when empty.
+	BaseBackup string `json:"baseBackup,omitempty"`
+	// TargetTime is the recovery_target_time, e.g. "2020-11-04 10:30:00 UTC".
+	// The whole archive is replayed when it is empty.
+	TargetTime string `json:"targetTime,omitempty"`
 }
 
 // PostgresSpec defines the desired state of Postgres
@@ -66,6 +115,22 @@ func (p *Postgres) PodsCertSubjects(podList *corev1.PodList, serviceIP string) [
 // PostgresInstanceType is type unique name used for labels
 const PostgresInstanceType = "postgres"
 
+// DumpsToRetain returns the number of logical backups to keep.
+func (b *PostgresBackup) DumpsToRetain() int32 {
+	if b.Retention.Dumps == nil {
+		return 7
+	}
+	return *b.Retention.Dumps
+}
+
+// BaseBackupsToRetain returns the number of physical backups to keep.
+func (b *PostgresBackup) BaseBackupsToRetain() int32 {
+	if b.Retention.BaseBackups == nil {
+		return 3
+	}
+	return *b.Retention.BaseBackups
+}
+
 func init() {
 	SchemeBuilder.Register(&Postgres{}, &PostgresList{})
 }
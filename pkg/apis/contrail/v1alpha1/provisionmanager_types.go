@@ -88,6 +88,10 @@ type ProvisionManagerStatus struct {
 	Active              *bool             `json:"active,omitempty"`
 	Nodes               map[string]string `json:"nodes,omitempty"`
 	GlobalConfiguration map[string]string `json:"globalConfiguration,omitempty"`
+	// +optional
+	// +patchMergeKey=type
+	// +patchStrategy=merge
+	Conditions []Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -247,8 +251,8 @@ func (c *ProvisionManager) GetGlobalVrouterConfig() (*GlobalVrouterConfiguration
 
 func (c *ProvisionManager) GetAuthParameters(client client.Client, podIP string) (*KeystoneAuthParameters, error) {
 	k := &KeystoneAuthParameters{
-		AdminUsername: "admin",
-		TenantName:    "admin",
+		AdminUsername: KeystoneAuthAdminUser,
+		TenantName:    KeystoneAuthAdminTenant,
 		Encryption: Encryption{
 			CA:       certificates.SignerCAFilepath,
 			Key:      "/etc/certificates/server-key-" + podIP + ".pem",
@@ -271,7 +275,10 @@ func (c *ProvisionManager) GetAuthParameters(client client.Client, podIP string)
 	if keystone.Status.Endpoint == "" {
 		return nil, fmt.Errorf("%q Status.Endpoint empty", keystoneInstanceName)
 	}
-	k.AuthUrl = fmt.Sprintf("%s://%s:%d/v3/auth", keystone.Spec.ServiceConfiguration.AuthProtocol, keystone.Status.Endpoint, keystone.Spec.ServiceConfiguration.ListenPort)
+	keystoneConfiguration := keystone.ConfigurationParameters()
+	k.AdminUsername = keystoneConfiguration.AdminUsername
+	k.TenantName = keystoneConfiguration.AdminProject
+	k.AuthUrl = fmt.Sprintf("%s://%s:%d/v3/auth", keystoneConfiguration.AuthProtocol, keystone.Status.Endpoint, keystoneConfiguration.ListenPort)
 
 	return k, nil
 }
@@ -288,5 +295,5 @@ func (c *ProvisionManager) SetPodsToReady(podIPList *corev1.PodList, client clie
 
 // SetInstanceActive sets the ProvisionManager instance to active.
 func (c *ProvisionManager) SetInstanceActive(client client.Client, activeStatus *bool, sts *appsv1.StatefulSet, request reconcile.Request) error {
-	return SetInstanceActive(client, activeStatus, sts, request, c)
+	return SetInstanceActive(client, activeStatus, &c.Status.Conditions, sts, request, c)
 }
@@ -69,6 +69,10 @@ type RabbitmqStatus struct {
 	Nodes  map[string]string   `json:"nodes,omitempty"`
 	Ports  RabbitmqStatusPorts `json:"ports,omitempty"`
 	Secret string              `json:"secret,omitempty"`
+	// +optional
+	// +patchMergeKey=type
+	// +patchStrategy=merge
+	Conditions []Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
 }
 
 type RabbitmqStatusPorts struct {
@@ -242,6 +246,18 @@ func (c *Rabbitmq) CreateSecret(secretName string,
 		c)
 }
 
+// InstanceName returns the name of the Rabbitmq of the cluster checked by IsActive,
+// or the label selector of the cluster when there is no such Rabbitmq.
+func (c *Rabbitmq) InstanceName(name string, namespace string, myclient client.Client) string {
+	labelSelector := labels.SelectorFromSet(map[string]string{"contrail_cluster": name})
+	listOps := &client.ListOptions{Namespace: namespace, LabelSelector: labelSelector}
+	rabbitmqList := &RabbitmqList{}
+	if err := myclient.List(context.TODO(), rabbitmqList, listOps); err != nil || len(rabbitmqList.Items) == 0 {
+		return labelSelector.String()
+	}
+	return rabbitmqList.Items[0].Name
+}
+
 // IsActive returns true if instance is active.
 func (c *Rabbitmq) IsActive(name string, namespace string, myclient client.Client) bool {
 	labelSelector := labels.SelectorFromSet(map[string]string{"contrail_cluster": name})
@@ -317,7 +333,7 @@ func (c *Rabbitmq) PodsCertSubjects(podList *corev1.PodList) []certificates.Cert
 
 // SetInstanceActive sets the Cassandra instance to active.
 func (c *Rabbitmq) SetInstanceActive(client client.Client, activeStatus *bool, sts *appsv1.StatefulSet, request reconcile.Request) error {
-	return SetInstanceActive(client, activeStatus, sts, request, c)
+	return SetInstanceActive(client, activeStatus, &c.Status.Conditions, sts, request, c)
 }
 
 func (c *Rabbitmq) ManageNodeStatus(podNameIPMap map[string]string,
@@ -8,6 +8,10 @@ type Status struct {
 	Active        bool  `json:"active,omitempty"`
 	Replicas      int32 `json:"replicas,omitempty"`
 	ReadyReplicas int32 `json:"readyReplicas,omitempty"`
+	// +optional
+	// +patchMergeKey=type
+	// +patchStrategy=merge
+	Conditions []Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
 }
 
 func (s *Status) FromDeployment(d *appsv1.Deployment) {
@@ -19,6 +19,12 @@ type SwiftConfiguration struct {
 	SwiftStorageConfiguration SwiftStorageConfiguration `json:"swiftStorageConfiguration"`
 	SwiftProxyConfiguration   SwiftProxyConfiguration   `json:"swiftProxyConfiguration"`
 	CredentialsSecretName     string                    `json:"credentialsSecretName,omitempty"`
+	// NearlyFullPercent is the used space of a storage device in percent at which
+	// the NearlyFull condition is set. Default is 80.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	NearlyFullPercent int `json:"nearlyFullPercent,omitempty"`
 }
 
 // SwiftStatus defines the observed state of Swift
@@ -28,8 +34,38 @@ type SwiftStatus struct {
 	SwiftProxyPort        int    `json:"swiftProxyPort,omitempty"`
 	SwiftProxyClusterIP   string `json:"swiftProxyClusterIP,omitempty"`
 	CredentialsSecretName string `json:"credentialsSecretName,omitempty"`
+	// Usage sums usage of all storage pods gathered from swift-recon.
+	// +optional
+	Usage *SwiftUsage `json:"usage,omitempty"`
+	// +optional
+	// +patchMergeKey=type
+	// +patchStrategy=merge
+	Conditions []Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
 }
 
+// SwiftUsage summarizes disk usage, replication and quarantined items of storage pods.
+// +k8s:openapi-gen=true
+type SwiftUsage struct {
+	SizeBytes      int64 `json:"sizeBytes"`
+	UsedBytes      int64 `json:"usedBytes"`
+	AvailableBytes int64 `json:"availableBytes"`
+	// MaxDeviceUsedPercent is the used space of the fullest device.
+	MaxDeviceUsedPercent int `json:"maxDeviceUsedPercent"`
+	// MaxReplicationLagSeconds is the longest time since an object replicator
+	// of a storage pod completed its pass.
+	// +optional
+	MaxReplicationLagSeconds *int64 `json:"maxReplicationLagSeconds,omitempty"`
+	QuarantinedObjects       int64  `json:"quarantinedObjects"`
+	QuarantinedContainers    int64  `json:"quarantinedContainers"`
+	QuarantinedAccounts      int64  `json:"quarantinedAccounts"`
+	// ReportTime is the time the usage was gathered at.
+	ReportTime metav1.Time `json:"reportTime"`
+}
+
+// SwiftConditionNearlyFull is true when a storage device of the Swift
+// is filled above NearlyFullPercent.
+const SwiftConditionNearlyFull ConditionType = "NearlyFull"
+
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 
 // Swift is the Schema for the swifts API
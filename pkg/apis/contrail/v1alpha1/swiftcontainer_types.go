@@ -0,0 +1,130 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// SwiftContainerSpec defines the desired state of SwiftContainer
+// +k8s:openapi-gen=true
+type SwiftContainerSpec struct {
+	// SwiftInstance is the name of the Swift the container is created in.
+	SwiftInstance string `json:"swiftInstance"`
+	// ContainerName is the name of the container in Swift. The name of the resource is used when empty.
+	// +optional
+	ContainerName string `json:"containerName,omitempty"`
+	// ReadACL is the X-Container-Read ACL of the container, e.g. ".r:*,.rlistings".
+	// +optional
+	ReadACL string `json:"readACL,omitempty"`
+	// WriteACL is the X-Container-Write ACL of the container.
+	// +optional
+	WriteACL string `json:"writeACL,omitempty"`
+	// +optional
+	Quota *SwiftContainerQuota `json:"quota,omitempty"`
+	// +optional
+	Versioning *SwiftContainerVersioning `json:"versioning,omitempty"`
+	// Metadata is set as X-Container-Meta-* headers of the container.
+	// +optional
+	Metadata map[string]string `json:"metadata,omitempty"`
+	// DeletionPolicy tells whether the container is deleted together with the resource.
+	// Containers which still hold objects are never deleted. Default is Retain.
+	// +optional
+	// +kubebuilder:validation:Enum=Retain;Delete
+	DeletionPolicy SwiftContainerDeletionPolicy `json:"deletionPolicy,omitempty"`
+}
+
+// SwiftContainerQuota limits the size of the container.
+// +k8s:openapi-gen=true
+type SwiftContainerQuota struct {
+	// +optional
+	Bytes *int64 `json:"bytes,omitempty"`
+	// +optional
+	Count *int64 `json:"count,omitempty"`
+}
+
+// SwiftContainerVersioning keeps old versions of objects of the container in another container.
+// +k8s:openapi-gen=true
+type SwiftContainerVersioning struct {
+	// Location is the container old versions are kept in. It is created when missing.
+	Location string `json:"location"`
+	// Mode is Stack (X-Versions-Location) or History (X-History-Location). Default is Stack.
+	// +optional
+	// +kubebuilder:validation:Enum=Stack;History
+	Mode SwiftContainerVersioningMode `json:"mode,omitempty"`
+}
+
+// SwiftContainerVersioningMode tells how deletes of versioned objects are handled.
+type SwiftContainerVersioningMode string
+
+const (
+	// SwiftVersioningStack restores the previous version when an object is deleted.
+	SwiftVersioningStack SwiftContainerVersioningMode = "Stack"
+	// SwiftVersioningHistory keeps all versions, including deleted objects, in the location.
+	SwiftVersioningHistory SwiftContainerVersioningMode = "History"
+)
+
+// SwiftContainerDeletionPolicy tells what happens with the container when its resource is deleted.
+type SwiftContainerDeletionPolicy string
+
+const (
+	SwiftContainerRetain SwiftContainerDeletionPolicy = "Retain"
+	SwiftContainerDelete SwiftContainerDeletionPolicy = "Delete"
+)
+
+// SwiftContainerStatus defines the observed state of SwiftContainer
+// +k8s:openapi-gen=true
+type SwiftContainerStatus struct {
+	// Created is true when the container exists in Swift.
+	// +optional
+	Created bool `json:"created,omitempty"`
+	// +optional
+	ObjectCount int64 `json:"objectCount,omitempty"`
+	// +optional
+	BytesUsed int64 `json:"bytesUsed,omitempty"`
+	// MetadataKeys lists keys of metadata set by the operator, so that
+	// keys removed from the spec are also removed from the container.
+	// +optional
+	MetadataKeys []string `json:"metadataKeys,omitempty"`
+	// +optional
+	// +patchMergeKey=type
+	// +patchStrategy=merge
+	Conditions []Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// SwiftContainer is the Schema for the swiftcontainers API
+// +k8s:openapi-gen=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:path=swiftcontainers,scope=Namespaced
+// +kubebuilder:printcolumn:name="Swift",type=string,JSONPath=`.spec.swiftInstance`
+// +kubebuilder:printcolumn:name="Objects",type=integer,JSONPath=`.status.objectCount`
+// +kubebuilder:printcolumn:name="Bytes",type=integer,JSONPath=`.status.bytesUsed`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+type SwiftContainer struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   SwiftContainerSpec   `json:"spec,omitempty"`
+	Status SwiftContainerStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// SwiftContainerList contains a list of SwiftContainer
+type SwiftContainerList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []SwiftContainer `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&SwiftContainer{}, &SwiftContainerList{})
+}
+
+// ContainerName returns the name of the container in Swift.
+func (c *SwiftContainer) ContainerName() string {
+	if c.Spec.ContainerName != "" {
+		return c.Spec.ContainerName
+	}
+	return c.ObjectMeta.Name
+}
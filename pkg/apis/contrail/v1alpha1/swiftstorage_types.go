@@ -22,6 +22,73 @@ type SwiftStorageConfiguration struct {
 	Device              string       `json:"device,omitempty"`
 	Containers          []*Container `json:"containers,omitempty"`
 	Storage             Storage      `json:"storage,omitempty"`
+	// DeviceWeight is the weight of devices in rings, 100 by default.
+	DeviceWeight int `json:"deviceWeight,omitempty"`
+	// Nodes override ring placement of devices of storage nodes. By default region and zone
+	// of devices are derived from topology.kubernetes.io/region and topology.kubernetes.io/zone
+	// labels of nodes.
+	Nodes []SwiftStorageNode `json:"nodes,omitempty"`
+	// Devices are disks of every storage node. Each device is mounted in /srv/node/<name>
+	// and placed in rings as a separate entry. When empty, a single Device backed by
+	// Storage is used.
+	Devices []SwiftDeviceConfiguration `json:"devices,omitempty"`
+}
+
+// SwiftDeviceConfiguration is a device of every storage node.
+// +k8s:openapi-gen=true
+type SwiftDeviceConfiguration struct {
+	// Name of the device in rings.
+	// +kubebuilder:validation:Pattern=^[a-z0-9]([-a-z0-9]*[a-z0-9])?$
+	Name string `json:"name"`
+	// Storage is the size and the host path of local persistent volumes created for the device.
+	// The path is /mnt/swiftstorage/<name> by default.
+	Storage Storage `json:"storage,omitempty"`
+	// StorageClassName makes claims of the device provisioned from the storage class
+	// instead of local persistent volumes created by the operator.
+	StorageClassName string `json:"storageClassName,omitempty"`
+	// Selector makes claims of the device bound to existing persistent volumes with
+	// matching labels instead of local persistent volumes created by the operator.
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+	// Rings the device is placed in. All rings by default.
+	Rings []SwiftRing `json:"rings,omitempty"`
+	// Weight of the device in rings, which takes precedence over weights of nodes.
+	// DeviceWeight by default.
+	Weight int `json:"weight,omitempty"`
+}
+
+// SwiftRing is the type of a Swift ring.
+// +kubebuilder:validation:Enum=account;container;object
+type SwiftRing string
+
+const (
+	SwiftAccountRing   SwiftRing = "account"
+	SwiftContainerRing SwiftRing = "container"
+	SwiftObjectRing    SwiftRing = "object"
+)
+
+// ProvisionedByOperator tells whether local persistent volumes of the device are
+// created by the operator.
+func (d SwiftDeviceConfiguration) ProvisionedByOperator() bool {
+	return d.StorageClassName == "" && d.Selector == nil
+}
+
+// StorageDevices returns devices of every storage node. The single Device backed by
+// Storage is returned when no Devices are given.
+func (c SwiftStorageConfiguration) StorageDevices() []SwiftDeviceConfiguration {
+	if len(c.Devices) != 0 {
+		return c.Devices
+	}
+	return []SwiftDeviceConfiguration{{Name: c.Device, Storage: c.Storage}}
+}
+
+// SwiftStorageNode overrides ring placement of devices of a storage node.
+// +k8s:openapi-gen=true
+type SwiftStorageNode struct {
+	// Name is the name of the k8s node.
+	Name   string `json:"name"`
+	Region int    `json:"region,omitempty"`
+	Zone   int    `json:"zone,omitempty"`
+	Weight int    `json:"weight,omitempty"`
 }
 
 // SwiftStorageStatus defines the observed state of SwiftStorage
@@ -29,6 +96,75 @@ type SwiftStorageConfiguration struct {
 type SwiftStorageStatus struct {
 	Active bool     `json:"active"`
 	IPs    []string `json:"ip,omitempty"`
+	// Devices are ring entries of storage pods.
+	Devices []SwiftStorageDevice `json:"devices,omitempty"`
+	// RegionIDs map topology.kubernetes.io/region labels of nodes to ring regions.
+	RegionIDs map[string]int `json:"regionIDs,omitempty"`
+	// ZoneIDs map topology.kubernetes.io/zone labels of nodes to ring zones.
+	ZoneIDs map[string]int `json:"zoneIDs,omitempty"`
+	// Usage of storage pods gathered by the Swift controller from swift-recon.
+	// +optional
+	Usage []SwiftStoragePodUsage `json:"usage,omitempty"`
+	// +optional
+	// +patchMergeKey=type
+	// +patchStrategy=merge
+	Conditions []Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+}
+
+// SwiftStorageDevice is a device of a storage pod placed in rings.
+// +k8s:openapi-gen=true
+type SwiftStorageDevice struct {
+	// Pod is the name of the storage pod of the device.
+	Pod      string `json:"pod,omitempty"`
+	NodeName string `json:"nodeName,omitempty"`
+	IP       string `json:"ip"`
+	Device   string `json:"device"`
+	Region   int    `json:"region"`
+	Zone     int    `json:"zone"`
+	Weight   int    `json:"weight"`
+	// Rings the device is placed in. All rings when empty.
+	Rings []SwiftRing `json:"rings,omitempty"`
+}
+
+// SwiftStoragePodUsage is the usage of a storage pod reported by its recon middleware.
+// +k8s:openapi-gen=true
+type SwiftStoragePodUsage struct {
+	Pod     string             `json:"pod"`
+	Devices []SwiftDeviceUsage `json:"devices,omitempty"`
+	// ReplicationLagSeconds is the time since the object replicator of the pod
+	// completed its last pass. It is not set before the first pass completes.
+	// +optional
+	ReplicationLagSeconds *int64 `json:"replicationLagSeconds,omitempty"`
+	QuarantinedObjects    int64  `json:"quarantinedObjects"`
+	QuarantinedContainers int64  `json:"quarantinedContainers"`
+	QuarantinedAccounts   int64  `json:"quarantinedAccounts"`
+	// Error is set when recon of the pod cannot be read.
+	// +optional
+	Error string `json:"error,omitempty"`
+}
+
+// SwiftDeviceUsage is the disk usage of a device of a storage pod.
+// +k8s:openapi-gen=true
+type SwiftDeviceUsage struct {
+	Device         string `json:"device"`
+	Mounted        bool   `json:"mounted"`
+	SizeBytes      int64  `json:"sizeBytes"`
+	UsedBytes      int64  `json:"usedBytes"`
+	AvailableBytes int64  `json:"availableBytes"`
+	UsedPercent    int    `json:"usedPercent"`
+}
+
+// InRing tells whether the device is placed in the ring.
+func (d SwiftStorageDevice) InRing(ring SwiftRing) bool {
+	if len(d.Rings) == 0 {
+		return true
+	}
+	for _, r := range d.Rings {
+		if r == ring {
+			return true
+		}
+	}
+	return false
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
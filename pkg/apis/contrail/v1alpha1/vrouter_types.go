@@ -45,6 +45,10 @@ type VrouterStatus struct {
 	Ports  ConfigStatusPorts `json:"ports,omitempty"`
 	Nodes  map[string]string `json:"nodes,omitempty"`
 	Active *bool             `json:"active,omitempty"`
+	// +optional
+	// +patchMergeKey=type
+	// +patchStrategy=merge
+	Conditions []Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
 }
 
 // VrouterSpec is the Spec for the vrouter API.
@@ -289,6 +293,9 @@ func (c *Vrouter) SetInstanceActive(client client.Client, activeStatus *bool, ds
 	}
 
 	*activeStatus = active
+	if err := SetWorkloadConditions(client, &c.Status.Conditions, c.Generation, ds.Namespace, ds.Spec.Selector, ds.Status.DesiredNumberScheduled, ds.Status.NumberReady); err != nil {
+		return err
+	}
 	if err := client.Status().Update(context.TODO(), object); err != nil {
 		return err
 	}
@@ -98,6 +98,8 @@ type keystoneEndpoint struct {
 	region            string
 	userDomainName    string
 	projectDomainName string
+	adminUsername     string
+	adminProject      string
 }
 
 func init() {
@@ -147,6 +149,8 @@ func (c *Webui) InstanceConfiguration(request reconcile.Request,
 		if err != nil {
 			return err
 		}
+		webUIConfig.AdminUsername = keystoneData.adminUsername
+		webUIConfig.AdminProject = keystoneData.adminProject
 	}
 
 	configApiIPListCommaSeparatedQuoted := configtemplates.JoinListWithSeparatorAndSingleQuotes(configNodesInformation.APIServerIPList, ",")
@@ -208,11 +212,13 @@ func (c *Webui) InstanceConfiguration(request reconcile.Request,
 		configtemplates.WebuiAuthConfig.Execute(&webuiAuthConfigBuffer, struct {
 			AdminUsername             string
 			AdminPassword             string
+			AdminProject              string
 			KeystoneProjectDomainName string
 			KeystoneUserDomainName    string
 		}{
 			AdminUsername:             webUIConfig.AdminUsername,
 			AdminPassword:             webUIConfig.AdminPassword,
+			AdminProject:              webUIConfig.AdminProject,
 			KeystoneUserDomainName:    keystoneData.userDomainName,
 			KeystoneProjectDomainName: keystoneData.projectDomainName,
 		})
@@ -241,7 +247,8 @@ func (c *Webui) CreateSecret(secretName string,
 
 func (c *Webui) ConfigurationParameters(client client.Client) (*WebUIClusterConfiguration, error) {
 	w := &WebUIClusterConfiguration{
-		AdminUsername: "admin",
+		AdminUsername: KeystoneAuthAdminUser,
+		AdminProject:  KeystoneAuthAdminTenant,
 	}
 	adminPasswordSecretName := c.Spec.ServiceConfiguration.KeystoneSecretName
 	adminPasswordSecret := &corev1.Secret{}
@@ -302,7 +309,7 @@ func (c *Webui) PodsCertSubjects(podList *corev1.PodList) []certificates.Certifi
 
 // SetInstanceActive sets the Webui instance to active.
 func (c *Webui) SetInstanceActive(client client.Client, activeStatus *bool, sts *appsv1.StatefulSet, request reconcile.Request) error {
-	return SetInstanceActive(client, activeStatus, sts, request, c)
+	return SetInstanceActive(client, activeStatus, &c.Status.Conditions, sts, request, c)
 }
 
 func (c *Webui) ManageNodeStatus(podNameIPMap map[string]string,
@@ -324,11 +331,14 @@ func (c *Webui) getKeystoneEndpoint(k *keystoneEndpoint, client client.Client) e
 	if keystone.Status.Endpoint == "" {
 		return fmt.Errorf("%q Status.Endpoint empty", keystoneInstanceName)
 	}
+	keystoneConfiguration := keystone.ConfigurationParameters()
 	k.address = keystone.Status.Endpoint
-	k.port = keystone.Spec.ServiceConfiguration.ListenPort
-	k.region = keystone.Spec.ServiceConfiguration.Region
-	k.authProtocol = keystone.Spec.ServiceConfiguration.AuthProtocol
-	k.userDomainName = keystone.Spec.ServiceConfiguration.UserDomainName
-	k.projectDomainName = keystone.Spec.ServiceConfiguration.ProjectDomainName
+	k.port = keystoneConfiguration.ListenPort
+	k.region = keystoneConfiguration.Region
+	k.authProtocol = keystoneConfiguration.AuthProtocol
+	k.userDomainName = keystoneConfiguration.UserDomainName
+	k.projectDomainName = keystoneConfiguration.ProjectDomainName
+	k.adminUsername = keystoneConfiguration.AdminUsername
+	k.adminProject = keystoneConfiguration.AdminProject
 	return nil
 }
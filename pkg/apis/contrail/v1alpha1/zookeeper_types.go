@@ -47,6 +47,10 @@ type ZookeeperStatus struct {
 	Active *bool                `json:"active,omitempty"`
 	Nodes  map[string]string    `json:"nodes,omitempty"`
 	Ports  ZookeeperStatusPorts `json:"ports,omitempty"`
+	// +optional
+	// +patchMergeKey=type
+	// +patchStrategy=merge
+	Conditions []Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
 }
 
 // ZookeeperStatusPorts defines the status of the ports of the zookeeper object.
@@ -200,7 +204,7 @@ func (c *Zookeeper) UpdateSTS(sts *appsv1.StatefulSet, instanceType string, requ
 
 // SetInstanceActive sets the Cassandra instance to active.
 func (c *Zookeeper) SetInstanceActive(client client.Client, activeStatus *bool, sts *appsv1.StatefulSet, request reconcile.Request) error {
-	return SetInstanceActive(client, activeStatus, sts, request, c)
+	return SetInstanceActive(client, activeStatus, &c.Status.Conditions, sts, request, c)
 }
 
 // ManageNodeStatus manages the status of the Cassandra nodes.
@@ -25,6 +25,9 @@ const (
 const (
 	caSecretName               = "contrail-ca-certificate"
 	signerCAPrivateKeyFilename = "ca-priv-key.pem"
+	nextCAFilename             = "ca-next.crt"
+	nextCAPrivateKeyFilename   = "ca-next-priv-key.pem"
+	previousCAFilename         = "ca-previous.crt"
 )
 
 type CACertificate struct {
@@ -34,42 +37,95 @@ type CACertificate struct {
 	secret caCertSecret
 }
 
-func NewCACertificate(client client.Client, scheme *runtime.Scheme, owner metav1.Object, ownerType string) *CACertificate {
+// NewCACertificate returns the CA of certificates of pods. The key selects the
+// private key of the CA generated by the operator.
+func NewCACertificate(client client.Client, scheme *runtime.Scheme, owner metav1.Object, ownerType string, key KeyConfig) *CACertificate {
 	kubernetes := k8s.New(client, scheme)
 	return &CACertificate{
 		client: client,
 		owner:  owner,
 		scheme: scheme,
-		secret: caCertSecret{kubernetes.Secret(caSecretName, ownerType, owner)},
+		secret: caCertSecret{sc: kubernetes.Secret(caSecretName, ownerType, owner), key: key},
 	}
 }
 
+// EnsureExists generates the contrail-signer CA, when the operator signs certificates
+// with it, or checks that the secret with the external CA exists otherwise.
 func (c *CACertificate) EnsureExists() error {
+	if CertificateIssuer != CAIssuer {
+		if _, err := getExternalCASecret(c.client, c.owner.GetNamespace()); err != nil {
+			return fmt.Errorf("failed to get secret %s with external ca: %w", ExternalCASecretName, err)
+		}
+		return nil
+	}
 	return c.secret.ensureExists()
 }
 
+// GetCaCert returns the bundle of CA certificates which pods should trust. During
+// the CA rollover it contains both the old and the new CA certificate.
 func (c *CACertificate) GetCaCert() ([]byte, error) {
+	if CertificateIssuer != CAIssuer {
+		secret, err := getExternalCASecret(c.client, c.owner.GetNamespace())
+		if err != nil {
+			return nil, err
+		}
+		return externalCABundle(secret.Data), nil
+	}
 	secret, err := c.getCaCertSecret()
 	if err != nil {
 		return nil, err
 	}
-	return secret.Data[SignerCAFilename], nil
+	return caBundle(secret.Data), nil
+}
+
+// GetCRL returns the certificate revocation list signed by the CA or nil when
+// revoked certificates are not published or certificates are issued by cert-manager.
+func (c *CACertificate) GetCRL() ([]byte, error) {
+	if !PublishCRL || CertificateIssuer == CertManagerIssuer {
+		return nil, nil
+	}
+	var secret *corev1.Secret
+	var err error
+	certKey, privKeyKey := SignerCAFilename, signerCAPrivateKeyFilename
+	if CertificateIssuer == ExternalCAIssuer {
+		secret, err = getExternalCASecret(c.client, c.owner.GetNamespace())
+		certKey, privKeyKey = corev1.TLSCertKey, corev1.TLSPrivateKeyKey
+	} else {
+		secret, err = c.getCaCertSecret()
+	}
+	if err != nil {
+		return nil, err
+	}
+	caCert, err := parseCertificate(secret.Data, certKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ca cert: %w", err)
+	}
+	caCertPrivKeyPemBlock, err := getAndDecodePem(secret.Data, privKeyKey)
+	if err != nil || caCertPrivKeyPemBlock == nil {
+		return nil, fmt.Errorf("failed to decode ca cert priv key pem: %v", err)
+	}
+	caCertPrivKey, err := parsePrivateKey(caCertPrivKeyPemBlock.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ca cert priv key: %w", err)
+	}
+	return certificateRevocationList(c.client, c.owner.GetNamespace(), caCert, caCertPrivKey)
 }
 
 type caCertSecret struct {
-	sc *k8s.Secret
+	sc  *k8s.Secret
+	key KeyConfig
 }
 
 func (s caCertSecret) ensureExists() error {
 	return s.sc.EnsureExists(s)
 }
 
-func (caCertSecret) FillSecret(secret *corev1.Secret) error {
+func (s caCertSecret) FillSecret(secret *corev1.Secret) error {
 	if caCertExistsInSecret(secret) {
-		return nil
+		return rolloverCaCertificate(secret, s.key)
 	}
 
-	caCert, caCertPrivKey, err := generateCaCertificate()
+	caCert, caCertPrivKey, err := generateCaCertificate(s.key)
 	if err != nil {
 		return fmt.Errorf("failed to generate ca certificate: %w", err)
 	}
@@ -87,6 +143,59 @@ func (c *CACertificate) getCaCertSecret() (*corev1.Secret, error) {
 	return secret, err
 }
 
+// rolloverCaCertificate replaces the CA certificate which passed the RenewalThreshold
+// of its lifetime. The new CA is first published next to the current one for the
+// CARolloverOverlap, so that pods trust it before it starts signing certificates.
+// Then it becomes the signing CA and the old one is kept in the bundle until it
+// expires, so that certificates signed by it are still trusted until they are renewed.
+func rolloverCaCertificate(secret *corev1.Secret, key KeyConfig) error {
+	current, err := parseCertificate(secret.Data, SignerCAFilename)
+	if err != nil {
+		return fmt.Errorf("failed to parse ca cert: %w", err)
+	}
+	if _, ok := secret.Data[nextCAFilename]; ok {
+		next, err := parseCertificate(secret.Data, nextCAFilename)
+		if err != nil {
+			return fmt.Errorf("failed to parse next ca cert: %w", err)
+		}
+		if now().Before(next.NotBefore.Add(CARolloverOverlap)) && now().Before(current.NotAfter) {
+			return nil
+		}
+		secret.Data[previousCAFilename] = secret.Data[SignerCAFilename]
+		secret.Data[SignerCAFilename] = secret.Data[nextCAFilename]
+		secret.Data[signerCAPrivateKeyFilename] = secret.Data[nextCAPrivateKeyFilename]
+		delete(secret.Data, nextCAFilename)
+		delete(secret.Data, nextCAPrivateKeyFilename)
+		return nil
+	}
+	if _, ok := secret.Data[previousCAFilename]; ok {
+		previous, err := parseCertificate(secret.Data, previousCAFilename)
+		if err != nil || !now().Before(previous.NotAfter) {
+			delete(secret.Data, previousCAFilename)
+		}
+	}
+	if !dueForRenewal(current) && key.matches(current.PublicKey) {
+		return nil
+	}
+	nextCert, nextPrivKey, err := generateCaCertificate(key)
+	if err != nil {
+		return fmt.Errorf("failed to generate next ca certificate: %w", err)
+	}
+	secret.Data[nextCAFilename] = nextCert
+	secret.Data[nextCAPrivateKeyFilename] = nextPrivKey
+	return nil
+}
+
+// caBundle concatenates the signing CA certificate with the CA certificates
+// published during the rollover.
+func caBundle(data map[string][]byte) []byte {
+	var bundle []byte
+	for _, key := range []string{SignerCAFilename, nextCAFilename, previousCAFilename} {
+		bundle = append(bundle, data[key]...)
+	}
+	return bundle
+}
+
 func caCertExistsInSecret(secret *corev1.Secret) bool {
 	if secret.Data == nil {
 		return false
@@ -96,8 +205,8 @@ func caCertExistsInSecret(secret *corev1.Secret) bool {
 	return certOk && privKeyOk
 }
 
-func generateCaCertificate() ([]byte, []byte, error) {
-	caCertTemplate, caPrivKey, err := generateCaCertificateTemplate()
+func generateCaCertificate(key KeyConfig) ([]byte, []byte, error) {
+	caCertTemplate, caPrivKey, err := generateCaCertificateTemplate(key)
 
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to create certificate template: %w", err)
@@ -115,7 +224,7 @@ func generateCaCertificate() ([]byte, []byte, error) {
 		return nil, nil, fmt.Errorf("failed to encode certificate with pem format: %w", err)
 	}
 
-	caCertPrivKeyPem, err := encodeInPemFormat(x509.MarshalPKCS1PrivateKey(caPrivKey), privateKeyPemType)
+	caCertPrivKeyPem, err := encodePrivateKey(caPrivKey)
 
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to encode private key with pem format: %w", err)
@@ -5,6 +5,7 @@ import (
 	"crypto/x509"
 	"encoding/pem"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -35,7 +36,7 @@ func TestCaCertGeneration(t *testing.T) {
 		},
 	}
 
-	caCertificate := NewCACertificate(cl, scheme, owner, "ownerType")
+	caCertificate := NewCACertificate(cl, scheme, owner, "ownerType", KeyConfig{})
 	assert.NoError(t, caCertificate.EnsureExists())
 
 	key := client.ObjectKey{
@@ -61,7 +62,70 @@ func TestCaCertGeneration(t *testing.T) {
 	assert.NoError(t, err)
 
 	assert.True(t, caCert.IsCA)
-	assert.Equal(t, caCert.KeyUsage, x509.KeyUsageKeyEncipherment|x509.KeyUsageDigitalSignature|x509.KeyUsageCertSign)
+	assert.Equal(t, caCert.KeyUsage, x509.KeyUsageKeyEncipherment|x509.KeyUsageDigitalSignature|x509.KeyUsageCertSign|x509.KeyUsageCRLSign)
 	dur := caCert.NotAfter.Sub(caCert.NotBefore)
-	assert.GreaterOrEqual(t, dur.Hours(), caCertValidityPeriod.Hours())
+	assert.GreaterOrEqual(t, dur.Hours(), CACertValidityPeriod.Hours())
+}
+
+func TestCaCertRollover(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, core.SchemeBuilder.AddToScheme(scheme))
+	cl := fake.NewFakeClientWithScheme(scheme)
+	owner := &core.Pod{ObjectMeta: meta.ObjectMeta{Name: "owner", UID: "ownerUID", Namespace: "default"}}
+	caCertificate := NewCACertificate(cl, scheme, owner, "ownerType", KeyConfig{})
+	restoreNow := now
+	defer func() { now = restoreNow }()
+	later := func(d time.Duration) { now = func() time.Time { return restoreNow().Add(d) } }
+	getSecret := func() *core.Secret {
+		secret := &core.Secret{}
+		require.NoError(t, cl.Get(context.Background(), client.ObjectKey{Name: caSecretName, Namespace: "default"}, secret))
+		return secret
+	}
+	bundleSize := func() int {
+		bundle, err := caCertificate.GetCaCert()
+		require.NoError(t, err)
+		var blocks int
+		for block, rest := pem.Decode(bundle); block != nil; block, rest = pem.Decode(rest) {
+			blocks++
+		}
+		return blocks
+	}
+	require.NoError(t, caCertificate.EnsureExists())
+	oldCA := getSecret().Data[SignerCAFilename]
+	rolloverStart := time.Duration(float64(CACertValidityPeriod) * RenewalThreshold)
+
+	t.Run("should publish new CA next to the signing one", func(t *testing.T) {
+		later(rolloverStart)
+		// when
+		require.NoError(t, caCertificate.EnsureExists())
+		// then
+		secret := getSecret()
+		assert.Equal(t, oldCA, secret.Data[SignerCAFilename])
+		assert.NotEmpty(t, secret.Data[nextCAFilename])
+		assert.Equal(t, 2, bundleSize())
+	})
+
+	t.Run("should sign with new CA after the overlap", func(t *testing.T) {
+		nextCA := getSecret().Data[nextCAFilename]
+		nextKey := getSecret().Data[nextCAPrivateKeyFilename]
+		later(rolloverStart + CARolloverOverlap)
+		// when
+		require.NoError(t, caCertificate.EnsureExists())
+		// then
+		secret := getSecret()
+		assert.Equal(t, nextCA, secret.Data[SignerCAFilename])
+		assert.Equal(t, nextKey, secret.Data[signerCAPrivateKeyFilename])
+		assert.Equal(t, oldCA, secret.Data[previousCAFilename])
+		assert.NotContains(t, secret.Data, nextCAFilename)
+		assert.Equal(t, 2, bundleSize())
+	})
+
+	t.Run("should drop old CA once it expires", func(t *testing.T) {
+		later(CACertValidityPeriod + time.Hour)
+		// when
+		require.NoError(t, caCertificate.EnsureExists())
+		// then
+		assert.NotContains(t, getSecret().Data, previousCAFilename)
+		assert.Equal(t, 1, bundleSize())
+	})
 }
@@ -1,49 +1,68 @@
 package certificates
 
 import (
-	"crypto/rsa"
+	"bytes"
+	"context"
+	"crypto/sha256"
 	"crypto/x509"
+	"encoding/hex"
 	"fmt"
+	"strings"
 
 	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/Juniper/contrail-operator/pkg/k8s"
 )
 
+const (
+	caBundleHashAnnotation = "contrail.juniper.net/ca-bundle-hash"
+	approvedStatus         = "Approved"
+	// renewedStatus marks certificates of pods which have to be restarted
+	// to load the renewed certificate or CA bundle.
+	renewedStatus = "Renewed"
+)
+
 type Certificate struct {
 	client              client.Client
 	scheme              *runtime.Scheme
 	owner               v1.Object
 	sc                  *k8s.Secret
-	signer              certificateSigner
+	issuer              issuer
 	certificateSubjects []CertificateSubject
+	// pending are names of subjects whose certificates are being issued.
+	pending []string
 }
 
-func NewCertificate(cl client.Client, scheme *runtime.Scheme, owner v1.Object, subjects []CertificateSubject, ownerType string) *Certificate {
+// NewCertificate returns certificates of the subjects with private keys selected by the key.
+func NewCertificate(cl client.Client, scheme *runtime.Scheme, owner v1.Object, subjects []CertificateSubject, ownerType string, key KeyConfig) *Certificate {
 	secretName := owner.GetName() + "-secret-certificates"
 	kubernetes := k8s.New(cl, scheme)
 	return &Certificate{
-		client: cl,
-		scheme: scheme,
-		owner:  owner,
-		sc:     kubernetes.Secret(secretName, ownerType, owner),
-		signer: &signer{
-			client: cl,
-			owner:  owner,
-		},
+		client:              cl,
+		scheme:              scheme,
+		owner:               owner,
+		sc:                  kubernetes.Secret(secretName, ownerType, owner),
+		issuer:              newIssuer(cl, scheme, owner, key),
 		certificateSubjects: subjects,
 	}
 }
 
+// EnsureExistsAndIsSigned issues certificates for subjects which have none,
+// stores certificates renewed by the CertificateIssuer and restarts pods with
+// renewed certificates one at a time.
 func (r *Certificate) EnsureExistsAndIsSigned() error {
-	return r.sc.EnsureExists(r)
-}
-
-type certificateSigner interface {
-	SignCertificate(certTemplate x509.Certificate, privateKey rsa.PrivateKey) ([]byte, error)
+	if err := r.sc.EnsureExists(r); err != nil {
+		return err
+	}
+	if len(r.pending) > 0 {
+		return fmt.Errorf("certificates of %s are not issued yet", strings.Join(r.pending, ", "))
+	}
+	return r.restartRenewedPod()
 }
 
 func (r *Certificate) FillSecret(secret *core.Secret) error {
@@ -51,13 +70,23 @@ func (r *Certificate) FillSecret(secret *core.Secret) error {
 		secret.Data = make(map[string][]byte)
 	}
 
+	bundle, err := r.issuer.CABundle()
+	if err != nil {
+		return err
+	}
+	bundleChanged := bundle != nil && updateCABundleHash(secret, bundle)
+	r.pending = nil
 	for _, subject := range r.certificateSubjects {
 		if subject.ip == "" {
 			continue
 		}
-		if err := r.createCertificateForPod(subject, secret); err != nil {
+		issued, err := r.createCertificateForPod(subject, secret, bundleChanged)
+		if err != nil {
 			return err
 		}
+		if !issued {
+			r.pending = append(r.pending, subject.name)
+		}
 	}
 
 	for _, subject := range r.certificateSubjects {
@@ -66,28 +95,153 @@ func (r *Certificate) FillSecret(secret *core.Secret) error {
 		}
 	}
 
+	// Pods without an IP may still get back their previous one, so
+	// certificates are pruned only when IPs of all subjects are known.
+	return r.pruneStaleCertificates(secret)
+}
+
+// createCertificateForPod stores the certificate of the subject in the secret
+// and tells whether it was issued.
+func (r *Certificate) createCertificateForPod(subject CertificateSubject, secret *core.Secret, bundleChanged bool) (bool, error) {
+	currentCert := secret.Data[serverCertificateFileName(subject.ip)]
+	currentKey := secret.Data[serverPrivateKeyFileName(subject.ip)]
+	cert, key, err := r.issuer.Issue(subject, currentCert, currentKey)
+	if err != nil {
+		return false, err
+	}
+	if cert == nil || key == nil {
+		return false, nil
+	}
+	issued := certInSecret(secret, subject.ip)
+	if issued && bytes.Equal(cert, currentCert) && bytes.Equal(key, currentKey) {
+		if bundleChanged {
+			secret.Data[statusFileName(subject.ip)] = []byte(renewedStatus)
+		}
+		return true, nil
+	}
+	secret.Data[serverPrivateKeyFileName(subject.ip)] = key
+	secret.Data[serverCertificateFileName(subject.ip)] = cert
+	if issued {
+		secret.Data[statusFileName(subject.ip)] = []byte(renewedStatus)
+	} else {
+		secret.Data[statusFileName(subject.ip)] = []byte(approvedStatus)
+	}
+	return true, nil
+}
+
+// pruneStaleCertificates removes certificates of IPs which do not belong to any
+// subject. With PublishCRL the removed certificates are revoked first, unless they
+// were issued by cert-manager, so that they stay in the secret until the revocation
+// succeeds.
+func (r *Certificate) pruneStaleCertificates(secret *core.Secret) error {
+	ips := map[string]bool{}
+	for _, subject := range r.certificateSubjects {
+		ips[subject.ip] = true
+	}
+	var stale []string
+	for key := range secret.Data {
+		if ip := certificateKeyIP(key); ip != "" && !ips[ip] {
+			stale = append(stale, key)
+		}
+	}
+	if PublishCRL && CertificateIssuer != CertManagerIssuer {
+		var revoked []*x509.Certificate
+		for _, key := range stale {
+			if !strings.HasSuffix(key, ".crt") {
+				continue
+			}
+			if cert, err := decodeCertificate(secret.Data[key]); err == nil {
+				revoked = append(revoked, cert)
+			}
+		}
+		if len(revoked) > 0 {
+			if err := revokeCertificates(r.client, r.owner.GetNamespace(), revoked); err != nil {
+				return fmt.Errorf("failed to revoke stale certificates: %w", err)
+			}
+		}
+	}
+	for _, key := range stale {
+		delete(secret.Data, key)
+	}
 	return nil
 }
 
-func (r *Certificate) createCertificateForPod(subject CertificateSubject, secret *core.Secret) error {
-	if certInSecret(secret, subject.ip) {
+// certificateKeyIP returns the IP of the certificate, private key or status
+// stored under the key in the secret.
+func certificateKeyIP(key string) string {
+	switch {
+	case strings.HasPrefix(key, "server-key-") && strings.HasSuffix(key, ".pem"):
+		return strings.TrimSuffix(strings.TrimPrefix(key, "server-key-"), ".pem")
+	case strings.HasPrefix(key, "server-") && strings.HasSuffix(key, ".crt"):
+		return strings.TrimSuffix(strings.TrimPrefix(key, "server-"), ".crt")
+	case strings.HasPrefix(key, "status-"):
+		return strings.TrimPrefix(key, "status-")
+	}
+	return ""
+}
+
+// restartRenewedPod deletes the first pod whose certificate was renewed,
+// once all pods with certificates are ready.
+func (r *Certificate) restartRenewedPod() error {
+	secret := &core.Secret{}
+	if err := r.client.Get(context.Background(), types.NamespacedName{Name: r.sc.Name(), Namespace: r.owner.GetNamespace()}, secret); err != nil {
+		return err
+	}
+	var renewed *core.Pod
+	for _, subject := range r.certificateSubjects {
+		if string(secret.Data[statusFileName(subject.ip)]) != renewedStatus {
+			continue
+		}
+		pod := &core.Pod{}
+		if err := r.client.Get(context.Background(), types.NamespacedName{Name: subject.name, Namespace: r.owner.GetNamespace()}, pod); err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return err
+		}
+		if pod.Status.PodIP == subject.ip && renewed == nil {
+			renewed = pod
+		}
+	}
+	if renewed == nil {
 		return nil
 	}
-	certificateTemplate, privateKey, err := subject.generateCertificateTemplate()
-	if err != nil {
-		return fmt.Errorf("failed to generate certificate template for %s, %s: %w", subject.hostname, subject.name, err)
+	for _, subject := range r.certificateSubjects {
+		pod := &core.Pod{}
+		if err := r.client.Get(context.Background(), types.NamespacedName{Name: subject.name, Namespace: r.owner.GetNamespace()}, pod); err != nil {
+			return client.IgnoreNotFound(err)
+		}
+		if pod.DeletionTimestamp != nil || !podReady(pod) {
+			return nil
+		}
 	}
+	if err := r.client.Delete(context.Background(), renewed); err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	secret.Data[statusFileName(renewed.Status.PodIP)] = []byte(approvedStatus)
+	return r.client.Update(context.Background(), secret)
+}
 
-	certBytes, err := r.signer.SignCertificate(certificateTemplate, *privateKey)
-	if err != nil {
-		return fmt.Errorf("failed to sign certificate for %s, %s: %w", subject.hostname, subject.name, err)
+// updateCABundleHash stores the hash of the CA bundle in the secret and tells
+// whether the bundle changed since certificates in the secret were issued.
+func updateCABundleHash(secret *core.Secret, bundle []byte) bool {
+	sum := sha256.Sum256(bundle)
+	hash := hex.EncodeToString(sum[:])
+	previous, found := secret.Annotations[caBundleHashAnnotation]
+	if secret.Annotations == nil {
+		secret.Annotations = map[string]string{}
 	}
+	secret.Annotations[caBundleHashAnnotation] = hash
+	return found && previous != hash
+}
 
-	certPrivKeyPem, err := encodeInPemFormat(x509.MarshalPKCS1PrivateKey(privateKey), privateKeyPemType)
-	secret.Data[serverPrivateKeyFileName(subject.ip)] = certPrivKeyPem
-	secret.Data[serverCertificateFileName(subject.ip)] = certBytes
-	secret.Data["status-"+subject.ip] = []byte("Approved")
-	return nil
+func podReady(pod *core.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == core.PodReady {
+			return condition.Status == core.ConditionTrue
+		}
+	}
+	return false
 }
 
 func certInSecret(secret *core.Secret, podIP string) bool {
@@ -103,3 +257,7 @@ func serverPrivateKeyFileName(ip string) string {
 func serverCertificateFileName(ip string) string {
 	return fmt.Sprintf("server-%s.crt", ip)
 }
+
+func statusFileName(ip string) string {
+	return "status-" + ip
+}
@@ -1,13 +1,11 @@
 package certificates
 
 import (
-	"crypto/rand"
-	"crypto/rsa"
+	"crypto"
 	"crypto/x509"
 	"crypto/x509/pkix"
 	"fmt"
 	"net"
-	"time"
 )
 
 type CertificateSubject struct {
@@ -21,15 +19,15 @@ func NewSubject(name string, hostname string, ip string, alternativeIPs []string
 	return CertificateSubject{name: name, hostname: hostname, ip: ip, alternativeIPs: alternativeIPs}
 }
 
-func (c CertificateSubject) generateCertificateTemplate() (x509.Certificate, *rsa.PrivateKey, error) {
-	certPrivKey, err := rsa.GenerateKey(rand.Reader, certKeyLength)
+func (c CertificateSubject) generateCertificateTemplate(key KeyConfig) (x509.Certificate, crypto.Signer, error) {
+	certPrivKey, err := key.generateKey()
 
 	if err != nil {
 		return x509.Certificate{}, nil, fmt.Errorf("failed to generate private key: %w", err)
 	}
 
-	notBefore := time.Now()
-	notAfter := notBefore.Add(certValidityPeriod)
+	notBefore := now()
+	notAfter := notBefore.Add(CertValidityPeriod)
 
 	serialNumber, err := generateSerialNumber()
 	if err != nil {
@@ -56,7 +54,7 @@ func (c CertificateSubject) generateCertificateTemplate() (x509.Certificate, *rs
 		IPAddresses: ips,
 		NotBefore:   notBefore,
 		NotAfter:    notAfter,
-		KeyUsage:    x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature,
+		KeyUsage:    key.keyUsage(),
 		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
 	}
 
@@ -68,7 +68,7 @@ func TestSubjectGenerateCertificateTemplate(t *testing.T) {
 	}
 
 	for _, test := range tests {
-		cert, _, err := test.subject.generateCertificateTemplate()
+		cert, _, err := test.subject.generateCertificateTemplate(KeyConfig{})
 		assert.NoError(t, err)
 		assertCertificatesEqual(t, test.expectedCert, cert)
 	}
@@ -1,8 +1,8 @@
 package certificates
 
 import (
+	"crypto"
 	"crypto/rand"
-	"crypto/rsa"
 	"crypto/x509"
 	"crypto/x509/pkix"
 	"fmt"
@@ -10,23 +10,30 @@ import (
 	"time"
 )
 
-const (
-	caCommonName         = "contrail-signer"
-	caCertValidityPeriod = 10 * 365 * 24 * time.Hour // 10 years
-	certValidityPeriod   = 10 * 365 * 24 * time.Hour // 10 years
-	caCertKeyLength      = 2048
-	certKeyLength        = 2048
+const caCommonName = "contrail-signer"
+
+// Validity periods of the CA and pod certificates, the part of their lifetime
+// after which they are renewed and how long a new CA is published next to the
+// old one before it starts signing certificates. They are set from the operator
+// command line.
+var (
+	CACertValidityPeriod = 10 * 365 * 24 * time.Hour // 10 years
+	CertValidityPeriod   = 10 * 365 * 24 * time.Hour // 10 years
+	RenewalThreshold     = 0.8
+	CARolloverOverlap    = 7 * 24 * time.Hour // 7 days
 )
 
-func generateCaCertificateTemplate() (x509.Certificate, *rsa.PrivateKey, error) {
-	caPrivKey, err := rsa.GenerateKey(rand.Reader, caCertKeyLength)
+var now = time.Now
+
+func generateCaCertificateTemplate(key KeyConfig) (x509.Certificate, crypto.Signer, error) {
+	caPrivKey, err := key.generateKey()
 
 	if err != nil {
 		return x509.Certificate{}, nil, fmt.Errorf("failed to generate private key: %w", err)
 	}
 
-	notBefore := time.Now()
-	notAfter := notBefore.Add(caCertValidityPeriod)
+	notBefore := now()
+	notAfter := notBefore.Add(CACertValidityPeriod)
 
 	serialNumber, err := generateSerialNumber()
 	if err != nil {
@@ -47,12 +54,19 @@ func generateCaCertificateTemplate() (x509.Certificate, *rsa.PrivateKey, error)
 		},
 		NotBefore: notBefore,
 		NotAfter:  notAfter,
-		KeyUsage:  x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		KeyUsage:  key.keyUsage() | x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
 	}
 	return caCertTemplate, caPrivKey, nil
 
 }
 
+// dueForRenewal tells whether the certificate passed the RenewalThreshold of its lifetime.
+func dueForRenewal(cert *x509.Certificate) bool {
+	lifetime := cert.NotAfter.Sub(cert.NotBefore)
+	renewAt := cert.NotBefore.Add(time.Duration(float64(lifetime) * RenewalThreshold))
+	return !now().Before(renewAt)
+}
+
 func generateSerialNumber() (*big.Int, error) {
 	serialNumberLimit := new(big.Int).Lsh(big.NewInt(1), 128)
 	return rand.Int(rand.Reader, serialNumberLimit)
@@ -2,33 +2,42 @@ package certificates
 
 import (
 	"context"
-	"crypto/rsa"
+	"crypto"
 	"crypto/x509"
+	"encoding/pem"
 	"errors"
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	core "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
 	"github.com/Juniper/contrail-operator/pkg/k8s"
 )
 
 type signerSpy struct {
-	data map[string]rsa.PrivateKey
+	data map[string]crypto.Signer
 	err  error
 }
 
-func (s *signerSpy) SignCertificate(certTemplate x509.Certificate, privateKey rsa.PrivateKey) ([]byte, error) {
+func (s *signerSpy) SignCertificate(certTemplate x509.Certificate, privateKey crypto.Signer) ([]byte, error) {
 	s.data[certTemplate.Subject.CommonName] = privateKey
 	return []byte(certTemplate.Subject.CommonName), s.err
 }
 
+func (s *signerSpy) CACertificates() (*x509.Certificate, []byte, error) {
+	return nil, nil, nil
+}
+
 func TestCertificate(t *testing.T) {
 	const (
 		testOwnerName      = "testName"
@@ -104,13 +113,13 @@ func TestCertificate(t *testing.T) {
 		t.Run(test.name, func(t *testing.T) {
 			cl := fake.NewFakeClientWithScheme(scheme)
 			sc := k8s.New(cl, scheme).Secret(secretName, ownerType, owner)
-			signerSpy := &signerSpy{data: map[string]rsa.PrivateKey{}, err: test.signerError}
+			signerSpy := &signerSpy{data: map[string]crypto.Signer{}, err: test.signerError}
 			crt := Certificate{
 				client:              cl,
 				scheme:              scheme,
 				owner:               owner,
 				sc:                  sc,
-				signer:              signerSpy,
+				issuer:              &caIssuer{signer: signerSpy},
 				certificateSubjects: test.certificateSubjects,
 			}
 			err := crt.EnsureExistsAndIsSigned()
@@ -137,7 +146,7 @@ func getExpectedCertificates(t *testing.T, spy *signerSpy, expectedSubjects []Ce
 	for _, sub := range expectedSubjects {
 		privateKey, ok := spy.data[sub.ip]
 		assert.Truef(t, ok, "subject % was not passed to signer", sub)
-		certPrivKeyPem, err := encodeInPemFormat(x509.MarshalPKCS1PrivateKey(&privateKey), privateKeyPemType)
+		certPrivKeyPem, err := encodePrivateKey(privateKey)
 		assert.NoError(t, err, "private key generated for % is incorect", sub)
 		expectedCerts["server-key-"+sub.ip+".pem"] = certPrivKeyPem
 		expectedCerts["server-"+sub.ip+".crt"] = []byte(sub.ip)
@@ -145,3 +154,218 @@ func getExpectedCertificates(t *testing.T, spy *signerSpy, expectedSubjects []Ce
 	}
 	return expectedCerts
 }
+
+func TestCertificateRenewal(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, core.SchemeBuilder.AddToScheme(scheme))
+	owner := &core.Pod{ObjectMeta: meta.ObjectMeta{Name: "owner", UID: "ownerUID", Namespace: "default"}}
+	newPod := func(name, ip string) *core.Pod {
+		return &core.Pod{
+			ObjectMeta: meta.ObjectMeta{Name: name, Namespace: "default"},
+			Status: core.PodStatus{
+				PodIP:      ip,
+				Conditions: []core.PodCondition{{Type: core.PodReady, Status: core.ConditionTrue}},
+			},
+		}
+	}
+	subjects := []CertificateSubject{NewSubject("pod-0", "host-0", "10.0.0.1", nil), NewSubject("pod-1", "host-1", "10.0.0.2", nil)}
+	restoreNow := now
+	defer func() { now = restoreNow }()
+	later := func(d time.Duration) { now = func() time.Time { return restoreNow().Add(d) } }
+
+	cl := fake.NewFakeClientWithScheme(scheme, newPod("pod-0", "10.0.0.1"), newPod("pod-1", "10.0.0.2"))
+	require.NoError(t, NewCACertificate(cl, scheme, owner, "ownerType", KeyConfig{}).EnsureExists())
+	crt := NewCertificate(cl, scheme, owner, subjects, "ownerType", KeyConfig{})
+	getSecret := func() *core.Secret {
+		secret := &core.Secret{}
+		require.NoError(t, cl.Get(context.Background(), types.NamespacedName{Name: "owner-secret-certificates", Namespace: "default"}, secret))
+		return secret
+	}
+	podExists := func(name string) bool {
+		return cl.Get(context.Background(), types.NamespacedName{Name: name, Namespace: "default"}, &core.Pod{}) == nil
+	}
+	require.NoError(t, crt.EnsureExistsAndIsSigned())
+	issued := getSecret().Data["server-10.0.0.1.crt"]
+
+	t.Run("should keep certificates before renewal threshold", func(t *testing.T) {
+		later(CertValidityPeriod / 2)
+		// when
+		require.NoError(t, crt.EnsureExistsAndIsSigned())
+		// then
+		secret := getSecret()
+		assert.Equal(t, issued, secret.Data["server-10.0.0.1.crt"])
+		assert.Equal(t, "Approved", string(secret.Data["status-10.0.0.1"]))
+		assert.True(t, podExists("pod-0"))
+		assert.True(t, podExists("pod-1"))
+	})
+
+	t.Run("should renew certificates and restart one pod at a time", func(t *testing.T) {
+		later(CertValidityPeriod * 9 / 10)
+		// when
+		require.NoError(t, crt.EnsureExistsAndIsSigned())
+		// then
+		secret := getSecret()
+		assert.NotEqual(t, issued, secret.Data["server-10.0.0.1.crt"])
+		cert, err := parseCertificate(secret.Data, "server-10.0.0.1.crt")
+		require.NoError(t, err)
+		assert.Equal(t, now().Add(CertValidityPeriod).Unix(), cert.NotAfter.Unix())
+		assert.False(t, podExists("pod-0"))
+		assert.True(t, podExists("pod-1"))
+		assert.Equal(t, "Approved", string(secret.Data["status-10.0.0.1"]))
+		assert.Equal(t, "Renewed", string(secret.Data["status-10.0.0.2"]))
+
+		// when
+		require.NoError(t, crt.EnsureExistsAndIsSigned())
+		// then
+		assert.True(t, podExists("pod-1"))
+
+		// when
+		require.NoError(t, cl.Create(context.Background(), newPod("pod-0", "10.0.0.1")))
+		require.NoError(t, crt.EnsureExistsAndIsSigned())
+		// then
+		assert.False(t, podExists("pod-1"))
+		assert.Equal(t, "Approved", string(getSecret().Data["status-10.0.0.2"]))
+	})
+
+	t.Run("should restart pods when CA bundle changes", func(t *testing.T) {
+		require.NoError(t, cl.Create(context.Background(), newPod("pod-1", "10.0.0.2")))
+		later(CACertValidityPeriod * 9 / 10)
+		require.NoError(t, NewCACertificate(cl, scheme, owner, "ownerType", KeyConfig{}).EnsureExists())
+		// when
+		require.NoError(t, crt.EnsureExistsAndIsSigned())
+		// then
+		secret := getSecret()
+		assert.False(t, podExists("pod-0"))
+		assert.Equal(t, "Approved", string(secret.Data["status-10.0.0.1"]))
+		assert.Equal(t, "Renewed", string(secret.Data["status-10.0.0.2"]))
+	})
+}
+
+func TestStaleCertificatesPruning(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, core.SchemeBuilder.AddToScheme(scheme))
+	owner := &core.Pod{ObjectMeta: meta.ObjectMeta{Name: "owner", UID: "ownerUID", Namespace: "default"}}
+	restorePublishCRL := PublishCRL
+	defer func() { PublishCRL = restorePublishCRL }()
+	getSecret := func(cl client.Client) *core.Secret {
+		secret := &core.Secret{}
+		require.NoError(t, cl.Get(context.Background(), types.NamespacedName{Name: "owner-secret-certificates", Namespace: "default"}, secret))
+		return secret
+	}
+	issue := func(cl client.Client, ips ...string) {
+		var subjects []CertificateSubject
+		for i, ip := range ips {
+			subjects = append(subjects, NewSubject(fmt.Sprintf("pod-%d", i), "host", ip, nil))
+		}
+		require.NoError(t, NewCertificate(cl, scheme, owner, subjects, "ownerType", KeyConfig{}).EnsureExistsAndIsSigned())
+	}
+
+	t.Run("should remove certificates of IPs which no longer belong to pods", func(t *testing.T) {
+		PublishCRL = false
+		cl := fake.NewFakeClientWithScheme(scheme)
+		require.NoError(t, NewCACertificate(cl, scheme, owner, "ownerType", KeyConfig{}).EnsureExists())
+		issue(cl, "10.0.0.1", "10.0.0.2")
+		// when
+		issue(cl, "10.0.0.1", "10.0.0.3")
+		// then
+		secret := getSecret(cl)
+		assert.Contains(t, secret.Data, "server-10.0.0.1.crt")
+		assert.Contains(t, secret.Data, "server-10.0.0.3.crt")
+		assert.NotContains(t, secret.Data, "server-10.0.0.2.crt")
+		assert.NotContains(t, secret.Data, "server-key-10.0.0.2.pem")
+		assert.NotContains(t, secret.Data, "status-10.0.0.2")
+		assert.True(t, k8serrors.IsNotFound(cl.Get(context.Background(), types.NamespacedName{Name: RevocationsConfigMapName, Namespace: "default"}, &core.ConfigMap{})))
+	})
+
+	t.Run("should revoke removed certificates", func(t *testing.T) {
+		PublishCRL = true
+		cl := fake.NewFakeClientWithScheme(scheme)
+		caCertificate := NewCACertificate(cl, scheme, owner, "ownerType", KeyConfig{})
+		require.NoError(t, caCertificate.EnsureExists())
+		issue(cl, "10.0.0.1", "10.0.0.2")
+		removed, err := parseCertificate(getSecret(cl).Data, "server-10.0.0.2.crt")
+		require.NoError(t, err)
+		// when
+		issue(cl, "10.0.0.1")
+		// then
+		crlPem, err := caCertificate.GetCRL()
+		require.NoError(t, err)
+		block, _ := pem.Decode(crlPem)
+		require.NotNil(t, block)
+		crl, err := x509.ParseRevocationList(block.Bytes)
+		require.NoError(t, err)
+		caCert, err := parseCertificate(getCASecret(t, cl).Data, SignerCAFilename)
+		require.NoError(t, err)
+		assert.NoError(t, crl.CheckSignatureFrom(caCert))
+		require.Len(t, crl.RevokedCertificateEntries, 1)
+		assert.Equal(t, removed.SerialNumber, crl.RevokedCertificateEntries[0].SerialNumber)
+
+		// when
+		again, err := caCertificate.GetCRL()
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, crlPem, again)
+	})
+
+	t.Run("should keep certificates while a pod has no IP", func(t *testing.T) {
+		PublishCRL = true
+		cl := fake.NewFakeClientWithScheme(scheme)
+		require.NoError(t, NewCACertificate(cl, scheme, owner, "ownerType", KeyConfig{}).EnsureExists())
+		issue(cl, "10.0.0.1", "10.0.0.2")
+		subjects := []CertificateSubject{
+			NewSubject("pod-0", "host", "10.0.0.1", nil),
+			NewSubject("pod-1", "host", "", nil),
+		}
+		// when
+		err := NewCertificate(cl, scheme, owner, subjects, "ownerType", KeyConfig{}).EnsureExistsAndIsSigned()
+		// then
+		assert.EqualError(t, err, "pod-1 subject IP still no available")
+		secret := getSecret(cl)
+		assert.Contains(t, secret.Data, "server-10.0.0.2.crt")
+		assert.Contains(t, secret.Data, "server-key-10.0.0.2.pem")
+		assert.True(t, k8serrors.IsNotFound(cl.Get(context.Background(), types.NamespacedName{Name: RevocationsConfigMapName, Namespace: "default"}, &core.ConfigMap{})))
+		// when
+		issue(cl, "10.0.0.1", "10.0.0.2")
+		// then
+		assert.Equal(t, secret.Data["server-10.0.0.2.crt"], getSecret(cl).Data["server-10.0.0.2.crt"])
+		assert.True(t, k8serrors.IsNotFound(cl.Get(context.Background(), types.NamespacedName{Name: RevocationsConfigMapName, Namespace: "default"}, &core.ConfigMap{})))
+	})
+
+	t.Run("should keep certificates until they are revoked", func(t *testing.T) {
+		PublishCRL = true
+		cl := fake.NewFakeClientWithScheme(scheme)
+		require.NoError(t, NewCACertificate(cl, scheme, owner, "ownerType", KeyConfig{}).EnsureExists())
+		issue(cl, "10.0.0.1", "10.0.0.2")
+		subjects := []CertificateSubject{NewSubject("pod-0", "host", "10.0.0.1", nil)}
+		failing := &configMapCreateFailingClient{Client: cl}
+		// when
+		err := NewCertificate(failing, scheme, owner, subjects, "ownerType", KeyConfig{}).EnsureExistsAndIsSigned()
+		// then
+		assert.EqualError(t, err, "failed to revoke stale certificates: create failed")
+		assert.Contains(t, getSecret(cl).Data, "server-10.0.0.2.crt")
+		// when
+		issue(cl, "10.0.0.1")
+		// then
+		assert.NotContains(t, getSecret(cl).Data, "server-10.0.0.2.crt")
+		revocations := &core.ConfigMap{}
+		require.NoError(t, cl.Get(context.Background(), types.NamespacedName{Name: RevocationsConfigMapName, Namespace: "default"}, revocations))
+		assert.Len(t, revocations.Data, 1)
+	})
+}
+
+type configMapCreateFailingClient struct {
+	client.Client
+}
+
+func (c *configMapCreateFailingClient) Create(ctx context.Context, obj runtime.Object, opts ...client.CreateOption) error {
+	if _, ok := obj.(*core.ConfigMap); ok {
+		return errors.New("create failed")
+	}
+	return c.Client.Create(ctx, obj, opts...)
+}
+
+func getCASecret(t *testing.T, cl client.Client) *core.Secret {
+	secret := &core.Secret{}
+	require.NoError(t, cl.Get(context.Background(), types.NamespacedName{Name: caSecretName, Namespace: "default"}, secret))
+	return secret
+}
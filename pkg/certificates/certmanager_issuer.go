@@ -0,0 +1,99 @@
+package certificates
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// certManagerIssuer requests certificates of subjects with cert-manager Certificate
+// resources issued by the CertManagerIssuerName issuer. Cert-manager renews them
+// on its own, so the issuer only copies the certificate from the secret it issues.
+type certManagerIssuer struct {
+	client client.Client
+	scheme *runtime.Scheme
+	owner  metav1.Object
+	key    KeyConfig
+}
+
+func (i *certManagerIssuer) Issue(subject CertificateSubject, _, _ []byte) ([]byte, []byte, error) {
+	name := subject.name + "-certificate"
+	certificate := &unstructured.Unstructured{}
+	certificate.SetAPIVersion("cert-manager.io/v1")
+	certificate.SetKind("Certificate")
+	certificate.SetName(name)
+	certificate.SetNamespace(i.owner.GetNamespace())
+	_, err := controllerutil.CreateOrUpdate(context.Background(), i.client, certificate, func() error {
+		if err := unstructured.SetNestedField(certificate.Object, i.certificateSpec(subject, name), "spec"); err != nil {
+			return err
+		}
+		return controllerutil.SetControllerReference(i.owner, certificate, i.scheme)
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to request certificate %s from cert-manager: %w", name, err)
+	}
+
+	secret := &corev1.Secret{}
+	err = i.client.Get(context.Background(), types.NamespacedName{Name: name, Namespace: i.owner.GetNamespace()}, secret)
+	if k8serrors.IsNotFound(err) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return secret.Data[corev1.TLSCertKey], secret.Data[corev1.TLSPrivateKeyKey], nil
+}
+
+func (i *certManagerIssuer) CABundle() ([]byte, error) {
+	secret, err := getExternalCASecret(i.client, i.owner.GetNamespace())
+	if k8serrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get secret %s with ca: %w", ExternalCASecretName, err)
+	}
+	return externalCABundle(secret.Data), nil
+}
+
+func (i *certManagerIssuer) certificateSpec(subject CertificateSubject, secretName string) map[string]interface{} {
+	ipAddresses := []interface{}{subject.ip}
+	for _, ip := range subject.alternativeIPs {
+		ipAddresses = append(ipAddresses, ip)
+	}
+	renewBefore := time.Duration(float64(CertValidityPeriod) * (1 - RenewalThreshold))
+	return map[string]interface{}{
+		"secretName":  secretName,
+		"commonName":  subject.ip,
+		"dnsNames":    []interface{}{subject.hostname},
+		"ipAddresses": ipAddresses,
+		"duration":    CertValidityPeriod.String(),
+		"renewBefore": renewBefore.String(),
+		"subject": map[string]interface{}{
+			"countries":           []interface{}{"US"},
+			"provinces":           []interface{}{"CA"},
+			"localities":          []interface{}{"Sunnyvale"},
+			"organizations":       []interface{}{"Juniper Networks"},
+			"organizationalUnits": []interface{}{"Contrail"},
+		},
+		"privateKey": map[string]interface{}{
+			"algorithm": string(i.key.algorithm()),
+			"encoding":  "PKCS1",
+			"size":      int64(i.key.size()),
+		},
+		"usages": []interface{}{"digital signature", "key encipherment", "server auth", "client auth"},
+		"issuerRef": map[string]interface{}{
+			"name":  CertManagerIssuerName,
+			"kind":  CertManagerIssuerKind,
+			"group": "cert-manager.io",
+		},
+	}
+}
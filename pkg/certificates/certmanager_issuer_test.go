@@ -0,0 +1,84 @@
+package certificates
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestCertManagerIssuer(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, core.SchemeBuilder.AddToScheme(scheme))
+	owner := &core.Pod{ObjectMeta: meta.ObjectMeta{Name: "owner", UID: "ownerUID", Namespace: "default"}}
+	restoreIssuer := CertificateIssuer
+	defer func() { CertificateIssuer = restoreIssuer }()
+	require.NoError(t, UseIssuer(CertManagerIssuer))
+	externalCA := &core.Secret{
+		ObjectMeta: meta.ObjectMeta{Name: ExternalCASecretName, Namespace: "default"},
+		Data:       map[string][]byte{core.TLSCertKey: []byte("ca"), rootCAKey: []byte("root-ca")},
+	}
+	cl := fake.NewFakeClientWithScheme(scheme, externalCA)
+	crt := NewCertificate(cl, scheme, owner, []CertificateSubject{NewSubject("pod-0", "host-0", "10.0.0.1", []string{"10.0.0.100"})}, "ownerType", KeyConfig{})
+	getSecret := func() *core.Secret {
+		secret := &core.Secret{}
+		require.NoError(t, cl.Get(context.Background(), types.NamespacedName{Name: "owner-secret-certificates", Namespace: "default"}, secret))
+		return secret
+	}
+
+	t.Run("should request certificate from cert-manager", func(t *testing.T) {
+		// when
+		err := crt.EnsureExistsAndIsSigned()
+		// then
+		assert.EqualError(t, err, "certificates of pod-0 are not issued yet")
+		certificate := &unstructured.Unstructured{}
+		certificate.SetAPIVersion("cert-manager.io/v1")
+		certificate.SetKind("Certificate")
+		require.NoError(t, cl.Get(context.Background(), types.NamespacedName{Name: "pod-0-certificate", Namespace: "default"}, certificate))
+		spec, _, _ := unstructured.NestedMap(certificate.Object, "spec")
+		assert.Equal(t, "pod-0-certificate", spec["secretName"])
+		assert.Equal(t, []interface{}{"10.0.0.1", "10.0.0.100"}, spec["ipAddresses"])
+		assert.Equal(t, []interface{}{"host-0"}, spec["dnsNames"])
+		assert.Equal(t, map[string]interface{}{"name": "contrail-issuer", "kind": "Issuer", "group": "cert-manager.io"}, spec["issuerRef"])
+		assert.Equal(t, "owner", certificate.GetOwnerReferences()[0].Name)
+	})
+
+	t.Run("should copy certificate issued by cert-manager", func(t *testing.T) {
+		issued := &core.Secret{
+			ObjectMeta: meta.ObjectMeta{Name: "pod-0-certificate", Namespace: "default"},
+			Data:       map[string][]byte{core.TLSCertKey: []byte("cert"), core.TLSPrivateKeyKey: []byte("key")},
+		}
+		require.NoError(t, cl.Create(context.Background(), issued))
+		// when
+		require.NoError(t, crt.EnsureExistsAndIsSigned())
+		// then
+		secret := getSecret()
+		assert.Equal(t, []byte("cert"), secret.Data["server-10.0.0.1.crt"])
+		assert.Equal(t, []byte("key"), secret.Data["server-key-10.0.0.1.pem"])
+		assert.Equal(t, "Approved", string(secret.Data["status-10.0.0.1"]))
+		bundle, err := NewCACertificate(cl, scheme, owner, "ownerType", KeyConfig{}).GetCaCert()
+		require.NoError(t, err)
+		assert.Equal(t, []byte("caroot-ca"), bundle)
+	})
+
+	t.Run("should mark certificate renewed by cert-manager", func(t *testing.T) {
+		issued := &core.Secret{}
+		require.NoError(t, cl.Get(context.Background(), types.NamespacedName{Name: "pod-0-certificate", Namespace: "default"}, issued))
+		issued.Data[core.TLSCertKey] = []byte("renewed-cert")
+		require.NoError(t, cl.Update(context.Background(), issued))
+		// when
+		require.NoError(t, crt.EnsureExistsAndIsSigned())
+		// then
+		secret := getSecret()
+		assert.Equal(t, []byte("renewed-cert"), secret.Data["server-10.0.0.1.crt"])
+		assert.Equal(t, "Renewed", string(secret.Data["status-10.0.0.1"]))
+	})
+}
@@ -0,0 +1,76 @@
+package certificates
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/x509"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// rootCAKey is the key of the root CA certificate in secrets with a CA.
+const rootCAKey = "ca.crt"
+
+// externalSigner signs certificates with the CA key pair supplied by the
+// administrator in the ExternalCASecretName secret.
+type externalSigner struct {
+	client client.Client
+	owner  metav1.Object
+}
+
+func (s *externalSigner) SignCertificate(certTemplate x509.Certificate, privateKey crypto.Signer) ([]byte, error) {
+	secret, err := getExternalCASecret(s.client, s.owner.GetNamespace())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get secret %s with external ca: %w", ExternalCASecretName, err)
+	}
+	caCert, err := parseCertificate(secret.Data, corev1.TLSCertKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse external ca cert: %w", err)
+	}
+	caCertPrivKeyPemBlock, err := getAndDecodePem(secret.Data, corev1.TLSPrivateKeyKey)
+	if err != nil || caCertPrivKeyPemBlock == nil {
+		return nil, fmt.Errorf("failed to decode external ca priv key pem: %v", err)
+	}
+	caCertPrivKey, err := parsePrivateKey(caCertPrivKeyPemBlock.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse external ca priv key: %w", err)
+	}
+	return signWithCA(certTemplate, privateKey, caCert, caCertPrivKey)
+}
+
+func (s *externalSigner) CACertificates() (*x509.Certificate, []byte, error) {
+	secret, err := getExternalCASecret(s.client, s.owner.GetNamespace())
+	if k8serrors.IsNotFound(err) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get secret %s with external ca: %w", ExternalCASecretName, err)
+	}
+	caCert, err := parseCertificate(secret.Data, corev1.TLSCertKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse external ca cert: %w", err)
+	}
+	return caCert, externalCABundle(secret.Data), nil
+}
+
+func getExternalCASecret(cl client.Client, namespace string) (*corev1.Secret, error) {
+	secret := &corev1.Secret{}
+	err := cl.Get(context.Background(), types.NamespacedName{Name: ExternalCASecretName, Namespace: namespace}, secret)
+	return secret, err
+}
+
+// externalCABundle concatenates the CA certificate with the root CA
+// certificate when the secret has one.
+func externalCABundle(data map[string][]byte) []byte {
+	bundle := data[corev1.TLSCertKey]
+	if rootCA := data[rootCAKey]; len(rootCA) > 0 && !bytes.Equal(rootCA, bundle) {
+		bundle = append(append([]byte{}, bundle...), rootCA...)
+	}
+	return bundle
+}
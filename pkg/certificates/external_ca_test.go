@@ -0,0 +1,83 @@
+package certificates
+
+import (
+	"context"
+	"crypto/x509"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestExternalCAIssuer(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, core.SchemeBuilder.AddToScheme(scheme))
+	owner := &core.Pod{ObjectMeta: meta.ObjectMeta{Name: "owner", UID: "ownerUID", Namespace: "default"}}
+	restoreIssuer := CertificateIssuer
+	defer func() { CertificateIssuer = restoreIssuer }()
+	require.NoError(t, UseIssuer(ExternalCAIssuer))
+	caCert, caKey, err := generateCaCertificate(KeyConfig{})
+	require.NoError(t, err)
+	subjects := []CertificateSubject{NewSubject("pod-0", "host-0", "10.0.0.1", nil)}
+
+	t.Run("should report missing external CA", func(t *testing.T) {
+		cl := fake.NewFakeClientWithScheme(scheme)
+		// when
+		err := NewCACertificate(cl, scheme, owner, "ownerType", KeyConfig{}).EnsureExists()
+		// then
+		assert.Error(t, err)
+	})
+
+	t.Run("should sign certificates with external CA", func(t *testing.T) {
+		externalCA := &core.Secret{
+			ObjectMeta: meta.ObjectMeta{Name: ExternalCASecretName, Namespace: "default"},
+			Type:       core.SecretTypeTLS,
+			Data:       map[string][]byte{core.TLSCertKey: caCert, core.TLSPrivateKeyKey: caKey},
+		}
+		cl := fake.NewFakeClientWithScheme(scheme, externalCA)
+		caCertificate := NewCACertificate(cl, scheme, owner, "ownerType", KeyConfig{})
+		require.NoError(t, caCertificate.EnsureExists())
+		// when
+		require.NoError(t, NewCertificate(cl, scheme, owner, subjects, "ownerType", KeyConfig{}).EnsureExistsAndIsSigned())
+		// then
+		secret := &core.Secret{}
+		require.NoError(t, cl.Get(context.Background(), types.NamespacedName{Name: "owner-secret-certificates", Namespace: "default"}, secret))
+		cert, err := parseCertificate(secret.Data, "server-10.0.0.1.crt")
+		require.NoError(t, err)
+		roots := x509.NewCertPool()
+		bundle, err := caCertificate.GetCaCert()
+		require.NoError(t, err)
+		assert.Equal(t, caCert, bundle)
+		require.True(t, roots.AppendCertsFromPEM(bundle))
+		_, err = cert.Verify(x509.VerifyOptions{Roots: roots, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}})
+		assert.NoError(t, err)
+		assert.True(t, errors.IsNotFound(cl.Get(context.Background(), types.NamespacedName{Name: caSecretName, Namespace: "default"}, &core.Secret{})))
+	})
+
+	t.Run("should accept PKCS#8 key of external CA", func(t *testing.T) {
+		block, err := getAndDecodePem(map[string][]byte{"key": caKey}, "key")
+		require.NoError(t, err)
+		key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		require.NoError(t, err)
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		require.NoError(t, err)
+		pkcs8Key, err := encodeInPemFormat(der, "PRIVATE KEY")
+		require.NoError(t, err)
+		externalCA := &core.Secret{
+			ObjectMeta: meta.ObjectMeta{Name: ExternalCASecretName, Namespace: "default"},
+			Data:       map[string][]byte{core.TLSCertKey: caCert, core.TLSPrivateKeyKey: pkcs8Key},
+		}
+		cl := fake.NewFakeClientWithScheme(scheme, externalCA)
+		// when
+		err = NewCertificate(cl, scheme, owner, subjects, "ownerType", KeyConfig{}).EnsureExistsAndIsSigned()
+		// then
+		assert.NoError(t, err)
+	})
+}
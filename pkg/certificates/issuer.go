@@ -0,0 +1,123 @@
+package certificates
+
+import (
+	"crypto"
+	"crypto/x509"
+	"fmt"
+
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// IssuerType selects how certificates of pods are issued.
+type IssuerType string
+
+const (
+	// CAIssuer signs certificates with the contrail-signer CA generated by the operator.
+	CAIssuer IssuerType = "ca"
+	// ExternalCAIssuer signs certificates with the CA key pair from the ExternalCASecretName secret.
+	ExternalCAIssuer IssuerType = "external-ca"
+	// CertManagerIssuer requests certificates from cert-manager.
+	CertManagerIssuer IssuerType = "cert-manager"
+)
+
+// Issuer of certificates and its settings. They are set from the operator command line.
+var (
+	CertificateIssuer = CAIssuer
+	// ExternalCASecretName is the name of the kubernetes.io/tls secret with the CA
+	// certificate (tls.crt), its key (tls.key) and optionally the root CA (ca.crt).
+	// It signs certificates of the ExternalCAIssuer and is published as the CA bundle
+	// of the CertManagerIssuer, where the key is not needed.
+	ExternalCASecretName  = "contrail-external-ca"
+	CertManagerIssuerName = "contrail-issuer"
+	CertManagerIssuerKind = "Issuer"
+)
+
+// UseIssuer sets the CertificateIssuer.
+func UseIssuer(issuerType IssuerType) error {
+	switch issuerType {
+	case CAIssuer, ExternalCAIssuer, CertManagerIssuer:
+		CertificateIssuer = issuerType
+		return nil
+	}
+	return fmt.Errorf("unknown certificate issuer %q, expected one of %s, %s, %s", issuerType, CAIssuer, ExternalCAIssuer, CertManagerIssuer)
+}
+
+// issuer issues certificates of subjects.
+type issuer interface {
+	// Issue returns PEM encoded certificate and private key of the subject. The current
+	// ones are returned when they need no renewal and nil when the certificate is being
+	// issued.
+	Issue(subject CertificateSubject, currentCert, currentKey []byte) ([]byte, []byte, error)
+	// CABundle returns CA certificates trusted by pods or nil when the CA does not exist yet.
+	CABundle() ([]byte, error)
+}
+
+func newIssuer(cl client.Client, scheme *runtime.Scheme, owner v1.Object, key KeyConfig) issuer {
+	switch CertificateIssuer {
+	case ExternalCAIssuer:
+		return &caIssuer{signer: &externalSigner{client: cl, owner: owner}, key: key}
+	case CertManagerIssuer:
+		return &certManagerIssuer{client: cl, scheme: scheme, owner: owner, key: key}
+	}
+	return &caIssuer{signer: &signer{client: cl, owner: owner}, key: key}
+}
+
+type certificateSigner interface {
+	SignCertificate(certTemplate x509.Certificate, privateKey crypto.Signer) ([]byte, error)
+	// CACertificates returns the signing CA certificate and the bundle of CA certificates
+	// trusted by pods or nil when the CA does not exist yet.
+	CACertificates() (*x509.Certificate, []byte, error)
+}
+
+// caIssuer generates private keys of subjects and signs their certificates with a CA.
+type caIssuer struct {
+	signer certificateSigner
+	key    KeyConfig
+}
+
+func (i *caIssuer) Issue(subject CertificateSubject, currentCert, currentKey []byte) ([]byte, []byte, error) {
+	caCert, _, err := i.signer.CACertificates()
+	if err != nil {
+		return nil, nil, err
+	}
+	if currentCert != nil && currentKey != nil && !certNeedsRenewal(currentCert, caCert, i.key) {
+		return currentCert, currentKey, nil
+	}
+
+	certificateTemplate, privateKey, err := subject.generateCertificateTemplate(i.key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate certificate template for %s, %s: %w", subject.hostname, subject.name, err)
+	}
+
+	certBytes, err := i.signer.SignCertificate(certificateTemplate, privateKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to sign certificate for %s, %s: %w", subject.hostname, subject.name, err)
+	}
+
+	certPrivKeyPem, err := encodePrivateKey(privateKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to encode private key with pem format: %w", err)
+	}
+	return certBytes, certPrivKeyPem, nil
+}
+
+func (i *caIssuer) CABundle() ([]byte, error) {
+	_, bundle, err := i.signer.CACertificates()
+	return bundle, err
+}
+
+// certNeedsRenewal tells whether the certificate cannot be parsed, is not signed
+// by the current CA, has a key other than selected or passed the RenewalThreshold.
+// Certificates are not renewed until the CA exists.
+func certNeedsRenewal(certPem []byte, caCert *x509.Certificate, key KeyConfig) bool {
+	if caCert == nil {
+		return false
+	}
+	cert, err := decodeCertificate(certPem)
+	if err != nil || cert.CheckSignatureFrom(caCert) != nil || !key.matches(cert.PublicKey) {
+		return true
+	}
+	return dueForRenewal(cert)
+}
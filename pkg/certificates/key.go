@@ -0,0 +1,108 @@
+package certificates
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"fmt"
+)
+
+// KeyAlgorithm is the algorithm of private keys of certificates.
+type KeyAlgorithm string
+
+const (
+	RSAKey   KeyAlgorithm = "RSA"
+	ECDSAKey KeyAlgorithm = "ECDSA"
+)
+
+const (
+	defaultRSAKeySize   = 2048
+	defaultECDSAKeySize = 256
+)
+
+// KeyConfig selects private keys of certificates. The zero value selects 2048 bit RSA keys.
+type KeyConfig struct {
+	Algorithm KeyAlgorithm
+	// Size is the length of RSA keys in bits or the size of the ECDSA curve (256, 384 or 521).
+	Size int
+}
+
+func (k KeyConfig) algorithm() KeyAlgorithm {
+	if k.Algorithm == "" {
+		return RSAKey
+	}
+	return k.Algorithm
+}
+
+func (k KeyConfig) size() int {
+	if k.Size != 0 {
+		return k.Size
+	}
+	if k.algorithm() == ECDSAKey {
+		return defaultECDSAKeySize
+	}
+	return defaultRSAKeySize
+}
+
+// Validate checks that the algorithm is known and the size fits the algorithm.
+func (k KeyConfig) Validate() error {
+	switch k.algorithm() {
+	case RSAKey:
+		if k.size() < 2048 {
+			return fmt.Errorf("RSA key size %d is shorter than 2048 bits", k.size())
+		}
+	case ECDSAKey:
+		if _, err := k.curve(); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown key algorithm %q, expected %s or %s", k.Algorithm, RSAKey, ECDSAKey)
+	}
+	return nil
+}
+
+func (k KeyConfig) curve() (elliptic.Curve, error) {
+	switch k.size() {
+	case 256:
+		return elliptic.P256(), nil
+	case 384:
+		return elliptic.P384(), nil
+	case 521:
+		return elliptic.P521(), nil
+	}
+	return nil, fmt.Errorf("ECDSA key size %d is not one of 256, 384 or 521", k.size())
+}
+
+func (k KeyConfig) generateKey() (crypto.Signer, error) {
+	if err := k.Validate(); err != nil {
+		return nil, err
+	}
+	if k.algorithm() == ECDSAKey {
+		curve, _ := k.curve()
+		return ecdsa.GenerateKey(curve, rand.Reader)
+	}
+	return rsa.GenerateKey(rand.Reader, k.size())
+}
+
+// keyUsage returns key usages of certificates with keys of the algorithm.
+// Only RSA keys can encipher keys.
+func (k KeyConfig) keyUsage() x509.KeyUsage {
+	if k.algorithm() == ECDSAKey {
+		return x509.KeyUsageDigitalSignature
+	}
+	return x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature
+}
+
+// matches tells whether the public key was generated with the config.
+func (k KeyConfig) matches(publicKey crypto.PublicKey) bool {
+	switch key := publicKey.(type) {
+	case *rsa.PublicKey:
+		return k.algorithm() == RSAKey && key.N.BitLen() == k.size()
+	case *ecdsa.PublicKey:
+		return k.algorithm() == ECDSAKey && key.Curve.Params().BitSize == k.size()
+	}
+	return false
+}
@@ -0,0 +1,83 @@
+package certificates
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/x509"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestKeyConfigValidate(t *testing.T) {
+	tests := []struct {
+		key   KeyConfig
+		valid bool
+	}{
+		{key: KeyConfig{}, valid: true},
+		{key: KeyConfig{Algorithm: RSAKey, Size: 4096}, valid: true},
+		{key: KeyConfig{Algorithm: RSAKey, Size: 1024}},
+		{key: KeyConfig{Algorithm: ECDSAKey}, valid: true},
+		{key: KeyConfig{Algorithm: ECDSAKey, Size: 384}, valid: true},
+		{key: KeyConfig{Algorithm: ECDSAKey, Size: 2048}},
+		{key: KeyConfig{Algorithm: "DSA"}},
+	}
+	for _, test := range tests {
+		err := test.key.Validate()
+		assert.Equal(t, test.valid, err == nil, "%+v: %v", test.key, err)
+	}
+}
+
+func TestECDSACertificates(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, core.SchemeBuilder.AddToScheme(scheme))
+	owner := &core.Pod{ObjectMeta: meta.ObjectMeta{Name: "owner", UID: "ownerUID", Namespace: "default"}}
+	cl := fake.NewFakeClientWithScheme(scheme)
+	subjects := []CertificateSubject{NewSubject("pod-0", "host-0", "10.0.0.1", nil)}
+	getSecret := func(name string) *core.Secret {
+		secret := &core.Secret{}
+		require.NoError(t, cl.Get(context.Background(), types.NamespacedName{Name: name, Namespace: "default"}, secret))
+		return secret
+	}
+	caCertificate := NewCACertificate(cl, scheme, owner, "ownerType", KeyConfig{Algorithm: ECDSAKey, Size: 384})
+	require.NoError(t, caCertificate.EnsureExists())
+
+	t.Run("should issue certificates with ECDSA keys", func(t *testing.T) {
+		// when
+		require.NoError(t, NewCertificate(cl, scheme, owner, subjects, "ownerType", KeyConfig{Algorithm: ECDSAKey}).EnsureExistsAndIsSigned())
+		// then
+		caCert, err := parseCertificate(getSecret(caSecretName).Data, SignerCAFilename)
+		require.NoError(t, err)
+		assert.Equal(t, 384, caCert.PublicKey.(*ecdsa.PublicKey).Curve.Params().BitSize)
+		secret := getSecret("owner-secret-certificates")
+		cert, err := parseCertificate(secret.Data, "server-10.0.0.1.crt")
+		require.NoError(t, err)
+		assert.Equal(t, 256, cert.PublicKey.(*ecdsa.PublicKey).Curve.Params().BitSize)
+		assert.Equal(t, x509.KeyUsageDigitalSignature, cert.KeyUsage)
+		assert.NoError(t, cert.CheckSignatureFrom(caCert))
+		block, err := getAndDecodePem(secret.Data, "server-key-10.0.0.1.pem")
+		require.NoError(t, err)
+		assert.Equal(t, "EC PRIVATE KEY", block.Type)
+		key, err := parsePrivateKey(block.Bytes)
+		require.NoError(t, err)
+		assert.Equal(t, cert.PublicKey, key.Public())
+	})
+
+	t.Run("should renew certificate when key algorithm changes", func(t *testing.T) {
+		// when
+		require.NoError(t, NewCertificate(cl, scheme, owner, subjects, "ownerType", KeyConfig{Algorithm: RSAKey}).EnsureExistsAndIsSigned())
+		// then
+		secret := getSecret("owner-secret-certificates")
+		block, err := getAndDecodePem(secret.Data, "server-key-10.0.0.1.pem")
+		require.NoError(t, err)
+		assert.Equal(t, "RSA PRIVATE KEY", block.Type)
+		assert.Equal(t, "Renewed", string(secret.Data["status-10.0.0.1"]))
+	})
+}
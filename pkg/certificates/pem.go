@@ -2,14 +2,21 @@ package certificates
 
 import (
 	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
 	"encoding/pem"
 	"errors"
+	"fmt"
 	"io/ioutil"
 )
 
 const (
-	certificatePemType = "CERTIFICATE"
-	privateKeyPemType  = "RSA PRIVATE KEY"
+	certificatePemType  = "CERTIFICATE"
+	privateKeyPemType   = "RSA PRIVATE KEY"
+	ecPrivateKeyPemType = "EC PRIVATE KEY"
+	crlPemType          = "X509 CRL"
 )
 
 func getAndDecodePem(data map[string][]byte, key string) (*pem.Block, error) {
@@ -29,3 +36,53 @@ func encodeInPemFormat(buff []byte, pemType string) ([]byte, error) {
 	})
 	return ioutil.ReadAll(pemFormatBuffer)
 }
+
+func parseCertificate(data map[string][]byte, key string) (*x509.Certificate, error) {
+	pemData, ok := data[key]
+	if !ok {
+		return nil, fmt.Errorf("pem block %s not found in data map", key)
+	}
+	return decodeCertificate(pemData)
+}
+
+func decodeCertificate(pemData []byte) (*x509.Certificate, error) {
+	pemBlock, _ := pem.Decode(pemData)
+	if pemBlock == nil {
+		return nil, errors.New("no pem block found")
+	}
+	return x509.ParseCertificate(pemBlock.Bytes)
+}
+
+// encodePrivateKey encodes RSA keys in PKCS#1 and ECDSA keys in SEC 1 form.
+func encodePrivateKey(key crypto.Signer) ([]byte, error) {
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		return encodeInPemFormat(x509.MarshalPKCS1PrivateKey(k), privateKeyPemType)
+	case *ecdsa.PrivateKey:
+		der, err := x509.MarshalECPrivateKey(k)
+		if err != nil {
+			return nil, err
+		}
+		return encodeInPemFormat(der, ecPrivateKeyPemType)
+	}
+	return nil, fmt.Errorf("unsupported private key type %T", key)
+}
+
+// parsePrivateKey parses a private key in PKCS#1, SEC 1 or PKCS#8 form.
+func parsePrivateKey(der []byte) (crypto.Signer, error) {
+	if key, err := x509.ParsePKCS1PrivateKey(der); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParseECPrivateKey(der); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key type %T", key)
+	}
+	return signer, nil
+}
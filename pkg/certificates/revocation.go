@@ -0,0 +1,111 @@
+package certificates
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// RevocationsConfigMapName is the name of the ConfigMap with certificates
+	// revoked when they were pruned from secrets.
+	RevocationsConfigMapName = "contrail-certificate-revocations"
+	// SignerCRLFilename is the key of the certificate revocation list in the
+	// SignerCAConfigMapName ConfigMap.
+	SignerCRLFilename = "ca.crl"
+)
+
+// PublishCRL enables revocation of pruned certificates and publishing of the
+// certificate revocation list. It is set from the operator command line.
+var PublishCRL = false
+
+type revocation struct {
+	RevocationTime time.Time `json:"revocationTime"`
+	NotAfter       time.Time `json:"notAfter"`
+}
+
+// revokeCertificates records the certificates in the revocations ConfigMap keyed
+// by their serial numbers and forgets revoked certificates which expired.
+func revokeCertificates(cl client.Client, namespace string, certs []*x509.Certificate) error {
+	configMap := &corev1.ConfigMap{}
+	err := cl.Get(context.Background(), types.NamespacedName{Name: RevocationsConfigMapName, Namespace: namespace}, configMap)
+	found := err == nil
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	if !found {
+		configMap.ObjectMeta = metav1.ObjectMeta{Name: RevocationsConfigMapName, Namespace: namespace}
+	}
+	if configMap.Data == nil {
+		configMap.Data = map[string]string{}
+	}
+	for serial, value := range configMap.Data {
+		var r revocation
+		if err := json.Unmarshal([]byte(value), &r); err != nil || !now().Before(r.NotAfter) {
+			delete(configMap.Data, serial)
+		}
+	}
+	for _, cert := range certs {
+		serial := cert.SerialNumber.Text(16)
+		if _, ok := configMap.Data[serial]; ok {
+			continue
+		}
+		value, err := json.Marshal(revocation{RevocationTime: now().UTC().Truncate(time.Second), NotAfter: cert.NotAfter})
+		if err != nil {
+			return err
+		}
+		configMap.Data[serial] = string(value)
+	}
+	if !found {
+		return cl.Create(context.Background(), configMap)
+	}
+	return cl.Update(context.Background(), configMap)
+}
+
+// certificateRevocationList returns the PEM encoded list of revoked certificates
+// signed by the CA. It only changes when certificates are revoked, so it is valid
+// until the CA expires.
+func certificateRevocationList(cl client.Client, namespace string, caCert *x509.Certificate, caKey crypto.Signer) ([]byte, error) {
+	configMap := &corev1.ConfigMap{}
+	err := cl.Get(context.Background(), types.NamespacedName{Name: RevocationsConfigMapName, Namespace: namespace}, configMap)
+	if err != nil && !errors.IsNotFound(err) {
+		return nil, err
+	}
+	thisUpdate := caCert.NotBefore
+	var revoked []pkix.RevokedCertificate
+	for serial, value := range configMap.Data {
+		var r revocation
+		if err := json.Unmarshal([]byte(value), &r); err != nil {
+			return nil, fmt.Errorf("failed to parse revocation of %s: %w", serial, err)
+		}
+		serialNumber, ok := new(big.Int).SetString(serial, 16)
+		if !ok {
+			return nil, fmt.Errorf("invalid serial number %s of revoked certificate", serial)
+		}
+		revoked = append(revoked, pkix.RevokedCertificate{SerialNumber: serialNumber, RevocationTime: r.RevocationTime})
+		if r.RevocationTime.After(thisUpdate) {
+			thisUpdate = r.RevocationTime
+		}
+	}
+	sort.Slice(revoked, func(i, j int) bool {
+		return revoked[i].SerialNumber.Cmp(revoked[j].SerialNumber) < 0
+	})
+	crl, err := caCert.CreateCRL(rand.Reader, caKey, revoked, thisUpdate, caCert.NotAfter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create certificate revocation list: %w", err)
+	}
+	return encodeInPemFormat(crl, crlPemType)
+}
@@ -2,12 +2,13 @@ package certificates
 
 import (
 	"context"
+	"crypto"
 	"crypto/rand"
-	"crypto/rsa"
 	"crypto/x509"
 	"fmt"
 
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -24,7 +25,7 @@ func (s *signer) getCaCertSecret() (*corev1.Secret, error) {
 	return secret, err
 }
 
-func (s *signer) SignCertificate(certTemplate x509.Certificate, privateKey rsa.PrivateKey) ([]byte, error) {
+func (s *signer) SignCertificate(certTemplate x509.Certificate, privateKey crypto.Signer) ([]byte, error) {
 	secret, err := s.getCaCertSecret()
 
 	if err != nil {
@@ -48,11 +49,15 @@ func (s *signer) SignCertificate(certTemplate x509.Certificate, privateKey rsa.P
 		return nil, fmt.Errorf("failed to decode ca cert priv key pem: %w", err)
 	}
 
-	caCertPrivKey, err := x509.ParsePKCS1PrivateKey(caCertPrivKeyPemBlock.Bytes)
+	caCertPrivKey, err := parsePrivateKey(caCertPrivKeyPemBlock.Bytes)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse ca cert: %w", err)
 	}
 
+	return signWithCA(certTemplate, privateKey, caCert, caCertPrivKey)
+}
+
+func signWithCA(certTemplate x509.Certificate, privateKey crypto.Signer, caCert *x509.Certificate, caCertPrivKey crypto.Signer) ([]byte, error) {
 	certBytes, err := x509.CreateCertificate(rand.Reader, &certTemplate, caCert, privateKey.Public(), caCertPrivKey)
 
 	if err != nil {
@@ -67,3 +72,18 @@ func (s *signer) SignCertificate(certTemplate x509.Certificate, privateKey rsa.P
 
 	return certPem, nil
 }
+
+func (s *signer) CACertificates() (*x509.Certificate, []byte, error) {
+	secret, err := s.getCaCertSecret()
+	if errors.IsNotFound(err) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get secret %s with ca cert: %w", caSecretName, err)
+	}
+	caCert, err := parseCertificate(secret.Data, SignerCAFilename)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse ca cert: %w", err)
+	}
+	return caCert, caBundle(secret.Data), nil
+}
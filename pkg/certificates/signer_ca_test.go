@@ -39,7 +39,7 @@ func TestCertificateSigning(t *testing.T) {
 		},
 	}
 
-	caCertificate := NewCACertificate(cl, scheme, owner, "ownerType")
+	caCertificate := NewCACertificate(cl, scheme, owner, "ownerType", KeyConfig{})
 	assert.NoError(t, caCertificate.EnsureExists())
 
 	certPrivKey, _ := rsa.GenerateKey(rand.Reader, 2048)
@@ -60,7 +60,7 @@ func TestCertificateSigning(t *testing.T) {
 	}
 
 	certSigner := signer{cl, owner}
-	certBytes, err := certSigner.SignCertificate(certificateTemplate, *certPrivKey)
+	certBytes, err := certSigner.SignCertificate(certificateTemplate, certPrivKey)
 	assert.NoError(t, err)
 
 	pemBlock, restData := pem.Decode(certBytes)
@@ -71,7 +71,7 @@ func TestCertificateSigning(t *testing.T) {
 	assert.Equal(t, caCert.KeyUsage, x509.KeyUsageKeyEncipherment|x509.KeyUsageDigitalSignature)
 	assert.Equal(t, caCert.ExtKeyUsage, []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth})
 	dur := caCert.NotAfter.Sub(caCert.NotBefore)
-	assert.GreaterOrEqual(t, dur.Hours(), caCertValidityPeriod.Hours())
+	assert.GreaterOrEqual(t, dur.Hours(), CACertValidityPeriod.Hours())
 
 	assert.Equal(t, caCert.Issuer.CommonName, "contrail-signer")
 }
@@ -0,0 +1,460 @@
+package keystone
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+)
+
+// Service is an entry of the Keystone service catalog.
+type Service struct {
+	ID          string `json:"id,omitempty"`
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Description string `json:"description,omitempty"`
+	Enabled     bool   `json:"enabled"`
+}
+
+// Endpoint is an URL of a service exposed through one of interfaces: admin, internal or public.
+type Endpoint struct {
+	ID        string `json:"id,omitempty"`
+	ServiceID string `json:"service_id"`
+	RegionID  string `json:"region_id"`
+	Interface string `json:"interface"`
+	URL       string `json:"url"`
+	Enabled   bool   `json:"enabled"`
+}
+
+// Project is a Keystone project.
+type Project struct {
+	ID       string `json:"id,omitempty"`
+	Name     string `json:"name"`
+	DomainID string `json:"domain_id"`
+	Enabled  bool   `json:"enabled"`
+}
+
+// User is a Keystone user. Password is never returned by Keystone.
+type User struct {
+	ID               string `json:"id,omitempty"`
+	Name             string `json:"name"`
+	DomainID         string `json:"domain_id"`
+	DefaultProjectID string `json:"default_project_id,omitempty"`
+	Password         string `json:"password,omitempty"`
+	Enabled          bool   `json:"enabled"`
+}
+
+// Role is a Keystone role.
+type Role struct {
+	ID   string `json:"id,omitempty"`
+	Name string `json:"name"`
+}
+
+// Domain is a Keystone identity domain.
+type Domain struct {
+	ID          string `json:"id,omitempty"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Enabled     bool   `json:"enabled"`
+}
+
+// Group is a Keystone group. Groups of LDAP backed domains are read from the directory.
+type Group struct {
+	ID       string `json:"id,omitempty"`
+	Name     string `json:"name"`
+	DomainID string `json:"domain_id"`
+}
+
+// ServiceRegistration describes a service registered in Keystone together with
+// its endpoints and service user.
+type ServiceRegistration struct {
+	Name        string
+	Type        string
+	Description string
+	Region      string
+	// Endpoints maps endpoint interfaces (admin, internal, public) to URLs.
+	Endpoints map[string]string
+	// User is the name of the service user. No user is registered if it is empty.
+	User     string
+	Password string
+	// Project is the project of the service user.
+	Project string
+	// Roles are granted to the service user on the project. Missing roles are created.
+	Roles []string
+}
+
+// EnsureServiceRegistration creates or updates the service, its endpoints and user.
+// Users and projects are created in the domains configured in the Keystone CR.
+func (c *Client) EnsureServiceRegistration(token string, registration ServiceRegistration) error {
+	serviceID, err := c.EnsureService(token, registration.Name, registration.Type, registration.Description)
+	if err != nil {
+		return err
+	}
+	interfaces := make([]string, 0, len(registration.Endpoints))
+	for endpointInterface := range registration.Endpoints {
+		interfaces = append(interfaces, endpointInterface)
+	}
+	sort.Strings(interfaces)
+	for _, endpointInterface := range interfaces {
+		endpointURL := registration.Endpoints[endpointInterface]
+		if err := c.EnsureEndpoint(token, serviceID, registration.Region, endpointInterface, endpointURL); err != nil {
+			return err
+		}
+	}
+	if registration.User == "" {
+		return nil
+	}
+	projectID, err := c.EnsureProject(token, registration.Project, c.KeystoneConf.ProjectDomainID)
+	if err != nil {
+		return err
+	}
+	userID, err := c.EnsureUser(token, registration.User, registration.Password, c.KeystoneConf.UserDomainID, projectID)
+	if err != nil {
+		return err
+	}
+	for _, role := range registration.Roles {
+		roleID, err := c.EnsureRole(token, role)
+		if err != nil {
+			return err
+		}
+		if err := c.EnsureRoleAssignment(token, projectID, userID, roleID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListServices returns services of the type.
+func (c *Client) ListServices(token, serviceType string) ([]Service, error) {
+	services := struct {
+		Services []Service `json:"services"`
+	}{}
+	err := c.adminRequest(http.MethodGet, "/v3/services?"+url.Values{"type": {serviceType}}.Encode(), token, nil, &services)
+	return services.Services, err
+}
+
+// CreateService creates the service and returns it with its ID.
+func (c *Client) CreateService(token string, service Service) (Service, error) {
+	created := struct {
+		Service Service `json:"service"`
+	}{}
+	err := c.adminRequest(http.MethodPost, "/v3/services", token, map[string]Service{"service": service}, &created)
+	return created.Service, err
+}
+
+// DeleteService deletes the service. Deleting a missing service succeeds.
+func (c *Client) DeleteService(token, id string) error {
+	return c.deleteRequest("/v3/services/"+id, token)
+}
+
+// EnsureService returns the ID of the service with the name and type, creating it when missing.
+func (c *Client) EnsureService(token, name, serviceType, description string) (string, error) {
+	services, err := c.ListServices(token, serviceType)
+	if err != nil {
+		return "", err
+	}
+	for _, service := range services {
+		if service.Name == name {
+			return service.ID, nil
+		}
+	}
+	service, err := c.CreateService(token, Service{Name: name, Type: serviceType, Description: description, Enabled: true})
+	return service.ID, err
+}
+
+// ListEndpoints returns endpoints of the service with the interface.
+func (c *Client) ListEndpoints(token, serviceID, endpointInterface string) ([]Endpoint, error) {
+	endpoints := struct {
+		Endpoints []Endpoint `json:"endpoints"`
+	}{}
+	query := url.Values{"service_id": {serviceID}, "interface": {endpointInterface}}
+	err := c.adminRequest(http.MethodGet, "/v3/endpoints?"+query.Encode(), token, nil, &endpoints)
+	return endpoints.Endpoints, err
+}
+
+// CreateEndpoint creates the endpoint and returns it with its ID.
+func (c *Client) CreateEndpoint(token string, endpoint Endpoint) (Endpoint, error) {
+	created := struct {
+		Endpoint Endpoint `json:"endpoint"`
+	}{}
+	err := c.adminRequest(http.MethodPost, "/v3/endpoints", token, map[string]Endpoint{"endpoint": endpoint}, &created)
+	return created.Endpoint, err
+}
+
+// UpdateEndpoint updates the endpoint with the ID of the passed one.
+func (c *Client) UpdateEndpoint(token string, endpoint Endpoint) error {
+	return c.adminRequest(http.MethodPatch, "/v3/endpoints/"+endpoint.ID, token, map[string]Endpoint{"endpoint": endpoint}, nil)
+}
+
+// DeleteEndpoint deletes the endpoint. Deleting a missing endpoint succeeds.
+func (c *Client) DeleteEndpoint(token, id string) error {
+	return c.deleteRequest("/v3/endpoints/"+id, token)
+}
+
+// EnsureEndpoint creates the endpoint of the service with the interface in the region
+// or updates its URL when it differs.
+func (c *Client) EnsureEndpoint(token, serviceID, region, endpointInterface, endpointURL string) error {
+	endpoints, err := c.ListEndpoints(token, serviceID, endpointInterface)
+	if err != nil {
+		return err
+	}
+	endpoint := Endpoint{ServiceID: serviceID, RegionID: region, Interface: endpointInterface, URL: endpointURL, Enabled: true}
+	for _, e := range endpoints {
+		if e.RegionID != region {
+			continue
+		}
+		if e.URL == endpointURL {
+			return nil
+		}
+		endpoint.ID = e.ID
+		return c.UpdateEndpoint(token, endpoint)
+	}
+	_, err = c.CreateEndpoint(token, endpoint)
+	return err
+}
+
+// ListProjects returns projects with the name in the domain.
+func (c *Client) ListProjects(token, name, domainID string) ([]Project, error) {
+	projects := struct {
+		Projects []Project `json:"projects"`
+	}{}
+	query := url.Values{"name": {name}, "domain_id": {domainID}}
+	err := c.adminRequest(http.MethodGet, "/v3/projects?"+query.Encode(), token, nil, &projects)
+	return projects.Projects, err
+}
+
+// CreateProject creates the project and returns it with its ID.
+func (c *Client) CreateProject(token string, project Project) (Project, error) {
+	created := struct {
+		Project Project `json:"project"`
+	}{}
+	err := c.adminRequest(http.MethodPost, "/v3/projects", token, map[string]Project{"project": project}, &created)
+	return created.Project, err
+}
+
+// DeleteProject deletes the project. Deleting a missing project succeeds.
+func (c *Client) DeleteProject(token, id string) error {
+	return c.deleteRequest("/v3/projects/"+id, token)
+}
+
+// EnsureProject returns the ID of the project in the domain, creating it when missing.
+func (c *Client) EnsureProject(token, name, domainID string) (string, error) {
+	projects, err := c.ListProjects(token, name, domainID)
+	if err != nil {
+		return "", err
+	}
+	if len(projects) != 0 {
+		return projects[0].ID, nil
+	}
+	project, err := c.CreateProject(token, Project{Name: name, DomainID: domainID, Enabled: true})
+	return project.ID, err
+}
+
+// ListUsers returns users with the name in the domain.
+func (c *Client) ListUsers(token, name, domainID string) ([]User, error) {
+	users := struct {
+		Users []User `json:"users"`
+	}{}
+	query := url.Values{"name": {name}, "domain_id": {domainID}}
+	err := c.adminRequest(http.MethodGet, "/v3/users?"+query.Encode(), token, nil, &users)
+	return users.Users, err
+}
+
+// CreateUser creates the user and returns it with its ID.
+func (c *Client) CreateUser(token string, user User) (User, error) {
+	created := struct {
+		User User `json:"user"`
+	}{}
+	err := c.adminRequest(http.MethodPost, "/v3/users", token, map[string]User{"user": user}, &created)
+	return created.User, err
+}
+
+// UpdateUserPassword sets the password of the user.
+func (c *Client) UpdateUserPassword(token, id, password string) error {
+	update := map[string]interface{}{
+		"user": map[string]string{"password": password},
+	}
+	return c.adminRequest(http.MethodPatch, "/v3/users/"+id, token, update, nil)
+}
+
+// DeleteUser deletes the user. Deleting a missing user succeeds.
+func (c *Client) DeleteUser(token, id string) error {
+	return c.deleteRequest("/v3/users/"+id, token)
+}
+
+// EnsureUser returns the ID of the user in the domain, creating it when missing.
+// The password of an existing user is updated, so that it matches the passed one.
+func (c *Client) EnsureUser(token, name, password, domainID, defaultProjectID string) (string, error) {
+	users, err := c.ListUsers(token, name, domainID)
+	if err != nil {
+		return "", err
+	}
+	if len(users) != 0 {
+		return users[0].ID, c.UpdateUserPassword(token, users[0].ID, password)
+	}
+	user, err := c.CreateUser(token, User{
+		Name:             name,
+		Password:         password,
+		DomainID:         domainID,
+		DefaultProjectID: defaultProjectID,
+		Enabled:          true,
+	})
+	return user.ID, err
+}
+
+// ListRoles returns roles with the name.
+func (c *Client) ListRoles(token, name string) ([]Role, error) {
+	roles := struct {
+		Roles []Role `json:"roles"`
+	}{}
+	err := c.adminRequest(http.MethodGet, "/v3/roles?"+url.Values{"name": {name}}.Encode(), token, nil, &roles)
+	return roles.Roles, err
+}
+
+// CreateRole creates the role and returns it with its ID.
+func (c *Client) CreateRole(token string, role Role) (Role, error) {
+	created := struct {
+		Role Role `json:"role"`
+	}{}
+	err := c.adminRequest(http.MethodPost, "/v3/roles", token, map[string]Role{"role": role}, &created)
+	return created.Role, err
+}
+
+// DeleteRole deletes the role. Deleting a missing role succeeds.
+func (c *Client) DeleteRole(token, id string) error {
+	return c.deleteRequest("/v3/roles/"+id, token)
+}
+
+// EnsureRole returns the ID of the role with the name, creating it when missing.
+func (c *Client) EnsureRole(token, name string) (string, error) {
+	roles, err := c.ListRoles(token, name)
+	if err != nil {
+		return "", err
+	}
+	if len(roles) != 0 {
+		return roles[0].ID, nil
+	}
+	role, err := c.CreateRole(token, Role{Name: name})
+	return role.ID, err
+}
+
+// EnsureRoleAssignment grants the role to the user on the project.
+func (c *Client) EnsureRoleAssignment(token, projectID, userID, roleID string) error {
+	return c.adminRequest(http.MethodPut, roleAssignmentPath(projectID, userID, roleID), token, nil, nil)
+}
+
+// DeleteRoleAssignment revokes the role of the user on the project. Revoking a role
+// which is not granted succeeds.
+func (c *Client) DeleteRoleAssignment(token, projectID, userID, roleID string) error {
+	return c.deleteRequest(roleAssignmentPath(projectID, userID, roleID), token)
+}
+
+func roleAssignmentPath(projectID, userID, roleID string) string {
+	return fmt.Sprintf("/v3/projects/%s/users/%s/roles/%s", projectID, userID, roleID)
+}
+
+// ListDomains returns domains with the name.
+func (c *Client) ListDomains(token, name string) ([]Domain, error) {
+	domains := struct {
+		Domains []Domain `json:"domains"`
+	}{}
+	err := c.adminRequest(http.MethodGet, "/v3/domains?"+url.Values{"name": {name}}.Encode(), token, nil, &domains)
+	return domains.Domains, err
+}
+
+// CreateDomain creates the domain and returns it with its ID.
+func (c *Client) CreateDomain(token string, domain Domain) (Domain, error) {
+	created := struct {
+		Domain Domain `json:"domain"`
+	}{}
+	err := c.adminRequest(http.MethodPost, "/v3/domains", token, map[string]Domain{"domain": domain}, &created)
+	return created.Domain, err
+}
+
+// EnsureDomain returns the ID of the domain with the name, creating it when missing.
+func (c *Client) EnsureDomain(token, name, description string) (string, error) {
+	domains, err := c.ListDomains(token, name)
+	if err != nil {
+		return "", err
+	}
+	if len(domains) != 0 {
+		return domains[0].ID, nil
+	}
+	domain, err := c.CreateDomain(token, Domain{Name: name, Description: description, Enabled: true})
+	return domain.ID, err
+}
+
+// ListGroups returns groups with the name in the domain.
+func (c *Client) ListGroups(token, name, domainID string) ([]Group, error) {
+	groups := struct {
+		Groups []Group `json:"groups"`
+	}{}
+	query := url.Values{"name": {name}, "domain_id": {domainID}}
+	err := c.adminRequest(http.MethodGet, "/v3/groups?"+query.Encode(), token, nil, &groups)
+	return groups.Groups, err
+}
+
+// EnsureGroupRoleAssignment grants the role to members of the group on the project.
+func (c *Client) EnsureGroupRoleAssignment(token, projectID, groupID, roleID string) error {
+	path := fmt.Sprintf("/v3/projects/%s/groups/%s/roles/%s", projectID, groupID, roleID)
+	return c.adminRequest(http.MethodPut, path, token, nil, nil)
+}
+
+type statusError struct {
+	method     string
+	path       string
+	statusCode int
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("%s %s: invalid status code returned: %d", e.method, e.path, e.statusCode)
+}
+
+func (c *Client) deleteRequest(path, token string) error {
+	err := c.adminRequest(http.MethodDelete, path, token, nil, nil)
+	if statusErr, ok := err.(*statusError); ok && statusErr.statusCode == http.StatusNotFound {
+		return nil
+	}
+	return err
+}
+
+// adminRequest sends the request authorized with the token and decodes the response into out.
+func (c *Client) adminRequest(method, path, token string, in, out interface{}) error {
+	var body []byte
+	if in != nil {
+		var err error
+		if body, err = json.Marshal(in); err != nil {
+			return err
+		}
+	}
+	request, err := c.Connector.NewRequest(method, path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("X-Auth-Token", token)
+	if in != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+	response, err := c.Connector.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode == http.StatusUnauthorized {
+		return newUnauthorized()
+	}
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return &statusError{method: method, path: path, statusCode: response.StatusCode}
+	}
+	if out == nil {
+		return nil
+	}
+	bytesRead, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(bytesRead, out)
+}
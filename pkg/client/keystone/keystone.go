@@ -2,6 +2,7 @@ package keystone
 
 import (
 	"bytes"
+	"context"
 	"crypto/tls"
 	"crypto/x509"
 	"encoding/json"
@@ -10,7 +11,9 @@ import (
 	"io/ioutil"
 	"net/http"
 
+	core "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/rest"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
@@ -25,16 +28,24 @@ func NewClient(kubClient client.Client, scheme *runtime.Scheme, config *rest.Con
 	if err != nil {
 		return nil, err
 	}
+	keystoneConf := k.ConfigurationParameters()
 	return &Client{
 		Connector:    connector,
-		KeystoneConf: &k.Spec.ServiceConfiguration,
+		KeystoneConf: &keystoneConf,
 	}, nil
 }
 
 func newConnector(kubClient client.Client, scheme *runtime.Scheme, config *rest.Config, k *contrail.Keystone) (keystoneClient, error) {
 	if k.Spec.ServiceConfiguration.ExternalAddress != "" {
-		caCertificate := certificates.NewCACertificate(kubClient, scheme, k, k.GetName())
+		caCertificate := certificates.NewCACertificate(kubClient, scheme, k, k.GetName(), certificates.KeyConfig{})
 		caBundle, _ := caCertificate.GetCaCert()
+		if name := k.Spec.ServiceConfiguration.CABundleConfigMapName; name != "" {
+			extCABundle, err := ExternalCABundle(kubClient, name, k.Namespace)
+			if err != nil {
+				return nil, err
+			}
+			caBundle = append(append(caBundle, '\n'), extCABundle...)
+		}
 		return newExtKeystoneClient(k.Spec.ServiceConfiguration.AuthProtocol, k.Spec.ServiceConfiguration.ExternalAddress, k.Spec.ServiceConfiguration.ListenPort, caBundle), nil
 	}
 	proxy, err := kubeproxy.New(config)
@@ -47,6 +58,19 @@ func newConnector(kubClient client.Client, scheme *runtime.Scheme, config *rest.
 	return proxy.NewClientForService(k.Namespace, k.Name+"-service", k.Status.Port), nil
 }
 
+// ExternalCABundle reads CA certificates of the external keystone from the ConfigMap.
+func ExternalCABundle(kubClient client.Client, name, namespace string) ([]byte, error) {
+	cm := &core.ConfigMap{}
+	if err := kubClient.Get(context.Background(), types.NamespacedName{Name: name, Namespace: namespace}, cm); err != nil {
+		return nil, fmt.Errorf("failed to get keystone CA bundle %s: %v", name, err)
+	}
+	caBundle, ok := cm.Data[certificates.SignerCAFilename]
+	if !ok {
+		return nil, fmt.Errorf("keystone CA bundle %s has no %s key", name, certificates.SignerCAFilename)
+	}
+	return []byte(caBundle), nil
+}
+
 type keystoneClient interface {
 	NewRequest(method, path string, body io.Reader) (*http.Request, error)
 	Do(req *http.Request) (*http.Response, error)
@@ -106,6 +130,11 @@ func (c *Client) PostAuthTokens(username, password, project string) (AuthTokens,
 	return c.PostAuthTokensWithHeaders(username, password, project, http.Header{})
 }
 
+// PostAdminAuthTokens retrieves tokens of the admin user configured in the Keystone CR.
+func (c *Client) PostAdminAuthTokens(password string) (AuthTokens, error) {
+	return c.PostAuthTokens(c.KeystoneConf.AdminUsername, password, c.KeystoneConf.AdminProject)
+}
+
 func (c *Client) PostAuthTokensWithHeaders(username, password, project string, headers http.Header) (AuthTokens, error) {
 	kar := &keystoneAuthRequest{}
 	kar.Auth.Identity.Methods = []string{"password"}
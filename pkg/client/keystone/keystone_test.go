@@ -1 +1,308 @@
 package keystone_test
+
+import (
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	contrail "github.com/Juniper/contrail-operator/pkg/apis/contrail/v1alpha1"
+	"github.com/Juniper/contrail-operator/pkg/client/keystone"
+)
+
+func TestPostAdminAuthTokens(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request := authRequest{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		assert.Equal(t, "cloud-admin", request.Auth.Identity.Password.User.Name)
+		assert.Equal(t, "secret", request.Auth.Identity.Password.User.Password)
+		assert.Equal(t, "ldap", request.Auth.Identity.Password.User.Domain.ID)
+		assert.Equal(t, "operations", request.Auth.Scope.Project.Name)
+		w.Header().Set("X-Subject-Token", "admin-token")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"token": {}}`))
+	}))
+	defer srv.Close()
+	caBundle := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+
+	t.Run("should trust CA bundle of external keystone", func(t *testing.T) {
+		k := newExtKeystone(t, srv.URL, "keystone-ca")
+		caConfigMap := &core.ConfigMap{
+			ObjectMeta: meta.ObjectMeta{Name: "keystone-ca", Namespace: "default"},
+			Data:       map[string]string{"ca-bundle.crt": string(caBundle)},
+		}
+		keystoneClient, err := keystone.NewClient(newFakeClient(t, k, caConfigMap), scheme(t), nil, k)
+		require.NoError(t, err)
+		token, err := keystoneClient.PostAdminAuthTokens("secret")
+		require.NoError(t, err)
+		assert.Equal(t, "admin-token", token.XAuthTokenHeader)
+	})
+
+	t.Run("should fail when CA bundle config map does not exist", func(t *testing.T) {
+		k := newExtKeystone(t, srv.URL, "keystone-ca")
+		_, err := keystone.NewClient(newFakeClient(t, k), scheme(t), nil, k)
+		assert.Error(t, err)
+	})
+
+	t.Run("should not trust external keystone without CA bundle", func(t *testing.T) {
+		k := newExtKeystone(t, srv.URL, "")
+		keystoneClient, err := keystone.NewClient(newFakeClient(t, k), scheme(t), nil, k)
+		require.NoError(t, err)
+		_, err = keystoneClient.PostAdminAuthTokens("secret")
+		assert.Error(t, err)
+	})
+}
+
+func TestAdminRequests(t *testing.T) {
+	ks := &fakeKeystone{}
+	srv := httptest.NewServer(ks)
+	defer srv.Close()
+	k := newExtKeystone(t, srv.URL, "")
+	keystoneClient, err := keystone.NewClient(newFakeClient(t, k), scheme(t), nil, k)
+	require.NoError(t, err)
+
+	t.Run("should create missing service once", func(t *testing.T) {
+		ks.reset(`{"services": []}`, `{"service": {"id": "swift-id"}}`)
+		id, err := keystoneClient.EnsureService("admin-token", "swift", "object-store", "object store service")
+		require.NoError(t, err)
+		assert.Equal(t, "swift-id", id)
+		assert.Equal(t, []string{"GET /v3/services?type=object-store", "POST /v3/services"}, ks.requests)
+		assert.Equal(t, "admin-token", ks.token)
+
+		ks.reset(`{"services": [{"id": "other-id", "name": "other"}, {"id": "swift-id", "name": "swift"}]}`)
+		id, err = keystoneClient.EnsureService("admin-token", "swift", "object-store", "object store service")
+		require.NoError(t, err)
+		assert.Equal(t, "swift-id", id)
+		assert.Equal(t, []string{"GET /v3/services?type=object-store"}, ks.requests)
+	})
+
+	t.Run("should create, keep and update endpoints", func(t *testing.T) {
+		ks.reset(`{"endpoints": [{"id": "e1", "region_id": "RegionTwo", "url": "https://10.0.0.1:5080/v1"}]}`, `{}`)
+		require.NoError(t, keystoneClient.EnsureEndpoint("admin-token", "swift-id", "RegionOne", "admin", "https://10.0.0.1:5080/v1"))
+		assert.Equal(t, []string{"GET /v3/endpoints?interface=admin&service_id=swift-id", "POST /v3/endpoints"}, ks.requests)
+
+		ks.reset(`{"endpoints": [{"id": "e1", "region_id": "RegionOne", "url": "https://10.0.0.1:5080/v1"}]}`)
+		require.NoError(t, keystoneClient.EnsureEndpoint("admin-token", "swift-id", "RegionOne", "admin", "https://10.0.0.1:5080/v1"))
+		assert.Len(t, ks.requests, 1)
+
+		ks.reset(`{"endpoints": [{"id": "e1", "region_id": "RegionOne", "url": "https://10.0.0.2:5080/v1"}]}`, `{}`)
+		require.NoError(t, keystoneClient.EnsureEndpoint("admin-token", "swift-id", "RegionOne", "admin", "https://10.0.0.1:5080/v1"))
+		assert.Equal(t, "PATCH /v3/endpoints/e1", ks.requests[1])
+		assert.Contains(t, ks.bodies[1], `"url":"https://10.0.0.1:5080/v1"`)
+	})
+
+	t.Run("should create missing project", func(t *testing.T) {
+		ks.reset(`{"projects": []}`, `{"project": {"id": "p1"}}`)
+		id, err := keystoneClient.EnsureProject("admin-token", "service", "default")
+		require.NoError(t, err)
+		assert.Equal(t, "p1", id)
+		assert.Equal(t, []string{"GET /v3/projects?domain_id=default&name=service", "POST /v3/projects"}, ks.requests)
+	})
+
+	t.Run("should update password of existing user", func(t *testing.T) {
+		ks.reset(`{"users": [{"id": "u1"}]}`, `{}`)
+		id, err := keystoneClient.EnsureUser("admin-token", "swift", "new-password", "default", "p1")
+		require.NoError(t, err)
+		assert.Equal(t, "u1", id)
+		assert.Equal(t, []string{"GET /v3/users?domain_id=default&name=swift", "PATCH /v3/users/u1"}, ks.requests)
+		assert.Contains(t, ks.bodies[1], `"password":"new-password"`)
+	})
+
+	t.Run("should create missing role and assign it", func(t *testing.T) {
+		ks.reset(`{"roles": []}`, `{"role": {"id": "r1"}}`, ``)
+		id, err := keystoneClient.EnsureRole("admin-token", "ResellerAdmin")
+		require.NoError(t, err)
+		assert.Equal(t, "r1", id)
+		require.NoError(t, keystoneClient.EnsureRoleAssignment("admin-token", "p1", "u1", id))
+		assert.Equal(t, []string{"GET /v3/roles?name=ResellerAdmin", "POST /v3/roles", "PUT /v3/projects/p1/users/u1/roles/r1"}, ks.requests)
+	})
+
+	t.Run("should create missing domain and grant role to its group", func(t *testing.T) {
+		ks.reset(`{"domains": []}`, `{"domain": {"id": "d1"}}`, `{"groups": [{"id": "g1"}]}`, ``)
+		id, err := keystoneClient.EnsureDomain("admin-token", "corp", "LDAP backed domain")
+		require.NoError(t, err)
+		assert.Equal(t, "d1", id)
+		groups, err := keystoneClient.ListGroups("admin-token", "cloud-admins", id)
+		require.NoError(t, err)
+		require.NoError(t, keystoneClient.EnsureGroupRoleAssignment("admin-token", "p1", groups[0].ID, "r1"))
+		assert.Equal(t, []string{
+			"GET /v3/domains?name=corp",
+			"POST /v3/domains",
+			"GET /v3/groups?domain_id=d1&name=cloud-admins",
+			"PUT /v3/projects/p1/groups/g1/roles/r1",
+		}, ks.requests)
+		assert.Contains(t, ks.bodies[1], `"enabled":true`)
+	})
+
+	t.Run("should ignore already deleted resources", func(t *testing.T) {
+		ks.reset(``)
+		require.NoError(t, keystoneClient.DeleteUser("admin-token", "u1"))
+		require.NoError(t, keystoneClient.DeleteProject("admin-token", "p1"))
+		require.NoError(t, keystoneClient.DeleteRoleAssignment("admin-token", "p1", "u1", "r1"))
+		assert.Equal(t, []string{"DELETE /v3/users/u1", "DELETE /v3/projects/p1", "DELETE /v3/projects/p1/users/u1/roles/r1"}, ks.requests)
+	})
+
+	t.Run("should fail on unexpected status", func(t *testing.T) {
+		ks.reset()
+		ks.status = http.StatusInternalServerError
+		_, err := keystoneClient.ListRoles("admin-token", "admin")
+		assert.Error(t, err)
+		assert.False(t, keystone.IsUnauthorized(err))
+	})
+
+	t.Run("should register service with user and roles", func(t *testing.T) {
+		ks.reset(
+			`{"services": [{"id": "s1", "name": "swift"}]}`,
+			`{"endpoints": []}`, `{}`,
+			`{"endpoints": []}`, `{}`,
+			`{"projects": [{"id": "p1"}]}`,
+			`{"users": []}`, `{"user": {"id": "u1"}}`,
+			`{"roles": [{"id": "r1", "name": "admin"}]}`, ``,
+		)
+		err := keystoneClient.EnsureServiceRegistration("admin-token", keystone.ServiceRegistration{
+			Name:        "swift",
+			Type:        "object-store",
+			Description: "object store service",
+			Region:      "RegionOne",
+			Endpoints: map[string]string{
+				"public": "https://10.0.0.2:5080/v1",
+				"admin":  "https://10.0.0.1:5080/v1",
+			},
+			User:     "swift",
+			Password: "swiftpass",
+			Project:  "service",
+			Roles:    []string{"admin"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{
+			"GET /v3/services?type=object-store",
+			"GET /v3/endpoints?interface=admin&service_id=s1",
+			"POST /v3/endpoints",
+			"GET /v3/endpoints?interface=public&service_id=s1",
+			"POST /v3/endpoints",
+			"GET /v3/projects?domain_id=default&name=service",
+			"GET /v3/users?domain_id=ldap&name=swift",
+			"POST /v3/users",
+			"GET /v3/roles?name=admin",
+			"PUT /v3/projects/p1/users/u1/roles/r1",
+		}, ks.requests)
+		assert.Contains(t, ks.bodies[4], `"url":"https://10.0.0.2:5080/v1"`)
+		assert.Contains(t, ks.bodies[7], `"default_project_id":"p1"`)
+	})
+
+	t.Run("should return unauthorized error", func(t *testing.T) {
+		ks.reset()
+		ks.status = http.StatusUnauthorized
+		_, err := keystoneClient.EnsureProject("expired-token", "service", "default")
+		assert.True(t, keystone.IsUnauthorized(err))
+	})
+}
+
+type fakeKeystone struct {
+	responses []string
+	status    int
+	requests  []string
+	bodies    []string
+	token     string
+}
+
+func (f *fakeKeystone) reset(responses ...string) {
+	f.responses = responses
+	f.status = 0
+	f.requests = nil
+	f.bodies = nil
+}
+
+func (f *fakeKeystone) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := ioutil.ReadAll(r.Body)
+	request := r.Method + " " + r.URL.Path
+	if r.URL.RawQuery != "" {
+		request += "?" + r.URL.RawQuery
+	}
+	f.requests = append(f.requests, request)
+	f.bodies = append(f.bodies, string(body))
+	f.token = r.Header.Get("X-Auth-Token")
+	if f.status != 0 {
+		w.WriteHeader(f.status)
+		return
+	}
+	if len(f.responses) == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	response := f.responses[0]
+	f.responses = f.responses[1:]
+	if response == "" {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	w.Write([]byte(response))
+}
+
+type authRequest struct {
+	Auth struct {
+		Identity struct {
+			Password struct {
+				User struct {
+					Name     string
+					Password string
+					Domain   struct {
+						ID string
+					}
+				}
+			}
+		}
+		Scope struct {
+			Project struct {
+				Name string
+			}
+		}
+	}
+}
+
+func newExtKeystone(t *testing.T, srvURL, caBundleConfigMapName string) *contrail.Keystone {
+	u, err := url.Parse(srvURL)
+	require.NoError(t, err)
+	host, portStr, err := net.SplitHostPort(u.Host)
+	require.NoError(t, err)
+	port, err := strconv.Atoi(portStr)
+	require.NoError(t, err)
+	return &contrail.Keystone{
+		ObjectMeta: meta.ObjectMeta{Name: "keystone", Namespace: "default"},
+		Spec: contrail.KeystoneSpec{
+			ServiceConfiguration: contrail.KeystoneConfiguration{
+				ExternalAddress:       host,
+				ListenPort:            port,
+				AuthProtocol:          u.Scheme,
+				UserDomainID:          "ldap",
+				AdminUsername:         "cloud-admin",
+				AdminProject:          "operations",
+				CABundleConfigMapName: caBundleConfigMapName,
+			},
+		},
+	}
+}
+
+func scheme(t *testing.T) *runtime.Scheme {
+	scheme, err := contrail.SchemeBuilder.Build()
+	require.NoError(t, err)
+	require.NoError(t, core.SchemeBuilder.AddToScheme(scheme))
+	return scheme
+}
+
+func newFakeClient(t *testing.T, objs ...runtime.Object) client.Client {
+	return fake.NewFakeClientWithScheme(scheme(t), objs...)
+}
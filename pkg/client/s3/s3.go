@@ -0,0 +1,167 @@
+package s3
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	defaultRegion = "us-east-1"
+	timeFormat    = "20060102T150405Z"
+	dateFormat    = "20060102"
+)
+
+// NewClient returns a client of the S3 compatible object storage available at the endpoint.
+// Buckets are addressed with path-style URLs, which are supported by most implementations.
+func NewClient(endpoint, region, accessKey, secretKey string) (*Client, error) {
+	endpointURL, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if endpointURL.Scheme == "" || endpointURL.Host == "" {
+		return nil, fmt.Errorf("invalid endpoint %q: scheme and host are required", endpoint)
+	}
+	if region == "" {
+		region = defaultRegion
+	}
+	return &Client{
+		endpoint:  endpointURL,
+		region:    region,
+		accessKey: accessKey,
+		secretKey: secretKey,
+		client:    &http.Client{},
+		now:       time.Now,
+	}, nil
+}
+
+// Client uploads and downloads objects. Requests are signed with AWS Signature Version 4.
+type Client struct {
+	endpoint  *url.URL
+	region    string
+	accessKey string
+	secretKey string
+	client    *http.Client
+	now       func() time.Time
+}
+
+func (c *Client) PutObject(bucket, key string, content []byte) error {
+	response, err := c.do(http.MethodPut, bucket, key, content)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("invalid status code returned: %d, response: %s", response.StatusCode, c.response(response))
+	}
+	return nil
+}
+
+func (c *Client) GetObject(bucket, key string) ([]byte, error) {
+	response, err := c.do(http.MethodGet, bucket, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("invalid status code returned: %d, response: %s", response.StatusCode, c.response(response))
+	}
+	return ioutil.ReadAll(response.Body)
+}
+
+func (c *Client) do(method, bucket, key string, content []byte) (*http.Response, error) {
+	objectURL := *c.endpoint
+	objectURL.Path = strings.TrimSuffix(objectURL.Path, "/") + "/" + bucket + "/" + key
+	objectURL.RawPath = ""
+	request, err := http.NewRequest(method, objectURL.String(), bytes.NewReader(content))
+	if err != nil {
+		return nil, err
+	}
+	payloadHash := sha256.Sum256(content)
+	c.sign(request, hex.EncodeToString(payloadHash[:]))
+	return c.client.Do(request)
+}
+
+func (c *Client) response(response *http.Response) string {
+	bodyAsString, _ := ioutil.ReadAll(response.Body)
+	return string(bodyAsString)
+}
+
+// sign adds the Authorization header to the request. All headers of the request are signed.
+func (c *Client) sign(request *http.Request, payloadHash string) {
+	now := c.now().UTC()
+	request.Header.Set("X-Amz-Date", now.Format(timeFormat))
+	request.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{"host": request.URL.Host}
+	for name, values := range request.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	var names []string
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		request.Method,
+		canonicalURI(request.URL.Path),
+		request.URL.Query().Encode(),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	canonicalRequestHash := sha256.Sum256([]byte(canonicalRequest))
+	scope := now.Format(dateFormat) + "/" + c.region + "/s3/aws4_request"
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		now.Format(timeFormat),
+		scope,
+		hex.EncodeToString(canonicalRequestHash[:]),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+c.secretKey), now.Format(dateFormat))
+	key = hmacSHA256(key, c.region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	request.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		c.accessKey, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// canonicalURI encodes every byte of the path except unreserved characters and slashes.
+func canonicalURI(path string) string {
+	if path == "" {
+		return "/"
+	}
+	var encoded strings.Builder
+	for i := 0; i < len(path); i++ {
+		b := path[i]
+		if b >= 'A' && b <= 'Z' || b >= 'a' && b <= 'z' || b >= '0' && b <= '9' || strings.IndexByte("-._~/", b) >= 0 {
+			encoded.WriteByte(b)
+			continue
+		}
+		encoded.WriteString(fmt.Sprintf("%%%02X", b))
+	}
+	return encoded.String()
+}
@@ -0,0 +1,101 @@
+package swift
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/Juniper/contrail-operator/pkg/client/kubeproxy"
+)
+
+// NewReconClient returns a client of the recon middleware of a storage server,
+// which serves the data shown by swift-recon.
+func NewReconClient(client *kubeproxy.Client) *ReconClient {
+	return &ReconClient{proxy: client}
+}
+
+type ReconClient struct {
+	proxy *kubeproxy.Client
+}
+
+// DiskUsage is the usage of a device of the storage server. Size, Used and Avail
+// are set for mounted devices only. Mounted holds the error of the mount check
+// when it is not a bool.
+type DiskUsage struct {
+	Device  string      `json:"device"`
+	Mounted interface{} `json:"mounted"`
+	Size    interface{} `json:"size"`
+	Used    interface{} `json:"used"`
+	Avail   interface{} `json:"avail"`
+}
+
+// IsMounted returns true when the device passed the mount check.
+func (d DiskUsage) IsMounted() bool {
+	mounted, ok := d.Mounted.(bool)
+	return ok && mounted
+}
+
+// Bytes returns size, used and available bytes of the device.
+func (d DiskUsage) Bytes() (size, used, avail int64) {
+	return reconInt(d.Size), reconInt(d.Used), reconInt(d.Avail)
+}
+
+// Replication is the state of the object replicator of the storage server.
+type Replication struct {
+	// Last is the unix time the replicator completed its last pass. It is nil
+	// until the first pass completes.
+	Last *float64 `json:"object_replication_last"`
+	// Time is the duration of the last pass in minutes.
+	Time *float64 `json:"object_replication_time"`
+}
+
+// Quarantined holds numbers of quarantined objects, containers and accounts.
+type Quarantined struct {
+	Objects    int64 `json:"objects"`
+	Containers int64 `json:"containers"`
+	Accounts   int64 `json:"accounts"`
+}
+
+func (c *ReconClient) DiskUsage() ([]DiskUsage, error) {
+	var usage []DiskUsage
+	err := c.get("/recon/diskusage", &usage)
+	return usage, err
+}
+
+func (c *ReconClient) ObjectReplication() (Replication, error) {
+	replication := Replication{}
+	err := c.get("/recon/replication/object", &replication)
+	return replication, err
+}
+
+func (c *ReconClient) Quarantined() (Quarantined, error) {
+	quarantined := Quarantined{}
+	err := c.get("/recon/quarantined", &quarantined)
+	return quarantined, err
+}
+
+func (c *ReconClient) get(path string, v interface{}) error {
+	request, err := c.proxy.NewRequest(http.MethodGet, path, nil)
+	if err != nil {
+		return err
+	}
+	response, err := c.proxy.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(response.Body)
+		return fmt.Errorf("invalid status code returned: %d, response: %s", response.StatusCode, body)
+	}
+	return json.NewDecoder(response.Body).Decode(v)
+}
+
+// reconInt converts numbers of recon responses. Values of unmounted devices are empty strings.
+func reconInt(v interface{}) int64 {
+	if n, ok := v.(float64); ok {
+		return int64(n)
+	}
+	return 0
+}
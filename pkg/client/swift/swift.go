@@ -2,6 +2,7 @@ package swift
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
@@ -39,46 +40,98 @@ func (c *Client) PutReadAllContainer(name string) error {
 }
 
 func (c *Client) PutContainerWithHeaders(name string, headers http.Header) error {
-	request, err := c.proxy.NewRequest(http.MethodPut, c.path+"/"+name, nil)
+	response, err := c.do(http.MethodPut, c.path+"/"+name, headers)
 	if err != nil {
 		return err
 	}
-	if c.token != "" {
-		request.Header.Set("X-Auth-Token", c.token)
-	}
-	for name, values := range headers {
-		for _, value := range values {
-			request.Header.Add(name, value)
-		}
+	defer response.Body.Close()
+	if response.StatusCode >= 300 {
+		return fmt.Errorf("invalid status code returned: %d, response: %s", response.StatusCode, c.response(response))
 	}
-	response, err := c.proxy.Do(request)
+	return nil
+}
+
+func (c *Client) GetContainer(name string) error {
+	response, err := c.do(http.MethodGet, c.path+"/"+name, nil)
 	if err != nil {
 		return err
 	}
+	defer response.Body.Close()
 	if response.StatusCode >= 300 {
 		return fmt.Errorf("invalid status code returned: %d, response: %s", response.StatusCode, c.response(response))
 	}
 	return nil
 }
 
-func (c *Client) GetContainer(name string) error {
-	request, err := c.proxy.NewRequest(http.MethodGet, c.path+"/"+name, nil)
+// ErrNotFound is returned when the container does not exist.
+var ErrNotFound = errors.New("not found")
+
+// HeadContainer returns headers of the container with its metadata,
+// ACLs and usage, e.g. X-Container-Object-Count and X-Container-Bytes-Used.
+func (c *Client) HeadContainer(name string) (http.Header, error) {
+	response, err := c.do(http.MethodHead, c.path+"/"+name, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	if response.StatusCode >= 300 {
+		return nil, fmt.Errorf("invalid status code returned: %d, response: %s", response.StatusCode, c.response(response))
+	}
+	return response.Header, nil
+}
+
+// PostContainer sets metadata headers of the existing container. Headers not
+// passed are left unchanged and X-Remove-* headers remove them.
+func (c *Client) PostContainer(name string, headers http.Header) error {
+	response, err := c.do(http.MethodPost, c.path+"/"+name, headers)
 	if err != nil {
 		return err
 	}
-	if c.token != "" {
-		request.Header.Set("X-Auth-Token", c.token)
+	defer response.Body.Close()
+	if response.StatusCode == http.StatusNotFound {
+		return ErrNotFound
 	}
-	response, err := c.proxy.Do(request)
+	if response.StatusCode >= 300 {
+		return fmt.Errorf("invalid status code returned: %d, response: %s", response.StatusCode, c.response(response))
+	}
+	return nil
+}
+
+// DeleteContainer deletes the empty container. Swift refuses to delete containers with objects.
+func (c *Client) DeleteContainer(name string) error {
+	response, err := c.do(http.MethodDelete, c.path+"/"+name, nil)
 	if err != nil {
 		return err
 	}
+	defer response.Body.Close()
+	if response.StatusCode == http.StatusNotFound {
+		return ErrNotFound
+	}
 	if response.StatusCode >= 300 {
 		return fmt.Errorf("invalid status code returned: %d, response: %s", response.StatusCode, c.response(response))
 	}
 	return nil
 }
 
+func (c *Client) do(method, path string, headers http.Header) (*http.Response, error) {
+	request, err := c.proxy.NewRequest(method, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	if c.token != "" {
+		request.Header.Set("X-Auth-Token", c.token)
+	}
+	for name, values := range headers {
+		for _, value := range values {
+			request.Header.Add(name, value)
+		}
+	}
+	return c.proxy.Do(request)
+}
+
 func (c *Client) response(response *http.Response) string {
 	bodyAsString, _ := ioutil.ReadAll(response.Body)
 	return string(bodyAsString)
@@ -154,7 +154,7 @@ var auth = {};
 auth.admin_user = '{{ .AdminUsername }}';
 auth.admin_password = '{{ .AdminPassword }}';
 auth.admin_token = '';
-auth.admin_tenant_name = '{{ .AdminUsername }}';
+auth.admin_tenant_name = '{{ .AdminProject }}';
 auth.project_domain_name = '{{ .KeystoneProjectDomainName }}';
 auth.user_domain_name = '{{ .KeystoneUserDomainName }}';
 module.exports = auth;
@@ -2,7 +2,9 @@ package configuration
 
 import (
 	"fmt"
+	"sort"
 	"strconv"
+	"strings"
 
 	"text/template"
 
@@ -25,23 +27,30 @@ skipACL=yes
 dynamicConfigFile=/var/lib/zookeeper/zoo.cfg.dynamic
 `))
 
+// DynamicZookeeperConfig renders the initial dynamic configuration of each pod. A pod
+// starts with servers of pods with lower ordinals and itself, as pods are started
+// one by one and added to the ensemble with reconfig by the controller.
 func DynamicZookeeperConfig(pods []core.Pod, electionPort, serverPort, clientPort string) (map[string]string, error) {
 	dynamicConf := make(map[string]string, 0)
-	var firstServerDef string
+	myids := make(map[string]int, len(pods))
 	for _, pod := range pods {
-		myidString := pod.Name[len(pod.Name)-1:]
-		myidInt, err := strconv.Atoi(myidString)
+		ordinal, err := strconv.Atoi(pod.Name[strings.LastIndex(pod.Name, "-")+1:])
 		if err != nil {
 			return nil, err
 		}
-		serverDef := firstServerDef + fmt.Sprintf("server.%d=%s:%s:participant;%s:%s\n",
-			myidInt+1, pod.Status.PodIP,
+		myids[pod.Name] = ordinal + 1
+	}
+	sorted := make([]core.Pod, len(pods))
+	copy(sorted, pods)
+	sort.SliceStable(sorted, func(i, j int) bool { return myids[sorted[i].Name] < myids[sorted[j].Name] })
+	var serverDefs string
+	for _, pod := range sorted {
+		myid := myids[pod.Name]
+		serverDefs += fmt.Sprintf("server.%d=%s:%s:participant;%s:%s\n",
+			myid, pod.Status.PodIP,
 			electionPort+":"+serverPort, pod.Status.PodIP, clientPort)
-		if myidInt == 0 {
-			firstServerDef = serverDef
-		}
-		dynamicConf["myid."+pod.Status.PodIP] = strconv.Itoa(myidInt + 1)
-		dynamicConf["zoo.cfg.dynamic."+pod.Status.PodIP] = serverDef
+		dynamicConf["myid."+pod.Status.PodIP] = strconv.Itoa(myid)
+		dynamicConf["zoo.cfg.dynamic."+pod.Status.PodIP] = serverDefs
 	}
 	return dynamicConf, nil
 }
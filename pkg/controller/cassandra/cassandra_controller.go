@@ -101,8 +101,9 @@ func Add(mgr manager.Manager) error {
 }
 
 func newReconciler(mgr manager.Manager) reconcile.Reconciler {
-	kubernetes := k8s.New(mgr.GetClient(), mgr.GetScheme())
-	return &ReconcileCassandra{Client: mgr.GetClient(), Scheme: mgr.GetScheme(), Manager: mgr, Kubernetes: kubernetes}
+	client := utils.NewEventRecordingClient(mgr.GetClient(), mgr.GetEventRecorderFor("cassandra-controller"))
+	kubernetes := k8s.New(client, mgr.GetScheme())
+	return &ReconcileCassandra{Client: client, Scheme: mgr.GetScheme(), Manager: mgr, Kubernetes: kubernetes}
 }
 
 // add adds a new Controller to mgr with r as the reconcile.Reconciler.
@@ -220,6 +221,11 @@ func (r *ReconcileCassandra) Reconcile(request reconcile.Request) (reconcile.Res
 	if err = instance.PrepareSTS(statefulSet, &instance.Spec.CommonConfiguration, request, r.Scheme, r.Client); err != nil {
 		return reconcile.Result{}, err
 	}
+	replicas, requeue, err := r.statefulSetReplicas(instance)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	statefulSet.Spec.Replicas = &replicas
 
 	csrSignerCaVolumeName := request.Name + "-csr-signer-ca"
 	instance.AddVolumesToIntendedSTS(statefulSet, map[string]string{
@@ -522,6 +528,9 @@ func (r *ReconcileCassandra) Reconcile(request reconcile.Request) (reconcile.Res
 		if err = instance.SetPodsToReady(podIPList, r.Client); err != nil {
 			return reconcile.Result{}, err
 		}
+		if err = r.removeDeadNodes(instance, podIPList.Items, replicas); err != nil {
+			return reconcile.Result{}, err
+		}
 		if err = instance.ManageNodeStatus(podIPMap, r.Client); err != nil {
 			return reconcile.Result{}, err
 		}
@@ -551,11 +560,14 @@ func (r *ReconcileCassandra) Reconcile(request reconcile.Request) (reconcile.Res
 		return reconcile.Result{}, err
 	}
 
+	if requeue || len(instance.Status.RemovingNodes) > 0 {
+		return reconcile.Result{RequeueAfter: decommissionRequeuePeriod}, nil
+	}
 	return reconcile.Result{}, nil
 }
 
 func (r *ReconcileCassandra) ensureCertificatesExist(cassandra *v1alpha1.Cassandra, pods *corev1.PodList, serviceIP string, instanceType string) error {
 	subjects := cassandra.PodsCertSubjects(pods, serviceIP)
-	crt := certificates.NewCertificate(r.Client, r.Scheme, cassandra, subjects, instanceType)
+	crt := certificates.NewCertificate(r.Client, r.Scheme, cassandra, subjects, instanceType, utils.CertificateKeyConfig(cassandra.Spec.CommonConfiguration.CertificateKey))
 	return crt.EnsureExistsAndIsSigned()
 }
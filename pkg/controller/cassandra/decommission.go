@@ -0,0 +1,271 @@
+package cassandra
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/Juniper/contrail-operator/pkg/apis/contrail/v1alpha1"
+	"github.com/Juniper/contrail-operator/pkg/controller/utils"
+	"github.com/Juniper/contrail-operator/pkg/job"
+	"github.com/Juniper/contrail-operator/pkg/k8s"
+)
+
+// decommissionRequeuePeriod is how often the cluster is checked while
+// nodes are decommissioned or removed.
+const decommissionRequeuePeriod = 10 * time.Second
+
+var execToPod = k8s.ExecToPodThroughAPI
+
+var (
+	netstatsModeRegexp    = regexp.MustCompile(`(?m)^Mode: (\w+)`)
+	netstatsSendingRegexp = regexp.MustCompile(`(?m)^\s*(Sending .*)$`)
+	statusNodeRegexp      = regexp.MustCompile(`(?m)^([UD][NLJM])\s+(\S+)\s.*\s([0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12})(\s|$)`)
+)
+
+// ringNode is a node of the cluster as listed by nodetool status.
+type ringNode struct {
+	state   string
+	address string
+	hostID  string
+}
+
+func parseStatus(out string) []ringNode {
+	var nodes []ringNode
+	for _, match := range statusNodeRegexp.FindAllStringSubmatch(out, -1) {
+		nodes = append(nodes, ringNode{state: match[1], address: match[2], hostID: match[3]})
+	}
+	return nodes
+}
+
+// streamingProgress summarizes data streams listed by nodetool netstats.
+func streamingProgress(netstats string) string {
+	var streams []string
+	for _, match := range netstatsSendingRegexp.FindAllStringSubmatch(netstats, -1) {
+		streams = append(streams, strings.TrimSpace(match[1]))
+	}
+	return strings.Join(streams, "; ")
+}
+
+// statefulSetReplicas returns the number of replicas to set in the StatefulSet.
+// When replicas are reduced, the node of the highest pod is decommissioned first
+// and the StatefulSet is shrunk by one pod once the node left the cluster. Data
+// of the removed pod is wiped, so that the pod joins as a new node when replicas
+// are raised again. A started decommission is always completed. It returns true
+// when the cluster has to be checked again.
+func (r *ReconcileCassandra) statefulSetReplicas(cassandra *v1alpha1.Cassandra) (int32, bool, error) {
+	desired := cassandra.Spec.CommonConfiguration.GetReplicas()
+	sts := &appsv1.StatefulSet{}
+	err := r.Client.Get(context.TODO(), types.NamespacedName{Name: cassandra.Name + "-cassandra-statefulset", Namespace: cassandra.Namespace}, sts)
+	if errors.IsNotFound(err) {
+		return desired, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	current := int32(1)
+	if sts.Spec.Replicas != nil {
+		current = *sts.Spec.Replicas
+	}
+	podName := fmt.Sprintf("%s-%d", sts.Name, current-1)
+	status := cassandra.Status.Decommission
+	if status != nil && status.Phase == v1alpha1.CassandraDecommissioned {
+		if status.Pod == podName {
+			return current - 1, true, nil
+		}
+		done, err := r.cleanUpDecommissioned(cassandra, status.Pod)
+		if err != nil || !done {
+			return current, true, err
+		}
+		cassandra.Status.Decommission = nil
+		status = nil
+	}
+	if current == 0 {
+		return desired, false, nil
+	}
+	if status != nil && status.Pod != podName {
+		status = nil
+	}
+	if status == nil && desired >= current {
+		cassandra.Status.Decommission = nil
+		return desired, false, nil
+	}
+	if status == nil {
+		now := metav1.Now()
+		status = &v1alpha1.CassandraDecommissionStatus{Pod: podName, Phase: v1alpha1.CassandraDecommissioning, StartTime: &now}
+		cassandra.Status.Decommission = status
+	}
+	pod := &corev1.Pod{}
+	err = r.Client.Get(context.TODO(), types.NamespacedName{Name: podName, Namespace: cassandra.Namespace}, pod)
+	if err != nil && !errors.IsNotFound(err) {
+		return 0, false, err
+	}
+	if errors.IsNotFound(err) || pod.Status.Phase != corev1.PodRunning || pod.Status.PodIP == "" {
+		log.Info("Cassandra node is not running, removing its pod", "Pod", podName)
+		status.Phase = v1alpha1.CassandraDecommissioned
+		status.Message = "node is not running, it is removed from the cluster once it is dead"
+		return current - 1, true, nil
+	}
+	jmxPort := *cassandra.ConfigurationParameters().JmxLocalPort
+	netstats, err := nodetool(pod, jmxPort, "netstats")
+	if err != nil {
+		return current, true, err
+	}
+	mode := ""
+	if match := netstatsModeRegexp.FindStringSubmatch(netstats); match != nil {
+		mode = match[1]
+	}
+	switch mode {
+	case "NORMAL":
+		log.Info("Decommissioning Cassandra node", "Pod", podName)
+		lastOutput, err := startNodetool(pod, jmxPort, "decommission")
+		status.Message = lastOutput
+		return current, true, err
+	case "LEAVING":
+		status.Message = streamingProgress(netstats)
+	case "DECOMMISSIONED":
+		log.Info("Cassandra node decommissioned, shrinking StatefulSet", "Pod", podName, "Replicas", current-1)
+		status.Phase = v1alpha1.CassandraDecommissioned
+		status.Message = ""
+		return current - 1, true, nil
+	default:
+		status.Message = "waiting for node in mode " + mode
+	}
+	return current, true, nil
+}
+
+// cleanUpDecommissioned wipes data of the claim of the removed pod and deletes
+// the claim and its volume. It returns true once the claim is deleted.
+func (r *ReconcileCassandra) cleanUpDecommissioned(cassandra *v1alpha1.Cassandra, podName string) (bool, error) {
+	namespace := cassandra.Namespace
+	err := r.Client.Get(context.TODO(), types.NamespacedName{Name: podName, Namespace: namespace}, &corev1.Pod{})
+	if err == nil {
+		return false, nil
+	}
+	if !errors.IsNotFound(err) {
+		return false, err
+	}
+	claim := &corev1.PersistentVolumeClaim{}
+	err = r.Client.Get(context.TODO(), types.NamespacedName{Name: "pvc-" + podName, Namespace: namespace}, claim)
+	if errors.IsNotFound(err) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if claim.Status.Phase == corev1.ClaimBound {
+		wipe := utils.WipeJob(claim, utils.WipeImage(cassandra.Spec.ServiceConfiguration.Containers))
+		err := r.Client.Get(context.TODO(), types.NamespacedName{Name: wipe.Name, Namespace: wipe.Namespace}, wipe)
+		if errors.IsNotFound(err) {
+			log.Info("Wiping data of decommissioned Cassandra node", "Claim", claim.Name)
+			return false, r.Client.Create(context.TODO(), utils.WipeJob(claim, utils.WipeImage(cassandra.Spec.ServiceConfiguration.Containers)))
+		}
+		if err != nil {
+			return false, err
+		}
+		if job.Job(*wipe).JobFailed() {
+			return false, fmt.Errorf("job %s failed to wipe data of persistent volume claim %s", wipe.Name, claim.Name)
+		}
+		if !job.Job(*wipe).JobCompleted() {
+			return false, nil
+		}
+		background := client.PropagationPolicy(metav1.DeletePropagationBackground)
+		if err := r.Client.Delete(context.TODO(), wipe, background); err != nil && !errors.IsNotFound(err) {
+			return false, err
+		}
+	}
+	log.Info("Deleting persistent volume claim of decommissioned Cassandra node", "Claim", claim.Name)
+	if err := r.Client.Delete(context.TODO(), claim); err != nil && !errors.IsNotFound(err) {
+		return false, err
+	}
+	if claim.Spec.VolumeName != "" {
+		pv := &corev1.PersistentVolume{ObjectMeta: metav1.ObjectMeta{Name: claim.Spec.VolumeName}}
+		if err := r.Client.Delete(context.TODO(), pv); err != nil && !errors.IsNotFound(err) {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// removeDeadNodes removes nodes which are down and are not run by any pod of the
+// StatefulSet, one node at a time. Nodes are checked only when all pods are ready
+// and no node is decommissioned, so that restarting pods are not taken for dead
+// nodes. Host IDs of nodes being removed are stored in the status.
+func (r *ReconcileCassandra) removeDeadNodes(cassandra *v1alpha1.Cassandra, pods []corev1.Pod, replicas int32) error {
+	if cassandra.Status.Decommission != nil || len(pods) == 0 || len(pods) != int(replicas) {
+		return nil
+	}
+	addresses := map[string]bool{}
+	for i := range pods {
+		if !podReady(&pods[i]) {
+			return nil
+		}
+		addresses[pods[i].Status.PodIP] = true
+	}
+	pod := &pods[0]
+	jmxPort := *cassandra.ConfigurationParameters().JmxLocalPort
+	out, err := nodetool(pod, jmxPort, "status")
+	if err != nil {
+		return err
+	}
+	var dead, removing []string
+	for _, node := range parseStatus(out) {
+		if addresses[node.address] {
+			continue
+		}
+		switch node.state {
+		case "DN":
+			dead = append(dead, node.hostID)
+		case "DL":
+			removing = append(removing, node.hostID)
+		}
+	}
+	if len(removing) == 0 && len(dead) > 0 {
+		log.Info("Removing dead Cassandra node", "HostID", dead[0])
+		if _, err := startNodetool(pod, jmxPort, "removenode "+dead[0]); err != nil {
+			return err
+		}
+		removing = append(removing, dead[0])
+	}
+	cassandra.Status.RemovingNodes = removing
+	return nil
+}
+
+func nodetool(pod *corev1.Pod, jmxPort int, args string) (string, error) {
+	runScript := fmt.Sprintf("nodetool -p %d %s", jmxPort, args)
+	stdout, stderr, err := execToPod([]string{"bash", "-c", runScript}, "cassandra", pod.Name, pod.Namespace, nil)
+	if err != nil {
+		return "", fmt.Errorf("nodetool %s failed on pod %s: %v: %s", args, pod.Name, err, stderr)
+	}
+	return stdout, nil
+}
+
+// startNodetool runs the long running nodetool command in the background of the pod
+// and returns the last line of output of its previous run.
+func startNodetool(pod *corev1.Pod, jmxPort int, args string) (string, error) {
+	logFile := "/tmp/nodetool-" + strings.Fields(args)[0] + ".log"
+	runScript := fmt.Sprintf("tail -n 1 %s 2>/dev/null; nohup nodetool -p %d %s > %s 2>&1 &", logFile, jmxPort, args, logFile)
+	stdout, stderr, err := execToPod([]string{"bash", "-c", runScript}, "cassandra", pod.Name, pod.Namespace, nil)
+	if err != nil {
+		return "", fmt.Errorf("nodetool %s failed on pod %s: %v: %s", args, pod.Name, err, stderr)
+	}
+	return strings.TrimSpace(stdout), nil
+}
+
+func podReady(pod *corev1.Pod) bool {
+	for _, c := range pod.Status.Conditions {
+		if c.Type == corev1.PodReady {
+			return c.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
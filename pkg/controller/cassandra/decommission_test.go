@@ -0,0 +1,184 @@
+package cassandra
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apps "k8s.io/api/apps/v1"
+	batch "k8s.io/api/batch/v1"
+	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	contrail "github.com/Juniper/contrail-operator/pkg/apis/contrail/v1alpha1"
+)
+
+const nodetoolStatus = `Datacenter: datacenter1
+=======================
+Status=Up/Down
+|/ State=Normal/Leaving/Joining/Moving
+--  Address   Load       Tokens       Owns (effective)  Host ID                               Rack
+UN  10.0.0.1  1.2 MiB    256          66.7%             5b4e5d33-1c86-4e4a-9d0d-6f1b2c7a0c01  rack1
+UN  10.0.0.2  1.1 MiB    256          66.7%             5b4e5d33-1c86-4e4a-9d0d-6f1b2c7a0c02  rack1
+DN  10.0.0.9  ?          256          66.7%             5b4e5d33-1c86-4e4a-9d0d-6f1b2c7a0c09  rack1
+`
+
+func TestCassandraScaleDown(t *testing.T) {
+	scheme, err := contrail.SchemeBuilder.Build()
+	require.NoError(t, err)
+	require.NoError(t, core.SchemeBuilder.AddToScheme(scheme))
+	require.NoError(t, apps.SchemeBuilder.AddToScheme(scheme))
+	require.NoError(t, batch.SchemeBuilder.AddToScheme(scheme))
+
+	newSTS := func(replicas int32) *apps.StatefulSet {
+		return &apps.StatefulSet{
+			ObjectMeta: meta.ObjectMeta{Name: "cassandra-cassandra-statefulset", Namespace: "default"},
+			Spec:       apps.StatefulSetSpec{Replicas: &replicas},
+		}
+	}
+	newPod := func(ordinal int) *core.Pod {
+		return &core.Pod{
+			ObjectMeta: meta.ObjectMeta{Name: fmt.Sprintf("cassandra-cassandra-statefulset-%d", ordinal), Namespace: "default"},
+			Status: core.PodStatus{
+				Phase:      core.PodRunning,
+				PodIP:      fmt.Sprintf("10.0.0.%d", ordinal+1),
+				Conditions: []core.PodCondition{{Type: core.PodReady, Status: core.ConditionTrue}},
+			},
+		}
+	}
+	scaledCassandra := func(replicas int32, decommission *contrail.CassandraDecommissionStatus) *contrail.Cassandra {
+		cassandra := newCassandra()
+		cassandra.Spec.CommonConfiguration.Replicas = &replicas
+		cassandra.Status.Decommission = decommission
+		return cassandra
+	}
+	restoreExecToPod := execToPod
+	defer func() { execToPod = restoreExecToPod }()
+	var executed []string
+	// fakeNodetool answers nodetool commands with outputs by subcommand.
+	fakeNodetool := func(outputs map[string]string) {
+		executed = nil
+		execToPod = func(command []string, container, pod, namespace string, stdin io.Reader) (string, string, error) {
+			assert.Equal(t, "cassandra", container)
+			executed = append(executed, pod+": "+command[2])
+			for subcommand, output := range outputs {
+				if strings.Contains(command[2], "nodetool -p 7200 "+subcommand) {
+					return output, "", nil
+				}
+			}
+			return "", "", nil
+		}
+	}
+
+	t.Run("should decommission highest pod before shrinking StatefulSet", func(t *testing.T) {
+		fakeNodetool(map[string]string{"netstats": "Mode: NORMAL\nNot sending any streams.\n"})
+		cassandra := scaledCassandra(2, nil)
+		r := &ReconcileCassandra{Client: fake.NewFakeClientWithScheme(scheme, cassandra, newSTS(3), newPod(2))}
+		// when
+		replicas, requeue, err := r.statefulSetReplicas(cassandra)
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, int32(3), replicas)
+		assert.True(t, requeue)
+		require.Len(t, executed, 2)
+		assert.Contains(t, executed[1], "cassandra-cassandra-statefulset-2: ")
+		assert.Contains(t, executed[1], "nohup nodetool -p 7200 decommission > /tmp/nodetool-decommission.log 2>&1 &")
+		require.NotNil(t, cassandra.Status.Decommission)
+		assert.Equal(t, "cassandra-cassandra-statefulset-2", cassandra.Status.Decommission.Pod)
+		assert.Equal(t, contrail.CassandraDecommissioning, cassandra.Status.Decommission.Phase)
+	})
+
+	t.Run("should report streaming while node is leaving", func(t *testing.T) {
+		fakeNodetool(map[string]string{"netstats": "Mode: LEAVING\nUnbootstrap 8a5b\n    /10.0.0.1\n        Sending 4 files, 1048576 bytes total. Already sent 1 files, 262144 bytes total\n"})
+		cassandra := scaledCassandra(2, &contrail.CassandraDecommissionStatus{Pod: "cassandra-cassandra-statefulset-2", Phase: contrail.CassandraDecommissioning})
+		r := &ReconcileCassandra{Client: fake.NewFakeClientWithScheme(scheme, cassandra, newSTS(3), newPod(2))}
+		// when
+		replicas, requeue, err := r.statefulSetReplicas(cassandra)
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, int32(3), replicas)
+		assert.True(t, requeue)
+		assert.Len(t, executed, 1)
+		assert.Equal(t, "Sending 4 files, 1048576 bytes total. Already sent 1 files, 262144 bytes total", cassandra.Status.Decommission.Message)
+	})
+
+	t.Run("should shrink StatefulSet once node is decommissioned", func(t *testing.T) {
+		fakeNodetool(map[string]string{"netstats": "Mode: DECOMMISSIONED\nNot sending any streams.\n"})
+		cassandra := scaledCassandra(1, &contrail.CassandraDecommissionStatus{Pod: "cassandra-cassandra-statefulset-2", Phase: contrail.CassandraDecommissioning})
+		r := &ReconcileCassandra{Client: fake.NewFakeClientWithScheme(scheme, cassandra, newSTS(3), newPod(2))}
+		// when
+		replicas, requeue, err := r.statefulSetReplicas(cassandra)
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, int32(2), replicas)
+		assert.True(t, requeue)
+		assert.Equal(t, contrail.CassandraDecommissioned, cassandra.Status.Decommission.Phase)
+	})
+
+	t.Run("should wipe and delete claim of removed pod", func(t *testing.T) {
+		fakeNodetool(nil)
+		cassandra := scaledCassandra(2, &contrail.CassandraDecommissionStatus{Pod: "cassandra-cassandra-statefulset-2", Phase: contrail.CassandraDecommissioned})
+		claim := &core.PersistentVolumeClaim{
+			ObjectMeta: meta.ObjectMeta{Name: "pvc-cassandra-cassandra-statefulset-2", Namespace: "default"},
+			Spec:       core.PersistentVolumeClaimSpec{VolumeName: "cassandra-pv-2"},
+			Status:     core.PersistentVolumeClaimStatus{Phase: core.ClaimBound},
+		}
+		pv := &core.PersistentVolume{ObjectMeta: meta.ObjectMeta{Name: "cassandra-pv-2"}}
+		cl := fake.NewFakeClientWithScheme(scheme, cassandra, newSTS(2), claim, pv)
+		r := &ReconcileCassandra{Client: cl}
+		// when
+		replicas, requeue, err := r.statefulSetReplicas(cassandra)
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, int32(2), replicas)
+		assert.True(t, requeue)
+		wipe := &batch.Job{}
+		require.NoError(t, cl.Get(context.Background(), types.NamespacedName{Name: "pvc-cassandra-cassandra-statefulset-2-wipe", Namespace: "default"}, wipe))
+		assert.Equal(t, claim.Name, wipe.Spec.Template.Spec.Volumes[0].PersistentVolumeClaim.ClaimName)
+		assert.Equal(t, "localhost:5000/busybox:1.31", wipe.Spec.Template.Spec.Containers[0].Image)
+
+		// when
+		wipe.Status.Conditions = []batch.JobCondition{{Type: batch.JobComplete, Status: core.ConditionTrue}}
+		require.NoError(t, cl.Update(context.Background(), wipe))
+		replicas, requeue, err = r.statefulSetReplicas(cassandra)
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, int32(2), replicas)
+		assert.False(t, requeue)
+		assert.Nil(t, cassandra.Status.Decommission)
+		assert.True(t, errors.IsNotFound(cl.Get(context.Background(), types.NamespacedName{Name: claim.Name, Namespace: "default"}, &core.PersistentVolumeClaim{})))
+		assert.True(t, errors.IsNotFound(cl.Get(context.Background(), types.NamespacedName{Name: pv.Name}, &core.PersistentVolume{})))
+	})
+
+	t.Run("should remove dead node not run by any pod", func(t *testing.T) {
+		fakeNodetool(map[string]string{"status": nodetoolStatus})
+		cassandra := scaledCassandra(2, nil)
+		r := &ReconcileCassandra{Client: fake.NewFakeClientWithScheme(scheme, cassandra)}
+		// when
+		err := r.removeDeadNodes(cassandra, []core.Pod{*newPod(0), *newPod(1)}, 2)
+		// then
+		require.NoError(t, err)
+		require.Len(t, executed, 2)
+		assert.Contains(t, executed[1], "nohup nodetool -p 7200 removenode 5b4e5d33-1c86-4e4a-9d0d-6f1b2c7a0c09")
+		assert.Equal(t, []string{"5b4e5d33-1c86-4e4a-9d0d-6f1b2c7a0c09"}, cassandra.Status.RemovingNodes)
+	})
+
+	t.Run("should not remove nodes while pods are not ready", func(t *testing.T) {
+		fakeNodetool(map[string]string{"status": nodetoolStatus})
+		cassandra := scaledCassandra(3, nil)
+		r := &ReconcileCassandra{Client: fake.NewFakeClientWithScheme(scheme, cassandra)}
+		// when
+		err := r.removeDeadNodes(cassandra, []core.Pod{*newPod(0), *newPod(1)}, 3)
+		// then
+		require.NoError(t, err)
+		assert.Empty(t, executed)
+		assert.Empty(t, cassandra.Status.RemovingNodes)
+	})
+}
@@ -0,0 +1,256 @@
+package cassandrabackup
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	contrail "github.com/Juniper/contrail-operator/pkg/apis/contrail/v1alpha1"
+	"github.com/Juniper/contrail-operator/pkg/controller/utils"
+	"github.com/Juniper/contrail-operator/pkg/k8s"
+)
+
+var log = logf.Log.WithName("controller_cassandrabackup")
+
+// execToPod runs commands in Cassandra pods. It is replaced in tests.
+var execToPod func(command []string, containerName, podName, namespace string, stdin io.Reader) (string, string, error) = k8s.ExecToPodThroughAPI
+
+// Add creates controllers of CassandraBackup and CassandraRestore and adds them to the Manager.
+func Add(mgr manager.Manager) error {
+	stores := newStoreFactory(mgr.GetClient(), mgr.GetScheme(), mgr.GetConfig())
+	backup := NewReconcileCassandraBackup(mgr.GetClient(), mgr.GetScheme(), mgr.GetEventRecorderFor("cassandrabackup-controller"), stores)
+	if _, err := add(mgr, "cassandrabackup-controller", &contrail.CassandraBackup{}, backup, backupsOf); err != nil {
+		return err
+	}
+	restore := NewReconcileCassandraRestore(mgr.GetClient(), mgr.GetScheme(), mgr.GetEventRecorderFor("cassandrarestore-controller"), stores)
+	c, err := add(mgr, "cassandrarestore-controller", &contrail.CassandraRestore{}, restore, restoresOf)
+	if err != nil {
+		return err
+	}
+	return c.Watch(&source.Kind{Type: &contrail.CassandraBackup{}}, &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: handler.ToRequestsFunc(func(o handler.MapObject) []reconcile.Request {
+			return restoresOfBackup(mgr.GetClient(), o)
+		}),
+	})
+}
+
+// add adds a new Controller watching resources of the given type and Cassandras they use.
+func add(mgr manager.Manager, name string, object runtime.Object, r reconcile.Reconciler, mapper func(client.Client, handler.MapObject) []reconcile.Request) (controller.Controller, error) {
+	c, err := controller.New(name, mgr, controller.Options{Reconciler: r})
+	if err != nil {
+		return nil, err
+	}
+	if err = c.Watch(&source.Kind{Type: object}, &handler.EnqueueRequestForObject{}); err != nil {
+		return nil, err
+	}
+	err = c.Watch(&source.Kind{Type: &contrail.Cassandra{}}, &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: handler.ToRequestsFunc(func(o handler.MapObject) []reconcile.Request {
+			return mapper(mgr.GetClient(), o)
+		}),
+	})
+	return c, err
+}
+
+func backupsOf(c client.Client, o handler.MapObject) []reconcile.Request {
+	backups := &contrail.CassandraBackupList{}
+	if err := c.List(context.TODO(), backups, client.InNamespace(o.Meta.GetNamespace())); err != nil {
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, b := range backups.Items {
+		if b.Spec.CassandraInstance == o.Meta.GetName() && !b.Finished() {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: b.Name, Namespace: b.Namespace}})
+		}
+	}
+	return requests
+}
+
+// blank assignment to verify that ReconcileCassandraBackup implements reconcile.Reconciler
+var _ reconcile.Reconciler = &ReconcileCassandraBackup{}
+
+// ReconcileCassandraBackup reconciles a CassandraBackup object
+type ReconcileCassandraBackup struct {
+	client   client.Client
+	scheme   *runtime.Scheme
+	recorder record.EventRecorder
+	stores   storeFactory
+}
+
+func NewReconcileCassandraBackup(client client.Client, scheme *runtime.Scheme, recorder record.EventRecorder, stores storeFactory) *ReconcileCassandraBackup {
+	return &ReconcileCassandraBackup{client: client, scheme: scheme, recorder: recorder, stores: stores}
+}
+
+// Reconcile takes a snapshot of every node of the Cassandra and uploads it to the target.
+// Nodes are backed up one by one and progress is kept in the status, so that a backup
+// interrupted by a restart of the operator continues with the remaining nodes.
+func (r *ReconcileCassandraBackup) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	reqLogger := log.WithValues("Request.Namespace", request.Namespace, "Request.Name", request.Name)
+	reqLogger.Info("Reconciling CassandraBackup")
+	backup := &contrail.CassandraBackup{}
+	if err := r.client.Get(context.TODO(), request.NamespacedName, backup); err != nil {
+		if errors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+	if !backup.GetDeletionTimestamp().IsZero() || backup.Finished() {
+		return reconcile.Result{}, nil
+	}
+
+	cassandra, ready, err := getActiveCassandra(r.client, backup.Namespace, backup.Spec.CassandraInstance)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if !ready {
+		if backup.Status.Phase == "" {
+			backup.Status.Phase = contrail.BackupPending
+		}
+		return reconcile.Result{}, contrail.WaitForDependency(r.client, r.recorder, backup, &backup.Status.Conditions, "Cassandra", backup.Spec.CassandraInstance)
+	}
+
+	if backup.Status.Phase != contrail.BackupRunning {
+		now := meta.Now()
+		backup.Status.Phase = contrail.BackupRunning
+		backup.Status.StartTime = &now
+		backup.Status.Path = backup.Name + "-" + now.UTC().Format("20060102150405")
+		contrail.SetActiveConditions(&backup.Status.Conditions, backup.Generation, false, "BackupRunning", "")
+		if err = r.client.Status().Update(context.TODO(), backup); err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+
+	store, err := r.stores(backup.Namespace, backup.Spec.Target)
+	if err != nil {
+		return reconcile.Result{}, r.fail(backup, fmt.Errorf("failed to access backup target: %v", err))
+	}
+	pods, err := runningPods(r.client, cassandra)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	cqlPort := *cassandra.ConfigurationParameters().CqlPort
+	for _, pod := range pods {
+		archive := backup.Status.Path + "/" + pod.Name + ".tar.gz"
+		if contains(backup.Status.Archives, archive) {
+			continue
+		}
+		reqLogger.Info("Backing up Cassandra node", "Pod", pod.Name)
+		if err = backupNode(store, pod, backup.Status.Path, backup.Spec.Keyspaces, archive, cqlPort); err != nil {
+			return reconcile.Result{}, r.fail(backup, fmt.Errorf("failed to back up pod %s: %v", pod.Name, err))
+		}
+		backup.Status.Archives = append(backup.Status.Archives, archive)
+		if err = r.client.Status().Update(context.TODO(), backup); err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+
+	m := manifest{Cassandra: cassandra.Name, Keyspaces: backup.Spec.Keyspaces, Archives: backup.Status.Archives}
+	if err = putManifest(store, backup.Status.Path, m); err != nil {
+		return reconcile.Result{}, r.fail(backup, fmt.Errorf("failed to upload manifest: %v", err))
+	}
+	now := meta.Now()
+	backup.Status.Phase = contrail.BackupCompleted
+	backup.Status.CompletionTime = &now
+	backup.Status.Message = ""
+	contrail.SetActiveConditions(&backup.Status.Conditions, backup.Generation, true, "BackupCompleted", "")
+	if err = r.client.Status().Update(context.TODO(), backup); err != nil {
+		return reconcile.Result{}, err
+	}
+	r.recorder.Eventf(backup, core.EventTypeNormal, utils.ReasonBackupCompleted, "Backed up %d Cassandra nodes to %s", len(backup.Status.Archives), backup.Status.Path)
+	return reconcile.Result{}, nil
+}
+
+// backupNode snapshots data of the pod, uploads the archive of the snapshot
+// and removes the snapshot from the pod.
+func backupNode(store objectStore, pod core.Pod, tag string, keyspaces []string, archive string, cqlPort int) error {
+	snapshot, err := snapshotCommand(tag, keyspaces, cqlPort)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if _, stderr, err := execToPod(cleanupCommand(tag), "cassandra", pod.Name, pod.Namespace, nil); err != nil {
+			log.Error(err, "Failed to remove snapshot", "Pod", pod.Name, "Stderr", stderr)
+		}
+	}()
+	if _, stderr, err := execToPod(snapshot, "cassandra", pod.Name, pod.Namespace, nil); err != nil {
+		return fmt.Errorf("%v: %s", err, strings.TrimSpace(stderr))
+	}
+	content, stderr, err := execToPod(archiveCommand(tag), "cassandra", pod.Name, pod.Namespace, nil)
+	if err != nil {
+		return fmt.Errorf("%v: %s", err, strings.TrimSpace(stderr))
+	}
+	return store.Put(archive, []byte(content))
+}
+
+func (r *ReconcileCassandraBackup) fail(backup *contrail.CassandraBackup, err error) error {
+	now := meta.Now()
+	backup.Status.Phase = contrail.BackupFailed
+	backup.Status.CompletionTime = &now
+	backup.Status.Message = err.Error()
+	setFailedConditions(&backup.Status.Conditions, backup.Generation, "BackupFailed", err.Error())
+	r.recorder.Event(backup, core.EventTypeWarning, utils.ReasonBackupFailed, err.Error())
+	return r.client.Status().Update(context.TODO(), backup)
+}
+
+// setFailedConditions sets conditions of a backup or restore which will not progress any further.
+func setFailedConditions(conditions *[]contrail.Condition, generation int64, reason, message string) {
+	contrail.SetActiveConditions(conditions, generation, false, reason, message)
+	contrail.SetCondition(conditions, contrail.Condition{Type: contrail.ConditionProgressing, Status: contrail.ConditionFalse, ObservedGeneration: generation, Reason: reason, Message: message})
+	contrail.SetCondition(conditions, contrail.Condition{Type: contrail.ConditionDegraded, Status: contrail.ConditionTrue, ObservedGeneration: generation, Reason: reason, Message: message})
+}
+
+// getActiveCassandra returns the Cassandra and true when it is active.
+func getActiveCassandra(c client.Client, namespace, name string) (*contrail.Cassandra, bool, error) {
+	cassandra := &contrail.Cassandra{}
+	err := c.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: namespace}, cassandra)
+	if errors.IsNotFound(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return cassandra, cassandra.Status.Active != nil && *cassandra.Status.Active, nil
+}
+
+func runningPods(c client.Client, cassandra *contrail.Cassandra) ([]core.Pod, error) {
+	pods := &core.PodList{}
+	labels := client.MatchingLabels{"contrail_manager": "cassandra", "cassandra": cassandra.Name}
+	if err := c.List(context.TODO(), pods, client.InNamespace(cassandra.Namespace), labels); err != nil {
+		return nil, err
+	}
+	var running []core.Pod
+	for _, pod := range pods.Items {
+		if pod.Status.Phase == core.PodRunning && pod.DeletionTimestamp.IsZero() {
+			running = append(running, pod)
+		}
+	}
+	sort.Slice(running, func(i, j int) bool { return running[i].Name < running[j].Name })
+	if len(running) == 0 {
+		return nil, fmt.Errorf("no running pods of Cassandra %s", cassandra.Name)
+	}
+	return running, nil
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,230 @@
+package cassandrabackup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	contrail "github.com/Juniper/contrail-operator/pkg/apis/contrail/v1alpha1"
+)
+
+type memoryStore map[string][]byte
+
+func (s memoryStore) Put(name string, content []byte) error {
+	s[name] = content
+	return nil
+}
+
+func (s memoryStore) Get(name string) ([]byte, error) {
+	content, ok := s[name]
+	if !ok {
+		return nil, fmt.Errorf("%s not found", name)
+	}
+	return content, nil
+}
+
+type execCall struct {
+	pod    string
+	script string
+	stdin  string
+}
+
+// fakeExec records commands run in pods and returns archives named after pods.
+type fakeExec struct {
+	calls []execCall
+	err   error
+}
+
+func (f *fakeExec) exec(command []string, containerName, podName, namespace string, stdin io.Reader) (string, string, error) {
+	call := execCall{pod: podName, script: strings.Join(command, " ")}
+	if stdin != nil {
+		content, _ := ioutil.ReadAll(stdin)
+		call.stdin = string(content)
+	}
+	f.calls = append(f.calls, call)
+	if f.err != nil {
+		return "", "error output", f.err
+	}
+	if command[0] == "tar" {
+		return "archive of " + podName, "", nil
+	}
+	return "", "", nil
+}
+
+func TestCassandraBackupAndRestore(t *testing.T) {
+	scheme, err := contrail.SchemeBuilder.Build()
+	require.NoError(t, err)
+	require.NoError(t, core.SchemeBuilder.AddToScheme(scheme))
+	trueVal := true
+	falseVal := false
+	cassandra := &contrail.Cassandra{
+		ObjectMeta: meta.ObjectMeta{Name: "cassandra1", Namespace: "default"},
+		Status:     contrail.CassandraStatus{Active: &trueVal},
+	}
+	pod := func(name string) *core.Pod {
+		return &core.Pod{
+			ObjectMeta: meta.ObjectMeta{Name: name, Namespace: "default", Labels: map[string]string{"contrail_manager": "cassandra", "cassandra": "cassandra1"}},
+			Status:     core.PodStatus{Phase: core.PodRunning},
+		}
+	}
+	target := contrail.BackupTarget{S3: &contrail.S3BackupTarget{Endpoint: "http://minio:9000", Bucket: "backups", CredentialsSecretName: "s3-credentials"}}
+	backup := &contrail.CassandraBackup{
+		ObjectMeta: meta.ObjectMeta{Name: "backup1", Namespace: "default"},
+		Spec:       contrail.CassandraBackupSpec{CassandraInstance: "cassandra1", Keyspaces: []string{"config_db_uuid"}, Target: target},
+	}
+	restore := &contrail.CassandraRestore{
+		ObjectMeta: meta.ObjectMeta{Name: "restore1", Namespace: "default"},
+		Spec:       contrail.CassandraRestoreSpec{CassandraInstance: "cassandra1", BackupName: "backup1"},
+	}
+	backupName := types.NamespacedName{Name: "backup1", Namespace: "default"}
+	restoreName := types.NamespacedName{Name: "restore1", Namespace: "default"}
+
+	setup := func(objs ...runtime.Object) (client.Client, memoryStore, *fakeExec, *record.FakeRecorder) {
+		store := memoryStore{}
+		fe := &fakeExec{}
+		execToPod = fe.exec
+		return fake.NewFakeClientWithScheme(scheme, objs...), store, fe, record.NewFakeRecorder(10)
+	}
+	stores := func(store objectStore) storeFactory {
+		return func(namespace string, target contrail.BackupTarget) (objectStore, error) {
+			return store, nil
+		}
+	}
+	defer func(orig func([]string, string, string, string, io.Reader) (string, string, error)) { execToPod = orig }(execToPod)
+
+	t.Run("should upload snapshots of all Cassandra nodes", func(t *testing.T) {
+		cl, store, fe, recorder := setup(cassandra.DeepCopy(), backup.DeepCopy(), pod("cassandra1-cassandra-statefulset-1"), pod("cassandra1-cassandra-statefulset-0"))
+		r := NewReconcileCassandraBackup(cl, scheme, recorder, stores(store))
+		// when
+		_, err := r.Reconcile(reconcile.Request{NamespacedName: backupName})
+		// then
+		require.NoError(t, err)
+		b := &contrail.CassandraBackup{}
+		require.NoError(t, cl.Get(context.Background(), backupName, b))
+		assert.Equal(t, contrail.BackupCompleted, b.Status.Phase)
+		assert.NotNil(t, b.Status.CompletionTime)
+		path := b.Status.Path
+		assert.True(t, strings.HasPrefix(path, "backup1-"))
+		assert.Equal(t, []string{
+			path + "/cassandra1-cassandra-statefulset-0.tar.gz",
+			path + "/cassandra1-cassandra-statefulset-1.tar.gz",
+		}, b.Status.Archives)
+		assert.Equal(t, []byte("archive of cassandra1-cassandra-statefulset-0"), store[path+"/cassandra1-cassandra-statefulset-0.tar.gz"])
+		m, err := getManifest(store, path)
+		require.NoError(t, err)
+		assert.Equal(t, manifest{Cassandra: "cassandra1", Keyspaces: []string{"config_db_uuid"}, Archives: b.Status.Archives}, m)
+		require.Len(t, fe.calls, 6)
+		assert.Contains(t, fe.calls[0].script, "nodetool snapshot -t "+path+" config_db_uuid")
+		assert.Contains(t, fe.calls[1].script, "tar -czf - -C /var/lib/cassandra/backups/"+path)
+		assert.Contains(t, fe.calls[2].script, "rm -rf /var/lib/cassandra/backups/"+path)
+		assert.True(t, contrail.IsConditionTrue(b.Status.Conditions, contrail.ConditionReady))
+		assert.Contains(t, <-recorder.Events, "BackupCompleted")
+	})
+
+	t.Run("should wait for active Cassandra", func(t *testing.T) {
+		inactive := cassandra.DeepCopy()
+		inactive.Status.Active = &falseVal
+		cl, store, fe, recorder := setup(inactive, backup.DeepCopy())
+		r := NewReconcileCassandraBackup(cl, scheme, recorder, stores(store))
+		// when
+		_, err := r.Reconcile(reconcile.Request{NamespacedName: backupName})
+		// then
+		require.NoError(t, err)
+		b := &contrail.CassandraBackup{}
+		require.NoError(t, cl.Get(context.Background(), backupName, b))
+		assert.Equal(t, contrail.BackupPending, b.Status.Phase)
+		assert.False(t, contrail.IsConditionTrue(b.Status.Conditions, contrail.ConditionDependenciesReady))
+		assert.Empty(t, fe.calls)
+	})
+
+	t.Run("should fail backup when snapshot fails", func(t *testing.T) {
+		cl, store, fe, recorder := setup(cassandra.DeepCopy(), backup.DeepCopy(), pod("cassandra1-cassandra-statefulset-0"))
+		fe.err = errors.New("command terminated with exit code 1")
+		r := NewReconcileCassandraBackup(cl, scheme, recorder, stores(store))
+		// when
+		_, err := r.Reconcile(reconcile.Request{NamespacedName: backupName})
+		// then
+		require.NoError(t, err)
+		b := &contrail.CassandraBackup{}
+		require.NoError(t, cl.Get(context.Background(), backupName, b))
+		assert.Equal(t, contrail.BackupFailed, b.Status.Phase)
+		assert.Equal(t, "failed to back up pod cassandra1-cassandra-statefulset-0: command terminated with exit code 1: error output", b.Status.Message)
+		assert.True(t, contrail.IsConditionTrue(b.Status.Conditions, contrail.ConditionDegraded))
+		assert.Empty(t, store)
+	})
+
+	t.Run("should load all snapshots of the backup into Cassandra", func(t *testing.T) {
+		completed := backup.DeepCopy()
+		completed.Status = contrail.CassandraBackupStatus{
+			Phase:    contrail.BackupCompleted,
+			Path:     "backup1-20200101000000",
+			Archives: []string{"backup1-20200101000000/pod-0.tar.gz", "backup1-20200101000000/pod-1.tar.gz"},
+		}
+		cl, store, fe, recorder := setup(cassandra.DeepCopy(), completed, restore.DeepCopy(), pod("cassandra1-cassandra-statefulset-0"))
+		require.NoError(t, putManifest(store, "backup1-20200101000000", manifest{Cassandra: "cassandra1", Archives: completed.Status.Archives}))
+		store["backup1-20200101000000/pod-0.tar.gz"] = []byte("archive 0")
+		store["backup1-20200101000000/pod-1.tar.gz"] = []byte("archive 1")
+		r := NewReconcileCassandraRestore(cl, scheme, recorder, stores(store))
+		// when
+		_, err := r.Reconcile(reconcile.Request{NamespacedName: restoreName})
+		// then
+		require.NoError(t, err)
+		rs := &contrail.CassandraRestore{}
+		require.NoError(t, cl.Get(context.Background(), restoreName, rs))
+		assert.Equal(t, contrail.BackupCompleted, rs.Status.Phase)
+		assert.Equal(t, completed.Status.Archives, rs.Status.RestoredArchives)
+		require.Len(t, fe.calls, 2)
+		assert.Equal(t, "archive 0", fe.calls[0].stdin)
+		assert.Equal(t, "archive 1", fe.calls[1].stdin)
+		assert.Equal(t, "cassandra1-cassandra-statefulset-0", fe.calls[0].pod)
+		assert.Contains(t, fe.calls[0].script, "sstableloader")
+	})
+
+	t.Run("should wait for backup to complete", func(t *testing.T) {
+		running := backup.DeepCopy()
+		running.Status.Phase = contrail.BackupRunning
+		cl, store, fe, recorder := setup(cassandra.DeepCopy(), running, restore.DeepCopy())
+		r := NewReconcileCassandraRestore(cl, scheme, recorder, stores(store))
+		// when
+		_, err := r.Reconcile(reconcile.Request{NamespacedName: restoreName})
+		// then
+		require.NoError(t, err)
+		rs := &contrail.CassandraRestore{}
+		require.NoError(t, cl.Get(context.Background(), restoreName, rs))
+		assert.Equal(t, contrail.BackupPending, rs.Status.Phase)
+		dependencies := contrail.FindCondition(rs.Status.Conditions, contrail.ConditionDependenciesReady)
+		require.NotNil(t, dependencies)
+		assert.Equal(t, "waiting for CassandraBackup backup1", dependencies.Message)
+		assert.Empty(t, fe.calls)
+	})
+
+	t.Run("should fail restore without backup source", func(t *testing.T) {
+		noSource := restore.DeepCopy()
+		noSource.Spec.BackupName = ""
+		cl, store, _, recorder := setup(cassandra.DeepCopy(), noSource)
+		r := NewReconcileCassandraRestore(cl, scheme, recorder, stores(store))
+		// when
+		_, err := r.Reconcile(reconcile.Request{NamespacedName: restoreName})
+		// then
+		require.NoError(t, err)
+		rs := &contrail.CassandraRestore{}
+		require.NoError(t, cl.Get(context.Background(), restoreName, rs))
+		assert.Equal(t, contrail.BackupFailed, rs.Status.Phase)
+		assert.Equal(t, "either backupName or target and path have to be set", rs.Status.Message)
+	})
+}
@@ -0,0 +1,191 @@
+package cassandrabackup
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	core "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	contrail "github.com/Juniper/contrail-operator/pkg/apis/contrail/v1alpha1"
+	"github.com/Juniper/contrail-operator/pkg/controller/utils"
+)
+
+func restoresOf(c client.Client, o handler.MapObject) []reconcile.Request {
+	return restoresMatching(c, o.Meta.GetNamespace(), func(r contrail.CassandraRestore) bool {
+		return r.Spec.CassandraInstance == o.Meta.GetName()
+	})
+}
+
+func restoresOfBackup(c client.Client, o handler.MapObject) []reconcile.Request {
+	return restoresMatching(c, o.Meta.GetNamespace(), func(r contrail.CassandraRestore) bool {
+		return r.Spec.BackupName == o.Meta.GetName()
+	})
+}
+
+func restoresMatching(c client.Client, namespace string, matches func(contrail.CassandraRestore) bool) []reconcile.Request {
+	restores := &contrail.CassandraRestoreList{}
+	if err := c.List(context.TODO(), restores, client.InNamespace(namespace)); err != nil {
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, r := range restores.Items {
+		if matches(r) && !r.Finished() {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: r.Name, Namespace: r.Namespace}})
+		}
+	}
+	return requests
+}
+
+// blank assignment to verify that ReconcileCassandraRestore implements reconcile.Reconciler
+var _ reconcile.Reconciler = &ReconcileCassandraRestore{}
+
+// ReconcileCassandraRestore reconciles a CassandraRestore object
+type ReconcileCassandraRestore struct {
+	client   client.Client
+	scheme   *runtime.Scheme
+	recorder record.EventRecorder
+	stores   storeFactory
+}
+
+func NewReconcileCassandraRestore(client client.Client, scheme *runtime.Scheme, recorder record.EventRecorder, stores storeFactory) *ReconcileCassandraRestore {
+	return &ReconcileCassandraRestore{client: client, scheme: scheme, recorder: recorder, stores: stores}
+}
+
+// Reconcile loads snapshots of all nodes of the backup into the Cassandra. Snapshots are
+// streamed with sstableloader, so the Cassandra may have a different number of nodes than
+// the backed up one. Missing keyspaces and tables are created from the schema of the backup.
+func (r *ReconcileCassandraRestore) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	reqLogger := log.WithValues("Request.Namespace", request.Namespace, "Request.Name", request.Name)
+	reqLogger.Info("Reconciling CassandraRestore")
+	restore := &contrail.CassandraRestore{}
+	if err := r.client.Get(context.TODO(), request.NamespacedName, restore); err != nil {
+		if k8serrors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+	if !restore.GetDeletionTimestamp().IsZero() || restore.Finished() {
+		return reconcile.Result{}, nil
+	}
+
+	target, path, ready, err := r.source(restore)
+	if err != nil {
+		return reconcile.Result{}, r.fail(restore, err)
+	}
+	if !ready {
+		return reconcile.Result{}, r.wait(restore, "CassandraBackup", restore.Spec.BackupName)
+	}
+	cassandra, ready, err := getActiveCassandra(r.client, restore.Namespace, restore.Spec.CassandraInstance)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if !ready {
+		return reconcile.Result{}, r.wait(restore, "Cassandra", restore.Spec.CassandraInstance)
+	}
+
+	if restore.Status.Phase != contrail.BackupRunning {
+		now := meta.Now()
+		restore.Status.Phase = contrail.BackupRunning
+		restore.Status.StartTime = &now
+		contrail.SetActiveConditions(&restore.Status.Conditions, restore.Generation, false, "RestoreRunning", "")
+		if err = r.client.Status().Update(context.TODO(), restore); err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+
+	store, err := r.stores(restore.Namespace, target)
+	if err != nil {
+		return reconcile.Result{}, r.fail(restore, fmt.Errorf("failed to access backup target: %v", err))
+	}
+	m, err := getManifest(store, path)
+	if err != nil {
+		return reconcile.Result{}, r.fail(restore, fmt.Errorf("failed to read manifest of backup %s: %v", path, err))
+	}
+	pods, err := runningPods(r.client, cassandra)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	command, err := restoreCommand(restore.Name, *cassandra.ConfigurationParameters().CqlPort)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	for _, archive := range m.Archives {
+		if contains(restore.Status.RestoredArchives, archive) {
+			continue
+		}
+		reqLogger.Info("Restoring Cassandra node snapshot", "Archive", archive)
+		content, err := store.Get(archive)
+		if err != nil {
+			return reconcile.Result{}, r.fail(restore, fmt.Errorf("failed to download %s: %v", archive, err))
+		}
+		if _, stderr, err := execToPod(command, "cassandra", pods[0].Name, pods[0].Namespace, bytes.NewReader(content)); err != nil {
+			return reconcile.Result{}, r.fail(restore, fmt.Errorf("failed to restore %s: %v: %s", archive, err, strings.TrimSpace(stderr)))
+		}
+		restore.Status.RestoredArchives = append(restore.Status.RestoredArchives, archive)
+		if err = r.client.Status().Update(context.TODO(), restore); err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+
+	now := meta.Now()
+	restore.Status.Phase = contrail.BackupCompleted
+	restore.Status.CompletionTime = &now
+	restore.Status.Message = ""
+	contrail.SetActiveConditions(&restore.Status.Conditions, restore.Generation, true, "RestoreCompleted", "")
+	if err = r.client.Status().Update(context.TODO(), restore); err != nil {
+		return reconcile.Result{}, err
+	}
+	r.recorder.Eventf(restore, core.EventTypeNormal, utils.ReasonRestoreCompleted, "Restored %d Cassandra node snapshots from %s", len(m.Archives), path)
+	return reconcile.Result{}, nil
+}
+
+// source returns the target and the path of the backup to restore.
+// It returns false when the CassandraBackup has not completed yet.
+func (r *ReconcileCassandraRestore) source(restore *contrail.CassandraRestore) (contrail.BackupTarget, string, bool, error) {
+	if restore.Spec.BackupName == "" {
+		if restore.Spec.Target == nil || restore.Spec.Path == "" {
+			return contrail.BackupTarget{}, "", false, errors.New("either backupName or target and path have to be set")
+		}
+		return *restore.Spec.Target, restore.Spec.Path, true, nil
+	}
+	backup := &contrail.CassandraBackup{}
+	err := r.client.Get(context.TODO(), types.NamespacedName{Name: restore.Spec.BackupName, Namespace: restore.Namespace}, backup)
+	if k8serrors.IsNotFound(err) {
+		return contrail.BackupTarget{}, "", false, nil
+	}
+	if err != nil {
+		return contrail.BackupTarget{}, "", false, err
+	}
+	if backup.Status.Phase == contrail.BackupFailed {
+		return contrail.BackupTarget{}, "", false, fmt.Errorf("backup %s has failed", backup.Name)
+	}
+	return backup.Spec.Target, backup.Status.Path, backup.Status.Phase == contrail.BackupCompleted, nil
+}
+
+func (r *ReconcileCassandraRestore) wait(restore *contrail.CassandraRestore, kind, name string) error {
+	if restore.Status.Phase == "" {
+		restore.Status.Phase = contrail.BackupPending
+	}
+	return contrail.WaitForDependency(r.client, r.recorder, restore, &restore.Status.Conditions, kind, name)
+}
+
+func (r *ReconcileCassandraRestore) fail(restore *contrail.CassandraRestore, err error) error {
+	now := meta.Now()
+	restore.Status.Phase = contrail.BackupFailed
+	restore.Status.CompletionTime = &now
+	restore.Status.Message = err.Error()
+	setFailedConditions(&restore.Status.Conditions, restore.Generation, "RestoreFailed", err.Error())
+	r.recorder.Event(restore, core.EventTypeWarning, utils.ReasonRestoreFailed, err.Error())
+	return r.client.Status().Update(context.TODO(), restore)
+}
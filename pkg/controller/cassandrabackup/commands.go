@@ -0,0 +1,94 @@
+package cassandrabackup
+
+import (
+	"bytes"
+	"text/template"
+
+	"github.com/Juniper/contrail-operator/pkg/certificates"
+)
+
+// Snapshots are prepared and loaded on the volume of Cassandra, so that
+// files of snapshots can be hard linked instead of copied.
+const (
+	backupDir  = "/var/lib/cassandra/backups"
+	restoreDir = "/var/lib/cassandra/restore"
+)
+
+// snapshotCommandTemplate takes a snapshot of keyspaces and links files of the
+// snapshot into keyspace/table directories, the layout expected by sstableloader.
+// The schema is dumped next to them, as snapshots do not contain keyspaces.
+var snapshotCommandTemplate = template.Must(template.New("").Parse(`set -e
+dir={{ .Dir }}
+rm -rf $dir
+mkdir -p $dir
+nodetool snapshot -t {{ .Tag }}{{ range .Keyspaces }} {{ . }}{{ end }} > /dev/null
+SSL_CERTFILE={{ .CAFilePath }} SSL_VALIDATE=false cqlsh --ssl ${POD_IP} {{ .CQLPort }} -e 'DESC SCHEMA' > $dir/schema.cql
+for snapshot in /var/lib/cassandra/data/*/*/snapshots/{{ .Tag }}; do
+  [ -d $snapshot ] || continue
+  table=${snapshot%/snapshots/*}
+  keyspace=$(basename $(dirname $table))
+  case $keyspace in system*) continue;; esac
+  table=$(basename $table)
+  mkdir -p $dir/$keyspace/${table%-*}
+  find $snapshot -maxdepth 1 -type f ! -name manifest.json ! -name schema.cql -exec cp -l {} $dir/$keyspace/${table%-*}/ \;
+done
+nodetool clearsnapshot -t {{ .Tag }} > /dev/null
+`))
+
+// restoreCommandTemplate unpacks the archive read from stdin, creates
+// missing parts of the schema and streams tables into the cluster.
+var restoreCommandTemplate = template.Must(template.New("").Parse(`set -e
+dir={{ .Dir }}
+rm -rf $dir
+mkdir -p $dir
+tar -xzf - -C $dir
+sed -E 's/^CREATE (KEYSPACE|TABLE|TYPE|INDEX|CUSTOM INDEX|MATERIALIZED VIEW) /CREATE \1 IF NOT EXISTS /' $dir/schema.cql > $dir/schema-restore.cql
+SSL_CERTFILE={{ .CAFilePath }} SSL_VALIDATE=false cqlsh --ssl ${POD_IP} {{ .CQLPort }} -f $dir/schema-restore.cql
+truststorePassword=$(awk '/truststore_password/ {print $2; exit}' /mydata/${POD_IP}.yaml)
+for table in $dir/*/*/; do
+  sstableloader -f /mydata/${POD_IP}.yaml -d ${POD_IP} -p {{ .CQLPort }} -ts /etc/keystore/server-truststore.jks -tspw $truststorePassword ${table%/}
+done
+rm -rf $dir
+`))
+
+type commandData struct {
+	Dir        string
+	Tag        string
+	Keyspaces  []string
+	CQLPort    int
+	CAFilePath string
+}
+
+func snapshotCommand(tag string, keyspaces []string, cqlPort int) ([]string, error) {
+	return command(snapshotCommandTemplate, commandData{
+		Dir:        backupDir + "/" + tag,
+		Tag:        tag,
+		Keyspaces:  keyspaces,
+		CQLPort:    cqlPort,
+		CAFilePath: certificates.SignerCAFilepath,
+	})
+}
+
+func archiveCommand(tag string) []string {
+	return []string{"tar", "-czf", "-", "-C", backupDir + "/" + tag, "."}
+}
+
+func cleanupCommand(tag string) []string {
+	return []string{"rm", "-rf", backupDir + "/" + tag}
+}
+
+func restoreCommand(name string, cqlPort int) ([]string, error) {
+	return command(restoreCommandTemplate, commandData{
+		Dir:        restoreDir + "/" + name,
+		CQLPort:    cqlPort,
+		CAFilePath: certificates.SignerCAFilepath,
+	})
+}
+
+func command(t *template.Template, data commandData) ([]string, error) {
+	var buffer bytes.Buffer
+	if err := t.Execute(&buffer, data); err != nil {
+		return nil, err
+	}
+	return []string{"bash", "-c", buffer.String()}, nil
+}
@@ -0,0 +1,148 @@
+package cassandrabackup
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	contrail "github.com/Juniper/contrail-operator/pkg/apis/contrail/v1alpha1"
+	"github.com/Juniper/contrail-operator/pkg/client/keystone"
+	"github.com/Juniper/contrail-operator/pkg/client/kubeproxy"
+	"github.com/Juniper/contrail-operator/pkg/client/s3"
+	"github.com/Juniper/contrail-operator/pkg/client/swift"
+)
+
+const manifestName = "manifest.json"
+
+// objectStore is an object storage archives of backups are kept in.
+type objectStore interface {
+	Put(name string, content []byte) error
+	Get(name string) ([]byte, error)
+}
+
+// storeFactory returns the object storage of the target.
+type storeFactory func(namespace string, target contrail.BackupTarget) (objectStore, error)
+
+// manifest describes a backup. It is uploaded next to archives of the backup,
+// so that the backup can be restored when its CassandraBackup is gone.
+type manifest struct {
+	Cassandra string   `json:"cassandra"`
+	Keyspaces []string `json:"keyspaces,omitempty"`
+	Archives  []string `json:"archives"`
+}
+
+func putManifest(store objectStore, path string, m manifest) error {
+	content, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	return store.Put(path+"/"+manifestName, content)
+}
+
+func getManifest(store objectStore, path string) (manifest, error) {
+	m := manifest{}
+	content, err := store.Get(path + "/" + manifestName)
+	if err != nil {
+		return m, err
+	}
+	err = json.Unmarshal(content, &m)
+	return m, err
+}
+
+func newStoreFactory(c client.Client, scheme *runtime.Scheme, config *rest.Config) storeFactory {
+	return func(namespace string, target contrail.BackupTarget) (objectStore, error) {
+		switch {
+		case target.Swift != nil:
+			return newSwiftStore(c, scheme, config, namespace, target.Swift)
+		case target.S3 != nil:
+			return newS3Store(c, namespace, target.S3)
+		}
+		return nil, errors.New("backup target is not set")
+	}
+}
+
+type swiftStore struct {
+	client    *swift.Client
+	container string
+}
+
+func newSwiftStore(c client.Client, scheme *runtime.Scheme, config *rest.Config, namespace string, target *contrail.SwiftBackupTarget) (*swiftStore, error) {
+	swiftInstance := &contrail.Swift{}
+	if err := c.Get(context.TODO(), types.NamespacedName{Name: target.SwiftInstance, Namespace: namespace}, swiftInstance); err != nil {
+		return nil, err
+	}
+	proxyConfiguration := swiftInstance.Spec.ServiceConfiguration.SwiftProxyConfiguration
+	keystoneInstance := &contrail.Keystone{}
+	if err := c.Get(context.TODO(), types.NamespacedName{Name: proxyConfiguration.KeystoneInstance, Namespace: namespace}, keystoneInstance); err != nil {
+		return nil, err
+	}
+	adminPasswordSecret := &core.Secret{}
+	if err := c.Get(context.TODO(), types.NamespacedName{Name: proxyConfiguration.KeystoneSecretName, Namespace: namespace}, adminPasswordSecret); err != nil {
+		return nil, err
+	}
+	keystoneClient, err := keystone.NewClient(c, scheme, config, keystoneInstance)
+	if err != nil {
+		return nil, err
+	}
+	token, err := keystoneClient.PostAdminAuthTokens(string(adminPasswordSecret.Data["password"]))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get keystone token: %v", err)
+	}
+	proxy, err := kubeproxy.New(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create kubeproxy: %v", err)
+	}
+	swiftServiceName := proxyConfiguration.SwiftServiceName
+	if swiftServiceName == "" {
+		swiftServiceName = "swift"
+	}
+	swiftProxy := proxy.NewSecureClientForService(namespace, target.SwiftInstance+"-proxy-swiftproxy", swiftInstance.Status.SwiftProxyPort)
+	swiftClient, err := swift.NewClient(swiftProxy, token.XAuthTokenHeader, token.EndpointURL(swiftServiceName, "public"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create swift client %v", err)
+	}
+	if err = swiftClient.PutContainer(target.Container); err != nil {
+		return nil, fmt.Errorf("failed to create swift container (%s): %v", target.Container, err)
+	}
+	return &swiftStore{client: swiftClient, container: target.Container}, nil
+}
+
+func (s *swiftStore) Put(name string, content []byte) error {
+	return s.client.PutFile(s.container, name, content)
+}
+
+func (s *swiftStore) Get(name string) ([]byte, error) {
+	return s.client.GetFile(s.container, name)
+}
+
+type s3Store struct {
+	client *s3.Client
+	bucket string
+}
+
+func newS3Store(c client.Client, namespace string, target *contrail.S3BackupTarget) (*s3Store, error) {
+	credentials := &core.Secret{}
+	if err := c.Get(context.TODO(), types.NamespacedName{Name: target.CredentialsSecretName, Namespace: namespace}, credentials); err != nil {
+		return nil, err
+	}
+	s3Client, err := s3.NewClient(target.Endpoint, target.Region, string(credentials.Data["accessKey"]), string(credentials.Data["secretKey"]))
+	if err != nil {
+		return nil, err
+	}
+	return &s3Store{client: s3Client, bucket: target.Bucket}, nil
+}
+
+func (s *s3Store) Put(name string, content []byte) error {
+	return s.client.PutObject(s.bucket, name, content)
+}
+
+func (s *s3Store) Get(name string) ([]byte, error) {
+	return s.client.GetObject(s.bucket, name)
+}
@@ -16,6 +16,7 @@ type commandConf struct {
 	ConfigAPIURL         string
 	AdminUsername        string
 	AdminPassword        string
+	AdminProject         string
 	SwiftUsername        string
 	SwiftPassword        string
 	PostgresAddress      string
@@ -27,12 +28,14 @@ type commandConf struct {
 	KeystoneAddress      string
 	KeystonePort         int
 	KeystoneAuthProtocol string
+	KeystoneUserDomainID string
 }
 
 type commandPodConf struct {
 	ConfigAPIURL         string
 	AdminUsername        string
 	AdminPassword        string
+	AdminProject         string
 	SwiftUsername        string
 	SwiftPassword        string
 	PostgresAddress      string
@@ -44,6 +47,7 @@ type commandPodConf struct {
 	KeystoneAddress      string
 	KeystonePort         int
 	KeystoneAuthProtocol string
+	KeystoneUserDomainID string
 }
 
 func (c *commandConf) FillConfigMap(cm *core.ConfigMap) {
@@ -51,6 +55,7 @@ func (c *commandConf) FillConfigMap(cm *core.ConfigMap) {
 		conf := &commandPodConf{
 			AdminUsername:        c.AdminUsername,
 			AdminPassword:        c.AdminPassword,
+			AdminProject:         c.AdminProject,
 			SwiftUsername:        c.SwiftUsername,
 			SwiftPassword:        c.SwiftPassword,
 			ConfigAPIURL:         c.ConfigAPIURL,
@@ -63,6 +68,7 @@ func (c *commandConf) FillConfigMap(cm *core.ConfigMap) {
 			KeystoneAddress:      c.KeystoneAddress,
 			KeystonePort:         c.KeystonePort,
 			KeystoneAuthProtocol: c.KeystoneAuthProtocol,
+			KeystoneUserDomainID: c.KeystoneUserDomainID,
 		}
 		conf.fillConfigMapForPod(cm)
 	}
@@ -157,7 +163,7 @@ keystone:
     id: {{ .SwiftUsername }}
     password: {{ .SwiftPassword }}
     project_name: service
-    domain_id: default
+    domain_id: {{ .KeystoneUserDomainID }}
 {{- end }}
 
 sync:
@@ -166,8 +172,8 @@ sync:
 client:
   id: {{ .AdminUsername }}
   password: {{ .AdminPassword }}
-  project_name: admin
-  domain_id: default
+  project_name: {{ .AdminProject }}
+  domain_id: {{ .KeystoneUserDomainID }}
   schema_root: /
   endpoint: https://localhost:9091
 
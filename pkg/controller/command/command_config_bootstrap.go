@@ -16,11 +16,13 @@ type commandBootstrapConf struct {
 	ConfigAPIURL         string
 	AdminUsername        string
 	AdminPassword        string
+	AdminProject         string
 	SwiftUsername        string
 	SwiftPassword        string
 	KeystoneAddress      string
 	KeystonePort         int
 	KeystoneAuthProtocol string
+	KeystoneUserDomainID string
 	PostgresAddress      string
 	PostgresUser         string
 	PostgresDBName       string
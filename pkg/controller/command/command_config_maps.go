@@ -20,16 +20,18 @@ var NameSpaceCommand = uuid.Must(uuid.Parse("362ad2d9-9460-4f65-a702-2cd99fbb0f6
 type configMaps struct {
 	cm                      *k8s.ConfigMap
 	ccSpec                  contrail.CommandSpec
+	keystone                contrail.KeystoneConfiguration
 	keystoneAdminPassSecret *corev1.Secret
 	swiftCredentialsSecret  *corev1.Secret
 }
 
 func (r *ReconcileCommand) configMap(
-	configMapName string, ownerType string, cc *contrail.Command, keystoneSecret *corev1.Secret, swiftSecret *corev1.Secret,
+	configMapName string, ownerType string, cc *contrail.Command, keystone *contrail.Keystone, keystoneSecret *corev1.Secret, swiftSecret *corev1.Secret,
 ) *configMaps {
 	return &configMaps{
 		cm:                      r.kubernetes.ConfigMap(configMapName, ownerType, cc),
 		ccSpec:                  cc.Spec,
+		keystone:                keystone.ConfigurationParameters(),
 		keystoneAdminPassSecret: keystoneSecret,
 		swiftCredentialsSecret:  swiftSecret,
 	}
@@ -37,8 +39,9 @@ func (r *ReconcileCommand) configMap(
 
 func (c *configMaps) ensureCommandConfigExist(postgresAddress, ConfigEndpoint string, podIPs []string, keystoneAuthProtocol string, keystoneAddress string, keystonePort int) error {
 	cc := &commandConf{
-		AdminUsername:        "admin",
+		AdminUsername:        c.keystone.AdminUsername,
 		AdminPassword:        string(c.keystoneAdminPassSecret.Data["password"]),
+		AdminProject:         c.keystone.AdminProject,
 		SwiftUsername:        string(c.swiftCredentialsSecret.Data["user"]),
 		SwiftPassword:        string(c.swiftCredentialsSecret.Data["password"]),
 		ConfigAPIURL:         "https://" + ConfigEndpoint + ":" + strconv.Itoa(contrail.ConfigApiPort),
@@ -51,6 +54,7 @@ func (c *configMaps) ensureCommandConfigExist(postgresAddress, ConfigEndpoint st
 		KeystoneAddress:      keystoneAddress,
 		KeystoneAuthProtocol: keystoneAuthProtocol,
 		KeystonePort:         keystonePort,
+		KeystoneUserDomainID: c.keystone.UserDomainID,
 	}
 	return c.cm.EnsureExists(cc)
 }
@@ -83,8 +87,9 @@ func (c *configMaps) ensureCommandInitConfigExist(webUIPort, swiftProxyPort, key
 	}
 	cc := &commandBootstrapConf{
 		ClusterName:          clusterName,
-		AdminUsername:        "admin",
+		AdminUsername:        c.keystone.AdminUsername,
 		AdminPassword:        string(c.keystoneAdminPassSecret.Data["password"]),
+		AdminProject:         c.keystone.AdminProject,
 		SwiftUsername:        string(c.swiftCredentialsSecret.Data["user"]),
 		SwiftPassword:        string(c.swiftCredentialsSecret.Data["password"]),
 		ConfigAPIURL:         configAPIURL,
@@ -96,6 +101,7 @@ func (c *configMaps) ensureCommandInitConfigExist(webUIPort, swiftProxyPort, key
 		KeystoneAddress:      keystoneAddress,
 		KeystonePort:         keystonePort,
 		KeystoneAuthProtocol: keystoneAuthProtocol,
+		KeystoneUserDomainID: c.keystone.UserDomainID,
 		ContrailVersion:      c.ccSpec.ServiceConfiguration.ContrailVersion,
 		Endpoints:            bes,
 	}
@@ -14,6 +14,7 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
@@ -42,9 +43,13 @@ func Add(mgr manager.Manager) error {
 
 // newReconciler returns a new reconcile.Reconciler
 func newReconciler(mgr manager.Manager) reconcile.Reconciler {
-	kubernetes := k8s.New(mgr.GetClient(), mgr.GetScheme())
+	recorder := mgr.GetEventRecorderFor("command-controller")
+	client := utils.NewEventRecordingClient(mgr.GetClient(), recorder)
+	kubernetes := k8s.New(client, mgr.GetScheme())
 	config := mgr.GetConfig()
-	return NewReconciler(mgr.GetClient(), mgr.GetScheme(), kubernetes, config)
+	r := NewReconciler(client, mgr.GetScheme(), kubernetes, config)
+	r.recorder = recorder
+	return r
 }
 
 // add adds a new Controller to mgr with r as the reconcile.Reconciler
@@ -136,6 +141,7 @@ type ReconcileCommand struct {
 	scheme     *runtime.Scheme
 	kubernetes *k8s.Kubernetes
 	config     *rest.Config
+	recorder   record.EventRecorder
 }
 
 // NewReconciler is used to create command reconciler
@@ -198,7 +204,7 @@ func (r *ReconcileCommand) Reconcile(request reconcile.Request) (reconcile.Resul
 		return reconcile.Result{}, err
 	}
 	if !psql.Status.Active {
-		return reconcile.Result{}, nil
+		return reconcile.Result{}, contrail.WaitForDependency(r.client, r.recorder, command, &command.Status.Conditions, "Postgres", psql.Name)
 	}
 
 	keystone, err := r.getKeystone(command)
@@ -208,8 +214,8 @@ func (r *ReconcileCommand) Reconcile(request reconcile.Request) (reconcile.Resul
 	if err := r.kubernetes.Owner(command).EnsureOwns(keystone); err != nil {
 		return reconcile.Result{}, err
 	}
-	if !keystone.Status.Active {
-		return reconcile.Result{}, nil
+	if !keystone.IsAvailable() {
+		return reconcile.Result{}, contrail.WaitForDependency(r.client, r.recorder, command, &command.Status.Conditions, "Keystone", keystone.Name)
 	}
 
 	if keystone.Status.Endpoint == "" {
@@ -235,7 +241,7 @@ func (r *ReconcileCommand) Reconcile(request reconcile.Request) (reconcile.Resul
 			return reconcile.Result{}, err
 		}
 		if !swiftService.Status.Active {
-			return reconcile.Result{}, nil
+			return reconcile.Result{}, contrail.WaitForDependency(r.client, r.recorder, command, &command.Status.Conditions, "Swift", swiftService.Name)
 		}
 
 		swiftSecretName := swiftService.Status.CredentialsSecretName
@@ -262,7 +268,7 @@ func (r *ReconcileCommand) Reconcile(request reconcile.Request) (reconcile.Resul
 		return reconcile.Result{}, err
 	}
 	if config.Status.Endpoint == "" {
-		return reconcile.Result{}, nil
+		return reconcile.Result{}, contrail.WaitForDependency(r.client, r.recorder, command, &command.Status.Conditions, "Config", config.Name)
 	}
 
 	webUI, err := r.getWebUI(command)
@@ -273,7 +279,7 @@ func (r *ReconcileCommand) Reconcile(request reconcile.Request) (reconcile.Resul
 		return reconcile.Result{}, err
 	}
 	if !webUI.Status.Active {
-		return reconcile.Result{}, nil
+		return reconcile.Result{}, contrail.WaitForDependency(r.client, r.recorder, command, &command.Status.Conditions, "Webui", webUI.Name)
 	}
 	webUIAddress := webUI.Status.Endpoint
 	webUIPort := webUI.Status.Ports.WebUIHttpsPort
@@ -291,12 +297,12 @@ func (r *ReconcileCommand) Reconcile(request reconcile.Request) (reconcile.Resul
 	for _, pod := range commandPods.Items {
 		podIPs = append(podIPs, pod.Status.PodIP)
 	}
-	if err = r.configMap(commandConfigName, "command", command, adminPasswordSecret, swiftSecret).ensureCommandConfigExist(psql.Status.Endpoint, config.Status.Endpoint, podIPs, keystoneAuthProtocol, keystoneAddress, keystonePort); err != nil {
+	if err = r.configMap(commandConfigName, "command", command, keystone, adminPasswordSecret, swiftSecret).ensureCommandConfigExist(psql.Status.Endpoint, config.Status.Endpoint, podIPs, keystoneAuthProtocol, keystoneAddress, keystonePort); err != nil {
 		return reconcile.Result{}, err
 	}
 
 	commandBootStrapConfigName := command.Name + "-bootstrap-configmap"
-	if err = r.configMap(commandBootStrapConfigName, "command", command, adminPasswordSecret, swiftSecret).ensureCommandInitConfigExist(webUIPort, swiftProxyPort, keystonePort, webUIAddress, swiftProxyAddress, keystoneAddress, keystoneAuthProtocol, psql.Status.Endpoint, config.Status.Endpoint, commandClusterIP); err != nil {
+	if err = r.configMap(commandBootStrapConfigName, "command", command, keystone, adminPasswordSecret, swiftSecret).ensureCommandInitConfigExist(webUIPort, swiftProxyPort, keystonePort, webUIAddress, swiftProxyAddress, keystoneAddress, keystoneAuthProtocol, psql.Status.Endpoint, config.Status.Endpoint, commandClusterIP); err != nil {
 		return reconcile.Result{}, err
 	}
 	if err = r.reconcileBootstrapJob(command, commandBootStrapConfigName); err != nil {
@@ -574,16 +580,36 @@ func (r *ReconcileCommand) updateStatus(command *contrail.Command, deployment *a
 	if command.Status.ContainerImage == "" {
 		command.Status.ContainerImage = getImage(command.Spec.ServiceConfiguration.Containers, "api")
 	}
+	if err := contrail.SetWorkloadConditions(r.client, &command.Status.Conditions, command.Generation, deployment.Namespace, deployment.Spec.Selector, expectedReplicas, deployment.Status.ReadyReplicas); err != nil {
+		return err
+	}
+	setUpgradeConditions(command)
 
 	return r.client.Status().Update(context.Background(), command)
 }
 
+// setUpgradeConditions overrides conditions set from the deployment while an upgrade is in progress.
+func setUpgradeConditions(command *contrail.Command) {
+	state := command.Status.UpgradeState
+	if state == contrail.CommandNotUpgrading || state == "" {
+		return
+	}
+	conditions := &command.Status.Conditions
+	if state == contrail.CommandUpgradeFailed {
+		contrail.SetCondition(conditions, contrail.Condition{Type: contrail.ConditionDegraded, Status: contrail.ConditionTrue, ObservedGeneration: command.Generation, Reason: "UpgradeFailed", Message: "upgrade to " + command.Status.TargetContainerImage + " failed"})
+		contrail.SetCondition(conditions, contrail.Condition{Type: contrail.ConditionProgressing, Status: contrail.ConditionFalse, ObservedGeneration: command.Generation, Reason: "UpgradeFailed"})
+	} else {
+		contrail.SetCondition(conditions, contrail.Condition{Type: contrail.ConditionProgressing, Status: contrail.ConditionTrue, ObservedGeneration: command.Generation, Reason: "Upgrading", Message: string(state)})
+	}
+	contrail.SetCondition(conditions, contrail.Condition{Type: contrail.ConditionReady, Status: contrail.ConditionFalse, ObservedGeneration: command.Generation, Reason: "Upgrading", Message: string(state)})
+}
+
 func (r *ReconcileCommand) ensureContrailSwiftContainerExists(command *contrail.Command, k *contrail.Keystone, sPort int, adminPass *core.Secret, serviceName string) error {
 	keystoneClient, err := keystone.NewClient(r.client, r.scheme, r.config, k)
 	if err != nil {
 		return err
 	}
-	token, err := keystoneClient.PostAuthTokens("admin", string(adminPass.Data["password"]), "admin")
+	token, err := keystoneClient.PostAdminAuthTokens(string(adminPass.Data["password"]))
 	if err != nil {
 		return fmt.Errorf("failed to get keystone token: %v", err)
 	}
@@ -607,7 +633,7 @@ func (r *ReconcileCommand) ensureContrailSwiftContainerExists(command *contrail.
 
 func (r *ReconcileCommand) ensureCertificatesExist(command *contrail.Command, pods *core.PodList, instanceType, serviceIP string) error {
 	subjects := command.PodsCertSubjects(pods, serviceIP)
-	crt := certificates.NewCertificate(r.client, r.scheme, command, subjects, instanceType)
+	crt := certificates.NewCertificate(r.client, r.scheme, command, subjects, instanceType, utils.CertificateKeyConfig(command.Spec.CommonConfiguration.CertificateKey))
 	return crt.EnsureExistsAndIsSigned()
 }
 
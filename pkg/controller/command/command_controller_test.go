@@ -382,6 +382,29 @@ func TestCommand(t *testing.T) {
 			expectedPostgres:   newPostgresWithOwner(true),
 			expectedSwift:      newSwiftWithOwner(true),
 		},
+		{
+			name: "create a new deployment when Keystone is available during its rolling update",
+			initObjs: []runtime.Object{
+				newCommand(),
+				newCommandService(),
+				newConfig(true),
+				newPostgres(true),
+				newAdminSecret(),
+				newSwiftSecret(),
+				newSwift(true),
+				newKeystone(contrail.KeystoneStatus{Port: 5555, ReadyReplicas: 2, Endpoint: "10.0.2.16"}, nil),
+				newPodList(),
+				newWebUI(true),
+			},
+			expectedStatus: contrail.CommandStatus{
+				Endpoint:       "20.20.20.20",
+				UpgradeState:   contrail.CommandNotUpgrading,
+				ContainerImage: "registry:5000/contrail-command",
+			},
+			expectedDeployment: newDeployment(apps.DeploymentStatus{}),
+			expectedPostgres:   newPostgresWithOwner(true),
+			expectedSwift:      newSwiftWithOwner(true),
+		},
 		{
 			name: "create a new deployment with no Swift",
 			initObjs: []runtime.Object{
@@ -817,7 +840,10 @@ func TestCommand(t *testing.T) {
 				Namespace: "default",
 			}, cc)
 			assert.NoError(t, err)
+			conditions := cc.Status.Conditions
+			cc.Status.Conditions = nil
 			assert.Equal(t, tt.expectedStatus, cc.Status)
+			assert.Equal(t, tt.expectedStatus.Active, contrail.IsConditionTrue(conditions, contrail.ConditionReady))
 			swiftInstance := cc.Spec.ServiceConfiguration.SwiftInstance
 
 			// Check and verify command deployment
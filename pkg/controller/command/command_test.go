@@ -20,11 +20,13 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
 	contrail "github.com/Juniper/contrail-operator/pkg/apis/contrail/v1alpha1"
+	"github.com/Juniper/contrail-operator/pkg/controller/utils"
 	"github.com/Juniper/contrail-operator/pkg/k8s"
 )
 
 // Test function for newReconciler
 func TestNewReconciler(t *testing.T) {
+	client := utils.NewEventRecordingClient(nil, nil)
 	newReconcilerCases := map[string]struct {
 		manager            *mockManager
 		expectedReconciler *ReconcileCommand
@@ -32,7 +34,8 @@ func TestNewReconciler(t *testing.T) {
 		"empty manager": {
 			manager: &mockManager{},
 			expectedReconciler: &ReconcileCommand{
-				kubernetes: &k8s.Kubernetes{},
+				client:     client,
+				kubernetes: k8s.New(client, nil),
 			},
 		},
 	}
@@ -44,6 +47,56 @@ func TestNewReconciler(t *testing.T) {
 	}
 }
 
+func TestRecordUpgradeStateChange(t *testing.T) {
+	tests := []struct {
+		name     string
+		previous contrail.CommandUpgradeState
+		current  contrail.CommandUpgradeState
+		expected []string
+	}{
+		{
+			name:    "should not record initial state",
+			current: contrail.CommandNotUpgrading,
+		},
+		{
+			name:     "should not record unchanged state",
+			previous: contrail.CommandUpgrading,
+			current:  contrail.CommandUpgrading,
+		},
+		{
+			name:     "should record state transition",
+			previous: contrail.CommandNotUpgrading,
+			current:  contrail.CommandShuttingDownBeforeUpgrade,
+			expected: []string{`Normal UpgradeStateChanged Upgrade state changed from "not upgrading" to "shutting down before upgrade"`},
+		},
+		{
+			name:     "should record failed upgrade as warning",
+			previous: contrail.CommandUpgrading,
+			current:  contrail.CommandUpgradeFailed,
+			expected: []string{"Warning UpgradeFailed Upgrade to registry:5000/contrail-command:new failed"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := record.NewFakeRecorder(10)
+			r := &ReconcileCommand{recorder: recorder}
+			command := &contrail.Command{Status: contrail.CommandStatus{
+				UpgradeState:         test.current,
+				TargetContainerImage: "registry:5000/contrail-command:new",
+			}}
+			// when
+			r.recordUpgradeStateChange(command, test.previous)
+			// then
+			close(recorder.Events)
+			var events []string
+			for event := range recorder.Events {
+				events = append(events, event)
+			}
+			assert.Equal(t, test.expected, events)
+		})
+	}
+}
+
 // Test function for add
 func TestAdd(t *testing.T) {
 	scheme, err := contrail.SchemeBuilder.Build()
@@ -12,10 +12,12 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 
 	contrail "github.com/Juniper/contrail-operator/pkg/apis/contrail/v1alpha1"
+	"github.com/Juniper/contrail-operator/pkg/controller/utils"
 	"github.com/Juniper/contrail-operator/pkg/job"
 )
 
 func (r *ReconcileCommand) performUpgradeIfNeeded(command *contrail.Command, replicas int) error {
+	defer r.recordUpgradeStateChange(command, command.Status.UpgradeState)
 	switch command.Status.UpgradeState {
 	case contrail.CommandShuttingDownBeforeUpgrade:
 		if replicas == 0 {
@@ -67,6 +69,18 @@ func (r *ReconcileCommand) performUpgradeIfNeeded(command *contrail.Command, rep
 	return nil
 }
 
+func (r *ReconcileCommand) recordUpgradeStateChange(command *contrail.Command, previous contrail.CommandUpgradeState) {
+	current := command.Status.UpgradeState
+	if r.recorder == nil || current == previous || previous == "" && current == contrail.CommandNotUpgrading {
+		return
+	}
+	if current == contrail.CommandUpgradeFailed {
+		r.recorder.Eventf(command, core.EventTypeWarning, utils.ReasonUpgradeFailed, "Upgrade to %s failed", command.Status.TargetContainerImage)
+		return
+	}
+	r.recorder.Eventf(command, core.EventTypeNormal, utils.ReasonUpgradeStateChanged, "Upgrade state changed from %q to %q", previous, current)
+}
+
 func (r *ReconcileCommand) deleteMigrationJob(commandCR *contrail.Command) error {
 	job := &batch.Job{
 		ObjectMeta: meta.ObjectMeta{
@@ -10,6 +10,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/workqueue"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
@@ -92,11 +93,14 @@ func Add(mgr manager.Manager) error {
 }
 
 func newReconciler(mgr manager.Manager) reconcile.Reconciler {
+	recorder := mgr.GetEventRecorderFor("config-controller")
+	client := utils.NewEventRecordingClient(mgr.GetClient(), recorder)
 	return &ReconcileConfig{
-		Client:     mgr.GetClient(),
+		Client:     client,
 		Scheme:     mgr.GetScheme(),
 		Manager:    mgr,
-		Kubernetes: k8s.New(mgr.GetClient(), mgr.GetScheme()),
+		Kubernetes: k8s.New(client, mgr.GetScheme()),
+		Recorder:   recorder,
 	}
 }
 func add(mgr manager.Manager, r reconcile.Reconciler) error {
@@ -148,6 +152,13 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 		return err
 	}
 
+	srcKeystone := &source.Kind{Type: &v1alpha1.Keystone{}}
+	keystoneHandler := resourceHandler(mgr.GetClient())
+	predKeystoneAvailableChange := utils.KeystoneAvailableChange()
+	if err = c.Watch(srcKeystone, keystoneHandler, predKeystoneAvailableChange); err != nil {
+		return err
+	}
+
 	srcZookeeper := &source.Kind{Type: &v1alpha1.Zookeeper{}}
 	zookeeperHandler := resourceHandler(mgr.GetClient())
 	predZookeeperSizeChange := utils.ZookeeperActiveChange()
@@ -179,6 +190,7 @@ type ReconcileConfig struct {
 	Scheme     *runtime.Scheme
 	Manager    manager.Manager
 	Kubernetes *k8s.Kubernetes
+	Recorder   record.EventRecorder
 }
 
 // Reconcile reconciles Config.
@@ -205,8 +217,24 @@ func (r *ReconcileConfig) Reconcile(request reconcile.Request) (reconcile.Result
 	rabbitmqActive := rabbitmqInstance.IsActive(config.Labels["contrail_cluster"],
 		request.Namespace, r.Client)
 
-	if !cassandraActive || !rabbitmqActive || !zookeeperActive {
-		return reconcile.Result{}, nil
+	if !cassandraActive {
+		return reconcile.Result{}, v1alpha1.WaitForDependency(r.Client, r.Recorder, config, &config.Status.Conditions, "Cassandra", config.Spec.ServiceConfiguration.CassandraInstance)
+	}
+	if !zookeeperActive {
+		return reconcile.Result{}, v1alpha1.WaitForDependency(r.Client, r.Recorder, config, &config.Status.Conditions, "Zookeeper", config.Spec.ServiceConfiguration.ZookeeperInstance)
+	}
+	if !rabbitmqActive {
+		return reconcile.Result{}, v1alpha1.WaitForDependency(r.Client, r.Recorder, config, &config.Status.Conditions, "Rabbitmq", rabbitmqInstance.InstanceName(config.Labels["contrail_cluster"], request.Namespace, r.Client))
+	}
+	if config.Spec.ServiceConfiguration.AuthMode == v1alpha1.AuthenticationModeKeystone {
+		keystoneInstance := &v1alpha1.Keystone{}
+		keystoneName := types.NamespacedName{Namespace: request.Namespace, Name: config.Spec.ServiceConfiguration.KeystoneInstance}
+		if err := r.Client.Get(context.TODO(), keystoneName, keystoneInstance); err != nil && !errors.IsNotFound(err) {
+			return reconcile.Result{}, err
+		}
+		if !keystoneInstance.IsAvailable() {
+			return reconcile.Result{}, v1alpha1.WaitForDependency(r.Client, r.Recorder, config, &config.Status.Conditions, "Keystone", config.Spec.ServiceConfiguration.KeystoneInstance)
+		}
 	}
 	servicePortsMap := map[int32]string{
 		int32(v1alpha1.ConfigApiPort):    "api",
@@ -735,6 +763,6 @@ func (r *ReconcileConfig) Reconcile(request reconcile.Request) (reconcile.Result
 
 func (r *ReconcileConfig) ensureCertificatesExist(config *v1alpha1.Config, pods *corev1.PodList, instanceType string) error {
 	subjects := config.PodsCertSubjects(pods)
-	crt := certificates.NewCertificate(r.Client, r.Scheme, config, subjects, instanceType)
+	crt := certificates.NewCertificate(r.Client, r.Scheme, config, subjects, instanceType, utils.CertificateKeyConfig(config.Spec.CommonConfiguration.CertificateKey))
 	return crt.EnsureExistsAndIsSigned()
 }
@@ -126,6 +126,7 @@ func TestConfig(t *testing.T) {
 		testcase7(),
 		testcase8(),
 		testcase9(),
+		testcase10(),
 	}
 
 	for _, tt := range tests {
@@ -574,3 +575,28 @@ func testcase9() *TestCase {
 	}
 	return tc
 }
+
+func testcase10() *TestCase {
+	falseVal := false
+	cfg := newConfigInst()
+	cfg.Spec.ServiceConfiguration.AuthMode = contrail.AuthenticationModeKeystone
+	cfg.Spec.ServiceConfiguration.KeystoneInstance = "keystone"
+
+	tc := &TestCase{
+		name: "Wait for keystone to become available",
+		initObjs: []runtime.Object{
+			newManager(cfg),
+			newZookeeper(),
+			newCassandra(),
+			newRabbitmq(),
+			cfg,
+			configService(),
+			&contrail.Keystone{
+				ObjectMeta: meta.ObjectMeta{Name: "keystone", Namespace: "default"},
+				Status:     contrail.KeystoneStatus{Endpoint: "10.0.2.16"},
+			},
+		},
+		expectedStatus: contrail.ConfigStatus{Active: &falseVal},
+	}
+	return tc
+}
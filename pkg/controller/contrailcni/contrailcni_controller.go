@@ -9,6 +9,7 @@ import (
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
@@ -32,12 +33,16 @@ func Add(mgr manager.Manager, clusterInfo v1alpha1.CNIClusterInfo) error {
 
 // newReconciler returns a new reconcile.Reconciler
 func newReconciler(mgr manager.Manager, clusterInfo v1alpha1.CNIClusterInfo) reconcile.Reconciler {
-	kubernetes := k8s.New(mgr.GetClient(), mgr.GetScheme())
-	return NewReconciler(mgr.GetClient(), mgr.GetScheme(), kubernetes, clusterInfo)
+	recorder := mgr.GetEventRecorderFor("contrailcni-controller")
+	client := utils.NewEventRecordingClient(mgr.GetClient(), recorder)
+	kubernetes := k8s.New(client, mgr.GetScheme())
+	r := NewReconciler(client, mgr.GetScheme(), kubernetes, clusterInfo)
+	r.Recorder = recorder
+	return r
 }
 
 // NewReconciler returns a new reconcile.Reconciler
-func NewReconciler(client client.Client, scheme *runtime.Scheme, kubernetes *k8s.Kubernetes, clusterInfo v1alpha1.CNIClusterInfo) reconcile.Reconciler {
+func NewReconciler(client client.Client, scheme *runtime.Scheme, kubernetes *k8s.Kubernetes, clusterInfo v1alpha1.CNIClusterInfo) *ReconcileContrailCNI {
 	return &ReconcileContrailCNI{Client: client, Scheme: scheme, kubernetes: kubernetes, ClusterInfo: clusterInfo}
 }
 
@@ -86,6 +91,7 @@ type ReconcileContrailCNI struct {
 	Scheme      *runtime.Scheme
 	kubernetes  *k8s.Kubernetes
 	ClusterInfo v1alpha1.CNIClusterInfo
+	Recorder    record.EventRecorder
 }
 
 // Reconcile reads that state of the cluster for a ContrailCNI object and makes changes based on the state read
@@ -117,8 +123,11 @@ func (r *ReconcileContrailCNI) Reconcile(request reconcile.Request) (reconcile.R
 		controlActive := controlInstance.IsActive(instance.Spec.ServiceConfiguration.ControlInstance,
 			request.Namespace, r.Client)
 
-		if !configActive || !controlActive {
-			return reconcile.Result{}, nil
+		if !configActive {
+			return reconcile.Result{}, v1alpha1.WaitForDependency(r.Client, r.Recorder, instance, &instance.Status.Conditions, "Config", configInstance.InstanceName(instance.Labels["contrail_cluster"], request.Namespace, r.Client))
+		}
+		if !controlActive {
+			return reconcile.Result{}, v1alpha1.WaitForDependency(r.Client, r.Recorder, instance, &instance.Status.Conditions, "Control", instance.Spec.ServiceConfiguration.ControlInstance)
 		}
 	}
 
@@ -22,6 +22,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"sigs.k8s.io/controller-runtime/pkg/source"
 
+	"github.com/Juniper/contrail-operator/pkg/controller/utils"
 	"github.com/Juniper/contrail-operator/pkg/k8s"
 )
 
@@ -34,8 +35,9 @@ func Add(mgr manager.Manager) error {
 }
 
 func newReconciler(mgr manager.Manager) reconcile.Reconciler {
+	client := utils.NewEventRecordingClient(mgr.GetClient(), mgr.GetEventRecorderFor("contrailmonitor-controller"))
 	return NewReconciler(
-		mgr.GetClient(), mgr.GetScheme(), k8s.New(mgr.GetClient(), mgr.GetScheme()))
+		client, mgr.GetScheme(), k8s.New(client, mgr.GetScheme()))
 }
 
 // NewReconciler returns a new reconcile.Reconciler
@@ -482,6 +484,7 @@ func (r *ReconcileContrailmonitor) Reconcile(request reconcile.Request) (reconci
 
 	instance.Status.Name = "contrailmonitor"
 	instance.Status.Active = true
+	contrailv1alpha1.SetActiveConditions(&instance.Status.Conditions, instance.Generation, true, contrailv1alpha1.ReasonResourcesReady, "status of services is monitored")
 	if err := r.client.Status().Update(context.Background(), instance); err != nil {
 		return reconcile.Result{}, err
 	}
@@ -11,6 +11,7 @@ import (
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/workqueue"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
@@ -94,7 +95,8 @@ func Add(mgr manager.Manager) error {
 
 // newReconciler returns a new reconcile.Reconciler
 func newReconciler(mgr manager.Manager) reconcile.Reconciler {
-	return &ReconcileControl{Client: mgr.GetClient(), Scheme: mgr.GetScheme(), Manager: mgr}
+	recorder := mgr.GetEventRecorderFor("control-controller")
+	return &ReconcileControl{Client: utils.NewEventRecordingClient(mgr.GetClient(), recorder), Scheme: mgr.GetScheme(), Manager: mgr, Recorder: recorder}
 }
 
 // add adds a new Controller to mgr with r as the reconcile.Reconciler
@@ -169,9 +171,10 @@ var _ reconcile.Reconciler = &ReconcileControl{}
 type ReconcileControl struct {
 	// This client, initialized using mgr.Client() above, is a split client
 	// that reads objects from the cache and writes to the apiserver
-	Client  client.Client
-	Scheme  *runtime.Scheme
-	Manager manager.Manager
+	Client   client.Client
+	Scheme   *runtime.Scheme
+	Manager  manager.Manager
+	Recorder record.EventRecorder
 }
 
 // Reconcile reconciles control resource
@@ -198,8 +201,14 @@ func (r *ReconcileControl) Reconcile(request reconcile.Request) (reconcile.Resul
 		request.Namespace, r.Client)
 	configActive := configInstance.IsActive(instance.Labels["contrail_cluster"],
 		request.Namespace, r.Client)
-	if !configActive || !cassandraActive || !rabbitmqActive {
-		return reconcile.Result{}, nil
+	if !cassandraActive {
+		return reconcile.Result{}, v1alpha1.WaitForDependency(r.Client, r.Recorder, instance, &instance.Status.Conditions, "Cassandra", instance.Spec.ServiceConfiguration.CassandraInstance)
+	}
+	if !rabbitmqActive {
+		return reconcile.Result{}, v1alpha1.WaitForDependency(r.Client, r.Recorder, instance, &instance.Status.Conditions, "Rabbitmq", rabbitmqInstance.InstanceName(instance.Labels["contrail_cluster"], request.Namespace, r.Client))
+	}
+	if !configActive {
+		return reconcile.Result{}, v1alpha1.WaitForDependency(r.Client, r.Recorder, instance, &instance.Status.Conditions, "Config", configInstance.InstanceName(instance.Labels["contrail_cluster"], request.Namespace, r.Client))
 	}
 
 	configMap, err := instance.CreateConfigMap(request.Name+"-"+instanceType+"-configmap", r.Client, r.Scheme, request)
@@ -484,6 +493,6 @@ func (r *ReconcileControl) Reconcile(request reconcile.Request) (reconcile.Resul
 
 func (r *ReconcileControl) ensureCertificatesExist(control *v1alpha1.Control, pods *corev1.PodList, instanceType string) error {
 	subjects := control.PodsCertSubjects(pods)
-	crt := certificates.NewCertificate(r.Client, r.Scheme, control, subjects, instanceType)
+	crt := certificates.NewCertificate(r.Client, r.Scheme, control, subjects, instanceType, utils.CertificateKeyConfig(control.Spec.CommonConfiguration.CertificateKey))
 	return crt.EnsureExistsAndIsSigned()
 }
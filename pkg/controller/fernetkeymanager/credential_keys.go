@@ -0,0 +1,211 @@
+package fernetkeymanager
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	batch "k8s.io/api/batch/v1"
+	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	contrail "github.com/Juniper/contrail-operator/pkg/apis/contrail/v1alpha1"
+)
+
+// reconcileCredentialKeys rotates Keystone credential keys. Rotation promotes the staged
+// key to a new primary key and keeps previous keys, so that stored credentials can be
+// decrypted. Once all pods observe the new keys, credentials are re-encrypted with the
+// primary key by the migration Job and previous keys are dropped. It returns the time
+// after which credential keys should be reconciled again.
+func (r *ReconcileFernetKeyManager) reconcileCredentialKeys(fernetKeyManager *contrail.FernetKeyManager) (time.Duration, error) {
+	spec := fernetKeyManager.Spec.CredentialKeys
+	if spec == nil {
+		return 0, nil
+	}
+	keySecret, err := r.getSecret(spec.SecretName, fernetKeyManager.Namespace)
+	if errors.IsNotFound(err) {
+		log.Info(fmt.Sprintf("Credential keys secret %s does not exist yet", spec.SecretName))
+		return observationRequeuePeriod, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	if fernetKeyManager.Status.CredentialKeys == nil {
+		// Keys generated with the secret are as old as the secret.
+		fernetKeyManager.Status.CredentialKeys = &contrail.CredentialKeysStatus{
+			LastRotationTime: keySecret.CreationTimestamp.DeepCopy(),
+		}
+	}
+	status := fernetKeyManager.Status.CredentialKeys
+	interval := time.Second * time.Duration(spec.RotationInterval)
+
+	if status.Migrating {
+		migrated, err := r.migrateCredentials(fernetKeyManager, keySecret)
+		if err != nil || !migrated {
+			return observationRequeuePeriod, err
+		}
+		if err := r.dropPreviousCredentialKeys(keySecret); err != nil {
+			return 0, err
+		}
+		status.Migrating = false
+	} else if !time.Now().Before(status.LastRotationTime.Add(interval)) {
+		observed, err := r.podsObservedKeys(keySecret)
+		if err != nil || !observed {
+			return observationRequeuePeriod, err
+		}
+		if err := r.rotateCredentialKeys(keySecret); err != nil {
+			return 0, err
+		}
+		status.LastRotationTime = &metav1.Time{Time: time.Now()}
+		status.Migrating = true
+	}
+
+	indices, err := keyIndices(keySecret.Data)
+	if err != nil {
+		return 0, err
+	}
+	if len(indices) != 0 {
+		status.PrimaryKeyIndex = indices[len(indices)-1]
+	}
+	if status.Migrating {
+		return observationRequeuePeriod, nil
+	}
+	return time.Until(status.LastRotationTime.Add(interval)), nil
+}
+
+// rotateCredentialKeys promotes the staged key to the primary key and stages a new key.
+// Previous primary keys are kept until credentials are migrated.
+func (r *ReconcileFernetKeyManager) rotateCredentialKeys(sc *core.Secret) error {
+	indices, err := keyIndices(sc.Data)
+	if err != nil {
+		return err
+	}
+	if len(indices) == 0 {
+		return fmt.Errorf("credential key repository %s is empty", sc.Name)
+	}
+	newKey, err := generateKey()
+	if err != nil {
+		return err
+	}
+	primaryKeyIndex := indices[len(indices)-1] + 1
+	log.Info(fmt.Sprintf("Promoting staged credential key to primary key %d", primaryKeyIndex))
+	sc.Data[strconv.Itoa(primaryKeyIndex)] = sc.Data["0"]
+	sc.Data["0"] = newKey
+	return r.client.Update(context.Background(), sc)
+}
+
+// dropPreviousCredentialKeys removes keys other than the staged and the primary key.
+func (r *ReconcileFernetKeyManager) dropPreviousCredentialKeys(sc *core.Secret) error {
+	indices, err := keyIndices(sc.Data)
+	if err != nil {
+		return err
+	}
+	if len(indices) <= 2 {
+		return nil
+	}
+	for _, index := range indices[1 : len(indices)-1] {
+		if index == 0 {
+			continue
+		}
+		log.Info(fmt.Sprintf("Dropping migrated credential key %d", index))
+		delete(sc.Data, strconv.Itoa(index))
+	}
+	return r.client.Update(context.Background(), sc)
+}
+
+// migrateCredentials runs the migration Job once all pods observe the rotated keys and
+// tells whether it succeeded. The Job is removed after it succeeds or fails, so that a
+// failed migration is run again by a new Job.
+func (r *ReconcileFernetKeyManager) migrateCredentials(fernetKeyManager *contrail.FernetKeyManager, sc *core.Secret) (bool, error) {
+	observed, err := r.podsObservedKeys(sc)
+	if err != nil || !observed {
+		return false, err
+	}
+	indices, err := keyIndices(sc.Data)
+	if err != nil {
+		return false, err
+	}
+	job := newCredentialMigrationJob(fernetKeyManager, indices[len(indices)-1])
+	err = r.client.Get(context.Background(), types.NamespacedName{Name: job.Name, Namespace: job.Namespace}, job)
+	if errors.IsNotFound(err) {
+		if err := controllerutil.SetControllerReference(fernetKeyManager, job, r.scheme); err != nil {
+			return false, err
+		}
+		log.Info(fmt.Sprintf("Migrating credentials with job %s", job.Name))
+		return false, r.client.Create(context.Background(), job)
+	}
+	if err != nil {
+		return false, err
+	}
+	for _, condition := range job.Status.Conditions {
+		if condition.Type == batch.JobFailed && condition.Status == core.ConditionTrue {
+			log.Info(fmt.Sprintf("Removing failed credential migration job %s", job.Name))
+			if err := r.client.Delete(context.Background(), job, client.PropagationPolicy(metav1.DeletePropagationBackground)); err != nil {
+				return false, err
+			}
+			return false, fmt.Errorf("credential migration job %s failed: %s", job.Name, condition.Message)
+		}
+	}
+	if job.Status.Succeeded == 0 {
+		return false, nil
+	}
+	return true, r.client.Delete(context.Background(), job, client.PropagationPolicy(metav1.DeletePropagationBackground))
+}
+
+func newCredentialMigrationJob(fernetKeyManager *contrail.FernetKeyManager, primaryKeyIndex int) *batch.Job {
+	spec := fernetKeyManager.Spec.CredentialKeys
+	return &batch.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-credential-migrate-%d", fernetKeyManager.Name, primaryKeyIndex),
+			Namespace: fernetKeyManager.Namespace,
+		},
+		Spec: batch.JobSpec{
+			Template: core.PodTemplateSpec{
+				Spec: core.PodSpec{
+					RestartPolicy: core.RestartPolicyNever,
+					Volumes: []core.Volume{
+						{
+							Name: "keystone-credential-migrate-config-volume",
+							VolumeSource: core.VolumeSource{
+								ConfigMap: &core.ConfigMapVolumeSource{
+									LocalObjectReference: core.LocalObjectReference{Name: spec.ConfigMapName},
+								},
+							},
+						},
+						{
+							Name: "keystone-credential-keys",
+							VolumeSource: core.VolumeSource{
+								Secret: &core.SecretVolumeSource{SecretName: spec.SecretName},
+							},
+						},
+					},
+					Containers: []core.Container{
+						{
+							Name:            "keystone-credential-migrate",
+							Image:           spec.Image,
+							ImagePullPolicy: core.PullIfNotPresent,
+							Command:         spec.Command,
+							Env: []core.EnvVar{
+								{Name: "KOLLA_SERVICE_NAME", Value: "keystone"},
+								{Name: "KOLLA_CONFIG_STRATEGY", Value: "COPY_ALWAYS"},
+							},
+							VolumeMounts: []core.VolumeMount{
+								{Name: "keystone-credential-migrate-config-volume", MountPath: "/var/lib/kolla/config_files/"},
+								{Name: "keystone-credential-keys", MountPath: "/etc/keystone/credential-keys"},
+							},
+						},
+					},
+					Tolerations: []core.Toleration{
+						{Operator: "Exists", Effect: "NoSchedule"},
+						{Operator: "Exists", Effect: "NoExecute"},
+					},
+				},
+			},
+		},
+	}
+}
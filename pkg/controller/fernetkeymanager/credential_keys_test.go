@@ -0,0 +1,194 @@
+package fernetkeymanager
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	batch "k8s.io/api/batch/v1"
+	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	contrail "github.com/Juniper/contrail-operator/pkg/apis/contrail/v1alpha1"
+	"github.com/Juniper/contrail-operator/pkg/k8s"
+)
+
+func TestCredentialKeysRotation(t *testing.T) {
+	scheme, err := contrail.SchemeBuilder.Build()
+	require.NoError(t, err)
+	require.NoError(t, core.SchemeBuilder.AddToScheme(scheme))
+	require.NoError(t, batch.SchemeBuilder.AddToScheme(scheme))
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: "keystone-fernet-key-manager", Namespace: "default"}}
+
+	newFernetKeyManager := func(status *contrail.CredentialKeysStatus) *contrail.FernetKeyManager {
+		return &contrail.FernetKeyManager{
+			ObjectMeta: meta.ObjectMeta{Name: "keystone-fernet-key-manager", Namespace: "default"},
+			Spec: contrail.FernetKeyManagerSpec{
+				TokenExpiration: 3600, TokenAllowExpiredWindow: 3600, RotationInterval: 3600,
+				CredentialKeys: &contrail.CredentialKeysRotation{
+					SecretName:       "keystone-credential-keys-repository",
+					RotationInterval: 3600,
+					Image:            "keystone:train",
+					ConfigMapName:    "keystone-keystone-credential-migrate",
+				},
+			},
+			Status: contrail.FernetKeyManagerStatus{
+				SecretName:       "keystone-fernet-key-manager-keys",
+				LastRotationTime: &meta.Time{Time: time.Now()},
+				CredentialKeys:   status,
+			},
+		}
+	}
+	fernetKeys := &core.Secret{
+		ObjectMeta: meta.ObjectMeta{Name: "keystone-fernet-key-manager-keys", Namespace: "default"},
+		Data:       map[string][]byte{"0": []byte("staged"), "1": []byte("primary")},
+	}
+	newCredentialKeys := func(keys map[string][]byte) *core.Secret {
+		return &core.Secret{
+			ObjectMeta: meta.ObjectMeta{
+				Name:              "keystone-credential-keys-repository",
+				Namespace:         "default",
+				CreationTimestamp: meta.NewTime(time.Now().Add(-2 * time.Hour)),
+			},
+			Data: keys,
+		}
+	}
+	reconcileWith := func(t *testing.T, objs ...runtime.Object) (client.Client, reconcile.Result) {
+		cl := fake.NewFakeClientWithScheme(scheme, objs...)
+		res, err := NewReconciler(cl, scheme, k8s.New(cl, scheme)).Reconcile(req)
+		require.NoError(t, err)
+		return cl, res
+	}
+	getCredentialKeys := func(t *testing.T, cl client.Client) map[string][]byte {
+		sc := &core.Secret{}
+		require.NoError(t, cl.Get(context.Background(), types.NamespacedName{Name: "keystone-credential-keys-repository", Namespace: "default"}, sc))
+		return sc.Data
+	}
+	getStatus := func(t *testing.T, cl client.Client) *contrail.CredentialKeysStatus {
+		fkm := &contrail.FernetKeyManager{}
+		require.NoError(t, cl.Get(context.Background(), req.NamespacedName, fkm))
+		return fkm.Status.CredentialKeys
+	}
+	jobName := types.NamespacedName{Name: "keystone-fernet-key-manager-credential-migrate-2", Namespace: "default"}
+	migrating := func() *contrail.CredentialKeysStatus {
+		return &contrail.CredentialKeysStatus{LastRotationTime: &meta.Time{Time: time.Now()}, PrimaryKeyIndex: 2, Migrating: true}
+	}
+	rotatedKeys := func() map[string][]byte {
+		return map[string][]byte{"0": []byte("new"), "1": []byte("previous"), "2": []byte("staged")}
+	}
+
+	restoreExecToPod := execToPod
+	defer func() { execToPod = restoreExecToPod }()
+	execToPod = func(command []string, container, pod, namespace string, stdin io.Reader) (string, string, error) {
+		return "", "", nil
+	}
+
+	t.Run("should promote staged credential key and keep previous keys", func(t *testing.T) {
+		cl, res := reconcileWith(t, newFernetKeyManager(nil), fernetKeys, newCredentialKeys(map[string][]byte{"0": []byte("staged"), "1": []byte("primary")}))
+		keys := getCredentialKeys(t, cl)
+		assert.Len(t, keys, 3)
+		assert.Equal(t, []byte("primary"), keys["1"])
+		assert.Equal(t, []byte("staged"), keys["2"])
+		status := getStatus(t, cl)
+		assert.True(t, status.Migrating)
+		assert.Equal(t, 2, status.PrimaryKeyIndex)
+		assert.Equal(t, observationRequeuePeriod, res.RequeueAfter)
+	})
+
+	t.Run("should not rotate credential keys before rotation interval passes", func(t *testing.T) {
+		status := &contrail.CredentialKeysStatus{LastRotationTime: &meta.Time{Time: time.Now()}, PrimaryKeyIndex: 1}
+		cl, _ := reconcileWith(t, newFernetKeyManager(status), fernetKeys, newCredentialKeys(map[string][]byte{"0": []byte("staged"), "1": []byte("primary")}))
+		assert.Len(t, getCredentialKeys(t, cl), 2)
+		assert.False(t, getStatus(t, cl).Migrating)
+	})
+
+	t.Run("should run migration job after rotation", func(t *testing.T) {
+		cl, _ := reconcileWith(t, newFernetKeyManager(migrating()), fernetKeys, newCredentialKeys(rotatedKeys()))
+		job := &batch.Job{}
+		require.NoError(t, cl.Get(context.Background(), jobName, job))
+		assert.Equal(t, "keystone:train", job.Spec.Template.Spec.Containers[0].Image)
+		assert.Equal(t, "keystone-credential-keys-repository", job.Spec.Template.Spec.Volumes[1].Secret.SecretName)
+		assert.Equal(t, rotatedKeys(), getCredentialKeys(t, cl))
+		assert.True(t, getStatus(t, cl).Migrating)
+	})
+
+	t.Run("should not run migration job until pods observe rotated keys", func(t *testing.T) {
+		pod := &core.Pod{
+			ObjectMeta: meta.ObjectMeta{Name: "keystone-0", Namespace: "default"},
+			Spec: core.PodSpec{
+				Containers: []core.Container{{
+					Name:         "keystone",
+					VolumeMounts: []core.VolumeMount{{Name: "keystone-credential-keys", MountPath: "/etc/keystone/credential-keys"}},
+				}},
+				Volumes: []core.Volume{{
+					Name:         "keystone-credential-keys",
+					VolumeSource: core.VolumeSource{Secret: &core.SecretVolumeSource{SecretName: "keystone-credential-keys-repository"}},
+				}},
+			},
+			Status: core.PodStatus{
+				Phase:             core.PodRunning,
+				ContainerStatuses: []core.ContainerStatus{{Name: "keystone", State: core.ContainerState{Running: &core.ContainerStateRunning{}}}},
+			},
+		}
+		execToPod = func(command []string, container, pod, namespace string, stdin io.Reader) (string, string, error) {
+			return "0\n1\n", "", nil
+		}
+		defer func() {
+			execToPod = func(command []string, container, pod, namespace string, stdin io.Reader) (string, string, error) {
+				return "", "", nil
+			}
+		}()
+		cl, _ := reconcileWith(t, newFernetKeyManager(migrating()), fernetKeys, newCredentialKeys(rotatedKeys()), pod)
+		err := cl.Get(context.Background(), jobName, &batch.Job{})
+		assert.True(t, errors.IsNotFound(err))
+	})
+
+	t.Run("should drop previous keys when credentials are migrated", func(t *testing.T) {
+		job := newCredentialMigrationJob(newFernetKeyManager(nil), 2)
+		job.Status.Succeeded = 1
+		cl, res := reconcileWith(t, newFernetKeyManager(migrating()), fernetKeys, newCredentialKeys(rotatedKeys()), job)
+		assert.Equal(t, map[string][]byte{"0": []byte("new"), "2": []byte("staged")}, getCredentialKeys(t, cl))
+		status := getStatus(t, cl)
+		assert.False(t, status.Migrating)
+		assert.Equal(t, 2, status.PrimaryKeyIndex)
+		assert.True(t, res.RequeueAfter > 59*time.Minute)
+		err := cl.Get(context.Background(), jobName, &batch.Job{})
+		assert.True(t, errors.IsNotFound(err))
+	})
+
+	t.Run("should remove failed migration job and run it again", func(t *testing.T) {
+		job := newCredentialMigrationJob(newFernetKeyManager(nil), 2)
+		job.Status.Conditions = []batch.JobCondition{{Type: batch.JobFailed, Status: core.ConditionTrue, Message: "BackoffLimitExceeded"}}
+		cl := fake.NewFakeClientWithScheme(scheme, newFernetKeyManager(migrating()), fernetKeys, newCredentialKeys(rotatedKeys()), job)
+		reconciler := NewReconciler(cl, scheme, k8s.New(cl, scheme))
+		// when
+		_, err := reconciler.Reconcile(req)
+		// then
+		assert.EqualError(t, err, "credential migration job keystone-fernet-key-manager-credential-migrate-2 failed: BackoffLimitExceeded")
+		assert.True(t, errors.IsNotFound(cl.Get(context.Background(), jobName, &batch.Job{})))
+		assert.Equal(t, rotatedKeys(), getCredentialKeys(t, cl))
+		assert.True(t, getStatus(t, cl).Migrating)
+		fkm := &contrail.FernetKeyManager{}
+		require.NoError(t, cl.Get(context.Background(), req.NamespacedName, fkm))
+		degraded := contrail.FindCondition(fkm.Status.Conditions, contrail.ConditionDegraded)
+		require.NotNil(t, degraded)
+		assert.Equal(t, contrail.ConditionTrue, degraded.Status)
+		assert.Equal(t, "CredentialKeysFailed", degraded.Reason)
+		// when
+		_, err = reconciler.Reconcile(req)
+		// then
+		require.NoError(t, err)
+		require.NoError(t, cl.Get(context.Background(), jobName, &batch.Job{}))
+		require.NoError(t, cl.Get(context.Background(), req.NamespacedName, fkm))
+		assert.False(t, contrail.IsConditionTrue(fkm.Status.Conditions, contrail.ConditionDegraded))
+	})
+}
@@ -1,22 +1,39 @@
 package fernetkeymanager
 
 import (
+	"context"
 	"crypto/rand"
 	"encoding/base64"
+	"fmt"
+	"sort"
+	"strconv"
 
 	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
 
 	contrail "github.com/Juniper/contrail-operator/pkg/apis/contrail/v1alpha1"
 	"github.com/Juniper/contrail-operator/pkg/k8s"
 )
 
+// legacySecretName is the key repository shared by FernetKeyManagers of a namespace
+// before each of them got its own secret.
+const legacySecretName = "fernet-keys-repository"
+
 type secret struct {
 	sc *k8s.Secret
+	// legacyKeys initialize the key repository, so that tokens issued with keys
+	// of the legacy repository stay valid.
+	legacyKeys map[string][]byte
 }
 
 // Fill secret initializes key repository with staged key
 func (s *secret) FillSecret(sc *core.Secret) error {
-	if sc.Data != nil {
+	if len(sc.Data) != 0 {
+		return nil
+	}
+	if len(s.legacyKeys) != 0 {
+		sc.Data = s.legacyKeys
 		return nil
 	}
 	key, err := generateKey()
@@ -35,6 +52,22 @@ func (r *ReconcileFernetKeyManager) secret(secretName, ownerType string, fernetK
 	}
 }
 
+// legacyKeys returns keys of the legacy repository when it belongs to the FernetKeyManager.
+func (r *ReconcileFernetKeyManager) legacyKeys(fernetKeyManager *contrail.FernetKeyManager) (map[string][]byte, error) {
+	sc := &core.Secret{}
+	err := r.client.Get(context.Background(), types.NamespacedName{Name: legacySecretName, Namespace: fernetKeyManager.Namespace}, sc)
+	if errors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if sc.Labels["fernetKeyManager"] != fernetKeyManager.Name {
+		return nil, nil
+	}
+	return sc.Data, nil
+}
+
 func generateKey() ([]byte, error) {
 	key := make([]byte, 32)
 	_, err := rand.Read(key)
@@ -47,6 +80,21 @@ func generateKey() ([]byte, error) {
 	return encodedKey, nil
 }
 
+// keyIndices returns sorted indices of keys in the repository. Index 0 is the staged
+// key and the highest index is the primary key.
+func keyIndices(keys map[string][]byte) ([]int, error) {
+	indices := make([]int, 0, len(keys))
+	for k := range keys {
+		index, err := strconv.Atoi(k)
+		if err != nil {
+			return nil, fmt.Errorf("invalid key index %q: %w", k, err)
+		}
+		indices = append(indices, index)
+	}
+	sort.Ints(indices)
+	return indices, nil
+}
+
 func (s *secret) ensureKeysRepoSecretExists() error {
 	return s.sc.EnsureExists(s)
 }
@@ -3,12 +3,13 @@ package fernetkeymanager
 import (
 	"context"
 	"fmt"
-	"sort"
 	"strconv"
 	"time"
 
+	batch "k8s.io/api/batch/v1"
 	core "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -20,6 +21,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	contrailv1alpha1 "github.com/Juniper/contrail-operator/pkg/apis/contrail/v1alpha1"
+	"github.com/Juniper/contrail-operator/pkg/controller/utils"
 	"github.com/Juniper/contrail-operator/pkg/k8s"
 )
 
@@ -33,7 +35,8 @@ func Add(mgr manager.Manager) error {
 
 // newReconciler returns a new reconcile.Reconciler
 func newReconciler(mgr manager.Manager) reconcile.Reconciler {
-	return NewReconciler(mgr.GetClient(), mgr.GetScheme(), k8s.New(mgr.GetClient(), mgr.GetScheme()))
+	client := utils.NewEventRecordingClient(mgr.GetClient(), mgr.GetEventRecorderFor("fernetkeymanager-controller"))
+	return NewReconciler(client, mgr.GetScheme(), k8s.New(client, mgr.GetScheme()))
 }
 
 // add adds a new Controller to mgr with r as the reconcile.Reconciler
@@ -50,6 +53,15 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 		return err
 	}
 
+	// Watch for changes to credential migration jobs
+	err = c.Watch(&source.Kind{Type: &batch.Job{}}, &handler.EnqueueRequestForOwner{
+		IsController: true,
+		OwnerType:    &contrailv1alpha1.FernetKeyManager{},
+	})
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -88,8 +100,14 @@ func (r *ReconcileFernetKeyManager) Reconcile(request reconcile.Request) (reconc
 		return reconcile.Result{}, nil
 	}
 
-	keySecretName := "fernet-keys-repository"
-	if err = r.secret(keySecretName, "fernetKeyManager", fernetKeyManager).ensureKeysRepoSecretExists(); err != nil {
+	keySecretName := fernetKeyManager.Name + "-keys"
+	keysRepo := r.secret(keySecretName, "fernetKeyManager", fernetKeyManager)
+	if fernetKeyManager.Status.SecretName == legacySecretName {
+		if keysRepo.legacyKeys, err = r.legacyKeys(fernetKeyManager); err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+	if err = keysRepo.ensureKeysRepoSecretExists(); err != nil {
 		return reconcile.Result{}, err
 	}
 
@@ -111,21 +129,68 @@ func (r *ReconcileFernetKeyManager) Reconcile(request reconcile.Request) (reconc
 	if err != nil {
 		return reconcile.Result{}, err
 	}
-	if err := r.rotateKeys(keySecret, maxActiveKeys); err != nil {
+
+	interval := time.Second * time.Duration(rotationInterval)
+	requeueAfter := interval
+	status := &fernetKeyManager.Status
+	if rotationDue(fernetKeyManager, interval) {
+		observed, err := r.podsObservedKeys(keySecret)
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+		if observed {
+			if err := r.rotateKeys(keySecret, maxActiveKeys); err != nil {
+				return reconcile.Result{}, err
+			}
+			status.LastRotationTime = &metav1.Time{Time: time.Now()}
+			status.ObservedRotationRequest = fernetKeyManager.Spec.RotationRequest
+		} else {
+			requeueAfter = observationRequeuePeriod
+		}
+	} else {
+		requeueAfter = time.Until(status.LastRotationTime.Add(interval))
+	}
+
+	indices, err := keyIndices(keySecret.Data)
+	if err != nil {
 		return reconcile.Result{}, err
 	}
+	if len(indices) != 0 {
+		status.PrimaryKeyIndex = indices[len(indices)-1]
+	}
+	status.SecretName = keySecretName
 
-	fernetKeyManager.Status.SecretName = keySecretName
+	credentialKeysRequeueAfter, credentialKeysErr := r.reconcileCredentialKeys(fernetKeyManager)
+	if credentialKeysRequeueAfter != 0 && credentialKeysRequeueAfter < requeueAfter {
+		requeueAfter = credentialKeysRequeueAfter
+	}
+	contrailv1alpha1.SetActiveConditions(&fernetKeyManager.Status.Conditions, fernetKeyManager.Generation, true, contrailv1alpha1.ReasonResourcesReady, "fernet keys are stored in secret "+keySecretName)
+	if credentialKeysErr != nil {
+		contrailv1alpha1.SetCondition(&fernetKeyManager.Status.Conditions, contrailv1alpha1.Condition{Type: contrailv1alpha1.ConditionDegraded, Status: contrailv1alpha1.ConditionTrue, ObservedGeneration: fernetKeyManager.Generation, Reason: "CredentialKeysFailed", Message: credentialKeysErr.Error()})
+	}
 	if err := r.client.Status().Update(context.TODO(), fernetKeyManager); err != nil {
 		return reconcile.Result{}, err
 	}
+	if credentialKeysErr != nil {
+		return reconcile.Result{}, credentialKeysErr
+	}
 
 	return reconcile.Result{
 		Requeue:      true,
-		RequeueAfter: time.Second * time.Duration(rotationInterval),
+		RequeueAfter: requeueAfter,
 	}, nil
 }
 
+// rotationDue tells whether keys were never rotated, the rotation interval passed since
+// the last rotation or another rotation was requested.
+func rotationDue(fernetKeyManager *contrailv1alpha1.FernetKeyManager, interval time.Duration) bool {
+	status := fernetKeyManager.Status
+	if status.LastRotationTime == nil || fernetKeyManager.Spec.RotationRequest != status.ObservedRotationRequest {
+		return true
+	}
+	return !time.Now().Before(status.LastRotationTime.Add(interval))
+}
+
 func (r *ReconcileFernetKeyManager) getSecret(secretName, secretNamespace string) (*core.Secret, error) {
 	secret := &core.Secret{}
 	namespacedName := types.NamespacedName{Name: secretName, Namespace: secretNamespace}
@@ -137,13 +202,9 @@ func (r *ReconcileFernetKeyManager) getSecret(secretName, secretNamespace string
 
 func (r *ReconcileFernetKeyManager) rotateKeys(sc *core.Secret, maxActiveKeys int) error {
 	keys := sc.Data
-	existingKeysIndices := make([]int, 0, len(keys))
-	for k := range keys {
-		key, err := strconv.Atoi(k)
-		if err != nil {
-			return err
-		}
-		existingKeysIndices = append(existingKeysIndices, key)
+	existingKeysIndices, err := keyIndices(keys)
+	if err != nil {
+		return err
 	}
 
 	activeKeysNumber := len(existingKeysIndices)
@@ -152,7 +213,6 @@ func (r *ReconcileFernetKeyManager) rotateKeys(sc *core.Secret, maxActiveKeys in
 	}
 	log.Info(fmt.Sprintf("Starting rotation with %d keys", activeKeysNumber))
 
-	sort.Ints(existingKeysIndices)
 	maxKeyIndex := existingKeysIndices[activeKeysNumber-1]
 	log.Info(fmt.Sprintf("Current primary is: %d", maxKeyIndex))
 	log.Info(fmt.Sprintf("Next primary key will be: %d", maxKeyIndex+1))
@@ -45,7 +45,7 @@ func TestFernetKeyManager(t *testing.T) {
 	t.Run("when fernetKeyManager is reconciled and key repository is not initialized", func(t *testing.T) {
 		initSecret := &core.Secret{
 			ObjectMeta: v1.ObjectMeta{
-				Name:      "fernet-keys-repository",
+				Name:      "test-fernet-key-manager-keys",
 				Namespace: "default",
 				Labels:    map[string]string{"contrail_manager": "fernetKeyManager", "fernetKeyManager": "test-fernet-key-manager"},
 				OwnerReferences: []v1.OwnerReference{
@@ -63,7 +63,7 @@ func TestFernetKeyManager(t *testing.T) {
 		t.Run("then key repository should contain staged and primary key", func(t *testing.T) {
 			expectedSecret := &core.Secret{
 				ObjectMeta: v1.ObjectMeta{
-					Name:      "fernet-keys-repository",
+					Name:      "test-fernet-key-manager-keys",
 					Namespace: "default",
 					Labels:    map[string]string{"contrail_manager": "fernetKeyManager", "fernetKeyManager": "test-fernet-key-manager"},
 					OwnerReferences: []v1.OwnerReference{
@@ -91,7 +91,7 @@ func TestFernetKeyManager(t *testing.T) {
 	t.Run("when fernetKeyManager is reconciled and key repository already exists with staged key only", func(t *testing.T) {
 		initSecret := &core.Secret{
 			ObjectMeta: v1.ObjectMeta{
-				Name:      "fernet-keys-repository",
+				Name:      "test-fernet-key-manager-keys",
 				Namespace: "default",
 				Labels:    map[string]string{"contrail_manager": "fernetKeyManager", "fernetKeyManager": "test-fernet-key-manager"},
 				OwnerReferences: []v1.OwnerReference{
@@ -127,14 +127,14 @@ func TestFernetKeyManager(t *testing.T) {
 			})
 		})
 
-		t.Run("then status should contain information about secret name", func(t *testing.T) {
-			expectedStatus := contrail.FernetKeyManagerStatus{
-				SecretName: "fernet-keys-repository",
-			}
+		t.Run("then status should contain secret name and rotation", func(t *testing.T) {
 			k := &contrail.FernetKeyManager{}
 			err = cl.Get(context.Background(), req.NamespacedName, k)
 			assert.NoError(t, err)
-			assert.Equal(t, expectedStatus, k.Status)
+			assert.True(t, contrail.IsConditionTrue(k.Status.Conditions, contrail.ConditionReady))
+			assert.Equal(t, "test-fernet-key-manager-keys", k.Status.SecretName)
+			assert.NotNil(t, k.Status.LastRotationTime)
+			assert.Equal(t, maxIndexFromKeyRepository(initSecret)+1, k.Status.PrimaryKeyIndex)
 		})
 
 	})
@@ -142,7 +142,7 @@ func TestFernetKeyManager(t *testing.T) {
 	t.Run("when fernetKeyManager is reconciled and key repository has maximum active keys", func(t *testing.T) {
 		initSecret := &core.Secret{
 			ObjectMeta: v1.ObjectMeta{
-				Name:      "fernet-keys-repository",
+				Name:      "test-fernet-key-manager-keys",
 				Namespace: "default",
 				Labels:    map[string]string{"contrail_manager": "fernetKeyManager", "fernetKeyManager": "test-fernet-key-manager"},
 				OwnerReferences: []v1.OwnerReference{
@@ -181,14 +181,14 @@ func TestFernetKeyManager(t *testing.T) {
 			})
 		})
 
-		t.Run("then status should contain information about secret name", func(t *testing.T) {
-			expectedStatus := contrail.FernetKeyManagerStatus{
-				SecretName: "fernet-keys-repository",
-			}
+		t.Run("then status should contain secret name and rotation", func(t *testing.T) {
 			k := &contrail.FernetKeyManager{}
 			err = cl.Get(context.Background(), req.NamespacedName, k)
 			assert.NoError(t, err)
-			assert.Equal(t, expectedStatus, k.Status)
+			assert.True(t, contrail.IsConditionTrue(k.Status.Conditions, contrail.ConditionReady))
+			assert.Equal(t, "test-fernet-key-manager-keys", k.Status.SecretName)
+			assert.NotNil(t, k.Status.LastRotationTime)
+			assert.Equal(t, maxIndexFromKeyRepository(initSecret)+1, k.Status.PrimaryKeyIndex)
 		})
 	})
 }
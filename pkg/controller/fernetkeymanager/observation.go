@@ -0,0 +1,88 @@
+package fernetkeymanager
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	core "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/Juniper/contrail-operator/pkg/k8s"
+)
+
+// observationRequeuePeriod is how often pods are checked while rotation waits for
+// them to observe current keys.
+const observationRequeuePeriod = 10 * time.Second
+
+var execToPod = k8s.ExecToPodThroughAPI
+
+// podsObservedKeys tells whether running pods which mount the key repository see
+// its current keys. Kubelet refreshes mounted secrets periodically, so keys must not
+// be rotated again until all pods can validate tokens encrypted with the staged key.
+func (r *ReconcileFernetKeyManager) podsObservedKeys(sc *core.Secret) (bool, error) {
+	pods := &core.PodList{}
+	if err := r.client.List(context.Background(), pods, client.InNamespace(sc.Namespace)); err != nil {
+		return false, err
+	}
+	for _, pod := range pods.Items {
+		if pod.Status.Phase != core.PodRunning || pod.DeletionTimestamp != nil {
+			continue
+		}
+		container, mountPath := keysMount(pod, sc.Name)
+		if container == "" || !containerRunning(pod, container) {
+			continue
+		}
+		stdout, stderr, err := execToPod([]string{"ls", "-1", mountPath}, container, pod.Name, pod.Namespace, nil)
+		if err != nil {
+			return false, fmt.Errorf("failed to list fernet keys of pod %s: %v, %s", pod.Name, err, stderr)
+		}
+		if !sameKeys(strings.Fields(stdout), sc.Data) {
+			log.Info(fmt.Sprintf("Pod %s has not observed current keys yet", pod.Name))
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// keysMount returns the container of the pod which mounts the secret and the mount path.
+func keysMount(pod core.Pod, secretName string) (string, string) {
+	for _, volume := range pod.Spec.Volumes {
+		if volume.Secret == nil || volume.Secret.SecretName != secretName {
+			continue
+		}
+		for _, container := range pod.Spec.Containers {
+			for _, mount := range container.VolumeMounts {
+				if mount.Name == volume.Name && mount.SubPath == "" {
+					return container.Name, mount.MountPath
+				}
+			}
+		}
+	}
+	return "", ""
+}
+
+func containerRunning(pod core.Pod, container string) bool {
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Name == container {
+			return status.State.Running != nil
+		}
+	}
+	return false
+}
+
+// sameKeys tells whether the key files are the keys of the repository. Keys of an index
+// never change, except for the staged key which is replaced together with promotion
+// of a new primary key, so indices identify the keys.
+func sameKeys(files []string, keys map[string][]byte) bool {
+	if len(files) != len(keys) {
+		return false
+	}
+	for _, file := range files {
+		if _, ok := keys[file]; !ok {
+			return false
+		}
+	}
+	return true
+}
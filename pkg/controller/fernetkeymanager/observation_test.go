@@ -0,0 +1,135 @@
+package fernetkeymanager
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	contrail "github.com/Juniper/contrail-operator/pkg/apis/contrail/v1alpha1"
+	"github.com/Juniper/contrail-operator/pkg/k8s"
+)
+
+func TestKeyRotationGating(t *testing.T) {
+	scheme, err := contrail.SchemeBuilder.Build()
+	require.NoError(t, err)
+	require.NoError(t, core.SchemeBuilder.AddToScheme(scheme))
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: "keystone-fernet-key-manager", Namespace: "default"}}
+
+	newFernetKeyManager := func() *contrail.FernetKeyManager {
+		return &contrail.FernetKeyManager{
+			ObjectMeta: meta.ObjectMeta{Name: "keystone-fernet-key-manager", Namespace: "default"},
+			Spec:       contrail.FernetKeyManagerSpec{TokenExpiration: 3600, TokenAllowExpiredWindow: 3600, RotationInterval: 3600},
+			Status: contrail.FernetKeyManagerStatus{
+				SecretName:       "keystone-fernet-key-manager-keys",
+				LastRotationTime: &meta.Time{Time: time.Now().Add(-time.Minute)},
+				PrimaryKeyIndex:  1,
+			},
+		}
+	}
+	newKeysSecret := func() *core.Secret {
+		return &core.Secret{
+			ObjectMeta: meta.ObjectMeta{Name: "keystone-fernet-key-manager-keys", Namespace: "default"},
+			Data:       map[string][]byte{"0": []byte("staged"), "1": []byte("primary")},
+		}
+	}
+	keystonePod := &core.Pod{
+		ObjectMeta: meta.ObjectMeta{Name: "keystone-0", Namespace: "default"},
+		Spec: core.PodSpec{
+			Containers: []core.Container{{
+				Name:         "keystone",
+				VolumeMounts: []core.VolumeMount{{Name: "keystone-fernet-keys", MountPath: "/etc/keystone/fernet-keys"}},
+			}},
+			Volumes: []core.Volume{{
+				Name: "keystone-fernet-keys",
+				VolumeSource: core.VolumeSource{
+					Secret: &core.SecretVolumeSource{SecretName: "keystone-fernet-key-manager-keys"},
+				},
+			}},
+		},
+		Status: core.PodStatus{
+			Phase:             core.PodRunning,
+			ContainerStatuses: []core.ContainerStatus{{Name: "keystone", State: core.ContainerState{Running: &core.ContainerStateRunning{}}}},
+		},
+	}
+	reconcileWith := func(t *testing.T, objs ...runtime.Object) (client.Client, reconcile.Result) {
+		cl := fake.NewFakeClientWithScheme(scheme, objs...)
+		res, err := NewReconciler(cl, scheme, k8s.New(cl, scheme)).Reconcile(req)
+		require.NoError(t, err)
+		return cl, res
+	}
+	getKeys := func(t *testing.T, cl client.Client, name string) map[string][]byte {
+		sc := &core.Secret{}
+		require.NoError(t, cl.Get(context.Background(), types.NamespacedName{Name: name, Namespace: "default"}, sc))
+		return sc.Data
+	}
+	getStatus := func(t *testing.T, cl client.Client) contrail.FernetKeyManagerStatus {
+		fkm := &contrail.FernetKeyManager{}
+		require.NoError(t, cl.Get(context.Background(), req.NamespacedName, fkm))
+		return fkm.Status
+	}
+
+	restoreExecToPod := execToPod
+	defer func() { execToPod = restoreExecToPod }()
+	observedKeys := "0\n1\n"
+	execToPod = func(command []string, container, pod, namespace string, stdin io.Reader) (string, string, error) {
+		assert.Equal(t, []string{"ls", "-1", "/etc/keystone/fernet-keys"}, command)
+		assert.Equal(t, "keystone", container)
+		return observedKeys, "", nil
+	}
+
+	t.Run("should not rotate keys before rotation interval passes", func(t *testing.T) {
+		cl, res := reconcileWith(t, newFernetKeyManager(), newKeysSecret(), keystonePod)
+		assert.Equal(t, newKeysSecret().Data, getKeys(t, cl, "keystone-fernet-key-manager-keys"))
+		assert.True(t, res.RequeueAfter > 58*time.Minute && res.RequeueAfter <= 59*time.Minute)
+		assert.Equal(t, 1, getStatus(t, cl).PrimaryKeyIndex)
+	})
+
+	t.Run("should rotate keys when rotation is requested", func(t *testing.T) {
+		fkm := newFernetKeyManager()
+		fkm.Spec.RotationRequest = "incident-42"
+		cl, res := reconcileWith(t, fkm, newKeysSecret(), keystonePod)
+		keys := getKeys(t, cl, "keystone-fernet-key-manager-keys")
+		assert.Equal(t, []byte("staged"), keys["2"])
+		assert.Equal(t, time.Hour, res.RequeueAfter)
+		status := getStatus(t, cl)
+		assert.Equal(t, 2, status.PrimaryKeyIndex)
+		assert.Equal(t, "incident-42", status.ObservedRotationRequest)
+		assert.True(t, status.LastRotationTime.After(fkm.Status.LastRotationTime.Time))
+	})
+
+	t.Run("should not rotate keys until all pods observe current keys", func(t *testing.T) {
+		observedKeys = "0\n"
+		defer func() { observedKeys = "0\n1\n" }()
+		fkm := newFernetKeyManager()
+		fkm.Spec.RotationRequest = "incident-42"
+		cl, res := reconcileWith(t, fkm, newKeysSecret(), keystonePod)
+		assert.Equal(t, newKeysSecret().Data, getKeys(t, cl, "keystone-fernet-key-manager-keys"))
+		assert.Equal(t, observationRequeuePeriod, res.RequeueAfter)
+		assert.Equal(t, "", getStatus(t, cl).ObservedRotationRequest)
+	})
+
+	t.Run("should take over keys of legacy repository", func(t *testing.T) {
+		fkm := newFernetKeyManager()
+		fkm.Status = contrail.FernetKeyManagerStatus{SecretName: "fernet-keys-repository"}
+		legacySecret := newKeysSecret()
+		legacySecret.Name = "fernet-keys-repository"
+		legacySecret.Labels = map[string]string{"contrail_manager": "fernetKeyManager", "fernetKeyManager": "keystone-fernet-key-manager"}
+		cl, _ := reconcileWith(t, fkm, legacySecret)
+		keys := getKeys(t, cl, "keystone-fernet-key-manager-keys")
+		assert.Len(t, keys, 3)
+		assert.Equal(t, []byte("primary"), keys["1"])
+		assert.Equal(t, []byte("staged"), keys["2"])
+		assert.Equal(t, "keystone-fernet-key-manager-keys", getStatus(t, cl).SecretName)
+	})
+}
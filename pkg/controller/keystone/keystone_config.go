@@ -13,6 +13,11 @@ type keystoneConfig struct {
 	RabbitMQServer   string
 	PostgreSQLServer string
 	MemcacheServer   string
+	DomainFiles      []string
+	AdminUsername    string
+	AdminProject     string
+	UserDomainID     string
+	ProjectDomainID  string
 }
 
 type keystonePodConfig struct {
@@ -21,6 +26,7 @@ type keystonePodConfig struct {
 	RabbitMQServer   string
 	PostgreSQLServer string
 	MemcacheServer   string
+	DomainFiles      []string
 }
 
 func (c *keystoneConfig) FillConfigMap(cm *core.ConfigMap) {
@@ -31,9 +37,15 @@ func (c *keystoneConfig) FillConfigMap(cm *core.ConfigMap) {
 			RabbitMQServer:   c.RabbitMQServer,
 			PostgreSQLServer: c.PostgreSQLServer,
 			MemcacheServer:   c.MemcacheServer,
+			DomainFiles:      c.DomainFiles,
 		}
 		conf.fillConfigMapForPod(cm)
 	}
+	var buffer bytes.Buffer
+	if err := keystoneReadinessScript.Execute(&buffer, c); err != nil {
+		panic(err)
+	}
+	cm.Data["readiness.sh"] = buffer.String()
 }
 
 func (c *keystonePodConfig) fillConfigMapForPod(cm *core.ConfigMap) {
@@ -73,6 +85,14 @@ var keystoneKollaServiceConfig = template.Must(template.New("").Parse(`{
             "perm": "0600",
             "optional": true
         },
+        {{- range .DomainFiles }}
+        {
+            "source": "/var/lib/kolla/domains/{{ . }}",
+            "dest": "/etc/keystone/domains/{{ . }}",
+            "owner": "keystone",
+            "perm": "0600"
+        },
+        {{- end }}
         {
             "source": "/var/lib/kolla/config_files/wsgi-keystone{{ .ListenAddress }}.conf",
             "dest": "/etc/httpd/conf.d/wsgi-keystone.conf",
@@ -106,7 +126,11 @@ enable_proxy_headers_parsing = True
 connection = postgresql://keystone:contrail123@{{ .PostgreSQLServer }}/keystone
 max_retries = -1
 
-[token]
+{{ if .DomainFiles }}[identity]
+domain_specific_drivers_enabled = True
+domain_config_dir = /etc/keystone/domains
+
+{{ end }}[token]
 revoke_by_id = False
 provider = fernet
 expiration = 86400
@@ -156,3 +180,29 @@ TraceEnable off
     CustomLog "|/usr/sbin/rotatelogs /var/log/kolla/keystone/keystone-apache-public-access.log 604800" logformat
 </VirtualHost>
 `))
+
+// keystoneReadinessScript issues a token with the admin credentials on the local replica and validates it.
+var keystoneReadinessScript = template.Must(template.New("").Parse(`#!/bin/bash
+url=https://${MY_POD_IP}:{{ .ListenPort }}/v3/auth/tokens
+# json_escape escapes quotes, backslashes and control characters of a JSON string.
+json_escape() {
+    local s=$1 c i escaped=
+    for ((i = 0; i < ${#s}; i++)); do
+        c=${s:i:1}
+        case "${c}" in
+        '"' | '\') escaped+="\\${c}" ;;
+        [[:cntrl:]]) escaped+=$(printf '\\u%04x' "'${c}") ;;
+        *) escaped+=${c} ;;
+        esac
+    done
+    printf '%s' "${escaped}"
+}
+password=$(json_escape "${KEYSTONE_ADMIN_PASSWORD}")
+body='{"auth": {"identity": {"methods": ["password"], "password": {"user": {"name": "{{ .AdminUsername }}", "domain": {"id": "{{ .UserDomainID }}"}, "password": "'"${password}"'"}}}, "scope": {"project": {"name": "{{ .AdminProject }}", "domain": {"id": "{{ .ProjectDomainID }}"}}}}}'
+token=$(curl -skf --max-time 5 -o /dev/null -D - -H "Content-Type: application/json" -d "${body}" ${url} | awk 'tolower($1) == "x-subject-token:" {print $2}' | tr -d '\r')
+if [ -z "${token}" ]; then
+    echo "failed to issue token"
+    exit 1
+fi
+curl -skf --max-time 5 -o /dev/null -H "X-Auth-Token: ${token}" -H "X-Subject-Token: ${token}" ${url}
+`))
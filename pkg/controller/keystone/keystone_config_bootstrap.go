@@ -17,6 +17,8 @@ type keystoneBootstrapConf struct {
 	MemcacheServer   string
 	AdminPassword    string
 	Region           string
+	// DomainFiles are left empty, as jobs do not read identity domains.
+	DomainFiles []string
 }
 
 func (c *keystoneBootstrapConf) FillConfigMap(cm *core.ConfigMap) {
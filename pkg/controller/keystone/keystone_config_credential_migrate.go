@@ -0,0 +1,47 @@
+package keystone
+
+import (
+	"bytes"
+	"text/template"
+
+	core "k8s.io/api/core/v1"
+)
+
+type keystoneCredentialMigrateConf struct {
+	RabbitMQServer   string
+	PostgreSQLServer string
+	MemcacheServer   string
+	// DomainFiles are left empty, as jobs do not read identity domains.
+	DomainFiles []string
+}
+
+func (c *keystoneCredentialMigrateConf) FillConfigMap(cm *core.ConfigMap) {
+	cm.Data["config.json"] = keystoneCredentialMigrateKollaServiceConfig
+	cm.Data["keystone.conf"] = c.executeTemplate(keystoneConf)
+}
+
+func (c *keystoneCredentialMigrateConf) executeTemplate(t *template.Template) string {
+	var buffer bytes.Buffer
+	if err := t.Execute(&buffer, c); err != nil {
+		panic(err)
+	}
+	return buffer.String()
+}
+
+const keystoneCredentialMigrateKollaServiceConfig = `{
+    "command": "keystone-manage credential_migrate",
+    "config_files": [
+        {
+            "source": "/var/lib/kolla/config_files/keystone.conf",
+            "dest": "/etc/keystone/keystone.conf",
+            "owner": "keystone",
+            "perm": "0600"
+        }
+    ],
+    "permissions": [
+        {
+            "path": "/var/log/kolla",
+            "owner": "keystone:kolla"
+        }
+    ]
+}`
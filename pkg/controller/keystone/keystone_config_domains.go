@@ -0,0 +1,150 @@
+package keystone
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"text/template"
+
+	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	contrail "github.com/Juniper/contrail-operator/pkg/apis/contrail/v1alpha1"
+	"github.com/Juniper/contrail-operator/pkg/client/keystone"
+)
+
+// domainsDir is the directory to which kolla copies domain specific configuration.
+const domainsDir = "/etc/keystone/domains"
+
+type keystoneDomainsConfig struct {
+	Domains []keystoneLDAPDomainConfig
+}
+
+type keystoneLDAPDomainConfig struct {
+	contrail.KeystoneLDAPDomain
+	BindDN       string
+	BindPassword string
+	CABundle     []byte
+}
+
+// FillSecret renders configuration of LDAP domains. It holds bind passwords,
+// so it is kept in a Secret instead of the keystone ConfigMap.
+func (c *keystoneDomainsConfig) FillSecret(sc *core.Secret) error {
+	sc.Data = map[string][]byte{}
+	for _, d := range c.Domains {
+		var buffer bytes.Buffer
+		if err := keystoneLDAPDomainConf.Execute(&buffer, d); err != nil {
+			return err
+		}
+		sc.Data[domainConfigFile(d.Name)] = buffer.Bytes()
+		if d.CABundleConfigMapName != "" {
+			sc.Data[domainCAFile(d.Name)] = d.CABundle
+		}
+	}
+	return nil
+}
+
+// domainFiles lists files of the domains secret, which are copied to the domains directory.
+func domainFiles(domains []contrail.KeystoneLDAPDomain) []string {
+	var files []string
+	for _, d := range domains {
+		files = append(files, domainConfigFile(d.Name))
+		if d.CABundleConfigMapName != "" {
+			files = append(files, domainCAFile(d.Name))
+		}
+	}
+	return files
+}
+
+func domainConfigFile(domain string) string {
+	return "keystone." + domain + ".conf"
+}
+
+func domainCAFile(domain string) string {
+	return "ca-" + domain + ".crt"
+}
+
+// ensureLDAPDomainsSecretExists renders configuration of LDAP domains into the secret
+// mounted by keystone pods. It returns an empty name if no LDAP domain is configured.
+func (r *ReconcileKeystone) ensureLDAPDomainsSecretExists(k *contrail.Keystone) (string, error) {
+	ldapDomains := k.Spec.ServiceConfiguration.LDAPDomains
+	if len(ldapDomains) == 0 {
+		return "", nil
+	}
+	config := &keystoneDomainsConfig{}
+	for _, d := range ldapDomains {
+		bindSecret := &core.Secret{}
+		if err := r.client.Get(context.TODO(), types.NamespacedName{Name: d.BindSecretName, Namespace: k.Namespace}, bindSecret); err != nil {
+			return "", fmt.Errorf("failed to get bind secret of domain %s: %v", d.Name, err)
+		}
+		domainConfig := keystoneLDAPDomainConfig{
+			KeystoneLDAPDomain: d,
+			BindDN:             string(bindSecret.Data["user"]),
+			BindPassword:       string(bindSecret.Data["password"]),
+		}
+		if d.CABundleConfigMapName != "" {
+			caBundle, err := keystone.ExternalCABundle(r.client, d.CABundleConfigMapName, k.Namespace)
+			if err != nil {
+				return "", err
+			}
+			domainConfig.CABundle = caBundle
+		}
+		config.Domains = append(config.Domains, domainConfig)
+	}
+	name := k.Name + "-keystone-domains"
+	return name, r.kubernetes.Secret(name, "keystone", k).EnsureExists(config)
+}
+
+var keystoneLDAPDomainConf = template.Must(template.New("").Funcs(template.FuncMap{
+	"caFile": func(domain string) string { return domainsDir + "/" + domainCAFile(domain) },
+}).Parse(`[identity]
+driver = ldap
+
+[ldap]
+url = {{ .URL }}
+user = {{ .BindDN }}
+password = {{ .BindPassword }}
+query_scope = sub
+page_size = 100
+{{- if .UseStartTLS }}
+use_tls = True
+{{- end }}
+{{- if .CABundleConfigMapName }}
+tls_cacertfile = {{ caFile .Name }}
+tls_req_cert = demand
+{{- end }}
+user_tree_dn = {{ .Users.TreeDN }}
+{{- with .Users.Filter }}
+user_filter = {{ . }}
+{{- end }}
+{{- with .Users.ObjectClass }}
+user_objectclass = {{ . }}
+{{- end }}
+{{- with .Users.IDAttribute }}
+user_id_attribute = {{ . }}
+{{- end }}
+{{- with .Users.NameAttribute }}
+user_name_attribute = {{ . }}
+{{- end }}
+{{- with .Users.MailAttribute }}
+user_mail_attribute = {{ . }}
+{{- end }}
+{{- with .Groups }}
+group_tree_dn = {{ .TreeDN }}
+{{- with .Filter }}
+group_filter = {{ . }}
+{{- end }}
+{{- with .ObjectClass }}
+group_objectclass = {{ . }}
+{{- end }}
+{{- with .IDAttribute }}
+group_id_attribute = {{ . }}
+{{- end }}
+{{- with .NameAttribute }}
+group_name_attribute = {{ . }}
+{{- end }}
+{{- with .MemberAttribute }}
+group_member_attribute = {{ . }}
+{{- end }}
+{{- end }}
+`))
@@ -10,6 +10,7 @@ import (
 type configMaps struct {
 	cm           *k8s.ConfigMap
 	keystoneSpec contrail.KeystoneSpec
+	parameters   contrail.KeystoneConfiguration
 	secret       *core.Secret
 }
 
@@ -17,6 +18,7 @@ func (r *ReconcileKeystone) configMap(configMapName, ownerType string, keystone
 	return &configMaps{
 		cm:           r.kubernetes.ConfigMap(configMapName, ownerType, keystone),
 		keystoneSpec: keystone.Spec,
+		parameters:   keystone.ConfigurationParameters(),
 		secret:       secret,
 	}
 }
@@ -28,6 +30,11 @@ func (c *configMaps) ensureKeystoneExists(postgresNode, memcachedNode string, po
 		RabbitMQServer:   "localhost:5672",
 		PostgreSQLServer: postgresNode,
 		MemcacheServer:   memcachedNode,
+		DomainFiles:      domainFiles(c.keystoneSpec.ServiceConfiguration.LDAPDomains),
+		AdminUsername:    c.parameters.AdminUsername,
+		AdminProject:     c.parameters.AdminProject,
+		UserDomainID:     c.parameters.UserDomainID,
+		ProjectDomainID:  c.parameters.ProjectDomainID,
 	}
 	return c.cm.EnsureExists(cc)
 }
@@ -53,3 +60,12 @@ func (c *configMaps) ensureKeystoneInitExist(postgresNode, memcachedNode string,
 
 	return c.cm.EnsureExists(cc)
 }
+
+func (c *configMaps) ensureCredentialMigrateExists(postgresNode, memcachedNode string) error {
+	cc := &keystoneCredentialMigrateConf{
+		RabbitMQServer:   "localhost:5672",
+		PostgreSQLServer: postgresNode,
+		MemcacheServer:   memcachedNode,
+	}
+	return c.cm.EnsureExists(cc)
+}
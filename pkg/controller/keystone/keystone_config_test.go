@@ -1,5 +1,65 @@
 package keystone_test
 
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeystoneReadinessScriptEscapesPassword(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash is not available")
+	}
+	dir, err := ioutil.TempDir("", "keystone-readiness")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	// curl stores the request body and responds with a token.
+	fakeCurl := `#!/bin/bash
+while [ $# -gt 0 ]; do
+    if [ "$1" = "-d" ]; then
+        printf '%s' "$2" > "${BODY_FILE}"
+    fi
+    shift
+done
+printf 'X-Subject-Token: token\r\n'
+`
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "curl"), []byte(fakeCurl), 0755))
+	password := "p\"a\\ss\tw\nord\x01"
+	cmd := exec.Command("bash", "-c", expectedKeystoneReadinessScript)
+	cmd.Env = append(os.Environ(),
+		"PATH="+dir+string(os.PathListSeparator)+os.Getenv("PATH"),
+		"MY_POD_IP=1.1.1.1",
+		"KEYSTONE_ADMIN_PASSWORD="+password,
+		"BODY_FILE="+filepath.Join(dir, "body"),
+	)
+	output, err := cmd.CombinedOutput()
+	assert.NoError(t, err, string(output))
+	body, err := ioutil.ReadFile(filepath.Join(dir, "body"))
+	if !assert.NoError(t, err) {
+		return
+	}
+	var request struct {
+		Auth struct {
+			Identity struct {
+				Password struct {
+					User struct {
+						Password string `json:"password"`
+					} `json:"user"`
+				} `json:"password"`
+			} `json:"identity"`
+		} `json:"auth"`
+	}
+	assert.NoError(t, json.Unmarshal(body, &request), string(body))
+	assert.Equal(t, password, request.Auth.Identity.Password.User.Password)
+}
+
 const expectedKeystoneKollaServiceConfig = `{
     "command": "/usr/sbin/httpd",
     "config_files": [
@@ -157,3 +217,46 @@ keystone-manage bootstrap --bootstrap-password test123 \
   --bootstrap-internal-url https://10.10.10.10:5555/v3/ \
   --bootstrap-public-url https://192.168.0.1:30020/v3/
 `
+
+const expectedLDAPDomainConfig = `[identity]
+driver = ldap
+
+[ldap]
+url = ldaps://ad.example.com:636
+user = CN=keystone,OU=Services,DC=example,DC=com
+password = bindpass
+query_scope = sub
+page_size = 100
+tls_cacertfile = /etc/keystone/domains/ca-corp.crt
+tls_req_cert = demand
+user_tree_dn = OU=Users,DC=example,DC=com
+user_objectclass = person
+user_name_attribute = sAMAccountName
+group_tree_dn = OU=Groups,DC=example,DC=com
+group_objectclass = group
+`
+
+const expectedKeystoneReadinessScript = `#!/bin/bash
+url=https://${MY_POD_IP}:5555/v3/auth/tokens
+# json_escape escapes quotes, backslashes and control characters of a JSON string.
+json_escape() {
+    local s=$1 c i escaped=
+    for ((i = 0; i < ${#s}; i++)); do
+        c=${s:i:1}
+        case "${c}" in
+        '"' | '\') escaped+="\\${c}" ;;
+        [[:cntrl:]]) escaped+=$(printf '\\u%04x' "'${c}") ;;
+        *) escaped+=${c} ;;
+        esac
+    done
+    printf '%s' "${escaped}"
+}
+password=$(json_escape "${KEYSTONE_ADMIN_PASSWORD}")
+body='{"auth": {"identity": {"methods": ["password"], "password": {"user": {"name": "admin", "domain": {"id": "default"}, "password": "'"${password}"'"}}}, "scope": {"project": {"name": "admin", "domain": {"id": "default"}}}}}'
+token=$(curl -skf --max-time 5 -o /dev/null -D - -H "Content-Type: application/json" -d "${body}" ${url} | awk 'tolower($1) == "x-subject-token:" {print $2}' | tr -d '\r')
+if [ -z "${token}" ]; then
+    echo "failed to issue token"
+    exit 1
+fi
+curl -skf --max-time 5 -o /dev/null -H "X-Auth-Token: ${token}" -H "X-Subject-Token: ${token}" ${url}
+`
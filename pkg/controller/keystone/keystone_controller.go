@@ -3,6 +3,8 @@ package keystone
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	apps "k8s.io/api/apps/v1"
@@ -14,8 +16,8 @@ import (
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
-	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
@@ -42,9 +44,13 @@ func Add(mgr manager.Manager) error {
 
 // newReconciler returns a new reconcile.Reconciler
 func newReconciler(mgr manager.Manager) reconcile.Reconciler {
-	return NewReconciler(
-		mgr.GetClient(), mgr.GetScheme(), k8s.New(mgr.GetClient(), mgr.GetScheme()), mgr.GetConfig(),
+	recorder := mgr.GetEventRecorderFor("keystone-controller")
+	client := utils.NewEventRecordingClient(mgr.GetClient(), recorder)
+	r := NewReconciler(
+		client, mgr.GetScheme(), k8s.New(client, mgr.GetScheme()), mgr.GetConfig(),
 	)
+	r.recorder = recorder
+	return r
 }
 
 // add adds a new Controller to mgr with r as the reconcile.Reconciler
@@ -109,6 +115,7 @@ type ReconcileKeystone struct {
 	scheme     *runtime.Scheme
 	kubernetes *k8s.Kubernetes
 	restConfig *rest.Config
+	recorder   record.EventRecorder
 }
 
 // NewReconciler is used to create a new ReconcileKeystone
@@ -152,7 +159,7 @@ func (r *ReconcileKeystone) Reconcile(request reconcile.Request) (reconcile.Resu
 
 	fernetKeyManagerName := keystone.Name + "-fernet-key-manager"
 
-	if err := r.ensureFernetKeyManagerExists(fernetKeyManagerName, keystone.Namespace); err != nil {
+	if err := r.ensureFernetKeyManagerExists(fernetKeyManagerName, keystone); err != nil {
 		return reconcile.Result{}, err
 	}
 
@@ -183,19 +190,25 @@ func (r *ReconcileKeystone) Reconcile(request reconcile.Request) (reconcile.Resu
 		return reconcile.Result{}, err
 	}
 	if !psql.Status.Active {
-		return reconcile.Result{}, nil
+		return reconcile.Result{}, contrail.WaitForDependency(r.client, r.recorder, keystone, &keystone.Status.Conditions, "Postgres", psql.Name)
 	}
 
-	memcached, err := r.getMemcached(keystone)
-	if err != nil {
-		return reconcile.Result{}, err
-	}
-	if err = r.kubernetes.Owner(keystone).EnsureOwns(memcached); err != nil {
-		return reconcile.Result{}, err
-	}
-	if !memcached.Status.Active {
-		return reconcile.Result{}, nil
+	var memcachedServers []string
+	for _, memcachedName := range keystone.MemcachedInstanceNames() {
+		memcached, err := r.getMemcached(keystone.Namespace, memcachedName)
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+		if err = r.kubernetes.Owner(keystone).EnsureOwns(memcached); err != nil {
+			return reconcile.Result{}, err
+		}
+		if !memcached.Status.Active {
+			return reconcile.Result{}, contrail.WaitForDependency(r.client, r.recorder, keystone, &keystone.Status.Conditions, "Memcached", memcached.Name)
+		}
+		memcachedServers = append(memcachedServers, memcached.Status.Endpoint)
 	}
+	// All replicas share the memcached pool, so tokens cached by one replica are valid in others.
+	memcachedNode := strings.Join(memcachedServers, ",")
 
 	adminPasswordSecretName := keystone.Spec.ServiceConfiguration.KeystoneSecretName
 	adminPasswordSecret := &core.Secret{}
@@ -209,13 +222,18 @@ func (r *ReconcileKeystone) Reconcile(request reconcile.Request) (reconcile.Resu
 	}
 
 	kcName := keystone.Name + "-keystone"
-	if err = r.configMap(kcName, "keystone", keystone, adminPasswordSecret).ensureKeystoneExists(psql.Status.Endpoint, memcached.Status.Endpoint, podIPs); err != nil {
+	if err = r.configMap(kcName, "keystone", keystone, adminPasswordSecret).ensureKeystoneExists(psql.Status.Endpoint, memcachedNode, podIPs); err != nil {
 		return reconcile.Result{}, err
 	}
 
 	kcbName := keystone.Name + "-keystone-bootstrap"
 	if err = r.configMap(kcbName, "keystone", keystone, adminPasswordSecret).ensureKeystoneInitExist(
-		psql.Status.Endpoint, memcached.Status.Endpoint, svc.ClusterIP(), svc.NodePort("api")); err != nil {
+		psql.Status.Endpoint, memcachedNode, svc.ClusterIP(), svc.NodePort("api")); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	if err = r.configMap(keystone.Name+"-keystone-credential-migrate", "keystone", keystone, adminPasswordSecret).ensureCredentialMigrateExists(
+		psql.Status.Endpoint, memcachedNode); err != nil {
 		return reconcile.Result{}, err
 	}
 
@@ -223,6 +241,10 @@ func (r *ReconcileKeystone) Reconcile(request reconcile.Request) (reconcile.Resu
 	if err = r.secret(credentialKeysSecretName, "keystone", keystone).ensureCredentialKeysSecretExists(); err != nil {
 		return reconcile.Result{}, err
 	}
+	domainsSecretName, err := r.ensureLDAPDomainsSecretExists(keystone)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
 	fernetKeyManager, err := r.getFernetKeyManager(fernetKeyManagerName, keystone.Namespace)
 	if err != nil {
 		return reconcile.Result{}, err
@@ -240,24 +262,29 @@ func (r *ReconcileKeystone) Reconcile(request reconcile.Request) (reconcile.Resu
 		return reconcile.Result{}, err
 	}
 
-	sts, err := r.ensureStatefulSetExists(keystone, kcName, fernetKeysSecretName, credentialKeysSecretName)
+	sts, err := r.ensureStatefulSetExists(keystone, kcName, fernetKeysSecretName, credentialKeysSecretName, domainsSecretName)
 	if err != nil {
 		return reconcile.Result{}, err
 	}
 
-	strategy := "deleteFirst"
+	// Replicas share fernet keys and the memcached pool, so they may be replaced one at a time.
+	strategy := "rolling"
 	if err = contrail.UpdateSTS(sts, "keystone", request, r.client, strategy); err != nil {
 		return reconcile.Result{}, err
 	}
 
-	return reconcile.Result{}, r.updateStatus(keystone, sts, svc.ClusterIP())
+	if err = r.updateStatus(keystone, sts, keystonePods, svc.ClusterIP()); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	return r.reconcileIdentityRegistration(keystone, sts, svc, adminPasswordSecret)
 }
 
-func (r *ReconcileKeystone) ensureFernetKeyManagerExists(name, namespace string) error {
+func (r *ReconcileKeystone) ensureFernetKeyManagerExists(name string, keystone *contrail.Keystone) error {
 	keyManager := &contrail.FernetKeyManager{
 		ObjectMeta: meta.ObjectMeta{
 			Name:      name,
-			Namespace: namespace,
+			Namespace: keystone.Namespace,
 		},
 	}
 	_, err := controllerutil.CreateOrUpdate(context.TODO(), r.client, keyManager, func() error {
@@ -267,6 +294,14 @@ func (r *ReconcileKeystone) ensureFernetKeyManagerExists(name, namespace string)
 		keyManager.Spec.TokenAllowExpiredWindow = 172800
 		// Three days
 		keyManager.Spec.RotationInterval = 259200
+		keyManager.Spec.CredentialKeys = &contrail.CredentialKeysRotation{
+			SecretName: keystone.Name + "-credential-keys-repository",
+			// Thirty days
+			RotationInterval: 2592000,
+			Image:            getImage(keystone, "keystoneInit"),
+			Command:          getCommand(keystone, "keystoneInit"),
+			ConfigMapName:    keystone.Name + "-keystone-credential-migrate",
+		}
 		return nil
 	})
 	return err
@@ -280,11 +315,11 @@ func (r *ReconcileKeystone) getFernetKeyManager(name, namespace string) (*contra
 }
 
 func (r *ReconcileKeystone) ensureStatefulSetExists(keystone *contrail.Keystone,
-	kcName, fernetKeysSecretName, credentialKeysSecretName string,
+	kcName, fernetKeysSecretName, credentialKeysSecretName, domainsSecretName string,
 ) (*apps.StatefulSet, error) {
 	sts := newKeystoneSTS(keystone)
 	_, err := controllerutil.CreateOrUpdate(context.Background(), r.client, sts, func() error {
-		updateKeystoneSTS(keystone, sts, kcName, fernetKeysSecretName, credentialKeysSecretName)
+		updateKeystoneSTS(keystone, sts, kcName, fernetKeysSecretName, credentialKeysSecretName, domainsSecretName)
 		req := reconcile.Request{
 			NamespacedName: types.NamespacedName{Name: keystone.Name, Namespace: keystone.Namespace},
 		}
@@ -295,27 +330,51 @@ func (r *ReconcileKeystone) ensureStatefulSetExists(keystone *contrail.Keystone,
 
 func (r *ReconcileKeystone) updateStatus(
 	k *contrail.Keystone,
-	sts *apps.StatefulSet, cip string,
+	sts *apps.StatefulSet, pods *core.PodList, cip string,
 ) error {
-	k.Status = contrail.KeystoneStatus{}
+	previousPort := k.Status.Port
+	k.Status = contrail.KeystoneStatus{Conditions: k.Status.Conditions}
 	intendentReplicas := int32(1)
 	if sts.Spec.Replicas != nil {
 		intendentReplicas = *sts.Spec.Replicas
 	}
+	k.Status.Replicas = replicasStatus(pods)
+	k.Status.ReadyReplicas = sts.Status.ReadyReplicas
 	if sts.Status.ReadyReplicas == intendentReplicas {
 		k.Status.Active = true
 		k.Status.Port = k.Spec.ServiceConfiguration.ListenPort
+	} else if previousPort != 0 && sts.Status.ReadyReplicas > 0 {
+		// Keystone stays available while some of its replicas are being replaced.
+		k.Status.Port = previousPort
 	}
 	k.Status.Endpoint = cip
+	if err := contrail.SetWorkloadConditions(r.client, &k.Status.Conditions, k.Generation, sts.Namespace, sts.Spec.Selector, intendentReplicas, sts.Status.ReadyReplicas); err != nil {
+		return err
+	}
 	return r.client.Status().Update(context.Background(), k)
 }
 
+func replicasStatus(pods *core.PodList) []contrail.KeystoneReplicaStatus {
+	var replicas []contrail.KeystoneReplicaStatus
+	for _, pod := range pods.Items {
+		replica := contrail.KeystoneReplicaStatus{Name: pod.Name, IP: pod.Status.PodIP}
+		for _, c := range pod.Status.Conditions {
+			if c.Type == core.PodReady && c.Status == core.ConditionTrue {
+				replica.Ready = true
+			}
+		}
+		replicas = append(replicas, replica)
+	}
+	sort.Slice(replicas, func(i, j int) bool { return replicas[i].Name < replicas[j].Name })
+	return replicas
+}
+
 func (r *ReconcileKeystone) externalKeystoneReady(k *contrail.Keystone, adminPasswordSecret string) (bool, error) {
 	keystoneClient, err := keystone.NewClient(r.client, r.scheme, r.restConfig, k)
 	if err != nil {
 		return false, err
 	}
-	_, err = keystoneClient.PostAuthTokens("admin", adminPasswordSecret, "admin")
+	_, err = keystoneClient.PostAdminAuthTokens(adminPasswordSecret)
 
 	if err != nil {
 		return false, fmt.Errorf("failed to get keystone token: %v", err)
@@ -326,11 +385,12 @@ func (r *ReconcileKeystone) externalKeystoneReady(k *contrail.Keystone, adminPas
 func (r *ReconcileKeystone) updateStatusWithExternalKeystone(
 	k *contrail.Keystone,
 ) error {
-	k.Status = contrail.KeystoneStatus{}
+	k.Status = contrail.KeystoneStatus{Conditions: k.Status.Conditions}
 	k.Status.Active = true
 	k.Status.External = true
 	k.Status.Port = k.Spec.ServiceConfiguration.ListenPort
 	k.Status.Endpoint = k.Spec.ServiceConfiguration.ExternalAddress
+	contrail.SetActiveConditions(&k.Status.Conditions, k.Generation, true, contrail.ReasonExternalServiceReady, "external keystone at "+k.Status.Endpoint+" is ready")
 	return r.client.Status().Update(context.Background(), k)
 }
 
@@ -345,9 +405,9 @@ func (r *ReconcileKeystone) getPostgres(cr *contrail.Keystone) (*contrail.Postgr
 	return psql, err
 }
 
-func (r *ReconcileKeystone) getMemcached(cr *contrail.Keystone) (*contrail.Memcached, error) {
+func (r *ReconcileKeystone) getMemcached(namespace, memcachedName string) (*contrail.Memcached, error) {
 	memcached := &contrail.Memcached{}
-	name := types.NamespacedName{Namespace: cr.Namespace, Name: cr.Spec.ServiceConfiguration.MemcachedInstance}
+	name := types.NamespacedName{Namespace: namespace, Name: memcachedName}
 	err := r.client.Get(context.Background(), name, memcached)
 	return memcached, err
 }
@@ -394,7 +454,7 @@ func newKeystoneSTS(cr *contrail.Keystone) *apps.StatefulSet {
 							Name:            "keystone",
 							Image:           getImage(cr, "keystone"),
 							ImagePullPolicy: core.PullIfNotPresent,
-							Env:             newKollaEnvs("keystone"),
+							Env:             newKeystoneEnvs(cr),
 							Command:         getCommand(cr, "keystone"),
 							VolumeMounts: []core.VolumeMount{
 								{Name: "keystone-config-volume", MountPath: "/var/lib/kolla/config_files/"},
@@ -402,15 +462,16 @@ func newKeystoneSTS(cr *contrail.Keystone) *apps.StatefulSet {
 								{Name: "keystone-credential-keys", MountPath: "/etc/keystone/credential-keys"},
 								{Name: cr.Name + "-secret-certificates", MountPath: "/etc/certificates"},
 							},
+							// The replica is ready only when it issues and validates tokens, which
+							// requires access to the database, fernet keys and memcached.
 							ReadinessProbe: &core.Probe{
 								Handler: core.Handler{
-									HTTPGet: &core.HTTPGetAction{
-										Scheme: core.URISchemeHTTPS,
-										Path:   "/v3",
-										Port: intstr.IntOrString{
-											IntVal: int32(cr.Spec.ServiceConfiguration.ListenPort),
-										}},
+									Exec: &core.ExecAction{
+										Command: []string{"/bin/bash", "/var/lib/kolla/config_files/readiness.sh"},
+									},
 								},
+								TimeoutSeconds: 10,
+								PeriodSeconds:  10,
 							},
 							Resources: core.ResourceRequirements{
 								Requests: core.ResourceList{
@@ -429,6 +490,19 @@ func newKeystoneSTS(cr *contrail.Keystone) *apps.StatefulSet {
 	}
 }
 
+func newKeystoneEnvs(cr *contrail.Keystone) []core.EnvVar {
+	// The admin password is used by the readiness probe to request a token.
+	return append(newKollaEnvs("keystone"), core.EnvVar{
+		Name: "KEYSTONE_ADMIN_PASSWORD",
+		ValueFrom: &core.EnvVarSource{
+			SecretKeyRef: &core.SecretKeySelector{
+				LocalObjectReference: core.LocalObjectReference{Name: cr.Spec.ServiceConfiguration.KeystoneSecretName},
+				Key:                  "password",
+			},
+		},
+	})
+}
+
 func newKollaEnvs(kollaService string) []core.EnvVar {
 	return []core.EnvVar{
 		{
@@ -493,7 +567,7 @@ psql -h ${PSQL_ENDPOINT} -U $DB_USER -d postgres -c "GRANT ALL PRIVILEGES ON DAT
 
 func (r *ReconcileKeystone) ensureCertificatesExist(keystone *contrail.Keystone, pods *core.PodList, serviceIP string) error {
 	subjects := keystone.PodsCertSubjects(pods, serviceIP)
-	crt := certificates.NewCertificate(r.client, r.scheme, keystone, subjects, "keystone")
+	crt := certificates.NewCertificate(r.client, r.scheme, keystone, subjects, "keystone", utils.CertificateKeyConfig(keystone.Spec.CommonConfiguration.CertificateKey))
 	return crt.EnsureExistsAndIsSigned()
 }
 
@@ -642,7 +716,7 @@ func (r *ReconcileKeystone) reconcileBootstrapJob(keystone *contrail.Keystone, k
 	return r.client.Create(context.Background(), bootstrapJob)
 }
 
-func updateKeystoneSTS(keystone *contrail.Keystone, sts *apps.StatefulSet, kcName, fernetKeysSecretName, credentialKeysSecretName string) {
+func updateKeystoneSTS(keystone *contrail.Keystone, sts *apps.StatefulSet, kcName, fernetKeysSecretName, credentialKeysSecretName, domainsSecretName string) {
 	var labelsMountPermission int32 = 0644
 	newSTS := newKeystoneSTS(keystone)
 	sts.Spec.Template.Spec.Affinity = newSTS.Spec.Template.Spec.Affinity
@@ -701,4 +775,16 @@ func updateKeystoneSTS(keystone *contrail.Keystone, sts *apps.StatefulSet, kcNam
 			},
 		},
 	}
+	if domainsSecretName != "" {
+		sts.Spec.Template.Spec.Volumes = append(sts.Spec.Template.Spec.Volumes, core.Volume{
+			Name: "keystone-domains",
+			VolumeSource: core.VolumeSource{
+				Secret: &core.SecretVolumeSource{
+					SecretName: domainsSecretName,
+				},
+			},
+		})
+		container := &sts.Spec.Template.Spec.Containers[0]
+		container.VolumeMounts = append(container.VolumeMounts, core.VolumeMount{Name: "keystone-domains", MountPath: "/var/lib/kolla/domains"})
+	}
 }
@@ -2,12 +2,15 @@ package keystone_test
 
 import (
 	"context"
+	"io/ioutil"
 	"net"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	apps "k8s.io/api/apps/v1"
@@ -18,7 +21,6 @@ import (
 	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
-	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/client-go/rest"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
@@ -125,7 +127,7 @@ func TestKeystone(t *testing.T) {
 				newKeystoneService(),
 				newFernetSecret(),
 			},
-			expectedStatus: contrail.KeystoneStatus{Active: true, Port: 5555, Endpoint: "10.10.10.10"},
+			expectedStatus: contrail.KeystoneStatus{Active: true, Port: 5555, Endpoint: "10.10.10.10", ReadyReplicas: 1},
 			expectedSTS:    newExpectedSTSWithStatus(apps.StatefulSetStatus{ReadyReplicas: 1}),
 			expectedConfigs: []*core.ConfigMap{
 				newExpectedKeystoneConfigMap(),
@@ -167,8 +169,10 @@ func TestKeystone(t *testing.T) {
 				newKeystoneService(),
 				newFernetSecret(),
 			},
-			expectedStatus: contrail.KeystoneStatus{Active: true, Port: 5555, Endpoint: "10.10.10.10"},
-			expectedSTS:    newExpectedSTSWithStatus(apps.StatefulSetStatus{ReadyReplicas: 1}),
+			expectedStatus: contrail.KeystoneStatus{Active: true, Port: 5555, Endpoint: "10.10.10.10", ReadyReplicas: 1,
+				Replicas: []contrail.KeystoneReplicaStatus{{Name: "keystone-keystone-statefulset-0", IP: "1.1.1.1"}},
+			},
+			expectedSTS: newExpectedSTSWithStatus(apps.StatefulSetStatus{ReadyReplicas: 1}),
 			expectedConfigs: []*core.ConfigMap{
 				newExpectedFilledKeystoneConfigMap(),
 				newExpectedKeystoneInitConfigMap(),
@@ -280,7 +284,7 @@ func TestKeystone(t *testing.T) {
 			cl := fake.NewFakeClientWithScheme(scheme, tt.initObjs...)
 
 			r := keystone.NewReconciler(
-				cl, scheme, k8s.New(cl, scheme), &rest.Config{},
+				cl, scheme, k8s.New(cl, scheme), (&fakeKeystone{}).restConfig(),
 			)
 
 			req := reconcile.Request{
@@ -354,12 +358,372 @@ func TestKeystone(t *testing.T) {
 			k := &contrail.Keystone{}
 			err = cl.Get(context.Background(), req.NamespacedName, k)
 			assert.NoError(t, err)
+			conditions := k.Status.Conditions
+			k.Status.Conditions = nil
 			assert.Equal(t, tt.expectedStatus, k.Status)
+			assert.Equal(t, tt.expectedStatus.Active, contrail.IsConditionTrue(conditions, contrail.ConditionReady))
 		})
 	}
 
 }
 
+func TestKeystoneRegistersIdentityEndpoints(t *testing.T) {
+	scheme, err := contrail.SchemeBuilder.Build()
+	assert.NoError(t, err)
+	assert.NoError(t, core.SchemeBuilder.AddToScheme(scheme))
+	assert.NoError(t, apps.SchemeBuilder.AddToScheme(scheme))
+	assert.NoError(t, batch.SchemeBuilder.AddToScheme(scheme))
+	cl := fake.NewFakeClientWithScheme(scheme,
+		newKeystone(),
+		&contrail.Postgres{
+			ObjectMeta: meta.ObjectMeta{Namespace: "default", Name: "psql"},
+			Status:     contrail.PostgresStatus{Status: contrail.Status{Active: true}, Endpoint: "10.10.10.20:5432"},
+		},
+		newExpectedSTSWithStatus(apps.StatefulSetStatus{ReadyReplicas: 1}),
+		&contrail.FernetKeyManager{
+			ObjectMeta: meta.ObjectMeta{Namespace: "default", Name: "keystone-fernet-key-manager"},
+			Status:     contrail.FernetKeyManagerStatus{SecretName: "fernet-keys-repository"},
+		},
+		newMemcached(),
+		newAdminSecret(),
+		newKeystoneService(),
+		newFernetSecret(),
+	)
+	ks := &fakeKeystone{}
+	r := keystone.NewReconciler(cl, scheme, k8s.New(cl, scheme), ks.restConfig())
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: "keystone", Namespace: "default"}}
+
+	t.Run("should become active and retry when registration fails", func(t *testing.T) {
+		ks.unauthorized = true
+		res, err := r.Reconcile(req)
+		assert.NoError(t, err)
+		assert.Equal(t, 30*time.Second, res.RequeueAfter)
+		k := &contrail.Keystone{}
+		assert.NoError(t, cl.Get(context.Background(), req.NamespacedName, k))
+		assert.True(t, k.Status.Active)
+		registered := contrail.FindCondition(k.Status.Conditions, contrail.KeystoneConditionIdentityRegistered)
+		if assert.NotNil(t, registered) {
+			assert.Equal(t, contrail.ConditionFalse, registered.Status)
+			assert.Equal(t, "RegistrationFailed", registered.Reason)
+		}
+		ks.unauthorized = false
+		ks.requests = nil
+		ks.bodies = nil
+	})
+
+	t.Run("should register identity service and endpoints", func(t *testing.T) {
+		_, err := r.Reconcile(req)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{
+			"POST /v3/auth/tokens",
+			"GET /v3/services?type=identity",
+			"POST /v3/services",
+			"GET /v3/endpoints?interface=admin&service_id=service-id",
+			"POST /v3/endpoints",
+			"GET /v3/endpoints?interface=internal&service_id=service-id",
+			"POST /v3/endpoints",
+			"GET /v3/endpoints?interface=public&service_id=service-id",
+			"POST /v3/endpoints",
+		}, ks.requests)
+		for _, i := range []int{4, 6, 8} {
+			assert.Contains(t, ks.bodies[i], `"url":"https://10.10.10.10:5555/v3/"`)
+			assert.Contains(t, ks.bodies[i], `"region_id":"RegionOne"`)
+		}
+		k := &contrail.Keystone{}
+		assert.NoError(t, cl.Get(context.Background(), req.NamespacedName, k))
+		assert.True(t, contrail.IsConditionTrue(k.Status.Conditions, contrail.KeystoneConditionIdentityRegistered))
+	})
+
+	t.Run("should not register again when catalog is up to date", func(t *testing.T) {
+		ks.requests = nil
+		ks.catalog = `[{"name": "keystone", "endpoints": [
+			{"interface": "admin", "url": "https://10.10.10.10:5555/v3/"},
+			{"interface": "internal", "url": "https://10.10.10.10:5555/v3/"},
+			{"interface": "public", "url": "https://10.10.10.10:5555/v3/"}
+		]}]`
+		_, err := r.Reconcile(req)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"POST /v3/auth/tokens"}, ks.requests)
+	})
+}
+
+func TestKeystoneLDAPDomains(t *testing.T) {
+	scheme, err := contrail.SchemeBuilder.Build()
+	assert.NoError(t, err)
+	assert.NoError(t, core.SchemeBuilder.AddToScheme(scheme))
+	assert.NoError(t, apps.SchemeBuilder.AddToScheme(scheme))
+	assert.NoError(t, batch.SchemeBuilder.AddToScheme(scheme))
+	k := newKeystone()
+	k.Spec.ServiceConfiguration.LDAPDomains = []contrail.KeystoneLDAPDomain{{
+		Name:                  "corp",
+		URL:                   "ldaps://ad.example.com:636",
+		BindSecretName:        "corp-bind",
+		CABundleConfigMapName: "corp-ca",
+		Users: contrail.KeystoneLDAPUsers{
+			TreeDN:        "OU=Users,DC=example,DC=com",
+			ObjectClass:   "person",
+			NameAttribute: "sAMAccountName",
+		},
+		Groups: &contrail.KeystoneLDAPGroups{
+			TreeDN:      "OU=Groups,DC=example,DC=com",
+			ObjectClass: "group",
+		},
+		GroupRoleMappings: []contrail.KeystoneLDAPRoleMapping{{Group: "cloud-admins", Role: "admin", Project: "admin"}},
+	}}
+	cl := fake.NewFakeClientWithScheme(scheme,
+		k,
+		&core.Pod{
+			ObjectMeta: meta.ObjectMeta{Namespace: "default", Name: "keystone-keystone-statefulset-0", Labels: map[string]string{
+				"contrail_manager": "keystone",
+				"keystone":         "keystone",
+			}},
+			Status: core.PodStatus{PodIP: "1.1.1.1"},
+		},
+		&contrail.Postgres{
+			ObjectMeta: meta.ObjectMeta{Namespace: "default", Name: "psql"},
+			Status:     contrail.PostgresStatus{Status: contrail.Status{Active: true}, Endpoint: "10.10.10.20:5432"},
+		},
+		newExpectedSTSWithStatus(apps.StatefulSetStatus{ReadyReplicas: 1}),
+		&contrail.FernetKeyManager{
+			ObjectMeta: meta.ObjectMeta{Namespace: "default", Name: "keystone-fernet-key-manager"},
+			Status:     contrail.FernetKeyManagerStatus{SecretName: "fernet-keys-repository"},
+		},
+		&core.Secret{
+			ObjectMeta: meta.ObjectMeta{Namespace: "default", Name: "corp-bind"},
+			Data:       map[string][]byte{"user": []byte("CN=keystone,OU=Services,DC=example,DC=com"), "password": []byte("bindpass")},
+		},
+		&core.ConfigMap{
+			ObjectMeta: meta.ObjectMeta{Namespace: "default", Name: "corp-ca"},
+			Data:       map[string]string{"ca-bundle.crt": "corp-ca-cert"},
+		},
+		newMemcached(),
+		newCertSecret(),
+		newAdminSecret(),
+		newKeystoneService(),
+		newFernetSecret(),
+	)
+	ks := &fakeKeystone{catalog: `[{"name": "keystone", "endpoints": [
+		{"interface": "admin", "url": "https://10.10.10.10:5555/v3/"},
+		{"interface": "internal", "url": "https://10.10.10.10:5555/v3/"},
+		{"interface": "public", "url": "https://10.10.10.10:5555/v3/"}
+	]}]`}
+	r := keystone.NewReconciler(cl, scheme, k8s.New(cl, scheme), ks.restConfig())
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: "keystone", Namespace: "default"}}
+
+	_, err = r.Reconcile(req)
+
+	t.Run("should restart keystone pods after the domain is created", func(t *testing.T) {
+		assert.NoError(t, err)
+		assert.Equal(t, []string{
+			"POST /v3/auth/tokens",
+			"GET /v3/domains?name=corp",
+			"POST /v3/domains",
+		}, ks.requests)
+		sts := &apps.StatefulSet{}
+		assert.NoError(t, cl.Get(context.Background(), types.NamespacedName{Name: "keystone-keystone-statefulset", Namespace: "default"}, sts))
+		assert.Equal(t, "corp", sts.Spec.Template.Annotations["keystone.contrail.juniper.net/ldap-domains"])
+		k := &contrail.Keystone{}
+		assert.NoError(t, cl.Get(context.Background(), req.NamespacedName, k))
+		assert.True(t, k.Status.Active)
+		registered := contrail.FindCondition(k.Status.Conditions, contrail.KeystoneConditionIdentityRegistered)
+		if assert.NotNil(t, registered) {
+			assert.Equal(t, contrail.ConditionFalse, registered.Status)
+			assert.Equal(t, "restarting keystone pods to load configuration of domains corp", registered.Message)
+		}
+	})
+
+	t.Run("should report group which is not found in the domain", func(t *testing.T) {
+		ks.requests = nil
+		ks.responses = map[string]string{
+			"GET /v3/domains?name=corp": `{"domains": [{"id": "corp-id"}]}`,
+		}
+		_, err := r.Reconcile(req)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{
+			"POST /v3/auth/tokens",
+			"GET /v3/domains?name=corp",
+			"GET /v3/groups?domain_id=corp-id&name=cloud-admins",
+		}, ks.requests)
+		k := &contrail.Keystone{}
+		assert.NoError(t, cl.Get(context.Background(), req.NamespacedName, k))
+		registered := contrail.FindCondition(k.Status.Conditions, contrail.KeystoneConditionIdentityRegistered)
+		if assert.NotNil(t, registered) {
+			assert.Equal(t, contrail.ConditionFalse, registered.Status)
+			assert.Equal(t, "group cloud-admins not found in domain corp", registered.Message)
+		}
+	})
+
+	t.Run("should render domain configuration into secret", func(t *testing.T) {
+		secret := &core.Secret{}
+		assert.NoError(t, cl.Get(context.Background(), types.NamespacedName{Name: "keystone-keystone-domains", Namespace: "default"}, secret))
+		assert.Equal(t, map[string][]byte{
+			"keystone.corp.conf": []byte(expectedLDAPDomainConfig),
+			"ca-corp.crt":        []byte("corp-ca-cert"),
+		}, secret.Data)
+	})
+
+	t.Run("should copy domain configuration to keystone pods", func(t *testing.T) {
+		configMap := &core.ConfigMap{}
+		assert.NoError(t, cl.Get(context.Background(), types.NamespacedName{Name: "keystone-keystone", Namespace: "default"}, configMap))
+		assert.Contains(t, configMap.Data["config1.1.1.1.json"], `"source": "/var/lib/kolla/domains/keystone.corp.conf",
+            "dest": "/etc/keystone/domains/keystone.corp.conf",`)
+		assert.Contains(t, configMap.Data["config1.1.1.1.json"], `"source": "/var/lib/kolla/domains/ca-corp.crt",
+            "dest": "/etc/keystone/domains/ca-corp.crt",`)
+		assert.Contains(t, configMap.Data["keystone1.1.1.1.conf"], `[identity]
+domain_specific_drivers_enabled = True
+domain_config_dir = /etc/keystone/domains
+`)
+		sts := &apps.StatefulSet{}
+		assert.NoError(t, cl.Get(context.Background(), types.NamespacedName{Name: "keystone-keystone-statefulset", Namespace: "default"}, sts))
+		assert.Contains(t, sts.Spec.Template.Spec.Volumes, core.Volume{
+			Name:         "keystone-domains",
+			VolumeSource: core.VolumeSource{Secret: &core.SecretVolumeSource{SecretName: "keystone-keystone-domains"}},
+		})
+		assert.Contains(t, sts.Spec.Template.Spec.Containers[0].VolumeMounts, core.VolumeMount{Name: "keystone-domains", MountPath: "/var/lib/kolla/domains"})
+	})
+
+	t.Run("should grant role to the group", func(t *testing.T) {
+		ks.requests = nil
+		ks.responses = map[string]string{
+			"GET /v3/domains?name=corp":                          `{"domains": [{"id": "corp-id"}]}`,
+			"GET /v3/groups?domain_id=corp-id&name=cloud-admins": `{"groups": [{"id": "group-id"}]}`,
+			"GET /v3/projects?domain_id=default&name=admin":      `{"projects": [{"id": "admin-project-id"}]}`,
+			"GET /v3/roles?name=admin":                           `{"roles": [{"id": "admin-role-id"}]}`,
+		}
+		_, err := r.Reconcile(req)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{
+			"POST /v3/auth/tokens",
+			"GET /v3/domains?name=corp",
+			"GET /v3/groups?domain_id=corp-id&name=cloud-admins",
+			"GET /v3/projects?domain_id=default&name=admin",
+			"GET /v3/roles?name=admin",
+			"PUT /v3/projects/admin-project-id/groups/group-id/roles/admin-role-id",
+		}, ks.requests)
+		k := &contrail.Keystone{}
+		assert.NoError(t, cl.Get(context.Background(), req.NamespacedName, k))
+		assert.True(t, k.Status.Active)
+		assert.True(t, contrail.IsConditionTrue(k.Status.Conditions, contrail.KeystoneConditionIdentityRegistered))
+	})
+}
+
+func TestKeystoneWaitingForPostgres(t *testing.T) {
+	scheme, err := contrail.SchemeBuilder.Build()
+	assert.NoError(t, err)
+	assert.NoError(t, core.SchemeBuilder.AddToScheme(scheme))
+	assert.NoError(t, apps.SchemeBuilder.AddToScheme(scheme))
+	cl := fake.NewFakeClientWithScheme(scheme,
+		newKeystone(),
+		&contrail.Postgres{ObjectMeta: meta.ObjectMeta{Namespace: "default", Name: "psql"}},
+		newMemcached(),
+		newAdminSecret(),
+		newKeystoneService(),
+	)
+	r := keystone.NewReconciler(cl, scheme, k8s.New(cl, scheme), &rest.Config{})
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: "keystone", Namespace: "default"}}
+
+	_, err = r.Reconcile(req)
+	assert.NoError(t, err)
+
+	k := &contrail.Keystone{}
+	assert.NoError(t, cl.Get(context.Background(), req.NamespacedName, k))
+	dependencies := contrail.FindCondition(k.Status.Conditions, contrail.ConditionDependenciesReady)
+	if assert.NotNil(t, dependencies) {
+		assert.Equal(t, contrail.ConditionFalse, dependencies.Status)
+		assert.Equal(t, contrail.ReasonDependencyNotReady, dependencies.Reason)
+		assert.Equal(t, "waiting for Postgres psql", dependencies.Message)
+	}
+	assert.False(t, contrail.IsConditionTrue(k.Status.Conditions, contrail.ConditionReady))
+	assert.True(t, contrail.IsConditionTrue(k.Status.Conditions, contrail.ConditionProgressing))
+}
+
+func TestKeystoneHighAvailability(t *testing.T) {
+	scheme, err := contrail.SchemeBuilder.Build()
+	assert.NoError(t, err)
+	assert.NoError(t, core.SchemeBuilder.AddToScheme(scheme))
+	assert.NoError(t, apps.SchemeBuilder.AddToScheme(scheme))
+	assert.NoError(t, batch.SchemeBuilder.AddToScheme(scheme))
+	threeVal := int32(3)
+	k := newKeystone()
+	k.Spec.CommonConfiguration.Replicas = &threeVal
+	k.Spec.ServiceConfiguration.MemcachedInstances = []string{"memcached-instance", "memcached-instance-2"}
+	// Keystone was active before one of its replicas has been restarted.
+	k.Status = contrail.KeystoneStatus{Active: true, Port: 5555, Endpoint: "10.10.10.10", ReadyReplicas: 3}
+	sts := newExpectedSTSWithStatus(apps.StatefulSetStatus{ReadyReplicas: 2})
+	sts.Spec.Replicas = &threeVal
+	newPod := func(name, ip string, ready core.ConditionStatus) *core.Pod {
+		return &core.Pod{
+			ObjectMeta: meta.ObjectMeta{Namespace: "default", Name: name, Labels: map[string]string{
+				"contrail_manager": "keystone",
+				"keystone":         "keystone",
+			}},
+			Status: core.PodStatus{
+				PodIP:      ip,
+				Conditions: []core.PodCondition{{Type: core.PodReady, Status: ready}},
+			},
+		}
+	}
+	certSecret := newCertSecret()
+	for _, ip := range []string{"1.1.1.2", "1.1.1.3"} {
+		certSecret.Data["server-key-"+ip+".pem"] = []byte("key")
+		certSecret.Data["server-"+ip+".crt"] = []byte("cert")
+	}
+	cl := fake.NewFakeClientWithScheme(scheme,
+		k,
+		newPod("keystone-keystone-statefulset-1", "1.1.1.2", core.ConditionFalse),
+		newPod("keystone-keystone-statefulset-0", "1.1.1.1", core.ConditionTrue),
+		newPod("keystone-keystone-statefulset-2", "1.1.1.3", core.ConditionTrue),
+		&contrail.Postgres{
+			ObjectMeta: meta.ObjectMeta{Namespace: "default", Name: "psql"},
+			Status:     contrail.PostgresStatus{Status: contrail.Status{Active: true}, Endpoint: "10.10.10.20:5432"},
+		},
+		sts,
+		&contrail.FernetKeyManager{
+			ObjectMeta: meta.ObjectMeta{Namespace: "default", Name: "keystone-fernet-key-manager"},
+			Status:     contrail.FernetKeyManagerStatus{SecretName: "fernet-keys-repository"},
+		},
+		newMemcached(),
+		&contrail.Memcached{
+			ObjectMeta: meta.ObjectMeta{Name: "memcached-instance-2", Namespace: "default"},
+			Status:     contrail.MemcachedStatus{Status: contrail.Status{Active: true}, Endpoint: "10.10.10.30:11211"},
+		},
+		certSecret,
+		newAdminSecret(),
+		newKeystoneService(),
+		newFernetSecret(),
+	)
+	r := keystone.NewReconciler(cl, scheme, k8s.New(cl, scheme), (&fakeKeystone{}).restConfig())
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: "keystone", Namespace: "default"}}
+
+	_, err = r.Reconcile(req)
+	assert.NoError(t, err)
+
+	t.Run("should configure all replicas with the memcached pool", func(t *testing.T) {
+		cm := &core.ConfigMap{}
+		assert.NoError(t, cl.Get(context.Background(), types.NamespacedName{Name: "keystone-keystone", Namespace: "default"}, cm))
+		for _, ip := range []string{"1.1.1.1", "1.1.1.2", "1.1.1.3"} {
+			assert.Contains(t, cm.Data["keystone"+ip+".conf"], "memcache_servers = localhost:11211,10.10.10.30:11211")
+		}
+		memcached := &contrail.Memcached{}
+		assert.NoError(t, cl.Get(context.Background(), types.NamespacedName{Name: "memcached-instance-2", Namespace: "default"}, memcached))
+		assert.Len(t, memcached.OwnerReferences, 1)
+	})
+
+	t.Run("should report health of every replica", func(t *testing.T) {
+		k := &contrail.Keystone{}
+		assert.NoError(t, cl.Get(context.Background(), req.NamespacedName, k))
+		assert.False(t, k.Status.Active)
+		assert.Equal(t, 5555, k.Status.Port)
+		assert.Equal(t, int32(2), k.Status.ReadyReplicas)
+		assert.Equal(t, []contrail.KeystoneReplicaStatus{
+			{Name: "keystone-keystone-statefulset-0", IP: "1.1.1.1", Ready: true},
+			{Name: "keystone-keystone-statefulset-1", IP: "1.1.1.2", Ready: false},
+			{Name: "keystone-keystone-statefulset-2", IP: "1.1.1.3", Ready: true},
+		}, k.Status.Replicas)
+		assert.True(t, k.IsAvailable())
+	})
+}
+
 func TestExternalKeystone(t *testing.T) {
 	scheme, err := contrail.SchemeBuilder.Build()
 	assert.NoError(t, err)
@@ -450,6 +814,8 @@ func TestExternalKeystone(t *testing.T) {
 	err = cl.Get(context.Background(), req.NamespacedName, k)
 	assert.NoError(t, err)
 	expectedStatus := contrail.KeystoneStatus{Endpoint: host, Active: true, External: true, Port: port}
+	assert.True(t, contrail.IsConditionTrue(k.Status.Conditions, contrail.ConditionReady))
+	k.Status.Conditions = nil
 	assert.Equal(t, expectedStatus, k.Status)
 }
 
@@ -609,6 +975,14 @@ func newExpectedSTS() *apps.StatefulSet {
 								}, {
 									Name:  "KOLLA_CONFIG_FILE",
 									Value: "/var/lib/kolla/config_files/config$(MY_POD_IP).json",
+								}, {
+									Name: "KEYSTONE_ADMIN_PASSWORD",
+									ValueFrom: &core.EnvVarSource{
+										SecretKeyRef: &core.SecretKeySelector{
+											LocalObjectReference: core.LocalObjectReference{Name: "keystone-adminpass-secret"},
+											Key:                  "password",
+										},
+									},
 								},
 							},
 							VolumeMounts: []core.VolumeMount{
@@ -619,13 +993,12 @@ func newExpectedSTS() *apps.StatefulSet {
 							},
 							ReadinessProbe: &core.Probe{
 								Handler: core.Handler{
-									HTTPGet: &core.HTTPGetAction{
-										Scheme: core.URISchemeHTTPS,
-										Path:   "/v3",
-										Port: intstr.IntOrString{
-											IntVal: 5555,
-										}},
+									Exec: &core.ExecAction{
+										Command: []string{"/bin/bash", "/var/lib/kolla/config_files/readiness.sh"},
+									},
 								},
+								TimeoutSeconds: 10,
+								PeriodSeconds:  10,
 							},
 							Resources: core.ResourceRequirements{
 								Requests: core.ResourceList{
@@ -767,6 +1140,9 @@ func newKeystoneService() *core.Service {
 func newExpectedKeystoneConfigMap() *core.ConfigMap {
 	trueVal := true
 	return &core.ConfigMap{
+		Data: map[string]string{
+			"readiness.sh": expectedKeystoneReadinessScript,
+		},
 		TypeMeta: meta.TypeMeta{Kind: "ConfigMap", APIVersion: "v1"},
 		ObjectMeta: meta.ObjectMeta{
 			Name:      "keystone-keystone",
@@ -786,6 +1162,7 @@ func newExpectedFilledKeystoneConfigMap() *core.ConfigMap {
 			"config1.1.1.1.json":        expectedKeystoneKollaServiceConfig,
 			"keystone1.1.1.1.conf":      expectedKeystoneConfig,
 			"wsgi-keystone1.1.1.1.conf": expectedWSGIKeystoneConfig,
+			"readiness.sh":              expectedKeystoneReadinessScript,
 		},
 		TypeMeta: meta.TypeMeta{Kind: "ConfigMap", APIVersion: "v1"},
 		ObjectMeta: meta.ObjectMeta{
@@ -888,6 +1265,14 @@ func newExpectedSTSWithCustomImages() *apps.StatefulSet {
 				}, {
 					Name:  "KOLLA_CONFIG_FILE",
 					Value: "/var/lib/kolla/config_files/config$(MY_POD_IP).json",
+				}, {
+					Name: "KEYSTONE_ADMIN_PASSWORD",
+					ValueFrom: &core.EnvVarSource{
+						SecretKeyRef: &core.SecretKeySelector{
+							LocalObjectReference: core.LocalObjectReference{Name: "keystone-adminpass-secret"},
+							Key:                  "password",
+						},
+					},
 				},
 			},
 			VolumeMounts: []core.VolumeMount{
@@ -898,13 +1283,12 @@ func newExpectedSTSWithCustomImages() *apps.StatefulSet {
 			},
 			ReadinessProbe: &core.Probe{
 				Handler: core.Handler{
-					HTTPGet: &core.HTTPGetAction{
-						Scheme: core.URISchemeHTTPS,
-						Path:   "/v3",
-						Port: intstr.IntOrString{
-							IntVal: 5555,
-						}},
+					Exec: &core.ExecAction{
+						Command: []string{"/bin/bash", "/var/lib/kolla/config_files/readiness.sh"},
+					},
 				},
+				TimeoutSeconds: 10,
+				PeriodSeconds:  10,
 			},
 			Resources: core.ResourceRequirements{
 				Requests: core.ResourceList{
@@ -1041,3 +1425,62 @@ createdb -h ${PSQL_ENDPOINT} -U $DB_USER $KEYSTONE
 psql -h ${PSQL_ENDPOINT} -U $DB_USER -d postgres -c "GRANT ALL PRIVILEGES ON DATABASE $KEYSTONE TO $KEYSTONE"`
 
 const expectedCommandWaitForReadyContainer = "until grep ready /tmp/podinfo/pod_labels > /dev/null 2>&1; do sleep 1; done"
+
+type mockRoundTripFunc func(r *http.Request) (*http.Response, error)
+
+func (m mockRoundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return m(r)
+}
+
+// fakeKeystone is a Keystone reached through the kube proxy. Tokens carry the given catalog
+// and created resources are given IDs named after their kind.
+type fakeKeystone struct {
+	catalog      string
+	unauthorized bool
+	// responses are bodies of responses to GET requests. Other GET requests find nothing.
+	responses map[string]string
+	requests  []string
+	bodies    []string
+}
+
+func (f *fakeKeystone) restConfig() *rest.Config {
+	return &rest.Config{
+		Host:      "localhost",
+		APIPath:   "/",
+		Transport: mockRoundTripFunc(f.roundTrip),
+	}
+}
+
+func (f *fakeKeystone) roundTrip(r *http.Request) (*http.Response, error) {
+	path := r.URL.Path[strings.Index(r.URL.Path, "/proxy/")+len("/proxy"):]
+	request := r.Method + " " + path
+	if r.URL.RawQuery != "" {
+		request += "?" + r.URL.RawQuery
+	}
+	f.requests = append(f.requests, request)
+	var body []byte
+	if r.Body != nil {
+		body, _ = ioutil.ReadAll(r.Body)
+	}
+	f.bodies = append(f.bodies, string(body))
+	response := &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: ioutil.NopCloser(strings.NewReader("{}"))}
+	switch {
+	case f.unauthorized:
+		response.StatusCode = http.StatusUnauthorized
+	case path == "/v3/auth/tokens":
+		catalog := f.catalog
+		if catalog == "" {
+			catalog = "[]"
+		}
+		response.StatusCode = http.StatusCreated
+		response.Header.Set("X-Subject-Token", "admin-token")
+		response.Body = ioutil.NopCloser(strings.NewReader(`{"token": {"catalog": ` + catalog + `}}`))
+	case r.Method == http.MethodGet && f.responses[request] != "":
+		response.Body = ioutil.NopCloser(strings.NewReader(f.responses[request]))
+	case r.Method == http.MethodPost:
+		kind := strings.TrimSuffix(strings.TrimPrefix(path, "/v3/"), "s")
+		response.StatusCode = http.StatusCreated
+		response.Body = ioutil.NopCloser(strings.NewReader(`{"` + kind + `": {"id": "` + kind + `-id"}}`))
+	}
+	return response, nil
+}
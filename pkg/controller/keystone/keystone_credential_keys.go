@@ -16,7 +16,7 @@ type secret struct {
 
 // Fill secret sets up credential keys repository
 func (s *secret) FillSecret(sc *core.Secret) error {
-	if sc.Data != nil {
+	if len(sc.Data) != 0 {
 		return nil
 	}
 	var stagedKey, primaryKey []byte
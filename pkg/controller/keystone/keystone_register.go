@@ -0,0 +1,153 @@
+package keystone
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	apps "k8s.io/api/apps/v1"
+	core "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	contrail "github.com/Juniper/contrail-operator/pkg/apis/contrail/v1alpha1"
+	"github.com/Juniper/contrail-operator/pkg/client/keystone"
+	"github.com/Juniper/contrail-operator/pkg/k8s"
+)
+
+// registrationRetryPeriod is the time after which failed registration is retried.
+const registrationRetryPeriod = 30 * time.Second
+
+// ldapDomainsAnnotation lists LDAP domains which existed when keystone pods were started.
+// Keystone loads configuration of a domain only if the domain exists when it starts.
+const ldapDomainsAnnotation = "keystone.contrail.juniper.net/ldap-domains"
+
+// reconcileIdentityRegistration registers the identity service once keystone is active and
+// reports the result in the IdentityRegistered condition, so that failures of the Keystone
+// API do not keep keystone from becoming active.
+func (r *ReconcileKeystone) reconcileIdentityRegistration(k *contrail.Keystone, sts *apps.StatefulSet, svc *k8s.Service, adminSecret *core.Secret) (reconcile.Result, error) {
+	if !k.Status.Active {
+		return reconcile.Result{}, nil
+	}
+	condition := contrail.Condition{Type: contrail.KeystoneConditionIdentityRegistered, Status: contrail.ConditionTrue, ObservedGeneration: k.Generation, Reason: "Registered"}
+	result := reconcile.Result{}
+	if err := r.ensureIdentityRegistered(k, sts, svc, adminSecret); err != nil {
+		log.Error(err, "Failed to register identity service", "Keystone", k.Name)
+		condition.Status = contrail.ConditionFalse
+		condition.Reason = "RegistrationFailed"
+		condition.Message = err.Error()
+		result.RequeueAfter = registrationRetryPeriod
+	}
+	contrail.SetCondition(&k.Status.Conditions, condition)
+	return result, r.client.Status().Update(context.Background(), k)
+}
+
+// ensureIdentityRegistered reconciles the identity service endpoints and LDAP domains through
+// the Keystone API.
+func (r *ReconcileKeystone) ensureIdentityRegistered(k *contrail.Keystone, sts *apps.StatefulSet, svc *k8s.Service, adminSecret *core.Secret) error {
+	keystoneClient, err := keystone.NewClient(r.client, r.scheme, r.restConfig, k)
+	if err != nil {
+		return err
+	}
+	token, err := keystoneClient.PostAdminAuthTokens(string(adminSecret.Data["password"]))
+	if err != nil {
+		return fmt.Errorf("failed to get keystone token: %v", err)
+	}
+	endpoints := identityEndpoints(k, svc.ClusterIP(), svc.NodePort("api"))
+	registered := true
+	for iface, url := range endpoints {
+		if token.EndpointURL("keystone", iface) != url {
+			registered = false
+		}
+	}
+	if !registered {
+		err = keystoneClient.EnsureServiceRegistration(token.XAuthTokenHeader, keystone.ServiceRegistration{
+			Name:        "keystone",
+			Type:        "identity",
+			Description: "Keystone Identity Service",
+			Region:      keystoneClient.KeystoneConf.Region,
+			Endpoints:   endpoints,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return r.ensureLDAPDomainsRegistered(keystoneClient, token.XAuthTokenHeader, k.Spec.ServiceConfiguration.LDAPDomains, sts)
+}
+
+// ensureLDAPDomainsRegistered creates LDAP backed domains and grants roles to their groups.
+// Keystone pods are restarted after domains are created, so that they load configuration
+// of the domains. Missing roles and projects are created, while groups must exist in the
+// directory. Groups which are not found do not keep roles from being granted to others.
+func (r *ReconcileKeystone) ensureLDAPDomainsRegistered(keystoneClient *keystone.Client, token string, domains []contrail.KeystoneLDAPDomain, sts *apps.StatefulSet) error {
+	if len(domains) == 0 {
+		return nil
+	}
+	domainIDs := map[string]string{}
+	var names []string
+	for _, d := range domains {
+		domainID, err := keystoneClient.EnsureDomain(token, d.Name, "LDAP backed domain")
+		if err != nil {
+			return err
+		}
+		domainIDs[d.Name] = domainID
+		names = append(names, d.Name)
+	}
+	sort.Strings(names)
+	if loaded := strings.Join(names, ","); sts.Spec.Template.Annotations[ldapDomainsAnnotation] != loaded {
+		if sts.Spec.Template.Annotations == nil {
+			sts.Spec.Template.Annotations = map[string]string{}
+		}
+		sts.Spec.Template.Annotations[ldapDomainsAnnotation] = loaded
+		if err := r.client.Update(context.Background(), sts); err != nil {
+			return err
+		}
+		return fmt.Errorf("restarting keystone pods to load configuration of domains %s", loaded)
+	}
+	var notFound []string
+	for _, d := range domains {
+		for _, m := range d.GroupRoleMappings {
+			groups, err := keystoneClient.ListGroups(token, m.Group, domainIDs[d.Name])
+			if err != nil {
+				return err
+			}
+			if len(groups) == 0 {
+				notFound = append(notFound, fmt.Sprintf("group %s not found in domain %s", m.Group, d.Name))
+				continue
+			}
+			projectID, err := keystoneClient.EnsureProject(token, m.Project, keystoneClient.KeystoneConf.ProjectDomainID)
+			if err != nil {
+				return err
+			}
+			roleID, err := keystoneClient.EnsureRole(token, m.Role)
+			if err != nil {
+				return err
+			}
+			if err = keystoneClient.EnsureGroupRoleAssignment(token, projectID, groups[0].ID, roleID); err != nil {
+				return err
+			}
+		}
+	}
+	if len(notFound) > 0 {
+		return errors.New(strings.Join(notFound, "; "))
+	}
+	return nil
+}
+
+func identityEndpoints(k *contrail.Keystone, clusterIP string, nodePort int32) map[string]string {
+	listenPort := k.Spec.ServiceConfiguration.ListenPort
+	publicAddress := clusterIP
+	publicPort := int32(listenPort)
+	if k.Spec.ServiceConfiguration.PublicEndpoint != "" {
+		publicAddress = k.Spec.ServiceConfiguration.PublicEndpoint
+		publicPort = nodePort
+	}
+	publicURL := fmt.Sprintf("https://%v:%v/v3/", publicAddress, publicPort)
+	return map[string]string{
+		"admin":    publicURL,
+		"internal": fmt.Sprintf("https://%v:%v/v3/", clusterIP, listenPort),
+		"public":   publicURL,
+	}
+}
@@ -97,7 +97,8 @@ func Add(mgr manager.Manager, ci v1alpha1.KubemanagerClusterInfo) error {
 }
 
 func newReconciler(mgr manager.Manager, ci v1alpha1.KubemanagerClusterInfo) reconcile.Reconciler {
-	return NewReconciler(mgr.GetClient(), mgr.GetScheme(), mgr.GetConfig(), ci)
+	client := utils.NewEventRecordingClient(mgr.GetClient(), mgr.GetEventRecorderFor("kubemanager-controller"))
+	return NewReconciler(client, mgr.GetScheme(), mgr.GetConfig(), ci)
 }
 
 // NewReconciler returns a new reconcile.Reconciler.
@@ -544,6 +545,6 @@ func (r *ReconcileKubemanager) Reconcile(request reconcile.Request) (reconcile.R
 
 func (r *ReconcileKubemanager) ensureCertificatesExist(config *v1alpha1.Kubemanager, pods *corev1.PodList, instanceType string) error {
 	subjects := config.PodsCertSubjects(pods)
-	crt := certificates.NewCertificate(r.Client, r.Scheme, config, subjects, instanceType)
+	crt := certificates.NewCertificate(r.Client, r.Scheme, config, subjects, instanceType, utils.CertificateKeyConfig(config.Spec.CommonConfiguration.CertificateKey))
 	return crt.EnsureExistsAndIsSigned()
 }
@@ -0,0 +1,154 @@
+package manager
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/Juniper/contrail-operator/pkg/apis/contrail/v1alpha1"
+)
+
+// managedResource identifies a resource created by the Manager for one of its services.
+type managedResource struct {
+	kind string
+	name string
+}
+
+func managedResources(s v1alpha1.Services) []managedResource {
+	var resources []managedResource
+	for _, c := range s.Cassandras {
+		resources = append(resources, managedResource{"Cassandra", c.Name})
+	}
+	for _, z := range s.Zookeepers {
+		resources = append(resources, managedResource{"Zookeeper", z.Name})
+	}
+	if s.Rabbitmq != nil {
+		resources = append(resources, managedResource{"Rabbitmq", s.Rabbitmq.Name})
+	}
+	if s.Postgres != nil {
+		resources = append(resources, managedResource{"Postgres", s.Postgres.Name})
+	}
+	if s.Memcached != nil {
+		resources = append(resources, managedResource{"Memcached", s.Memcached.Name})
+	}
+	if s.Keystone != nil {
+		resources = append(resources, managedResource{"Keystone", s.Keystone.Name})
+	}
+	if s.Swift != nil {
+		resources = append(resources, managedResource{"Swift", s.Swift.Name})
+	}
+	if s.Config != nil {
+		resources = append(resources, managedResource{"Config", s.Config.Name})
+	}
+	for _, c := range s.Controls {
+		resources = append(resources, managedResource{"Control", c.Name})
+	}
+	if s.ProvisionManager != nil {
+		resources = append(resources, managedResource{"ProvisionManager", s.ProvisionManager.Name})
+	}
+	if s.Webui != nil {
+		resources = append(resources, managedResource{"Webui", s.Webui.Name})
+	}
+	if s.Command != nil {
+		resources = append(resources, managedResource{"Command", s.Command.Name})
+	}
+	for _, k := range s.Kubemanagers {
+		resources = append(resources, managedResource{"Kubemanager", k.Name})
+	}
+	for _, v := range s.Vrouters {
+		resources = append(resources, managedResource{"Vrouter", v.Name})
+	}
+	for _, c := range s.ContrailCNIs {
+		resources = append(resources, managedResource{"ContrailCNI", c.Name})
+	}
+	if s.Contrailmonitor != nil {
+		resources = append(resources, managedResource{"Contrailmonitor", s.Contrailmonitor.Name})
+	}
+	return resources
+}
+
+// service returns the resource or nil when the resource does not exist yet.
+func (r *ReconcileManager) service(namespace string, resource managedResource) (*unstructured.Unstructured, error) {
+	u := &unstructured.Unstructured{}
+	u.SetGroupVersionKind(v1alpha1.SchemeGroupVersion.WithKind(resource.kind))
+	if err := r.client.Get(context.TODO(), types.NamespacedName{Name: resource.name, Namespace: namespace}, u); err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return u, nil
+}
+
+// decodeNested decodes the field of the resource into out.
+// It returns false when the field is not set.
+func decodeNested(u *unstructured.Unstructured, out interface{}, fields ...string) (bool, error) {
+	raw, found, err := unstructured.NestedFieldNoCopy(u.Object, fields...)
+	if err != nil || !found {
+		return false, err
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return false, err
+	}
+	return true, json.Unmarshal(data, out)
+}
+
+// conditions returns conditions of the resource or nil when the resource does not exist yet.
+func (r *ReconcileManager) conditions(namespace string, resource managedResource) ([]v1alpha1.Condition, error) {
+	u, err := r.service(namespace, resource)
+	if err != nil || u == nil {
+		return nil, err
+	}
+	var conditions []v1alpha1.Condition
+	_, err = decodeNested(u, &conditions, "status", "conditions")
+	return conditions, err
+}
+
+// setConditions summarizes conditions of all services of the manager.
+func (r *ReconcileManager) setConditions(manager *v1alpha1.Manager) error {
+	var notReady, degraded, waiting []string
+	for _, resource := range managedResources(manager.Spec.Services) {
+		conditions, err := r.conditions(manager.Namespace, resource)
+		if err != nil {
+			return err
+		}
+		name := resource.kind + " " + resource.name
+		if !v1alpha1.IsConditionTrue(conditions, v1alpha1.ConditionReady) {
+			notReady = append(notReady, name)
+		}
+		if c := v1alpha1.FindCondition(conditions, v1alpha1.ConditionDegraded); c != nil && c.Status == v1alpha1.ConditionTrue {
+			degraded = append(degraded, fmt.Sprintf("%s: %s", name, c.Message))
+		}
+		if c := v1alpha1.FindCondition(conditions, v1alpha1.ConditionDependenciesReady); c != nil && c.Status == v1alpha1.ConditionFalse {
+			waiting = append(waiting, fmt.Sprintf("%s: %s", name, c.Message))
+		}
+	}
+
+	generation := manager.Generation
+	conditions := &manager.Status.Conditions
+	if manager.IsClusterReady() {
+		v1alpha1.SetCondition(conditions, v1alpha1.Condition{Type: v1alpha1.ConditionReady, Status: v1alpha1.ConditionTrue, ObservedGeneration: generation, Reason: v1alpha1.ReasonServicesReady})
+		v1alpha1.SetCondition(conditions, v1alpha1.Condition{Type: v1alpha1.ConditionProgressing, Status: v1alpha1.ConditionFalse, ObservedGeneration: generation, Reason: v1alpha1.ReasonServicesReady})
+	} else {
+		message := "services not ready: " + strings.Join(notReady, ", ")
+		v1alpha1.SetCondition(conditions, v1alpha1.Condition{Type: v1alpha1.ConditionReady, Status: v1alpha1.ConditionFalse, ObservedGeneration: generation, Reason: v1alpha1.ReasonServicesNotReady, Message: message})
+		v1alpha1.SetCondition(conditions, v1alpha1.Condition{Type: v1alpha1.ConditionProgressing, Status: v1alpha1.ConditionTrue, ObservedGeneration: generation, Reason: v1alpha1.ReasonServicesNotReady, Message: message})
+	}
+	if len(degraded) > 0 {
+		v1alpha1.SetCondition(conditions, v1alpha1.Condition{Type: v1alpha1.ConditionDegraded, Status: v1alpha1.ConditionTrue, ObservedGeneration: generation, Reason: v1alpha1.ReasonServicesDegraded, Message: strings.Join(degraded, "; ")})
+	} else {
+		v1alpha1.SetCondition(conditions, v1alpha1.Condition{Type: v1alpha1.ConditionDegraded, Status: v1alpha1.ConditionFalse, ObservedGeneration: generation, Reason: v1alpha1.ReasonAsExpected})
+	}
+	if len(waiting) > 0 {
+		v1alpha1.SetCondition(conditions, v1alpha1.Condition{Type: v1alpha1.ConditionDependenciesReady, Status: v1alpha1.ConditionFalse, ObservedGeneration: generation, Reason: v1alpha1.ReasonDependencyNotReady, Message: strings.Join(waiting, "; ")})
+	} else {
+		v1alpha1.SetCondition(conditions, v1alpha1.Condition{Type: v1alpha1.ConditionDependenciesReady, Status: v1alpha1.ConditionTrue, ObservedGeneration: generation, Reason: v1alpha1.ReasonDependenciesReady})
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package manager
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	contrail "github.com/Juniper/contrail-operator/pkg/apis/contrail/v1alpha1"
+	"github.com/Juniper/contrail-operator/pkg/k8s"
+)
+
+func TestManagerConditions(t *testing.T) {
+	scheme, err := contrail.SchemeBuilder.Build()
+	require.NoError(t, err)
+	falseVal := false
+	trueVal := true
+
+	manager := &contrail.Manager{
+		ObjectMeta: meta.ObjectMeta{Name: "cluster1", Namespace: "default", Generation: 2},
+		Spec: contrail.ManagerSpec{
+			Services: contrail.Services{
+				Cassandras: []*contrail.CassandraService{{ObjectMeta: contrail.ObjectMeta{Name: "cassandra1"}}},
+				Config:     &contrail.ConfigService{ObjectMeta: contrail.ObjectMeta{Name: "config1"}},
+			},
+		},
+		Status: contrail.ManagerStatus{
+			Cassandras: []*contrail.ServiceStatus{{Name: strPtr("cassandra1"), Active: &falseVal}},
+			Config:     &contrail.ServiceStatus{Name: strPtr("config1"), Active: &falseVal},
+		},
+	}
+	cassandra := &contrail.Cassandra{
+		ObjectMeta: meta.ObjectMeta{Name: "cassandra1", Namespace: "default"},
+		Status:     contrail.CassandraStatus{Active: &falseVal},
+	}
+	contrail.SetReplicasConditions(&cassandra.Status.Conditions, 1, 1, 0, nil)
+	contrail.SetCondition(&cassandra.Status.Conditions, contrail.Condition{
+		Type: contrail.ConditionDegraded, Status: contrail.ConditionTrue, Reason: "CrashLoopBackOff", Message: "container cassandra of pod cassandra1-cassandra-statefulset-0: back-off",
+	})
+	config := &contrail.Config{
+		ObjectMeta: meta.ObjectMeta{Name: "config1", Namespace: "default"},
+		Status:     contrail.ConfigStatus{Active: &falseVal},
+	}
+	contrail.SetDependencyNotReady(&config.Status.Conditions, 1, "Cassandra", "cassandra1")
+
+	t.Run("should summarize conditions of services", func(t *testing.T) {
+		fakeClient := fake.NewFakeClientWithScheme(scheme, cassandra, config)
+		reconciler := ReconcileManager{client: fakeClient, scheme: scheme, kubernetes: k8s.New(fakeClient, scheme)}
+		// when
+		require.NoError(t, reconciler.setConditions(manager))
+		// then
+		conditions := manager.Status.Conditions
+		ready := contrail.FindCondition(conditions, contrail.ConditionReady)
+		require.NotNil(t, ready)
+		assert.Equal(t, contrail.ConditionFalse, ready.Status)
+		assert.Equal(t, int64(2), ready.ObservedGeneration)
+		assert.Equal(t, "services not ready: Cassandra cassandra1, Config config1", ready.Message)
+		degraded := contrail.FindCondition(conditions, contrail.ConditionDegraded)
+		require.NotNil(t, degraded)
+		assert.Equal(t, contrail.ConditionTrue, degraded.Status)
+		assert.Contains(t, degraded.Message, "Cassandra cassandra1: container cassandra")
+		dependencies := contrail.FindCondition(conditions, contrail.ConditionDependenciesReady)
+		require.NotNil(t, dependencies)
+		assert.Equal(t, contrail.ConditionFalse, dependencies.Status)
+		assert.Equal(t, "Config config1: waiting for Cassandra cassandra1", dependencies.Message)
+	})
+
+	t.Run("should be ready when all services are active", func(t *testing.T) {
+		m := manager.DeepCopy()
+		m.Status.Cassandras[0].Active = &trueVal
+		m.Status.Config.Active = &trueVal
+		fakeClient := fake.NewFakeClientWithScheme(scheme)
+		reconciler := ReconcileManager{client: fakeClient, scheme: scheme, kubernetes: k8s.New(fakeClient, scheme)}
+		// when
+		require.NoError(t, reconciler.setConditions(m))
+		// then
+		assert.True(t, contrail.IsConditionTrue(m.Status.Conditions, contrail.ConditionReady))
+		assert.False(t, contrail.IsConditionTrue(m.Status.Conditions, contrail.ConditionProgressing))
+		assert.False(t, contrail.IsConditionTrue(m.Status.Conditions, contrail.ConditionDegraded))
+		assert.True(t, contrail.IsConditionTrue(m.Status.Conditions, contrail.ConditionDependenciesReady))
+	})
+}
+
+func strPtr(s string) *string {
+	return &s
+}
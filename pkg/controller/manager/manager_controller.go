@@ -23,6 +23,7 @@ import (
 
 	"github.com/Juniper/contrail-operator/pkg/apis/contrail/v1alpha1"
 	"github.com/Juniper/contrail-operator/pkg/certificates"
+	"github.com/Juniper/contrail-operator/pkg/client/keystone"
 	"github.com/Juniper/contrail-operator/pkg/controller/utils"
 	"github.com/Juniper/contrail-operator/pkg/k8s"
 )
@@ -61,11 +62,12 @@ func Add(mgr manager.Manager) error {
 		return err
 	}
 	var r reconcile.Reconciler
-	reconcileManager := ReconcileManager{client: mgr.GetClient(),
+	client := utils.NewEventRecordingClient(mgr.GetClient(), mgr.GetEventRecorderFor("manager-controller"))
+	reconcileManager := ReconcileManager{client: client,
 		scheme:     mgr.GetScheme(),
 		manager:    mgr,
 		cache:      mgr.GetCache(),
-		kubernetes: k8s.New(mgr.GetClient(), mgr.GetScheme()),
+		kubernetes: k8s.New(client, mgr.GetScheme()),
 	}
 	r = &reconcileManager
 	//r := newReconciler(mgr)
@@ -134,7 +136,14 @@ func (r *ReconcileManager) Reconcile(request reconcile.Request) (reconcile.Resul
 	}
 
 	if !instance.GetDeletionTimestamp().IsZero() {
-		return reconcile.Result{}, nil
+		if !utils.HasFinalizer(instance, utils.CleanupFinalizer) {
+			return reconcile.Result{}, nil
+		}
+		finished, err := r.teardown(instance)
+		if err != nil || !finished {
+			return reconcile.Result{RequeueAfter: teardownRequeuePeriod}, err
+		}
+		return reconcile.Result{}, utils.RemoveFinalizer(r.client, instance, utils.CleanupFinalizer)
 	}
 
 	provisionConfigMap := &corev1.ConfigMap{}
@@ -161,6 +170,7 @@ func (r *ReconcileManager) Reconcile(request reconcile.Request) (reconcile.Resul
 	if err = r.secret(adminPasswordSecretName, "manager", instance).ensureAdminPassSecretExist(); err != nil {
 		return reconcile.Result{}, err
 	}
+	controllerutil.AddFinalizer(instance, utils.CleanupFinalizer)
 	if err = r.client.Update(context.TODO(), instance); err != nil {
 		return reconcile.Result{}, err
 	}
@@ -174,6 +184,11 @@ func (r *ReconcileManager) Reconcile(request reconcile.Request) (reconcile.Resul
 		return reconcile.Result{}, nil
 	}
 
+	upgrading, err := r.planUpgrade(instance)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
 	nodesHostAliases := r.getNodesHostAliases(nodes)
 	if err := r.processCassandras(instance, replicas, nodesHostAliases); err != nil {
 		return reconcile.Result{}, err
@@ -239,25 +254,18 @@ func (r *ReconcileManager) Reconcile(request reconcile.Request) (reconcile.Resul
 		return reconcile.Result{}, err
 	}
 
-	r.setConditions(instance)
+	if err = r.setConditions(instance); err != nil {
+		return reconcile.Result{}, err
+	}
 
 	err = r.client.Status().Update(context.TODO(), instance)
 	if err != nil {
 		return reconcile.Result{}, err
 	}
-	return reconcile.Result{}, nil
-}
-
-func (r *ReconcileManager) setConditions(manager *v1alpha1.Manager) {
-	readyStatus := v1alpha1.ConditionFalse
-	if manager.IsClusterReady() {
-		readyStatus = v1alpha1.ConditionTrue
+	if upgrading {
+		return reconcile.Result{RequeueAfter: upgradeRequeuePeriod}, nil
 	}
-
-	manager.Status.Conditions = []v1alpha1.ManagerCondition{{
-		Type:   v1alpha1.ManagerReady,
-		Status: readyStatus,
-	}}
+	return reconcile.Result{}, nil
 }
 
 func (r *ReconcileManager) getNodes(selector labels.Selector) ([]corev1.Node, error) {
@@ -333,7 +341,9 @@ func (r *ReconcileManager) processZookeepers(manager *v1alpha1.Manager, replicas
 		zookeeper.ObjectMeta = zookeeperService.ObjectMeta.ToMeta()
 		zookeeper.ObjectMeta.Namespace = manager.Namespace
 		_, err := controllerutil.CreateOrUpdate(context.TODO(), r.client, zookeeper, func() error {
+			currentContainers := zookeeper.Spec.ServiceConfiguration.Containers
 			zookeeper.Spec = zookeeperService.Spec
+			zookeeper.Spec.ServiceConfiguration.Containers = intendedContainers(manager, "Zookeeper", zookeeper.Name, currentContainers, zookeeperService.Spec.ServiceConfiguration.Containers)
 			zookeeper.Spec.CommonConfiguration = utils.MergeCommonConfiguration(manager.Spec.CommonConfiguration, zookeeper.Spec.CommonConfiguration)
 			if zookeeper.Spec.CommonConfiguration.Replicas == nil {
 				zookeeper.Spec.CommonConfiguration.Replicas = &replicas
@@ -384,7 +394,9 @@ func (r *ReconcileManager) processCassandras(manager *v1alpha1.Manager, replicas
 		cassandra.ObjectMeta = cassandraService.ObjectMeta.ToMeta()
 		cassandra.ObjectMeta.Namespace = manager.Namespace
 		_, err := controllerutil.CreateOrUpdate(context.TODO(), r.client, cassandra, func() error {
+			currentContainers := cassandra.Spec.ServiceConfiguration.Containers
 			cassandra.Spec = cassandraService.Spec
+			cassandra.Spec.ServiceConfiguration.Containers = intendedContainers(manager, "Cassandra", cassandra.Name, currentContainers, cassandraService.Spec.ServiceConfiguration.Containers)
 			if cassandra.Spec.ServiceConfiguration.ClusterName == "" {
 				cassandra.Spec.ServiceConfiguration.ClusterName = manager.GetName()
 			}
@@ -524,7 +536,9 @@ func (r *ReconcileManager) processConfig(manager *v1alpha1.Manager, replicas int
 	config.ObjectMeta.Namespace = manager.Namespace
 	manager.Spec.Services.Config.Spec.ServiceConfiguration.KeystoneSecretName = manager.Spec.KeystoneSecretName
 	_, err := controllerutil.CreateOrUpdate(context.TODO(), r.client, config, func() error {
+		currentContainers := config.Spec.ServiceConfiguration.Containers
 		config.Spec = manager.Spec.Services.Config.Spec
+		config.Spec.ServiceConfiguration.Containers = intendedContainers(manager, "Config", config.Name, currentContainers, manager.Spec.Services.Config.Spec.ServiceConfiguration.Containers)
 		config.Spec.CommonConfiguration = utils.MergeCommonConfiguration(manager.Spec.CommonConfiguration, config.Spec.CommonConfiguration)
 		if config.Spec.CommonConfiguration.Replicas == nil {
 			config.Spec.CommonConfiguration.Replicas = &replicas
@@ -634,7 +648,9 @@ func (r *ReconcileManager) processControls(manager *v1alpha1.Manager, replicas i
 		control.ObjectMeta = controlService.ObjectMeta.ToMeta()
 		control.ObjectMeta.Namespace = manager.Namespace
 		_, err := controllerutil.CreateOrUpdate(context.TODO(), r.client, control, func() error {
+			currentContainers := control.Spec.ServiceConfiguration.Containers
 			control.Spec = controlService.Spec
+			control.Spec.ServiceConfiguration.Containers = intendedContainers(manager, "Control", control.Name, currentContainers, controlService.Spec.ServiceConfiguration.Containers)
 			control.Spec.CommonConfiguration = utils.MergeCommonConfiguration(manager.Spec.CommonConfiguration, control.Spec.CommonConfiguration)
 			if control.Spec.CommonConfiguration.Replicas == nil {
 				control.Spec.CommonConfiguration.Replicas = &replicas
@@ -677,7 +693,9 @@ func (r *ReconcileManager) processRabbitMQ(manager *v1alpha1.Manager, replicas i
 	rabbitMQ.ObjectMeta = manager.Spec.Services.Rabbitmq.ObjectMeta.ToMeta()
 	rabbitMQ.ObjectMeta.Namespace = manager.Namespace
 	_, err := controllerutil.CreateOrUpdate(context.TODO(), r.client, rabbitMQ, func() error {
+		currentContainers := rabbitMQ.Spec.ServiceConfiguration.Containers
 		rabbitMQ.Spec = manager.Spec.Services.Rabbitmq.Spec
+		rabbitMQ.Spec.ServiceConfiguration.Containers = intendedContainers(manager, "Rabbitmq", rabbitMQ.Name, currentContainers, manager.Spec.Services.Rabbitmq.Spec.ServiceConfiguration.Containers)
 		rabbitMQ.Spec.CommonConfiguration = utils.MergeCommonConfiguration(manager.Spec.CommonConfiguration, rabbitMQ.Spec.CommonConfiguration)
 		if rabbitMQ.Spec.CommonConfiguration.Replicas == nil {
 			rabbitMQ.Spec.CommonConfiguration.Replicas = &replicas
@@ -725,7 +743,9 @@ func (r *ReconcileManager) processVRouters(manager *v1alpha1.Manager, replicas i
 		vRouter.ObjectMeta = vRouterService.ObjectMeta.ToMeta()
 		vRouter.ObjectMeta.Namespace = manager.Namespace
 		_, err := controllerutil.CreateOrUpdate(context.TODO(), r.client, vRouter, func() error {
+			currentContainers := vRouter.Spec.ServiceConfiguration.Containers
 			vRouter.Spec.ServiceConfiguration.VrouterConfiguration = vRouterService.Spec.ServiceConfiguration.VrouterConfiguration
+			vRouter.Spec.ServiceConfiguration.Containers = intendedContainers(manager, "Vrouter", vRouter.Name, currentContainers, vRouterService.Spec.ServiceConfiguration.Containers)
 			vRouter.Spec.CommonConfiguration = utils.MergeCommonConfiguration(manager.Spec.CommonConfiguration, vRouterService.Spec.CommonConfiguration)
 			if err := fillVrouterConfiguration(vRouter, vRouterService.Spec.ServiceConfiguration.ControlInstance, manager.ObjectMeta, r.client); err != nil {
 				return err
@@ -998,7 +1018,7 @@ func (r *ReconcileManager) processMemcached(manager *v1alpha1.Manager, replicas
 }
 
 func (r *ReconcileManager) processCSRSignerCaConfigMap(manager *v1alpha1.Manager) error {
-	caCertificate := certificates.NewCACertificate(r.client, r.scheme, manager, "manager")
+	caCertificate := certificates.NewCACertificate(r.client, r.scheme, manager, "manager", utils.CertificateKeyConfig(manager.Spec.CommonConfiguration.CertificateKey))
 	if err := caCertificate.EnsureExists(); err != nil {
 		return err
 	}
@@ -1012,7 +1032,25 @@ func (r *ReconcileManager) processCSRSignerCaConfigMap(manager *v1alpha1.Manager
 		if err != nil {
 			return err
 		}
+		if keystoneService := manager.Spec.Services.Keystone; keystoneService != nil {
+			keystoneConfiguration := keystoneService.Spec.ServiceConfiguration
+			if keystoneConfiguration.ExternalAddress != "" && keystoneConfiguration.CABundleConfigMapName != "" {
+				// Pods trust the external keystone through the signer CA bundle.
+				keystoneCABundle, err := keystone.ExternalCABundle(r.client, keystoneConfiguration.CABundleConfigMapName, manager.Namespace)
+				if err != nil {
+					return err
+				}
+				csrSignerCAValue = append(append(csrSignerCAValue, '\n'), keystoneCABundle...)
+			}
+		}
 		csrSignerCaConfigMap.Data = map[string]string{certificates.SignerCAFilename: string(csrSignerCAValue)}
+		crl, err := caCertificate.GetCRL()
+		if err != nil {
+			return err
+		}
+		if crl != nil {
+			csrSignerCaConfigMap.Data[certificates.SignerCRLFilename] = string(crl)
+		}
 		return controllerutil.SetControllerReference(manager, csrSignerCaConfigMap, r.scheme)
 	})
 
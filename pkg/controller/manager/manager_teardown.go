@@ -0,0 +1,250 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+
+	"github.com/Juniper/contrail-operator/pkg/apis/contrail/v1alpha1"
+	"github.com/Juniper/contrail-operator/pkg/controller/utils"
+	"github.com/Juniper/contrail-operator/pkg/job"
+)
+
+// teardownRequeuePeriod is how often the teardown checks whether
+// resources deleted in the current stage are gone.
+const teardownRequeuePeriod = 5 * time.Second
+
+// maxOwnerDepth limits how many controllers are followed up
+// from an object to find out if it belongs to the Manager.
+const maxOwnerDepth = 3
+
+// teardownStages lists resources created by the Manager in reverse order of their
+// dependencies. Resources of a stage are deleted once resources of all previous
+// stages are gone.
+var teardownStages = [][]runtime.Object{
+	{&v1alpha1.CommandList{}, &v1alpha1.ContrailmonitorList{}, &v1alpha1.WebuiList{}, &v1alpha1.KubemanagerList{}, &v1alpha1.ContrailCNIList{}},
+	{&v1alpha1.VrouterList{}},
+	{&v1alpha1.ControlList{}},
+	{&v1alpha1.ProvisionManagerList{}},
+	{&v1alpha1.SwiftList{}},
+	{&v1alpha1.ConfigList{}, &v1alpha1.KeystoneList{}},
+	{&v1alpha1.RabbitmqList{}, &v1alpha1.PostgresList{}, &v1alpha1.MemcachedList{}},
+	{&v1alpha1.CassandraList{}, &v1alpha1.ZookeeperList{}},
+}
+
+// teardown deletes resources of the Manager stage by stage and, when the retention
+// policy says so, wipes and deletes persistent volumes of services.
+// It returns true when nothing is left to delete.
+func (r *ReconcileManager) teardown(manager *v1alpha1.Manager) (bool, error) {
+	deleteData := manager.Spec.DataRetentionPolicy == v1alpha1.DataRetentionPolicyDelete
+	if deleteData {
+		if err := r.labelClaims(manager); err != nil {
+			return false, err
+		}
+	}
+	for _, stage := range teardownStages {
+		remaining, err := r.deleteStage(manager, stage)
+		if err != nil || remaining > 0 {
+			return false, err
+		}
+	}
+	if deleteData {
+		return r.deleteClaims(manager)
+	}
+	return true, nil
+}
+
+// deleteStage deletes resources of the stage controlled by the Manager
+// and returns the number of resources which still exist.
+func (r *ReconcileManager) deleteStage(manager *v1alpha1.Manager, stage []runtime.Object) (int, error) {
+	remaining := 0
+	for _, l := range stage {
+		list := l.DeepCopyObject()
+		if err := r.client.List(context.TODO(), list, client.InNamespace(manager.Namespace)); err != nil {
+			return 0, err
+		}
+		items, err := meta.ExtractList(list)
+		if err != nil {
+			return 0, err
+		}
+		for _, item := range items {
+			accessor, err := meta.Accessor(item)
+			if err != nil {
+				return 0, err
+			}
+			if !v1.IsControlledBy(accessor, manager) {
+				continue
+			}
+			remaining++
+			if !accessor.GetDeletionTimestamp().IsZero() {
+				continue
+			}
+			// Resources cleaning up after themselves still need their pods,
+			// other resources are gone only when their pods are gone.
+			propagation := v1.DeletePropagationForeground
+			if utils.HasFinalizer(accessor, utils.CleanupFinalizer) {
+				propagation = v1.DeletePropagationBackground
+			}
+			gvk, err := apiutil.GVKForObject(item, r.scheme)
+			if err != nil {
+				return 0, err
+			}
+			log.Info("Deleting resource", "Kind", gvk.Kind, "Name", accessor.GetName())
+			if err := r.client.Delete(context.TODO(), item, client.PropagationPolicy(propagation)); err != nil && !errors.IsNotFound(err) {
+				return 0, err
+			}
+		}
+	}
+	return remaining, nil
+}
+
+// labelClaims labels claims of stateful sets of the Manager's services with
+// the cluster name, so that they can be found once the stateful sets are gone.
+func (r *ReconcileManager) labelClaims(manager *v1alpha1.Manager) error {
+	statefulSets := &appsv1.StatefulSetList{}
+	if err := r.client.List(context.TODO(), statefulSets, client.InNamespace(manager.Namespace)); err != nil {
+		return err
+	}
+	for i := range statefulSets.Items {
+		sts := &statefulSets.Items[i]
+		if len(sts.Spec.VolumeClaimTemplates) == 0 {
+			continue
+		}
+		owned, err := r.isOwnedBy(manager, sts)
+		if err != nil {
+			return err
+		}
+		if !owned {
+			continue
+		}
+		replicas := sts.Status.Replicas
+		if sts.Spec.Replicas != nil && *sts.Spec.Replicas > replicas {
+			replicas = *sts.Spec.Replicas
+		}
+		for _, template := range sts.Spec.VolumeClaimTemplates {
+			for ordinal := 0; ordinal < int(replicas); ordinal++ {
+				name := template.Name + "-" + sts.Name + "-" + strconv.Itoa(ordinal)
+				if err := r.labelClaim(manager, name); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (r *ReconcileManager) labelClaim(manager *v1alpha1.Manager, name string) error {
+	claim := &corev1.PersistentVolumeClaim{}
+	err := r.client.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: manager.Namespace}, claim)
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if claim.Labels["contrail_cluster"] == manager.Name {
+		return nil
+	}
+	if claim.Labels == nil {
+		claim.Labels = map[string]string{}
+	}
+	claim.Labels["contrail_cluster"] = manager.Name
+	return r.client.Update(context.TODO(), claim)
+}
+
+// isOwnedBy follows controllers of the object up to the Manager.
+func (r *ReconcileManager) isOwnedBy(manager *v1alpha1.Manager, object v1.Object) (bool, error) {
+	for depth := 0; depth < maxOwnerDepth; depth++ {
+		owner := v1.GetControllerOf(object)
+		if owner == nil {
+			return false, nil
+		}
+		if owner.UID == manager.UID {
+			return true, nil
+		}
+		u := &unstructured.Unstructured{}
+		u.SetAPIVersion(owner.APIVersion)
+		u.SetKind(owner.Kind)
+		err := r.client.Get(context.TODO(), types.NamespacedName{Name: owner.Name, Namespace: object.GetNamespace()}, u)
+		if errors.IsNotFound(err) {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		object = u
+	}
+	return false, nil
+}
+
+// deleteClaims wipes data of the labeled claims with jobs and then deletes
+// the claims and their volumes. It returns true when all claims are deleted.
+func (r *ReconcileManager) deleteClaims(manager *v1alpha1.Manager) (bool, error) {
+	claims := &corev1.PersistentVolumeClaimList{}
+	if err := r.client.List(context.TODO(), claims, client.InNamespace(manager.Namespace), client.MatchingLabels{"contrail_cluster": manager.Name}); err != nil {
+		return false, err
+	}
+	wiped := true
+	for i := range claims.Items {
+		done, err := r.wipeClaim(manager, &claims.Items[i])
+		if err != nil {
+			return false, err
+		}
+		wiped = wiped && done
+	}
+	if !wiped {
+		return false, nil
+	}
+	for i := range claims.Items {
+		claim := &claims.Items[i]
+		log.Info("Deleting persistent volume claim", "Name", claim.Name)
+		background := client.PropagationPolicy(v1.DeletePropagationBackground)
+		if err := r.client.Delete(context.TODO(), utils.WipeJob(claim, utils.WipeImage(manager.Spec.Containers)), background); err != nil && !errors.IsNotFound(err) {
+			return false, err
+		}
+		if err := r.client.Delete(context.TODO(), claim); err != nil && !errors.IsNotFound(err) {
+			return false, err
+		}
+		if claim.Spec.VolumeName == "" {
+			continue
+		}
+		pv := &corev1.PersistentVolume{ObjectMeta: v1.ObjectMeta{Name: claim.Spec.VolumeName}}
+		if err := r.client.Delete(context.TODO(), pv); err != nil && !errors.IsNotFound(err) {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// wipeClaim runs the job removing data from the claim and returns true
+// when the job has completed. Claims which are not bound have no data.
+func (r *ReconcileManager) wipeClaim(manager *v1alpha1.Manager, claim *corev1.PersistentVolumeClaim) (bool, error) {
+	if claim.Status.Phase != corev1.ClaimBound {
+		return true, nil
+	}
+	wipe := utils.WipeJob(claim, utils.WipeImage(manager.Spec.Containers))
+	err := r.client.Get(context.TODO(), types.NamespacedName{Name: wipe.Name, Namespace: wipe.Namespace}, wipe)
+	if errors.IsNotFound(err) {
+		log.Info("Wiping persistent volume claim", "Name", claim.Name)
+		return false, r.client.Create(context.TODO(), utils.WipeJob(claim, utils.WipeImage(manager.Spec.Containers)))
+	}
+	if err != nil {
+		return false, err
+	}
+	if job.Job(*wipe).JobFailed() {
+		return false, fmt.Errorf("job %s failed to wipe data of persistent volume claim %s", wipe.Name, claim.Name)
+	}
+	return job.Job(*wipe).JobCompleted(), nil
+}
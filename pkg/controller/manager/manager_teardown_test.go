@@ -0,0 +1,178 @@
+package manager
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apps "k8s.io/api/apps/v1"
+	batch "k8s.io/api/batch/v1"
+	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	contrail "github.com/Juniper/contrail-operator/pkg/apis/contrail/v1alpha1"
+	"github.com/Juniper/contrail-operator/pkg/controller/utils"
+	"github.com/Juniper/contrail-operator/pkg/k8s"
+)
+
+func TestManagerTeardown(t *testing.T) {
+	scheme, err := contrail.SchemeBuilder.Build()
+	require.NoError(t, err)
+	require.NoError(t, core.SchemeBuilder.AddToScheme(scheme))
+	require.NoError(t, apps.SchemeBuilder.AddToScheme(scheme))
+	require.NoError(t, batch.SchemeBuilder.AddToScheme(scheme))
+	trueVal := true
+	replicas := int32(1)
+	now := meta.Now()
+
+	newManager := func(policy contrail.DataRetentionPolicy) *contrail.Manager {
+		return &contrail.Manager{
+			ObjectMeta: meta.ObjectMeta{
+				Name: "cluster1", Namespace: "default", UID: "manager-uid",
+				DeletionTimestamp: &now, Finalizers: []string{utils.CleanupFinalizer},
+			},
+			Spec: contrail.ManagerSpec{DataRetentionPolicy: policy},
+		}
+	}
+	controlledBy := func(kind, name string, uid types.UID) []meta.OwnerReference {
+		return []meta.OwnerReference{{APIVersion: "contrail.juniper.net/v1alpha1", Kind: kind, Name: name, UID: uid, Controller: &trueVal}}
+	}
+	webui := &contrail.Webui{ObjectMeta: meta.ObjectMeta{
+		Name: "webui1", Namespace: "default", OwnerReferences: controlledBy("Manager", "cluster1", "manager-uid"),
+	}}
+	cassandra := &contrail.Cassandra{ObjectMeta: meta.ObjectMeta{
+		Name: "cassandra1", Namespace: "default", UID: "cassandra-uid", OwnerReferences: controlledBy("Manager", "cluster1", "manager-uid"),
+	}}
+	sts := &apps.StatefulSet{
+		ObjectMeta: meta.ObjectMeta{
+			Name: "cassandra1-cassandra-statefulset", Namespace: "default", OwnerReferences: controlledBy("Cassandra", "cassandra1", "cassandra-uid"),
+		},
+		Spec: apps.StatefulSetSpec{
+			Replicas:             &replicas,
+			VolumeClaimTemplates: []core.PersistentVolumeClaim{{ObjectMeta: meta.ObjectMeta{Name: "pvc"}}},
+		},
+	}
+	claim := &core.PersistentVolumeClaim{
+		ObjectMeta: meta.ObjectMeta{Name: "pvc-cassandra1-cassandra-statefulset-0", Namespace: "default"},
+		Spec:       core.PersistentVolumeClaimSpec{VolumeName: "pv1"},
+		Status:     core.PersistentVolumeClaimStatus{Phase: core.ClaimBound},
+	}
+	volume := &core.PersistentVolume{ObjectMeta: meta.ObjectMeta{Name: "pv1"}}
+	request := reconcile.Request{NamespacedName: types.NamespacedName{Name: "cluster1", Namespace: "default"}}
+
+	newReconciler := func(objs ...runtime.Object) *ReconcileManager {
+		fakeClient := fake.NewFakeClientWithScheme(scheme, objs...)
+		return &ReconcileManager{client: fakeClient, scheme: scheme, kubernetes: k8s.New(fakeClient, scheme)}
+	}
+	exists := func(c client.Client, name types.NamespacedName, obj runtime.Object) bool {
+		err := c.Get(context.Background(), name, obj)
+		if errors.IsNotFound(err) {
+			return false
+		}
+		require.NoError(t, err)
+		return true
+	}
+
+	t.Run("should delete services in reverse order of dependencies", func(t *testing.T) {
+		r := newReconciler(newManager(contrail.DataRetentionPolicyRetain), webui.DeepCopy(), cassandra.DeepCopy())
+		// when
+		result, err := r.Reconcile(request)
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, teardownRequeuePeriod, result.RequeueAfter)
+		assert.False(t, exists(r.client, types.NamespacedName{Name: "webui1", Namespace: "default"}, &contrail.Webui{}))
+		assert.True(t, exists(r.client, types.NamespacedName{Name: "cassandra1", Namespace: "default"}, &contrail.Cassandra{}))
+		// when
+		_, err = r.Reconcile(request)
+		// then
+		require.NoError(t, err)
+		assert.False(t, exists(r.client, types.NamespacedName{Name: "cassandra1", Namespace: "default"}, &contrail.Cassandra{}))
+		// when
+		result, err = r.Reconcile(request)
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, reconcile.Result{}, result)
+		manager := &contrail.Manager{}
+		require.True(t, exists(r.client, request.NamespacedName, manager))
+		assert.Empty(t, manager.Finalizers)
+	})
+
+	t.Run("should keep persistent volumes when policy is Retain", func(t *testing.T) {
+		r := newReconciler(newManager(contrail.DataRetentionPolicyRetain), cassandra.DeepCopy(), sts.DeepCopy(), claim.DeepCopy(), volume.DeepCopy())
+		// when
+		for i := 0; i < 3; i++ {
+			_, err := r.Reconcile(request)
+			require.NoError(t, err)
+		}
+		// then
+		manager := &contrail.Manager{}
+		require.True(t, exists(r.client, request.NamespacedName, manager))
+		assert.Empty(t, manager.Finalizers)
+		assert.True(t, exists(r.client, types.NamespacedName{Name: claim.Name, Namespace: "default"}, &core.PersistentVolumeClaim{}))
+		assert.True(t, exists(r.client, types.NamespacedName{Name: "pv1"}, &core.PersistentVolume{}))
+	})
+
+	t.Run("should wipe and delete persistent volumes when policy is Delete", func(t *testing.T) {
+		r := newReconciler(newManager(contrail.DataRetentionPolicyDelete), cassandra.DeepCopy(), sts.DeepCopy(), claim.DeepCopy(), volume.DeepCopy())
+		// when
+		for i := 0; i < 3; i++ {
+			_, err := r.Reconcile(request)
+			require.NoError(t, err)
+		}
+		// then
+		labeledClaim := &core.PersistentVolumeClaim{}
+		require.True(t, exists(r.client, types.NamespacedName{Name: claim.Name, Namespace: "default"}, labeledClaim))
+		assert.Equal(t, "cluster1", labeledClaim.Labels["contrail_cluster"])
+		wipe := &batch.Job{}
+		jobName := types.NamespacedName{Name: claim.Name + "-wipe", Namespace: "default"}
+		require.True(t, exists(r.client, jobName, wipe))
+		assert.Equal(t, claim.Name, wipe.Spec.Template.Spec.Volumes[0].PersistentVolumeClaim.ClaimName)
+		assert.Equal(t, "localhost:5000/busybox:1.31", wipe.Spec.Template.Spec.Containers[0].Image)
+		// when
+		wipe.Status.Conditions = []batch.JobCondition{{Type: batch.JobComplete, Status: core.ConditionTrue}}
+		require.NoError(t, r.client.Status().Update(context.Background(), wipe))
+		result, err := r.Reconcile(request)
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, reconcile.Result{}, result)
+		assert.False(t, exists(r.client, jobName, &batch.Job{}))
+		assert.False(t, exists(r.client, types.NamespacedName{Name: claim.Name, Namespace: "default"}, &core.PersistentVolumeClaim{}))
+		assert.False(t, exists(r.client, types.NamespacedName{Name: "pv1"}, &core.PersistentVolume{}))
+		manager := &contrail.Manager{}
+		require.True(t, exists(r.client, request.NamespacedName, manager))
+		assert.Empty(t, manager.Finalizers)
+	})
+
+	t.Run("should wipe persistent volumes with image from containers of Manager", func(t *testing.T) {
+		manager := newManager(contrail.DataRetentionPolicyDelete)
+		manager.Spec.Containers = []*contrail.Container{{Name: "wipe", Image: "registry:5000/busybox:1.31"}}
+		r := newReconciler(manager, cassandra.DeepCopy(), sts.DeepCopy(), claim.DeepCopy(), volume.DeepCopy())
+		// when
+		for i := 0; i < 3; i++ {
+			_, err := r.Reconcile(request)
+			require.NoError(t, err)
+		}
+		// then
+		wipe := &batch.Job{}
+		require.True(t, exists(r.client, types.NamespacedName{Name: claim.Name + "-wipe", Namespace: "default"}, wipe))
+		assert.Equal(t, "registry:5000/busybox:1.31", wipe.Spec.Template.Spec.Containers[0].Image)
+	})
+
+	t.Run("should not tear down Manager without finalizer", func(t *testing.T) {
+		manager := newManager(contrail.DataRetentionPolicyRetain)
+		manager.Finalizers = nil
+		r := newReconciler(manager, webui.DeepCopy())
+		// when
+		_, err := r.Reconcile(request)
+		// then
+		require.NoError(t, err)
+		assert.True(t, exists(r.client, types.NamespacedName{Name: "webui1", Namespace: "default"}, &contrail.Webui{}))
+	})
+}
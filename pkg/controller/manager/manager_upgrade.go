@@ -0,0 +1,359 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/Juniper/contrail-operator/pkg/apis/contrail/v1alpha1"
+)
+
+// upgradeRequeuePeriod is how often the service upgraded in the
+// current step of the ordered upgrade is checked.
+const upgradeRequeuePeriod = 15 * time.Second
+
+// functionalState is the state of a process reported in its UVE when it works.
+const functionalState = "Functional"
+
+// upgradeStep is a service upgraded in a single step of the ordered upgrade.
+type upgradeStep struct {
+	managedResource
+	containers []*v1alpha1.Container
+}
+
+// upgradeSteps lists services in the order of the ordered upgrade:
+// data stores, config, control and vrouter.
+func upgradeSteps(s v1alpha1.Services) []upgradeStep {
+	var steps []upgradeStep
+	for _, c := range s.Cassandras {
+		steps = append(steps, upgradeStep{managedResource{"Cassandra", c.Name}, c.Spec.ServiceConfiguration.Containers})
+	}
+	for _, z := range s.Zookeepers {
+		steps = append(steps, upgradeStep{managedResource{"Zookeeper", z.Name}, z.Spec.ServiceConfiguration.Containers})
+	}
+	if s.Rabbitmq != nil {
+		steps = append(steps, upgradeStep{managedResource{"Rabbitmq", s.Rabbitmq.Name}, s.Rabbitmq.Spec.ServiceConfiguration.Containers})
+	}
+	if s.Config != nil {
+		steps = append(steps, upgradeStep{managedResource{"Config", s.Config.Name}, s.Config.Spec.ServiceConfiguration.Containers})
+	}
+	for _, c := range s.Controls {
+		steps = append(steps, upgradeStep{managedResource{"Control", c.Name}, c.Spec.ServiceConfiguration.Containers})
+	}
+	for _, v := range s.Vrouters {
+		steps = append(steps, upgradeStep{managedResource{"Vrouter", v.Name}, v.Spec.ServiceConfiguration.Containers})
+	}
+	return steps
+}
+
+// planUpgrade records steps of the ordered upgrade in the status of the Manager.
+// Services with changed images are upgraded one at a time: a service waits as
+// Pending with its current images until the previous service is active and healthy
+// with its intended images. A step which fails or times out pauses the upgrade
+// until the spec of the Manager changes. It returns true when the upgrade is in progress.
+func (r *ReconcileManager) planUpgrade(manager *v1alpha1.Manager) (bool, error) {
+	upgrade := manager.Spec.Upgrade
+	if upgrade == nil || upgrade.Strategy != v1alpha1.ManagerUpgradeOrdered {
+		manager.Status.Upgrade = nil
+		return false, nil
+	}
+	status := manager.Status.Upgrade
+	if status == nil {
+		status = &v1alpha1.ManagerUpgradeStatus{}
+		manager.Status.Upgrade = status
+	}
+	previous := map[managedResource]v1alpha1.ManagerUpgradeStep{}
+	for _, step := range status.Steps {
+		previous[managedResource{step.Kind, step.Name}] = step
+	}
+	pausedAfterFailure := status.Phase == v1alpha1.ManagerUpgradePaused && status.PausedGeneration == manager.Generation
+	paused := upgrade.Paused || pausedAfterFailure
+
+	now := v1.Now()
+	var steps []v1alpha1.ManagerUpgradeStep
+	services := map[managedResource]*unstructured.Unstructured{}
+	intended := map[managedResource][]*v1alpha1.Container{}
+	newUpgrade := false
+	for _, s := range upgradeSteps(manager.Spec.Services) {
+		u, err := r.service(manager.Namespace, s.managedResource)
+		if err != nil {
+			return false, err
+		}
+		// Services which do not exist yet are created with their intended images.
+		if u == nil {
+			continue
+		}
+		current, err := serviceContainers(u)
+		if err != nil {
+			return false, err
+		}
+		services[s.managedResource] = u
+		intended[s.managedResource] = s.containers
+		changed := imagesChanged(current, s.containers)
+		step, found := previous[s.managedResource]
+		switch {
+		case found && (step.State == v1alpha1.UpgradeStepUpgrading || step.State == v1alpha1.UpgradeStepFailed):
+		case changed:
+			if !found || step.State == v1alpha1.UpgradeStepCompleted {
+				newUpgrade = true
+			}
+			step = v1alpha1.ManagerUpgradeStep{Kind: s.kind, Name: s.name, State: v1alpha1.UpgradeStepPending}
+		case found && step.State == v1alpha1.UpgradeStepCompleted:
+		default:
+			continue
+		}
+		if step.State == v1alpha1.UpgradeStepFailed && !paused {
+			step.State = v1alpha1.UpgradeStepUpgrading
+			step.StartTime = &now
+			step.Message = ""
+		}
+		steps = append(steps, step)
+	}
+	// Steps of a completed upgrade are kept until services are upgraded again.
+	if newUpgrade && status.Phase == v1alpha1.ManagerUpgradeCompleted {
+		var pending []v1alpha1.ManagerUpgradeStep
+		for _, step := range steps {
+			if step.State != v1alpha1.UpgradeStepCompleted {
+				pending = append(pending, step)
+			}
+		}
+		steps = pending
+	}
+
+	upgrading := false
+	for i := range steps {
+		step := &steps[i]
+		if step.State != v1alpha1.UpgradeStepUpgrading {
+			continue
+		}
+		resource := managedResource{step.Kind, step.Name}
+		healthy, message, err := r.serviceUpgraded(manager.Namespace, services[resource], intended[resource])
+		if err != nil {
+			return false, err
+		}
+		switch {
+		case healthy:
+			log.Info("Service upgraded", "Kind", step.Kind, "Name", step.Name)
+			step.State = v1alpha1.UpgradeStepCompleted
+			step.CompletionTime = &now
+			step.Message = ""
+		case strings.HasPrefix(message, "degraded: ") || step.StartTime != nil && now.Sub(step.StartTime.Time) > upgrade.StepTimeout():
+			if !strings.HasPrefix(message, "degraded: ") {
+				message = fmt.Sprintf("not active and healthy within %s: %s", upgrade.StepTimeout(), message)
+			}
+			log.Info("Service upgrade failed, pausing upgrade", "Kind", step.Kind, "Name", step.Name, "Reason", message)
+			step.State = v1alpha1.UpgradeStepFailed
+			step.Message = message
+			status.PausedGeneration = manager.Generation
+			pausedAfterFailure = true
+			paused = true
+		default:
+			step.Message = message
+			upgrading = true
+		}
+	}
+	if !upgrading && !paused {
+		for i := range steps {
+			step := &steps[i]
+			if step.State == v1alpha1.UpgradeStepPending {
+				log.Info("Upgrading service", "Kind", step.Kind, "Name", step.Name)
+				step.State = v1alpha1.UpgradeStepUpgrading
+				step.StartTime = &now
+				upgrading = true
+				break
+			}
+		}
+	}
+
+	status.Steps = steps
+	pending := false
+	for _, step := range steps {
+		if step.State == v1alpha1.UpgradeStepPending {
+			pending = true
+		}
+	}
+	switch {
+	case pausedAfterFailure:
+		status.Phase = v1alpha1.ManagerUpgradePaused
+		status.Message = "upgrade paused after failed step, change the Manager to resume"
+	case paused && (pending || upgrading):
+		status.Phase = v1alpha1.ManagerUpgradePaused
+		status.Message = "upgrade paused"
+	case pending || upgrading:
+		status.Phase = v1alpha1.ManagerUpgrading
+		status.Message = ""
+	default:
+		status.Phase = v1alpha1.ManagerUpgradeCompleted
+		status.Message = ""
+	}
+	if !pausedAfterFailure {
+		status.PausedGeneration = 0
+	}
+	return upgrading, nil
+}
+
+// serviceUpgraded returns true when the service runs its intended images and is active
+// and healthy: its workloads are rolled out, it is ready for its current generation
+// and its processes are functional according to their UVEs. Otherwise it returns what
+// the service waits for, prefixed with "degraded: " when pods of the service fail.
+func (r *ReconcileManager) serviceUpgraded(namespace string, u *unstructured.Unstructured, intended []*v1alpha1.Container) (bool, string, error) {
+	current, err := serviceContainers(u)
+	if err != nil {
+		return false, "", err
+	}
+	if imagesChanged(current, intended) {
+		return false, "waiting for images to be updated", nil
+	}
+	var conditions []v1alpha1.Condition
+	if _, err := decodeNested(u, &conditions, "status", "conditions"); err != nil {
+		return false, "", err
+	}
+	if c := v1alpha1.FindCondition(conditions, v1alpha1.ConditionDegraded); c != nil && c.Status == v1alpha1.ConditionTrue && c.ObservedGeneration == u.GetGeneration() {
+		return false, "degraded: " + c.Message, nil
+	}
+	ready := v1alpha1.FindCondition(conditions, v1alpha1.ConditionReady)
+	if ready == nil || ready.Status != v1alpha1.ConditionTrue || ready.ObservedGeneration != u.GetGeneration() {
+		return false, "waiting for service to be ready", nil
+	}
+	if active, _, _ := unstructured.NestedBool(u.Object, "status", "active"); !active {
+		return false, "waiting for service to be active", nil
+	}
+	rolledOut, err := r.workloadsRolledOut(namespace, u)
+	if err != nil || !rolledOut {
+		return false, "waiting for pods to be updated", err
+	}
+	notFunctional, err := notFunctionalProcesses(u)
+	if err != nil {
+		return false, "", err
+	}
+	if len(notFunctional) > 0 {
+		return false, "waiting for functional processes: " + strings.Join(notFunctional, ", "), nil
+	}
+	return true, "", nil
+}
+
+// workloadsRolledOut checks that stateful sets and daemon sets of the service run
+// ready pods of their current template.
+func (r *ReconcileManager) workloadsRolledOut(namespace string, u *unstructured.Unstructured) (bool, error) {
+	controlledBy := func(object v1.Object) bool {
+		owner := v1.GetControllerOf(object)
+		return owner != nil && owner.UID == u.GetUID()
+	}
+	statefulSets := &appsv1.StatefulSetList{}
+	if err := r.client.List(context.TODO(), statefulSets, client.InNamespace(namespace)); err != nil {
+		return false, err
+	}
+	for _, sts := range statefulSets.Items {
+		if !controlledBy(&sts) {
+			continue
+		}
+		replicas := int32(1)
+		if sts.Spec.Replicas != nil {
+			replicas = *sts.Spec.Replicas
+		}
+		if sts.Status.ObservedGeneration < sts.Generation || sts.Status.UpdatedReplicas < replicas ||
+			sts.Status.ReadyReplicas < replicas || sts.Status.UpdateRevision != sts.Status.CurrentRevision {
+			return false, nil
+		}
+	}
+	daemonSets := &appsv1.DaemonSetList{}
+	if err := r.client.List(context.TODO(), daemonSets, client.InNamespace(namespace)); err != nil {
+		return false, err
+	}
+	for _, ds := range daemonSets.Items {
+		if !controlledBy(&ds) {
+			continue
+		}
+		if ds.Status.ObservedGeneration < ds.Generation || ds.Status.UpdatedNumberScheduled < ds.Status.DesiredNumberScheduled ||
+			ds.Status.NumberAvailable < ds.Status.DesiredNumberScheduled {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// notFunctionalProcesses lists processes of Config and Control whose
+// state reported by the status monitor from UVEs is not functional.
+func notFunctionalProcesses(u *unstructured.Unstructured) ([]string, error) {
+	var notFunctional []string
+	switch u.GetKind() {
+	case "Config":
+		var nodes map[string]v1alpha1.ConfigServiceStatusMap
+		if _, err := decodeNested(u, &nodes, "status", "serviceStatus"); err != nil {
+			return nil, err
+		}
+		for node, processes := range nodes {
+			for name, process := range processes {
+				if process.ModuleState != functionalState {
+					notFunctional = append(notFunctional, fmt.Sprintf("%s/%s: %s", node, name, process.ModuleState))
+				}
+			}
+		}
+	case "Control":
+		var nodes map[string]v1alpha1.ControlServiceStatus
+		if _, err := decodeNested(u, &nodes, "status", "serviceStatus"); err != nil {
+			return nil, err
+		}
+		for node, process := range nodes {
+			if process.State != functionalState {
+				notFunctional = append(notFunctional, fmt.Sprintf("%s: %s", node, process.State))
+			}
+		}
+	}
+	return notFunctional, nil
+}
+
+// intendedContainers returns containers to set in the service. Services waiting
+// for their step of the ordered upgrade keep images they run.
+func intendedContainers(manager *v1alpha1.Manager, kind, name string, current, intended []*v1alpha1.Container) []*v1alpha1.Container {
+	if manager.Status.Upgrade == nil {
+		return intended
+	}
+	for _, step := range manager.Status.Upgrade.Steps {
+		if step.Kind != kind || step.Name != name {
+			continue
+		}
+		if step.State != v1alpha1.UpgradeStepPending {
+			return intended
+		}
+		images := map[string]string{}
+		for _, c := range current {
+			images[c.Name] = c.Image
+		}
+		containers := make([]*v1alpha1.Container, 0, len(intended))
+		for _, c := range intended {
+			container := *c
+			if image, ok := images[c.Name]; ok {
+				container.Image = image
+			}
+			containers = append(containers, &container)
+		}
+		return containers
+	}
+	return intended
+}
+
+func imagesChanged(current, intended []*v1alpha1.Container) bool {
+	images := map[string]string{}
+	for _, c := range current {
+		images[c.Name] = c.Image
+	}
+	for _, c := range intended {
+		if image, ok := images[c.Name]; ok && image != c.Image {
+			return true
+		}
+	}
+	return false
+}
+
+func serviceContainers(u *unstructured.Unstructured) ([]*v1alpha1.Container, error) {
+	var containers []*v1alpha1.Container
+	_, err := decodeNested(u, &containers, "spec", "serviceConfiguration", "containers")
+	return containers, err
+}
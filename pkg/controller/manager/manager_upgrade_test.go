@@ -0,0 +1,218 @@
+package manager
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apps "k8s.io/api/apps/v1"
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	contrail "github.com/Juniper/contrail-operator/pkg/apis/contrail/v1alpha1"
+	"github.com/Juniper/contrail-operator/pkg/k8s"
+)
+
+func TestManagerOrderedUpgrade(t *testing.T) {
+	scheme, err := contrail.SchemeBuilder.Build()
+	require.NoError(t, err)
+	require.NoError(t, core.SchemeBuilder.AddToScheme(scheme))
+	require.NoError(t, apps.SchemeBuilder.AddToScheme(scheme))
+	trueVal := true
+	replicas := int32(1)
+
+	newManager := func(strategy contrail.ManagerUpgradeStrategy, upgradeStatus *contrail.ManagerUpgradeStatus) *contrail.Manager {
+		return &contrail.Manager{
+			ObjectMeta: meta.ObjectMeta{Name: "cluster1", Namespace: "default", UID: "manager-uid", Generation: 2},
+			Spec: contrail.ManagerSpec{
+				Upgrade: &contrail.ManagerUpgrade{Strategy: strategy},
+				Services: contrail.Services{
+					Cassandras: []*contrail.CassandraService{{
+						ObjectMeta: contrail.ObjectMeta{Name: "cassandra1"},
+						Spec: contrail.CassandraSpec{ServiceConfiguration: contrail.CassandraConfiguration{
+							Containers: []*contrail.Container{{Name: "cassandra", Image: "cassandra:3.11.4"}},
+						}},
+					}},
+					Config: &contrail.ConfigService{
+						ObjectMeta: contrail.ObjectMeta{Name: "config1"},
+						Spec: contrail.ConfigSpec{ServiceConfiguration: contrail.ConfigConfiguration{
+							Containers: []*contrail.Container{{Name: "api", Image: "contrail-controller-config-api:2008.1"}},
+						}},
+					},
+				},
+			},
+			Status: contrail.ManagerStatus{Upgrade: upgradeStatus},
+		}
+	}
+	controlledBy := func(kind, name string, uid types.UID) []meta.OwnerReference {
+		return []meta.OwnerReference{{APIVersion: "contrail.juniper.net/v1alpha1", Kind: kind, Name: name, UID: uid, Controller: &trueVal}}
+	}
+	newCassandra := func(image string, conditions ...contrail.Condition) *contrail.Cassandra {
+		return &contrail.Cassandra{
+			ObjectMeta: meta.ObjectMeta{
+				Name: "cassandra1", Namespace: "default", UID: "cassandra-uid", Generation: 3,
+				OwnerReferences: controlledBy("Manager", "cluster1", "manager-uid"),
+			},
+			Spec: contrail.CassandraSpec{
+				CommonConfiguration:  contrail.PodConfiguration{Replicas: &replicas},
+				ServiceConfiguration: contrail.CassandraConfiguration{Containers: []*contrail.Container{{Name: "cassandra", Image: image}}},
+			},
+			Status: contrail.CassandraStatus{Active: &trueVal, Conditions: conditions},
+		}
+	}
+	newConfig := func(image string) *contrail.Config {
+		return &contrail.Config{
+			ObjectMeta: meta.ObjectMeta{Name: "config1", Namespace: "default", OwnerReferences: controlledBy("Manager", "cluster1", "manager-uid")},
+			Spec: contrail.ConfigSpec{
+				CommonConfiguration:  contrail.PodConfiguration{Replicas: &replicas},
+				ServiceConfiguration: contrail.ConfigConfiguration{Containers: []*contrail.Container{{Name: "api", Image: image}}},
+			},
+		}
+	}
+	newSTS := func(updated int32) *apps.StatefulSet {
+		return &apps.StatefulSet{
+			ObjectMeta: meta.ObjectMeta{
+				Name: "cassandra1-cassandra-statefulset", Namespace: "default", Generation: 2,
+				OwnerReferences: controlledBy("Cassandra", "cassandra1", "cassandra-uid"),
+			},
+			Spec: apps.StatefulSetSpec{Replicas: &replicas},
+			Status: apps.StatefulSetStatus{
+				ObservedGeneration: 2, ReadyReplicas: 1, UpdatedReplicas: updated,
+				CurrentRevision: "rev2", UpdateRevision: "rev2",
+			},
+		}
+	}
+	ready := contrail.Condition{Type: contrail.ConditionReady, Status: contrail.ConditionTrue, ObservedGeneration: 3}
+	cassandraUpgrading := contrail.ManagerUpgradeStep{Kind: "Cassandra", Name: "cassandra1", State: contrail.UpgradeStepUpgrading, StartTime: &meta.Time{Time: meta.Now().Time}}
+	configPending := contrail.ManagerUpgradeStep{Kind: "Config", Name: "config1", State: contrail.UpgradeStepPending}
+	newReconciler := func(objs ...runtime.Object) *ReconcileManager {
+		fakeClient := fake.NewFakeClientWithScheme(scheme, objs...)
+		return &ReconcileManager{client: fakeClient, scheme: scheme, kubernetes: k8s.New(fakeClient, scheme)}
+	}
+	getConfig := func(r *ReconcileManager) *contrail.Config {
+		config := &contrail.Config{}
+		require.NoError(t, r.client.Get(context.Background(), types.NamespacedName{Name: "config1", Namespace: "default"}, config))
+		return config
+	}
+
+	t.Run("should upgrade data stores first and keep images of other services", func(t *testing.T) {
+		manager := newManager(contrail.ManagerUpgradeOrdered, nil)
+		r := newReconciler(manager, newCassandra("cassandra:3.11.3"), newConfig("contrail-controller-config-api:2005.1"))
+		// when
+		upgrading, err := r.planUpgrade(manager)
+		require.NoError(t, err)
+		require.NoError(t, r.processCassandras(manager, 1, nil))
+		require.NoError(t, r.processConfig(manager, 1, nil))
+		// then
+		assert.True(t, upgrading)
+		require.NotNil(t, manager.Status.Upgrade)
+		assert.Equal(t, contrail.ManagerUpgrading, manager.Status.Upgrade.Phase)
+		require.Len(t, manager.Status.Upgrade.Steps, 2)
+		assert.Equal(t, contrail.UpgradeStepUpgrading, manager.Status.Upgrade.Steps[0].State)
+		assert.Equal(t, contrail.UpgradeStepPending, manager.Status.Upgrade.Steps[1].State)
+		cassandra := &contrail.Cassandra{}
+		require.NoError(t, r.client.Get(context.Background(), types.NamespacedName{Name: "cassandra1", Namespace: "default"}, cassandra))
+		assert.Equal(t, "cassandra:3.11.4", cassandra.Spec.ServiceConfiguration.Containers[0].Image)
+		assert.Equal(t, "contrail-controller-config-api:2005.1", getConfig(r).Spec.ServiceConfiguration.Containers[0].Image)
+		assert.Equal(t, "contrail-controller-config-api:2008.1", manager.Spec.Services.Config.Spec.ServiceConfiguration.Containers[0].Image)
+	})
+
+	t.Run("should wait until pods of upgraded service are updated", func(t *testing.T) {
+		manager := newManager(contrail.ManagerUpgradeOrdered, &contrail.ManagerUpgradeStatus{
+			Phase: contrail.ManagerUpgrading, Steps: []contrail.ManagerUpgradeStep{cassandraUpgrading, configPending},
+		})
+		r := newReconciler(manager, newCassandra("cassandra:3.11.4", ready), newSTS(0), newConfig("contrail-controller-config-api:2005.1"))
+		// when
+		upgrading, err := r.planUpgrade(manager)
+		// then
+		require.NoError(t, err)
+		assert.True(t, upgrading)
+		assert.Equal(t, contrail.UpgradeStepUpgrading, manager.Status.Upgrade.Steps[0].State)
+		assert.Equal(t, "waiting for pods to be updated", manager.Status.Upgrade.Steps[0].Message)
+		assert.Equal(t, contrail.UpgradeStepPending, manager.Status.Upgrade.Steps[1].State)
+	})
+
+	t.Run("should upgrade next service once previous one is active and healthy", func(t *testing.T) {
+		manager := newManager(contrail.ManagerUpgradeOrdered, &contrail.ManagerUpgradeStatus{
+			Phase: contrail.ManagerUpgrading, Steps: []contrail.ManagerUpgradeStep{cassandraUpgrading, configPending},
+		})
+		r := newReconciler(manager, newCassandra("cassandra:3.11.4", ready), newSTS(1), newConfig("contrail-controller-config-api:2005.1"))
+		// when
+		upgrading, err := r.planUpgrade(manager)
+		require.NoError(t, err)
+		require.NoError(t, r.processConfig(manager, 1, nil))
+		// then
+		assert.True(t, upgrading)
+		assert.Equal(t, contrail.UpgradeStepCompleted, manager.Status.Upgrade.Steps[0].State)
+		assert.NotNil(t, manager.Status.Upgrade.Steps[0].CompletionTime)
+		assert.Equal(t, contrail.UpgradeStepUpgrading, manager.Status.Upgrade.Steps[1].State)
+		assert.Equal(t, "contrail-controller-config-api:2008.1", getConfig(r).Spec.ServiceConfiguration.Containers[0].Image)
+	})
+
+	t.Run("should wait for functional processes of config", func(t *testing.T) {
+		configUpgrading := contrail.ManagerUpgradeStep{Kind: "Config", Name: "config1", State: contrail.UpgradeStepUpgrading, StartTime: &meta.Time{Time: meta.Now().Time}}
+		manager := newManager(contrail.ManagerUpgradeOrdered, &contrail.ManagerUpgradeStatus{
+			Phase: contrail.ManagerUpgrading, Steps: []contrail.ManagerUpgradeStep{configUpgrading},
+		})
+		config := newConfig("contrail-controller-config-api:2008.1")
+		config.Status.Active = &trueVal
+		config.Status.Conditions = []contrail.Condition{{Type: contrail.ConditionReady, Status: contrail.ConditionTrue}}
+		config.Status.ServiceStatus = map[string]contrail.ConfigServiceStatusMap{
+			"node1": {"api": {ModuleName: "contrail-api", ModuleState: "initializing"}},
+		}
+		r := newReconciler(manager, newCassandra("cassandra:3.11.4", ready), config)
+		// when
+		upgrading, err := r.planUpgrade(manager)
+		// then
+		require.NoError(t, err)
+		assert.True(t, upgrading)
+		assert.Equal(t, "waiting for functional processes: node1/api: initializing", manager.Status.Upgrade.Steps[0].Message)
+	})
+
+	t.Run("should pause upgrade when upgraded service is degraded", func(t *testing.T) {
+		manager := newManager(contrail.ManagerUpgradeOrdered, &contrail.ManagerUpgradeStatus{
+			Phase: contrail.ManagerUpgrading, Steps: []contrail.ManagerUpgradeStep{cassandraUpgrading, configPending},
+		})
+		degraded := contrail.Condition{Type: contrail.ConditionDegraded, Status: contrail.ConditionTrue, ObservedGeneration: 3, Message: "pod cassandra1-cassandra-statefulset-0: ImagePullBackOff"}
+		r := newReconciler(manager, newCassandra("cassandra:3.11.4", degraded), newConfig("contrail-controller-config-api:2005.1"))
+		// when
+		upgrading, err := r.planUpgrade(manager)
+		require.NoError(t, err)
+		require.NoError(t, r.processConfig(manager, 1, nil))
+		// then
+		assert.False(t, upgrading)
+		assert.Equal(t, contrail.ManagerUpgradePaused, manager.Status.Upgrade.Phase)
+		assert.Equal(t, int64(2), manager.Status.Upgrade.PausedGeneration)
+		assert.Equal(t, contrail.UpgradeStepFailed, manager.Status.Upgrade.Steps[0].State)
+		assert.Equal(t, "degraded: pod cassandra1-cassandra-statefulset-0: ImagePullBackOff", manager.Status.Upgrade.Steps[0].Message)
+		assert.Equal(t, contrail.UpgradeStepPending, manager.Status.Upgrade.Steps[1].State)
+		assert.Equal(t, "contrail-controller-config-api:2005.1", getConfig(r).Spec.ServiceConfiguration.Containers[0].Image)
+
+		// when
+		manager.Generation = 3
+		manager.Spec.Services.Cassandras[0].Spec.ServiceConfiguration.Containers[0].Image = "cassandra:3.11.5"
+		upgrading, err = r.planUpgrade(manager)
+		// then
+		require.NoError(t, err)
+		assert.True(t, upgrading)
+		assert.Equal(t, contrail.ManagerUpgrading, manager.Status.Upgrade.Phase)
+		assert.Equal(t, contrail.UpgradeStepUpgrading, manager.Status.Upgrade.Steps[0].State)
+	})
+
+	t.Run("should roll out images of all services at once with parallel strategy", func(t *testing.T) {
+		manager := newManager(contrail.ManagerUpgradeParallel, &contrail.ManagerUpgradeStatus{Phase: contrail.ManagerUpgradeCompleted})
+		r := newReconciler(manager, newCassandra("cassandra:3.11.3"), newConfig("contrail-controller-config-api:2005.1"))
+		// when
+		upgrading, err := r.planUpgrade(manager)
+		require.NoError(t, err)
+		require.NoError(t, r.processConfig(manager, 1, nil))
+		// then
+		assert.False(t, upgrading)
+		assert.Nil(t, manager.Status.Upgrade)
+		assert.Equal(t, "contrail-controller-config-api:2008.1", getConfig(r).Spec.ServiceConfiguration.Containers[0].Image)
+	})
+}
@@ -35,7 +35,8 @@ func Add(mgr manager.Manager) error {
 
 // newReconciler returns a new reconcile.Reconciler
 func newReconciler(mgr manager.Manager) reconcile.Reconciler {
-	return NewReconcileMemcached(mgr.GetClient(), mgr.GetScheme(), k8s.New(mgr.GetClient(), mgr.GetScheme()))
+	client := utils.NewEventRecordingClient(mgr.GetClient(), mgr.GetEventRecorderFor("memcached-controller"))
+	return NewReconcileMemcached(client, mgr.GetScheme(), k8s.New(client, mgr.GetScheme()))
 }
 
 func NewReconcileMemcached(client client.Client, scheme *runtime.Scheme, kubernetes *k8s.Kubernetes) *ReconcileMemcached {
@@ -139,6 +140,9 @@ func (r *ReconcileMemcached) updateStatus(memcachedCR *contrail.Memcached, deplo
 	port := memcachedCR.Spec.ServiceConfiguration.GetListenPort()
 	memcachedCR.Status.Endpoint = fmt.Sprintf("%s:%d", ip, port)
 	memcachedCR.Status.Status.FromDeployment(deployment)
+	if err := contrail.SetWorkloadConditions(r.client, &memcachedCR.Status.Conditions, memcachedCR.Generation, deployment.Namespace, deployment.Spec.Selector, memcachedCR.Status.Replicas, memcachedCR.Status.ReadyReplicas); err != nil {
+		return err
+	}
 	return r.client.Status().Update(context.Background(), memcachedCR)
 }
 
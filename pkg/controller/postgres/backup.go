@@ -0,0 +1,403 @@
+package postgres
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"text/template"
+
+	apps "k8s.io/api/apps/v1"
+	batch "k8s.io/api/batch/v1"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
+	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	contrail "github.com/Juniper/contrail-operator/pkg/apis/contrail/v1alpha1"
+	"github.com/Juniper/contrail-operator/pkg/job"
+)
+
+const (
+	defaultBackupStoragePath = "/mnt/postgres-backup"
+	defaultBackupStorageSize = "10Gi"
+	localStorageClassName    = "local-storage"
+	backupMountPath          = "/backup"
+)
+
+var dumpCommandTemplate = template.Must(template.New("").Parse(`set -e
+mkdir -p /backup/dump
+name=$(date -u +%Y%m%d%H%M%S)
+pg_dumpall -h "$PGHOST" -p "$PGPORT" -U root | gzip > "/backup/dump/.$name.sql.gz"
+mv "/backup/dump/.$name.sql.gz" "/backup/dump/$name.sql.gz"
+ls -1 /backup/dump | grep '\.sql\.gz$' | sort -r | tail -n +{{ .Skip }} | while read f; do rm -f "/backup/dump/$f"; done
+{{- if .MaxAgeDays }}
+ls -1 /backup/dump | grep '\.sql\.gz$' | sort -r | tail -n +2 | while read f; do find "/backup/dump/$f" -mtime +{{ .MaxAgeDays }} -delete; done
+{{- end }}
+`))
+
+var baseBackupCommandTemplate = template.Must(template.New("").Parse(`set -e
+mkdir -p /backup/base
+name=$(date -u +%Y%m%d%H%M%S)
+rm -rf /backup/base/.[0-9]*
+pg_basebackup -h "$PGHOST" -p "$PGPORT" -U standby -D "/backup/base/.$name" -Ft -z -X stream
+mv "/backup/base/.$name" "/backup/base/$name"
+ls -1 /backup/base | sort -r | tail -n +{{ .Skip }} | while read b; do rm -rf "/backup/base/$b"; done
+{{- if .MaxAgeDays }}
+ls -1 /backup/base | sort -r | tail -n +2 | while read b; do find "/backup/base/$b" -maxdepth 0 -mtime +{{ .MaxAgeDays }} -exec rm -rf {} +; done
+{{- end }}
+oldest=$(ls -1 /backup/base | sort | head -n 1)
+start=$(tar -xzOf "/backup/base/$oldest/base.tar.gz" backup_label | sed -n 's/^START WAL LOCATION: .* (file \(.*\))$/\1/p')
+if [ -d /backup/wal ] && [ -n "$start" ]; then pg_archivecleanup /backup/wal "$start"; fi
+`))
+
+const walArchiverCommand = `mkdir -p /backup/wal && exec pg_receivewal -h "$PGHOST" -p "$PGPORT" -U standby -D /backup/wal`
+
+// restoreCommandTemplate prepares the data directory of the first pod from a
+// base backup. The archived write-ahead log is copied next to the one of the
+// backup, so that the new leader replays it up to the target when it starts.
+var restoreCommandTemplate = template.Must(template.New("").Parse(`set -e
+data=/pgdata/postgres
+if [ -f "$data/PG_VERSION" ]; then exit 0; fi
+{{- if .BaseBackup }}
+backup={{ .BaseBackup }}
+{{- else }}
+backup=$(ls -1 /backup/base | sort | tail -n 1)
+{{- end }}
+rm -rf "$data" && mkdir -p "$data/pg_wal" && chmod 0750 "$data"
+tar -xzf "/backup/base/$backup/base.tar.gz" -C "$data"
+tar -xzf "/backup/base/$backup/pg_wal.tar.gz" -C "$data/pg_wal"
+if [ -d /backup/wal ]; then
+  for f in /backup/wal/*; do
+    [ -f "$f" ] || continue
+    case "$f" in
+      *.partial) cp "$f" "$data/pg_wal/$(basename "$f" .partial)" ;;
+      *) cp "$f" "$data/pg_wal/" ;;
+    esac
+  done
+fi
+cat >> "$data/postgresql.auto.conf" <<EOF
+restore_command = 'false'
+recovery_target_timeline = 'latest'
+recovery_target_action = 'promote'
+{{- if .TargetTime }}
+recovery_target_time = '{{ .TargetTime }}'
+{{- end }}
+EOF
+touch "$data/recovery.signal"
+`))
+
+func backupClaimName(postgres *contrail.Postgres) string {
+	return postgres.Name + "-postgres-backup"
+}
+
+// ensureBackupExists creates CronJobs backing up the databases through the leader
+// service and the Deployment archiving its write-ahead log. They are removed
+// when backups are no longer configured, the backup claim is kept.
+func (r *ReconcilePostgres) ensureBackupExists(postgres *contrail.Postgres, leaderIP, replicationPassSecretName, rootPassSecretName string) error {
+	backup := postgres.Spec.ServiceConfiguration.Backup
+	dump := &batchv1beta1.CronJob{ObjectMeta: meta.ObjectMeta{Name: postgres.Name + "-postgres-dump", Namespace: postgres.Namespace}}
+	baseBackup := &batchv1beta1.CronJob{ObjectMeta: meta.ObjectMeta{Name: postgres.Name + "-postgres-basebackup", Namespace: postgres.Namespace}}
+	walArchiver := &apps.Deployment{ObjectMeta: meta.ObjectMeta{Name: postgres.Name + "-postgres-wal-archiver", Namespace: postgres.Namespace}}
+	if backup == nil || (backup.DumpSchedule == "" && backup.BaseBackupSchedule == "") {
+		return r.deleteIfExist(dump, baseBackup, walArchiver)
+	}
+	if err := r.ensureBackupClaimExists(postgres, backup); err != nil {
+		return err
+	}
+	env := backupEnv(postgres, leaderIP)
+	rootEnv := append(env, secretEnv("PGPASSWORD", rootPassSecretName, "password"))
+	replicationEnv := append(env, secretEnv("PGPASSWORD", replicationPassSecretName, "replication-password"))
+	retention := backup.Retention
+
+	if backup.DumpSchedule == "" {
+		if err := r.deleteIfExist(dump); err != nil {
+			return err
+		}
+	} else {
+		command, err := render(dumpCommandTemplate, map[string]int32{"Skip": backup.DumpsToRetain() + 1, "MaxAgeDays": retention.MaxAgeDays})
+		if err != nil {
+			return err
+		}
+		if err := r.ensureCronJobExists(postgres, dump, backup.DumpSchedule, r.backupPodSpec(postgres, backup, "dump", command, rootEnv)); err != nil {
+			return err
+		}
+	}
+
+	if backup.BaseBackupSchedule == "" {
+		return r.deleteIfExist(baseBackup, walArchiver)
+	}
+	command, err := render(baseBackupCommandTemplate, map[string]int32{"Skip": backup.BaseBackupsToRetain() + 1, "MaxAgeDays": retention.MaxAgeDays})
+	if err != nil {
+		return err
+	}
+	if err := r.ensureCronJobExists(postgres, baseBackup, backup.BaseBackupSchedule, r.backupPodSpec(postgres, backup, "basebackup", command, replicationEnv)); err != nil {
+		return err
+	}
+	_, err = controllerutil.CreateOrUpdate(context.Background(), r.client, walArchiver, func() error {
+		labels := map[string]string{"postgres-wal-archiver": postgres.Name}
+		replicas := int32(1)
+		walArchiver.Spec.Replicas = &replicas
+		walArchiver.Spec.Strategy = apps.DeploymentStrategy{Type: apps.RecreateDeploymentStrategyType}
+		walArchiver.Spec.Selector = &meta.LabelSelector{MatchLabels: labels}
+		walArchiver.Spec.Template.Labels = labels
+		walArchiver.Spec.Template.Spec = r.backupPodSpec(postgres, backup, "wal-archiver", walArchiverCommand, replicationEnv)
+		walArchiver.Spec.Template.Spec.RestartPolicy = core.RestartPolicyAlways
+		return controllerutil.SetControllerReference(postgres, walArchiver, r.scheme)
+	})
+	return err
+}
+
+// ensureBackupClaimExists creates the claim with backups. The claim is not owned
+// by the Postgres, so that the cluster can be restored once it is deleted.
+func (r *ReconcilePostgres) ensureBackupClaimExists(postgres *contrail.Postgres, backup *contrail.PostgresBackup) error {
+	size := backup.Storage.Size
+	if size == "" {
+		size = defaultBackupStorageSize
+	}
+	storage, err := resource.ParseQuantity(size)
+	if err != nil {
+		return err
+	}
+	storageClassName := backup.StorageClassName
+	if storageClassName == "" {
+		storageClassName = localStorageClassName
+	}
+	labels := map[string]string{"postgres-backup": postgres.Name}
+	claim := &core.PersistentVolumeClaim{
+		ObjectMeta: meta.ObjectMeta{
+			Name:      backupClaimName(postgres),
+			Namespace: postgres.Namespace,
+			Labels:    labels,
+		},
+		Spec: core.PersistentVolumeClaimSpec{
+			AccessModes:      []core.PersistentVolumeAccessMode{core.ReadWriteOnce},
+			StorageClassName: &storageClassName,
+			Resources: core.ResourceRequirements{
+				Requests: core.ResourceList{core.ResourceStorage: storage},
+			},
+		},
+	}
+	if storageClassName == localStorageClassName {
+		path := backup.Storage.Path
+		if path == "" {
+			path = defaultBackupStoragePath
+		}
+		name := fmt.Sprintf("%v-%v-postgres-backup", postgres.Name, postgres.Namespace)
+		lv, err := r.volumes.New(name, path, storage, labels, backupNodeSelector(postgres, backup))
+		if err != nil {
+			return err
+		}
+		if err := lv.EnsureExists(); err != nil {
+			return err
+		}
+		claim.Spec.Selector = &meta.LabelSelector{MatchLabels: labels}
+	}
+	err = r.client.Get(context.TODO(), types.NamespacedName{Name: claim.Name, Namespace: claim.Namespace}, &core.PersistentVolumeClaim{})
+	if errors.IsNotFound(err) {
+		return r.client.Create(context.TODO(), claim)
+	}
+	return err
+}
+
+func (r *ReconcilePostgres) ensureCronJobExists(postgres *contrail.Postgres, cronJob *batchv1beta1.CronJob, schedule string, podSpec core.PodSpec) error {
+	_, err := controllerutil.CreateOrUpdate(context.Background(), r.client, cronJob, func() error {
+		backoffLimit := int32(2)
+		successfulJobs := int32(1)
+		failedJobs := int32(3)
+		cronJob.Spec.Schedule = schedule
+		cronJob.Spec.ConcurrencyPolicy = batchv1beta1.ForbidConcurrent
+		cronJob.Spec.SuccessfulJobsHistoryLimit = &successfulJobs
+		cronJob.Spec.FailedJobsHistoryLimit = &failedJobs
+		cronJob.Spec.JobTemplate.Spec.BackoffLimit = &backoffLimit
+		cronJob.Spec.JobTemplate.Spec.Template.Spec = podSpec
+		return controllerutil.SetControllerReference(postgres, cronJob, r.scheme)
+	})
+	return err
+}
+
+// restore bootstraps the data of the first pod of a new cluster from a base backup.
+// It returns true once the data is in place or the cluster already exists.
+func (r *ReconcilePostgres) restore(postgres *contrail.Postgres) (bool, error) {
+	restore := postgres.Spec.ServiceConfiguration.Restore
+	if restore == nil {
+		return true, nil
+	}
+	err := r.client.Get(context.TODO(), types.NamespacedName{Name: postgres.Name + "-statefulset", Namespace: postgres.Namespace}, &apps.StatefulSet{})
+	if err == nil || !errors.IsNotFound(err) {
+		return err == nil, err
+	}
+	if err := r.ensureDataClaimExists(postgres); err != nil {
+		return false, err
+	}
+	restoreJob := &batch.Job{}
+	err = r.client.Get(context.TODO(), types.NamespacedName{Name: postgres.Name + "-postgres-restore", Namespace: postgres.Namespace}, restoreJob)
+	if errors.IsNotFound(err) {
+		restoreJob, err = r.restoreJob(postgres, restore)
+		if err != nil {
+			return false, err
+		}
+		log.Info("Restoring Postgres from backup", "Name", postgres.Name, "Claim", restore.BackupClaimName)
+		return false, r.client.Create(context.TODO(), restoreJob)
+	}
+	if err != nil {
+		return false, err
+	}
+	if job.Job(*restoreJob).JobFailed() {
+		return false, fmt.Errorf("job %s failed to restore Postgres %s", restoreJob.Name, postgres.Name)
+	}
+	return job.Job(*restoreJob).JobCompleted(), nil
+}
+
+// ensureDataClaimExists creates the claim of the first pod of the StatefulSet
+// in advance, so that the restore job can fill it before the pod starts.
+func (r *ReconcilePostgres) ensureDataClaimExists(postgres *contrail.Postgres) error {
+	storageClassName := localStorageClassName
+	claim := &core.PersistentVolumeClaim{
+		ObjectMeta: meta.ObjectMeta{
+			Name:      "pgdata-" + postgres.Name + "-statefulset-0",
+			Namespace: postgres.Namespace,
+			Labels:    postgres.Labels,
+		},
+		Spec: core.PersistentVolumeClaimSpec{
+			AccessModes:      []core.PersistentVolumeAccessMode{core.ReadWriteOnce},
+			Selector:         &meta.LabelSelector{MatchLabels: postgres.Labels},
+			StorageClassName: &storageClassName,
+			Resources: core.ResourceRequirements{
+				Requests: core.ResourceList{core.ResourceStorage: resource.MustParse("5Gi")},
+			},
+		},
+	}
+	err := r.client.Get(context.TODO(), types.NamespacedName{Name: claim.Name, Namespace: claim.Namespace}, &core.PersistentVolumeClaim{})
+	if errors.IsNotFound(err) {
+		return r.client.Create(context.TODO(), claim)
+	}
+	return err
+}
+
+func (r *ReconcilePostgres) restoreJob(postgres *contrail.Postgres, restore *contrail.PostgresRestore) (*batch.Job, error) {
+	command, err := render(restoreCommandTemplate, restore)
+	if err != nil {
+		return nil, err
+	}
+	postgresUID := int64(999)
+	backoffLimit := int32(2)
+	restoreJob := &batch.Job{
+		ObjectMeta: meta.ObjectMeta{
+			Name:      postgres.Name + "-postgres-restore",
+			Namespace: postgres.Namespace,
+		},
+		Spec: batch.JobSpec{
+			BackoffLimit: &backoffLimit,
+			Template: core.PodTemplateSpec{
+				Spec: core.PodSpec{
+					RestartPolicy: core.RestartPolicyNever,
+					NodeSelector:  postgres.Spec.CommonConfiguration.NodeSelector,
+					Tolerations:   postgres.Spec.CommonConfiguration.Tolerations,
+					SecurityContext: &core.PodSecurityContext{
+						RunAsUser: &postgresUID,
+						FSGroup:   &postgresUID,
+					},
+					Containers: []core.Container{{
+						Name:            "restore",
+						Image:           getImage(postgres.Spec.ServiceConfiguration.Containers, "backup"),
+						ImagePullPolicy: core.PullIfNotPresent,
+						Command:         []string{"/bin/sh", "-c", command},
+						VolumeMounts: []core.VolumeMount{
+							{Name: "backup", MountPath: backupMountPath, ReadOnly: true},
+							{Name: "pgdata", MountPath: "/pgdata"},
+						},
+					}},
+					Volumes: []core.Volume{
+						claimVolume("backup", restore.BackupClaimName),
+						claimVolume("pgdata", "pgdata-"+postgres.Name+"-statefulset-0"),
+					},
+				},
+			},
+		},
+	}
+	return restoreJob, controllerutil.SetControllerReference(postgres, restoreJob, r.scheme)
+}
+
+func (r *ReconcilePostgres) backupPodSpec(postgres *contrail.Postgres, backup *contrail.PostgresBackup, name, command string, env []core.EnvVar) core.PodSpec {
+	postgresUID := int64(999)
+	return core.PodSpec{
+		RestartPolicy: core.RestartPolicyNever,
+		NodeSelector:  backupNodeSelector(postgres, backup),
+		Tolerations:   postgres.Spec.CommonConfiguration.Tolerations,
+		SecurityContext: &core.PodSecurityContext{
+			RunAsUser: &postgresUID,
+			FSGroup:   &postgresUID,
+		},
+		Containers: []core.Container{{
+			Name:            name,
+			Image:           getImage(postgres.Spec.ServiceConfiguration.Containers, "backup"),
+			ImagePullPolicy: core.PullIfNotPresent,
+			Command:         []string{"/bin/sh", "-c", command},
+			Env:             env,
+			VolumeMounts:    []core.VolumeMount{{Name: "backup", MountPath: backupMountPath}},
+		}},
+		Volumes: []core.Volume{claimVolume("backup", backupClaimName(postgres))},
+	}
+}
+
+func (r *ReconcilePostgres) deleteIfExist(objects ...runtime.Object) error {
+	for _, o := range objects {
+		err := r.client.Delete(context.TODO(), o, client.PropagationPolicy(meta.DeletePropagationBackground))
+		if err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+func backupNodeSelector(postgres *contrail.Postgres, backup *contrail.PostgresBackup) map[string]string {
+	if len(backup.NodeSelector) != 0 {
+		return backup.NodeSelector
+	}
+	return postgres.Spec.CommonConfiguration.NodeSelector
+}
+
+func backupEnv(postgres *contrail.Postgres, leaderIP string) []core.EnvVar {
+	port := postgres.Spec.ServiceConfiguration.ListenPort
+	if port == 0 {
+		port = 5432
+	}
+	return []core.EnvVar{
+		{Name: "PGHOST", Value: leaderIP},
+		{Name: "PGPORT", Value: fmt.Sprint(port)},
+	}
+}
+
+func secretEnv(name, secretName, key string) core.EnvVar {
+	return core.EnvVar{
+		Name: name,
+		ValueFrom: &core.EnvVarSource{
+			SecretKeyRef: &core.SecretKeySelector{
+				LocalObjectReference: core.LocalObjectReference{Name: secretName},
+				Key:                  key,
+			},
+		},
+	}
+}
+
+func claimVolume(name, claimName string) core.Volume {
+	return core.Volume{
+		Name: name,
+		VolumeSource: core.VolumeSource{
+			PersistentVolumeClaim: &core.PersistentVolumeClaimVolumeSource{ClaimName: claimName},
+		},
+	}
+}
+
+func render(t *template.Template, data interface{}) (string, error) {
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,172 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apps "k8s.io/api/apps/v1"
+	batch "k8s.io/api/batch/v1"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
+	"k8s.io/api/certificates/v1beta1"
+	core "k8s.io/api/core/v1"
+	rbac "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	contrail "github.com/Juniper/contrail-operator/pkg/apis/contrail/v1alpha1"
+)
+
+func TestPostgresBackup(t *testing.T) {
+	scheme, err := contrail.SchemeBuilder.Build()
+	require.NoError(t, err)
+	require.NoError(t, core.SchemeBuilder.AddToScheme(scheme))
+	require.NoError(t, apps.SchemeBuilder.AddToScheme(scheme))
+	require.NoError(t, batch.SchemeBuilder.AddToScheme(scheme))
+	require.NoError(t, batchv1beta1.SchemeBuilder.AddToScheme(scheme))
+	require.NoError(t, v1beta1.SchemeBuilder.AddToScheme(scheme))
+	require.NoError(t, rbac.SchemeBuilder.AddToScheme(scheme))
+
+	request := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "postgres"}}
+	newPostgres := func(backup *contrail.PostgresBackup, restore *contrail.PostgresRestore) *contrail.Postgres {
+		return &contrail.Postgres{
+			ObjectMeta: meta.ObjectMeta{Namespace: "default", Name: "postgres"},
+			Spec: contrail.PostgresSpec{
+				CommonConfiguration: contrail.PodConfiguration{
+					NodeSelector: map[string]string{"node-role.kubernetes.io/master": ""},
+				},
+				ServiceConfiguration: contrail.PostgresConfiguration{
+					RootPassSecretName: "rootpass-secret",
+					Backup:             backup,
+					Restore:            restore,
+				},
+			},
+		}
+	}
+	reconcilePostgres := func(objs ...runtime.Object) client.Client {
+		fakeClient := fake.NewFakeClientWithScheme(scheme, append(objs, newAdminSecret())...)
+		_, err := NewReconciler(fakeClient, scheme).Reconcile(request)
+		require.NoError(t, err)
+		return fakeClient
+	}
+	get := func(c client.Client, name string, obj runtime.Object) error {
+		return c.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: name}, obj)
+	}
+
+	t.Run("should create backup CronJobs, WAL archiver and backup claim", func(t *testing.T) {
+		dumps := int32(2)
+		// when
+		c := reconcilePostgres(newPostgres(&contrail.PostgresBackup{
+			DumpSchedule:       "0 1 * * *",
+			BaseBackupSchedule: "0 2 * * 0",
+			Retention:          contrail.PostgresBackupRetention{Dumps: &dumps, MaxAgeDays: 30},
+		}, nil))
+		// then
+		dump := &batchv1beta1.CronJob{}
+		require.NoError(t, get(c, "postgres-postgres-dump", dump))
+		assert.Equal(t, "0 1 * * *", dump.Spec.Schedule)
+		assert.Equal(t, batchv1beta1.ForbidConcurrent, dump.Spec.ConcurrencyPolicy)
+		dumpContainer := dump.Spec.JobTemplate.Spec.Template.Spec.Containers[0]
+		assert.Contains(t, dumpContainer.Command[2], "pg_dumpall")
+		assert.Contains(t, dumpContainer.Command[2], "tail -n +3")
+		assert.Contains(t, dumpContainer.Command[2], "-mtime +30")
+		assert.Contains(t, dumpContainer.Env, core.EnvVar{Name: "PGPORT", Value: "5432"})
+		assert.Equal(t, "rootpass-secret", dumpContainer.Env[2].ValueFrom.SecretKeyRef.Name)
+
+		baseBackup := &batchv1beta1.CronJob{}
+		require.NoError(t, get(c, "postgres-postgres-basebackup", baseBackup))
+		assert.Equal(t, "0 2 * * 0", baseBackup.Spec.Schedule)
+		baseBackupContainer := baseBackup.Spec.JobTemplate.Spec.Template.Spec.Containers[0]
+		assert.Contains(t, baseBackupContainer.Command[2], "pg_basebackup")
+		assert.Contains(t, baseBackupContainer.Command[2], "tail -n +4")
+		assert.Equal(t, "postgres-postgres-replication-secret", baseBackupContainer.Env[2].ValueFrom.SecretKeyRef.Name)
+
+		archiver := &apps.Deployment{}
+		require.NoError(t, get(c, "postgres-postgres-wal-archiver", archiver))
+		assert.Contains(t, archiver.Spec.Template.Spec.Containers[0].Command[2], "pg_receivewal")
+		assert.Equal(t, core.RestartPolicyAlways, archiver.Spec.Template.Spec.RestartPolicy)
+
+		claim := &core.PersistentVolumeClaim{}
+		require.NoError(t, get(c, "postgres-postgres-backup", claim))
+		assert.Empty(t, claim.OwnerReferences)
+		assert.Equal(t, map[string]string{"postgres-backup": "postgres"}, claim.Spec.Selector.MatchLabels)
+		pv := &core.PersistentVolume{}
+		require.NoError(t, c.Get(context.Background(), types.NamespacedName{Name: "postgres-default-postgres-backup"}, pv))
+		assert.Equal(t, defaultBackupStoragePath, pv.Spec.Local.Path)
+	})
+
+	t.Run("should run backup and restore with default image when backup container is not set", func(t *testing.T) {
+		// when
+		c := reconcilePostgres(newPostgres(&contrail.PostgresBackup{DumpSchedule: "0 1 * * *", BaseBackupSchedule: "0 2 * * 0"}, nil))
+		restoring := reconcilePostgres(newPostgres(nil, &contrail.PostgresRestore{BackupClaimName: "old-postgres-backup"}))
+		// then
+		const image = "localhost:5000/patroni:2.0.0.logical"
+		dump := &batchv1beta1.CronJob{}
+		require.NoError(t, get(c, "postgres-postgres-dump", dump))
+		assert.Equal(t, image, dump.Spec.JobTemplate.Spec.Template.Spec.Containers[0].Image)
+		baseBackup := &batchv1beta1.CronJob{}
+		require.NoError(t, get(c, "postgres-postgres-basebackup", baseBackup))
+		assert.Equal(t, image, baseBackup.Spec.JobTemplate.Spec.Template.Spec.Containers[0].Image)
+		archiver := &apps.Deployment{}
+		require.NoError(t, get(c, "postgres-postgres-wal-archiver", archiver))
+		assert.Equal(t, image, archiver.Spec.Template.Spec.Containers[0].Image)
+		restoreJob := &batch.Job{}
+		require.NoError(t, get(restoring, "postgres-postgres-restore", restoreJob))
+		assert.Equal(t, image, restoreJob.Spec.Template.Spec.Containers[0].Image)
+	})
+
+	t.Run("should remove backup CronJobs when backups are disabled", func(t *testing.T) {
+		trueVal := true
+		owner := []meta.OwnerReference{{APIVersion: "contrail.juniper.net/v1alpha1", Kind: "Postgres", Name: "postgres", Controller: &trueVal}}
+		dump := &batchv1beta1.CronJob{ObjectMeta: meta.ObjectMeta{Name: "postgres-postgres-dump", Namespace: "default", OwnerReferences: owner}}
+		archiver := &apps.Deployment{ObjectMeta: meta.ObjectMeta{Name: "postgres-postgres-wal-archiver", Namespace: "default", OwnerReferences: owner}}
+		// when
+		c := reconcilePostgres(newPostgres(nil, nil), dump, archiver)
+		// then
+		assert.True(t, errors.IsNotFound(get(c, dump.Name, &batchv1beta1.CronJob{})))
+		assert.True(t, errors.IsNotFound(get(c, archiver.Name, &apps.Deployment{})))
+	})
+
+	t.Run("should restore data of new cluster before creating StatefulSet", func(t *testing.T) {
+		restore := &contrail.PostgresRestore{BackupClaimName: "old-postgres-backup", TargetTime: "2020-11-04 10:30:00 UTC"}
+		// when
+		c := reconcilePostgres(newPostgres(nil, restore))
+		// then
+		restoreJob := &batch.Job{}
+		require.NoError(t, get(c, "postgres-postgres-restore", restoreJob))
+		command := restoreJob.Spec.Template.Spec.Containers[0].Command[2]
+		assert.Contains(t, command, "backup=$(ls -1 /backup/base | sort | tail -n 1)")
+		assert.Contains(t, command, "recovery_target_time = '2020-11-04 10:30:00 UTC'")
+		assert.Equal(t, "old-postgres-backup", restoreJob.Spec.Template.Spec.Volumes[0].PersistentVolumeClaim.ClaimName)
+		assert.Equal(t, "pgdata-postgres-statefulset-0", restoreJob.Spec.Template.Spec.Volumes[1].PersistentVolumeClaim.ClaimName)
+		require.NoError(t, get(c, "pgdata-postgres-statefulset-0", &core.PersistentVolumeClaim{}))
+		assert.True(t, errors.IsNotFound(get(c, "postgres-statefulset", &apps.StatefulSet{})))
+		postgres := &contrail.Postgres{}
+		require.NoError(t, get(c, "postgres", postgres))
+		ready := contrail.FindCondition(postgres.Status.Conditions, contrail.ConditionReady)
+		require.NotNil(t, ready)
+		assert.Equal(t, contrail.ReasonRestoring, ready.Reason)
+	})
+
+	t.Run("should create StatefulSet once data is restored", func(t *testing.T) {
+		restore := &contrail.PostgresRestore{BackupClaimName: "old-postgres-backup", BaseBackup: "20201104000000"}
+		trueVal := true
+		restoreJob := &batch.Job{
+			ObjectMeta: meta.ObjectMeta{
+				Name: "postgres-postgres-restore", Namespace: "default",
+				OwnerReferences: []meta.OwnerReference{{APIVersion: "contrail.juniper.net/v1alpha1", Kind: "Postgres", Name: "postgres", Controller: &trueVal}},
+			},
+			Status: batch.JobStatus{Conditions: []batch.JobCondition{{Type: batch.JobComplete, Status: core.ConditionTrue}}},
+		}
+		// when
+		c := reconcilePostgres(newPostgres(nil, restore), restoreJob)
+		// then
+		require.NoError(t, get(c, "postgres-statefulset", &apps.StatefulSet{}))
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	apps "k8s.io/api/apps/v1"
+	batch "k8s.io/api/batch/v1"
 	core "k8s.io/api/core/v1"
 	rbac "k8s.io/api/rbac/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
@@ -43,11 +44,12 @@ func Add(mgr manager.Manager) error {
 
 // newReconciler returns a new reconcile.Reconciler
 func newReconciler(mgr manager.Manager) reconcile.Reconciler {
+	client := utils.NewEventRecordingClient(mgr.GetClient(), mgr.GetEventRecorderFor("postgres-controller"))
 	return &ReconcilePostgres{
-		client:     mgr.GetClient(),
+		client:     client,
 		scheme:     mgr.GetScheme(),
-		kubernetes: k8s.New(mgr.GetClient(), mgr.GetScheme()),
-		volumes:    localvolume.New(mgr.GetClient()),
+		kubernetes: k8s.New(client, mgr.GetScheme()),
+		volumes:    localvolume.New(client),
 	}
 }
 
@@ -78,6 +80,15 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 		IsController: true,
 		OwnerType:    &contrail.Postgres{},
 	})
+	if err != nil {
+		return err
+	}
+
+	// Watch for the restore Job to create the StatefulSet once the Job completes
+	err = c.Watch(&source.Kind{Type: &batch.Job{}}, &handler.EnqueueRequestForOwner{
+		IsController: true,
+		OwnerType:    &contrail.Postgres{},
+	})
 
 	return err
 }
@@ -171,6 +182,16 @@ func (r *ReconcilePostgres) Reconcile(request reconcile.Request) (reconcile.Resu
 		return reconcile.Result{}, err
 	}
 
+	restored, err := r.restore(postgres)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if !restored {
+		postgres.Status.Active = false
+		contrail.SetActiveConditions(&postgres.Status.Conditions, postgres.Generation, false, contrail.ReasonRestoring, "restoring from backup")
+		return reconcile.Result{}, r.client.Status().Update(context.Background(), postgres)
+	}
+
 	serviceAccountName := "serviceaccount-postgres"
 	rootPassSecretName := postgres.Spec.ServiceConfiguration.RootPassSecretName
 	statefulSet, err := r.createOrUpdateSts(postgres, replicationPassSecretName, rootPassSecretName, serviceAccountName)
@@ -182,6 +203,10 @@ func (r *ReconcilePostgres) Reconcile(request reconcile.Request) (reconcile.Resu
 		return reconcile.Result{}, err
 	}
 
+	if err = r.ensureBackupExists(postgres, leaderClusterIP, replicationPassSecretName, rootPassSecretName); err != nil {
+		return reconcile.Result{}, err
+	}
+
 	postgres.Status.Endpoint = leaderClusterIP
 	postgres.Status.Active = false
 	intendentReplicas := int32(1)
@@ -192,6 +217,9 @@ func (r *ReconcilePostgres) Reconcile(request reconcile.Request) (reconcile.Resu
 	if statefulSet.Status.ReadyReplicas == intendentReplicas {
 		postgres.Status.Active = true
 	}
+	if err := contrail.SetWorkloadConditions(r.client, &postgres.Status.Conditions, postgres.Generation, statefulSet.Namespace, statefulSet.Spec.Selector, intendentReplicas, statefulSet.Status.ReadyReplicas); err != nil {
+		return reconcile.Result{}, err
+	}
 
 	return reconcile.Result{}, r.client.Status().Update(context.Background(), postgres)
 }
@@ -208,7 +236,7 @@ func (r *ReconcilePostgres) ensureLabelExists(p *contrail.Postgres) error {
 
 func (r *ReconcilePostgres) ensureCertificatesExist(postgres *contrail.Postgres, pods *core.PodList, serviceIP string) error {
 	subjects := postgres.PodsCertSubjects(pods, serviceIP)
-	crt := certificates.NewCertificate(r.client, r.scheme, postgres, subjects, "postgres")
+	crt := certificates.NewCertificate(r.client, r.scheme, postgres, subjects, "postgres", utils.CertificateKeyConfig(postgres.Spec.CommonConfiguration.CertificateKey))
 	return crt.EnsureExistsAndIsSigned()
 }
 
@@ -758,6 +786,9 @@ func getImage(containers []*contrail.Container, containerName string) string {
 		"patroni":             "localhost:5000/patroni:2.0.0.logical",
 		"init":                "localhost:5000/busybox:1.31",
 		"wait-for-ready-conf": "localhost:5000/busybox:1.31",
+		// backup runs the Postgres client tools of the patroni image,
+		// so that they match the version of the server
+		"backup": "localhost:5000/patroni:2.0.0.logical",
 	}
 	c := utils.GetContainerFromList(containerName, containers)
 	if c == nil {
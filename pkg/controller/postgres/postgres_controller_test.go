@@ -8,6 +8,8 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	apps "k8s.io/api/apps/v1"
+	batch "k8s.io/api/batch/v1"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
 	"k8s.io/api/certificates/v1beta1"
 	core "k8s.io/api/core/v1"
 	rbac "k8s.io/api/rbac/v1"
@@ -30,12 +32,14 @@ import (
 
 	contrail "github.com/Juniper/contrail-operator/pkg/apis/contrail/v1alpha1"
 	"github.com/Juniper/contrail-operator/pkg/certificates"
+	"github.com/Juniper/contrail-operator/pkg/controller/utils"
 	"github.com/Juniper/contrail-operator/pkg/k8s"
 	contraillabel "github.com/Juniper/contrail-operator/pkg/label"
 	"github.com/Juniper/contrail-operator/pkg/localvolume"
 )
 
 func TestNewReconciler(t *testing.T) {
+	client := utils.NewEventRecordingClient(nil, nil)
 	newReconcilerCases := map[string]struct {
 		manager            *mockManager
 		expectedReconciler *ReconcilePostgres
@@ -43,10 +47,10 @@ func TestNewReconciler(t *testing.T) {
 		"empty manager": {
 			manager: &mockManager{},
 			expectedReconciler: &ReconcilePostgres{
-				client:     nil,
+				client:     client,
 				scheme:     nil,
-				kubernetes: k8s.New(nil, nil),
-				volumes:    localvolume.New(nil),
+				kubernetes: k8s.New(client, nil),
+				volumes:    localvolume.New(client),
 			},
 		},
 	}
@@ -88,6 +92,8 @@ func TestPostgresController(t *testing.T) {
 	require.NoError(t, err)
 	require.NoError(t, core.SchemeBuilder.AddToScheme(scheme))
 	require.NoError(t, apps.SchemeBuilder.AddToScheme(scheme))
+	require.NoError(t, batch.SchemeBuilder.AddToScheme(scheme))
+	require.NoError(t, batchv1beta1.SchemeBuilder.AddToScheme(scheme))
 	require.NoError(t, v1beta1.SchemeBuilder.AddToScheme(scheme))
 	assert.NoError(t, rbac.SchemeBuilder.AddToScheme(scheme))
 
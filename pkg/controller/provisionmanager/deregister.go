@@ -0,0 +1,69 @@
+package provisionmanager
+
+import (
+	"context"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/Juniper/contrail-operator/pkg/apis/contrail/v1alpha1"
+	"github.com/Juniper/contrail-operator/pkg/controller/utils"
+	"github.com/Juniper/contrail-operator/pkg/k8s"
+)
+
+// deregisterTimeout is how long after the deletion of the instance failing
+// deregistration is retried, before the finalizer is removed anyway.
+const deregisterTimeout = 10 * time.Minute
+
+// deregisterCommand runs the provisioner once with empty node lists,
+// so that all nodes managed by the provisioner are removed from Contrail.
+var deregisterCommand = []string{"sh", "-c",
+	`/app/contrail-provisioner/contrail-provisioner-image.binary \
+		-controlNodes /dev/null \
+		-configNodes /dev/null \
+		-analyticsNodes /dev/null \
+		-vrouterNodes /dev/null \
+		-databaseNodes /dev/null \
+		-apiserver /etc/provision/apiserver/apiserver-${POD_IP}.yaml \
+		-keystoneAuthConf /etc/provision/keystone/keystone-auth-${POD_IP}.yaml \
+		-mode run`,
+}
+
+var execToPod = k8s.ExecToPodThroughAPI
+
+// deregisterNodes removes nodes provisioned by the instance from Contrail using
+// one of the running provisioner pods. Nothing is done when no pod is running,
+// as there is no way to reach Contrail then. When deregistration keeps failing
+// for deregisterTimeout, e.g. because the config nodes are already gone, a
+// warning event is recorded and nil is returned, so that deletion can complete.
+func (r *ReconcileProvisionManager) deregisterNodes(instance *v1alpha1.ProvisionManager) error {
+	err := r.execDeregister(instance)
+	if err == nil || time.Since(instance.DeletionTimestamp.Time) < deregisterTimeout {
+		return err
+	}
+	r.Recorder.Eventf(instance, corev1.EventTypeWarning, utils.ReasonDeregisterFailed,
+		"Gave up deregistering nodes after %s, they have to be removed from Contrail manually: %v", deregisterTimeout, err)
+	return nil
+}
+
+func (r *ReconcileProvisionManager) execDeregister(instance *v1alpha1.ProvisionManager) error {
+	pods := &corev1.PodList{}
+	labels := client.MatchingLabels{"contrail_manager": "provisionmanager", "provisionmanager": instance.Name}
+	if err := r.Client.List(context.TODO(), pods, client.InNamespace(instance.Namespace), labels); err != nil {
+		return err
+	}
+	for _, pod := range pods.Items {
+		if pod.Status.Phase != corev1.PodRunning || !pod.DeletionTimestamp.IsZero() {
+			continue
+		}
+		log.Info("Deregistering nodes", "Pod", pod.Name)
+		_, stderr, err := execToPod(deregisterCommand, "provisioner", pod.Name, pod.Namespace, nil)
+		if err != nil {
+			log.Error(err, "Failed to deregister nodes", "Pod", pod.Name, "Stderr", stderr)
+		}
+		return err
+	}
+	log.Info("No running provisioner pod, skipping deregistration of nodes", "ProvisionManager", instance.Name)
+	return nil
+}
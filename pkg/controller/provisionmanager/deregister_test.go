@@ -0,0 +1,107 @@
+package provisionmanager
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	core "k8s.io/api/core/v1"
+	meta1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	contrail "github.com/Juniper/contrail-operator/pkg/apis/contrail/v1alpha1"
+	"github.com/Juniper/contrail-operator/pkg/controller/utils"
+)
+
+func TestDeregisterNodesOnDeletion(t *testing.T) {
+	scheme, err := contrail.SchemeBuilder.Build()
+	require.NoError(t, err)
+	require.NoError(t, core.SchemeBuilder.AddToScheme(scheme))
+	now := meta1.Now()
+	instance := &contrail.ProvisionManager{ObjectMeta: meta1.ObjectMeta{
+		Name: "provisionmanager", Namespace: "default",
+		DeletionTimestamp: &now, Finalizers: []string{utils.CleanupFinalizer},
+	}}
+	pod := &core.Pod{
+		ObjectMeta: meta1.ObjectMeta{
+			Name: "provisionmanager-provisionmanager-statefulset-0", Namespace: "default",
+			Labels: map[string]string{"contrail_manager": "provisionmanager", "provisionmanager": "provisionmanager"},
+		},
+		Status: core.PodStatus{Phase: core.PodRunning},
+	}
+	deletedLongAgo := instance.DeepCopy()
+	deletedLongAgo.DeletionTimestamp = &meta1.Time{Time: now.Add(-deregisterTimeout - time.Minute)}
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: "provisionmanager", Namespace: "default"}}
+	var execs []string
+	defer func(orig func([]string, string, string, string, io.Reader) (string, string, error)) { execToPod = orig }(execToPod)
+	execErr := error(nil)
+	execToPod = func(command []string, containerName, podName, namespace string, stdin io.Reader) (string, string, error) {
+		execs = append(execs, containerName+"@"+podName)
+		return "", "", execErr
+	}
+
+	tests := []struct {
+		name            string
+		objs            []runtime.Object
+		execErr         error
+		expectedExecs   []string
+		expectFinalizer bool
+		expectedErr     error
+		expectedEvent   bool
+	}{
+		{
+			name:          "should deregister nodes and remove finalizer",
+			objs:          []runtime.Object{instance.DeepCopy(), pod.DeepCopy()},
+			expectedExecs: []string{"provisioner@provisionmanager-provisionmanager-statefulset-0"},
+		},
+		{
+			name: "should remove finalizer when no provisioner pod is running",
+			objs: []runtime.Object{instance.DeepCopy()},
+		},
+		{
+			name:            "should keep finalizer when deregistration fails",
+			objs:            []runtime.Object{instance.DeepCopy(), pod.DeepCopy()},
+			execErr:         errors.New("exit code 1"),
+			expectedExecs:   []string{"provisioner@provisionmanager-provisionmanager-statefulset-0"},
+			expectFinalizer: true,
+			expectedErr:     errors.New("exit code 1"),
+		},
+		{
+			name:          "should give up deregistration failing after timeout and remove finalizer",
+			objs:          []runtime.Object{deletedLongAgo, pod.DeepCopy()},
+			execErr:       errors.New("exit code 1"),
+			expectedExecs: []string{"provisioner@provisionmanager-provisionmanager-statefulset-0"},
+			expectedEvent: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			execs, execErr = nil, tt.execErr
+			cl := fake.NewFakeClientWithScheme(scheme, tt.objs...)
+			recorder := record.NewFakeRecorder(10)
+			r := &ReconcileProvisionManager{Client: cl, Scheme: scheme, Recorder: recorder}
+			// when
+			_, err := r.Reconcile(req)
+			// then
+			assert.Equal(t, tt.expectedErr, err)
+			if tt.expectedEvent {
+				require.Len(t, recorder.Events, 1)
+				assert.Contains(t, <-recorder.Events, "Warning DeregisterFailed Gave up deregistering nodes")
+			} else {
+				assert.Empty(t, recorder.Events)
+			}
+			assert.Equal(t, tt.expectedExecs, execs)
+			pm := &contrail.ProvisionManager{}
+			require.NoError(t, cl.Get(context.Background(), req.NamespacedName, pm))
+			assert.Equal(t, tt.expectFinalizer, utils.HasFinalizer(pm, utils.CleanupFinalizer))
+		})
+	}
+}
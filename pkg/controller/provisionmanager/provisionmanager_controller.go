@@ -14,6 +14,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/workqueue"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
@@ -105,7 +106,9 @@ func Add(mgr manager.Manager) error {
 
 // newReconciler returns a new reconcile.Reconciler
 func newReconciler(mgr manager.Manager) reconcile.Reconciler {
-	return &ReconcileProvisionManager{Client: mgr.GetClient(), Scheme: mgr.GetScheme(), Manager: mgr}
+	recorder := mgr.GetEventRecorderFor("provisionmanager-controller")
+	client := utils.NewEventRecordingClient(mgr.GetClient(), recorder)
+	return &ReconcileProvisionManager{Client: client, Scheme: mgr.GetScheme(), Manager: mgr, Recorder: recorder}
 }
 
 // add adds a new Controller to mgr with r as the reconcile.Reconciler
@@ -170,9 +173,10 @@ var _ reconcile.Reconciler = &ReconcileProvisionManager{}
 type ReconcileProvisionManager struct {
 	// This client, initialized using mgr.Client() above, is a split client
 	// that reads objects from the cache and writes to the apiserver
-	Client  client.Client
-	Scheme  *runtime.Scheme
-	Manager manager.Manager
+	Client   client.Client
+	Scheme   *runtime.Scheme
+	Manager  manager.Manager
+	Recorder record.EventRecorder
 }
 
 func (r *ReconcileProvisionManager) Reconcile(request reconcile.Request) (reconcile.Result, error) {
@@ -189,7 +193,17 @@ func (r *ReconcileProvisionManager) Reconcile(request reconcile.Request) (reconc
 	}
 
 	if !instance.GetDeletionTimestamp().IsZero() {
-		return reconcile.Result{}, nil
+		if !utils.HasFinalizer(instance, utils.CleanupFinalizer) {
+			return reconcile.Result{}, nil
+		}
+		if err = r.deregisterNodes(instance); err != nil {
+			return reconcile.Result{}, err
+		}
+		return reconcile.Result{}, utils.RemoveFinalizer(r.Client, instance, utils.CleanupFinalizer)
+	}
+
+	if err = utils.EnsureFinalizer(r.Client, instance, utils.CleanupFinalizer); err != nil {
+		return reconcile.Result{}, err
 	}
 
 	configMapConfigNodes, err := instance.CreateConfigMap(request.Name+"-"+instanceType+"-configmap-confignodes", r.Client, r.Scheme, request)
@@ -396,7 +410,7 @@ func (r *ReconcileProvisionManager) Reconcile(request reconcile.Request) (reconc
 
 func (r *ReconcileProvisionManager) ensureCertificatesExist(provision *v1alpha1.ProvisionManager, pods *corev1.PodList, instanceType string) error {
 	subjects := provision.PodsCertSubjects(pods)
-	crt := certificates.NewCertificate(r.Client, r.Scheme, provision, subjects, instanceType)
+	crt := certificates.NewCertificate(r.Client, r.Scheme, provision, subjects, instanceType, utils.CertificateKeyConfig(provision.Spec.CommonConfiguration.CertificateKey))
 	return crt.EnsureExistsAndIsSigned()
 }
 
@@ -203,7 +203,7 @@ func TestProvisionManagerController(t *testing.T) {
 		}
 
 		cl := fake.NewFakeClientWithScheme(scheme, initObjs...)
-		caCertificate := certificates.NewCACertificate(cl, scheme, pmr, "provisionmanager")
+		caCertificate := certificates.NewCACertificate(cl, scheme, pmr, "provisionmanager", certificates.KeyConfig{})
 		assert.NoError(t, caCertificate.EnsureExists())
 
 		r := &ReconcileProvisionManager{Client: cl, Scheme: scheme}
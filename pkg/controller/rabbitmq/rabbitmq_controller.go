@@ -93,7 +93,8 @@ func Add(mgr manager.Manager) error {
 }
 
 func newReconciler(mgr manager.Manager) reconcile.Reconciler {
-	return &ReconcileRabbitmq{Client: mgr.GetClient(), Scheme: mgr.GetScheme(), Manager: mgr}
+	client := utils.NewEventRecordingClient(mgr.GetClient(), mgr.GetEventRecorderFor("rabbitmq-controller"))
+	return &ReconcileRabbitmq{Client: client, Scheme: mgr.GetScheme(), Manager: mgr}
 }
 
 // add adds a new Controller to mgr with r as the reconcile.Reconciler.
@@ -352,6 +353,6 @@ func (r *ReconcileRabbitmq) Reconcile(request reconcile.Request) (reconcile.Resu
 
 func (r *ReconcileRabbitmq) ensureCertificatesExist(rabbitmq *v1alpha1.Rabbitmq, pods *corev1.PodList, instanceType string) error {
 	subjects := rabbitmq.PodsCertSubjects(pods)
-	crt := certificates.NewCertificate(r.Client, r.Scheme, rabbitmq, subjects, instanceType)
+	crt := certificates.NewCertificate(r.Client, r.Scheme, rabbitmq, subjects, instanceType, utils.CertificateKeyConfig(rabbitmq.Spec.CommonConfiguration.CertificateKey))
 	return crt.EnsureExistsAndIsSigned()
 }
@@ -4,12 +4,12 @@ import (
 	"context"
 	"time"
 
-	batch "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/rest"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
@@ -21,13 +21,18 @@ import (
 
 	contrail "github.com/Juniper/contrail-operator/pkg/apis/contrail/v1alpha1"
 	"github.com/Juniper/contrail-operator/pkg/controller/utils"
-	"github.com/Juniper/contrail-operator/pkg/job"
 	"github.com/Juniper/contrail-operator/pkg/k8s"
 	"github.com/Juniper/contrail-operator/pkg/swift/ring"
 )
 
 var log = logf.Log.WithName("controller_swift")
 
+const (
+	ringPartPower    = 10
+	ringReplicas     = 1
+	ringMinPartHours = 1
+)
+
 // Add creates a new Swift Controller and adds it to the Manager. The Manager will set fields on the Controller
 // and Start it when the Manager is Started.
 func Add(mgr manager.Manager) error {
@@ -36,15 +41,16 @@ func Add(mgr manager.Manager) error {
 
 // newReconciler returns a new reconcile.Reconciler
 func newReconciler(mgr manager.Manager) reconcile.Reconciler {
-	return NewReconciler(mgr.GetClient(), mgr.GetScheme())
+	return NewReconciler(utils.NewEventRecordingClient(mgr.GetClient(), mgr.GetEventRecorderFor("swift-controller")), mgr.GetScheme(), mgr.GetConfig())
 }
 
 // NewReconciler is used to create a new ReconcileSwiftProxy
-func NewReconciler(client client.Client, scheme *runtime.Scheme) *ReconcileSwift {
+func NewReconciler(client client.Client, scheme *runtime.Scheme, restConfig *rest.Config) *ReconcileSwift {
 	return &ReconcileSwift{
 		client:     client,
 		scheme:     scheme,
 		kubernetes: k8s.New(client, scheme),
+		restConfig: restConfig,
 	}
 }
 
@@ -102,6 +108,7 @@ type ReconcileSwift struct {
 	client     client.Client
 	scheme     *runtime.Scheme
 	kubernetes *k8s.Kubernetes
+	restConfig *rest.Config
 }
 
 func (r *ReconcileSwift) Reconcile(request reconcile.Request) (reconcile.Result, error) {
@@ -155,26 +162,37 @@ func (r *ReconcileSwift) Reconcile(request reconcile.Request) (reconcile.Result,
 		return reconcile.Result{}, err
 	}
 
-	if err = contrail.CreateAccount("swift", request.Namespace, r.client, r.scheme, swift); err != nil {
+	ringsResult, err := r.reconcileRings(swift, ringConfigMapName)
+	if err != nil {
 		return reconcile.Result{}, err
 	}
 
-	var result reconcile.Result
-	if result, err = r.reconcileRings(swift, ringConfigMapName); err != nil || result.Requeue {
-		return result, err
-	}
-
 	swiftProxyAndStorageActiveStatus := false
 	if err, swiftProxyAndStorageActiveStatus = r.checkSwiftProxyAndStorageActive(swift); err != nil {
 		return reconcile.Result{}, err
 	}
 	swift.Status.Active = swiftProxyAndStorageActiveStatus
+	if swift.Status.Active {
+		contrail.SetActiveConditions(&swift.Status.Conditions, swift.Generation, true, contrail.ReasonResourcesReady, "swift proxy and storage are active")
+	} else {
+		contrail.SetActiveConditions(&swift.Status.Conditions, swift.Generation, false, contrail.ReasonResourcesNotReady, "waiting for swift proxy and storage to become active")
+	}
 	swift.Status.SwiftProxyPort = swift.Spec.ServiceConfiguration.SwiftProxyConfiguration.ListenPort
 	err, swift.Status.SwiftProxyClusterIP = r.getSwiftProxyClusterIP(swift)
 	if err != nil {
 		return reconcile.Result{}, err
 	}
-	return reconcile.Result{}, r.client.Status().Update(context.Background(), swift)
+	result := ringsResult
+	if swift.Status.Active {
+		usageAfter, err := r.reconcileUsage(swift)
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+		if result.RequeueAfter == 0 || usageAfter < result.RequeueAfter {
+			result.RequeueAfter = usageAfter
+		}
+	}
+	return result, r.client.Status().Update(context.Background(), swift)
 }
 
 func (r *ReconcileSwift) getSwiftProxyClusterIP(swift *contrail.Swift) (error, string) {
@@ -274,112 +292,107 @@ func (r *ReconcileSwift) reconcileRings(swift *contrail.Swift, ringConfigMapName
 	if err := r.client.Get(context.TODO(), types.NamespacedName{Name: swift.Name + "-storage", Namespace: swift.Namespace}, swiftStorage); err != nil {
 		return reconcile.Result{}, err
 	}
-	ips := swiftStorage.Status.IPs
-	if len(ips) == 0 {
-		ips = []string{"0.0.0.0"}
-	}
-	swiftName := types.NamespacedName{
-		Namespace: swift.Namespace,
-		Name:      swift.Name,
-	}
-	if result, err := r.removeRingReconcilingJobs(swiftName); err != nil || result.Requeue {
-		return result, err
-	}
-	accountPort := swift.Spec.ServiceConfiguration.SwiftStorageConfiguration.AccountBindPort
-	if err := r.startRingReconcilingJob(ringConfigMapName, "account", accountPort, ips, swift); err != nil {
+	configMap := &corev1.ConfigMap{}
+	if err := r.client.Get(context.TODO(), types.NamespacedName{Name: ringConfigMapName, Namespace: swift.Namespace}, configMap); err != nil {
 		return reconcile.Result{}, err
 	}
-	objectPort := swift.Spec.ServiceConfiguration.SwiftStorageConfiguration.ObjectBindPort
-	if err := r.startRingReconcilingJob(ringConfigMapName, "object", objectPort, ips, swift); err != nil {
-		return reconcile.Result{}, err
-	}
-	containerPort := swift.Spec.ServiceConfiguration.SwiftStorageConfiguration.ContainerBindPort
-	if err := r.startRingReconcilingJob(ringConfigMapName, "container", containerPort, ips, swift); err != nil {
+	storageConfig := swift.Spec.ServiceConfiguration.SwiftStorageConfiguration
+	ringPorts := map[contrail.SwiftRing]int{
+		contrail.SwiftAccountRing:   storageConfig.AccountBindPort,
+		contrail.SwiftObjectRing:    storageConfig.ObjectBindPort,
+		contrail.SwiftContainerRing: storageConfig.ContainerBindPort,
+	}
+	now := time.Now()
+	balanced := true
+	_, err := controllerutil.CreateOrUpdate(context.Background(), r.client, configMap, func() error {
+		if configMap.BinaryData == nil {
+			configMap.BinaryData = map[string][]byte{}
+		}
+		for _, ringType := range []contrail.SwiftRing{contrail.SwiftAccountRing, contrail.SwiftObjectRing, contrail.SwiftContainerRing} {
+			devices := ringDevices(swiftStorage, ringType)
+			ringBalanced, err := reconcileRing(configMap.BinaryData, string(ringType), ringPorts[ringType], devices, now)
+			if err != nil {
+				return err
+			}
+			balanced = balanced && ringBalanced
+		}
+		return nil
+	})
+	if err != nil || balanced {
 		return reconcile.Result{}, err
 	}
-	return reconcile.Result{}, nil
+	// Some partitions cannot be moved before min_part_hours pass since their last move
+	return reconcile.Result{RequeueAfter: ringMinPartHours * time.Hour}, nil
 }
 
-func (r *ReconcileSwift) removeRingReconcilingJobs(swiftName types.NamespacedName) (reconcile.Result, error) {
-	var atLeastOneJobDeleted bool
-	ringTypes := []string{"account", "container", "object"}
-	for _, ringType := range ringTypes {
-		jobName := types.NamespacedName{
-			Namespace: swiftName.Namespace,
-			Name:      swiftName.Name + "-ring-" + ringType + "-job",
-		}
-		ringJob := &batch.Job{}
-		err := r.client.Get(context.Background(), jobName, ringJob)
-		existingJob := err == nil
-		if existingJob {
-			if job.Job(*ringJob).JobPending() {
-				// Wait until job finish executing to avoid breaking the ongoing ring reconciliation
-				return reconcile.Result{
-					Requeue:      true,
-					RequeueAfter: time.Second * 5,
-				}, nil
-			}
-			atLeastOneJobDeleted = true
-			if err := r.client.Delete(context.Background(), ringJob, client.PropagationPolicy(meta.DeletePropagationForeground)); err != nil {
-				return reconcile.Result{}, err
-			}
+// ringDevices returns devices of storage pods placed in the ring. Until storage pods
+// get their addresses, the ring holds a placeholder device.
+func ringDevices(swiftStorage *contrail.SwiftStorage, ringType contrail.SwiftRing) []contrail.SwiftStorageDevice {
+	var devices []contrail.SwiftStorageDevice
+	for _, device := range swiftStorage.Status.Devices {
+		if device.InRing(ringType) {
+			devices = append(devices, device)
 		}
 	}
-	if atLeastOneJobDeleted {
-		// We have to wait for some time until job gets deleted because r.client.Delete does not delete job synchronously.
-		return reconcile.Result{
-			Requeue:      true,
-			RequeueAfter: time.Second * 5,
-		}, nil
+	if len(devices) != 0 {
+		return devices
 	}
-	return reconcile.Result{}, nil
+	return []contrail.SwiftStorageDevice{{
+		IP:     "0.0.0.0",
+		Device: swiftStorage.Spec.ServiceConfiguration.StorageDevices()[0].Name,
+		Region: 1,
+		Zone:   1,
+		Weight: 1,
+	}}
 }
 
-func (r *ReconcileSwift) startRingReconcilingJob(ringConfigMapName, ringType string, port int, ips []string, swift *contrail.Swift) error {
-
-	jobName := types.NamespacedName{
-		Namespace: swift.Namespace,
-		Name:      swift.Name + "-ring-" + ringType + "-job",
+// reconcileRing rebalances the ring builder kept in the ring config map and writes
+// the ring file read by proxy and storage services next to it.
+func reconcileRing(data map[string][]byte, ringType string, port int, devices []contrail.SwiftStorageDevice, now time.Time) (bool, error) {
+	builder, err := loadRingBuilder(data, ringType)
+	if err != nil {
+		return false, err
+	}
+	var ringDevices []ring.Device
+	for _, d := range devices {
+		ringDevices = append(ringDevices, ring.Device{
+			Region: d.Region,
+			Zone:   d.Zone,
+			IP:     d.IP,
+			Port:   port,
+			Device: d.Device,
+			Meta:   d.Pod,
+			Weight: float64(d.Weight),
+		})
 	}
-
-	theRing, err := ring.New(types.NamespacedName{
-		Namespace: swift.Namespace,
-		Name:      ringConfigMapName,
-	}, ringType, "serviceaccount-swift")
+	if err = builder.SetDevices(ringDevices); err != nil {
+		return false, err
+	}
+	balanced, err := builder.Rebalance(now)
 	if err != nil {
-		return err
+		return false, err
 	}
-	for _, ip := range ips {
-		device := ring.Device{
-			Region: "1",
-			Zone:   "1",
-			IP:     ip,
-			Port:   port,
-			Device: swift.Spec.ServiceConfiguration.SwiftStorageConfiguration.Device,
-		}
-		if err = theRing.AddDevice(device); err != nil {
-			return err
-		}
+	ringFile, err := builder.Ring()
+	if err != nil {
+		return false, err
 	}
-	job, err := theRing.BuildJob(jobName, swift.Spec.CommonConfiguration.NodeSelector)
+	builderData, err := builder.Marshal()
 	if err != nil {
-		return err
+		return false, err
 	}
+	data[ringType+".ring.gz"] = ringFile
+	data[ringType+".builder"] = builderData
+	// Builder pickled by the former ring controller job is not used anymore
+	delete(data, ringType)
+	return balanced, nil
+}
 
-	for idx, jc := range job.Spec.Template.Spec.Containers {
-		c := utils.GetContainerFromList(jc.Name, swift.Spec.ServiceConfiguration.Containers)
-		if c != nil {
-			if len(c.Command) > 0 {
-				job.Spec.Template.Spec.Containers[idx].Command = c.Command
-			}
-			if c.Image != "" {
-				job.Spec.Template.Spec.Containers[idx].Image = c.Image
-			}
-		}
+func loadRingBuilder(data map[string][]byte, ringType string) (*ring.Builder, error) {
+	if builderData, ok := data[ringType+".builder"]; ok {
+		return ring.Load(builderData)
 	}
-
-	if err = controllerutil.SetControllerReference(swift, &job, r.scheme); err != nil {
-		return err
+	if ringFile, ok := data[ringType+".ring.gz"]; ok {
+		return ring.ReadRing(ringFile, ringMinPartHours)
 	}
-	return r.client.Create(context.Background(), &job)
+	return ring.NewBuilder(ringPartPower, ringReplicas, ringMinPartHours)
 }
@@ -2,15 +2,20 @@ package swift_test
 
 import (
 	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
 	"testing"
+	"time"
 
 	"k8s.io/apimachinery/pkg/runtime"
 
-	batch "k8s.io/api/batch/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 
 	contrail "github.com/Juniper/contrail-operator/pkg/apis/contrail/v1alpha1"
 	"github.com/Juniper/contrail-operator/pkg/controller/swift"
+	"github.com/Juniper/contrail-operator/pkg/swift/ring"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -18,6 +23,7 @@ import (
 	core "k8s.io/api/core/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/rest"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
@@ -31,7 +37,6 @@ func TestSwiftController(t *testing.T) {
 	require.NoError(t, err)
 	require.NoError(t, core.SchemeBuilder.AddToScheme(scheme))
 	require.NoError(t, apps.SchemeBuilder.AddToScheme(scheme))
-	require.NoError(t, batch.SchemeBuilder.AddToScheme(scheme))
 
 	trueVal := true
 
@@ -85,7 +90,7 @@ func TestSwiftController(t *testing.T) {
 					},
 				}
 				fakeClient := fake.NewFakeClientWithScheme(scheme, swiftCR)
-				reconciler := swift.NewReconciler(fakeClient, scheme)
+				reconciler := swift.NewReconciler(fakeClient, scheme, &rest.Config{})
 				// when
 				_, err = reconciler.Reconcile(reconcile.Request{NamespacedName: swiftName})
 				// then
@@ -224,7 +229,7 @@ func TestSwiftController(t *testing.T) {
 			}}
 
 		fakeClient := fake.NewFakeClientWithScheme(scheme, initObjs...)
-		reconciler := swift.NewReconciler(fakeClient, scheme)
+		reconciler := swift.NewReconciler(fakeClient, scheme, &rest.Config{})
 		// when
 		_, err = reconciler.Reconcile(reconcile.Request{NamespacedName: swiftName})
 		// then
@@ -287,7 +292,7 @@ func TestSwiftController(t *testing.T) {
 		}
 
 		fakeClient := fake.NewFakeClientWithScheme(scheme, swiftCR, existingSwiftProxy, existingSwiftStorage)
-		reconciler := swift.NewReconciler(fakeClient, scheme)
+		reconciler := swift.NewReconciler(fakeClient, scheme, &rest.Config{})
 		// when
 		_, err = reconciler.Reconcile(reconcile.Request{NamespacedName: swiftName})
 		// then
@@ -301,19 +306,277 @@ func TestSwiftController(t *testing.T) {
 			assertSwiftProxyCRExists(t, fakeClient, swiftCR)
 		})
 
-		t.Run("should start rings reconciling jobs", func(t *testing.T) {
-			assertJobExists(t, fakeClient, types.NamespacedName{
+		t.Run("should write rings and their builders to ring config map", func(t *testing.T) {
+			cm := &core.ConfigMap{}
+			require.NoError(t, fakeClient.Get(context.Background(), types.NamespacedName{
 				Namespace: swiftCR.Namespace,
-				Name:      swiftCR.Name + "-ring-account-job",
-			})
-			assertJobExists(t, fakeClient, types.NamespacedName{
+				Name:      ringConfigMapName,
+			}, cm))
+			for _, ringType := range []string{"account", "container", "object"} {
+				builder, err := ring.Load(cm.BinaryData[ringType+".builder"])
+				require.NoError(t, err)
+				ringFile, err := builder.Ring()
+				require.NoError(t, err)
+				assert.Equal(t, ringFile, cm.BinaryData[ringType+".ring.gz"])
+				assert.Equal(t, 10, builder.PartPower)
+				assert.Equal(t, 1, builder.Replicas)
+				assert.Equal(t, 1, builder.MinPartHours)
+			}
+		})
+	})
+
+	t.Run("when ring config map contains rings built by ring controller job", func(t *testing.T) {
+		// given
+		swiftCR := newReconciledSwift()
+		existingSwiftStorage := &contrail.SwiftStorage{
+			ObjectMeta: v1.ObjectMeta{
+				Name:      swiftName.Name + "-storage",
+				Namespace: swiftName.Namespace,
+			},
+			Status: contrail.SwiftStorageStatus{
+				Devices: []contrail.SwiftStorageDevice{
+					{IP: "192.168.0.1", Device: "dev", Region: 1, Zone: 1, Weight: 100},
+					{IP: "192.168.0.2", Device: "dev", Region: 1, Zone: 2, Weight: 100},
+				},
+			},
+		}
+		oldBuilder, err := ring.NewBuilder(10, 1, 1)
+		require.NoError(t, err)
+		require.NoError(t, oldBuilder.SetDevices([]ring.Device{
+			{Region: 1, Zone: 1, IP: "192.168.0.1", Port: 6000, Device: "dev", Weight: 100},
+			{Region: 1, Zone: 2, IP: "192.168.0.2", Port: 6000, Device: "dev", Weight: 100},
+		}))
+		_, err = oldBuilder.Rebalance(time.Now())
+		require.NoError(t, err)
+		oldRing, err := oldBuilder.Ring()
+		require.NoError(t, err)
+		existingConfigMap := &core.ConfigMap{
+			ObjectMeta: v1.ObjectMeta{
+				Name:      ringConfigMapName,
+				Namespace: swiftName.Namespace,
+			},
+			BinaryData: map[string][]byte{
+				"object":         []byte("pickled builder"),
+				"object.ring.gz": oldRing,
+			},
+		}
+		fakeClient := fake.NewFakeClientWithScheme(scheme, swiftCR, existingSwiftStorage, existingConfigMap)
+		reconciler := swift.NewReconciler(fakeClient, scheme, &rest.Config{})
+		// when
+		_, err = reconciler.Reconcile(reconcile.Request{NamespacedName: swiftName})
+		// then
+		assert.NoError(t, err)
+
+		t.Run("should keep partitions assigned in existing ring", func(t *testing.T) {
+			cm := &core.ConfigMap{}
+			require.NoError(t, fakeClient.Get(context.Background(), types.NamespacedName{
 				Namespace: swiftCR.Namespace,
-				Name:      swiftCR.Name + "-ring-container-job",
-			})
-			assertJobExists(t, fakeClient, types.NamespacedName{
+				Name:      ringConfigMapName,
+			}, cm))
+			assert.Equal(t, oldRing, cm.BinaryData["object.ring.gz"])
+			assert.NotContains(t, cm.BinaryData, "object")
+			builder, err := ring.Load(cm.BinaryData["object.builder"])
+			require.NoError(t, err)
+			assert.Equal(t, oldBuilder.Replica2Part2Dev, builder.Replica2Part2Dev)
+		})
+	})
+
+	t.Run("when Swift Storage devices are placed in regions and zones", func(t *testing.T) {
+		// given
+		swiftCR := newReconciledSwift()
+		existingSwiftStorage := &contrail.SwiftStorage{
+			ObjectMeta: v1.ObjectMeta{
+				Name:      swiftName.Name + "-storage",
+				Namespace: swiftName.Namespace,
+			},
+			Status: contrail.SwiftStorageStatus{
+				Devices: []contrail.SwiftStorageDevice{
+					{IP: "192.168.0.1", Device: "dev", Region: 1, Zone: 1, Weight: 100},
+					{IP: "192.168.0.2", Device: "dev", Region: 2, Zone: 3, Weight: 50},
+				},
+			},
+		}
+		fakeClient := fake.NewFakeClientWithScheme(scheme, swiftCR, existingSwiftStorage)
+		reconciler := swift.NewReconciler(fakeClient, scheme, &rest.Config{})
+		// when
+		_, err = reconciler.Reconcile(reconcile.Request{NamespacedName: swiftName})
+		// then
+		assert.NoError(t, err)
+
+		t.Run("should set weights of devices and rebalance rings", func(t *testing.T) {
+			cm := &core.ConfigMap{}
+			require.NoError(t, fakeClient.Get(context.Background(), types.NamespacedName{
 				Namespace: swiftCR.Namespace,
-				Name:      swiftCR.Name + "-ring-object-job",
-			})
+				Name:      ringConfigMapName,
+			}, cm))
+			objectRing, err := ring.ReadRing(cm.BinaryData["object.ring.gz"], 1)
+			require.NoError(t, err)
+			require.Len(t, objectRing.Devices, 2)
+			assert.Equal(t, "r1z1-192.168.0.1:6000/dev", objectRing.Devices[0].Formatted())
+			assert.Equal(t, 100.0, objectRing.Devices[0].Weight)
+			assert.Equal(t, "r2z3-192.168.0.2:6000/dev", objectRing.Devices[1].Formatted())
+			assert.Equal(t, 50.0, objectRing.Devices[1].Weight)
+			parts := map[int]int{}
+			for _, d := range objectRing.Replica2Part2Dev[0] {
+				parts[d]++
+			}
+			assert.Equal(t, map[int]int{0: 683, 1: 341}, parts)
+		})
+	})
+
+	t.Run("when Swift Storage pods report usage", func(t *testing.T) {
+		// given
+		swiftCR := newReconciledSwift()
+		swiftCR.Spec.ServiceConfiguration.NearlyFullPercent = 70
+		storageLabels := map[string]string{"contrail_manager": "swiftstorage", "swiftstorage": "test-swift-storage"}
+		existingSwiftStorage := &contrail.SwiftStorage{
+			ObjectMeta: v1.ObjectMeta{
+				Name:      swiftName.Name + "-storage",
+				Namespace: swiftName.Namespace,
+				Labels:    storageLabels,
+			},
+			Status: contrail.SwiftStorageStatus{Active: true},
+		}
+		existingSwiftProxy := &contrail.SwiftProxy{
+			ObjectMeta: v1.ObjectMeta{
+				Name:      swiftName.Name + "-proxy",
+				Namespace: swiftName.Namespace,
+			},
+			Status: contrail.SwiftProxyStatus{Status: contrail.Status{Active: true}},
+		}
+		storagePod := func(name, ip string) *core.Pod {
+			return &core.Pod{
+				ObjectMeta: v1.ObjectMeta{Name: name, Namespace: swiftName.Namespace, Labels: storageLabels},
+				Status:     core.PodStatus{Phase: core.PodRunning, PodIP: ip},
+			}
+		}
+		lastReplication := time.Now().Add(-2 * time.Minute).Unix()
+		recon := &fakeRecon{responses: map[string]string{
+			"test-swift-storage-statefulset-0/recon/diskusage":          `[{"device": "d1", "mounted": true, "size": 1000, "used": 750, "avail": 250}]`,
+			"test-swift-storage-statefulset-0/recon/replication/object": fmt.Sprintf(`{"object_replication_last": %d, "object_replication_time": 0.5}`, lastReplication),
+			"test-swift-storage-statefulset-0/recon/quarantined":        `{"objects": 2, "containers": 0, "accounts": 1, "policies": {}}`,
+			"test-swift-storage-statefulset-1/recon/diskusage":          `[{"device": "d1", "mounted": true, "size": 1000, "used": 100, "avail": 900}, {"device": "d2", "mounted": "Input/output error", "size": "", "used": "", "avail": ""}]`,
+			"test-swift-storage-statefulset-1/recon/replication/object": `{"object_replication_last": null, "object_replication_time": null}`,
+			"test-swift-storage-statefulset-1/recon/quarantined":        `{"objects": 1, "containers": 0, "accounts": 0, "policies": {}}`,
+		}}
+		fakeClient := fake.NewFakeClientWithScheme(scheme, swiftCR, existingSwiftStorage, existingSwiftProxy,
+			storagePod("test-swift-storage-statefulset-0", "10.0.0.1"), storagePod("test-swift-storage-statefulset-1", "10.0.0.2"))
+		reconciler := swift.NewReconciler(fakeClient, scheme, recon.restConfig())
+		// when
+		result, err := reconciler.Reconcile(reconcile.Request{NamespacedName: swiftName})
+		// then
+		require.NoError(t, err)
+
+		t.Run("should read recon of object servers", func(t *testing.T) {
+			assert.Len(t, recon.requests, 6)
+			assert.Contains(t, recon.requests, "/api/v1/namespaces/default/pods/http:test-swift-storage-statefulset-0:6000/proxy/recon/diskusage")
+		})
+
+		t.Run("should publish usage of pods in Swift Storage status", func(t *testing.T) {
+			ss := &contrail.SwiftStorage{}
+			require.NoError(t, fakeClient.Get(context.Background(), types.NamespacedName{Name: swiftName.Name + "-storage", Namespace: swiftName.Namespace}, ss))
+			require.Len(t, ss.Status.Usage, 2)
+			pod0 := ss.Status.Usage[0]
+			assert.Equal(t, "test-swift-storage-statefulset-0", pod0.Pod)
+			assert.Equal(t, []contrail.SwiftDeviceUsage{{Device: "d1", Mounted: true, SizeBytes: 1000, UsedBytes: 750, AvailableBytes: 250, UsedPercent: 75}}, pod0.Devices)
+			require.NotNil(t, pod0.ReplicationLagSeconds)
+			assert.InDelta(t, 120, *pod0.ReplicationLagSeconds, 5)
+			assert.Equal(t, int64(2), pod0.QuarantinedObjects)
+			assert.Equal(t, int64(1), pod0.QuarantinedAccounts)
+			pod1 := ss.Status.Usage[1]
+			assert.Nil(t, pod1.ReplicationLagSeconds)
+			assert.Equal(t, contrail.SwiftDeviceUsage{Device: "d2"}, pod1.Devices[1])
+			assert.True(t, contrail.IsConditionTrue(ss.Status.Conditions, contrail.SwiftConditionNearlyFull))
+		})
+
+		t.Run("should sum usage in Swift status", func(t *testing.T) {
+			s := &contrail.Swift{}
+			require.NoError(t, fakeClient.Get(context.Background(), swiftName, s))
+			require.NotNil(t, s.Status.Usage)
+			assert.Equal(t, int64(2000), s.Status.Usage.SizeBytes)
+			assert.Equal(t, int64(850), s.Status.Usage.UsedBytes)
+			assert.Equal(t, int64(1150), s.Status.Usage.AvailableBytes)
+			assert.Equal(t, 75, s.Status.Usage.MaxDeviceUsedPercent)
+			require.NotNil(t, s.Status.Usage.MaxReplicationLagSeconds)
+			assert.InDelta(t, 120, *s.Status.Usage.MaxReplicationLagSeconds, 5)
+			assert.Equal(t, int64(3), s.Status.Usage.QuarantinedObjects)
+			nearlyFull := contrail.FindCondition(s.Status.Conditions, contrail.SwiftConditionNearlyFull)
+			require.NotNil(t, nearlyFull)
+			assert.Equal(t, contrail.ConditionTrue, nearlyFull.Status)
+			assert.Equal(t, "device d1 of pod test-swift-storage-statefulset-0 is 75% full", nearlyFull.Message)
+		})
+
+		t.Run("should gather usage again after usage period", func(t *testing.T) {
+			assert.True(t, result.RequeueAfter > 0 && result.RequeueAfter <= 5*time.Minute)
+			recon.requests = nil
+			_, err := reconciler.Reconcile(reconcile.Request{NamespacedName: swiftName})
+			require.NoError(t, err)
+			assert.Empty(t, recon.requests)
+		})
+
+		t.Run("should report unknown nearly full condition when usage cannot be read", func(t *testing.T) {
+			// given
+			failingRecon := &fakeRecon{responses: map[string]string{}}
+			fakeClient := fake.NewFakeClientWithScheme(scheme, swiftCR.DeepCopy(), existingSwiftStorage.DeepCopy(), existingSwiftProxy.DeepCopy(),
+				storagePod("test-swift-storage-statefulset-0", "10.0.0.1"), storagePod("test-swift-storage-statefulset-1", "10.0.0.2"))
+			reconciler := swift.NewReconciler(fakeClient, scheme, failingRecon.restConfig())
+			// when
+			_, err := reconciler.Reconcile(reconcile.Request{NamespacedName: swiftName})
+			// then
+			require.NoError(t, err)
+			s := &contrail.Swift{}
+			require.NoError(t, fakeClient.Get(context.Background(), swiftName, s))
+			nearlyFull := contrail.FindCondition(s.Status.Conditions, contrail.SwiftConditionNearlyFull)
+			require.NotNil(t, nearlyFull)
+			assert.Equal(t, contrail.ConditionUnknown, nearlyFull.Status)
+			assert.Equal(t, "UsageUnknown", nearlyFull.Reason)
+			assert.Contains(t, nearlyFull.Message, "usage of pod test-swift-storage-statefulset-0 is unknown: failed to get disk usage")
+		})
+	})
+
+	t.Run("when Swift Storage devices are placed in separate rings", func(t *testing.T) {
+		// given
+		swiftCR := newReconciledSwift()
+		existingSwiftStorage := &contrail.SwiftStorage{
+			ObjectMeta: v1.ObjectMeta{
+				Name:      swiftName.Name + "-storage",
+				Namespace: swiftName.Namespace,
+			},
+			Status: contrail.SwiftStorageStatus{
+				Devices: []contrail.SwiftStorageDevice{
+					{IP: "192.168.0.1", Device: "sdb", Region: 1, Zone: 1, Weight: 100},
+					{IP: "192.168.0.1", Device: "ssd", Region: 1, Zone: 1, Weight: 100, Rings: []contrail.SwiftRing{contrail.SwiftAccountRing, contrail.SwiftContainerRing}},
+					{IP: "192.168.0.1", Device: "sdd", Region: 1, Zone: 1, Weight: 100, Rings: []contrail.SwiftRing{contrail.SwiftObjectRing}},
+				},
+			},
+		}
+		fakeClient := fake.NewFakeClientWithScheme(scheme, swiftCR, existingSwiftStorage)
+		reconciler := swift.NewReconciler(fakeClient, scheme, &rest.Config{})
+		// when
+		_, err = reconciler.Reconcile(reconcile.Request{NamespacedName: swiftName})
+		// then
+		assert.NoError(t, err)
+
+		t.Run("should place every device only in its rings", func(t *testing.T) {
+			cm := &core.ConfigMap{}
+			require.NoError(t, fakeClient.Get(context.Background(), types.NamespacedName{
+				Namespace: swiftCR.Namespace,
+				Name:      ringConfigMapName,
+			}, cm))
+			expectedDevices := map[string][]string{
+				"account":   {"r1z1-192.168.0.1:6001/sdb", "r1z1-192.168.0.1:6001/ssd"},
+				"container": {"r1z1-192.168.0.1:6002/sdb", "r1z1-192.168.0.1:6002/ssd"},
+				"object":    {"r1z1-192.168.0.1:6000/sdb", "r1z1-192.168.0.1:6000/sdd"},
+			}
+			for ringType, expected := range expectedDevices {
+				r, err := ring.ReadRing(cm.BinaryData[ringType+".ring.gz"], 1)
+				require.NoError(t, err)
+				var devices []string
+				for _, device := range r.Devices {
+					devices = append(devices, device.Formatted())
+				}
+				assert.Equal(t, expected, devices, ringType)
+			}
 		})
 	})
 
@@ -327,9 +590,6 @@ func newSwift(swiftName types.NamespacedName) *contrail.Swift {
 		},
 		Spec: contrail.SwiftSpec{
 			ServiceConfiguration: contrail.SwiftConfiguration{
-				Containers: []*contrail.Container{
-					{Name: "contrail-operator-ringcontroller", Image: "contrail-operator-ringcontroller"},
-				},
 				SwiftStorageConfiguration: contrail.SwiftStorageConfiguration{
 					AccountBindPort:   6001,
 					ContainerBindPort: 6002,
@@ -413,12 +673,35 @@ func assertSwiftProxyCRExists(t *testing.T, c client.Client, swiftCR *contrail.S
 	assert.Equal(t, expectedSwiftProxyConf.Containers, swiftProxy.Spec.ServiceConfiguration.Containers)
 }
 
-func assertJobExists(t *testing.T, fakeClient client.Client, jobName types.NamespacedName) {
-	job := &batch.Job{}
-	err := fakeClient.Get(context.Background(), client.ObjectKey{
-		Name:      jobName.Name,
-		Namespace: jobName.Namespace,
-	}, job)
-	require.NoError(t, err, "job %v does not exist", jobName)
-	assert.Equal(t, "contrail-operator-ringcontroller", job.Spec.Template.Spec.Containers[0].Image)
+type mockRoundTripFunc func(r *http.Request) (*http.Response, error)
+
+func (m mockRoundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return m(r)
+}
+
+// fakeRecon serves recon responses of storage pods reached through the kube proxy.
+// Responses are keyed by the pod name and the recon path.
+type fakeRecon struct {
+	responses map[string]string
+	requests  []string
+}
+
+func (f *fakeRecon) restConfig() *rest.Config {
+	return &rest.Config{
+		Host:      "localhost",
+		APIPath:   "/",
+		Transport: mockRoundTripFunc(f.roundTrip),
+	}
+}
+
+func (f *fakeRecon) roundTrip(r *http.Request) (*http.Response, error) {
+	f.requests = append(f.requests, r.URL.Path)
+	proxyIndex := strings.Index(r.URL.Path, "/proxy/")
+	target := strings.Split(r.URL.Path[:proxyIndex], ":")
+	pod := target[len(target)-2]
+	response, ok := f.responses[pod+r.URL.Path[proxyIndex+len("/proxy"):]]
+	if !ok {
+		return &http.Response{StatusCode: http.StatusNotFound, Body: ioutil.NopCloser(strings.NewReader(""))}, nil
+	}
+	return &http.Response{StatusCode: http.StatusOK, Body: ioutil.NopCloser(strings.NewReader(response))}, nil
 }
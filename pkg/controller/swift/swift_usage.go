@@ -0,0 +1,184 @@
+package swift
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	contrail "github.com/Juniper/contrail-operator/pkg/apis/contrail/v1alpha1"
+	"github.com/Juniper/contrail-operator/pkg/client/kubeproxy"
+	swiftclient "github.com/Juniper/contrail-operator/pkg/client/swift"
+)
+
+const (
+	// usagePeriod is how often usage of storage pods is gathered.
+	usagePeriod              = 5 * time.Minute
+	defaultNearlyFullPercent = 80
+)
+
+// reconcileUsage reads disk usage, replication and quarantined items from recon
+// middleware of object servers of running storage pods every usagePeriod. Usage
+// of pods is kept in the status of the SwiftStorage and its sum in the status of
+// the Swift. It returns the time left until usage should be gathered again.
+func (r *ReconcileSwift) reconcileUsage(swift *contrail.Swift) (time.Duration, error) {
+	now := time.Now()
+	if swift.Status.Usage != nil {
+		if elapsed := now.Sub(swift.Status.Usage.ReportTime.Time); elapsed >= 0 && elapsed < usagePeriod {
+			return usagePeriod - elapsed, nil
+		}
+	}
+	swiftStorage := &contrail.SwiftStorage{}
+	if err := r.client.Get(context.TODO(), types.NamespacedName{Name: swift.Name + "-storage", Namespace: swift.Namespace}, swiftStorage); err != nil {
+		return 0, err
+	}
+	pods, err := r.runningStoragePods(swiftStorage)
+	if err != nil || len(pods) == 0 {
+		return usagePeriod, err
+	}
+	proxy, err := kubeproxy.New(r.restConfig)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create kubeproxy: %v", err)
+	}
+	port := swiftStorage.Spec.ServiceConfiguration.ObjectBindPort
+	var usage []contrail.SwiftStoragePodUsage
+	for _, pod := range pods {
+		recon := swiftclient.NewReconClient(proxy.NewClient(pod.Namespace, pod.Name, port))
+		usage = append(usage, podUsage(recon, pod.Name, now))
+	}
+
+	nearlyFull := nearlyFullCondition(usage, swift.Spec.ServiceConfiguration.NearlyFullPercent)
+	swiftStorage.Status.Usage = usage
+	nearlyFull.ObservedGeneration = swiftStorage.Generation
+	contrail.SetCondition(&swiftStorage.Status.Conditions, nearlyFull)
+	if err = r.client.Status().Update(context.TODO(), swiftStorage); err != nil {
+		return 0, err
+	}
+	swift.Status.Usage = sumUsage(usage, now)
+	nearlyFull.ObservedGeneration = swift.Generation
+	contrail.SetCondition(&swift.Status.Conditions, nearlyFull)
+	return usagePeriod, nil
+}
+
+func (r *ReconcileSwift) runningStoragePods(swiftStorage *contrail.SwiftStorage) ([]core.Pod, error) {
+	if len(swiftStorage.Labels) == 0 {
+		return nil, nil
+	}
+	pods := &core.PodList{}
+	if err := r.client.List(context.TODO(), pods, client.InNamespace(swiftStorage.Namespace), client.MatchingLabels(swiftStorage.Labels)); err != nil {
+		return nil, err
+	}
+	var running []core.Pod
+	for _, pod := range pods.Items {
+		if pod.Status.Phase == core.PodRunning && pod.Status.PodIP != "" && pod.DeletionTimestamp.IsZero() {
+			running = append(running, pod)
+		}
+	}
+	sort.Slice(running, func(i, j int) bool { return running[i].Name < running[j].Name })
+	return running, nil
+}
+
+// podUsage reads recon of the pod. Errors are kept in the usage,
+// so that a single failing pod does not hide usage of the others.
+func podUsage(recon *swiftclient.ReconClient, pod string, now time.Time) contrail.SwiftStoragePodUsage {
+	usage := contrail.SwiftStoragePodUsage{Pod: pod}
+	disks, err := recon.DiskUsage()
+	if err != nil {
+		usage.Error = fmt.Sprintf("failed to get disk usage: %v", err)
+		return usage
+	}
+	for _, disk := range disks {
+		device := contrail.SwiftDeviceUsage{Device: disk.Device, Mounted: disk.IsMounted()}
+		if device.Mounted {
+			device.SizeBytes, device.UsedBytes, device.AvailableBytes = disk.Bytes()
+		}
+		if device.SizeBytes > 0 {
+			device.UsedPercent = int(device.UsedBytes * 100 / device.SizeBytes)
+		}
+		usage.Devices = append(usage.Devices, device)
+	}
+	replication, err := recon.ObjectReplication()
+	if err != nil {
+		usage.Error = fmt.Sprintf("failed to get replication: %v", err)
+		return usage
+	}
+	if replication.Last != nil && *replication.Last > 0 {
+		lag := now.Unix() - int64(*replication.Last)
+		if lag < 0 {
+			lag = 0
+		}
+		usage.ReplicationLagSeconds = &lag
+	}
+	quarantined, err := recon.Quarantined()
+	if err != nil {
+		usage.Error = fmt.Sprintf("failed to get quarantined: %v", err)
+		return usage
+	}
+	usage.QuarantinedObjects = quarantined.Objects
+	usage.QuarantinedContainers = quarantined.Containers
+	usage.QuarantinedAccounts = quarantined.Accounts
+	return usage
+}
+
+func sumUsage(usage []contrail.SwiftStoragePodUsage, now time.Time) *contrail.SwiftUsage {
+	sum := &contrail.SwiftUsage{ReportTime: meta.NewTime(now)}
+	for _, pod := range usage {
+		for _, device := range pod.Devices {
+			sum.SizeBytes += device.SizeBytes
+			sum.UsedBytes += device.UsedBytes
+			sum.AvailableBytes += device.AvailableBytes
+			if device.UsedPercent > sum.MaxDeviceUsedPercent {
+				sum.MaxDeviceUsedPercent = device.UsedPercent
+			}
+		}
+		if lag := pod.ReplicationLagSeconds; lag != nil && (sum.MaxReplicationLagSeconds == nil || *lag > *sum.MaxReplicationLagSeconds) {
+			sum.MaxReplicationLagSeconds = lag
+		}
+		sum.QuarantinedObjects += pod.QuarantinedObjects
+		sum.QuarantinedContainers += pod.QuarantinedContainers
+		sum.QuarantinedAccounts += pod.QuarantinedAccounts
+	}
+	return sum
+}
+
+// nearlyFullCondition is true when any device is filled at least to the threshold.
+// It is unknown when disk usage of some pod could not be read and no device is
+// known to be nearly full.
+func nearlyFullCondition(usage []contrail.SwiftStoragePodUsage, threshold int) contrail.Condition {
+	if threshold == 0 {
+		threshold = defaultNearlyFullPercent
+	}
+	for _, pod := range usage {
+		for _, device := range pod.Devices {
+			if device.UsedPercent >= threshold {
+				return contrail.Condition{
+					Type:    contrail.SwiftConditionNearlyFull,
+					Status:  contrail.ConditionTrue,
+					Reason:  "DeviceNearlyFull",
+					Message: fmt.Sprintf("device %s of pod %s is %d%% full", device.Device, pod.Pod, device.UsedPercent),
+				}
+			}
+		}
+	}
+	for _, pod := range usage {
+		if pod.Error != "" && len(pod.Devices) == 0 {
+			return contrail.Condition{
+				Type:    contrail.SwiftConditionNearlyFull,
+				Status:  contrail.ConditionUnknown,
+				Reason:  "UsageUnknown",
+				Message: fmt.Sprintf("usage of pod %s is unknown: %s", pod.Pod, pod.Error),
+			}
+		}
+	}
+	return contrail.Condition{
+		Type:    contrail.SwiftConditionNearlyFull,
+		Status:  contrail.ConditionFalse,
+		Reason:  contrail.ReasonAsExpected,
+		Message: fmt.Sprintf("all devices are less than %d%% full", threshold),
+	}
+}
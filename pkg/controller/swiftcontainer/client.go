@@ -0,0 +1,68 @@
+package swiftcontainer
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	contrail "github.com/Juniper/contrail-operator/pkg/apis/contrail/v1alpha1"
+	"github.com/Juniper/contrail-operator/pkg/client/keystone"
+	"github.com/Juniper/contrail-operator/pkg/client/kubeproxy"
+	"github.com/Juniper/contrail-operator/pkg/client/swift"
+)
+
+// containerClient is the part of the Swift API used to manage containers.
+type containerClient interface {
+	HeadContainer(name string) (http.Header, error)
+	PutContainerWithHeaders(name string, headers http.Header) error
+	PostContainer(name string, headers http.Header) error
+	DeleteContainer(name string) error
+}
+
+// clientFactory returns a client of the proxy of the Swift.
+type clientFactory func(swift *contrail.Swift) (containerClient, error)
+
+// newClientFactory returns clients which reach the Swift proxy through
+// the kube API server proxy with a token of the Keystone admin.
+func newClientFactory(c client.Client, scheme *runtime.Scheme, config *rest.Config) clientFactory {
+	return func(swiftInstance *contrail.Swift) (containerClient, error) {
+		namespace := swiftInstance.Namespace
+		proxyConfiguration := swiftInstance.Spec.ServiceConfiguration.SwiftProxyConfiguration
+		keystoneInstance := &contrail.Keystone{}
+		if err := c.Get(context.TODO(), types.NamespacedName{Name: proxyConfiguration.KeystoneInstance, Namespace: namespace}, keystoneInstance); err != nil {
+			return nil, err
+		}
+		adminPasswordSecret := &core.Secret{}
+		if err := c.Get(context.TODO(), types.NamespacedName{Name: proxyConfiguration.KeystoneSecretName, Namespace: namespace}, adminPasswordSecret); err != nil {
+			return nil, err
+		}
+		keystoneClient, err := keystone.NewClient(c, scheme, config, keystoneInstance)
+		if err != nil {
+			return nil, err
+		}
+		token, err := keystoneClient.PostAdminAuthTokens(string(adminPasswordSecret.Data["password"]))
+		if err != nil {
+			return nil, fmt.Errorf("failed to get keystone token: %v", err)
+		}
+		proxy, err := kubeproxy.New(config)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create kubeproxy: %v", err)
+		}
+		swiftServiceName := proxyConfiguration.SwiftServiceName
+		if swiftServiceName == "" {
+			swiftServiceName = "swift"
+		}
+		swiftProxy := proxy.NewSecureClientForService(namespace, swiftInstance.Name+"-proxy-swiftproxy", swiftInstance.Status.SwiftProxyPort)
+		swiftClient, err := swift.NewClient(swiftProxy, token.XAuthTokenHeader, token.EndpointURL(swiftServiceName, "public"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create swift client %v", err)
+		}
+		return swiftClient, nil
+	}
+}
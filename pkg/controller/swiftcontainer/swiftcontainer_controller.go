@@ -0,0 +1,320 @@
+package swiftcontainer
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	contrail "github.com/Juniper/contrail-operator/pkg/apis/contrail/v1alpha1"
+	"github.com/Juniper/contrail-operator/pkg/client/swift"
+	"github.com/Juniper/contrail-operator/pkg/controller/utils"
+)
+
+var log = logf.Log.WithName("controller_swiftcontainer")
+
+// syncPeriod is how often containers are checked for changes made
+// outside of the operator and their usage is refreshed.
+const syncPeriod = 5 * time.Minute
+
+const (
+	readACLHeader     = "X-Container-Read"
+	writeACLHeader    = "X-Container-Write"
+	quotaBytesHeader  = "X-Container-Meta-Quota-Bytes"
+	quotaCountHeader  = "X-Container-Meta-Quota-Count"
+	versionsHeader    = "X-Versions-Location"
+	historyHeader     = "X-History-Location"
+	metadataPrefix    = "X-Container-Meta-"
+	objectCountHeader = "X-Container-Object-Count"
+	bytesUsedHeader   = "X-Container-Bytes-Used"
+)
+
+// Add creates a new SwiftContainer Controller and adds it to the Manager.
+func Add(mgr manager.Manager) error {
+	clients := newClientFactory(mgr.GetClient(), mgr.GetScheme(), mgr.GetConfig())
+	return add(mgr, NewReconcileSwiftContainer(mgr.GetClient(), mgr.GetEventRecorderFor("swiftcontainer-controller"), clients))
+}
+
+// add adds a new Controller to mgr with r as the reconcile.Reconciler.
+func add(mgr manager.Manager, r reconcile.Reconciler) error {
+	c, err := controller.New("swiftcontainer-controller", mgr, controller.Options{Reconciler: r})
+	if err != nil {
+		return err
+	}
+	if err = c.Watch(&source.Kind{Type: &contrail.SwiftContainer{}}, &handler.EnqueueRequestForObject{}); err != nil {
+		return err
+	}
+	return c.Watch(&source.Kind{Type: &contrail.Swift{}}, &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: handler.ToRequestsFunc(func(o handler.MapObject) []reconcile.Request {
+			return containersOf(mgr.GetClient(), o)
+		}),
+	})
+}
+
+func containersOf(c client.Client, o handler.MapObject) []reconcile.Request {
+	containers := &contrail.SwiftContainerList{}
+	if err := c.List(context.TODO(), containers, client.InNamespace(o.Meta.GetNamespace())); err != nil {
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, sc := range containers.Items {
+		if sc.Spec.SwiftInstance == o.Meta.GetName() {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: sc.Name, Namespace: sc.Namespace}})
+		}
+	}
+	return requests
+}
+
+// blank assignment to verify that ReconcileSwiftContainer implements reconcile.Reconciler
+var _ reconcile.Reconciler = &ReconcileSwiftContainer{}
+
+// ReconcileSwiftContainer reconciles a SwiftContainer object
+type ReconcileSwiftContainer struct {
+	client   client.Client
+	recorder record.EventRecorder
+	clients  clientFactory
+}
+
+func NewReconcileSwiftContainer(client client.Client, recorder record.EventRecorder, clients clientFactory) *ReconcileSwiftContainer {
+	return &ReconcileSwiftContainer{client: client, recorder: recorder, clients: clients}
+}
+
+// Reconcile creates the container in Swift and brings its ACLs, quotas, versioning
+// and metadata back to the spec when they were changed outside of the operator.
+// Containers are checked again after syncPeriod to refresh their usage in the status.
+func (r *ReconcileSwiftContainer) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	reqLogger := log.WithValues("Request.Namespace", request.Namespace, "Request.Name", request.Name)
+	reqLogger.Info("Reconciling SwiftContainer")
+	sc := &contrail.SwiftContainer{}
+	if err := r.client.Get(context.TODO(), request.NamespacedName, sc); err != nil {
+		if errors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+	if !sc.GetDeletionTimestamp().IsZero() {
+		return reconcile.Result{}, r.delete(sc)
+	}
+	if sc.Spec.DeletionPolicy == contrail.SwiftContainerDelete {
+		if err := utils.EnsureFinalizer(r.client, sc, utils.CleanupFinalizer); err != nil {
+			return reconcile.Result{}, err
+		}
+	} else if err := utils.RemoveFinalizer(r.client, sc, utils.CleanupFinalizer); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	swiftInstance, active, err := r.getSwift(sc)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if !active {
+		return reconcile.Result{}, contrail.WaitForDependency(r.client, r.recorder, sc, &sc.Status.Conditions, "Swift", sc.Spec.SwiftInstance)
+	}
+	swiftClient, err := r.clients(swiftInstance)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	status := sc.Status.DeepCopy()
+	current, err := r.syncContainer(swiftClient, sc)
+	if err != nil {
+		contrail.SetActiveConditions(&status.Conditions, sc.Generation, false, "SyncFailed", err.Error())
+		contrail.SetCondition(&status.Conditions, contrail.Condition{Type: contrail.ConditionDegraded, Status: contrail.ConditionTrue, ObservedGeneration: sc.Generation, Reason: "SyncFailed", Message: err.Error()})
+		if updateErr := r.updateStatus(sc, status); updateErr != nil {
+			return reconcile.Result{}, updateErr
+		}
+		return reconcile.Result{}, err
+	}
+	status.Created = true
+	status.ObjectCount, _ = strconv.ParseInt(current.Get(objectCountHeader), 10, 64)
+	status.BytesUsed, _ = strconv.ParseInt(current.Get(bytesUsedHeader), 10, 64)
+	status.MetadataKeys = metadataKeys(sc)
+	contrail.SetActiveConditions(&status.Conditions, sc.Generation, true, "ContainerSynced", "")
+	return reconcile.Result{RequeueAfter: syncPeriod}, r.updateStatus(sc, status)
+}
+
+// syncContainer creates the container or updates its headers which differ from the spec.
+// It returns headers of the container after the sync.
+func (r *ReconcileSwiftContainer) syncContainer(swiftClient containerClient, sc *contrail.SwiftContainer) (http.Header, error) {
+	name := sc.ContainerName()
+	if versioning := sc.Spec.Versioning; versioning != nil {
+		if err := ensureContainerExists(swiftClient, versioning.Location); err != nil {
+			return nil, fmt.Errorf("failed to create versions container (%s): %v", versioning.Location, err)
+		}
+	}
+	current, err := swiftClient.HeadContainer(name)
+	if err == swift.ErrNotFound {
+		if err = swiftClient.PutContainerWithHeaders(name, desiredHeaders(sc)); err != nil {
+			return nil, fmt.Errorf("failed to create swift container (%s): %v", name, err)
+		}
+		r.recorder.Eventf(sc, core.EventTypeNormal, utils.ReasonContainerCreated, "Created container %s", name)
+		return swiftClient.HeadContainer(name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	changes := headerChanges(current, sc)
+	if len(changes) == 0 {
+		return current, nil
+	}
+	if err = swiftClient.PostContainer(name, changes); err != nil {
+		return nil, fmt.Errorf("failed to update swift container (%s): %v", name, err)
+	}
+	r.recorder.Eventf(sc, core.EventTypeNormal, utils.ReasonContainerUpdated, "Updated %s of container %s", strings.Join(sortedNames(changes), ", "), name)
+	return swiftClient.HeadContainer(name)
+}
+
+func ensureContainerExists(swiftClient containerClient, name string) error {
+	_, err := swiftClient.HeadContainer(name)
+	if err == swift.ErrNotFound {
+		return swiftClient.PutContainerWithHeaders(name, http.Header{})
+	}
+	return err
+}
+
+// delete removes the container from Swift when the deletion policy is Delete.
+// Containers which still hold objects are left in Swift, as Swift would refuse
+// to delete them anyway and keeping the resource would block its namespace.
+func (r *ReconcileSwiftContainer) delete(sc *contrail.SwiftContainer) error {
+	if !utils.HasFinalizer(sc, utils.CleanupFinalizer) {
+		return nil
+	}
+	swiftInstance, active, err := r.getSwift(sc)
+	if err != nil {
+		return err
+	}
+	if swiftInstance == nil {
+		return utils.RemoveFinalizer(r.client, sc, utils.CleanupFinalizer)
+	}
+	if !active {
+		return contrail.WaitForDependency(r.client, r.recorder, sc, &sc.Status.Conditions, "Swift", sc.Spec.SwiftInstance)
+	}
+	swiftClient, err := r.clients(swiftInstance)
+	if err != nil {
+		return err
+	}
+	name := sc.ContainerName()
+	current, err := swiftClient.HeadContainer(name)
+	switch {
+	case err == swift.ErrNotFound:
+	case err != nil:
+		return err
+	case current.Get(objectCountHeader) != "" && current.Get(objectCountHeader) != "0":
+		r.recorder.Eventf(sc, core.EventTypeWarning, utils.ReasonContainerRetained, "Container %s holds %s objects and is not deleted", name, current.Get(objectCountHeader))
+	default:
+		if err = swiftClient.DeleteContainer(name); err != nil && err != swift.ErrNotFound {
+			return fmt.Errorf("failed to delete swift container (%s): %v", name, err)
+		}
+		r.recorder.Eventf(sc, core.EventTypeNormal, utils.ReasonContainerDeleted, "Deleted container %s", name)
+	}
+	return utils.RemoveFinalizer(r.client, sc, utils.CleanupFinalizer)
+}
+
+// getSwift returns the Swift and true when it is active. Nil is returned when the Swift does not exist.
+func (r *ReconcileSwiftContainer) getSwift(sc *contrail.SwiftContainer) (*contrail.Swift, bool, error) {
+	swiftInstance := &contrail.Swift{}
+	err := r.client.Get(context.TODO(), types.NamespacedName{Name: sc.Spec.SwiftInstance, Namespace: sc.Namespace}, swiftInstance)
+	if errors.IsNotFound(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return swiftInstance, swiftInstance.Status.Active, nil
+}
+
+// updateStatus updates the status only when it changed, so that
+// the update does not trigger another reconcile with the same result.
+func (r *ReconcileSwiftContainer) updateStatus(sc *contrail.SwiftContainer, status *contrail.SwiftContainerStatus) error {
+	if reflect.DeepEqual(sc.Status, *status) {
+		return nil
+	}
+	sc.Status = *status
+	return r.client.Status().Update(context.TODO(), sc)
+}
+
+// desiredHeaders returns headers of the container set in the spec.
+func desiredHeaders(sc *contrail.SwiftContainer) http.Header {
+	headers := http.Header{}
+	spec := sc.Spec
+	if spec.ReadACL != "" {
+		headers.Set(readACLHeader, spec.ReadACL)
+	}
+	if spec.WriteACL != "" {
+		headers.Set(writeACLHeader, spec.WriteACL)
+	}
+	if spec.Quota != nil && spec.Quota.Bytes != nil {
+		headers.Set(quotaBytesHeader, strconv.FormatInt(*spec.Quota.Bytes, 10))
+	}
+	if spec.Quota != nil && spec.Quota.Count != nil {
+		headers.Set(quotaCountHeader, strconv.FormatInt(*spec.Quota.Count, 10))
+	}
+	if spec.Versioning != nil {
+		if spec.Versioning.Mode == contrail.SwiftVersioningHistory {
+			headers.Set(historyHeader, spec.Versioning.Location)
+		} else {
+			headers.Set(versionsHeader, spec.Versioning.Location)
+		}
+	}
+	for key, value := range spec.Metadata {
+		headers.Set(metadataPrefix+key, value)
+	}
+	return headers
+}
+
+// headerChanges returns headers which bring the container to the spec. Headers
+// managed by the operator which are not in the spec are removed with X-Remove-*
+// headers. Metadata keys are managed when they were set by the operator before.
+func headerChanges(current http.Header, sc *contrail.SwiftContainer) http.Header {
+	desired := desiredHeaders(sc)
+	changes := http.Header{}
+	for name := range desired {
+		if current.Get(name) != desired.Get(name) {
+			changes.Set(name, desired.Get(name))
+		}
+	}
+	managed := []string{readACLHeader, writeACLHeader, quotaBytesHeader, quotaCountHeader, versionsHeader, historyHeader}
+	for _, key := range sc.Status.MetadataKeys {
+		managed = append(managed, metadataPrefix+key)
+	}
+	for _, name := range managed {
+		if desired.Get(name) == "" && current.Get(name) != "" {
+			changes.Set("X-Remove-"+strings.TrimPrefix(http.CanonicalHeaderKey(name), "X-"), "x")
+		}
+	}
+	return changes
+}
+
+func metadataKeys(sc *contrail.SwiftContainer) []string {
+	var keys []string
+	for key := range sc.Spec.Metadata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sortedNames(headers http.Header) []string {
+	var names []string
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
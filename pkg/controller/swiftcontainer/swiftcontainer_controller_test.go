@@ -0,0 +1,262 @@
+package swiftcontainer
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	contrail "github.com/Juniper/contrail-operator/pkg/apis/contrail/v1alpha1"
+	"github.com/Juniper/contrail-operator/pkg/client/swift"
+	"github.com/Juniper/contrail-operator/pkg/controller/utils"
+)
+
+// fakeSwift keeps headers of containers and applies requests like the Swift proxy.
+type fakeSwift struct {
+	containers map[string]http.Header
+	posts      []http.Header
+}
+
+func newFakeSwift() *fakeSwift {
+	return &fakeSwift{containers: map[string]http.Header{}}
+}
+
+func (f *fakeSwift) HeadContainer(name string) (http.Header, error) {
+	headers, ok := f.containers[name]
+	if !ok {
+		return nil, swift.ErrNotFound
+	}
+	return headers.Clone(), nil
+}
+
+func (f *fakeSwift) PutContainerWithHeaders(name string, headers http.Header) error {
+	if _, ok := f.containers[name]; !ok {
+		f.containers[name] = http.Header{objectCountHeader: {"0"}, bytesUsedHeader: {"0"}}
+	}
+	f.apply(name, headers)
+	return nil
+}
+
+func (f *fakeSwift) PostContainer(name string, headers http.Header) error {
+	if _, ok := f.containers[name]; !ok {
+		return swift.ErrNotFound
+	}
+	f.posts = append(f.posts, headers)
+	f.apply(name, headers)
+	return nil
+}
+
+func (f *fakeSwift) DeleteContainer(name string) error {
+	if _, ok := f.containers[name]; !ok {
+		return swift.ErrNotFound
+	}
+	delete(f.containers, name)
+	return nil
+}
+
+func (f *fakeSwift) apply(name string, headers http.Header) {
+	for header := range headers {
+		if strings.HasPrefix(header, "X-Remove-") {
+			f.containers[name].Del("X-" + strings.TrimPrefix(header, "X-Remove-"))
+			continue
+		}
+		f.containers[name].Set(header, headers.Get(header))
+	}
+}
+
+func TestSwiftContainer(t *testing.T) {
+	scheme, err := contrail.SchemeBuilder.Build()
+	require.NoError(t, err)
+	require.NoError(t, core.SchemeBuilder.AddToScheme(scheme))
+	swiftInstance := &contrail.Swift{
+		ObjectMeta: meta.ObjectMeta{Name: "swift1", Namespace: "default"},
+		Status:     contrail.SwiftStatus{Active: true},
+	}
+	quotaBytes := int64(1 << 30)
+	newContainer := func() *contrail.SwiftContainer {
+		return &contrail.SwiftContainer{
+			ObjectMeta: meta.ObjectMeta{Name: "images", Namespace: "default"},
+			Spec: contrail.SwiftContainerSpec{
+				SwiftInstance: "swift1",
+				ReadACL:       ".r:*,.rlistings",
+				Quota:         &contrail.SwiftContainerQuota{Bytes: &quotaBytes},
+				Versioning:    &contrail.SwiftContainerVersioning{Location: "images-versions"},
+				Metadata:      map[string]string{"Owner": "contrail"},
+			},
+		}
+	}
+	name := types.NamespacedName{Name: "images", Namespace: "default"}
+
+	setup := func(objs ...runtime.Object) (client.Client, *fakeSwift, *ReconcileSwiftContainer) {
+		cl := fake.NewFakeClientWithScheme(scheme, objs...)
+		fs := newFakeSwift()
+		clients := func(*contrail.Swift) (containerClient, error) { return fs, nil }
+		return cl, fs, NewReconcileSwiftContainer(cl, record.NewFakeRecorder(10), clients)
+	}
+	get := func(t *testing.T, cl client.Client) *contrail.SwiftContainer {
+		sc := &contrail.SwiftContainer{}
+		require.NoError(t, cl.Get(context.Background(), name, sc))
+		return sc
+	}
+
+	t.Run("should wait for Swift", func(t *testing.T) {
+		inactive := swiftInstance.DeepCopy()
+		inactive.Status.Active = false
+		cl, fs, r := setup(inactive, newContainer())
+		// when
+		_, err := r.Reconcile(reconcile.Request{NamespacedName: name})
+		// then
+		require.NoError(t, err)
+		assert.Empty(t, fs.containers)
+		sc := get(t, cl)
+		assert.False(t, contrail.IsConditionTrue(sc.Status.Conditions, contrail.ConditionDependenciesReady))
+	})
+
+	t.Run("should create container with headers from spec", func(t *testing.T) {
+		cl, fs, r := setup(swiftInstance.DeepCopy(), newContainer())
+		// when
+		result, err := r.Reconcile(reconcile.Request{NamespacedName: name})
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, syncPeriod, result.RequeueAfter)
+		require.Contains(t, fs.containers, "images")
+		assert.Contains(t, fs.containers, "images-versions")
+		headers := fs.containers["images"]
+		assert.Equal(t, ".r:*,.rlistings", headers.Get("X-Container-Read"))
+		assert.Equal(t, "1073741824", headers.Get("X-Container-Meta-Quota-Bytes"))
+		assert.Equal(t, "images-versions", headers.Get("X-Versions-Location"))
+		assert.Equal(t, "contrail", headers.Get("X-Container-Meta-Owner"))
+		sc := get(t, cl)
+		assert.True(t, sc.Status.Created)
+		assert.Equal(t, []string{"Owner"}, sc.Status.MetadataKeys)
+		assert.True(t, contrail.IsConditionTrue(sc.Status.Conditions, contrail.ConditionReady))
+		assert.False(t, utils.HasFinalizer(sc, utils.CleanupFinalizer))
+	})
+
+	t.Run("should use container name from spec", func(t *testing.T) {
+		sc := newContainer()
+		sc.Spec.ContainerName = "contrail_images"
+		_, fs, r := setup(swiftInstance.DeepCopy(), sc)
+		// when
+		_, err := r.Reconcile(reconcile.Request{NamespacedName: name})
+		// then
+		require.NoError(t, err)
+		assert.Contains(t, fs.containers, "contrail_images")
+		assert.NotContains(t, fs.containers, "images")
+	})
+
+	t.Run("should revert changes made outside of the operator", func(t *testing.T) {
+		_, fs, r := setup(swiftInstance.DeepCopy(), newContainer())
+		_, err := r.Reconcile(reconcile.Request{NamespacedName: name})
+		require.NoError(t, err)
+		fs.containers["images"].Set("X-Container-Read", ".r:*")
+		fs.containers["images"].Set("X-Container-Write", "other:user")
+		fs.containers["images"].Del("X-Container-Meta-Owner")
+		fs.containers["images"].Set("X-Container-Meta-Team", "other")
+		// when
+		_, err = r.Reconcile(reconcile.Request{NamespacedName: name})
+		// then
+		require.NoError(t, err)
+		headers := fs.containers["images"]
+		assert.Equal(t, ".r:*,.rlistings", headers.Get("X-Container-Read"))
+		assert.Empty(t, headers.Get("X-Container-Write"))
+		assert.Equal(t, "contrail", headers.Get("X-Container-Meta-Owner"))
+		assert.Equal(t, "other", headers.Get("X-Container-Meta-Team"), "metadata not set by the operator should be kept")
+	})
+
+	t.Run("should not update container in sync", func(t *testing.T) {
+		_, fs, r := setup(swiftInstance.DeepCopy(), newContainer())
+		_, err := r.Reconcile(reconcile.Request{NamespacedName: name})
+		require.NoError(t, err)
+		// when
+		_, err = r.Reconcile(reconcile.Request{NamespacedName: name})
+		// then
+		require.NoError(t, err)
+		assert.Empty(t, fs.posts)
+	})
+
+	t.Run("should remove settings dropped from spec", func(t *testing.T) {
+		cl, fs, r := setup(swiftInstance.DeepCopy(), newContainer())
+		_, err := r.Reconcile(reconcile.Request{NamespacedName: name})
+		require.NoError(t, err)
+		sc := get(t, cl)
+		sc.Spec.Quota = nil
+		sc.Spec.Metadata = nil
+		sc.Spec.Versioning = &contrail.SwiftContainerVersioning{Location: "images-versions", Mode: contrail.SwiftVersioningHistory}
+		require.NoError(t, cl.Update(context.Background(), sc))
+		// when
+		_, err = r.Reconcile(reconcile.Request{NamespacedName: name})
+		// then
+		require.NoError(t, err)
+		headers := fs.containers["images"]
+		assert.Empty(t, headers.Get("X-Container-Meta-Quota-Bytes"))
+		assert.Empty(t, headers.Get("X-Container-Meta-Owner"))
+		assert.Empty(t, headers.Get("X-Versions-Location"))
+		assert.Equal(t, "images-versions", headers.Get("X-History-Location"))
+		assert.Empty(t, get(t, cl).Status.MetadataKeys)
+	})
+
+	t.Run("should report usage of container", func(t *testing.T) {
+		cl, fs, r := setup(swiftInstance.DeepCopy(), newContainer())
+		_, err := r.Reconcile(reconcile.Request{NamespacedName: name})
+		require.NoError(t, err)
+		fs.containers["images"].Set("X-Container-Object-Count", "3")
+		fs.containers["images"].Set("X-Container-Bytes-Used", "4096")
+		// when
+		_, err = r.Reconcile(reconcile.Request{NamespacedName: name})
+		// then
+		require.NoError(t, err)
+		sc := get(t, cl)
+		assert.Equal(t, int64(3), sc.Status.ObjectCount)
+		assert.Equal(t, int64(4096), sc.Status.BytesUsed)
+	})
+
+	t.Run("should delete empty container with Delete policy", func(t *testing.T) {
+		sc := newContainer()
+		sc.Spec.DeletionPolicy = contrail.SwiftContainerDelete
+		cl, fs, r := setup(swiftInstance.DeepCopy(), sc)
+		_, err := r.Reconcile(reconcile.Request{NamespacedName: name})
+		require.NoError(t, err)
+		sc = get(t, cl)
+		require.True(t, utils.HasFinalizer(sc, utils.CleanupFinalizer))
+		now := meta.Now()
+		sc.DeletionTimestamp = &now
+		require.NoError(t, cl.Update(context.Background(), sc))
+		// when
+		_, err = r.Reconcile(reconcile.Request{NamespacedName: name})
+		// then
+		require.NoError(t, err)
+		assert.NotContains(t, fs.containers, "images")
+		assert.False(t, utils.HasFinalizer(get(t, cl), utils.CleanupFinalizer))
+	})
+
+	t.Run("should retain container with objects", func(t *testing.T) {
+		sc := newContainer()
+		sc.Spec.DeletionPolicy = contrail.SwiftContainerDelete
+		cl, fs, r := setup(swiftInstance.DeepCopy(), sc)
+		_, err := r.Reconcile(reconcile.Request{NamespacedName: name})
+		require.NoError(t, err)
+		fs.containers["images"].Set("X-Container-Object-Count", "1")
+		sc = get(t, cl)
+		now := meta.Now()
+		sc.DeletionTimestamp = &now
+		require.NoError(t, cl.Update(context.Background(), sc))
+		// when
+		_, err = r.Reconcile(reconcile.Request{NamespacedName: name})
+		// then
+		require.NoError(t, err)
+		assert.Contains(t, fs.containers, "images")
+		assert.False(t, utils.HasFinalizer(get(t, cl), utils.CleanupFinalizer))
+	})
+}
@@ -73,7 +73,7 @@ cert_file = /etc/swift/proxy.crt
 key_file = /etc/swift/proxy.key
 
 [pipeline:main]
-pipeline = catch_errors gatekeeper healthcheck cache container_sync bulk tempurl ratelimit authtoken keystoneauth container_quotas account_quotas slo dlo proxy-server
+pipeline = catch_errors gatekeeper healthcheck cache container_sync bulk tempurl ratelimit authtoken keystoneauth container_quotas account_quotas slo dlo versioned_writes proxy-server
 
 [app:proxy-server]
 use = egg:swift#proxy
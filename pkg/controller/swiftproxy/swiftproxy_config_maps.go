@@ -1,12 +1,9 @@
 package swiftproxy
 
 import (
-	"fmt"
-
 	core "k8s.io/api/core/v1"
 
 	contrail "github.com/Juniper/contrail-operator/pkg/apis/contrail/v1alpha1"
-	"github.com/Juniper/contrail-operator/pkg/certificates"
 	"github.com/Juniper/contrail-operator/pkg/k8s"
 )
 
@@ -27,6 +24,18 @@ type keystoneEndpoint struct {
 	region          string
 }
 
+func newKeystoneEndpoint(k *contrail.Keystone) *keystoneEndpoint {
+	keystoneConfiguration := k.ConfigurationParameters()
+	return &keystoneEndpoint{
+		address:         k.Status.Endpoint,
+		port:            keystoneConfiguration.ListenPort,
+		authProtocol:    keystoneConfiguration.AuthProtocol,
+		projectDomainID: keystoneConfiguration.ProjectDomainID,
+		userDomainID:    keystoneConfiguration.UserDomainID,
+		region:          keystoneConfiguration.Region,
+	}
+}
+
 func (r *ReconcileSwiftProxy) configMap(
 	configMapName string,
 	swiftProxy *contrail.SwiftProxy,
@@ -58,22 +67,3 @@ func (c *configMaps) ensureExists(memcachedNode string) error {
 	}
 	return c.cm.EnsureExists(spc)
 }
-
-func (c *configMaps) ensureServiceExists(internalIP string, publicIP string) error {
-	spc := &registerServiceConfig{
-		KeystoneAddress:         c.keystone.address,
-		KeystonePort:            c.keystone.port,
-		KeystoneAdminPassword:   string(c.keystoneAdminPassSecret.Data["password"]),
-		KeystoneAuthProtocol:    c.keystone.authProtocol,
-		KeystoneUserDomainID:    c.keystone.userDomainID,
-		KeystoneProjectDomainID: c.keystone.projectDomainID,
-		KeystoneRegion:          c.keystone.region,
-		SwiftPassword:           string(c.credentialsSecret.Data["password"]),
-		SwiftUser:               string(c.credentialsSecret.Data["user"]),
-		SwiftServiceName:        c.swiftProxySpec.ServiceConfiguration.SwiftServiceName,
-		SwiftPublicEndpoint:     fmt.Sprintf("%v:%v", publicIP, c.swiftProxySpec.ServiceConfiguration.ListenPort),
-		SwiftInternalEndpoint:   fmt.Sprintf("%v:%v", internalIP, c.swiftProxySpec.ServiceConfiguration.ListenPort),
-		CAFilePath:              certificates.SignerCAFilepath,
-	}
-	return c.cm.EnsureExists(spc)
-}
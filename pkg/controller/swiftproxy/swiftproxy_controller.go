@@ -5,7 +5,6 @@ import (
 	"fmt"
 
 	apps "k8s.io/api/apps/v1"
-	batch "k8s.io/api/batch/v1"
 	core "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -14,6 +13,7 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
@@ -40,8 +40,12 @@ func Add(mgr manager.Manager) error {
 
 // newReconciler returns a new reconcile.Reconciler
 func newReconciler(mgr manager.Manager) reconcile.Reconciler {
-	kubernetes := k8s.New(mgr.GetClient(), mgr.GetScheme())
-	return NewReconciler(mgr.GetClient(), mgr.GetScheme(), kubernetes, mgr.GetConfig())
+	recorder := mgr.GetEventRecorderFor("swiftproxy-controller")
+	client := utils.NewEventRecordingClient(mgr.GetClient(), recorder)
+	kubernetes := k8s.New(client, mgr.GetScheme())
+	r := NewReconciler(client, mgr.GetScheme(), kubernetes, mgr.GetConfig())
+	r.recorder = recorder
+	return r
 }
 
 // NewReconciler is used to create a new ReconcileSwiftProxy
@@ -89,10 +93,6 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 		OwnerType: &contrail.SwiftProxy{},
 	})
 
-	err = c.Watch(&source.Kind{Type: &batch.Job{}}, &handler.EnqueueRequestForOwner{
-		OwnerType: &contrail.SwiftProxy{},
-	})
-
 	return err
 }
 
@@ -107,6 +107,7 @@ type ReconcileSwiftProxy struct {
 	scheme     *runtime.Scheme
 	kubernetes *k8s.Kubernetes
 	mgrConfig  *rest.Config
+	recorder   record.EventRecorder
 }
 
 // Reconcile reads that state of the cluster for a SwiftProxy object and makes changes based on the state read
@@ -174,7 +175,7 @@ func (r *ReconcileSwiftProxy) Reconcile(request reconcile.Request) (reconcile.Re
 		return reconcile.Result{}, err
 	}
 	if !keystone.Status.Active {
-		return reconcile.Result{}, nil
+		return reconcile.Result{}, contrail.WaitForDependency(r.client, r.recorder, swiftProxy, &swiftProxy.Status.Conditions, "Keystone", keystone.Name)
 	}
 	if keystone.Status.Endpoint == "" {
 		log.Info(fmt.Sprintf("%q Status.Endpoint empty", keystone.Name))
@@ -189,7 +190,7 @@ func (r *ReconcileSwiftProxy) Reconcile(request reconcile.Request) (reconcile.Re
 		return reconcile.Result{}, err
 	}
 	if !memcached.Status.Active {
-		return reconcile.Result{}, nil
+		return reconcile.Result{}, contrail.WaitForDependency(r.client, r.recorder, swiftProxy, &swiftProxy.Status.Conditions, "Memcached", memcached.Name)
 	}
 
 	adminPasswordSecretName := swiftProxy.Spec.ServiceConfiguration.KeystoneSecretName
@@ -204,14 +205,7 @@ func (r *ReconcileSwiftProxy) Reconcile(request reconcile.Request) (reconcile.Re
 		return reconcile.Result{}, err
 	}
 
-	keystoneData := &keystoneEndpoint{
-		address:         keystone.Status.Endpoint,
-		port:            keystone.Spec.ServiceConfiguration.ListenPort,
-		authProtocol:    keystone.Spec.ServiceConfiguration.AuthProtocol,
-		projectDomainID: keystone.Spec.ServiceConfiguration.ProjectDomainID,
-		userDomainID:    keystone.Spec.ServiceConfiguration.UserDomainID,
-		region:          keystone.Spec.ServiceConfiguration.Region,
-	}
+	keystoneData := newKeystoneEndpoint(keystone)
 	swiftConfigName := swiftProxy.Name + "-swiftproxy-config"
 	cm := r.configMap(swiftConfigName, swiftProxy, keystoneData, adminPasswordSecret, passwordSecret)
 	if err = cm.ensureExists(memcached.Status.Endpoint); err != nil {
@@ -223,13 +217,10 @@ func (r *ReconcileSwiftProxy) Reconcile(request reconcile.Request) (reconcile.Re
 		return reconcile.Result{}, err
 	}
 	if !registered {
-		var res reconcile.Result
-		if res, err = r.ensureSwiftRegistered(swiftProxy, adminPasswordSecret, passwordSecret, keystone); err != nil {
+		if err = r.ensureSwiftRegistered(swiftProxy, adminPasswordSecret, passwordSecret, keystone); err != nil {
 			return reconcile.Result{}, err
 		}
-		if res.Requeue {
-			return res, err
-		}
+		registered = true
 	}
 
 	if len(swiftProxyPods.Items) > 0 && registered {
@@ -305,7 +296,7 @@ func (r *ReconcileSwiftProxy) listSwiftProxyPods(swiftProxyName string) (*core.P
 
 func (r *ReconcileSwiftProxy) ensureCertificatesExist(swiftProxy *contrail.SwiftProxy, pods *core.PodList, serviceIP string, loadBalancerIP ...string) error {
 	subjects := swiftProxy.PodsCertSubjects(pods, serviceIP, loadBalancerIP...)
-	crt := certificates.NewCertificate(r.client, r.scheme, swiftProxy, subjects, "swiftproxy")
+	crt := certificates.NewCertificate(r.client, r.scheme, swiftProxy, subjects, "swiftproxy", utils.CertificateKeyConfig(swiftProxy.Spec.CommonConfiguration.CertificateKey))
 	return crt.EnsureExistsAndIsSigned()
 }
 
@@ -332,6 +323,9 @@ func (r *ReconcileSwiftProxy) updateStatus(
 	deployment *apps.Deployment,
 ) error {
 	sp.Status.FromDeployment(deployment)
+	if err := contrail.SetWorkloadConditions(r.client, &sp.Status.Conditions, sp.Generation, deployment.Namespace, deployment.Spec.Selector, sp.Status.Replicas, sp.Status.ReadyReplicas); err != nil {
+		return err
+	}
 	return r.client.Status().Update(context.Background(), sp)
 }
 
@@ -471,7 +465,6 @@ func updatePodTemplate(
 
 func getImage(containers []*contrail.Container, containerName string) string {
 	var defaultContainersImages = map[string]string{
-		"init":                "localhost:5000/centos-binary-kolla-toolbox:train",
 		"api":                 "localhost:5000/centos-binary-swift-proxy-server:train",
 		"wait-for-ready-conf": "localhost:5000/busybox",
 	}
@@ -485,7 +478,6 @@ func getImage(containers []*contrail.Container, containerName string) string {
 
 func getCommand(containers []*contrail.Container, containerName string) []string {
 	var defaultContainersCommand = map[string][]string{
-		"init":                {"ansible-playbook"},
 		"wait-for-ready-conf": {"sh", "-c", "until grep ready /tmp/podinfo/pod_labels > /dev/null 2>&1; do sleep 1; done"},
 	}
 
@@ -62,7 +62,6 @@ func TestSwiftProxyController(t *testing.T) {
 			),
 			expectedConfigs: []*core.ConfigMap{
 				newExpectedSwiftProxyConfigMap(),
-				newExpectedSwiftProxyInitConfigMap(),
 			},
 			expectedStatus: contrail.SwiftProxyStatus{
 				Status: contrail.Status{
@@ -83,7 +82,6 @@ func TestSwiftProxyController(t *testing.T) {
 				),
 				newExpectedDeployment(apps.DeploymentStatus{}),
 				newExpectedSwiftProxyConfigMap(),
-				newExpectedSwiftProxyInitConfigMap(),
 				newMemcached(),
 				newAdminSecret(),
 				newSwiftSecret(),
@@ -98,7 +96,6 @@ func TestSwiftProxyController(t *testing.T) {
 			),
 			expectedConfigs: []*core.ConfigMap{
 				newExpectedSwiftProxyConfigMap(),
-				newExpectedSwiftProxyInitConfigMap(),
 			},
 			expectedStatus: contrail.SwiftProxyStatus{
 				Status: contrail.Status{
@@ -203,8 +200,8 @@ func TestSwiftProxyController(t *testing.T) {
 			// given state
 			cl := fake.NewFakeClientWithScheme(scheme, tt.initObjs...)
 			kubernetes := k8s.New(cl, scheme)
-			conf := newFakeRestConfg()
-			r := swiftproxy.NewReconciler(cl, scheme, kubernetes, conf)
+			keystone := &fakeKeystone{}
+			r := swiftproxy.NewReconciler(cl, scheme, kubernetes, keystone.restConfig())
 			req := reconcile.Request{
 				NamespacedName: types.NamespacedName{
 					Name:      "swiftproxy",
@@ -216,6 +213,11 @@ func TestSwiftProxyController(t *testing.T) {
 			assert.NoError(t, err)
 			assert.False(t, res.Requeue)
 
+			// then swift is registered in keystone
+			assert.Equal(t, expectedRegistrationRequests, keystone.requests)
+			assert.Contains(t, keystone.bodies, `{"endpoint":{"service_id":"service-id","region_id":"RegionOne","interface":"public","url":"https://10.255.254.4:5070/v1/AUTH_%(tenant_id)s","enabled":true}}`)
+			assert.Contains(t, keystone.bodies, `{"user":{"name":"otherUser","domain_id":"default","default_project_id":"project-id","password":"password2","enabled":true}}`)
+
 			// then expected Deployment is present
 			dep := &apps.Deployment{}
 			exDep := tt.expectedDeployment
@@ -232,7 +234,10 @@ func TestSwiftProxyController(t *testing.T) {
 			sp := &contrail.SwiftProxy{}
 			err = cl.Get(context.Background(), req.NamespacedName, sp)
 			assert.NoError(t, err)
+			conditions := sp.Status.Conditions
+			sp.Status.Conditions = nil
 			assert.Equal(t, tt.expectedStatus, sp.Status)
+			assert.Equal(t, tt.expectedStatus.Active, contrail.IsConditionTrue(conditions, contrail.ConditionReady))
 
 			for _, expConfig := range tt.expectedConfigs {
 				configMap := &core.ConfigMap{}
@@ -259,26 +264,83 @@ func TestSwiftProxyController(t *testing.T) {
 	}
 }
 
+var expectedRegistrationRequests = []string{
+	"POST /v3/auth/tokens",
+	"POST /v3/auth/tokens",
+	"GET /v3/services?type=object-store",
+	"POST /v3/services",
+	"GET /v3/endpoints?interface=admin&service_id=service-id",
+	"POST /v3/endpoints",
+	"GET /v3/endpoints?interface=internal&service_id=service-id",
+	"POST /v3/endpoints",
+	"GET /v3/endpoints?interface=public&service_id=service-id",
+	"POST /v3/endpoints",
+	"GET /v3/projects?domain_id=default&name=service",
+	"POST /v3/projects",
+	"GET /v3/users?domain_id=default&name=otherUser",
+	"POST /v3/users",
+	"GET /v3/roles?name=admin",
+	"POST /v3/roles",
+	"PUT /v3/projects/project-id/users/user-id/roles/role-id",
+	"GET /v3/roles?name=ResellerAdmin",
+	"POST /v3/roles",
+	"PUT /v3/projects/project-id/users/user-id/roles/role-id",
+}
+
 type mockRoundTripFunc func(r *http.Request) (*http.Response, error)
 
 func (m mockRoundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
 	return m(r)
 }
 
-func newFakeRestConfg() *rest.Config {
+// fakeKeystone is an empty Keystone reached through the kube proxy. Only the admin user
+// can authenticate and created resources are given IDs named after their kind.
+type fakeKeystone struct {
+	requests []string
+	bodies   []string
+}
+
+func (f *fakeKeystone) restConfig() *rest.Config {
 	return &rest.Config{
-		Host:    "localhost",
-		APIPath: "/",
-		Transport: mockRoundTripFunc(func(r *http.Request) (*http.Response, error) {
-			requestBody := ioutil.NopCloser(strings.NewReader("everything fine"))
-			return &http.Response{
-				StatusCode: http.StatusUnauthorized,
-				Body:       requestBody,
-			}, nil
-		}),
+		Host:      "localhost",
+		APIPath:   "/",
+		Transport: mockRoundTripFunc(f.roundTrip),
 	}
 }
 
+func (f *fakeKeystone) roundTrip(r *http.Request) (*http.Response, error) {
+	path := r.URL.Path[strings.Index(r.URL.Path, "/proxy/")+len("/proxy"):]
+	request := r.Method + " " + path
+	if r.URL.RawQuery != "" {
+		request += "?" + r.URL.RawQuery
+	}
+	f.requests = append(f.requests, request)
+	var body []byte
+	if r.Body != nil {
+		body, _ = ioutil.ReadAll(r.Body)
+	}
+	f.bodies = append(f.bodies, string(body))
+	response := &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: ioutil.NopCloser(strings.NewReader("{}"))}
+	switch {
+	case path == "/v3/auth/tokens":
+		if !strings.Contains(string(body), `"name":"admin"`) {
+			response.StatusCode = http.StatusUnauthorized
+			break
+		}
+		response.StatusCode = http.StatusCreated
+		response.Header.Set("X-Subject-Token", "admin-token")
+	case r.Header.Get("X-Auth-Token") != "admin-token":
+		response.StatusCode = http.StatusUnauthorized
+	case r.Method == http.MethodPost:
+		kind := strings.TrimSuffix(strings.TrimPrefix(path, "/v3/"), "s")
+		response.StatusCode = http.StatusCreated
+		response.Body = ioutil.NopCloser(strings.NewReader(`{"` + kind + `": {"id": "` + kind + `-id"}}`))
+	case r.Method == http.MethodPut:
+		response.StatusCode = http.StatusNoContent
+	}
+	return response, nil
+}
+
 func newSwiftProxy(status contrail.SwiftProxyStatus) *contrail.SwiftProxy {
 	trueVal := true
 	return &contrail.SwiftProxy{
@@ -621,25 +683,6 @@ func newExpectedSwiftProxyConfigMap() *core.ConfigMap {
 	}
 }
 
-func newExpectedSwiftProxyInitConfigMap() *core.ConfigMap {
-	trueVal := true
-	return &core.ConfigMap{
-		Data: map[string]string{
-			"register.yaml": registerPlaybook,
-			"config.yaml":   registerConfig,
-		},
-		ObjectMeta: meta.ObjectMeta{
-			Name:      "swiftproxy-swiftproxy-register-job",
-			Namespace: "default",
-			Labels:    map[string]string{"contrail_manager": "SwiftProxy", "SwiftProxy": "swiftproxy"},
-			OwnerReferences: []meta.OwnerReference{
-				{"contrail.juniper.net/v1alpha1", "SwiftProxy", "swiftproxy", "", &trueVal, &trueVal},
-			},
-		},
-		TypeMeta: meta.TypeMeta{Kind: "ConfigMap", APIVersion: "v1"},
-	}
-}
-
 func newAdminSecret() *core.Secret {
 	trueVal := true
 	return &core.Secret{
@@ -719,7 +762,7 @@ cert_file = /etc/swift/proxy.crt
 key_file = /etc/swift/proxy.key
 
 [pipeline:main]
-pipeline = catch_errors gatekeeper healthcheck cache container_sync bulk tempurl ratelimit authtoken keystoneauth container_quotas account_quotas slo dlo proxy-server
+pipeline = catch_errors gatekeeper healthcheck cache container_sync bulk tempurl ratelimit authtoken keystoneauth container_quotas account_quotas slo dlo versioned_writes proxy-server
 
 [app:proxy-server]
 use = egg:swift#proxy
@@ -791,84 +834,4 @@ allow_versioned_writes = True
 
 `
 
-const registerPlaybook = `
-- hosts: localhost
-  tasks:
-    - name: create swift service
-      os_keystone_service:
-        name: "{{ service_name }}"
-        service_type: "object-store"
-        description: "object store service"
-        interface: "admin"
-        auth: "{{ openstack_auth }}"
-        ca_cert: "{{ ca_cert_filepath }}"
-
-    - name: create swift endpoints service
-      os_keystone_endpoint:
-        service: "{{ service_name }}"
-        url: "{{ item.url }}"
-        region: "{{ region_name }}"
-        endpoint_interface: "{{ item.interface }}"
-        interface: "admin"
-        auth: "{{ openstack_auth }}"
-        ca_cert: "{{ ca_cert_filepath }}"
-      with_items:
-        - { url: "https://{{ swift_internal_endpoint }}/v1", interface: "admin" }
-        - { url: "https://{{ swift_internal_endpoint }}/v1/AUTH_%(tenant_id)s", interface: "internal" }
-        - { url: "https://{{ swift_public_endpoint }}/v1/AUTH_%(tenant_id)s", interface: "public" }
-    - name: create service project
-      os_project:
-        name: "service"
-        domain: "{{ openstack_auth['domain_id'] }}"
-        interface: "admin"
-        auth: "{{ openstack_auth }}"
-        ca_cert: "{{ ca_cert_filepath }}"
-    - name: create swift user
-      os_user:
-        default_project: "service"
-        name: "{{ swift_user }}"
-        password: "{{ swift_password }}"
-        domain: "{{ openstack_auth['user_domain_id'] }}"
-        interface: "admin"
-        auth: "{{ openstack_auth }}"
-        ca_cert: "{{ ca_cert_filepath }}"
-    - name: create admin role    
-      os_keystone_role:
-        name: "{{ item }}"
-        interface: "admin"
-        auth: "{{ openstack_auth }}"
-        ca_cert: "{{ ca_cert_filepath }}"
-      with_items:
-        - admin
-        - ResellerAdmin
-    - name: grant user role 
-      os_user_role:
-        user: "{{ swift_user }}"
-        role: "admin"
-        project: "service"
-        domain: "{{ openstack_auth['user_domain_id'] }}"
-        interface: "admin"
-        auth: "{{ openstack_auth }}"
-        ca_cert: "{{ ca_cert_filepath }}"
-`
-
-var registerConfig = `
-openstack_auth:
-  auth_url: "https://10.0.2.16:5555/v3"
-  username: "admin"
-  password: "test123"
-  project_name: "admin"
-  domain_id: "default"
-  user_domain_id: "default"
-
-region_name: "RegionOne"
-swift_internal_endpoint: "10.10.10.10:5070"
-swift_public_endpoint: "10.255.254.4:5070"
-swift_password: "password2"
-swift_user: "otherUser"
-service_name: "swift"
-
-ca_cert_filepath: "/etc/ssl/certs/kubernetes/ca-bundle.crt"
-`
-
 const expectedCommandWaitForReadyContainer = "until grep ready /tmp/podinfo/pod_labels > /dev/null 2>&1; do sleep 1; done"
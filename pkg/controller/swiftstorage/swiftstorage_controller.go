@@ -30,7 +30,10 @@ import (
 
 var log = logf.Log.WithName("controller_swiftstorage")
 
-const defaultSwiftStoragePath = "/mnt/swiftstorage"
+const (
+	defaultSwiftStoragePath = "/mnt/swiftstorage"
+	swiftDeviceLabel        = "swift_device"
+)
 
 // Add creates a new SwiftStorage Controller and adds it to the Manager. The Manager will set fields on the Controller
 // and Start it when the Manager is Started.
@@ -39,8 +42,9 @@ func Add(mgr manager.Manager) error {
 }
 
 func newReconciler(mgr manager.Manager) reconcile.Reconciler {
-	return NewReconciler(mgr.GetClient(), mgr.GetScheme(), k8s.New(mgr.GetClient(),
-		mgr.GetScheme()), localvolume.New(mgr.GetClient()),
+	client := utils.NewEventRecordingClient(mgr.GetClient(), mgr.GetEventRecorderFor("swiftstorage-controller"))
+	return NewReconciler(client, mgr.GetScheme(), k8s.New(client,
+		mgr.GetScheme()), localvolume.New(client),
 	)
 }
 
@@ -148,6 +152,9 @@ func (r *ReconcileSwiftStorage) Reconcile(request reconcile.Request) (reconcile.
 			swiftStorage.Status.IPs = append(swiftStorage.Status.IPs, pod.Status.PodIP)
 		}
 	}
+	if err = r.updateRingDevices(swiftStorage, statefulSet, pods.Items); err != nil {
+		return reconcile.Result{}, err
+	}
 	swiftStorage.Status.Active = false
 	intendentReplicas := int32(1)
 	if statefulSet.Spec.Replicas != nil {
@@ -157,41 +164,163 @@ func (r *ReconcileSwiftStorage) Reconcile(request reconcile.Request) (reconcile.
 	if statefulSet.Status.ReadyReplicas == intendentReplicas {
 		swiftStorage.Status.Active = true
 	}
+	if err := contrail.SetWorkloadConditions(r.client, &swiftStorage.Status.Conditions, swiftStorage.Generation, statefulSet.Namespace, statefulSet.Spec.Selector, intendentReplicas, statefulSet.Status.ReadyReplicas); err != nil {
+		return reconcile.Result{}, err
+	}
 
 	return reconcile.Result{}, r.client.Status().Update(context.Background(), swiftStorage)
 }
 
 func (r *ReconcileSwiftStorage) ensureLocalPVsExist(ss *contrail.SwiftStorage) error {
-	path := ss.Spec.ServiceConfiguration.Storage.Path
-	size := ss.Spec.ServiceConfiguration.Storage.Size
-	var storage resource.Quantity
-	var err error
-	if size == "" {
-		storage = resource.MustParse("5Gi")
-	} else {
-		storage, err = resource.ParseQuantity(size)
+	for _, device := range ss.Spec.ServiceConfiguration.StorageDevices() {
+		if !device.ProvisionedByOperator() {
+			continue
+		}
+		storage, err := deviceSize(device)
 		if err != nil {
 			return err
 		}
+		for i := int32(0); i < ss.Spec.CommonConfiguration.GetReplicas(); i++ {
+			name := fmt.Sprintf("%v-swift-data-%v", ss.Name, i)
+			if len(ss.Spec.ServiceConfiguration.Devices) != 0 {
+				name = fmt.Sprintf("%v-swift-data-%v-%v", ss.Name, device.Name, i)
+			}
+			nodeSelectors := ss.Spec.CommonConfiguration.NodeSelector
+			lv, err := r.volumes.New(name, devicePath(ss, device), storage, deviceLabels(ss, device), nodeSelectors)
+			if err != nil {
+				return err
+			}
+			if err := lv.EnsureExists(); err != nil {
+				return err
+			}
+		}
 	}
 
-	if path == "" {
-		path = defaultSwiftStoragePath
+	return nil
+}
+
+func deviceSize(device contrail.SwiftDeviceConfiguration) (resource.Quantity, error) {
+	if device.Storage.Size == "" {
+		return resource.MustParse("5Gi"), nil
+	}
+	return resource.ParseQuantity(device.Storage.Size)
+}
+
+func devicePath(ss *contrail.SwiftStorage, device contrail.SwiftDeviceConfiguration) string {
+	if device.Storage.Path != "" {
+		return device.Storage.Path
 	}
+	if len(ss.Spec.ServiceConfiguration.Devices) == 0 {
+		return defaultSwiftStoragePath
+	}
+	return defaultSwiftStoragePath + "/" + device.Name
+}
 
-	for i := int32(0); i < ss.Spec.CommonConfiguration.GetReplicas(); i++ {
-		name := fmt.Sprintf("%v-swift-data-%v", ss.Name, i)
-		nodeSelectors := ss.Spec.CommonConfiguration.NodeSelector
-		lv, err := r.volumes.New(name, path, storage, ss.Labels, nodeSelectors)
+// deviceLabels distinguish local persistent volumes of devices, so that claims
+// of a device are bound only to its volumes.
+func deviceLabels(ss *contrail.SwiftStorage, device contrail.SwiftDeviceConfiguration) map[string]string {
+	if len(ss.Spec.ServiceConfiguration.Devices) == 0 {
+		return ss.Labels
+	}
+	labels := map[string]string{swiftDeviceLabel: device.Name}
+	for k, v := range ss.Labels {
+		labels[k] = v
+	}
+	return labels
+}
+
+// deviceVolumeName returns the name of the claim template of the device. The single
+// device keeps the name used before devices could be listed, as claim templates
+// of existing stateful sets cannot be changed.
+func deviceVolumeName(ss *contrail.SwiftStorage, device contrail.SwiftDeviceConfiguration) string {
+	if len(ss.Spec.ServiceConfiguration.Devices) == 0 {
+		return "storage-device"
+	}
+	return "device-" + device.Name
+}
+
+func deviceVolumeClaims(ss *contrail.SwiftStorage) ([]core.PersistentVolumeClaim, error) {
+	var claims []core.PersistentVolumeClaim
+	for _, device := range ss.Spec.ServiceConfiguration.StorageDevices() {
+		storage, err := deviceSize(device)
 		if err != nil {
-			return err
+			return nil, err
 		}
-		if err := lv.EnsureExists(); err != nil {
-			return err
+		if len(ss.Spec.ServiceConfiguration.Devices) == 0 {
+			storage = resource.MustParse("5Gi")
+		}
+		storageClassName := "local-storage"
+		selector := &meta.LabelSelector{MatchLabels: deviceLabels(ss, device)}
+		if !device.ProvisionedByOperator() {
+			storageClassName = device.StorageClassName
+			selector = device.Selector
+		}
+		claim := core.PersistentVolumeClaim{
+			ObjectMeta: meta.ObjectMeta{
+				Name:      deviceVolumeName(ss, device),
+				Namespace: ss.Namespace,
+				Labels:    ss.Labels,
+			},
+			Spec: core.PersistentVolumeClaimSpec{
+				AccessModes: []core.PersistentVolumeAccessMode{
+					core.ReadWriteOnce,
+				},
+				Selector: selector,
+				Resources: core.ResourceRequirements{
+					Requests: map[core.ResourceName]resource.Quantity{
+						core.ResourceStorage: storage,
+					},
+				},
+			},
+		}
+		if storageClassName != "" {
+			claim.Spec.StorageClassName = &storageClassName
 		}
+		claims = append(claims, claim)
 	}
+	return claims, nil
+}
 
-	return nil
+// storageInitVolumes returns host paths of local persistent volumes. The init container
+// mounts them, so that they are created before volumes are bound.
+func storageInitVolumes(ss *contrail.SwiftStorage) ([]core.Volume, []core.VolumeMount) {
+	var volumes []core.Volume
+	var mounts []core.VolumeMount
+	initHostPathType := core.HostPathType(core.HostPathDirectoryOrCreate)
+	for _, device := range ss.Spec.ServiceConfiguration.StorageDevices() {
+		if !device.ProvisionedByOperator() {
+			continue
+		}
+		name, mountPath := "swift-storage-init", "/mnt/"
+		if len(ss.Spec.ServiceConfiguration.Devices) != 0 {
+			name, mountPath = "swift-storage-init-"+device.Name, "/mnt/"+device.Name
+		}
+		volumes = append(volumes, core.Volume{
+			Name: name,
+			VolumeSource: core.VolumeSource{
+				HostPath: &core.HostPathVolumeSource{
+					Path: devicePath(ss, device),
+					Type: &initHostPathType,
+				},
+			},
+		})
+		mounts = append(mounts, core.VolumeMount{
+			Name:      name,
+			MountPath: mountPath,
+		})
+	}
+	return volumes, mounts
+}
+
+func deviceVolumeMounts(ss *contrail.SwiftStorage) []core.VolumeMount {
+	var mounts []core.VolumeMount
+	for _, device := range ss.Spec.ServiceConfiguration.StorageDevices() {
+		mounts = append(mounts, core.VolumeMount{
+			Name:      deviceVolumeName(ss, device),
+			MountPath: "/srv/node/" + device.Name,
+		})
+	}
+	return mounts
 }
 
 func (r *ReconcileSwiftStorage) ensureLabelExists(ss *contrail.SwiftStorage) error {
@@ -232,67 +361,29 @@ func (r *ReconcileSwiftStorage) createOrUpdateSts(
 		containersSpec: swiftStorage.Spec.ServiceConfiguration.Containers,
 	}
 
-	_, err := controllerutil.CreateOrUpdate(context.Background(), r.client, statefulSet, func() error {
+	claims, err := deviceVolumeClaims(swiftStorage)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = controllerutil.CreateOrUpdate(context.Background(), r.client, statefulSet, func() error {
 		statefulSet.Spec.Template.ObjectMeta.Labels = swiftStorage.Labels
 		contrail.SetSTSCommonConfiguration(statefulSet, &swiftStorage.Spec.CommonConfiguration)
+		initVolumes, initVolumeMounts := storageInitVolumes(swiftStorage)
 		statefulSet.Spec.Template.Spec.InitContainers = []core.Container{{
-			Name:  "init",
-			Image: cg.getImage("swift-storage-init"),
-			VolumeMounts: []core.VolumeMount{
-				{
-					Name:      "swift-storage-init",
-					MountPath: "/mnt/",
-				},
-			},
+			Name:         "init",
+			Image:        cg.getImage("swift-storage-init"),
+			VolumeMounts: initVolumeMounts,
 		}}
-		statefulSet.Spec.Template.Spec.Containers = r.swiftContainers(swiftStorage.Spec.ServiceConfiguration.Containers, swiftStorage.Spec.ServiceConfiguration.Device)
+		statefulSet.Spec.Template.Spec.Containers = r.swiftContainers(swiftStorage.Spec.ServiceConfiguration.Containers, deviceVolumeMounts(swiftStorage))
 		var swiftGroupId int64 = 0
 		statefulSet.Spec.Template.Spec.SecurityContext = &core.PodSecurityContext{}
 		statefulSet.Spec.Template.Spec.SecurityContext.FSGroup = &swiftGroupId
 		statefulSet.Spec.Template.Spec.SecurityContext.RunAsGroup = &swiftGroupId
 		statefulSet.Spec.Template.Spec.SecurityContext.RunAsUser = &swiftGroupId
 		volumes := r.swiftServicesVolumes(swiftStorage.Name)
-		storageClassName := "local-storage"
-		statefulSet.Spec.VolumeClaimTemplates = []core.PersistentVolumeClaim{
-			{
-				ObjectMeta: meta.ObjectMeta{
-					Name:      "storage-device",
-					Namespace: request.Namespace,
-					Labels:    swiftStorage.Labels,
-				},
-				Spec: core.PersistentVolumeClaimSpec{
-
-					AccessModes: []core.PersistentVolumeAccessMode{
-						core.ReadWriteOnce,
-					},
-					Selector: &meta.LabelSelector{
-						MatchLabels: swiftStorage.Labels,
-					},
-					StorageClassName: &storageClassName,
-					Resources: core.ResourceRequirements{
-						Requests: map[core.ResourceName]resource.Quantity{
-							core.ResourceStorage: resource.MustParse("5Gi"),
-						},
-					},
-				},
-			},
-		}
-
-		storagePath := swiftStorage.Spec.ServiceConfiguration.Storage.Path
-		if storagePath == "" {
-			storagePath = defaultSwiftStoragePath
-		}
-		initHostPathType := core.HostPathType(core.HostPathDirectoryOrCreate)
-		statefulSet.Spec.Template.Spec.Volumes = append([]core.Volume{
-			{
-				Name: "swift-storage-init",
-				VolumeSource: core.VolumeSource{
-					HostPath: &core.HostPathVolumeSource{
-						Path: storagePath,
-						Type: &initHostPathType,
-					},
-				},
-			},
+		statefulSet.Spec.VolumeClaimTemplates = claims
+		statefulSet.Spec.Template.Spec.Volumes = append(append(initVolumes, []core.Volume{
 			{
 				Name: "swift-conf-volume",
 				VolumeSource: core.VolumeSource{
@@ -311,7 +402,13 @@ func (r *ReconcileSwiftStorage) createOrUpdateSts(
 					},
 				},
 			},
-		}, volumes...)
+			{
+				Name: "recon-cache",
+				VolumeSource: core.VolumeSource{
+					EmptyDir: &core.EmptyDirVolumeSource{},
+				},
+			},
+		}...), volumes...)
 		statefulSet.Spec.Template.Spec.Affinity = &core.Affinity{
 			PodAntiAffinity: &core.PodAntiAffinity{
 				RequiredDuringSchedulingIgnoredDuringExecution: []core.PodAffinityTerm{{
@@ -326,10 +423,10 @@ func (r *ReconcileSwiftStorage) createOrUpdateSts(
 	return statefulSet, err
 }
 
-func (r *ReconcileSwiftStorage) swiftContainers(containers []*contrail.Container, device string) []core.Container {
+func (r *ReconcileSwiftStorage) swiftContainers(containers []*contrail.Container, deviceMounts []core.VolumeMount) []core.Container {
 	cg := containerGenerator{
 		containersSpec: containers,
-		device:         device,
+		deviceMounts:   deviceMounts,
 	}
 	return []core.Container{
 		cg.swiftContainer("swift-account-server"),
@@ -350,14 +447,10 @@ func (r *ReconcileSwiftStorage) swiftContainers(containers []*contrail.Container
 
 type containerGenerator struct {
 	containersSpec []*contrail.Container
-	device         string
+	deviceMounts   []core.VolumeMount
 }
 
 func (cg *containerGenerator) swiftContainer(name string) core.Container {
-	deviceMountPointVolumeMount := core.VolumeMount{
-		Name:      "storage-device",
-		MountPath: "/srv/node/" + cg.device,
-	}
 	serviceVolumeMount := core.VolumeMount{
 		Name:      name + "-config-volume",
 		MountPath: "/var/lib/kolla/config_files/",
@@ -376,17 +469,24 @@ func (cg *containerGenerator) swiftContainer(name string) core.Container {
 		MountPath: "/etc/rings",
 	}
 
+	// Replicators, auditors and updaters write their stats to the recon cache
+	// which is read by the recon middleware of servers.
+	reconCacheVolumeMount := core.VolumeMount{
+		Name:      "recon-cache",
+		MountPath: "/var/cache/swift",
+	}
+
 	return core.Container{
 		Name:    name,
 		Image:   cg.getImage(name),
 		Env:     newKollaEnvs(name),
 		Command: cg.getCommand(name),
-		VolumeMounts: []core.VolumeMount{
-			deviceMountPointVolumeMount,
+		VolumeMounts: append(append([]core.VolumeMount{}, cg.deviceMounts...),
 			serviceVolumeMount,
 			swiftConfVolumeMount,
 			ringsVolumeMount,
-		},
+			reconCacheVolumeMount,
+		),
 	}
 }
 
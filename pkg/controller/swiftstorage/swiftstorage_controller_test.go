@@ -350,6 +350,27 @@ func TestSwiftStorageController(t *testing.T) {
 		assertVolumeMountMounted(t, fakeClient, statefulSetName, &expectedMountPoint)
 	})
 
+	t.Run("should mount shared recon cache to all Swift's containers", func(t *testing.T) {
+		// given
+		fakeClient := fake.NewFakeClientWithScheme(scheme, swiftStorageCR)
+		volumes := localvolume.New(fakeClient)
+		reconciler := swiftstorage.NewReconciler(fakeClient, scheme, k8s.New(fakeClient, scheme), volumes)
+		// when
+		_, err = reconciler.Reconcile(reconcile.Request{NamespacedName: name})
+		// then
+		require.NoError(t, err)
+
+		assertVolumeMountedToSTS(t, fakeClient, statefulSetName, core.Volume{
+			Name:         "recon-cache",
+			VolumeSource: core.VolumeSource{EmptyDir: &core.EmptyDirVolumeSource{}},
+		})
+		expectedMountPoint := core.VolumeMount{
+			Name:      "recon-cache",
+			MountPath: "/var/cache/swift",
+		}
+		assertVolumeMountMounted(t, fakeClient, statefulSetName, &expectedMountPoint)
+	})
+
 	t.Run("should update IPs of STS pods", func(t *testing.T) {
 		tests := map[string]struct {
 			podIPs            []string
@@ -389,6 +410,184 @@ func TestSwiftStorageController(t *testing.T) {
 		}
 	})
 
+	t.Run("should place devices in rings by topology of nodes", func(t *testing.T) {
+		// given
+		cr := swiftStorageCR.DeepCopy()
+		cr.Spec.ServiceConfiguration.Nodes = []contrail.SwiftStorageNode{{Name: "node-c", Zone: 7, Weight: 50}}
+		newNode := func(name, region, zone string) *core.Node {
+			return &core.Node{ObjectMeta: meta.ObjectMeta{Name: name, Labels: map[string]string{
+				"topology.kubernetes.io/region": region,
+				"topology.kubernetes.io/zone":   zone,
+			}}}
+		}
+		fakeClient := fake.NewFakeClientWithScheme(scheme, cr,
+			newNode("node-a", "eu", "eu-a"),
+			newNode("node-b", "us", "us-a"),
+			newNode("node-c", "eu", "eu-b"),
+			&core.Node{ObjectMeta: meta.ObjectMeta{Name: "node-d"}},
+		)
+		volumes := localvolume.New(fakeClient)
+		reconciler := swiftstorage.NewReconciler(fakeClient, scheme, k8s.New(fakeClient, scheme), volumes)
+		_, err = reconciler.Reconcile(reconcile.Request{NamespacedName: name})
+		require.NoError(t, err)
+		stsLabels := label.New(contrail.SwiftStorageInstanceType, name.Name)
+		for i, node := range []string{"node-a", "node-b", "node-c", "node-d"} {
+			pod := &core.Pod{
+				ObjectMeta: meta.ObjectMeta{Name: "pod-" + strconv.Itoa(i), Labels: stsLabels},
+				Spec:       core.PodSpec{NodeName: node},
+				Status:     core.PodStatus{PodIP: "192.168.0." + strconv.Itoa(i+1)},
+			}
+			require.NoError(t, fakeClient.Create(context.Background(), pod))
+		}
+		// when
+		_, err = reconciler.Reconcile(reconcile.Request{NamespacedName: name})
+		// then
+		require.NoError(t, err)
+		actualSwiftStorage := lookupSwiftStorage(t, fakeClient, name)
+		assert.Equal(t, []contrail.SwiftStorageDevice{
+			{Pod: "pod-0", NodeName: "node-a", IP: "192.168.0.1", Device: "dev", Region: 2, Zone: 2, Weight: 100},
+			{Pod: "pod-1", NodeName: "node-b", IP: "192.168.0.2", Device: "dev", Region: 3, Zone: 3, Weight: 100},
+			{Pod: "pod-2", NodeName: "node-c", IP: "192.168.0.3", Device: "dev", Region: 2, Zone: 7, Weight: 50},
+			{Pod: "pod-3", NodeName: "node-d", IP: "192.168.0.4", Device: "dev", Region: 1, Zone: 1, Weight: 100},
+		}, actualSwiftStorage.Status.Devices)
+		assert.Equal(t, map[string]int{"eu": 2, "us": 3}, actualSwiftStorage.Status.RegionIDs)
+		assert.Equal(t, map[string]int{"eu-a": 2, "us-a": 3, "eu-b": 4}, actualSwiftStorage.Status.ZoneIDs)
+	})
+
+	t.Run("should keep devices of pods without IP until they are scaled away", func(t *testing.T) {
+		// given
+		cr := swiftStorageCR.DeepCopy()
+		replicas := int32(2)
+		cr.Spec.CommonConfiguration.Replicas = &replicas
+		fakeClient := fake.NewFakeClientWithScheme(scheme, cr)
+		volumes := localvolume.New(fakeClient)
+		reconciler := swiftstorage.NewReconciler(fakeClient, scheme, k8s.New(fakeClient, scheme), volumes)
+		_, err = reconciler.Reconcile(reconcile.Request{NamespacedName: name})
+		require.NoError(t, err)
+		stsLabels := label.New(contrail.SwiftStorageInstanceType, name.Name)
+		deployPod(t, "test-statefulset-0", fakeClient, "192.168.0.1", stsLabels)
+		deployPod(t, "test-statefulset-1", fakeClient, "192.168.0.2", stsLabels)
+		_, err = reconciler.Reconcile(reconcile.Request{NamespacedName: name})
+		require.NoError(t, err)
+		expectedDevices := []contrail.SwiftStorageDevice{
+			{Pod: "test-statefulset-0", IP: "192.168.0.1", Device: "dev", Region: 1, Zone: 1, Weight: 100},
+			{Pod: "test-statefulset-1", IP: "192.168.0.2", Device: "dev", Region: 1, Zone: 1, Weight: 100},
+		}
+		require.Equal(t, expectedDevices, lookupSwiftStorage(t, fakeClient, name).Status.Devices)
+		// when
+		pod := &core.Pod{}
+		require.NoError(t, fakeClient.Get(context.Background(), types.NamespacedName{Name: "test-statefulset-0"}, pod))
+		require.NoError(t, fakeClient.Delete(context.Background(), pod))
+		require.NoError(t, fakeClient.Get(context.Background(), types.NamespacedName{Name: "test-statefulset-1"}, pod))
+		pod.Status.PodIP = ""
+		require.NoError(t, fakeClient.Update(context.Background(), pod))
+		_, err = reconciler.Reconcile(reconcile.Request{NamespacedName: name})
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, expectedDevices, lookupSwiftStorage(t, fakeClient, name).Status.Devices)
+
+		// when
+		actual := lookupSwiftStorage(t, fakeClient, name)
+		replicas = 1
+		actual.Spec.CommonConfiguration.Replicas = &replicas
+		require.NoError(t, fakeClient.Update(context.Background(), actual))
+		require.NoError(t, fakeClient.Delete(context.Background(), pod))
+		_, err = reconciler.Reconcile(reconcile.Request{NamespacedName: name})
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, expectedDevices[:1], lookupSwiftStorage(t, fakeClient, name).Status.Devices)
+	})
+
+	t.Run("when multiple devices are listed", func(t *testing.T) {
+		// given
+		cr := swiftStorageCR.DeepCopy()
+		cr.Spec.ServiceConfiguration.DeviceWeight = 80
+		cr.Spec.ServiceConfiguration.Nodes = []contrail.SwiftStorageNode{{Name: "node-a", Weight: 150}}
+		cr.Spec.ServiceConfiguration.Devices = []contrail.SwiftDeviceConfiguration{
+			{Name: "sdb", Storage: contrail.Storage{Size: "20Gi", Path: "/mnt/sdb"}, Weight: 200},
+			{Name: "sdc"},
+			{Name: "ssd", StorageClassName: "fast", Rings: []contrail.SwiftRing{contrail.SwiftAccountRing, contrail.SwiftContainerRing}},
+			{
+				Name:     "sdd",
+				Selector: &meta.LabelSelector{MatchLabels: map[string]string{"disk": "sdd"}},
+				Rings:    []contrail.SwiftRing{contrail.SwiftObjectRing},
+			},
+		}
+		fakeClient := fake.NewFakeClientWithScheme(scheme, cr)
+		volumes := localvolume.New(fakeClient)
+		reconciler := swiftstorage.NewReconciler(fakeClient, scheme, k8s.New(fakeClient, scheme), volumes)
+		_, err = reconciler.Reconcile(reconcile.Request{NamespacedName: name})
+		require.NoError(t, err)
+		pod := &core.Pod{
+			ObjectMeta: meta.ObjectMeta{Name: "pod-0", Labels: label.New(contrail.SwiftStorageInstanceType, name.Name)},
+			Spec:       core.PodSpec{NodeName: "node-a"},
+			Status:     core.PodStatus{PodIP: "192.168.0.1"},
+		}
+		require.NoError(t, fakeClient.Create(context.Background(), pod))
+		// when
+		_, err = reconciler.Reconcile(reconcile.Request{NamespacedName: name})
+		// then
+		require.NoError(t, err)
+		sts := &apps.StatefulSet{}
+		require.NoError(t, fakeClient.Get(context.Background(), statefulSetName, sts))
+
+		t.Run("should create volume claim template for every device", func(t *testing.T) {
+			require.Len(t, sts.Spec.VolumeClaimTemplates, 4)
+			sdb := sts.Spec.VolumeClaimTemplates[0]
+			assert.Equal(t, "device-sdb", sdb.Name)
+			assert.Equal(t, "local-storage", *sdb.Spec.StorageClassName)
+			assert.Equal(t, map[string]string{"contrail_manager": "SwiftStorage", "SwiftStorage": "test", "swift_device": "sdb"}, sdb.Spec.Selector.MatchLabels)
+			assert.Equal(t, resource.MustParse("20Gi"), sdb.Spec.Resources.Requests[core.ResourceStorage])
+			ssd := sts.Spec.VolumeClaimTemplates[2]
+			assert.Equal(t, "device-ssd", ssd.Name)
+			assert.Equal(t, "fast", *ssd.Spec.StorageClassName)
+			assert.Nil(t, ssd.Spec.Selector)
+			sdd := sts.Spec.VolumeClaimTemplates[3]
+			assert.Equal(t, "device-sdd", sdd.Name)
+			assert.Nil(t, sdd.Spec.StorageClassName)
+			assert.Equal(t, map[string]string{"disk": "sdd"}, sdd.Spec.Selector.MatchLabels)
+		})
+
+		t.Run("should create local persistent volumes only for devices without storage class and selector", func(t *testing.T) {
+			pvs := &core.PersistentVolumeList{}
+			require.NoError(t, fakeClient.List(context.Background(), pvs))
+			paths := map[string]string{}
+			for _, pv := range pvs.Items {
+				paths[pv.Name] = pv.Spec.Local.Path
+			}
+			assert.Equal(t, map[string]string{
+				"test-swift-data-sdb-0": "/mnt/sdb",
+				"test-swift-data-sdc-0": "/mnt/swiftstorage/sdc",
+			}, paths)
+		})
+
+		t.Run("should mount all devices to all Swift's containers", func(t *testing.T) {
+			for _, device := range []string{"sdb", "sdc", "ssd", "sdd"} {
+				assertVolumeMountMounted(t, fakeClient, statefulSetName, &core.VolumeMount{
+					Name:      "device-" + device,
+					MountPath: "/srv/node/" + device,
+				})
+			}
+		})
+
+		t.Run("should create host paths of local persistent volumes", func(t *testing.T) {
+			assert.Equal(t, []core.VolumeMount{
+				{Name: "swift-storage-init-sdb", MountPath: "/mnt/sdb"},
+				{Name: "swift-storage-init-sdc", MountPath: "/mnt/sdc"},
+			}, sts.Spec.Template.Spec.InitContainers[0].VolumeMounts)
+		})
+
+		t.Run("should place every device in rings with the weight of the device before the weight of the node", func(t *testing.T) {
+			actualSwiftStorage := lookupSwiftStorage(t, fakeClient, name)
+			assert.Equal(t, []contrail.SwiftStorageDevice{
+				{Pod: "pod-0", NodeName: "node-a", IP: "192.168.0.1", Device: "sdb", Region: 1, Zone: 1, Weight: 200},
+				{Pod: "pod-0", NodeName: "node-a", IP: "192.168.0.1", Device: "sdc", Region: 1, Zone: 1, Weight: 150},
+				{Pod: "pod-0", NodeName: "node-a", IP: "192.168.0.1", Device: "ssd", Region: 1, Zone: 1, Weight: 150, Rings: []contrail.SwiftRing{"account", "container"}},
+				{Pod: "pod-0", NodeName: "node-a", IP: "192.168.0.1", Device: "sdd", Region: 1, Zone: 1, Weight: 150, Rings: []contrail.SwiftRing{"object"}},
+			}, actualSwiftStorage.Status.Devices)
+		})
+	})
+
 }
 
 func deployPod(t *testing.T, name string, fakeClient client.Client, podIP string, labels map[string]string) {
@@ -480,7 +679,7 @@ func newExpectedAccountAuditorConfigMap() *core.ConfigMap {
 var bootstrapScript = `
 #!/bin/bash
 
-chmod 777 /srv/node/d1
+chmod 777 /srv/node/*
 ln -fs /etc/rings/account.ring.gz /etc/swift/account.ring.gz
 ln -fs /etc/rings/object.ring.gz /etc/swift/object.ring.gz
 ln -fs /etc/rings/container.ring.gz /etc/swift/container.ring.gz
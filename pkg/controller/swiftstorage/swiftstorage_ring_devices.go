@@ -0,0 +1,159 @@
+package swiftstorage
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	apps "k8s.io/api/apps/v1"
+	core "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+
+	contrail "github.com/Juniper/contrail-operator/pkg/apis/contrail/v1alpha1"
+)
+
+const (
+	regionLabel         = "topology.kubernetes.io/region"
+	zoneLabel           = "topology.kubernetes.io/zone"
+	defaultDeviceWeight = 100
+	// unlabelledTopologyID is the region and zone of nodes without topology labels.
+	unlabelledTopologyID = 1
+)
+
+// updateRingDevices places devices of every storage pod in rings. Region and zone
+// are derived from topology labels of the pod's node unless overridden in the spec.
+// Label values are mapped to numbers that are kept in the status, so that adding
+// a new zone never renumbers existing ones. The weight of a device is taken from
+// the device, then from the node and then from DeviceWeight. Pods without an IP,
+// e.g. restarting ones, keep their last known devices unless they were scaled away,
+// so that their partitions are not reassigned.
+func (r *ReconcileSwiftStorage) updateRingDevices(ss *contrail.SwiftStorage, statefulSet *apps.StatefulSet, pods []core.Pod) error {
+	if ss.Status.RegionIDs == nil {
+		ss.Status.RegionIDs = map[string]int{}
+	}
+	if ss.Status.ZoneIDs == nil {
+		ss.Status.ZoneIDs = map[string]int{}
+	}
+	config := ss.Spec.ServiceConfiguration
+	weight := config.DeviceWeight
+	if weight == 0 {
+		weight = defaultDeviceWeight
+	}
+	known := map[string][]contrail.SwiftStorageDevice{}
+	for _, device := range ss.Status.Devices {
+		if device.Pod != "" {
+			known[device.Pod] = append(known[device.Pod], device)
+		}
+	}
+	podsByName := map[string]*core.Pod{}
+	var names []string
+	for i := range pods {
+		podsByName[pods[i].Name] = &pods[i]
+		names = append(names, pods[i].Name)
+	}
+	replicas := int32(1)
+	if statefulSet.Spec.Replicas != nil {
+		replicas = *statefulSet.Spec.Replicas
+	}
+	for i := int32(0); i < replicas; i++ {
+		name := fmt.Sprintf("%s-%d", statefulSet.Name, i)
+		if _, ok := podsByName[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	ss.Status.Devices = nil
+	for _, name := range names {
+		pod, ok := podsByName[name]
+		if !ok || pod.Status.PodIP == "" {
+			ss.Status.Devices = append(ss.Status.Devices, knownDevices(config, known[name])...)
+			continue
+		}
+		nodeLabels, err := r.nodeLabels(pod.Spec.NodeName)
+		if err != nil {
+			return err
+		}
+		region := topologyID(ss.Status.RegionIDs, nodeLabels[regionLabel])
+		zone := topologyID(ss.Status.ZoneIDs, nodeLabels[zoneLabel])
+		for _, d := range config.StorageDevices() {
+			device := contrail.SwiftStorageDevice{
+				Pod:      pod.Name,
+				NodeName: pod.Spec.NodeName,
+				IP:       pod.Status.PodIP,
+				Device:   d.Name,
+				Region:   region,
+				Zone:     zone,
+				Weight:   weight,
+				Rings:    d.Rings,
+			}
+			for _, node := range config.Nodes {
+				if node.Name != pod.Spec.NodeName {
+					continue
+				}
+				if node.Region != 0 {
+					device.Region = node.Region
+				}
+				if node.Zone != 0 {
+					device.Zone = node.Zone
+				}
+				if node.Weight != 0 {
+					device.Weight = node.Weight
+				}
+			}
+			if d.Weight != 0 {
+				device.Weight = d.Weight
+			}
+			ss.Status.Devices = append(ss.Status.Devices, device)
+		}
+	}
+	return nil
+}
+
+// knownDevices returns last known devices of a pod which are still configured.
+func knownDevices(config contrail.SwiftStorageConfiguration, devices []contrail.SwiftStorageDevice) []contrail.SwiftStorageDevice {
+	configured := map[string]bool{}
+	for _, d := range config.StorageDevices() {
+		configured[d.Name] = true
+	}
+	var kept []contrail.SwiftStorageDevice
+	for _, device := range devices {
+		if configured[device.Device] {
+			kept = append(kept, device)
+		}
+	}
+	return kept
+}
+
+func (r *ReconcileSwiftStorage) nodeLabels(nodeName string) (map[string]string, error) {
+	if nodeName == "" {
+		return nil, nil
+	}
+	node := &core.Node{}
+	if err := r.client.Get(context.Background(), types.NamespacedName{Name: nodeName}, node); err != nil {
+		if k8serrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return node.Labels, nil
+}
+
+// topologyID returns the number of the topology label value. Nodes without the label
+// are placed in the first region or zone, which is never assigned to a label value.
+func topologyID(ids map[string]int, value string) int {
+	if value == "" {
+		return unlabelledTopologyID
+	}
+	if id, ok := ids[value]; ok {
+		return id
+	}
+	id := unlabelledTopologyID + 1
+	for _, existing := range ids {
+		if existing >= id {
+			id = existing + 1
+		}
+	}
+	ids[value] = id
+	return id
+}
@@ -0,0 +1,15 @@
+package utils
+
+import (
+	"github.com/Juniper/contrail-operator/pkg/apis/contrail/v1alpha1"
+	"github.com/Juniper/contrail-operator/pkg/certificates"
+)
+
+// CertificateKeyConfig returns the key config of certificates selected by the
+// certificate key. Nil selects default keys.
+func CertificateKeyConfig(k *v1alpha1.CertificateKey) certificates.KeyConfig {
+	if k == nil {
+		return certificates.KeyConfig{}
+	}
+	return certificates.KeyConfig{Algorithm: certificates.KeyAlgorithm(k.Algorithm), Size: k.Size}
+}
@@ -0,0 +1,145 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"sort"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Reasons of events recorded on contrail resources.
+const (
+	ReasonStatefulSetCreated   = "StatefulSetCreated"
+	ReasonStatefulSetUpdated   = "StatefulSetUpdated"
+	ReasonDaemonSetCreated     = "DaemonSetCreated"
+	ReasonDaemonSetUpdated     = "DaemonSetUpdated"
+	ReasonDeploymentCreated    = "DeploymentCreated"
+	ReasonDeploymentUpdated    = "DeploymentUpdated"
+	ReasonConfigurationUpdated = "ConfigurationUpdated"
+	ReasonCertificateIssued    = "CertificateIssued"
+	ReasonUpgradeStateChanged  = "UpgradeStateChanged"
+	ReasonUpgradeFailed        = "UpgradeFailed"
+	ReasonBackupCompleted      = "BackupCompleted"
+	ReasonBackupFailed         = "BackupFailed"
+	ReasonRestoreCompleted     = "RestoreCompleted"
+	ReasonRestoreFailed        = "RestoreFailed"
+	ReasonContainerCreated     = "ContainerCreated"
+	ReasonContainerUpdated     = "ContainerUpdated"
+	ReasonContainerDeleted     = "ContainerDeleted"
+	ReasonContainerRetained    = "ContainerRetained"
+	ReasonDeregisterFailed     = "DeregisterFailed"
+	ReasonCleanupFailed        = "CleanupFailed"
+)
+
+// NewEventRecordingClient returns a client which records events on the controller
+// (owner) of objects written through it when a StatefulSet, DaemonSet or Deployment
+// is created or updated, data of a ConfigMap changes or a certificate is issued
+// into a Secret.
+func NewEventRecordingClient(c client.Client, recorder record.EventRecorder) client.Client {
+	return &eventRecordingClient{Client: c, recorder: recorder}
+}
+
+type eventRecordingClient struct {
+	client.Client
+	recorder record.EventRecorder
+}
+
+func (c *eventRecordingClient) Create(ctx context.Context, obj runtime.Object, opts ...client.CreateOption) error {
+	if err := c.Client.Create(ctx, obj, opts...); err != nil {
+		return err
+	}
+	switch o := obj.(type) {
+	case *appsv1.StatefulSet:
+		c.eventf(o, ReasonStatefulSetCreated, "Created StatefulSet %s", o.Name)
+	case *appsv1.DaemonSet:
+		c.eventf(o, ReasonDaemonSetCreated, "Created DaemonSet %s", o.Name)
+	case *appsv1.Deployment:
+		c.eventf(o, ReasonDeploymentCreated, "Created Deployment %s", o.Name)
+	case *corev1.Secret:
+		c.certificatesIssued(o, nil)
+	}
+	return nil
+}
+
+func (c *eventRecordingClient) Update(ctx context.Context, obj runtime.Object, opts ...client.UpdateOption) error {
+	switch o := obj.(type) {
+	case *corev1.ConfigMap:
+		old := &corev1.ConfigMap{}
+		found := c.Client.Get(ctx, client.ObjectKey{Name: o.Name, Namespace: o.Namespace}, old) == nil
+		if err := c.Client.Update(ctx, obj, opts...); err != nil {
+			return err
+		}
+		if !found || !reflect.DeepEqual(old.Data, o.Data) {
+			c.eventf(o, ReasonConfigurationUpdated, "Updated configuration in ConfigMap %s", o.Name)
+		}
+		return nil
+	case *corev1.Secret:
+		old := &corev1.Secret{}
+		if err := c.Client.Get(ctx, client.ObjectKey{Name: o.Name, Namespace: o.Namespace}, old); err != nil {
+			old.Data = nil
+		}
+		if err := c.Client.Update(ctx, obj, opts...); err != nil {
+			return err
+		}
+		c.certificatesIssued(o, old.Data)
+		return nil
+	}
+	if err := c.Client.Update(ctx, obj, opts...); err != nil {
+		return err
+	}
+	switch o := obj.(type) {
+	case *appsv1.StatefulSet:
+		c.eventf(o, ReasonStatefulSetUpdated, "Updated StatefulSet %s", o.Name)
+	case *appsv1.DaemonSet:
+		c.eventf(o, ReasonDaemonSetUpdated, "Updated DaemonSet %s", o.Name)
+	case *appsv1.Deployment:
+		c.eventf(o, ReasonDeploymentUpdated, "Updated Deployment %s", o.Name)
+	}
+	return nil
+}
+
+// certificatesIssued records an event when the secret contains
+// certificates which are not present in old data of the secret.
+func (c *eventRecordingClient) certificatesIssued(secret *corev1.Secret, old map[string][]byte) {
+	var issued []string
+	for key, data := range secret.Data {
+		if strings.HasSuffix(key, ".crt") && !bytes.Equal(old[key], data) {
+			issued = append(issued, key)
+		}
+	}
+	if len(issued) == 0 {
+		return
+	}
+	sort.Strings(issued)
+	c.eventf(secret, ReasonCertificateIssued, "Issued %s in Secret %s", strings.Join(issued, ", "), secret.Name)
+}
+
+// eventf records the event on the controller of the object.
+// Objects without a controller are skipped.
+func (c *eventRecordingClient) eventf(obj runtime.Object, reason, messageFmt string, args ...interface{}) {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return
+	}
+	owner := metav1.GetControllerOf(accessor)
+	if owner == nil {
+		return
+	}
+	ref := &corev1.ObjectReference{
+		APIVersion: owner.APIVersion,
+		Kind:       owner.Kind,
+		Name:       owner.Name,
+		Namespace:  accessor.GetNamespace(),
+		UID:        owner.UID,
+	}
+	c.recorder.Eventf(ref, corev1.EventTypeNormal, reason, messageFmt, args...)
+}
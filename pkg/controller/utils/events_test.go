@@ -0,0 +1,96 @@
+package utils_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apps "k8s.io/api/apps/v1"
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/Juniper/contrail-operator/pkg/controller/utils"
+)
+
+func TestEventRecordingClient(t *testing.T) {
+	trueVal := true
+	owner := meta.OwnerReference{
+		APIVersion: "contrail.juniper.net/v1alpha1", Kind: "Cassandra", Name: "cassandra1", UID: "uid", Controller: &trueVal,
+	}
+	objectMeta := func(name string) meta.ObjectMeta {
+		return meta.ObjectMeta{Name: name, Namespace: "default", OwnerReferences: []meta.OwnerReference{owner}}
+	}
+	newClient := func(objs ...runtime.Object) (client.Client, *record.FakeRecorder) {
+		recorder := record.NewFakeRecorder(10)
+		return utils.NewEventRecordingClient(fake.NewFakeClientWithScheme(scheme.Scheme, objs...), recorder), recorder
+	}
+
+	t.Run("should record creation and update of StatefulSet", func(t *testing.T) {
+		c, recorder := newClient()
+		sts := &apps.StatefulSet{ObjectMeta: objectMeta("cassandra1-cassandra-statefulset")}
+		// when
+		require.NoError(t, c.Create(context.Background(), sts))
+		require.NoError(t, c.Update(context.Background(), sts))
+		// then
+		assert.Equal(t, []string{
+			"Normal StatefulSetCreated Created StatefulSet cassandra1-cassandra-statefulset",
+			"Normal StatefulSetUpdated Updated StatefulSet cassandra1-cassandra-statefulset",
+		}, events(recorder))
+	})
+
+	t.Run("should record update of ConfigMap only when data changes", func(t *testing.T) {
+		configMap := &core.ConfigMap{ObjectMeta: objectMeta("cassandra1-cassandra-configmap"), Data: map[string]string{"a": "1"}}
+		c, recorder := newClient(configMap.DeepCopy())
+		// when
+		require.NoError(t, c.Update(context.Background(), configMap))
+		configMap.Data["a"] = "2"
+		require.NoError(t, c.Update(context.Background(), configMap))
+		// then
+		assert.Equal(t, []string{
+			"Normal ConfigurationUpdated Updated configuration in ConfigMap cassandra1-cassandra-configmap",
+		}, events(recorder))
+	})
+
+	t.Run("should record certificates issued into Secret", func(t *testing.T) {
+		secret := &core.Secret{
+			ObjectMeta: objectMeta("cassandra1-secret-certificates"),
+			Data:       map[string][]byte{"server-10.0.0.1.crt": []byte("a"), "server-key-10.0.0.1.pem": []byte("key")},
+		}
+		c, recorder := newClient(secret.DeepCopy())
+		// when
+		secret.Data["server-10.0.0.2.crt"] = []byte("b")
+		secret.Data["server-key-10.0.0.2.pem"] = []byte("key")
+		require.NoError(t, c.Update(context.Background(), secret))
+		// then
+		assert.Equal(t, []string{
+			"Normal CertificateIssued Issued server-10.0.0.2.crt in Secret cassandra1-secret-certificates",
+		}, events(recorder))
+	})
+
+	t.Run("should not record events of objects without controller", func(t *testing.T) {
+		c, recorder := newClient()
+		sts := &apps.StatefulSet{ObjectMeta: meta.ObjectMeta{Name: "sts", Namespace: "default"}}
+		// when
+		require.NoError(t, c.Create(context.Background(), sts))
+		// then
+		assert.Empty(t, events(recorder))
+	})
+}
+
+func events(recorder *record.FakeRecorder) []string {
+	var events []string
+	for {
+		select {
+		case event := <-recorder.Events:
+			events = append(events, event)
+		default:
+			return events
+		}
+	}
+}
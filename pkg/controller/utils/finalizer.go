@@ -0,0 +1,53 @@
+package utils
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// CleanupFinalizer is set on resources which have to clean up state
+// outside of their owned objects before they are deleted.
+const CleanupFinalizer = "contrail.juniper.net/cleanup"
+
+// HasFinalizer returns true when the object has the finalizer.
+func HasFinalizer(o metav1.Object, finalizer string) bool {
+	for _, f := range o.GetFinalizers() {
+		if f == finalizer {
+			return true
+		}
+	}
+	return false
+}
+
+// EnsureFinalizer adds the finalizer to the object and updates the object
+// when the finalizer is not set yet.
+func EnsureFinalizer(c client.Client, obj runtime.Object, finalizer string) error {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return err
+	}
+	if HasFinalizer(accessor, finalizer) {
+		return nil
+	}
+	controllerutil.AddFinalizer(accessor, finalizer)
+	return c.Update(context.TODO(), obj)
+}
+
+// RemoveFinalizer removes the finalizer from the object and updates the object
+// when the finalizer is set.
+func RemoveFinalizer(c client.Client, obj runtime.Object, finalizer string) error {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return err
+	}
+	if !HasFinalizer(accessor, finalizer) {
+		return nil
+	}
+	controllerutil.RemoveFinalizer(accessor, finalizer)
+	return c.Update(context.TODO(), obj)
+}
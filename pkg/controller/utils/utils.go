@@ -486,6 +486,25 @@ func RabbitmqActiveChange() predicate.Funcs {
 	}
 }
 
+// KeystoneAvailableChange returns predicate which checks if keystone became available.
+func KeystoneAvailableChange() predicate.Funcs {
+	return predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			oldKeystone, ok := e.ObjectOld.(*v1alpha1.Keystone)
+			if !ok {
+				reqLogger.Info("type conversion mismatch")
+				return false
+			}
+			newKeystone, ok := e.ObjectNew.(*v1alpha1.Keystone)
+			if !ok {
+				reqLogger.Info("type conversion mismatch")
+				return false
+			}
+			return !oldKeystone.IsAvailable() && newKeystone.IsAvailable()
+		},
+	}
+}
+
 // ZookeeperActiveChange returns predicate function based on group kind.
 func ZookeeperActiveChange() predicate.Funcs {
 	return predicate.Funcs{
@@ -530,6 +549,9 @@ func MergeCommonConfiguration(manager v1alpha1.ManagerConfiguration,
 	if len(instance.Tolerations) == 0 && len(manager.Tolerations) > 0 {
 		instance.Tolerations = manager.Tolerations
 	}
+	if instance.CertificateKey == nil && manager.CertificateKey != nil {
+		instance.CertificateKey = manager.CertificateKey
+	}
 	return instance
 }
 
@@ -0,0 +1,61 @@
+package utils
+
+import (
+	batch "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/Juniper/contrail-operator/pkg/apis/contrail/v1alpha1"
+)
+
+// WipeContainerName is the name of the container of the job removing data
+// from a persistent volume. Its image can be set in containers of the resource.
+const WipeContainerName = "wipe"
+
+// DefaultWipeImage is the image of the wipe container when it is not set.
+const DefaultWipeImage = "localhost:5000/busybox:1.31"
+
+// WipeImage returns the image of the wipe container from containers or the default one.
+func WipeImage(containers []*v1alpha1.Container) string {
+	if c := GetContainerFromList(WipeContainerName, containers); c != nil && c.Image != "" {
+		return c.Image
+	}
+	return DefaultWipeImage
+}
+
+// WipeJob returns the job removing all data from the persistent volume claim.
+// It tolerates all taints, so that volumes local to any node can be wiped.
+func WipeJob(claim *corev1.PersistentVolumeClaim, image string) *batch.Job {
+	backoffLimit := int32(3)
+	return &batch.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      claim.Name + "-wipe",
+			Namespace: claim.Namespace,
+		},
+		Spec: batch.JobSpec{
+			BackoffLimit: &backoffLimit,
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					RestartPolicy: corev1.RestartPolicyNever,
+					Tolerations: []corev1.Toleration{
+						{Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule},
+						{Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoExecute},
+					},
+					Containers: []corev1.Container{{
+						Name:            WipeContainerName,
+						Image:           image,
+						ImagePullPolicy: corev1.PullIfNotPresent,
+						Command:         []string{"sh", "-c", "rm -rf /data/..?* /data/.[!.]* /data/*"},
+						VolumeMounts:    []corev1.VolumeMount{{Name: "data", MountPath: "/data"}},
+					}},
+					Volumes: []corev1.Volume{{
+						Name: "data",
+						VolumeSource: corev1.VolumeSource{
+							PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: claim.Name},
+						},
+					}},
+				},
+			},
+		},
+	}
+}
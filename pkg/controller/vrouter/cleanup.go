@@ -0,0 +1,148 @@
+package vrouter
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	apps "k8s.io/api/apps/v1"
+	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/Juniper/contrail-operator/pkg/apis/contrail/v1alpha1"
+	"github.com/Juniper/contrail-operator/pkg/controller/utils"
+)
+
+const cleanupRequeuePeriod = 5 * time.Second
+
+// cleanupTimeout is how long after the deletion of the vRouter the cleanup is
+// waited for, before the finalizer is removed anyway.
+const cleanupTimeout = 10 * time.Minute
+
+// cleanupCommand removes state left by vRouter on the host. Logs are kept.
+const cleanupCommand = "rm -rf /var/lib/contrail/* /var/contrail/crashes/* " +
+	"/etc/sysconfig/network-scripts/ifcfg-vhost0 /etc/sysconfig/network-scripts/route-vhost0 " +
+	"/host/usr/bin/contrail-status /host/usr/bin/contrail-tools"
+
+// cleanup removes vRouter pods and then runs a daemon set removing vRouter state
+// from the nodes. The finalizer is removed when the cleanup has run on all nodes.
+// When it does not complete within cleanupTimeout, e.g. because a node is not ready,
+// a warning event is recorded and the finalizer is removed anyway.
+func (r *ReconcileVrouter) cleanup(instance *v1alpha1.Vrouter) (reconcile.Result, error) {
+	daemonSet := &apps.DaemonSet{}
+	name := types.NamespacedName{Name: instance.Name + "-vrouter-daemonset", Namespace: instance.Namespace}
+	err := r.Client.Get(context.TODO(), name, daemonSet)
+	if err == nil && daemonSet.DeletionTimestamp.IsZero() {
+		if err = r.Client.Delete(context.TODO(), daemonSet); err != nil && !errors.IsNotFound(err) {
+			return reconcile.Result{}, err
+		}
+	} else if err != nil && !errors.IsNotFound(err) {
+		return reconcile.Result{}, err
+	}
+
+	pods := &core.PodList{}
+	labels := client.MatchingLabels{"contrail_manager": "vrouter", "vrouter": instance.Name}
+	if err = r.Client.List(context.TODO(), pods, client.InNamespace(instance.Namespace), labels); err != nil {
+		return reconcile.Result{}, err
+	}
+	if len(pods.Items) > 0 {
+		return r.waitForCleanup(instance, nil, fmt.Sprintf("%d vRouter pods are still running", len(pods.Items)))
+	}
+
+	cleanupDaemonSet := newCleanupDaemonSet(instance)
+	name = types.NamespacedName{Name: cleanupDaemonSet.Name, Namespace: cleanupDaemonSet.Namespace}
+	err = r.Client.Get(context.TODO(), name, cleanupDaemonSet)
+	if errors.IsNotFound(err) {
+		log.Info("Cleaning up vRouter nodes", "Vrouter", instance.Name)
+		return reconcile.Result{RequeueAfter: cleanupRequeuePeriod}, r.Client.Create(context.TODO(), newCleanupDaemonSet(instance))
+	}
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	status := cleanupDaemonSet.Status
+	if status.ObservedGeneration < cleanupDaemonSet.Generation || status.NumberReady < status.DesiredNumberScheduled {
+		return r.waitForCleanup(instance, cleanupDaemonSet, fmt.Sprintf("cleanup has run on %d of %d nodes", status.NumberReady, status.DesiredNumberScheduled))
+	}
+	return reconcile.Result{}, r.finishCleanup(instance, cleanupDaemonSet)
+}
+
+// waitForCleanup requeues the cleanup until cleanupTimeout passes since the deletion
+// of the vRouter. Then the cleanup is given up.
+func (r *ReconcileVrouter) waitForCleanup(instance *v1alpha1.Vrouter, cleanupDaemonSet *apps.DaemonSet, progress string) (reconcile.Result, error) {
+	if time.Since(instance.DeletionTimestamp.Time) < cleanupTimeout {
+		return reconcile.Result{RequeueAfter: cleanupRequeuePeriod}, nil
+	}
+	r.Recorder.Eventf(instance, core.EventTypeWarning, utils.ReasonCleanupFailed,
+		"Gave up cleaning up vRouter nodes after %s, %s", cleanupTimeout, progress)
+	return reconcile.Result{}, r.finishCleanup(instance, cleanupDaemonSet)
+}
+
+func (r *ReconcileVrouter) finishCleanup(instance *v1alpha1.Vrouter, cleanupDaemonSet *apps.DaemonSet) error {
+	if cleanupDaemonSet != nil {
+		background := client.PropagationPolicy(meta.DeletePropagationBackground)
+		if err := r.Client.Delete(context.TODO(), cleanupDaemonSet, background); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+	return utils.RemoveFinalizer(r.Client, instance, utils.CleanupFinalizer)
+}
+
+// newCleanupDaemonSet returns daemon set running the cleanup on nodes of the vRouter.
+// Pods become ready once the cleanup is done. The daemon set is not owned by the vRouter,
+// so that it is not garbage collected while the vRouter is being deleted.
+func newCleanupDaemonSet(instance *v1alpha1.Vrouter) *apps.DaemonSet {
+	image := utils.WipeImage(instance.Spec.ServiceConfiguration.Containers)
+	labels := map[string]string{"contrail_manager": "vrouter-cleanup", "vrouter-cleanup": instance.Name}
+	hostPathVolume := func(name, path string) core.Volume {
+		return core.Volume{Name: name, VolumeSource: core.VolumeSource{HostPath: &core.HostPathVolumeSource{Path: path}}}
+	}
+	ds := &apps.DaemonSet{
+		ObjectMeta: meta.ObjectMeta{
+			Name:      instance.Name + "-vrouter-cleanup-daemonset",
+			Namespace: instance.Namespace,
+			Labels:    labels,
+		},
+		Spec: apps.DaemonSetSpec{
+			Selector: &meta.LabelSelector{MatchLabels: labels},
+			Template: core.PodTemplateSpec{
+				ObjectMeta: meta.ObjectMeta{Labels: labels},
+				Spec: core.PodSpec{
+					Tolerations: []core.Toleration{
+						{Operator: core.TolerationOpExists, Effect: core.TaintEffectNoSchedule},
+						{Operator: core.TolerationOpExists, Effect: core.TaintEffectNoExecute},
+					},
+					InitContainers: []core.Container{{
+						Name:            "cleanup",
+						Image:           image,
+						ImagePullPolicy: core.PullIfNotPresent,
+						Command:         []string{"sh", "-c", cleanupCommand},
+						VolumeMounts: []core.VolumeMount{
+							{Name: "var-lib-contrail", MountPath: "/var/lib/contrail"},
+							{Name: "var-crashes", MountPath: "/var/contrail/crashes"},
+							{Name: "network-config-files", MountPath: "/etc/sysconfig/network-scripts"},
+							{Name: "host-usr-local-bin", MountPath: "/host/usr/bin"},
+						},
+					}},
+					Containers: []core.Container{{
+						Name:            "wait",
+						Image:           image,
+						ImagePullPolicy: core.PullIfNotPresent,
+						Command:         []string{"sh", "-c", "while true; do sleep 3600; done"},
+					}},
+					Volumes: []core.Volume{
+						hostPathVolume("var-lib-contrail", "/var/lib/contrail"),
+						hostPathVolume("var-crashes", "/var/contrail/crashes"),
+						hostPathVolume("network-config-files", "/etc/sysconfig/network-scripts"),
+						hostPathVolume("host-usr-local-bin", "/usr/local/bin"),
+					},
+				},
+			},
+		},
+	}
+	v1alpha1.SetDSCommonConfiguration(ds, &instance.Spec.CommonConfiguration)
+	return ds
+}
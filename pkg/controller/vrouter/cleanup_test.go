@@ -0,0 +1,131 @@
+package vrouter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apps "k8s.io/api/apps/v1"
+	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	contrail "github.com/Juniper/contrail-operator/pkg/apis/contrail/v1alpha1"
+	"github.com/Juniper/contrail-operator/pkg/controller/utils"
+)
+
+func TestVrouterCleanup(t *testing.T) {
+	scheme, err := contrail.SchemeBuilder.Build()
+	require.NoError(t, err)
+	require.NoError(t, core.SchemeBuilder.AddToScheme(scheme))
+	require.NoError(t, apps.SchemeBuilder.AddToScheme(scheme))
+	now := v1.Now()
+	name := types.NamespacedName{Namespace: "default", Name: "vrouter1"}
+	vrouter := &contrail.Vrouter{
+		ObjectMeta: v1.ObjectMeta{
+			Namespace: name.Namespace, Name: name.Name,
+			DeletionTimestamp: &now, Finalizers: []string{utils.CleanupFinalizer},
+		},
+		Spec: contrail.VrouterSpec{
+			ServiceConfiguration: contrail.VrouterServiceConfiguration{
+				VrouterConfiguration: contrail.VrouterConfiguration{
+					Containers: []*contrail.Container{{Name: "wipe", Image: "wipe-image"}},
+				},
+			},
+			CommonConfiguration: contrail.PodConfiguration{
+				NodeSelector: map[string]string{"node-role.opencontrail.org": "vrouter"},
+			},
+		},
+	}
+	daemonSet := &apps.DaemonSet{ObjectMeta: v1.ObjectMeta{Namespace: name.Namespace, Name: "vrouter1-vrouter-daemonset"}}
+	pod := &core.Pod{ObjectMeta: v1.ObjectMeta{
+		Namespace: name.Namespace, Name: "vrouter1-vrouter-daemonset-abcde",
+		Labels: map[string]string{"contrail_manager": "vrouter", "vrouter": "vrouter1"},
+	}}
+	cleanupName := types.NamespacedName{Namespace: name.Namespace, Name: "vrouter1-vrouter-cleanup-daemonset"}
+	cl := fake.NewFakeClientWithScheme(scheme, vrouter, daemonSet, pod)
+	r := &ReconcileVrouter{Client: cl, Scheme: scheme}
+	req := reconcile.Request{NamespacedName: name}
+
+	t.Run("should delete vRouter daemon set and wait for pods", func(t *testing.T) {
+		// when
+		result, err := r.Reconcile(req)
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, cleanupRequeuePeriod, result.RequeueAfter)
+		err = cl.Get(context.Background(), types.NamespacedName{Namespace: name.Namespace, Name: daemonSet.Name}, &apps.DaemonSet{})
+		assert.True(t, errors.IsNotFound(err))
+		err = cl.Get(context.Background(), cleanupName, &apps.DaemonSet{})
+		assert.True(t, errors.IsNotFound(err))
+	})
+
+	t.Run("should create cleanup daemon set when pods are gone", func(t *testing.T) {
+		require.NoError(t, cl.Delete(context.Background(), pod))
+		// when
+		result, err := r.Reconcile(req)
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, cleanupRequeuePeriod, result.RequeueAfter)
+		cleanup := &apps.DaemonSet{}
+		require.NoError(t, cl.Get(context.Background(), cleanupName, cleanup))
+		assert.Empty(t, cleanup.OwnerReferences)
+		assert.Equal(t, map[string]string{"node-role.opencontrail.org": "vrouter"}, cleanup.Spec.Template.Spec.NodeSelector)
+		require.Len(t, cleanup.Spec.Template.Spec.InitContainers, 1)
+		assert.Equal(t, "wipe-image", cleanup.Spec.Template.Spec.InitContainers[0].Image)
+	})
+
+	t.Run("should remove finalizer when cleanup has run on all nodes", func(t *testing.T) {
+		cleanup := &apps.DaemonSet{}
+		require.NoError(t, cl.Get(context.Background(), cleanupName, cleanup))
+		cleanup.Status = apps.DaemonSetStatus{DesiredNumberScheduled: 2, NumberReady: 2}
+		require.NoError(t, cl.Status().Update(context.Background(), cleanup))
+		// when
+		result, err := r.Reconcile(req)
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, reconcile.Result{}, result)
+		err = cl.Get(context.Background(), cleanupName, &apps.DaemonSet{})
+		assert.True(t, errors.IsNotFound(err))
+		instance := &contrail.Vrouter{}
+		require.NoError(t, cl.Get(context.Background(), name, instance))
+		assert.False(t, utils.HasFinalizer(instance, utils.CleanupFinalizer))
+	})
+}
+
+func TestVrouterCleanupTimeout(t *testing.T) {
+	scheme, err := contrail.SchemeBuilder.Build()
+	require.NoError(t, err)
+	require.NoError(t, core.SchemeBuilder.AddToScheme(scheme))
+	require.NoError(t, apps.SchemeBuilder.AddToScheme(scheme))
+	deleted := v1.NewTime(time.Now().Add(-cleanupTimeout - time.Minute))
+	name := types.NamespacedName{Namespace: "default", Name: "vrouter1"}
+	vrouter := &contrail.Vrouter{ObjectMeta: v1.ObjectMeta{
+		Namespace: name.Namespace, Name: name.Name,
+		DeletionTimestamp: &deleted, Finalizers: []string{utils.CleanupFinalizer},
+	}}
+	cleanupName := types.NamespacedName{Namespace: name.Namespace, Name: "vrouter1-vrouter-cleanup-daemonset"}
+	cleanup := newCleanupDaemonSet(vrouter)
+	cleanup.Status = apps.DaemonSetStatus{DesiredNumberScheduled: 2, NumberReady: 1}
+	cl := fake.NewFakeClientWithScheme(scheme, vrouter, cleanup)
+	recorder := record.NewFakeRecorder(10)
+	r := &ReconcileVrouter{Client: cl, Scheme: scheme, Recorder: recorder}
+	// when
+	result, err := r.Reconcile(reconcile.Request{NamespacedName: name})
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, reconcile.Result{}, result)
+	assert.Equal(t, utils.DefaultWipeImage, cleanup.Spec.Template.Spec.InitContainers[0].Image)
+	err = cl.Get(context.Background(), cleanupName, &apps.DaemonSet{})
+	assert.True(t, errors.IsNotFound(err))
+	instance := &contrail.Vrouter{}
+	require.NoError(t, cl.Get(context.Background(), name, instance))
+	assert.False(t, utils.HasFinalizer(instance, utils.CleanupFinalizer))
+	require.Len(t, recorder.Events, 1)
+	assert.Equal(t, "Warning CleanupFailed Gave up cleaning up vRouter nodes after 10m0s, cleanup has run on 1 of 2 nodes", <-recorder.Events)
+}
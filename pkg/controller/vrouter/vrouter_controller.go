@@ -7,6 +7,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/workqueue"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
@@ -97,7 +98,9 @@ func Add(mgr manager.Manager) error {
 
 // newReconciler returns a new reconcile.Reconciler.
 func newReconciler(mgr manager.Manager) reconcile.Reconciler {
-	return NewReconciler(mgr.GetClient(), mgr.GetScheme(), mgr.GetConfig())
+	recorder := mgr.GetEventRecorderFor("vrouter-controller")
+	client := utils.NewEventRecordingClient(mgr.GetClient(), recorder)
+	return &ReconcileVrouter{Client: client, Scheme: mgr.GetScheme(), Config: mgr.GetConfig(), Recorder: recorder}
 }
 
 // NewReconciler returns a new reconcile.Reconciler.
@@ -171,9 +174,10 @@ var _ reconcile.Reconciler = &ReconcileVrouter{}
 type ReconcileVrouter struct {
 	// This client, initialized using mgr.Client() above, is a split client
 	// that reads objects from the cache and writes to the apiserver.
-	Client client.Client
-	Scheme *runtime.Scheme
-	Config *rest.Config
+	Client   client.Client
+	Scheme   *runtime.Scheme
+	Config   *rest.Config
+	Recorder record.EventRecorder
 }
 
 // Reconcile reads that state of the cluster for a Vrouter object and makes changes based on the state read
@@ -189,7 +193,14 @@ func (r *ReconcileVrouter) Reconcile(request reconcile.Request) (reconcile.Resul
 	}
 
 	if !instance.GetDeletionTimestamp().IsZero() {
-		return reconcile.Result{}, nil
+		if !utils.HasFinalizer(instance, utils.CleanupFinalizer) {
+			return reconcile.Result{}, nil
+		}
+		return r.cleanup(instance)
+	}
+
+	if err := utils.EnsureFinalizer(r.Client, instance, utils.CleanupFinalizer); err != nil {
+		return reconcile.Result{}, err
 	}
 
 	configMap, err := instance.CreateConfigMap(request.Name+"-"+instanceType+"-configmap", r.Client, r.Scheme, request)
@@ -542,6 +553,6 @@ func (r *ReconcileVrouter) Reconcile(request reconcile.Request) (reconcile.Resul
 
 func (r *ReconcileVrouter) ensureCertificatesExist(vrouter *v1alpha1.Vrouter, pods *corev1.PodList, instanceType string) error {
 	subjects := vrouter.PodsCertSubjects(pods)
-	crt := certificates.NewCertificate(r.Client, r.Scheme, vrouter, subjects, instanceType)
+	crt := certificates.NewCertificate(r.Client, r.Scheme, vrouter, subjects, instanceType, utils.CertificateKeyConfig(vrouter.Spec.CommonConfiguration.CertificateKey))
 	return crt.EnsureExistsAndIsSigned()
 }
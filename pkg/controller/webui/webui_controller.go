@@ -13,6 +13,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/workqueue"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
@@ -98,11 +99,14 @@ func Add(mgr manager.Manager) error {
 
 // newReconciler returns a new reconcile.Reconciler.
 func newReconciler(mgr manager.Manager) reconcile.Reconciler {
+	recorder := mgr.GetEventRecorderFor("webui-controller")
+	client := utils.NewEventRecordingClient(mgr.GetClient(), recorder)
 	return &ReconcileWebui{
-		Client:     mgr.GetClient(),
+		Client:     client,
 		Scheme:     mgr.GetScheme(),
 		Manager:    mgr,
-		Kubernetes: k8s.New(mgr.GetClient(), mgr.GetScheme()),
+		Kubernetes: k8s.New(client, mgr.GetScheme()),
+		Recorder:   recorder,
 	}
 }
 
@@ -181,6 +185,7 @@ type ReconcileWebui struct {
 	Scheme     *runtime.Scheme
 	Manager    manager.Manager
 	Kubernetes *k8s.Kubernetes
+	Recorder   record.EventRecorder
 }
 
 // Reconcile reads that state of the cluster for a Webui object and makes changes based on the state read
@@ -212,7 +217,7 @@ func (r *ReconcileWebui) Reconcile(request reconcile.Request) (reconcile.Result,
 
 	configActive := configInstance.IsActive(instance.Labels["contrail_cluster"], request.Namespace, r.Client)
 	if !configActive {
-		return reconcile.Result{}, nil
+		return reconcile.Result{}, v1alpha1.WaitForDependency(r.Client, r.Recorder, instance, &instance.Status.Conditions, "Config", configInstance.InstanceName(instance.Labels["contrail_cluster"], request.Namespace, r.Client))
 	}
 
 	configMap, err := instance.CreateConfigMap(request.Name+"-"+instanceType+"-configmap", r.Client, r.Scheme, request)
@@ -526,6 +531,9 @@ func (r *ReconcileWebui) updateStatus(cr *v1alpha1.Webui, sts *appsv1.StatefulSe
 		return err
 	}
 	cr.Status.FromStatefulSet(sts)
+	if err := v1alpha1.SetWorkloadConditions(r.Client, &cr.Status.Conditions, cr.Generation, sts.Namespace, sts.Spec.Selector, cr.Status.Replicas, cr.Status.ReadyReplicas); err != nil {
+		return err
+	}
 	r.updatePorts(cr)
 	if err := r.updateServiceStatus(cr); err != nil {
 		return err
@@ -573,6 +581,6 @@ func (r *ReconcileWebui) listWebUIPods(webUIName string) (*corev1.PodList, error
 
 func (r *ReconcileWebui) ensureCertificatesExist(webUI *v1alpha1.Webui, pods *corev1.PodList, instanceType string) error {
 	subjects := webUI.PodsCertSubjects(pods)
-	crt := certificates.NewCertificate(r.Client, r.Scheme, webUI, subjects, instanceType)
+	crt := certificates.NewCertificate(r.Client, r.Scheme, webUI, subjects, instanceType, utils.CertificateKeyConfig(webUI.Spec.CommonConfiguration.CertificateKey))
 	return crt.EnsureExistsAndIsSigned()
 }
@@ -0,0 +1,287 @@
+package zookeeper
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/Juniper/contrail-operator/pkg/apis/contrail/v1alpha1"
+	"github.com/Juniper/contrail-operator/pkg/k8s"
+	"github.com/Juniper/contrail-operator/pkg/label"
+)
+
+// reconfigRequeuePeriod is how often the ensemble is checked while members
+// are added or removed.
+const reconfigRequeuePeriod = 10 * time.Second
+
+var execToPod = k8s.ExecToPodThroughAPI
+
+var adminHTTPClient = &http.Client{Timeout: 5 * time.Second}
+
+// adminCommand runs the command of the admin server of the ZooKeeper server
+// and decodes its JSON response.
+var adminCommand = func(ip string, port int, command string, response interface{}) error {
+	resp, err := adminHTTPClient.Get(fmt.Sprintf("http://%s:%d/commands/%s", ip, port, command))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("admin command %s on %s failed with status %s", command, ip, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(response)
+}
+
+var configServerRegexp = regexp.MustCompile(`(?m)^server\.(\d+)=`)
+
+// ensemble is the state of the ZooKeeper ensemble as seen by its members.
+type ensemble struct {
+	// members are ids of voting members of the current configuration.
+	members map[int]bool
+	// quorum is true when a majority of members follow the leader.
+	quorum bool
+	// server is a serving member through which the ensemble is reconfigured.
+	server *corev1.Pod
+}
+
+// serverID returns the id of the server run by the pod of the StatefulSet.
+func serverID(pod *corev1.Pod) (int, error) {
+	i := strings.LastIndex(pod.Name, "-")
+	ordinal, err := strconv.Atoi(pod.Name[i+1:])
+	if err != nil {
+		return 0, fmt.Errorf("pod %s is not a pod of a StatefulSet", pod.Name)
+	}
+	return ordinal + 1, nil
+}
+
+// readEnsemble reads members of the ensemble and checks its quorum through the admin
+// servers. When admin servers are disabled, members are read with zkCli and the quorum
+// is assumed when a majority of members is ready.
+func readEnsemble(config v1alpha1.ZookeeperConfiguration, pods []corev1.Pod) (*ensemble, error) {
+	e := &ensemble{members: map[int]bool{}}
+	serving := map[int]bool{}
+	for i := range pods {
+		pod := &pods[i]
+		if pod.Status.PodIP == "" || pod.Status.Phase != corev1.PodRunning {
+			continue
+		}
+		id, err := serverID(pod)
+		if err != nil {
+			return nil, err
+		}
+		if !*config.AdminEnableServer {
+			if podReady(pod) {
+				serving[id] = true
+				if e.server == nil {
+					e.server = pod
+				}
+			}
+			continue
+		}
+		mntr := struct {
+			ServerState string `json:"server_state"`
+		}{}
+		if err := adminCommand(pod.Status.PodIP, *config.AdminPort, "mntr", &mntr); err != nil {
+			log.Info("Failed to read state of ZooKeeper server", "Pod", pod.Name, "Error", err.Error())
+			continue
+		}
+		switch mntr.ServerState {
+		case "leader":
+			e.server = pod
+			serving[id] = true
+		case "follower":
+			serving[id] = true
+		}
+	}
+	if e.server == nil {
+		return e, nil
+	}
+	if *config.AdminEnableServer {
+		votingView := struct {
+			CurrentConfig map[string]json.RawMessage `json:"current_config"`
+		}{}
+		if err := adminCommand(e.server.Status.PodIP, *config.AdminPort, "voting_view", &votingView); err != nil {
+			return nil, err
+		}
+		for id := range votingView.CurrentConfig {
+			member, err := strconv.Atoi(id)
+			if err != nil {
+				return nil, fmt.Errorf("invalid server id %q in voting view", id)
+			}
+			e.members[member] = true
+		}
+	} else {
+		command := []string{"bash", "-c", fmt.Sprintf("zkCli.sh -server %s:%d config", e.server.Status.PodIP, *config.ClientPort)}
+		stdout, _, err := execToPod(command, "zookeeper", e.server.Name, e.server.Namespace, nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, match := range configServerRegexp.FindAllStringSubmatch(stdout, -1) {
+			member, _ := strconv.Atoi(match[1])
+			e.members[member] = true
+		}
+	}
+	following := 0
+	for id := range e.members {
+		if serving[id] {
+			following++
+		}
+	}
+	e.quorum = len(e.members) > 0 && following >= len(e.members)/2+1
+	return e, nil
+}
+
+// nextReplicas returns the number of replicas of the StatefulSet which moves the
+// ensemble by one member towards the desired size. A pod is added once all
+// current pods are members with quorum and it is removed once the ensemble, read
+// with quorum, confirms that it left. An ensemble which cannot be read keeps the pods.
+func nextReplicas(current, desired int32, e *ensemble) int32 {
+	if e == nil {
+		return current
+	}
+	switch {
+	case desired > current:
+		if !e.quorum {
+			return current
+		}
+		for id := 1; id <= int(current); id++ {
+			if !e.members[id] {
+				return current
+			}
+		}
+		return current + 1
+	case desired < current:
+		if e.server == nil || !e.quorum || e.members[int(current)] {
+			return current
+		}
+		return current - 1
+	}
+	return current
+}
+
+// statefulSetReplicas returns the number of replicas to set in the StatefulSet.
+// A new ensemble is created with all replicas at once, as pods are started one
+// by one and each of them is added to the ensemble once it runs.
+func (r *ReconcileZookeeper) statefulSetReplicas(zookeeper *v1alpha1.Zookeeper) (int32, error) {
+	desired := zookeeper.Spec.CommonConfiguration.GetReplicas()
+	sts := &appsv1.StatefulSet{}
+	err := r.Client.Get(context.TODO(), types.NamespacedName{Name: zookeeper.Name + "-zookeeper-statefulset", Namespace: zookeeper.Namespace}, sts)
+	if errors.IsNotFound(err) {
+		return desired, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	current := int32(1)
+	if sts.Spec.Replicas != nil {
+		current = *sts.Spec.Replicas
+	}
+	if current == desired {
+		return current, nil
+	}
+	pods, err := r.listPods(zookeeper)
+	if err != nil {
+		return 0, err
+	}
+	e, err := readEnsemble(zookeeper.ConfigurationParameters(), pods)
+	if err != nil {
+		return 0, err
+	}
+	next := nextReplicas(current, desired, e)
+	if next != current {
+		log.Info("Scaling ZooKeeper", "Name", zookeeper.Name, "From", current, "To", next, "Desired", desired)
+	}
+	return next, nil
+}
+
+// reconfigure adds running pods to the ensemble and removes servers of pods above
+// the desired number of replicas, one server at a time and only when the ensemble
+// has quorum. It returns true when the ensemble has to be checked again.
+func (r *ReconcileZookeeper) reconfigure(zookeeper *v1alpha1.Zookeeper, pods []corev1.Pod) (bool, error) {
+	config := zookeeper.ConfigurationParameters()
+	e, err := readEnsemble(config, pods)
+	if err != nil {
+		return false, err
+	}
+	if e.server == nil {
+		return len(pods) > 0, nil
+	}
+	if !e.quorum {
+		log.Info("Waiting for ZooKeeper quorum", "Name", zookeeper.Name)
+		return true, nil
+	}
+	desired := int(zookeeper.Spec.CommonConfiguration.GetReplicas())
+	var ids []int
+	for id := range e.members {
+		ids = append(ids, id)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(ids)))
+	for _, id := range ids {
+		if id <= desired || len(e.members) == 1 {
+			continue
+		}
+		log.Info("Removing server from ZooKeeper ensemble", "Name", zookeeper.Name, "Server", id)
+		return true, r.runReconfig(e.server, config, fmt.Sprintf("-remove %d", id))
+	}
+	sorted := make([]corev1.Pod, len(pods))
+	copy(sorted, pods)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+	for i := range sorted {
+		pod := &sorted[i]
+		if pod.Status.PodIP == "" || pod.Status.Phase != corev1.PodRunning {
+			continue
+		}
+		id, err := serverID(pod)
+		if err != nil {
+			return false, err
+		}
+		if id > desired || e.members[id] {
+			continue
+		}
+		serverDef := fmt.Sprintf("server.%d=%s:%d:%d:participant;%s:%d", id, pod.Status.PodIP,
+			*config.ElectionPort, *config.ServerPort, pod.Status.PodIP, *config.ClientPort)
+		log.Info("Adding server to ZooKeeper ensemble", "Name", zookeeper.Name, "Server", serverDef)
+		return true, r.runReconfig(e.server, config, fmt.Sprintf("-add %q", serverDef))
+	}
+	return len(e.members) != desired, nil
+}
+
+func (r *ReconcileZookeeper) runReconfig(server *corev1.Pod, config v1alpha1.ZookeeperConfiguration, args string) error {
+	runScript := fmt.Sprintf("zkCli.sh -server %s:%d reconfig %s", server.Status.PodIP, *config.ClientPort, args)
+	_, stderr, err := execToPod([]string{"bash", "-c", runScript}, "zookeeper", server.Name, server.Namespace, nil)
+	if err != nil {
+		return fmt.Errorf("reconfig %s failed: %v: %s", args, err, stderr)
+	}
+	return nil
+}
+
+func (r *ReconcileZookeeper) listPods(zookeeper *v1alpha1.Zookeeper) ([]corev1.Pod, error) {
+	pods := &corev1.PodList{}
+	labelSelector := labels.SelectorFromSet(label.New("zookeeper", zookeeper.Name))
+	if err := r.Client.List(context.TODO(), pods, &client.ListOptions{Namespace: zookeeper.Namespace, LabelSelector: labelSelector}); err != nil {
+		return nil, err
+	}
+	return pods.Items, nil
+}
+
+func podReady(pod *corev1.Pod) bool {
+	for _, c := range pod.Status.Conditions {
+		if c.Type == corev1.PodReady {
+			return c.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
@@ -0,0 +1,170 @@
+package zookeeper
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	contrail "github.com/Juniper/contrail-operator/pkg/apis/contrail/v1alpha1"
+)
+
+func TestNextReplicas(t *testing.T) {
+	members := func(ids ...int) map[int]bool {
+		m := map[int]bool{}
+		for _, id := range ids {
+			m[id] = true
+		}
+		return m
+	}
+	tests := []struct {
+		name             string
+		current, desired int32
+		ensemble         ensemble
+		expectedReplicas int32
+	}{
+		{"should add pod when all pods are members", 1, 3, ensemble{members: members(1), quorum: true}, 2},
+		{"should wait for pod to join", 2, 3, ensemble{members: members(1), quorum: true}, 2},
+		{"should not add pod without quorum", 2, 3, ensemble{members: members(1, 2)}, 2},
+		{"should wait for pod to leave", 3, 1, ensemble{members: members(1, 2, 3), quorum: true}, 3},
+		{"should remove pod which left ensemble", 3, 1, ensemble{server: &core.Pod{}, members: members(1, 2), quorum: true}, 2},
+		{"should keep pods when ensemble cannot be read", 3, 1, ensemble{members: members()}, 3},
+		{"should keep pods when ensemble has no quorum", 3, 1, ensemble{server: &core.Pod{}, members: members(1, 2)}, 3},
+		{"should keep desired replicas", 3, 3, ensemble{members: members(1, 2, 3), quorum: true}, 3},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expectedReplicas, nextReplicas(test.current, test.desired, &test.ensemble))
+		})
+	}
+	t.Run("should keep pods without ensemble", func(t *testing.T) {
+		assert.Equal(t, int32(3), nextReplicas(3, 1, nil))
+	})
+}
+
+func TestReconfigure(t *testing.T) {
+	newPod := func(ordinal int, ready bool) core.Pod {
+		status := core.ConditionFalse
+		if ready {
+			status = core.ConditionTrue
+		}
+		return core.Pod{
+			ObjectMeta: meta.ObjectMeta{Name: fmt.Sprintf("zookeeper-instance-zookeeper-statefulset-%d", ordinal), Namespace: "default"},
+			Status: core.PodStatus{
+				Phase:      core.PodRunning,
+				PodIP:      fmt.Sprintf("10.0.0.%d", ordinal+1),
+				Conditions: []core.PodCondition{{Type: core.PodReady, Status: status}},
+			},
+		}
+	}
+	newZookeeper := func(replicas int32) *contrail.Zookeeper {
+		zk := newZookeeper()
+		zk.Spec.CommonConfiguration.Replicas = &replicas
+		return zk
+	}
+	// fakeAdmin serves mntr and voting_view of servers by IP.
+	fakeAdmin := func(states map[string]string, votingView ...int) func(string, int, string, interface{}) error {
+		return func(ip string, port int, command string, response interface{}) error {
+			assert.Equal(t, contrail.ZookeeperAdminPort, port)
+			var body interface{}
+			switch command {
+			case "mntr":
+				state, ok := states[ip]
+				if !ok {
+					return &url.Error{Op: "Get", URL: ip, Err: io.EOF}
+				}
+				body = map[string]string{"server_state": state}
+			case "voting_view":
+				config := map[string]string{}
+				for _, id := range votingView {
+					config[fmt.Sprint(id)] = fmt.Sprintf("10.0.0.%d:2888:3888:participant;0.0.0.0:2181", id)
+				}
+				body = map[string]interface{}{"current_config": config}
+			}
+			data, err := json.Marshal(body)
+			require.NoError(t, err)
+			return json.Unmarshal(data, response)
+		}
+	}
+	restoreExecToPod, restoreAdminCommand := execToPod, adminCommand
+	var executed []string
+	execToPod = func(command []string, container, pod, namespace string, stdin io.Reader) (string, string, error) {
+		executed = append(executed, pod+": "+command[2])
+		return "", "", nil
+	}
+	defer func() {
+		execToPod = restoreExecToPod
+		adminCommand = restoreAdminCommand
+	}()
+	r := &ReconcileZookeeper{}
+
+	t.Run("should add running pod to ensemble through leader", func(t *testing.T) {
+		executed = nil
+		adminCommand = fakeAdmin(map[string]string{"10.0.0.1": "follower", "10.0.0.2": "leader"}, 1, 2)
+		// when
+		requeue, err := r.reconfigure(newZookeeper(3), []core.Pod{newPod(0, true), newPod(1, true), newPod(2, false)})
+		// then
+		require.NoError(t, err)
+		assert.True(t, requeue)
+		assert.Equal(t, []string{
+			`zookeeper-instance-zookeeper-statefulset-1: zkCli.sh -server 10.0.0.2:2181 reconfig -add "server.3=10.0.0.3:2888:3888:participant;10.0.0.3:2181"`,
+		}, executed)
+	})
+
+	t.Run("should remove server with highest id above replicas", func(t *testing.T) {
+		executed = nil
+		adminCommand = fakeAdmin(map[string]string{"10.0.0.1": "leader", "10.0.0.2": "follower", "10.0.0.3": "follower"}, 1, 2, 3)
+		// when
+		requeue, err := r.reconfigure(newZookeeper(1), []core.Pod{newPod(0, true), newPod(1, true), newPod(2, true)})
+		// then
+		require.NoError(t, err)
+		assert.True(t, requeue)
+		assert.Equal(t, []string{
+			"zookeeper-instance-zookeeper-statefulset-0: zkCli.sh -server 10.0.0.1:2181 reconfig -remove 3",
+		}, executed)
+	})
+
+	t.Run("should not reconfigure ensemble without quorum", func(t *testing.T) {
+		executed = nil
+		adminCommand = fakeAdmin(map[string]string{"10.0.0.1": "leader"}, 1, 2, 3)
+		// when
+		requeue, err := r.reconfigure(newZookeeper(1), []core.Pod{newPod(0, true), newPod(1, true), newPod(2, true)})
+		// then
+		require.NoError(t, err)
+		assert.True(t, requeue)
+		assert.Empty(t, executed)
+	})
+
+	t.Run("should do nothing when ensemble has desired members", func(t *testing.T) {
+		executed = nil
+		adminCommand = fakeAdmin(map[string]string{"10.0.0.1": "leader", "10.0.0.2": "follower", "10.0.0.3": "follower"}, 1, 2, 3)
+		// when
+		requeue, err := r.reconfigure(newZookeeper(3), []core.Pod{newPod(0, true), newPod(1, true), newPod(2, true)})
+		// then
+		require.NoError(t, err)
+		assert.False(t, requeue)
+		assert.Empty(t, executed)
+	})
+
+	t.Run("should read members with zkCli when admin server is disabled", func(t *testing.T) {
+		falseVal := false
+		config := newZookeeper(3).ConfigurationParameters()
+		config.AdminEnableServer = &falseVal
+		execToPod = func(command []string, container, pod, namespace string, stdin io.Reader) (string, string, error) {
+			assert.Equal(t, "zkCli.sh -server 10.0.0.1:2181 config", command[2])
+			return "server.1=10.0.0.1:2888:3888:participant;10.0.0.1:2181\nserver.2=10.0.0.2:2888:3888:participant;10.0.0.2:2181\nversion=100000003\n", "", nil
+		}
+		// when
+		e, err := readEnsemble(config, []core.Pod{newPod(0, true), newPod(1, true), newPod(2, false)})
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, map[int]bool{1: true, 2: true}, e.members)
+		assert.True(t, e.quorum)
+	})
+}
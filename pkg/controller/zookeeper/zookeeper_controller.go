@@ -2,8 +2,6 @@ package zookeeper
 
 import (
 	"context"
-	"fmt"
-	"sort"
 	"strconv"
 
 	storagev1 "k8s.io/api/storage/v1"
@@ -13,7 +11,6 @@ import (
 
 	"github.com/Juniper/contrail-operator/pkg/apis/contrail/v1alpha1"
 	"github.com/Juniper/contrail-operator/pkg/controller/utils"
-	"github.com/Juniper/contrail-operator/pkg/k8s"
 	"github.com/Juniper/contrail-operator/pkg/label"
 
 	"k8s.io/apimachinery/pkg/api/errors"
@@ -101,7 +98,8 @@ func Add(mgr manager.Manager) error {
 }
 
 func newReconciler(mgr manager.Manager) reconcile.Reconciler {
-	return &ReconcileZookeeper{Client: mgr.GetClient(), Scheme: mgr.GetScheme()}
+	client := utils.NewEventRecordingClient(mgr.GetClient(), mgr.GetEventRecorderFor("zookeeper-controller"))
+	return &ReconcileZookeeper{Client: client, Scheme: mgr.GetScheme()}
 }
 
 // add adds a new Controller to mgr with r as the reconcile.Reconciler.
@@ -363,6 +361,12 @@ func (r *ReconcileZookeeper) Reconcile(request reconcile.Request) (reconcile.Res
 		}
 	}
 
+	replicas, err := r.statefulSetReplicas(instance)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	statefulSet.Spec.Replicas = &replicas
+
 	if err = instance.CreateSTS(statefulSet, instanceType, request, r.Client); err != nil {
 		return reconcile.Result{}, err
 	}
@@ -374,6 +378,7 @@ func (r *ReconcileZookeeper) Reconcile(request reconcile.Request) (reconcile.Res
 	if err != nil {
 		return reconcile.Result{}, err
 	}
+	requeue := false
 	if len(podIPList.Items) > 0 {
 		if err = instance.InstanceConfiguration(request, configMapName, podIPList, r.Client); err != nil {
 			return reconcile.Result{}, err
@@ -391,37 +396,8 @@ func (r *ReconcileZookeeper) Reconcile(request reconcile.Request) (reconcile.Res
 			}
 		}
 
-		pods := make([]corev1.Pod, len(podIPList.Items))
-		copy(pods, podIPList.Items)
-		sort.SliceStable(pods, func(i, j int) bool { return pods[i].Name < pods[j].Name })
-
-		var found *corev1.Pod
-		for _, pod := range pods {
-			ip, ok := instance.Status.Nodes[pod.Name]
-			if !ok || ip != pod.Status.PodIP {
-				found = &pod
-			}
-			if found != nil {
-				break
-			}
-		}
-
-		if found != nil && len(pods) > 1 {
-			myidString := found.Name[len(found.Name)-1:]
-			myidInt, err := strconv.Atoi(myidString)
-			if err != nil {
-				return reconcile.Result{}, err
-			}
-
-			serverDef := fmt.Sprintf("server.%d=%s:%s;%s:2181",
-				myidInt+1, found.Status.PodIP,
-				strconv.Itoa(*zookeeperDefaultConfiguration.ElectionPort)+":"+strconv.Itoa(*zookeeperDefaultConfiguration.ServerPort), found.Status.PodIP)
-			runScript := fmt.Sprintf("zkCli.sh -server %s reconfig -add \"%s\"", found.Status.PodIP, serverDef)
-			command := []string{"bash", "-c", runScript, serverDef}
-			_, _, err = k8s.ExecToPodThroughAPI(command, "zookeeper", found.Name, found.Namespace, nil)
-			if err != nil {
-				return reconcile.Result{}, err
-			}
+		if requeue, err = r.reconfigure(instance, podIPList.Items); err != nil {
+			return reconcile.Result{}, err
 		}
 
 		if err = instance.ManageNodeStatus(podIPMap, r.Client); err != nil {
@@ -456,6 +432,9 @@ func (r *ReconcileZookeeper) Reconcile(request reconcile.Request) (reconcile.Res
 	if err = instance.SetInstanceActive(r.Client, instance.Status.Active, statefulSet, request); err != nil {
 		return reconcile.Result{}, err
 	}
+	if requeue || replicas != instance.Spec.CommonConfiguration.GetReplicas() {
+		return reconcile.Result{RequeueAfter: reconfigRequeuePeriod}, nil
+	}
 	return reconcile.Result{}, nil
 }
 func (r *ReconcileZookeeper) ensurePodDisruptionBudgetExists(zookeeper *v1alpha1.Zookeeper) error {
@@ -24,6 +24,11 @@ type SecretFiller interface {
 	FillSecret(sc *core.Secret) error
 }
 
+// Name returns the name of the secret.
+func (s *Secret) Name() string {
+	return s.name
+}
+
 func (s *Secret) EnsureExists(dataSetter SecretFiller) error {
 	secret, err := s.createNewOrGetExistingSecret()
 
@@ -0,0 +1,407 @@
+// Package metrics exposes the state of contrail resources as Prometheus metrics.
+package metrics
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	apps "k8s.io/api/apps/v1"
+	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	crmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	contrail "github.com/Juniper/contrail-operator/pkg/apis/contrail/v1alpha1"
+)
+
+var log = logf.Log.WithName("metrics")
+
+const (
+	certificatesSecretSuffix = "-secret-certificates"
+	caCertificateSecretName  = "contrail-ca-certificate"
+)
+
+var (
+	resourceActiveDesc = prometheus.NewDesc(
+		"contrail_resource_active",
+		"Whether the contrail resource is active (1) or not (0).",
+		[]string{"kind", "namespace", "name"}, nil,
+	)
+	resourceConditionDesc = prometheus.NewDesc(
+		"contrail_resource_condition",
+		"Whether the condition of the contrail resource is True (1) or not (0).",
+		[]string{"kind", "namespace", "name", "condition"}, nil,
+	)
+	replicasDesiredDesc = prometheus.NewDesc(
+		"contrail_resource_replicas_desired",
+		"Number of replicas desired by workloads of the contrail resource.",
+		[]string{"kind", "namespace", "name"}, nil,
+	)
+	replicasReadyDesc = prometheus.NewDesc(
+		"contrail_resource_replicas_ready",
+		"Number of ready replicas of workloads of the contrail resource.",
+		[]string{"kind", "namespace", "name"}, nil,
+	)
+	commandUpgradeStateDesc = prometheus.NewDesc(
+		"contrail_command_upgrade_state",
+		"Upgrade state of the Command resource, 1 for the current state and 0 for the others.",
+		[]string{"namespace", "name", "state"}, nil,
+	)
+	certificateExpiryDesc = prometheus.NewDesc(
+		"contrail_certificate_expiry_days",
+		"Number of days until the certificate expires.",
+		[]string{"namespace", "secret", "certificate"}, nil,
+	)
+	controlBGPPeersDesc = prometheus.NewDesc(
+		"contrail_control_bgp_peers",
+		"Number of BGP peers of the control pod.",
+		[]string{"namespace", "name", "pod"}, nil,
+	)
+	controlBGPPeersUpDesc = prometheus.NewDesc(
+		"contrail_control_bgp_peers_up",
+		"Number of BGP peers of the control pod which are up.",
+		[]string{"namespace", "name", "pod"}, nil,
+	)
+	controlXMPPPeersDesc = prometheus.NewDesc(
+		"contrail_control_xmpp_peers",
+		"Number of XMPP peers of the control pod.",
+		[]string{"namespace", "name", "pod"}, nil,
+	)
+	swiftDeviceSizeDesc = prometheus.NewDesc(
+		"contrail_swift_device_size_bytes",
+		"Size of the mounted device of the Swift storage pod.",
+		[]string{"namespace", "name", "pod", "device"}, nil,
+	)
+	swiftDeviceUsedDesc = prometheus.NewDesc(
+		"contrail_swift_device_used_bytes",
+		"Number of bytes used on the mounted device of the Swift storage pod.",
+		[]string{"namespace", "name", "pod", "device"}, nil,
+	)
+	swiftReplicationLagDesc = prometheus.NewDesc(
+		"contrail_swift_replication_lag_seconds",
+		"Number of seconds since the object replicator of the Swift storage pod completed its last pass.",
+		[]string{"namespace", "name", "pod"}, nil,
+	)
+	swiftQuarantinedDesc = prometheus.NewDesc(
+		"contrail_swift_quarantined",
+		"Number of quarantined objects, containers or accounts of the Swift storage pod.",
+		[]string{"namespace", "name", "pod", "type"}, nil,
+	)
+)
+
+// resourceKinds lists kinds of resources reported by the collector.
+var resourceKinds = []struct {
+	kind string
+	list func() runtime.Object
+}{
+	{"Cassandra", func() runtime.Object { return &contrail.CassandraList{} }},
+	{"Command", func() runtime.Object { return &contrail.CommandList{} }},
+	{"Config", func() runtime.Object { return &contrail.ConfigList{} }},
+	{"ContrailCNI", func() runtime.Object { return &contrail.ContrailCNIList{} }},
+	{"Contrailmonitor", func() runtime.Object { return &contrail.ContrailmonitorList{} }},
+	{"Control", func() runtime.Object { return &contrail.ControlList{} }},
+	{"FernetKeyManager", func() runtime.Object { return &contrail.FernetKeyManagerList{} }},
+	{"Keystone", func() runtime.Object { return &contrail.KeystoneList{} }},
+	{"Kubemanager", func() runtime.Object { return &contrail.KubemanagerList{} }},
+	{"Manager", func() runtime.Object { return &contrail.ManagerList{} }},
+	{"Memcached", func() runtime.Object { return &contrail.MemcachedList{} }},
+	{"Postgres", func() runtime.Object { return &contrail.PostgresList{} }},
+	{"ProvisionManager", func() runtime.Object { return &contrail.ProvisionManagerList{} }},
+	{"Rabbitmq", func() runtime.Object { return &contrail.RabbitmqList{} }},
+	{"Swift", func() runtime.Object { return &contrail.SwiftList{} }},
+	{"SwiftProxy", func() runtime.Object { return &contrail.SwiftProxyList{} }},
+	{"SwiftStorage", func() runtime.Object { return &contrail.SwiftStorageList{} }},
+	{"Vrouter", func() runtime.Object { return &contrail.VrouterList{} }},
+	{"Webui", func() runtime.Object { return &contrail.WebuiList{} }},
+	{"Zookeeper", func() runtime.Object { return &contrail.ZookeeperList{} }},
+}
+
+var commandUpgradeStates = []contrail.CommandUpgradeState{
+	contrail.CommandNotUpgrading,
+	contrail.CommandShuttingDownBeforeUpgrade,
+	contrail.CommandUpgrading,
+	contrail.CommandStartingUpgradedDeployment,
+	contrail.CommandUpgradeFailed,
+}
+
+// Collector is a prometheus.Collector which reads contrail resources,
+// their workloads and certificates on every scrape.
+type Collector struct {
+	client    client.Reader
+	namespace string
+	now       func() time.Time
+}
+
+// NewCollector creates a Collector of resources in the namespace.
+func NewCollector(c client.Reader, namespace string) *Collector {
+	return &Collector{client: c, namespace: namespace, now: time.Now}
+}
+
+// AddToManager registers the Collector in the registry served on the manager metrics endpoint.
+func AddToManager(mgr manager.Manager, namespace string) error {
+	return crmetrics.Registry.Register(NewCollector(mgr.GetClient(), namespace))
+}
+
+// Describe implements prometheus.Collector.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- resourceActiveDesc
+	ch <- resourceConditionDesc
+	ch <- replicasDesiredDesc
+	ch <- replicasReadyDesc
+	ch <- commandUpgradeStateDesc
+	ch <- certificateExpiryDesc
+	ch <- controlBGPPeersDesc
+	ch <- controlBGPPeersUpDesc
+	ch <- controlXMPPPeersDesc
+	ch <- swiftDeviceSizeDesc
+	ch <- swiftDeviceUsedDesc
+	ch <- swiftReplicationLagDesc
+	ch <- swiftQuarantinedDesc
+}
+
+// Collect implements prometheus.Collector. Errors are logged, so that
+// a single failing list does not hide the remaining metrics.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	for _, collect := range []func(chan<- prometheus.Metric) error{
+		c.collectResources,
+		c.collectReplicas,
+		c.collectCommandUpgrades,
+		c.collectControlPeers,
+		c.collectSwiftUsage,
+		c.collectCertificates,
+	} {
+		if err := collect(ch); err != nil {
+			log.Error(err, "Failed to collect metrics")
+		}
+	}
+}
+
+func (c *Collector) list(list runtime.Object) error {
+	return c.client.List(context.TODO(), list, client.InNamespace(c.namespace))
+}
+
+func (c *Collector) collectResources(ch chan<- prometheus.Metric) error {
+	for _, k := range resourceKinds {
+		list := k.list()
+		if err := c.list(list); err != nil {
+			return err
+		}
+		items, err := meta.ExtractList(list)
+		if err != nil {
+			return err
+		}
+		for _, item := range items {
+			obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(item)
+			if err != nil {
+				return err
+			}
+			accessor, err := meta.Accessor(item)
+			if err != nil {
+				return err
+			}
+			status, _ := obj["status"].(map[string]interface{})
+			// Resources without the active field in status, e.g. Manager,
+			// are active when their Ready condition is true.
+			active, hasActive := status["active"].(bool)
+			conditions, _ := status["conditions"].([]interface{})
+			for _, condition := range conditions {
+				cond, _ := condition.(map[string]interface{})
+				condType, _ := cond["type"].(string)
+				condTrue := cond["status"] == string(contrail.ConditionTrue)
+				if !hasActive && condType == string(contrail.ConditionReady) && condTrue {
+					active = true
+				}
+				ch <- prometheus.MustNewConstMetric(resourceConditionDesc, prometheus.GaugeValue, boolValue(condTrue),
+					k.kind, accessor.GetNamespace(), accessor.GetName(), condType)
+			}
+			ch <- prometheus.MustNewConstMetric(resourceActiveDesc, prometheus.GaugeValue, boolValue(active),
+				k.kind, accessor.GetNamespace(), accessor.GetName())
+		}
+	}
+	return nil
+}
+
+type owner struct {
+	kind, namespace, name string
+}
+
+// collectReplicas sums replicas of StatefulSets, DaemonSets and Deployments
+// controlled by contrail resources.
+func (c *Collector) collectReplicas(ch chan<- prometheus.Metric) error {
+	desired := map[owner]int32{}
+	ready := map[owner]int32{}
+	add := func(obj metav1.Object, d, r int32) {
+		ref := metav1.GetControllerOf(obj)
+		if ref == nil {
+			return
+		}
+		gv, err := schema.ParseGroupVersion(ref.APIVersion)
+		if err != nil || gv.Group != contrail.SchemeGroupVersion.Group {
+			return
+		}
+		o := owner{kind: ref.Kind, namespace: obj.GetNamespace(), name: ref.Name}
+		desired[o] += d
+		ready[o] += r
+	}
+
+	statefulSets := &apps.StatefulSetList{}
+	if err := c.list(statefulSets); err != nil {
+		return err
+	}
+	for i := range statefulSets.Items {
+		sts := &statefulSets.Items[i]
+		add(sts, replicas(sts.Spec.Replicas), sts.Status.ReadyReplicas)
+	}
+	daemonSets := &apps.DaemonSetList{}
+	if err := c.list(daemonSets); err != nil {
+		return err
+	}
+	for i := range daemonSets.Items {
+		ds := &daemonSets.Items[i]
+		add(ds, ds.Status.DesiredNumberScheduled, ds.Status.NumberReady)
+	}
+	deployments := &apps.DeploymentList{}
+	if err := c.list(deployments); err != nil {
+		return err
+	}
+	for i := range deployments.Items {
+		deployment := &deployments.Items[i]
+		add(deployment, replicas(deployment.Spec.Replicas), deployment.Status.ReadyReplicas)
+	}
+
+	for o, d := range desired {
+		ch <- prometheus.MustNewConstMetric(replicasDesiredDesc, prometheus.GaugeValue, float64(d), o.kind, o.namespace, o.name)
+		ch <- prometheus.MustNewConstMetric(replicasReadyDesc, prometheus.GaugeValue, float64(ready[o]), o.kind, o.namespace, o.name)
+	}
+	return nil
+}
+
+func (c *Collector) collectCommandUpgrades(ch chan<- prometheus.Metric) error {
+	commands := &contrail.CommandList{}
+	if err := c.list(commands); err != nil {
+		return err
+	}
+	for _, command := range commands.Items {
+		current := command.Status.UpgradeState
+		if current == "" {
+			current = contrail.CommandNotUpgrading
+		}
+		for _, state := range commandUpgradeStates {
+			ch <- prometheus.MustNewConstMetric(commandUpgradeStateDesc, prometheus.GaugeValue, boolValue(state == current),
+				command.Namespace, command.Name, string(state))
+		}
+	}
+	return nil
+}
+
+func (c *Collector) collectControlPeers(ch chan<- prometheus.Metric) error {
+	controls := &contrail.ControlList{}
+	if err := c.list(controls); err != nil {
+		return err
+	}
+	for _, control := range controls.Items {
+		for pod, status := range control.Status.ServiceStatus {
+			gauge := func(desc *prometheus.Desc, value string) {
+				if n, err := strconv.Atoi(value); err == nil {
+					ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, float64(n), control.Namespace, control.Name, pod)
+				}
+			}
+			gauge(controlBGPPeersDesc, status.BGPPeer.Number)
+			gauge(controlBGPPeersUpDesc, status.BGPPeer.Up)
+			gauge(controlXMPPPeersDesc, status.NumberOfXMPPPeers)
+		}
+	}
+	return nil
+}
+
+// collectSwiftUsage reports usage of storage pods gathered by the Swift controller.
+func (c *Collector) collectSwiftUsage(ch chan<- prometheus.Metric) error {
+	storages := &contrail.SwiftStorageList{}
+	if err := c.list(storages); err != nil {
+		return err
+	}
+	for _, storage := range storages.Items {
+		for _, pod := range storage.Status.Usage {
+			for _, device := range pod.Devices {
+				if !device.Mounted {
+					continue
+				}
+				ch <- prometheus.MustNewConstMetric(swiftDeviceSizeDesc, prometheus.GaugeValue, float64(device.SizeBytes),
+					storage.Namespace, storage.Name, pod.Pod, device.Device)
+				ch <- prometheus.MustNewConstMetric(swiftDeviceUsedDesc, prometheus.GaugeValue, float64(device.UsedBytes),
+					storage.Namespace, storage.Name, pod.Pod, device.Device)
+			}
+			if pod.ReplicationLagSeconds != nil {
+				ch <- prometheus.MustNewConstMetric(swiftReplicationLagDesc, prometheus.GaugeValue, float64(*pod.ReplicationLagSeconds),
+					storage.Namespace, storage.Name, pod.Pod)
+			}
+			if pod.Error != "" {
+				continue
+			}
+			quarantined := func(kind string, n int64) {
+				ch <- prometheus.MustNewConstMetric(swiftQuarantinedDesc, prometheus.GaugeValue, float64(n),
+					storage.Namespace, storage.Name, pod.Pod, kind)
+			}
+			quarantined("objects", pod.QuarantinedObjects)
+			quarantined("containers", pod.QuarantinedContainers)
+			quarantined("accounts", pod.QuarantinedAccounts)
+		}
+	}
+	return nil
+}
+
+// collectCertificates reports expiry of certificates stored in secrets
+// created for contrail resources and of the CA certificate.
+func (c *Collector) collectCertificates(ch chan<- prometheus.Metric) error {
+	secrets := &core.SecretList{}
+	if err := c.list(secrets); err != nil {
+		return err
+	}
+	now := c.now()
+	for _, secret := range secrets.Items {
+		if !strings.HasSuffix(secret.Name, certificatesSecretSuffix) && secret.Name != caCertificateSecretName {
+			continue
+		}
+		for key, data := range secret.Data {
+			if !strings.HasSuffix(key, ".crt") {
+				continue
+			}
+			block, _ := pem.Decode(data)
+			if block == nil || block.Type != "CERTIFICATE" {
+				continue
+			}
+			cert, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				log.Error(err, "Failed to parse certificate", "secret", secret.Name, "certificate", key)
+				continue
+			}
+			days := cert.NotAfter.Sub(now).Hours() / 24
+			ch <- prometheus.MustNewConstMetric(certificateExpiryDesc, prometheus.GaugeValue, days, secret.Namespace, secret.Name, key)
+		}
+	}
+	return nil
+}
+
+func replicas(r *int32) int32 {
+	if r == nil {
+		return 1
+	}
+	return *r
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
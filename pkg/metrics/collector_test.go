@@ -0,0 +1,217 @@
+package metrics
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apps "k8s.io/api/apps/v1"
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	contrail "github.com/Juniper/contrail-operator/pkg/apis/contrail/v1alpha1"
+)
+
+func TestCollector(t *testing.T) {
+	now := time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+	trueVal := true
+	replicas := int32(3)
+
+	cassandra := &contrail.Cassandra{
+		ObjectMeta: meta.ObjectMeta{Name: "cassandra1", Namespace: "default", UID: "cassandra-uid"},
+		Status: contrail.CassandraStatus{
+			Active: &trueVal,
+			Conditions: []contrail.Condition{
+				{Type: contrail.ConditionReady, Status: contrail.ConditionTrue},
+				{Type: contrail.ConditionDegraded, Status: contrail.ConditionFalse},
+			},
+		},
+	}
+	manager := &contrail.Manager{
+		ObjectMeta: meta.ObjectMeta{Name: "cluster1", Namespace: "default"},
+		Status: contrail.ManagerStatus{
+			Conditions: []contrail.Condition{{Type: contrail.ConditionReady, Status: contrail.ConditionTrue}},
+		},
+	}
+	sts := &apps.StatefulSet{
+		ObjectMeta: meta.ObjectMeta{
+			Name: "cassandra1-cassandra-statefulset", Namespace: "default",
+			OwnerReferences: []meta.OwnerReference{{
+				APIVersion: "contrail.juniper.net/v1alpha1", Kind: "Cassandra", Name: "cassandra1", UID: "cassandra-uid", Controller: &trueVal,
+			}},
+		},
+		Spec:   apps.StatefulSetSpec{Replicas: &replicas},
+		Status: apps.StatefulSetStatus{ReadyReplicas: 2},
+	}
+	command := &contrail.Command{
+		ObjectMeta: meta.ObjectMeta{Name: "command", Namespace: "default"},
+		Status:     contrail.CommandStatus{UpgradeState: contrail.CommandUpgrading},
+	}
+	control := &contrail.Control{
+		ObjectMeta: meta.ObjectMeta{Name: "control1", Namespace: "default"},
+		Status: contrail.ControlStatus{
+			ServiceStatus: map[string]contrail.ControlServiceStatus{
+				"control1-control-statefulset-0": {
+					NumberOfXMPPPeers: "4",
+					BGPPeer:           contrail.BGPPeer{Up: "1", Number: "2"},
+				},
+			},
+		},
+	}
+	secret := &core.Secret{
+		ObjectMeta: meta.ObjectMeta{Name: "config1-secret-certificates", Namespace: "default"},
+		Data: map[string][]byte{
+			"server-10.0.0.1.crt":        newCertificate(t, now.Add(30*24*time.Hour)),
+			"server-key-10.0.0.1.pem":    []byte("key"),
+			"status-10.0.0.1":            []byte("Approved"),
+			"server-10.0.0.2.crt.backup": []byte("not a certificate"),
+		},
+	}
+	otherSecret := &core.Secret{
+		ObjectMeta: meta.ObjectMeta{Name: "other", Namespace: "default"},
+		Data:       map[string][]byte{"server.crt": newCertificate(t, now)},
+	}
+
+	s := runtime.NewScheme()
+	require.NoError(t, scheme.AddToScheme(s))
+	require.NoError(t, contrail.SchemeBuilder.AddToScheme(s))
+	cl := fake.NewFakeClientWithScheme(s, cassandra, manager, sts, command, control, secret, otherSecret)
+	collector := NewCollector(cl, "default")
+	collector.now = func() time.Time { return now }
+
+	t.Run("should report active state and conditions of resources", func(t *testing.T) {
+		expected := `
+# HELP contrail_resource_active Whether the contrail resource is active (1) or not (0).
+# TYPE contrail_resource_active gauge
+contrail_resource_active{kind="Cassandra",name="cassandra1",namespace="default"} 1
+contrail_resource_active{kind="Command",name="command",namespace="default"} 0
+contrail_resource_active{kind="Control",name="control1",namespace="default"} 0
+contrail_resource_active{kind="Manager",name="cluster1",namespace="default"} 1
+# HELP contrail_resource_condition Whether the condition of the contrail resource is True (1) or not (0).
+# TYPE contrail_resource_condition gauge
+contrail_resource_condition{condition="Degraded",kind="Cassandra",name="cassandra1",namespace="default"} 0
+contrail_resource_condition{condition="Ready",kind="Cassandra",name="cassandra1",namespace="default"} 1
+contrail_resource_condition{condition="Ready",kind="Manager",name="cluster1",namespace="default"} 1
+`
+		assert.NoError(t, testutil.CollectAndCompare(collector, strings.NewReader(expected),
+			"contrail_resource_active", "contrail_resource_condition"))
+	})
+
+	t.Run("should report replicas of workloads owned by resources", func(t *testing.T) {
+		expected := `
+# HELP contrail_resource_replicas_desired Number of replicas desired by workloads of the contrail resource.
+# TYPE contrail_resource_replicas_desired gauge
+contrail_resource_replicas_desired{kind="Cassandra",name="cassandra1",namespace="default"} 3
+# HELP contrail_resource_replicas_ready Number of ready replicas of workloads of the contrail resource.
+# TYPE contrail_resource_replicas_ready gauge
+contrail_resource_replicas_ready{kind="Cassandra",name="cassandra1",namespace="default"} 2
+`
+		assert.NoError(t, testutil.CollectAndCompare(collector, strings.NewReader(expected),
+			"contrail_resource_replicas_desired", "contrail_resource_replicas_ready"))
+	})
+
+	t.Run("should report Command upgrade state", func(t *testing.T) {
+		expected := `
+# HELP contrail_command_upgrade_state Upgrade state of the Command resource, 1 for the current state and 0 for the others.
+# TYPE contrail_command_upgrade_state gauge
+contrail_command_upgrade_state{name="command",namespace="default",state="not upgrading"} 0
+contrail_command_upgrade_state{name="command",namespace="default",state="shutting down before upgrade"} 0
+contrail_command_upgrade_state{name="command",namespace="default",state="starting upgraded deployment"} 0
+contrail_command_upgrade_state{name="command",namespace="default",state="upgrade failed"} 0
+contrail_command_upgrade_state{name="command",namespace="default",state="upgrading"} 1
+`
+		assert.NoError(t, testutil.CollectAndCompare(collector, strings.NewReader(expected), "contrail_command_upgrade_state"))
+	})
+
+	t.Run("should report Control peers", func(t *testing.T) {
+		expected := `
+# HELP contrail_control_bgp_peers Number of BGP peers of the control pod.
+# TYPE contrail_control_bgp_peers gauge
+contrail_control_bgp_peers{name="control1",namespace="default",pod="control1-control-statefulset-0"} 2
+# HELP contrail_control_bgp_peers_up Number of BGP peers of the control pod which are up.
+# TYPE contrail_control_bgp_peers_up gauge
+contrail_control_bgp_peers_up{name="control1",namespace="default",pod="control1-control-statefulset-0"} 1
+# HELP contrail_control_xmpp_peers Number of XMPP peers of the control pod.
+# TYPE contrail_control_xmpp_peers gauge
+contrail_control_xmpp_peers{name="control1",namespace="default",pod="control1-control-statefulset-0"} 4
+`
+		assert.NoError(t, testutil.CollectAndCompare(collector, strings.NewReader(expected),
+			"contrail_control_bgp_peers", "contrail_control_bgp_peers_up", "contrail_control_xmpp_peers"))
+	})
+
+	t.Run("should report days to expiry of certificates of resources", func(t *testing.T) {
+		expected := `
+# HELP contrail_certificate_expiry_days Number of days until the certificate expires.
+# TYPE contrail_certificate_expiry_days gauge
+contrail_certificate_expiry_days{certificate="server-10.0.0.1.crt",namespace="default",secret="config1-secret-certificates"} 30
+`
+		assert.NoError(t, testutil.CollectAndCompare(collector, strings.NewReader(expected), "contrail_certificate_expiry_days"))
+	})
+
+	t.Run("should report usage of Swift storage pods", func(t *testing.T) {
+		lag := int64(120)
+		swiftStorage := &contrail.SwiftStorage{
+			ObjectMeta: meta.ObjectMeta{Name: "swift1-storage", Namespace: "default"},
+			Status: contrail.SwiftStorageStatus{
+				Usage: []contrail.SwiftStoragePodUsage{{
+					Pod: "swift1-storage-statefulset-0",
+					Devices: []contrail.SwiftDeviceUsage{
+						{Device: "d1", Mounted: true, SizeBytes: 1000, UsedBytes: 750, AvailableBytes: 250, UsedPercent: 75},
+						{Device: "d2"},
+					},
+					ReplicationLagSeconds: &lag,
+					QuarantinedObjects:    2,
+				}, {
+					Pod:   "swift1-storage-statefulset-1",
+					Error: "failed to get disk usage: connection refused",
+				}},
+			},
+		}
+		collector := NewCollector(fake.NewFakeClientWithScheme(s, swiftStorage), "default")
+		expected := `
+# HELP contrail_swift_device_size_bytes Size of the mounted device of the Swift storage pod.
+# TYPE contrail_swift_device_size_bytes gauge
+contrail_swift_device_size_bytes{device="d1",name="swift1-storage",namespace="default",pod="swift1-storage-statefulset-0"} 1000
+# HELP contrail_swift_device_used_bytes Number of bytes used on the mounted device of the Swift storage pod.
+# TYPE contrail_swift_device_used_bytes gauge
+contrail_swift_device_used_bytes{device="d1",name="swift1-storage",namespace="default",pod="swift1-storage-statefulset-0"} 750
+# HELP contrail_swift_replication_lag_seconds Number of seconds since the object replicator of the Swift storage pod completed its last pass.
+# TYPE contrail_swift_replication_lag_seconds gauge
+contrail_swift_replication_lag_seconds{name="swift1-storage",namespace="default",pod="swift1-storage-statefulset-0"} 120
+# HELP contrail_swift_quarantined Number of quarantined objects, containers or accounts of the Swift storage pod.
+# TYPE contrail_swift_quarantined gauge
+contrail_swift_quarantined{name="swift1-storage",namespace="default",pod="swift1-storage-statefulset-0",type="accounts"} 0
+contrail_swift_quarantined{name="swift1-storage",namespace="default",pod="swift1-storage-statefulset-0",type="containers"} 0
+contrail_swift_quarantined{name="swift1-storage",namespace="default",pod="swift1-storage-statefulset-0",type="objects"} 2
+`
+		assert.NoError(t, testutil.CollectAndCompare(collector, strings.NewReader(expected),
+			"contrail_swift_device_size_bytes", "contrail_swift_device_used_bytes",
+			"contrail_swift_replication_lag_seconds", "contrail_swift_quarantined"))
+	})
+}
+
+func newCertificate(t *testing.T, notAfter time.Time) []byte {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "test"},
+		NotBefore:    notAfter.Add(-365 * 24 * time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
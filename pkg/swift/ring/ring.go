@@ -3,128 +3,435 @@ package ring
 import (
 	"errors"
 	"fmt"
+	"math"
+	"sort"
+	"time"
+)
 
-	batch "k8s.io/api/batch/v1"
-	core "k8s.io/api/core/v1"
-	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/types"
+const (
+	maxPartPower = 32
+	// maxDevices is limited by two byte device ids stored in ring files.
+	maxDevices = math.MaxUint16
+	unassigned = -1
 )
 
-func New(configMap types.NamespacedName, ringType, serviceAccountName string) (*Ring, error) {
-	if configMap.Name == "" {
-		return nil, errors.New("empty config map name type")
+// Device is a disk of a storage node that holds partitions of a ring.
+type Device struct {
+	ID     int    `json:"id"`
+	Region int    `json:"region"`
+	Zone   int    `json:"zone"`
+	IP     string `json:"ip"`
+	Port   int    `json:"port"`
+	Device string `json:"device"`
+	// Meta identifies the disk of the device when its address changes.
+	Meta string `json:"meta,omitempty"`
+	// Weight sets the relative amount of partitions assigned to the device.
+	// Devices with zero weight are drained.
+	Weight float64 `json:"weight"`
+}
+
+func (d Device) Formatted() string {
+	return fmt.Sprintf("r%dz%d-%s:%d/%s", d.Region, d.Zone, d.IP, d.Port, d.Device)
+}
+
+// sameDisk tells whether the device is the given one moved to another address.
+func (d Device) sameDisk(other Device) bool {
+	return d.Meta != "" && d.Meta == other.Meta && d.Device == other.Device &&
+		d.Region == other.Region && d.Zone == other.Zone && d.Port == other.Port
+}
+
+func (d Device) validate() error {
+	if d.Region < 0 {
+		return errors.New("negative region")
 	}
+	if d.Zone < 0 {
+		return errors.New("negative zone")
+	}
+	if d.IP == "" {
+		return errors.New("empty IP")
+	}
+	if d.Device == "" {
+		return errors.New("empty device")
+	}
+	if d.Weight < 0 {
+		return errors.New("negative weight")
+	}
+	return nil
+}
 
-	if ringType == "" {
-		return nil, errors.New("empty ring type")
+// Builder assigns replicas of ring partitions to devices the same way
+// as swift-ring-builder does. The whole state of the builder is exported,
+// so that it can be stored between rebalances.
+type Builder struct {
+	PartPower    int `json:"partPower"`
+	Replicas     int `json:"replicas"`
+	MinPartHours int `json:"minPartHours"`
+	Version      int `json:"version"`
+	// Devices are indexed by their ids. Removed devices leave nil entries,
+	// so that ids of the remaining ones never change.
+	Devices []*Device `json:"devices"`
+	// Replica2Part2Dev holds ids of devices assigned to every replica of every partition.
+	Replica2Part2Dev [][]int `json:"replica2Part2Dev"`
+	// LastPartMoves holds the unix time of the last move of every partition.
+	LastPartMoves []int64 `json:"lastPartMoves"`
+}
+
+func NewBuilder(partPower, replicas, minPartHours int) (*Builder, error) {
+	if partPower < 1 || partPower > maxPartPower {
+		return nil, fmt.Errorf("part power must be between 1 and %d", maxPartPower)
+	}
+	if replicas < 1 {
+		return nil, errors.New("at least one replica is required")
+	}
+	if minPartHours < 0 {
+		return nil, errors.New("negative min part hours")
+	}
+	b := &Builder{
+		PartPower:        partPower,
+		Replicas:         replicas,
+		MinPartHours:     minPartHours,
+		Replica2Part2Dev: make([][]int, replicas),
+		LastPartMoves:    make([]int64, 1<<partPower),
 	}
+	for r := range b.Replica2Part2Dev {
+		b.Replica2Part2Dev[r] = make([]int, b.parts())
+		for p := range b.Replica2Part2Dev[r] {
+			b.Replica2Part2Dev[r][p] = unassigned
+		}
+	}
+	return b, nil
+}
+
+func (b *Builder) parts() int {
+	return 1 << b.PartPower
+}
 
-	if serviceAccountName == "" {
-		return nil, errors.New("service account name was not provided")
+// SetDevices brings devices of the ring to the given ones. Devices are identified
+// by region, zone, address and name, or by meta when their address changed.
+// Weights and addresses of existing devices are updated in place, so that they
+// keep their partitions, missing ones are added and the rest is removed.
+// Partitions of removed devices are reassigned during the next rebalance
+// regardless of MinPartHours.
+func (b *Builder) SetDevices(devices []Device) error {
+	if b == nil {
+		return errors.New("nil builder")
 	}
+	wanted := map[string]Device{}
+	for _, device := range devices {
+		if err := device.validate(); err != nil {
+			return err
+		}
+		if _, ok := wanted[device.Formatted()]; ok {
+			return fmt.Errorf("duplicated device %s", device.Formatted())
+		}
+		wanted[device.Formatted()] = device
+	}
+	matched := map[string]*Device{}
+	kept := map[int]bool{}
+	for _, d := range b.Devices {
+		if d == nil {
+			continue
+		}
+		device, ok := wanted[d.Formatted()]
+		if ok && (d.Meta == "" || device.Meta == "" || d.Meta == device.Meta) {
+			matched[d.Formatted()] = d
+			kept[d.ID] = true
+		}
+	}
+	for _, device := range devices {
+		if _, ok := matched[device.Formatted()]; ok {
+			continue
+		}
+		for _, d := range b.Devices {
+			if d != nil && !kept[d.ID] && d.sameDisk(device) {
+				matched[device.Formatted()] = d
+				kept[d.ID] = true
+				break
+			}
+		}
+	}
+	changed := false
+	for id, d := range b.Devices {
+		if d != nil && !kept[id] {
+			b.removeDevice(id)
+			changed = true
+		}
+	}
+	for _, device := range devices {
+		if d, ok := matched[device.Formatted()]; ok {
+			if d.IP != device.IP || d.Meta != device.Meta || d.Weight != device.Weight {
+				d.IP = device.IP
+				d.Meta = device.Meta
+				d.Weight = device.Weight
+				changed = true
+			}
+			continue
+		}
+		if err := b.addDevice(device); err != nil {
+			return err
+		}
+		changed = true
+	}
+	if changed {
+		b.Version++
+	}
+	return nil
+}
 
-	if configMap.Namespace == "" {
-		configMap.Namespace = "default"
+func (b *Builder) addDevice(device Device) error {
+	for id, d := range b.Devices {
+		if d == nil {
+			device.ID = id
+			b.Devices[id] = &device
+			return nil
+		}
 	}
+	if len(b.Devices) >= maxDevices {
+		return fmt.Errorf("ring cannot have more than %d devices", maxDevices)
+	}
+	device.ID = len(b.Devices)
+	b.Devices = append(b.Devices, &device)
+	return nil
+}
 
-	return &Ring{
-		configMap:          configMap,
-		ringType:           ringType,
-		serviceAccountName: serviceAccountName,
-	}, nil
+func (b *Builder) removeDevice(id int) {
+	b.Devices[id] = nil
+	for _, part2Dev := range b.Replica2Part2Dev {
+		for p, d := range part2Dev {
+			if d == id {
+				part2Dev[p] = unassigned
+			}
+		}
+	}
 }
 
-type Ring struct {
-	configMap          types.NamespacedName
-	devices            []Device
-	ringType           string
-	serviceAccountName string
+// Rebalance assigns unassigned replicas and moves replicas between devices until
+// every device holds the amount of partitions proportional to its weight. Replicas
+// of the same partition are spread across regions, zones and devices first.
+// At most one replica of a partition is moved within MinPartHours. Rebalance
+// returns false when some replicas are still waiting for MinPartHours to pass.
+func (b *Builder) Rebalance(now time.Time) (bool, error) {
+	if b == nil {
+		return false, errors.New("nil builder")
+	}
+	if b.weight() == 0 {
+		return false, errors.New("no devices with weight greater than zero")
+	}
+	wanted := b.partsWanted()
+	assigned := make([]int, len(b.Devices))
+	for _, part2Dev := range b.Replica2Part2Dev {
+		for p, d := range part2Dev {
+			if d >= len(b.Devices) || d != unassigned && b.Devices[d] == nil {
+				part2Dev[p] = unassigned
+			} else if d != unassigned {
+				assigned[d]++
+			}
+		}
+	}
+	moved := make([]bool, b.parts())
+	placed := make([][]bool, b.Replicas)
+	for r := range b.Replica2Part2Dev {
+		placed[r] = make([]bool, b.parts())
+	}
+	for p := 0; p < b.parts(); p++ {
+		for r := range b.Replica2Part2Dev {
+			if b.Replica2Part2Dev[r][p] != unassigned {
+				continue
+			}
+			d := b.pickDevice(p, unassigned, assigned, wanted)
+			b.Replica2Part2Dev[r][p] = d
+			assigned[d]++
+			moved[p] = true
+			placed[r][p] = true
+		}
+	}
+	minPartSeconds := int64(b.MinPartHours) * int64(time.Hour/time.Second)
+	balanced := true
+	for p := 0; p < b.parts(); p++ {
+		for r := range b.Replica2Part2Dev {
+			d := b.Replica2Part2Dev[r][p]
+			if !b.misplaced(p, r, assigned, wanted) {
+				continue
+			}
+			b.Replica2Part2Dev[r][p] = unassigned
+			assigned[d]--
+			best := b.pickDevice(p, d, assigned, wanted)
+			// Replicas placed during this rebalance hold no data yet, so they can be moved freely
+			locked := moved[p] || now.Unix()-b.LastPartMoves[p] < minPartSeconds
+			if best == d || locked && !placed[r][p] {
+				b.Replica2Part2Dev[r][p] = d
+				assigned[d]++
+				if best != d {
+					balanced = false
+				}
+				continue
+			}
+			b.Replica2Part2Dev[r][p] = best
+			assigned[best]++
+			moved[p] = true
+		}
+	}
+	changed := false
+	for p, m := range moved {
+		if m {
+			b.LastPartMoves[p] = now.Unix()
+			changed = true
+		}
+	}
+	if changed {
+		b.Version++
+	}
+	return balanced, nil
 }
 
-type Device struct {
-	Region string
-	Zone   string
-	IP     string
-	Port   int
-	Device string
+func (b *Builder) weight() float64 {
+	var weight float64
+	for _, device := range b.Devices {
+		if device != nil {
+			weight += device.Weight
+		}
+	}
+	return weight
 }
 
-func (d Device) Formatted() string {
-	return fmt.Sprintf("r%sz%s-%s:%d/%s", d.Region, d.Zone, d.IP, d.Port, d.Device)
+// partsWanted splits replicas of all partitions between devices proportionally
+// to their weights. A device never wants more than one replica of every partition.
+// Remainders are given to devices with the largest fractional parts.
+func (b *Builder) partsWanted() []int {
+	wanted := make([]int, len(b.Devices))
+	remaining := b.parts() * b.Replicas
+	var devices []*Device
+	for _, device := range b.Devices {
+		if device != nil && device.Weight > 0 {
+			devices = append(devices, device)
+		}
+	}
+	for {
+		var weight float64
+		for _, device := range devices {
+			weight += device.Weight
+		}
+		var uncapped []*Device
+		capped := 0
+		for _, device := range devices {
+			if float64(remaining)*device.Weight/weight > float64(b.parts()) {
+				wanted[device.ID] = b.parts()
+				capped++
+				continue
+			}
+			uncapped = append(uncapped, device)
+		}
+		remaining -= capped * b.parts()
+		devices = uncapped
+		if capped == 0 {
+			break
+		}
+	}
+	var weight float64
+	for _, device := range devices {
+		weight += device.Weight
+	}
+	type share struct {
+		id        int
+		remainder float64
+	}
+	var shares []share
+	total := remaining
+	for _, device := range devices {
+		exact := float64(total) * device.Weight / weight
+		wanted[device.ID] = int(math.Floor(exact))
+		remaining -= wanted[device.ID]
+		shares = append(shares, share{id: device.ID, remainder: exact - math.Floor(exact)})
+	}
+	sort.SliceStable(shares, func(i, j int) bool { return shares[i].remainder > shares[j].remainder })
+	for i := 0; remaining > 0 && i < len(shares); i++ {
+		wanted[shares[i].id]++
+		remaining--
+	}
+	return wanted
 }
 
-func (r *Ring) AddDevice(device Device) error {
-	if r == nil {
-		return errors.New("nil ring")
+// placement describes how well a device fits a replica of a partition.
+type placement struct {
+	sharedDevice bool
+	sharedRegion bool
+	sharedZone   bool
+	need         int
+}
+
+func (p placement) betterThan(other placement) bool {
+	if p.sharedDevice != other.sharedDevice {
+		return !p.sharedDevice
 	}
-	if device.Region == "" {
-		return errors.New("empty region")
+	if p.sharedRegion != other.sharedRegion {
+		return !p.sharedRegion
 	}
-	if device.Zone == "" {
-		return errors.New("empty zone")
+	if p.sharedZone != other.sharedZone {
+		return !p.sharedZone
 	}
-	if device.IP == "" {
-		return errors.New("empty IP")
+	return p.need > other.need
+}
+
+func (b *Builder) placement(part int, device *Device, assigned, wanted []int) placement {
+	pl := placement{need: wanted[device.ID] - assigned[device.ID]}
+	for _, part2Dev := range b.Replica2Part2Dev {
+		d := part2Dev[part]
+		if d == unassigned {
+			continue
+		}
+		other := b.Devices[d]
+		if other.ID == device.ID {
+			pl.sharedDevice = true
+		}
+		if other.Region == device.Region {
+			pl.sharedRegion = true
+			if other.Zone == device.Zone {
+				pl.sharedZone = true
+			}
+		}
 	}
-	if device.Device == "" {
-		return errors.New("empty device")
+	return pl
+}
+
+// pickDevice returns the best device for an unassigned replica of a partition.
+// The current device of the replica is kept unless it is drained or another one
+// fits strictly better. Other ties are broken by the lowest device id to keep
+// rebalances deterministic.
+func (b *Builder) pickDevice(part, current int, assigned, wanted []int) int {
+	best := unassigned
+	var bestPlacement placement
+	if current != unassigned && b.Devices[current].Weight > 0 {
+		best = current
+		bestPlacement = b.placement(part, b.Devices[current], assigned, wanted)
 	}
-	r.devices = append(r.devices, device)
-	return nil
+	for _, device := range b.Devices {
+		if device == nil || device.Weight == 0 {
+			continue
+		}
+		pl := b.placement(part, device, assigned, wanted)
+		if best == unassigned || pl.betterThan(bestPlacement) {
+			best = device.ID
+			bestPlacement = pl
+		}
+	}
+	return best
 }
 
-func (r *Ring) BuildJob(name types.NamespacedName, nodeSelector map[string]string) (batch.Job, error) {
-	if r == nil {
-		return batch.Job{}, errors.New("nil ring")
-	}
-	if len(r.devices) == 0 {
-		return batch.Job{}, errors.New("no devices added")
-	}
-	if name.Namespace == "" {
-		name.Namespace = "default"
-	}
-	if name.Name == "" {
-		return batch.Job{}, errors.New("no job name given")
-	}
-
-	return batch.Job{
-		ObjectMeta: meta.ObjectMeta{
-			Name:      name.Name,
-			Namespace: name.Namespace,
-		},
-		Spec: batch.JobSpec{
-			Template: core.PodTemplateSpec{
-				Spec: core.PodSpec{
-					NodeSelector:       nodeSelector,
-					HostNetwork:        true,
-					RestartPolicy:      core.RestartPolicyNever,
-					ServiceAccountName: r.serviceAccountName,
-					Containers: []core.Container{
-						{
-							Name:            "contrail-operator-ringcontroller",
-							Image:           "localhost:5000/contrail-operator/engprod-269421/contrail-operator-ringcontroller:master.latest",
-							ImagePullPolicy: core.PullIfNotPresent,
-							Args:            r.args(),
-						},
-					},
-					Tolerations: []core.Toleration{
-						{Operator: "Exists", Effect: "NoSchedule"},
-						{Operator: "Exists", Effect: "NoExecute"},
-					},
-				},
-			},
-		},
-	}, nil
-}
-
-func (r *Ring) args() []string {
-	if r == nil {
-		return []string{}
-	}
-	var argz []string
-	argz = append(argz, r.configMap.Namespace+"/"+r.configMap.Name, r.ringType)
-	for _, device := range r.devices {
-		argz = append(argz, device.Formatted())
-	}
-	return argz
+// misplaced tells whether a replica should be moved to another device because
+// the device is drained or overloaded or shares a zone with another replica.
+func (b *Builder) misplaced(part, replica int, assigned, wanted []int) bool {
+	device := b.Devices[b.Replica2Part2Dev[replica][part]]
+	if device.Weight == 0 || assigned[device.ID] > wanted[device.ID] {
+		return true
+	}
+	for r, part2Dev := range b.Replica2Part2Dev {
+		if r == replica {
+			continue
+		}
+		other := b.Devices[part2Dev[part]]
+		if other.Region == device.Region && other.Zone == device.Zone {
+			return true
+		}
+	}
+	return false
 }
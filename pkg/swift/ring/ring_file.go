@@ -0,0 +1,220 @@
+package ring
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+)
+
+// ringMagic starts ring files in the format read by Swift services.
+const ringMagic = "R1NG"
+
+const ringFormatVersion = 1
+
+type ringHeader struct {
+	ByteOrder    string        `json:"byteorder"`
+	Devices      []*ringDevice `json:"devs"`
+	PartShift    int           `json:"part_shift"`
+	ReplicaCount int           `json:"replica_count"`
+	Version      int           `json:"version"`
+}
+
+// ringDevice fields are sorted by their keys like in rings serialized by Swift.
+type ringDevice struct {
+	Device          string  `json:"device"`
+	ID              int     `json:"id"`
+	IP              string  `json:"ip"`
+	Meta            string  `json:"meta"`
+	Port            int     `json:"port"`
+	Region          int     `json:"region"`
+	ReplicationIP   string  `json:"replication_ip"`
+	ReplicationPort int     `json:"replication_port"`
+	Weight          float64 `json:"weight"`
+	Zone            int     `json:"zone"`
+}
+
+// Ring returns the gzipped ring file used by Swift proxy and storage services.
+// The same builder state always gives the same bytes.
+func (b *Builder) Ring() ([]byte, error) {
+	if b == nil {
+		return nil, errors.New("nil builder")
+	}
+	header := ringHeader{
+		ByteOrder:    "little",
+		Devices:      make([]*ringDevice, len(b.Devices)),
+		PartShift:    32 - b.PartPower,
+		ReplicaCount: b.Replicas,
+		Version:      b.Version,
+	}
+	for id, d := range b.Devices {
+		if d == nil {
+			continue
+		}
+		header.Devices[id] = &ringDevice{
+			Device:          d.Device,
+			ID:              d.ID,
+			IP:              d.IP,
+			Meta:            d.Meta,
+			Port:            d.Port,
+			Region:          d.Region,
+			ReplicationIP:   d.IP,
+			ReplicationPort: d.Port,
+			Weight:          d.Weight,
+			Zone:            d.Zone,
+		}
+	}
+	headerJSON, err := json.Marshal(header)
+	if err != nil {
+		return nil, err
+	}
+	var ring bytes.Buffer
+	ring.WriteString(ringMagic)
+	_ = binary.Write(&ring, binary.BigEndian, uint16(ringFormatVersion))
+	_ = binary.Write(&ring, binary.BigEndian, uint32(len(headerJSON)))
+	ring.Write(headerJSON)
+	for _, part2Dev := range b.Replica2Part2Dev {
+		for _, d := range part2Dev {
+			if d == unassigned {
+				return nil, errors.New("ring is not balanced")
+			}
+			_ = binary.Write(&ring, binary.LittleEndian, uint16(d))
+		}
+	}
+	return compress(ring.Bytes())
+}
+
+// ReadRing creates a builder from a ring file written by Swift or by the Ring method.
+// Partitions of the created builder can be moved immediately.
+func ReadRing(data []byte, minPartHours int) (*Builder, error) {
+	ring, err := decompress(data)
+	if err != nil {
+		return nil, err
+	}
+	r := bytes.NewReader(ring)
+	magic := make([]byte, len(ringMagic))
+	if _, err = io.ReadFull(r, magic); err != nil || string(magic) != ringMagic {
+		return nil, errors.New("unknown ring file format")
+	}
+	var formatVersion uint16
+	if err = binary.Read(r, binary.BigEndian, &formatVersion); err != nil {
+		return nil, err
+	}
+	if formatVersion != ringFormatVersion {
+		return nil, fmt.Errorf("unsupported ring file version %d", formatVersion)
+	}
+	var headerLength uint32
+	if err = binary.Read(r, binary.BigEndian, &headerLength); err != nil {
+		return nil, err
+	}
+	headerJSON := make([]byte, headerLength)
+	if _, err = io.ReadFull(r, headerJSON); err != nil {
+		return nil, err
+	}
+	header := ringHeader{}
+	if err = json.Unmarshal(headerJSON, &header); err != nil {
+		return nil, err
+	}
+	var byteOrder binary.ByteOrder = binary.LittleEndian
+	if header.ByteOrder == "big" {
+		byteOrder = binary.BigEndian
+	}
+	b, err := NewBuilder(32-header.PartShift, header.ReplicaCount, minPartHours)
+	if err != nil {
+		return nil, err
+	}
+	b.Version = header.Version
+	b.Devices = make([]*Device, len(header.Devices))
+	for id, d := range header.Devices {
+		if d == nil {
+			continue
+		}
+		b.Devices[id] = &Device{
+			ID:     id,
+			Region: d.Region,
+			Zone:   d.Zone,
+			IP:     d.IP,
+			Port:   d.Port,
+			Device: d.Device,
+			Meta:   d.Meta,
+			Weight: d.Weight,
+		}
+	}
+	part2Dev := make([]uint16, b.parts())
+	for _, assignments := range b.Replica2Part2Dev {
+		if err = binary.Read(r, byteOrder, part2Dev); err != nil {
+			return nil, err
+		}
+		for p, d := range part2Dev {
+			if int(d) >= len(b.Devices) || b.Devices[d] == nil {
+				return nil, fmt.Errorf("partition %d is assigned to unknown device %d", p, d)
+			}
+			assignments[p] = int(d)
+		}
+	}
+	return b, nil
+}
+
+// Marshal returns the compressed state of the builder.
+func (b *Builder) Marshal() ([]byte, error) {
+	if b == nil {
+		return nil, errors.New("nil builder")
+	}
+	data, err := json.Marshal(b)
+	if err != nil {
+		return nil, err
+	}
+	return compress(data)
+}
+
+// Load restores a builder saved by Marshal.
+func Load(data []byte) (*Builder, error) {
+	data, err := decompress(data)
+	if err != nil {
+		return nil, err
+	}
+	b := &Builder{}
+	if err = json.Unmarshal(data, b); err != nil {
+		return nil, err
+	}
+	if b.PartPower < 1 || b.PartPower > maxPartPower || len(b.LastPartMoves) != b.parts() {
+		return nil, errors.New("invalid part power")
+	}
+	if b.Replicas != len(b.Replica2Part2Dev) {
+		return nil, errors.New("invalid replica count")
+	}
+	for _, part2Dev := range b.Replica2Part2Dev {
+		if len(part2Dev) != b.parts() {
+			return nil, errors.New("invalid partition count")
+		}
+	}
+	return b, nil
+}
+
+func compress(data []byte) ([]byte, error) {
+	var buffer bytes.Buffer
+	writer, err := gzip.NewWriterLevel(&buffer, gzip.BestCompression)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = writer.Write(data); err != nil {
+		return nil, err
+	}
+	if err = writer.Close(); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+func decompress(data []byte) ([]byte, error) {
+	reader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return ioutil.ReadAll(reader)
+}
@@ -1,313 +1,413 @@
 package ring_test
 
 import (
-	"strings"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/json"
+	"io/ioutil"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
-	"k8s.io/apimachinery/pkg/types"
-
-	core "k8s.io/api/core/v1"
 
 	"github.com/Juniper/contrail-operator/pkg/swift/ring"
 )
 
-func TestNew(t *testing.T) {
-	t.Run("should return error when ring type is empty", func(t *testing.T) {
-		_, err := ring.New(types.NamespacedName{Name: "rings"}, "", "service-account")
+var now = time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+
+func TestNewBuilder(t *testing.T) {
+	tests := map[string]struct {
+		partPower    int
+		replicas     int
+		minPartHours int
+	}{
+		"zero part power":        {partPower: 0, replicas: 1, minPartHours: 1},
+		"too big part power":     {partPower: 33, replicas: 1, minPartHours: 1},
+		"zero replicas":          {partPower: 10, replicas: 0, minPartHours: 1},
+		"negative min part hour": {partPower: 10, replicas: 1, minPartHours: -1},
+	}
+	for name, test := range tests {
+		t.Run("should return error when "+name+" given", func(t *testing.T) {
+			_, err := ring.NewBuilder(test.partPower, test.replicas, test.minPartHours)
+			assert.Error(t, err)
+		})
+	}
+	t.Run("should create a builder without assigned partitions", func(t *testing.T) {
+		builder, err := ring.NewBuilder(4, 3, 1)
+		require.NoError(t, err)
+		require.Len(t, builder.Replica2Part2Dev, 3)
+		assert.Len(t, builder.Replica2Part2Dev[0], 16)
+		assert.Len(t, builder.LastPartMoves, 16)
+		_, err = builder.Ring()
 		assert.Error(t, err)
 	})
-	t.Run("should return error when service account name is empty", func(t *testing.T) {
-		_, err := ring.New(types.NamespacedName{Name: "rings"}, "account", "")
-		assert.Error(t, err)
+}
+
+func TestBuilder_SetDevices(t *testing.T) {
+	t.Run("should return error when device is invalid", func(t *testing.T) {
+		tests := map[string]ring.Device{
+			"negative region": {Region: -1, Zone: 1, IP: "192.168.0.1", Port: 6000, Device: "d1"},
+			"negative zone":   {Region: 1, Zone: -1, IP: "192.168.0.1", Port: 6000, Device: "d1"},
+			"empty IP":        {Region: 1, Zone: 1, IP: "", Port: 6000, Device: "d1"},
+			"empty device":    {Region: 1, Zone: 1, IP: "192.168.0.1", Port: 6000, Device: ""},
+			"negative weight": {Region: 1, Zone: 1, IP: "192.168.0.1", Port: 6000, Device: "d1", Weight: -1},
+		}
+		for name, device := range tests {
+			t.Run(name, func(t *testing.T) {
+				builder, _ := ring.NewBuilder(4, 1, 1)
+				assert.Error(t, builder.SetDevices([]ring.Device{device}))
+			})
+		}
+	})
+	t.Run("should return error when device is duplicated", func(t *testing.T) {
+		builder, _ := ring.NewBuilder(4, 1, 1)
+		device := newDevice(1, 1, "192.168.0.1", 100)
+		assert.Error(t, builder.SetDevices([]ring.Device{device, device}))
+	})
+	t.Run("should keep ids of devices and reuse ids of removed ones", func(t *testing.T) {
+		builder, _ := ring.NewBuilder(4, 1, 1)
+		require.NoError(t, builder.SetDevices([]ring.Device{
+			newDevice(1, 1, "192.168.0.1", 100),
+			newDevice(1, 1, "192.168.0.2", 100),
+			newDevice(1, 1, "192.168.0.3", 100),
+		}))
+		// when
+		require.NoError(t, builder.SetDevices([]ring.Device{
+			newDevice(1, 1, "192.168.0.3", 50),
+			newDevice(1, 1, "192.168.0.4", 100),
+			newDevice(1, 1, "192.168.0.1", 100),
+		}))
+		// then
+		require.Len(t, builder.Devices, 3)
+		assert.Equal(t, "r1z1-192.168.0.1:6000/d1", builder.Devices[0].Formatted())
+		assert.Equal(t, "r1z1-192.168.0.4:6000/d1", builder.Devices[1].Formatted())
+		assert.Equal(t, "r1z1-192.168.0.3:6000/d1", builder.Devices[2].Formatted())
+		assert.Equal(t, 50.0, builder.Devices[2].Weight)
+		assert.Equal(t, 2, builder.Version)
 	})
-	t.Run("should create a ring", func(t *testing.T) {
-		accountRing, err := ring.New(types.NamespacedName{Name: "rings"}, "account", "service-account")
+	t.Run("should keep partitions of devices moved to another address", func(t *testing.T) {
+		builder, _ := ring.NewBuilder(8, 1, 1)
+		first, second := newDevice(1, 1, "192.168.0.1", 100), newDevice(1, 2, "192.168.0.2", 100)
+		first.Meta, second.Meta = "pod-0", "pod-1"
+		require.NoError(t, builder.SetDevices([]ring.Device{first, second}))
+		_, err := builder.Rebalance(now)
 		require.NoError(t, err)
-		assert.NotNil(t, accountRing)
+		assignments := builder.Replica2Part2Dev[0]
+		expected := append([]int(nil), assignments...)
+		// when
+		first.IP = "192.168.0.3"
+		require.NoError(t, builder.SetDevices([]ring.Device{first, second}))
+		balanced, err := builder.Rebalance(now.Add(time.Minute))
+		// then
+		require.NoError(t, err)
+		assert.True(t, balanced)
+		require.Len(t, builder.Devices, 2)
+		assert.Equal(t, "r1z1-192.168.0.3:6000/d1", builder.Devices[0].Formatted())
+		assert.Equal(t, expected, builder.Replica2Part2Dev[0])
+	})
+	t.Run("should not confuse devices which swapped addresses", func(t *testing.T) {
+		builder, _ := ring.NewBuilder(4, 1, 1)
+		first, second := newDevice(1, 1, "192.168.0.1", 100), newDevice(1, 1, "192.168.0.2", 100)
+		first.Meta, second.Meta = "pod-0", "pod-1"
+		require.NoError(t, builder.SetDevices([]ring.Device{first, second}))
+		// when
+		first.IP, second.IP = second.IP, first.IP
+		require.NoError(t, builder.SetDevices([]ring.Device{first, second}))
+		// then
+		require.Len(t, builder.Devices, 2)
+		assert.Equal(t, "pod-0", builder.Devices[0].Meta)
+		assert.Equal(t, "192.168.0.2", builder.Devices[0].IP)
+		assert.Equal(t, "pod-1", builder.Devices[1].Meta)
+		assert.Equal(t, "192.168.0.1", builder.Devices[1].IP)
+	})
+	t.Run("should not change version when devices are the same", func(t *testing.T) {
+		builder, _ := ring.NewBuilder(4, 1, 1)
+		devices := []ring.Device{newDevice(1, 1, "192.168.0.1", 100)}
+		require.NoError(t, builder.SetDevices(devices))
+		// when
+		require.NoError(t, builder.SetDevices(devices))
+		// then
+		assert.Equal(t, 1, builder.Version)
 	})
 }
 
-func TestRing_BuildJob(t *testing.T) {
-	nodeSelector := map[string]string{"node-role.kubernetes.io/master": ""}
-	jobName := types.NamespacedName{
-		Namespace: "contrail",
-		Name:      "ring-account-job",
-	}
-	device := ring.Device{
-		Region: "1",
-		Zone:   "2",
-		IP:     "192.168.0.1",
-		Port:   5000,
-		Device: "d1",
-	}
-	t.Run("should return error when no devices have been added", func(t *testing.T) {
-		account, _ := ring.New(types.NamespacedName{Name: "rings"}, "account", "service-account")
-		_, err := account.BuildJob(jobName, nodeSelector)
+func TestBuilder_Rebalance(t *testing.T) {
+	t.Run("should return error when there are no devices with weight", func(t *testing.T) {
+		builder, _ := ring.NewBuilder(4, 1, 1)
+		_, err := builder.Rebalance(now)
+		assert.Error(t, err)
+		require.NoError(t, builder.SetDevices([]ring.Device{newDevice(1, 1, "192.168.0.1", 0)}))
+		_, err = builder.Rebalance(now)
 		assert.Error(t, err)
 	})
-	t.Run("should create a job with given name", func(t *testing.T) {
-		account, _ := ring.New(types.NamespacedName{Name: "rings"}, "account", "service-account")
-		_ = account.AddDevice(device)
+	t.Run("should assign partitions proportionally to weights", func(t *testing.T) {
+		builder, _ := ring.NewBuilder(10, 1, 1)
+		require.NoError(t, builder.SetDevices([]ring.Device{
+			newDevice(1, 1, "192.168.0.1", 100),
+			newDevice(1, 1, "192.168.0.2", 100),
+			newDevice(1, 1, "192.168.0.3", 200),
+		}))
 		// when
-		job, err := account.BuildJob(jobName, nodeSelector)
+		balanced, err := builder.Rebalance(now)
+		// then
 		require.NoError(t, err)
-		assert.Equal(t, jobName.Name, job.Name)
-		assert.Equal(t, jobName.Namespace, job.Namespace)
+		assert.True(t, balanced)
+		assert.Equal(t, []int{256, 256, 512}, partsPerDevice(builder))
 	})
-	t.Run("should use default namespace when job namespace not given", func(t *testing.T) {
-		account, _ := ring.New(types.NamespacedName{Name: "rings"}, "account", "service-account")
-		_ = account.AddDevice(device)
+	t.Run("should place replicas of every partition in different zones", func(t *testing.T) {
+		builder, _ := ring.NewBuilder(8, 3, 1)
+		require.NoError(t, builder.SetDevices([]ring.Device{
+			newDevice(1, 1, "192.168.0.1", 100),
+			newDevice(1, 1, "192.168.0.2", 100),
+			newDevice(1, 2, "192.168.0.3", 100),
+			newDevice(1, 2, "192.168.0.4", 100),
+			newDevice(1, 3, "192.168.0.5", 100),
+			newDevice(1, 3, "192.168.0.6", 100),
+		}))
 		// when
-		job, err := account.BuildJob(types.NamespacedName{
-			Namespace: "",
-			Name:      "ring-account-job",
-		}, nodeSelector)
+		balanced, err := builder.Rebalance(now)
+		// then
 		require.NoError(t, err)
-		assert.Equal(t, "default", job.Namespace)
+		assert.True(t, balanced)
+		assert.Equal(t, []int{128, 128, 128, 128, 128, 128}, partsPerDevice(builder))
+		for p := 0; p < 256; p++ {
+			zones := map[int]bool{}
+			for r := 0; r < 3; r++ {
+				zones[builder.Devices[builder.Replica2Part2Dev[r][p]].Zone] = true
+			}
+			assert.Len(t, zones, 3, "replicas of partition %d are not spread across zones", p)
+		}
 	})
-	t.Run("should return error when job name not given", func(t *testing.T) {
-		account, _ := ring.New(types.NamespacedName{Name: "rings"}, "account", "service-account")
-		_ = account.AddDevice(device)
+	t.Run("should place replicas on different devices when there are less zones than replicas", func(t *testing.T) {
+		builder, _ := ring.NewBuilder(6, 2, 1)
+		require.NoError(t, builder.SetDevices([]ring.Device{
+			newDevice(1, 1, "192.168.0.1", 100),
+			newDevice(1, 1, "192.168.0.2", 100),
+		}))
 		// when
-		_, err := account.BuildJob(types.NamespacedName{
-			Namespace: "contrail",
-			Name:      "",
-		}, nodeSelector)
-		assert.Error(t, err)
-	})
-	t.Run("should return error when config map name not given", func(t *testing.T) {
-		_, err := ring.New(types.NamespacedName{Name: ""}, "account", "service-account")
-		assert.Error(t, err)
-	})
-	t.Run("should pass namespace name of config map", func(t *testing.T) {
-		tests := map[string]struct {
-			configMap            types.NamespacedName
-			expectedRingFileName string
-		}{
-			"1": {
-				configMap:            types.NamespacedName{Namespace: "contrail", Name: "swift"},
-				expectedRingFileName: "contrail/swift",
-			},
-			"2": {
-				configMap:            types.NamespacedName{Namespace: "", Name: "swift"},
-				expectedRingFileName: "default/swift",
-			},
-			"3": {
-				configMap:            types.NamespacedName{Namespace: "contrail", Name: "contrail"},
-				expectedRingFileName: "contrail/contrail",
-			},
-		}
-		for name, test := range tests {
-			t.Run(name, func(t *testing.T) {
-				account, _ := ring.New(test.configMap, "account", "service-account")
-				_ = account.AddDevice(device)
-				// when
-				job, _ := account.BuildJob(jobName, nodeSelector)
-				// then
-				containers := job.Spec.Template.Spec.Containers
-				require.Len(t, containers, 1)
-				args := containers[0].Args
-				require.NotEmpty(t, args)
-				assert.Equal(t, test.expectedRingFileName, args[0])
-			})
+		_, err := builder.Rebalance(now)
+		// then
+		require.NoError(t, err)
+		for p := 0; p < 64; p++ {
+			assert.NotEqual(t, builder.Replica2Part2Dev[0][p], builder.Replica2Part2Dev[1][p])
 		}
 	})
-	t.Run("should return error when ringType not given", func(t *testing.T) {
-		_, err := ring.New(types.NamespacedName{Name: "rings"}, "", "service-account")
-		assert.Error(t, err)
+	t.Run("should be deterministic", func(t *testing.T) {
+		devices := []ring.Device{
+			newDevice(1, 1, "192.168.0.1", 100),
+			newDevice(1, 2, "192.168.0.2", 70),
+			newDevice(2, 1, "192.168.0.3", 30),
+		}
+		first, _ := ring.NewBuilder(8, 2, 1)
+		second, _ := ring.NewBuilder(8, 2, 1)
+		require.NoError(t, first.SetDevices(devices))
+		require.NoError(t, second.SetDevices(devices))
+		// when
+		_, err := first.Rebalance(now)
+		require.NoError(t, err)
+		_, err = second.Rebalance(now)
+		require.NoError(t, err)
+		// then
+		firstRing, err := first.Ring()
+		require.NoError(t, err)
+		secondRing, err := second.Ring()
+		require.NoError(t, err)
+		assert.Equal(t, firstRing, secondRing)
 	})
-	t.Run("should pass ringType", func(t *testing.T) {
-		account, _ := ring.New(types.NamespacedName{Name: "rings"}, "account", "service-account")
-		_ = account.AddDevice(device)
+	t.Run("should not move partitions before min part hours pass", func(t *testing.T) {
+		builder, _ := ring.NewBuilder(8, 1, 1)
+		require.NoError(t, builder.SetDevices([]ring.Device{newDevice(1, 1, "192.168.0.1", 100)}))
+		_, err := builder.Rebalance(now)
+		require.NoError(t, err)
+		require.NoError(t, builder.SetDevices([]ring.Device{
+			newDevice(1, 1, "192.168.0.1", 100),
+			newDevice(1, 2, "192.168.0.2", 100),
+		}))
 		// when
-		job, _ := account.BuildJob(jobName, nodeSelector)
+		balanced, err := builder.Rebalance(now.Add(30 * time.Minute))
+		// then
+		require.NoError(t, err)
+		assert.False(t, balanced)
+		assert.Equal(t, []int{256, 0}, partsPerDevice(builder))
+		// and when
+		balanced, err = builder.Rebalance(now.Add(time.Hour))
 		// then
-		containers := job.Spec.Template.Spec.Containers
-		require.Len(t, containers, 1)
-		args := containers[0].Args
-		require.NotEmpty(t, args)
-		assert.Equal(t, "account", args[1])
+		require.NoError(t, err)
+		assert.True(t, balanced)
+		assert.Equal(t, []int{128, 128}, partsPerDevice(builder))
 	})
-	t.Run("should pass formatted devices arguments", func(t *testing.T) {
-		tests := map[string]struct {
-			devices         []ring.Device
-			expectedDevices []string
-		}{
-			"one device": {
-				devices: []ring.Device{
-					{
-						Region: "1",
-						Zone:   "2",
-						IP:     "192.168.0.2",
-						Port:   6000,
-						Device: "d2",
-					},
-				},
-				expectedDevices: []string{
-					"r1z2-192.168.0.2:6000/d2",
-				},
-			},
-			"one device, different values": {
-				devices: []ring.Device{
-					{
-						Region: "2",
-						Zone:   "3",
-						IP:     "192.168.0.3",
-						Port:   5000,
-						Device: "d3",
-					},
-				},
-				expectedDevices: []string{
-					"r2z3-192.168.0.3:5000/d3",
-				},
-			},
-			"two devices": {
-				devices: []ring.Device{
-					{
-						Region: "2",
-						Zone:   "3",
-						IP:     "192.168.0.3",
-						Port:   5000,
-						Device: "d3",
-					},
-					{
-						Region: "5",
-						Zone:   "6",
-						IP:     "192.168.0.8",
-						Port:   6000,
-						Device: "d9",
-					},
-				},
-				expectedDevices: []string{
-					"r2z3-192.168.0.3:5000/d3",
-					"r5z6-192.168.0.8:6000/d9",
-				},
-			},
-		}
-		for name, test := range tests {
-			t.Run(name, func(t *testing.T) {
-				account, _ := ring.New(types.NamespacedName{Name: "rings"}, "account", "service-account")
-				for _, device := range test.devices {
-					_ = account.AddDevice(device)
+	t.Run("should move only one replica of a partition within min part hours", func(t *testing.T) {
+		builder, _ := ring.NewBuilder(6, 2, 1)
+		require.NoError(t, builder.SetDevices([]ring.Device{
+			newDevice(1, 1, "192.168.0.1", 100),
+			newDevice(1, 1, "192.168.0.2", 100),
+		}))
+		_, err := builder.Rebalance(now)
+		require.NoError(t, err)
+		require.NoError(t, builder.SetDevices([]ring.Device{
+			newDevice(1, 1, "192.168.0.1", 100),
+			newDevice(1, 1, "192.168.0.2", 100),
+			newDevice(1, 2, "192.168.0.3", 100),
+			newDevice(1, 3, "192.168.0.4", 100),
+		}))
+		before := copyAssignments(builder)
+		// when
+		balanced, err := builder.Rebalance(now.Add(2 * time.Hour))
+		// then
+		require.NoError(t, err)
+		assert.False(t, balanced)
+		for p := 0; p < 64; p++ {
+			moves := 0
+			for r := 0; r < 2; r++ {
+				if before[r][p] != builder.Replica2Part2Dev[r][p] {
+					moves++
 				}
-				// when
-				job, err := account.BuildJob(jobName, nodeSelector)
-				// then
-				require.NoError(t, err)
-				containers := job.Spec.Template.Spec.Containers
-				require.Len(t, containers, 1)
-				args := containers[0].Args
-				assert.Equal(t, test.expectedDevices, args[2:])
-			})
+			}
+			assert.True(t, moves <= 1, "%d replicas of partition %d moved", moves, p)
 		}
 	})
-	t.Run("should specify container's required properties", func(t *testing.T) {
-		account, _ := ring.New(types.NamespacedName{Name: "rings"}, "account", "service-account")
-		_ = account.AddDevice(device)
+	t.Run("should reassign partitions of removed devices immediately", func(t *testing.T) {
+		builder, _ := ring.NewBuilder(8, 1, 1)
+		require.NoError(t, builder.SetDevices([]ring.Device{
+			newDevice(1, 1, "192.168.0.1", 100),
+			newDevice(1, 2, "192.168.0.2", 100),
+		}))
+		_, err := builder.Rebalance(now)
+		require.NoError(t, err)
+		require.NoError(t, builder.SetDevices([]ring.Device{newDevice(1, 2, "192.168.0.2", 100)}))
 		// when
-		job, _ := account.BuildJob(jobName, nodeSelector)
+		balanced, err := builder.Rebalance(now)
 		// then
-		containers := job.Spec.Template.Spec.Containers
-		require.NotEmpty(t, containers)
-		container := containers[0]
-
-		t.Run("command", func(t *testing.T) {
-			assert.Empty(t, container.Command)
-		})
-
-		t.Run("name", func(t *testing.T) {
-			assert.NotEmpty(t, container.Name)
-		})
+		require.NoError(t, err)
+		assert.True(t, balanced)
+		assert.Equal(t, []int{0, 256}, partsPerDevice(builder))
 	})
-	t.Run("should specify container's image from local registry", func(t *testing.T) {
-		account, _ := ring.New(types.NamespacedName{Name: "rings"}, "account", "service-account")
-		_ = account.AddDevice(device)
+	t.Run("should drain devices with zero weight", func(t *testing.T) {
+		builder, _ := ring.NewBuilder(8, 1, 1)
+		require.NoError(t, builder.SetDevices([]ring.Device{
+			newDevice(1, 1, "192.168.0.1", 100),
+			newDevice(1, 2, "192.168.0.2", 100),
+		}))
+		_, err := builder.Rebalance(now)
+		require.NoError(t, err)
+		require.NoError(t, builder.SetDevices([]ring.Device{
+			newDevice(1, 1, "192.168.0.1", 0),
+			newDevice(1, 2, "192.168.0.2", 100),
+		}))
 		// when
-		job, _ := account.BuildJob(jobName, nodeSelector)
+		balanced, err := builder.Rebalance(now.Add(time.Hour))
 		// then
-		containers := job.Spec.Template.Spec.Containers
-		require.NotEmpty(t, containers)
-		image := containers[0].Image
-		assert.True(t, strings.HasPrefix(image, "localhost:5000/"))
+		require.NoError(t, err)
+		assert.True(t, balanced)
+		assert.Equal(t, []int{0, 256}, partsPerDevice(builder))
 	})
-
-	t.Run("should specify restartPolicy (default Always is not supported in jobs)", func(t *testing.T) {
-		account, _ := ring.New(types.NamespacedName{Name: "rings"}, "account", "service-account")
-		_ = account.AddDevice(device)
+	t.Run("should not change balanced ring", func(t *testing.T) {
+		builder, _ := ring.NewBuilder(8, 3, 1)
+		require.NoError(t, builder.SetDevices([]ring.Device{
+			newDevice(1, 1, "192.168.0.1", 100),
+			newDevice(1, 2, "192.168.0.2", 60),
+			newDevice(1, 3, "192.168.0.3", 100),
+			newDevice(1, 3, "192.168.0.4", 40),
+		}))
+		_, err := builder.Rebalance(now)
+		require.NoError(t, err)
+		ringBefore, err := builder.Ring()
+		require.NoError(t, err)
 		// when
-		job, _ := account.BuildJob(jobName, nodeSelector)
+		_, err = builder.Rebalance(now.Add(24 * time.Hour))
 		// then
-		assert.Equal(t, core.RestartPolicyNever, job.Spec.Template.Spec.RestartPolicy)
+		require.NoError(t, err)
+		ringAfter, err := builder.Ring()
+		require.NoError(t, err)
+		assert.Equal(t, ringBefore, ringAfter)
 	})
+}
 
-	t.Run("should specify nodeSelector", func(t *testing.T) {
-		account, _ := ring.New(types.NamespacedName{Name: "rings"}, "account", "service-account")
-		_ = account.AddDevice(device)
-		// when
-		job, _ := account.BuildJob(jobName, nodeSelector)
-		// then
-		assert.Equal(t, nodeSelector, job.Spec.Template.Spec.NodeSelector)
+func TestBuilder_Ring(t *testing.T) {
+	builder, _ := ring.NewBuilder(4, 2, 1)
+	require.NoError(t, builder.SetDevices([]ring.Device{
+		newDevice(1, 1, "192.168.0.1", 100),
+		newDevice(1, 2, "192.168.0.2", 100),
+	}))
+	_, err := builder.Rebalance(now)
+	require.NoError(t, err)
+	// when
+	ringFile, err := builder.Ring()
+	// then
+	require.NoError(t, err)
+	t.Run("should write ring in Swift format", func(t *testing.T) {
+		reader, err := gzip.NewReader(bytes.NewReader(ringFile))
+		require.NoError(t, err)
+		data, err := ioutil.ReadAll(reader)
+		require.NoError(t, err)
+		assert.Equal(t, "R1NG", string(data[:4]))
+		assert.Equal(t, uint16(1), binary.BigEndian.Uint16(data[4:6]))
+		headerLength := int(binary.BigEndian.Uint32(data[6:10]))
+		header := map[string]interface{}{}
+		require.NoError(t, json.Unmarshal(data[10:10+headerLength], &header))
+		assert.Equal(t, "little", header["byteorder"])
+		assert.Equal(t, 28.0, header["part_shift"])
+		assert.Equal(t, 2.0, header["replica_count"])
+		devs := header["devs"].([]interface{})
+		require.Len(t, devs, 2)
+		assert.Equal(t, map[string]interface{}{
+			"device": "d1", "id": 0.0, "ip": "192.168.0.1", "meta": "", "port": 6000.0, "region": 1.0,
+			"replication_ip": "192.168.0.1", "replication_port": 6000.0, "weight": 100.0, "zone": 1.0,
+		}, devs[0])
+		assert.Len(t, data[10+headerLength:], 2*2*16)
 	})
-
-	t.Run("should pass service account name", func(t *testing.T) {
-		account, _ := ring.New(types.NamespacedName{Name: "rings"}, "account", "service-account")
-		_ = account.AddDevice(device)
-		// when
-		job, _ := account.BuildJob(jobName, nodeSelector)
-		// then
-		assert.Equal(t, "service-account", job.Spec.Template.Spec.ServiceAccountName)
+	t.Run("should read written ring", func(t *testing.T) {
+		read, err := ring.ReadRing(ringFile, 1)
+		require.NoError(t, err)
+		assert.Equal(t, builder.Devices, read.Devices)
+		assert.Equal(t, builder.Replica2Part2Dev, read.Replica2Part2Dev)
+		assert.Equal(t, builder.Version, read.Version)
+		assert.Equal(t, 4, read.PartPower)
+		assert.Equal(t, 2, read.Replicas)
+	})
+	t.Run("should return error when ring file is invalid", func(t *testing.T) {
+		_, err := ring.ReadRing([]byte("ring"), 1)
+		assert.Error(t, err)
 	})
 }
 
-func TestRing_AddDevice(t *testing.T) {
-	t.Run("should return error when region is empty", func(t *testing.T) {
-		tests := map[string]ring.Device{
-			"empty region": {
-				Region: "",
-				Zone:   "1",
-				IP:     "192.168.0.1",
-				Port:   300,
-				Device: "d1",
-			},
-			"empty zone": {
-				Region: "1",
-				Zone:   "",
-				IP:     "192.168.0.1",
-				Port:   300,
-				Device: "d1",
-			},
-			"empty IP": {
-				Region: "1",
-				Zone:   "1",
-				IP:     "",
-				Port:   300,
-				Device: "d1",
-			},
-			"empty device": {
-				Region: "1",
-				Zone:   "1",
-				IP:     "192.168.0.1",
-				Port:   300,
-				Device: "",
-			},
-		}
-		for name, device := range tests {
-			t.Run(name, func(t *testing.T) {
-				theRing, _ := ring.New(types.NamespacedName{Name: "rings"}, "account", "service-account")
-				// when
-				err := theRing.AddDevice(device)
-				// then
-				require.Error(t, err)
-				// and when
-				_, err = theRing.BuildJob(types.NamespacedName{
-					Namespace: "t",
-					Name:      "t",
-				}, map[string]string{})
-				// then
-				require.Error(t, err)
-			})
+func TestLoad(t *testing.T) {
+	builder, _ := ring.NewBuilder(4, 2, 1)
+	require.NoError(t, builder.SetDevices([]ring.Device{
+		newDevice(1, 1, "192.168.0.1", 100),
+		newDevice(1, 2, "192.168.0.2", 100),
+	}))
+	_, err := builder.Rebalance(now)
+	require.NoError(t, err)
+	data, err := builder.Marshal()
+	require.NoError(t, err)
+	// when
+	loaded, err := ring.Load(data)
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, builder, loaded)
+}
+
+func newDevice(region, zone int, ip string, weight float64) ring.Device {
+	return ring.Device{Region: region, Zone: zone, IP: ip, Port: 6000, Device: "d1", Weight: weight}
+}
+
+func partsPerDevice(builder *ring.Builder) []int {
+	parts := make([]int, len(builder.Devices))
+	for _, part2Dev := range builder.Replica2Part2Dev {
+		for _, d := range part2Dev {
+			parts[d]++
 		}
-	})
+	}
+	return parts
+}
+
+func copyAssignments(builder *ring.Builder) [][]int {
+	var assignments [][]int
+	for _, part2Dev := range builder.Replica2Part2Dev {
+		assignments = append(assignments, append([]int{}, part2Dev...))
+	}
+	return assignments
 }
@@ -0,0 +1,100 @@
+package webhook
+
+import (
+	contrail "github.com/Juniper/contrail-operator/pkg/apis/contrail/v1alpha1"
+)
+
+// Defaulting functions copy values computed by ConfigurationParameters()
+// back into the spec, so that the stored resource shows the configuration
+// which is actually used by the controllers. ConfigurationParameters() keeps
+// values which are already set, so defaulting never overrides user input.
+
+func defaultCassandra(c *contrail.Cassandra) {
+	// ClusterName is left empty on purpose, Manager fills it with its own name.
+	config := c.ConfigurationParameters()
+	s := &c.Spec.ServiceConfiguration
+	s.Storage = config.Storage
+	s.Port = config.Port
+	s.CqlPort = config.CqlPort
+	s.JmxLocalPort = config.JmxLocalPort
+	s.StoragePort = config.StoragePort
+	s.SslStoragePort = config.SslStoragePort
+	if s.ListenAddress == "" {
+		s.ListenAddress = config.ListenAddress
+	}
+}
+
+func defaultZookeeper(z *contrail.Zookeeper) {
+	config := z.ConfigurationParameters()
+	s := &z.Spec.ServiceConfiguration
+	s.Storage = config.Storage
+	s.ClientPort = config.ClientPort
+	s.ElectionPort = config.ElectionPort
+	s.ServerPort = config.ServerPort
+	s.AdminEnableServer = config.AdminEnableServer
+	s.AdminPort = config.AdminPort
+}
+
+func defaultRabbitmq(r *contrail.Rabbitmq) {
+	config := r.ConfigurationParameters()
+	s := &r.Spec.ServiceConfiguration
+	s.Port = config.Port
+	s.SSLPort = config.SSLPort
+	s.Vhost = config.Vhost
+	s.Secret = config.Secret
+}
+
+func defaultConfig(c *contrail.Config) {
+	// AAAMode is not defaulted since its default depends on AuthMode
+	// and has to follow later changes of AuthMode.
+	config := c.ConfigurationParameters()
+	s := &c.Spec.ServiceConfiguration
+	s.APIPort = config.APIPort
+	s.AnalyticsPort = config.AnalyticsPort
+	s.CollectorPort = config.CollectorPort
+	s.RedisPort = config.RedisPort
+	s.ApiIntrospectPort = config.ApiIntrospectPort
+	s.SchemaIntrospectPort = config.SchemaIntrospectPort
+	s.DeviceManagerIntrospectPort = config.DeviceManagerIntrospectPort
+	s.SvcMonitorIntrospectPort = config.SvcMonitorIntrospectPort
+	s.AnalyticsApiIntrospectPort = config.AnalyticsApiIntrospectPort
+	s.CollectorIntrospectPort = config.CollectorIntrospectPort
+	s.NodeManager = config.NodeManager
+	s.LogLevel = config.LogLevel
+	s.AuthMode = config.AuthMode
+	s.AnalyticsDataTTL = config.AnalyticsDataTTL
+	s.AnalyticsConfigAuditTTL = config.AnalyticsConfigAuditTTL
+	s.AnalyticsStatisticsTTL = config.AnalyticsStatisticsTTL
+	s.AnalyticsFlowTTL = config.AnalyticsFlowTTL
+}
+
+func defaultControl(c *contrail.Control) {
+	config := c.ConfigurationParameters()
+	s := &c.Spec.ServiceConfiguration
+	s.BGPPort = config.BGPPort
+	s.ASNNumber = config.ASNNumber
+	s.XMPPPort = config.XMPPPort
+	s.DNSPort = config.DNSPort
+	s.DNSIntrospectPort = config.DNSIntrospectPort
+	s.NodeManager = config.NodeManager
+}
+
+func defaultKubemanager(k *contrail.Kubemanager) {
+	config := k.ConfigurationParameters()
+	s := &k.Spec.ServiceConfiguration
+	s.CloudOrchestrator = config.CloudOrchestrator
+	s.KubernetesAPIPort = config.KubernetesAPIPort
+	s.KubernetesAPISSLPort = config.KubernetesAPISSLPort
+	s.IPFabricSubnets = config.IPFabricSubnets
+	s.IPFabricForwarding = config.IPFabricForwarding
+	s.IPFabricSnat = config.IPFabricSnat
+	s.HostNetworkService = config.HostNetworkService
+	s.UseKubeadmConfig = config.UseKubeadmConfig
+}
+
+func defaultVrouter(v *contrail.Vrouter) {
+	config := v.ConfigurationParameters()
+	s := &v.Spec.ServiceConfiguration
+	s.MetaDataSecret = config.MetaDataSecret
+	s.NodeManager = config.NodeManager
+}
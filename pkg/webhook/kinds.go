@@ -0,0 +1,358 @@
+package webhook
+
+import (
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	contrail "github.com/Juniper/contrail-operator/pkg/apis/contrail/v1alpha1"
+)
+
+// kind describes how resources of a single kind are defaulted and validated.
+type kind struct {
+	object func() runtime.Object
+	// defaults fills unset fields with default values. May be nil.
+	defaults func(obj runtime.Object)
+	// validate returns errors found in obj. old is nil on create.
+	validate func(spec *field.Path, obj, old runtime.Object) field.ErrorList
+	// references lists the other instances obj depends on. May be nil.
+	references func(spec *field.Path, obj runtime.Object) []reference
+	// dependencies checks that a resource created on its own is given the
+	// configuration of the services it depends on, which the Manager fills
+	// in for resources it creates. May be nil.
+	dependencies func(spec *field.Path, obj runtime.Object) field.ErrorList
+}
+
+// reference points from a field of a resource to another instance by name.
+type reference struct {
+	path *field.Path
+	kind string
+	name string
+}
+
+// referencesTo returns references for all non empty instance names.
+func referencesTo(refs ...reference) []reference {
+	var nonEmpty []reference
+	for _, ref := range refs {
+		if ref.name != "" {
+			nonEmpty = append(nonEmpty, ref)
+		}
+	}
+	return nonEmpty
+}
+
+// nodesConfiguration tells whether the nodes configuration
+// of a dependency is set in the spec.
+type nodesConfiguration struct {
+	field string
+	set   bool
+}
+
+// requireNodesConfigurations returns errors for nodes configurations which are not set.
+func requireNodesConfigurations(serviceConfiguration *field.Path, configurations ...nodesConfiguration) field.ErrorList {
+	var errs field.ErrorList
+	for _, c := range configurations {
+		if !c.set {
+			errs = append(errs, field.Required(serviceConfiguration.Child(c.field), "must be set unless the resource is created by a Manager"))
+		}
+	}
+	return errs
+}
+
+var kinds = map[string]kind{
+	"Cassandra": {
+		object:   func() runtime.Object { return &contrail.Cassandra{} },
+		defaults: func(obj runtime.Object) { defaultCassandra(obj.(*contrail.Cassandra)) },
+		validate: func(spec *field.Path, obj, old runtime.Object) field.ErrorList {
+			var o *contrail.Cassandra
+			if old != nil {
+				o = old.(*contrail.Cassandra)
+			}
+			return validateCassandra(spec, obj.(*contrail.Cassandra), o)
+		},
+	},
+	"Zookeeper": {
+		object:   func() runtime.Object { return &contrail.Zookeeper{} },
+		defaults: func(obj runtime.Object) { defaultZookeeper(obj.(*contrail.Zookeeper)) },
+		validate: func(spec *field.Path, obj, old runtime.Object) field.ErrorList {
+			var o *contrail.Zookeeper
+			if old != nil {
+				o = old.(*contrail.Zookeeper)
+			}
+			return validateZookeeper(spec, obj.(*contrail.Zookeeper), o)
+		},
+	},
+	"Rabbitmq": {
+		object:   func() runtime.Object { return &contrail.Rabbitmq{} },
+		defaults: func(obj runtime.Object) { defaultRabbitmq(obj.(*contrail.Rabbitmq)) },
+		validate: func(spec *field.Path, obj, old runtime.Object) field.ErrorList {
+			return validateRabbitmq(spec, obj.(*contrail.Rabbitmq))
+		},
+	},
+	"Config": {
+		object:   func() runtime.Object { return &contrail.Config{} },
+		defaults: func(obj runtime.Object) { defaultConfig(obj.(*contrail.Config)) },
+		validate: func(spec *field.Path, obj, old runtime.Object) field.ErrorList {
+			var o *contrail.Config
+			if old != nil {
+				o = old.(*contrail.Config)
+			}
+			return validateConfig(spec, obj.(*contrail.Config), o)
+		},
+		references: func(spec *field.Path, obj runtime.Object) []reference {
+			c := obj.(*contrail.Config).Spec.ServiceConfiguration
+			sc := spec.Child("serviceConfiguration")
+			return referencesTo(
+				reference{sc.Child("cassandraInstance"), "Cassandra", c.CassandraInstance},
+				reference{sc.Child("zookeeperInstance"), "Zookeeper", c.ZookeeperInstance},
+				reference{sc.Child("keystoneInstance"), "Keystone", c.KeystoneInstance},
+			)
+		},
+	},
+	"Control": {
+		object:   func() runtime.Object { return &contrail.Control{} },
+		defaults: func(obj runtime.Object) { defaultControl(obj.(*contrail.Control)) },
+		validate: func(spec *field.Path, obj, old runtime.Object) field.ErrorList {
+			return validateControl(spec, obj.(*contrail.Control))
+		},
+		references: func(spec *field.Path, obj runtime.Object) []reference {
+			c := obj.(*contrail.Control).Spec.ServiceConfiguration
+			sc := spec.Child("serviceConfiguration")
+			return referencesTo(
+				reference{sc.Child("cassandraInstance"), "Cassandra", c.CassandraInstance},
+			)
+		},
+	},
+	"Kubemanager": {
+		object:   func() runtime.Object { return &contrail.Kubemanager{} },
+		defaults: func(obj runtime.Object) { defaultKubemanager(obj.(*contrail.Kubemanager)) },
+		validate: func(spec *field.Path, obj, old runtime.Object) field.ErrorList {
+			return validatePodConfiguration(spec.Child("commonConfiguration"), obj.(*contrail.Kubemanager).Spec.CommonConfiguration)
+		},
+		dependencies: func(spec *field.Path, obj runtime.Object) field.ErrorList {
+			c := obj.(*contrail.Kubemanager).Spec.ServiceConfiguration
+			return requireNodesConfigurations(spec.Child("serviceConfiguration"),
+				nodesConfiguration{"cassandraNodesConfiguration", c.CassandraNodesConfiguration != nil},
+				nodesConfiguration{"zookeeperNodesConfiguration", c.ZookeeperNodesConfiguration != nil},
+				nodesConfiguration{"rabbitmqNodesConfiguration", c.RabbbitmqNodesConfiguration != nil},
+				nodesConfiguration{"configNodesConfiguration", c.ConfigNodesConfiguration != nil},
+				nodesConfiguration{"keystoneNodesConfiguration", c.KeystoneNodesConfiguration != nil},
+			)
+		},
+	},
+	"Vrouter": {
+		object:   func() runtime.Object { return &contrail.Vrouter{} },
+		defaults: func(obj runtime.Object) { defaultVrouter(obj.(*contrail.Vrouter)) },
+		validate: func(spec *field.Path, obj, old runtime.Object) field.ErrorList {
+			return validatePodConfiguration(spec.Child("commonConfiguration"), obj.(*contrail.Vrouter).Spec.CommonConfiguration)
+		},
+		dependencies: func(spec *field.Path, obj runtime.Object) field.ErrorList {
+			c := obj.(*contrail.Vrouter).Spec.ServiceConfiguration
+			return requireNodesConfigurations(spec.Child("serviceConfiguration"),
+				nodesConfiguration{"controlNodesConfiguration", c.ControlNodesConfiguration != nil},
+				nodesConfiguration{"configNodesConfiguration", c.ConfigNodesConfiguration != nil},
+			)
+		},
+	},
+	"Webui": {
+		object: func() runtime.Object { return &contrail.Webui{} },
+		validate: func(spec *field.Path, obj, old runtime.Object) field.ErrorList {
+			return validatePodConfiguration(spec.Child("commonConfiguration"), obj.(*contrail.Webui).Spec.CommonConfiguration)
+		},
+		references: func(spec *field.Path, obj runtime.Object) []reference {
+			c := obj.(*contrail.Webui).Spec.ServiceConfiguration
+			sc := spec.Child("serviceConfiguration")
+			return referencesTo(
+				reference{sc.Child("cassandraInstance"), "Cassandra", c.CassandraInstance},
+				reference{sc.Child("keystoneInstance"), "Keystone", c.KeystoneInstance},
+			)
+		},
+	},
+	"ProvisionManager": {
+		object: func() runtime.Object { return &contrail.ProvisionManager{} },
+		validate: func(spec *field.Path, obj, old runtime.Object) field.ErrorList {
+			return validatePodConfiguration(spec.Child("commonConfiguration"), obj.(*contrail.ProvisionManager).Spec.CommonConfiguration)
+		},
+		references: func(spec *field.Path, obj runtime.Object) []reference {
+			c := obj.(*contrail.ProvisionManager).Spec.ServiceConfiguration
+			sc := spec.Child("serviceConfiguration")
+			return referencesTo(
+				reference{sc.Child("keystoneInstance"), "Keystone", c.KeystoneInstance},
+			)
+		},
+	},
+	"ContrailCNI": {
+		object: func() runtime.Object { return &contrail.ContrailCNI{} },
+		validate: func(spec *field.Path, obj, old runtime.Object) field.ErrorList {
+			return nil
+		},
+		references: func(spec *field.Path, obj runtime.Object) []reference {
+			c := obj.(*contrail.ContrailCNI).Spec.ServiceConfiguration
+			sc := spec.Child("serviceConfiguration")
+			return referencesTo(
+				reference{sc.Child("controlInstance"), "Control", c.ControlInstance},
+			)
+		},
+	},
+	"Contrailmonitor": {
+		object: func() runtime.Object { return &contrail.Contrailmonitor{} },
+		validate: func(spec *field.Path, obj, old runtime.Object) field.ErrorList {
+			return nil
+		},
+		references: func(spec *field.Path, obj runtime.Object) []reference {
+			c := obj.(*contrail.Contrailmonitor).Spec.ServiceConfiguration
+			sc := spec.Child("serviceConfiguration")
+			return referencesTo(
+				reference{sc.Child("memcachedInstance"), "Memcached", c.MemcachedInstance},
+				reference{sc.Child("postgresInstance"), "Postgres", c.PostgresInstance},
+				reference{sc.Child("cassandraInstance"), "Cassandra", c.CassandraInstance},
+				reference{sc.Child("keystoneInstance"), "Keystone", c.KeystoneInstance},
+				reference{sc.Child("configInstance"), "Config", c.ConfigInstance},
+				reference{sc.Child("zookeeperInstance"), "Zookeeper", c.ZookeeperInstance},
+				reference{sc.Child("rabbitmqInstance"), "Rabbitmq", c.RabbitmqInstance},
+				reference{sc.Child("provisionmanagerInstance"), "ProvisionManager", c.ProvisionmanagerInstance},
+				reference{sc.Child("commandInstance"), "Command", c.CommandInstance},
+				reference{sc.Child("controlInstance"), "Control", c.ControlInstance},
+				reference{sc.Child("webuiInstance"), "Webui", c.WebuiInstance},
+			)
+		},
+	},
+	"Command": {
+		object: func() runtime.Object { return &contrail.Command{} },
+		validate: func(spec *field.Path, obj, old runtime.Object) field.ErrorList {
+			return validatePodConfiguration(spec.Child("commonConfiguration"), obj.(*contrail.Command).Spec.CommonConfiguration)
+		},
+		references: func(spec *field.Path, obj runtime.Object) []reference {
+			c := obj.(*contrail.Command).Spec.ServiceConfiguration
+			sc := spec.Child("serviceConfiguration")
+			return referencesTo(
+				reference{sc.Child("postgresInstance"), "Postgres", c.PostgresInstance},
+				reference{sc.Child("swiftInstance"), "Swift", c.SwiftInstance},
+				reference{sc.Child("keystoneInstance"), "Keystone", c.KeystoneInstance},
+				reference{sc.Child("configInstance"), "Config", c.ConfigInstance},
+				reference{sc.Child("webuiInstance"), "Webui", c.WebUIInstance},
+			)
+		},
+	},
+	"Postgres": {
+		object: func() runtime.Object { return &contrail.Postgres{} },
+		validate: func(spec *field.Path, obj, old runtime.Object) field.ErrorList {
+			var o *contrail.Postgres
+			if old != nil {
+				o = old.(*contrail.Postgres)
+			}
+			return validatePostgres(spec, obj.(*contrail.Postgres), o)
+		},
+	},
+	"Keystone": {
+		object:   func() runtime.Object { return &contrail.Keystone{} },
+		defaults: func(obj runtime.Object) { obj.(*contrail.Keystone).SetDefaultValues() },
+		validate: func(spec *field.Path, obj, old runtime.Object) field.ErrorList {
+			return validateKeystone(spec, obj.(*contrail.Keystone))
+		},
+		references: func(spec *field.Path, obj runtime.Object) []reference {
+			c := obj.(*contrail.Keystone).Spec.ServiceConfiguration
+			sc := spec.Child("serviceConfiguration")
+			return referencesTo(
+				reference{sc.Child("memcachedInstance"), "Memcached", c.MemcachedInstance},
+				reference{sc.Child("postgresInstance"), "Postgres", c.PostgresInstance},
+			)
+		},
+	},
+	"Memcached": {
+		object: func() runtime.Object { return &contrail.Memcached{} },
+		validate: func(spec *field.Path, obj, old runtime.Object) field.ErrorList {
+			return validatePodConfiguration(spec.Child("commonConfiguration"), obj.(*contrail.Memcached).Spec.CommonConfiguration)
+		},
+	},
+	"Swift": {
+		object:   func() runtime.Object { return &contrail.Swift{} },
+		defaults: func(obj runtime.Object) { obj.(*contrail.Swift).SetDefaultValues() },
+		validate: func(spec *field.Path, obj, old runtime.Object) field.ErrorList {
+			var o *contrail.Swift
+			if old != nil {
+				o = old.(*contrail.Swift)
+			}
+			return validateSwift(spec, obj.(*contrail.Swift), o)
+		},
+		references: func(spec *field.Path, obj runtime.Object) []reference {
+			c := obj.(*contrail.Swift).Spec.ServiceConfiguration.SwiftProxyConfiguration
+			sc := spec.Child("serviceConfiguration", "swiftProxyConfiguration")
+			return referencesTo(
+				reference{sc.Child("memcachedInstance"), "Memcached", c.MemcachedInstance},
+				reference{sc.Child("keystoneInstance"), "Keystone", c.KeystoneInstance},
+			)
+		},
+	},
+	"SwiftProxy": {
+		object: func() runtime.Object { return &contrail.SwiftProxy{} },
+		validate: func(spec *field.Path, obj, old runtime.Object) field.ErrorList {
+			return validatePodConfiguration(spec.Child("commonConfiguration"), obj.(*contrail.SwiftProxy).Spec.CommonConfiguration)
+		},
+		references: func(spec *field.Path, obj runtime.Object) []reference {
+			c := obj.(*contrail.SwiftProxy).Spec.ServiceConfiguration
+			sc := spec.Child("serviceConfiguration")
+			return referencesTo(
+				reference{sc.Child("memcachedInstance"), "Memcached", c.MemcachedInstance},
+				reference{sc.Child("keystoneInstance"), "Keystone", c.KeystoneInstance},
+			)
+		},
+	},
+	"CassandraBackup": {
+		object: func() runtime.Object { return &contrail.CassandraBackup{} },
+		validate: func(spec *field.Path, obj, old runtime.Object) field.ErrorList {
+			var o *contrail.CassandraBackup
+			if old != nil {
+				o = old.(*contrail.CassandraBackup)
+			}
+			return validateCassandraBackup(spec, obj.(*contrail.CassandraBackup), o)
+		},
+		references: func(spec *field.Path, obj runtime.Object) []reference {
+			b := obj.(*contrail.CassandraBackup).Spec
+			refs := []reference{{spec.Child("cassandraInstance"), "Cassandra", b.CassandraInstance}}
+			if b.Target.Swift != nil {
+				refs = append(refs, reference{spec.Child("target", "swift", "swiftInstance"), "Swift", b.Target.Swift.SwiftInstance})
+			}
+			return referencesTo(refs...)
+		},
+	},
+	"CassandraRestore": {
+		object: func() runtime.Object { return &contrail.CassandraRestore{} },
+		validate: func(spec *field.Path, obj, old runtime.Object) field.ErrorList {
+			var o *contrail.CassandraRestore
+			if old != nil {
+				o = old.(*contrail.CassandraRestore)
+			}
+			return validateCassandraRestore(spec, obj.(*contrail.CassandraRestore), o)
+		},
+		references: func(spec *field.Path, obj runtime.Object) []reference {
+			r := obj.(*contrail.CassandraRestore).Spec
+			return referencesTo(
+				reference{spec.Child("cassandraInstance"), "Cassandra", r.CassandraInstance},
+				reference{spec.Child("backupName"), "CassandraBackup", r.BackupName},
+			)
+		},
+	},
+	"SwiftContainer": {
+		object: func() runtime.Object { return &contrail.SwiftContainer{} },
+		validate: func(spec *field.Path, obj, old runtime.Object) field.ErrorList {
+			var o *contrail.SwiftContainer
+			if old != nil {
+				o = old.(*contrail.SwiftContainer)
+			}
+			return validateSwiftContainer(spec, obj.(*contrail.SwiftContainer), o)
+		},
+		references: func(spec *field.Path, obj runtime.Object) []reference {
+			return referencesTo(reference{spec.Child("swiftInstance"), "Swift", obj.(*contrail.SwiftContainer).Spec.SwiftInstance})
+		},
+	},
+	"SwiftStorage": {
+		object: func() runtime.Object { return &contrail.SwiftStorage{} },
+		validate: func(spec *field.Path, obj, old runtime.Object) field.ErrorList {
+			var o *contrail.SwiftStorage
+			if old != nil {
+				o = old.(*contrail.SwiftStorage)
+			}
+			return validateSwiftStorage(spec, obj.(*contrail.SwiftStorage), o)
+		},
+	},
+}
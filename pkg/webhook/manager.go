@@ -0,0 +1,172 @@
+package webhook
+
+import (
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	contrail "github.com/Juniper/contrail-operator/pkg/apis/contrail/v1alpha1"
+)
+
+// Manager is registered in init, since its validation
+// refers to the validation of all other kinds.
+func init() {
+	kinds["Manager"] = kind{
+		object: func() runtime.Object { return &contrail.Manager{} },
+		validate: func(spec *field.Path, obj, old runtime.Object) field.ErrorList {
+			var o *contrail.Manager
+			if old != nil {
+				o = old.(*contrail.Manager)
+			}
+			return validateManager(spec, obj.(*contrail.Manager), o)
+		},
+		references: func(spec *field.Path, obj runtime.Object) []reference {
+			return managerReferences(spec, obj.(*contrail.Manager))
+		},
+	}
+}
+
+// managedService is a service from the Manager spec converted
+// to the resource which the Manager creates for it.
+type managedService struct {
+	path *field.Path
+	name string
+	kind string
+	obj  runtime.Object
+	// references which exist only in the Manager spec
+	references []reference
+}
+
+func managedServices(services *field.Path, s contrail.Services) []managedService {
+	var all []managedService
+	add := func(path *field.Path, kind string, meta contrail.ObjectMeta, obj runtime.Object, refs ...reference) {
+		all = append(all, managedService{path: path, name: meta.Name, kind: kind, obj: obj, references: referencesTo(refs...)})
+	}
+	for i, c := range s.Cassandras {
+		add(services.Child("cassandras").Index(i), "Cassandra", c.ObjectMeta, &contrail.Cassandra{ObjectMeta: c.ObjectMeta.ToMeta(), Spec: c.Spec})
+	}
+	for i, z := range s.Zookeepers {
+		add(services.Child("zookeepers").Index(i), "Zookeeper", z.ObjectMeta, &contrail.Zookeeper{ObjectMeta: z.ObjectMeta.ToMeta(), Spec: z.Spec})
+	}
+	for i, c := range s.Controls {
+		add(services.Child("controls").Index(i), "Control", c.ObjectMeta, &contrail.Control{ObjectMeta: c.ObjectMeta.ToMeta(), Spec: c.Spec})
+	}
+	for i, k := range s.Kubemanagers {
+		path := services.Child("kubemanagers").Index(i)
+		sc := path.Child("spec", "serviceConfiguration")
+		kubemanager := &contrail.Kubemanager{ObjectMeta: k.ObjectMeta.ToMeta()}
+		kubemanager.Spec.CommonConfiguration = k.Spec.CommonConfiguration
+		kubemanager.Spec.ServiceConfiguration.KubemanagerConfiguration = k.Spec.ServiceConfiguration.KubemanagerConfiguration
+		add(path, "Kubemanager", k.ObjectMeta, kubemanager,
+			reference{sc.Child("cassandraInstance"), "Cassandra", k.Spec.ServiceConfiguration.CassandraInstance},
+			reference{sc.Child("zookeeperInstance"), "Zookeeper", k.Spec.ServiceConfiguration.ZookeeperInstance},
+			reference{sc.Child("keystoneInstance"), "Keystone", k.Spec.ServiceConfiguration.KeystoneInstance},
+		)
+	}
+	for i, v := range s.Vrouters {
+		path := services.Child("vrouters").Index(i)
+		vrouter := &contrail.Vrouter{ObjectMeta: v.ObjectMeta.ToMeta()}
+		vrouter.Spec.CommonConfiguration = v.Spec.CommonConfiguration
+		vrouter.Spec.ServiceConfiguration.VrouterConfiguration = v.Spec.ServiceConfiguration.VrouterConfiguration
+		add(path, "Vrouter", v.ObjectMeta, vrouter,
+			reference{path.Child("spec", "serviceConfiguration", "controlInstance"), "Control", v.Spec.ServiceConfiguration.ControlInstance},
+		)
+	}
+	for i, c := range s.ContrailCNIs {
+		add(services.Child("contrailCNIs").Index(i), "ContrailCNI", c.ObjectMeta, &contrail.ContrailCNI{ObjectMeta: c.ObjectMeta.ToMeta(), Spec: c.Spec})
+	}
+	if c := s.Config; c != nil {
+		add(services.Child("config"), "Config", c.ObjectMeta, &contrail.Config{ObjectMeta: c.ObjectMeta.ToMeta(), Spec: c.Spec})
+	}
+	if w := s.Webui; w != nil {
+		add(services.Child("webui"), "Webui", w.ObjectMeta, &contrail.Webui{ObjectMeta: w.ObjectMeta.ToMeta(), Spec: w.Spec})
+	}
+	if r := s.Rabbitmq; r != nil {
+		add(services.Child("rabbitmq"), "Rabbitmq", r.ObjectMeta, &contrail.Rabbitmq{ObjectMeta: r.ObjectMeta.ToMeta(), Spec: r.Spec})
+	}
+	if p := s.ProvisionManager; p != nil {
+		provisionManager := &contrail.ProvisionManager{ObjectMeta: p.ObjectMeta.ToMeta()}
+		provisionManager.Spec.CommonConfiguration = p.Spec.CommonConfiguration
+		provisionManager.Spec.ServiceConfiguration.ProvisionManagerConfiguration = p.Spec.ServiceConfiguration
+		add(services.Child("provisionManager"), "ProvisionManager", p.ObjectMeta, provisionManager)
+	}
+	if c := s.Command; c != nil {
+		add(services.Child("command"), "Command", c.ObjectMeta, &contrail.Command{ObjectMeta: c.ObjectMeta.ToMeta(), Spec: c.Spec})
+	}
+	if p := s.Postgres; p != nil {
+		add(services.Child("postgres"), "Postgres", p.ObjectMeta, &contrail.Postgres{ObjectMeta: p.ObjectMeta.ToMeta(), Spec: p.Spec})
+	}
+	if k := s.Keystone; k != nil {
+		add(services.Child("keystone"), "Keystone", k.ObjectMeta, &contrail.Keystone{ObjectMeta: k.ObjectMeta.ToMeta(), Spec: k.Spec})
+	}
+	if sw := s.Swift; sw != nil {
+		add(services.Child("swift"), "Swift", sw.ObjectMeta, &contrail.Swift{ObjectMeta: sw.ObjectMeta.ToMeta(), Spec: sw.Spec})
+	}
+	if m := s.Memcached; m != nil {
+		add(services.Child("memcached"), "Memcached", m.ObjectMeta, &contrail.Memcached{ObjectMeta: m.ObjectMeta.ToMeta(), Spec: m.Spec})
+	}
+	if c := s.Contrailmonitor; c != nil {
+		add(services.Child("contrailmonitor"), "Contrailmonitor", c.ObjectMeta, &contrail.Contrailmonitor{ObjectMeta: c.ObjectMeta.ToMeta(), Spec: c.Spec})
+	}
+	return all
+}
+
+// validateManager validates every service as if it was created on its own
+// and checks that service names are unique within a kind.
+func validateManager(spec *field.Path, m, old *contrail.Manager) field.ErrorList {
+	errs := validateCertificateKey(spec.Child("commonConfiguration", "certificateKey"), m.Spec.CommonConfiguration.CertificateKey)
+	previous := map[string]map[string]managedService{}
+	if old != nil {
+		for _, s := range managedServices(spec.Child("services"), old.Spec.Services) {
+			if previous[s.kind] == nil {
+				previous[s.kind] = map[string]managedService{}
+			}
+			previous[s.kind][s.name] = s
+		}
+	}
+	names := map[string]map[string]bool{}
+	for _, s := range managedServices(spec.Child("services"), m.Spec.Services) {
+		namePath := s.path.Child("metadata", "name")
+		if s.name == "" {
+			errs = append(errs, field.Required(namePath, ""))
+		} else if names[s.kind][s.name] {
+			errs = append(errs, field.Duplicate(namePath, s.name))
+		}
+		if names[s.kind] == nil {
+			names[s.kind] = map[string]bool{}
+		}
+		names[s.kind][s.name] = true
+
+		var oldObj runtime.Object
+		if p, ok := previous[s.kind][s.name]; ok && s.name != "" {
+			oldObj = p.obj
+		}
+		errs = append(errs, kinds[s.kind].validate(s.path.Child("spec"), s.obj, oldObj)...)
+	}
+	return errs
+}
+
+// managerReferences returns references of the Manager services which
+// do not point to other services defined in the same Manager.
+func managerReferences(spec *field.Path, m *contrail.Manager) []reference {
+	services := managedServices(spec.Child("services"), m.Spec.Services)
+	names := map[string]map[string]bool{}
+	for _, s := range services {
+		if names[s.kind] == nil {
+			names[s.kind] = map[string]bool{}
+		}
+		names[s.kind][s.name] = true
+	}
+	var external []reference
+	for _, s := range services {
+		refs := s.references
+		if k := kinds[s.kind]; k.references != nil {
+			refs = append(refs, k.references(s.path.Child("spec"), s.obj)...)
+		}
+		for _, ref := range refs {
+			if !names[ref.kind][ref.name] {
+				external = append(external, ref)
+			}
+		}
+	}
+	return external
+}
@@ -0,0 +1,482 @@
+package webhook
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	apivalidation "k8s.io/apimachinery/pkg/api/validation"
+	utilvalidation "k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	contrail "github.com/Juniper/contrail-operator/pkg/apis/contrail/v1alpha1"
+	"github.com/Juniper/contrail-operator/pkg/controller/utils"
+)
+
+func validateCassandra(spec *field.Path, c, old *contrail.Cassandra) field.ErrorList {
+	sc := spec.Child("serviceConfiguration")
+	errs := validatePodConfiguration(spec.Child("commonConfiguration"), c.Spec.CommonConfiguration)
+	errs = append(errs, validateStorage(sc.Child("storage"), c.Spec.ServiceConfiguration.Storage)...)
+	config := c.ConfigurationParameters()
+	errs = append(errs, validatePorts(sc,
+		port{"port", config.Port},
+		port{"cqlPort", config.CqlPort},
+		port{"jmxLocalPort", config.JmxLocalPort},
+		port{"storagePort", config.StoragePort},
+		port{"sslStoragePort", config.SslStoragePort},
+	)...)
+	if old != nil {
+		if oldClusterName := old.Spec.ServiceConfiguration.ClusterName; oldClusterName != "" {
+			errs = append(errs, apivalidation.ValidateImmutableField(c.Spec.ServiceConfiguration.ClusterName, oldClusterName, sc.Child("clusterName"))...)
+		}
+		oldConfig := old.ConfigurationParameters()
+		errs = append(errs, apivalidation.ValidateImmutableField(config.Storage.Path, oldConfig.Storage.Path, sc.Child("storage", "path"))...)
+	}
+	return errs
+}
+
+func validateZookeeper(spec *field.Path, z, old *contrail.Zookeeper) field.ErrorList {
+	sc := spec.Child("serviceConfiguration")
+	errs := validatePodConfiguration(spec.Child("commonConfiguration"), z.Spec.CommonConfiguration)
+	errs = append(errs, validateStorage(sc.Child("storage"), z.Spec.ServiceConfiguration.Storage)...)
+	config := z.ConfigurationParameters()
+	errs = append(errs, validatePorts(sc,
+		port{"clientPort", config.ClientPort},
+		port{"electionPort", config.ElectionPort},
+		port{"serverPort", config.ServerPort},
+		port{"adminPort", config.AdminPort},
+	)...)
+	if old != nil {
+		oldConfig := old.ConfigurationParameters()
+		errs = append(errs, apivalidation.ValidateImmutableField(config.Storage.Path, oldConfig.Storage.Path, sc.Child("storage", "path"))...)
+	}
+	return errs
+}
+
+func validateRabbitmq(spec *field.Path, r *contrail.Rabbitmq) field.ErrorList {
+	sc := spec.Child("serviceConfiguration")
+	errs := validatePodConfiguration(spec.Child("commonConfiguration"), r.Spec.CommonConfiguration)
+	config := r.ConfigurationParameters()
+	return append(errs, validatePorts(sc,
+		port{"port", config.Port},
+		port{"sslPort", config.SSLPort},
+	)...)
+}
+
+func validateConfig(spec *field.Path, c, old *contrail.Config) field.ErrorList {
+	sc := spec.Child("serviceConfiguration")
+	errs := validatePodConfiguration(spec.Child("commonConfiguration"), c.Spec.CommonConfiguration)
+	errs = append(errs, validateStorage(sc.Child("storage"), c.Spec.ServiceConfiguration.Storage)...)
+	config := c.ConfigurationParameters()
+	errs = append(errs, validatePorts(sc,
+		port{"apiPort", config.APIPort},
+		port{"analyticsPort", config.AnalyticsPort},
+		port{"collectorPort", config.CollectorPort},
+		port{"redisPort", config.RedisPort},
+		port{"apiIntrospectPort", config.ApiIntrospectPort},
+		port{"schemaIntrospectPort", config.SchemaIntrospectPort},
+		port{"deviceManagerIntrospectPort", config.DeviceManagerIntrospectPort},
+		port{"svcMonitorIntrospectPort", config.SvcMonitorIntrospectPort},
+		port{"analyticsMonitorIntrospectPort", config.AnalyticsApiIntrospectPort},
+		port{"collectorMonitorIntrospectPort", config.CollectorIntrospectPort},
+	)...)
+	if old != nil {
+		errs = append(errs, validateStoragePathUnchanged(sc.Child("storage", "path"), c.Spec.ServiceConfiguration.Storage, old.Spec.ServiceConfiguration.Storage)...)
+	}
+	return errs
+}
+
+func validateControl(spec *field.Path, c *contrail.Control) field.ErrorList {
+	sc := spec.Child("serviceConfiguration")
+	errs := validatePodConfiguration(spec.Child("commonConfiguration"), c.Spec.CommonConfiguration)
+	config := c.ConfigurationParameters()
+	if asn := int64(*config.ASNNumber); asn < 1 || asn > 4294967295 {
+		errs = append(errs, field.Invalid(sc.Child("asnNumber"), asn, "must be between 1 and 4294967295, inclusive"))
+	}
+	return append(errs, validatePorts(sc,
+		port{"bgpPort", config.BGPPort},
+		port{"xmppPort", config.XMPPPort},
+		port{"dnsPort", config.DNSPort},
+		port{"dnsIntrospectPort", config.DNSIntrospectPort},
+	)...)
+}
+
+func validatePostgres(spec *field.Path, p, old *contrail.Postgres) field.ErrorList {
+	sc := spec.Child("serviceConfiguration")
+	errs := validatePodConfiguration(spec.Child("commonConfiguration"), p.Spec.CommonConfiguration)
+	errs = append(errs, validateStorage(sc.Child("storage"), p.Spec.ServiceConfiguration.Storage)...)
+	if backup := p.Spec.ServiceConfiguration.Backup; backup != nil {
+		errs = append(errs, validatePostgresBackup(sc.Child("backup"), backup)...)
+	}
+	if restore := p.Spec.ServiceConfiguration.Restore; restore != nil && restore.BackupClaimName == "" {
+		errs = append(errs, field.Required(sc.Child("restore", "backupClaimName"), ""))
+	}
+	if old != nil {
+		errs = append(errs, validateStoragePathUnchanged(sc.Child("storage", "path"), p.Spec.ServiceConfiguration.Storage, old.Spec.ServiceConfiguration.Storage)...)
+	}
+	return errs
+}
+
+func validatePostgresBackup(path *field.Path, b *contrail.PostgresBackup) field.ErrorList {
+	errs := validateStorage(path.Child("storage"), b.Storage)
+	errs = append(errs, validateSchedule(path.Child("dumpSchedule"), b.DumpSchedule)...)
+	errs = append(errs, validateSchedule(path.Child("baseBackupSchedule"), b.BaseBackupSchedule)...)
+	retention := path.Child("retention")
+	if b.DumpsToRetain() < 1 {
+		errs = append(errs, field.Invalid(retention.Child("dumps"), b.DumpsToRetain(), "must be at least 1"))
+	}
+	if b.BaseBackupsToRetain() < 1 {
+		errs = append(errs, field.Invalid(retention.Child("baseBackups"), b.BaseBackupsToRetain(), "must be at least 1"))
+	}
+	if b.Retention.MaxAgeDays < 0 {
+		errs = append(errs, field.Invalid(retention.Child("maxAgeDays"), b.Retention.MaxAgeDays, "must not be negative"))
+	}
+	return errs
+}
+
+// validateSchedule checks that the schedule has the five fields of cron or is one
+// of its predefined schedules. Values of fields are validated by Kubernetes.
+func validateSchedule(path *field.Path, schedule string) field.ErrorList {
+	if schedule == "" {
+		return nil
+	}
+	if strings.HasPrefix(schedule, "@") || len(strings.Fields(schedule)) == 5 {
+		return nil
+	}
+	return field.ErrorList{field.Invalid(path, schedule, "must be a cron schedule with five fields")}
+}
+
+func validateKeystone(spec *field.Path, k *contrail.Keystone) field.ErrorList {
+	sc := spec.Child("serviceConfiguration")
+	errs := validatePodConfiguration(spec.Child("commonConfiguration"), k.Spec.CommonConfiguration)
+	domains := k.Spec.ServiceConfiguration.LDAPDomains
+	if len(domains) != 0 && k.Spec.ServiceConfiguration.ExternalAddress != "" {
+		errs = append(errs, field.Forbidden(sc.Child("ldapDomains"), "may not be set together with externalAddress"))
+	}
+	names := map[string]bool{}
+	for i, d := range domains {
+		path := sc.Child("ldapDomains").Index(i)
+		errs = append(errs, validateKeystoneLDAPDomain(path, d, k.ConfigurationParameters().UserDomainName)...)
+		if names[strings.ToLower(d.Name)] {
+			errs = append(errs, field.Duplicate(path.Child("name"), d.Name))
+		}
+		names[strings.ToLower(d.Name)] = true
+	}
+	return errs
+}
+
+// validateKeystoneLDAPDomain checks the connection settings and directory trees of the domain.
+// The domain of service users cannot be backed by LDAP, as the operator creates users there.
+func validateKeystoneLDAPDomain(path *field.Path, d contrail.KeystoneLDAPDomain, userDomainName string) field.ErrorList {
+	var errs field.ErrorList
+	switch {
+	case d.Name == "":
+		errs = append(errs, field.Required(path.Child("name"), ""))
+	case strings.EqualFold(d.Name, userDomainName):
+		errs = append(errs, field.Invalid(path.Child("name"), d.Name, "must be different from the domain of service users"))
+	default:
+		for _, msg := range utilvalidation.IsConfigMapKey("keystone." + d.Name + ".conf") {
+			errs = append(errs, field.Invalid(path.Child("name"), d.Name, msg))
+		}
+	}
+	u, err := url.Parse(d.URL)
+	if err != nil || (u.Scheme != "ldap" && u.Scheme != "ldaps") || u.Host == "" {
+		errs = append(errs, field.Invalid(path.Child("url"), d.URL, "must be an ldap:// or ldaps:// URL with host"))
+	} else if u.Scheme == "ldaps" && d.UseStartTLS {
+		errs = append(errs, field.Forbidden(path.Child("useStartTLS"), "may not be used with ldaps:// URL"))
+	}
+	if d.BindSecretName == "" {
+		errs = append(errs, field.Required(path.Child("bindSecretName"), ""))
+	}
+	if d.Users.TreeDN == "" {
+		errs = append(errs, field.Required(path.Child("users", "treeDN"), ""))
+	}
+	if d.Groups != nil && d.Groups.TreeDN == "" {
+		errs = append(errs, field.Required(path.Child("groups", "treeDN"), ""))
+	}
+	if d.Groups == nil && len(d.GroupRoleMappings) != 0 {
+		errs = append(errs, field.Forbidden(path.Child("groupRoleMappings"), "groups must be configured to map them to roles"))
+	}
+	for i, m := range d.GroupRoleMappings {
+		mappingPath := path.Child("groupRoleMappings").Index(i)
+		if m.Group == "" {
+			errs = append(errs, field.Required(mappingPath.Child("group"), ""))
+		}
+		if m.Role == "" {
+			errs = append(errs, field.Required(mappingPath.Child("role"), ""))
+		}
+		if m.Project == "" {
+			errs = append(errs, field.Required(mappingPath.Child("project"), ""))
+		}
+	}
+	return errs
+}
+
+func validateSwift(spec *field.Path, s, old *contrail.Swift) field.ErrorList {
+	sc := spec.Child("serviceConfiguration")
+	errs := validatePodConfiguration(spec.Child("commonConfiguration"), s.Spec.CommonConfiguration)
+	errs = append(errs, validateStorage(sc.Child("ringsStorage"), s.Spec.ServiceConfiguration.RingsStorage)...)
+	storageConfig := s.Spec.ServiceConfiguration.SwiftStorageConfiguration
+	errs = append(errs, validateSwiftStorageConfiguration(sc.Child("swiftStorageConfiguration"), storageConfig)...)
+	if old != nil {
+		oldDevices := old.Spec.ServiceConfiguration.SwiftStorageConfiguration.Devices
+		errs = append(errs, validateSwiftDevicesUnchanged(sc.Child("swiftStorageConfiguration", "devices"), storageConfig.Devices, oldDevices)...)
+	}
+	return errs
+}
+
+func validateSwiftStorage(spec *field.Path, s, old *contrail.SwiftStorage) field.ErrorList {
+	sc := spec.Child("serviceConfiguration")
+	errs := validatePodConfiguration(spec.Child("commonConfiguration"), s.Spec.CommonConfiguration)
+	errs = append(errs, validateSwiftStorageConfiguration(sc, s.Spec.ServiceConfiguration)...)
+	if old != nil {
+		errs = append(errs, validateStoragePathUnchanged(sc.Child("storage", "path"), s.Spec.ServiceConfiguration.Storage, old.Spec.ServiceConfiguration.Storage)...)
+		errs = append(errs, validateSwiftDevicesUnchanged(sc.Child("devices"), s.Spec.ServiceConfiguration.Devices, old.Spec.ServiceConfiguration.Devices)...)
+	}
+	return errs
+}
+
+func validateSwiftStorageConfiguration(path *field.Path, s contrail.SwiftStorageConfiguration) field.ErrorList {
+	errs := validateStorage(path.Child("storage"), s.Storage)
+	errs = append(errs, validateSwiftDevices(path.Child("devices"), s.Devices)...)
+	return append(errs, validatePorts(path,
+		optionalPort("accountBindPort", s.AccountBindPort),
+		optionalPort("containerBindPort", s.ContainerBindPort),
+		optionalPort("objectBindPort", s.ObjectBindPort),
+	)...)
+}
+
+// validateSwiftDevices checks that names of devices are unique and that every
+// ring has a device.
+func validateSwiftDevices(path *field.Path, devices []contrail.SwiftDeviceConfiguration) field.ErrorList {
+	if len(devices) == 0 {
+		return nil
+	}
+	var errs field.ErrorList
+	names := map[string]bool{}
+	inRing := map[contrail.SwiftRing]bool{}
+	for i, d := range devices {
+		if names[d.Name] {
+			errs = append(errs, field.Duplicate(path.Index(i).Child("name"), d.Name))
+		}
+		names[d.Name] = true
+		rings := d.Rings
+		if len(rings) == 0 {
+			rings = []contrail.SwiftRing{contrail.SwiftAccountRing, contrail.SwiftContainerRing, contrail.SwiftObjectRing}
+		}
+		for _, ring := range rings {
+			inRing[ring] = true
+		}
+	}
+	for _, ring := range []contrail.SwiftRing{contrail.SwiftAccountRing, contrail.SwiftContainerRing, contrail.SwiftObjectRing} {
+		if !inRing[ring] {
+			errs = append(errs, field.Required(path, fmt.Sprintf("a device placed in the %s ring is required", ring)))
+		}
+	}
+	return errs
+}
+
+// validateSwiftDevicesUnchanged forbids changes of devices, including the move
+// from the single device to devices, since claim templates of the storage stateful
+// set cannot be changed. Rings and weights of devices may be changed.
+func validateSwiftDevicesUnchanged(path *field.Path, devices, old []contrail.SwiftDeviceConfiguration) field.ErrorList {
+	claims := func(devices []contrail.SwiftDeviceConfiguration) []contrail.SwiftDeviceConfiguration {
+		var claims []contrail.SwiftDeviceConfiguration
+		for _, d := range devices {
+			d.Rings = nil
+			d.Weight = 0
+			claims = append(claims, d)
+		}
+		return claims
+	}
+	return apivalidation.ValidateImmutableField(claims(devices), claims(old), path)
+}
+
+// validateCassandraBackup checks the target of the backup. The spec
+// cannot be changed once the backup is created.
+func validateCassandraBackup(spec *field.Path, b, old *contrail.CassandraBackup) field.ErrorList {
+	var errs field.ErrorList
+	if b.Spec.CassandraInstance == "" {
+		errs = append(errs, field.Required(spec.Child("cassandraInstance"), ""))
+	}
+	errs = append(errs, validateBackupTarget(spec.Child("target"), b.Spec.Target)...)
+	if old != nil {
+		errs = append(errs, apivalidation.ValidateImmutableField(b.Spec, old.Spec, spec)...)
+	}
+	return errs
+}
+
+// validateCassandraRestore checks that the restore points either to
+// a CassandraBackup or to a target and path of a backup.
+func validateCassandraRestore(spec *field.Path, r, old *contrail.CassandraRestore) field.ErrorList {
+	var errs field.ErrorList
+	if r.Spec.CassandraInstance == "" {
+		errs = append(errs, field.Required(spec.Child("cassandraInstance"), ""))
+	}
+	switch {
+	case r.Spec.BackupName != "" && (r.Spec.Target != nil || r.Spec.Path != ""):
+		errs = append(errs, field.Forbidden(spec.Child("backupName"), "may not be set together with target and path"))
+	case r.Spec.BackupName == "" && (r.Spec.Target == nil || r.Spec.Path == ""):
+		errs = append(errs, field.Required(spec.Child("backupName"), "either backupName or target and path are required"))
+	case r.Spec.Target != nil:
+		errs = append(errs, validateBackupTarget(spec.Child("target"), *r.Spec.Target)...)
+	}
+	if old != nil {
+		errs = append(errs, apivalidation.ValidateImmutableField(r.Spec, old.Spec, spec)...)
+	}
+	return errs
+}
+
+func validateBackupTarget(path *field.Path, t contrail.BackupTarget) field.ErrorList {
+	var errs field.ErrorList
+	switch {
+	case t.Swift != nil && t.S3 != nil:
+		errs = append(errs, field.Forbidden(path, "only one of swift and s3 may be set"))
+	case t.Swift != nil:
+		if t.Swift.SwiftInstance == "" {
+			errs = append(errs, field.Required(path.Child("swift", "swiftInstance"), ""))
+		}
+		if t.Swift.Container == "" {
+			errs = append(errs, field.Required(path.Child("swift", "container"), ""))
+		}
+	case t.S3 != nil:
+		if u, err := url.Parse(t.S3.Endpoint); err != nil || u.Scheme == "" || u.Host == "" {
+			errs = append(errs, field.Invalid(path.Child("s3", "endpoint"), t.S3.Endpoint, "must be a URL with scheme and host"))
+		}
+		if t.S3.Bucket == "" {
+			errs = append(errs, field.Required(path.Child("s3", "bucket"), ""))
+		}
+		if t.S3.CredentialsSecretName == "" {
+			errs = append(errs, field.Required(path.Child("s3", "credentialsSecretName"), ""))
+		}
+	default:
+		errs = append(errs, field.Required(path, "one of swift and s3 is required"))
+	}
+	return errs
+}
+
+// validateSwiftContainer checks names, quotas and metadata of the container.
+// The Swift and the name of the container cannot be changed, as the controller
+// would leave the old container behind.
+func validateSwiftContainer(spec *field.Path, c, old *contrail.SwiftContainer) field.ErrorList {
+	var errs field.ErrorList
+	if c.Spec.SwiftInstance == "" {
+		errs = append(errs, field.Required(spec.Child("swiftInstance"), ""))
+	}
+	errs = append(errs, validateSwiftContainerName(spec.Child("containerName"), c.ContainerName())...)
+	if q := c.Spec.Quota; q != nil {
+		if q.Bytes != nil {
+			errs = append(errs, apivalidation.ValidateNonnegativeField(*q.Bytes, spec.Child("quota", "bytes"))...)
+		}
+		if q.Count != nil {
+			errs = append(errs, apivalidation.ValidateNonnegativeField(*q.Count, spec.Child("quota", "count"))...)
+		}
+	}
+	if v := c.Spec.Versioning; v != nil {
+		location := spec.Child("versioning", "location")
+		if v.Location == "" {
+			errs = append(errs, field.Required(location, ""))
+		} else if v.Location == c.ContainerName() {
+			errs = append(errs, field.Invalid(location, v.Location, "must differ from the name of the container"))
+		} else {
+			errs = append(errs, validateSwiftContainerName(location, v.Location)...)
+		}
+	}
+	for key := range c.Spec.Metadata {
+		path := spec.Child("metadata").Key(key)
+		for _, msg := range utilvalidation.IsHTTPHeaderName(key) {
+			errs = append(errs, field.Invalid(path, key, msg))
+		}
+		if strings.EqualFold(key, "Quota-Bytes") || strings.EqualFold(key, "Quota-Count") {
+			errs = append(errs, field.Forbidden(path, "quotas are set with spec.quota"))
+		}
+	}
+	if old != nil {
+		errs = append(errs, apivalidation.ValidateImmutableField(c.Spec.SwiftInstance, old.Spec.SwiftInstance, spec.Child("swiftInstance"))...)
+		errs = append(errs, apivalidation.ValidateImmutableField(c.ContainerName(), old.ContainerName(), spec.Child("containerName"))...)
+	}
+	return errs
+}
+
+func validateSwiftContainerName(path *field.Path, name string) field.ErrorList {
+	if strings.Contains(name, "/") || len(name) > 256 {
+		return field.ErrorList{field.Invalid(path, name, "must be at most 256 characters long and may not contain '/'")}
+	}
+	return nil
+}
+
+func validatePodConfiguration(path *field.Path, c contrail.PodConfiguration) field.ErrorList {
+	errs := validateCertificateKey(path.Child("certificateKey"), c.CertificateKey)
+	if c.Replicas != nil {
+		errs = append(errs, apivalidation.ValidateNonnegativeField(int64(*c.Replicas), path.Child("replicas"))...)
+	}
+	return errs
+}
+
+func validateCertificateKey(path *field.Path, k *contrail.CertificateKey) field.ErrorList {
+	if k == nil {
+		return nil
+	}
+	if err := utils.CertificateKeyConfig(k).Validate(); err != nil {
+		return field.ErrorList{field.Invalid(path, *k, err.Error())}
+	}
+	return nil
+}
+
+func validateStorage(path *field.Path, s contrail.Storage) field.ErrorList {
+	var errs field.ErrorList
+	if s.Size != "" {
+		if _, err := s.SizeAsQuantity(); err != nil {
+			errs = append(errs, field.Invalid(path.Child("size"), s.Size, err.Error()))
+		}
+	}
+	if s.Path != "" && !filepath.IsAbs(s.Path) {
+		errs = append(errs, field.Invalid(path.Child("path"), s.Path, "must be an absolute path"))
+	}
+	return errs
+}
+
+// validateStoragePathUnchanged forbids to move data of a running service to
+// another directory. Setting the path for the first time is allowed.
+func validateStoragePathUnchanged(path *field.Path, s, old contrail.Storage) field.ErrorList {
+	if old.Path == "" {
+		return nil
+	}
+	return apivalidation.ValidateImmutableField(s.Path, old.Path, path)
+}
+
+// port is a named port of a service. Nil number means the port is not used.
+type port struct {
+	name   string
+	number *int
+}
+
+func optionalPort(name string, number int) port {
+	if number == 0 {
+		return port{name: name}
+	}
+	return port{name: name, number: &number}
+}
+
+// validatePorts checks that ports are in the valid range and that
+// no two ports of a service are the same.
+func validatePorts(path *field.Path, ports ...port) field.ErrorList {
+	var errs field.ErrorList
+	used := map[int]string{}
+	for _, p := range ports {
+		if p.number == nil {
+			continue
+		}
+		portPath := path.Child(p.name)
+		for _, msg := range utilvalidation.IsValidPortNum(*p.number) {
+			errs = append(errs, field.Invalid(portPath, *p.number, msg))
+		}
+		if other, ok := used[*p.number]; ok {
+			errs = append(errs, field.Invalid(portPath, *p.number, fmt.Sprintf("must be different from %s", path.Child(other))))
+			continue
+		}
+		used[*p.number] = p.name
+	}
+	return errs
+}
@@ -0,0 +1,181 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	contrail "github.com/Juniper/contrail-operator/pkg/apis/contrail/v1alpha1"
+)
+
+var log = logf.Log.WithName("webhook")
+
+const (
+	// MutatePath is the path on which the defaulting webhook for all contrail.juniper.net resources is served.
+	MutatePath = "/mutate-contrail-juniper-net-v1alpha1"
+	// ValidatePath is the path on which the validating webhook for all contrail.juniper.net resources is served.
+	ValidatePath = "/validate-contrail-juniper-net-v1alpha1"
+)
+
+// AddToManager registers the defaulting and validating admission webhooks in the manager's webhook server.
+func AddToManager(mgr manager.Manager) error {
+	server := mgr.GetWebhookServer()
+	server.Register(MutatePath, &webhook.Admission{Handler: &Defaulter{}})
+	server.Register(ValidatePath, &webhook.Admission{Handler: &Validator{}})
+	return nil
+}
+
+// Defaulter fills contrail resources with the same default values that
+// the controllers would otherwise derive from ConfigurationParameters().
+type Defaulter struct {
+	decoder *admission.Decoder
+}
+
+// InjectDecoder injects the decoder.
+func (d *Defaulter) InjectDecoder(decoder *admission.Decoder) error {
+	d.decoder = decoder
+	return nil
+}
+
+// Handle implements admission.Handler.
+func (d *Defaulter) Handle(ctx context.Context, req admission.Request) admission.Response {
+	k, ok := kinds[req.Kind.Kind]
+	if !ok || k.defaults == nil {
+		return admission.Allowed("")
+	}
+	obj := k.object()
+	if err := d.decoder.Decode(req, obj); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	k.defaults(obj)
+	marshaled, err := json.Marshal(obj)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	return admission.PatchResponseFromRaw(req.Object.Raw, marshaled)
+}
+
+// Validator rejects contrail resources with invalid values, dangling
+// references to other instances or changes to immutable fields.
+type Validator struct {
+	client  client.Client
+	decoder *admission.Decoder
+}
+
+// InjectClient injects the client.
+func (v *Validator) InjectClient(c client.Client) error {
+	v.client = c
+	return nil
+}
+
+// InjectDecoder injects the decoder.
+func (v *Validator) InjectDecoder(decoder *admission.Decoder) error {
+	v.decoder = decoder
+	return nil
+}
+
+// Handle implements admission.Handler.
+func (v *Validator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	k, ok := kinds[req.Kind.Kind]
+	if !ok {
+		return admission.Allowed("")
+	}
+	if req.Operation != admissionv1beta1.Create && req.Operation != admissionv1beta1.Update {
+		return admission.Allowed("")
+	}
+	obj := k.object()
+	if err := v.decoder.Decode(req, obj); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	var old runtime.Object
+	if req.Operation == admissionv1beta1.Update {
+		old = k.object()
+		if err := v.decoder.DecodeRaw(req.OldObject, old); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+	}
+	spec := field.NewPath("spec")
+	errs := k.validate(spec, obj, old)
+	if !ownedByContrailResource(obj) {
+		if k.references != nil {
+			errs = append(errs, v.checkReferences(ctx, req.Namespace, k.references(spec, obj), old, k)...)
+		}
+		if k.dependencies != nil {
+			errs = append(errs, k.dependencies(spec, obj)...)
+		}
+	}
+	if len(errs) > 0 {
+		log.Info("Rejecting invalid resource", "kind", req.Kind.Kind, "name", req.Name, "namespace", req.Namespace, "errors", errs.ToAggregate().Error())
+		return invalid(req, errs)
+	}
+	return admission.Allowed("")
+}
+
+// checkReferences verifies that the instances referenced by the resource exist.
+// References that did not change on update are not checked again, so that
+// the removal of a dependency does not block unrelated updates.
+func (v *Validator) checkReferences(ctx context.Context, namespace string, refs []reference, old runtime.Object, k kind) field.ErrorList {
+	previous := map[string]string{}
+	if old != nil {
+		for _, ref := range k.references(field.NewPath("spec"), old) {
+			previous[ref.path.String()] = ref.name
+		}
+	}
+	var errs field.ErrorList
+	for _, ref := range refs {
+		if name, ok := previous[ref.path.String()]; ok && name == ref.name {
+			continue
+		}
+		target := kinds[ref.kind].object()
+		err := v.client.Get(ctx, types.NamespacedName{Name: ref.name, Namespace: namespace}, target)
+		if apierrors.IsNotFound(err) {
+			errs = append(errs, field.NotFound(ref.path, ref.name))
+		} else if err != nil {
+			errs = append(errs, field.InternalError(ref.path, err))
+		}
+	}
+	return errs
+}
+
+// ownedByContrailResource returns true if the resource is created and controlled
+// by another contrail resource, e.g. a Manager. References of such resources
+// are validated together with the owner.
+func ownedByContrailResource(obj runtime.Object) bool {
+	meta, ok := obj.(metav1.Object)
+	if !ok {
+		return false
+	}
+	owner := metav1.GetControllerOf(meta)
+	if owner == nil {
+		return false
+	}
+	gv, err := schema.ParseGroupVersion(owner.APIVersion)
+	if err != nil {
+		return false
+	}
+	return gv.Group == contrail.SchemeGroupVersion.Group
+}
+
+func invalid(req admission.Request, errs field.ErrorList) admission.Response {
+	gk := schema.GroupKind{Group: req.Kind.Group, Kind: req.Kind.Kind}
+	status := apierrors.NewInvalid(gk, req.Name, errs).ErrStatus
+	return admission.Response{
+		AdmissionResponse: admissionv1beta1.AdmissionResponse{
+			Allowed: false,
+			Result:  &status,
+		},
+	}
+}
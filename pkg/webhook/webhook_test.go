@@ -0,0 +1,630 @@
+package webhook_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	contrail "github.com/Juniper/contrail-operator/pkg/apis/contrail/v1alpha1"
+	"github.com/Juniper/contrail-operator/pkg/webhook"
+)
+
+func TestDefaulter(t *testing.T) {
+	scheme, err := contrail.SchemeBuilder.Build()
+	require.NoError(t, err)
+	decoder, err := admission.NewDecoder(scheme)
+	require.NoError(t, err)
+	defaulter := &webhook.Defaulter{}
+	require.NoError(t, defaulter.InjectDecoder(decoder))
+
+	t.Run("should fill Cassandra with values from ConfigurationParameters", func(t *testing.T) {
+		port := 9161
+		cassandra := &contrail.Cassandra{
+			ObjectMeta: meta.ObjectMeta{Name: "cassandra1", Namespace: "default"},
+		}
+		cassandra.Spec.ServiceConfiguration.Port = &port
+		// when
+		resp := defaulter.Handle(context.Background(), newRequest(t, admissionv1beta1.Create, "Cassandra", cassandra, nil))
+		// then
+		require.True(t, resp.Allowed)
+		patches := map[string]interface{}{}
+		for _, patch := range resp.Patches {
+			assert.Equal(t, "add", patch.Operation)
+			patches[patch.Path] = patch.Value
+		}
+		assert.NotContains(t, patches, "/spec/serviceConfiguration/port")
+		assert.NotContains(t, patches, "/spec/serviceConfiguration/clusterName")
+		assert.Equal(t, float64(contrail.CassandraCqlPort), patches["/spec/serviceConfiguration/cqlPort"])
+		assert.Equal(t, "/mnt/cassandra", patches["/spec/serviceConfiguration/storage/path"])
+		assert.Equal(t, "5Gi", patches["/spec/serviceConfiguration/storage/size"])
+	})
+
+	t.Run("should leave resources without defaults unchanged", func(t *testing.T) {
+		memcached := &contrail.Memcached{
+			ObjectMeta: meta.ObjectMeta{Name: "memcached", Namespace: "default"},
+		}
+		// when
+		resp := defaulter.Handle(context.Background(), newRequest(t, admissionv1beta1.Create, "Memcached", memcached, nil))
+		// then
+		assert.True(t, resp.Allowed)
+		assert.Empty(t, resp.Patches)
+	})
+}
+
+func TestValidator(t *testing.T) {
+	scheme, err := contrail.SchemeBuilder.Build()
+	require.NoError(t, err)
+	decoder, err := admission.NewDecoder(scheme)
+	require.NoError(t, err)
+
+	newValidator := func(objs ...runtime.Object) *webhook.Validator {
+		validator := &webhook.Validator{}
+		require.NoError(t, validator.InjectDecoder(decoder))
+		require.NoError(t, validator.InjectClient(fake.NewFakeClientWithScheme(scheme, objs...)))
+		return validator
+	}
+
+	t.Run("should reject Control with duplicated ports", func(t *testing.T) {
+		control := newControl()
+		xmppPort := contrail.BgpPort
+		control.Spec.ServiceConfiguration.XMPPPort = &xmppPort
+		// when
+		resp := newValidator().Handle(context.Background(), newRequest(t, admissionv1beta1.Create, "Control", control, nil))
+		// then
+		assert.False(t, resp.Allowed)
+		assert.Contains(t, resp.Result.Message, "spec.serviceConfiguration.xmppPort")
+	})
+
+	t.Run("should reject Control with not existing Cassandra", func(t *testing.T) {
+		control := newControl()
+		control.Spec.ServiceConfiguration.CassandraInstance = "cassandra1"
+		// when
+		resp := newValidator().Handle(context.Background(), newRequest(t, admissionv1beta1.Create, "Control", control, nil))
+		// then
+		assert.False(t, resp.Allowed)
+		assert.Contains(t, resp.Result.Message, "spec.serviceConfiguration.cassandraInstance: Not found")
+	})
+
+	t.Run("should accept Control with existing Cassandra", func(t *testing.T) {
+		control := newControl()
+		control.Spec.ServiceConfiguration.CassandraInstance = "cassandra1"
+		// when
+		resp := newValidator(newCassandra()).Handle(context.Background(), newRequest(t, admissionv1beta1.Create, "Control", control, nil))
+		// then
+		assert.True(t, resp.Allowed)
+	})
+
+	t.Run("should not check references of resources created by Manager", func(t *testing.T) {
+		control := newControl()
+		control.Spec.ServiceConfiguration.CassandraInstance = "cassandra1"
+		trueVal := true
+		control.OwnerReferences = []meta.OwnerReference{{
+			APIVersion: "contrail.juniper.net/v1alpha1", Kind: "Manager", Name: "cluster1", UID: "uid", Controller: &trueVal,
+		}}
+		// when
+		resp := newValidator().Handle(context.Background(), newRequest(t, admissionv1beta1.Create, "Control", control, nil))
+		// then
+		assert.True(t, resp.Allowed)
+	})
+
+	t.Run("should not check unchanged references on update", func(t *testing.T) {
+		control := newControl()
+		control.Spec.ServiceConfiguration.CassandraInstance = "cassandra1"
+		old := control.DeepCopy()
+		replicas := int32(3)
+		control.Spec.CommonConfiguration.Replicas = &replicas
+		// when
+		resp := newValidator().Handle(context.Background(), newRequest(t, admissionv1beta1.Update, "Control", control, old))
+		// then
+		assert.True(t, resp.Allowed)
+	})
+
+	t.Run("should reject ECDSA certificate key with RSA size", func(t *testing.T) {
+		control := newControl()
+		control.Spec.CommonConfiguration.CertificateKey = &contrail.CertificateKey{Algorithm: "ECDSA", Size: 2048}
+		// when
+		resp := newValidator().Handle(context.Background(), newRequest(t, admissionv1beta1.Create, "Control", control, nil))
+		// then
+		assert.False(t, resp.Allowed)
+		assert.Contains(t, resp.Result.Message, "spec.commonConfiguration.certificateKey")
+	})
+
+	t.Run("should reject Cassandra with invalid storage size", func(t *testing.T) {
+		cassandra := newCassandra()
+		cassandra.Spec.ServiceConfiguration.Storage.Size = "5 gigabytes"
+		// when
+		resp := newValidator().Handle(context.Background(), newRequest(t, admissionv1beta1.Create, "Cassandra", cassandra, nil))
+		// then
+		assert.False(t, resp.Allowed)
+		assert.Contains(t, resp.Result.Message, "spec.serviceConfiguration.storage.size")
+	})
+
+	t.Run("should reject changes of Cassandra cluster name and storage path", func(t *testing.T) {
+		old := newCassandra()
+		old.Spec.ServiceConfiguration.ClusterName = "cluster1"
+		cassandra := old.DeepCopy()
+		cassandra.Spec.ServiceConfiguration.ClusterName = "cluster2"
+		cassandra.Spec.ServiceConfiguration.Storage.Path = "/mnt/other"
+		// when
+		resp := newValidator().Handle(context.Background(), newRequest(t, admissionv1beta1.Update, "Cassandra", cassandra, old))
+		// then
+		assert.False(t, resp.Allowed)
+		assert.Contains(t, resp.Result.Message, "spec.serviceConfiguration.clusterName")
+		assert.Contains(t, resp.Result.Message, "spec.serviceConfiguration.storage.path")
+	})
+
+	t.Run("should accept Cassandra update with default storage path set explicitly", func(t *testing.T) {
+		old := newCassandra()
+		cassandra := old.DeepCopy()
+		cassandra.Spec.ServiceConfiguration.Storage.Path = "/mnt/cassandra"
+		// when
+		resp := newValidator().Handle(context.Background(), newRequest(t, admissionv1beta1.Update, "Cassandra", cassandra, old))
+		// then
+		assert.True(t, resp.Allowed)
+	})
+
+	t.Run("should accept Manager with references to its own services", func(t *testing.T) {
+		manager := newManager()
+		// when
+		resp := newValidator().Handle(context.Background(), newRequest(t, admissionv1beta1.Create, "Manager", manager, nil))
+		// then
+		assert.True(t, resp.Allowed)
+	})
+
+	t.Run("should reject Manager with references to not existing services", func(t *testing.T) {
+		manager := newManager()
+		manager.Spec.Services.Config.Spec.ServiceConfiguration.ZookeeperInstance = "zookeeper1"
+		// when
+		resp := newValidator().Handle(context.Background(), newRequest(t, admissionv1beta1.Create, "Manager", manager, nil))
+		// then
+		assert.False(t, resp.Allowed)
+		assert.Contains(t, resp.Result.Message, "spec.services.config.spec.serviceConfiguration.zookeeperInstance: Not found")
+	})
+
+	t.Run("should reject Manager with Kubemanager referring to not existing Cassandra", func(t *testing.T) {
+		manager := newManager()
+		manager.Spec.Services.Kubemanagers = []*contrail.KubemanagerService{{
+			ObjectMeta: contrail.ObjectMeta{Name: "kubemanager1"},
+			Spec: contrail.KubemanagerServiceSpec{
+				ServiceConfiguration: contrail.KubemanagerManagerServiceConfiguration{CassandraInstance: "cassandra2"},
+			},
+		}}
+		// when
+		resp := newValidator().Handle(context.Background(), newRequest(t, admissionv1beta1.Create, "Manager", manager, nil))
+		// then
+		assert.False(t, resp.Allowed)
+		assert.Contains(t, resp.Result.Message, "spec.services.kubemanagers[0].spec.serviceConfiguration.cassandraInstance: Not found")
+	})
+
+	t.Run("should reject Manager with Vrouter referring to not existing Control", func(t *testing.T) {
+		manager := newManager()
+		manager.Spec.Services.Vrouters = []*contrail.VrouterService{{
+			ObjectMeta: contrail.ObjectMeta{Name: "vrouter1"},
+			Spec: contrail.VrouterServiceSpec{
+				ServiceConfiguration: contrail.VrouterManagerServiceConfiguration{ControlInstance: "control1"},
+			},
+		}}
+		// when
+		resp := newValidator().Handle(context.Background(), newRequest(t, admissionv1beta1.Create, "Manager", manager, nil))
+		// then
+		assert.False(t, resp.Allowed)
+		assert.Contains(t, resp.Result.Message, "spec.services.vrouters[0].spec.serviceConfiguration.controlInstance: Not found")
+	})
+
+	t.Run("should reject standalone Kubemanager without nodes of its dependencies", func(t *testing.T) {
+		kubemanager := &contrail.Kubemanager{ObjectMeta: meta.ObjectMeta{Name: "kubemanager1", Namespace: "default"}}
+		kubemanager.Spec.ServiceConfiguration.ConfigNodesConfiguration = &contrail.ConfigClusterConfiguration{}
+		// when
+		resp := newValidator().Handle(context.Background(), newRequest(t, admissionv1beta1.Create, "Kubemanager", kubemanager, nil))
+		// then
+		assert.False(t, resp.Allowed)
+		assert.Contains(t, resp.Result.Message, "spec.serviceConfiguration.cassandraNodesConfiguration: Required value")
+		assert.NotContains(t, resp.Result.Message, "configNodesConfiguration")
+	})
+
+	t.Run("should accept Kubemanager created by Manager", func(t *testing.T) {
+		kubemanager := &contrail.Kubemanager{ObjectMeta: meta.ObjectMeta{Name: "kubemanager1", Namespace: "default"}}
+		trueVal := true
+		kubemanager.OwnerReferences = []meta.OwnerReference{{
+			APIVersion: "contrail.juniper.net/v1alpha1", Kind: "Manager", Name: "cluster1", UID: "uid", Controller: &trueVal,
+		}}
+		// when
+		resp := newValidator().Handle(context.Background(), newRequest(t, admissionv1beta1.Create, "Kubemanager", kubemanager, nil))
+		// then
+		assert.True(t, resp.Allowed)
+	})
+
+	t.Run("should reject standalone Vrouter without Control nodes", func(t *testing.T) {
+		vrouter := &contrail.Vrouter{ObjectMeta: meta.ObjectMeta{Name: "vrouter1", Namespace: "default"}}
+		vrouter.Spec.ServiceConfiguration.ConfigNodesConfiguration = &contrail.ConfigClusterConfiguration{}
+		// when
+		resp := newValidator().Handle(context.Background(), newRequest(t, admissionv1beta1.Create, "Vrouter", vrouter, nil))
+		// then
+		assert.False(t, resp.Allowed)
+		assert.Contains(t, resp.Result.Message, "spec.serviceConfiguration.controlNodesConfiguration: Required value")
+	})
+
+	t.Run("should accept standalone Vrouter with nodes of its dependencies", func(t *testing.T) {
+		vrouter := &contrail.Vrouter{ObjectMeta: meta.ObjectMeta{Name: "vrouter1", Namespace: "default"}}
+		vrouter.Spec.ServiceConfiguration.ConfigNodesConfiguration = &contrail.ConfigClusterConfiguration{}
+		vrouter.Spec.ServiceConfiguration.ControlNodesConfiguration = &contrail.ControlClusterConfiguration{}
+		// when
+		resp := newValidator().Handle(context.Background(), newRequest(t, admissionv1beta1.Create, "Vrouter", vrouter, nil))
+		// then
+		assert.True(t, resp.Allowed)
+	})
+
+	t.Run("should reject Manager with duplicated service names", func(t *testing.T) {
+		manager := newManager()
+		manager.Spec.Services.Cassandras = append(manager.Spec.Services.Cassandras, manager.Spec.Services.Cassandras[0])
+		// when
+		resp := newValidator().Handle(context.Background(), newRequest(t, admissionv1beta1.Create, "Manager", manager, nil))
+		// then
+		assert.False(t, resp.Allowed)
+		assert.Contains(t, resp.Result.Message, "spec.services.cassandras[1].metadata.name: Duplicate value")
+	})
+
+	t.Run("should reject storage path change of Cassandra in Manager", func(t *testing.T) {
+		old := newManager()
+		manager := old.DeepCopy()
+		manager.Spec.Services.Cassandras[0].Spec.ServiceConfiguration.Storage.Path = "/mnt/other"
+		// when
+		resp := newValidator().Handle(context.Background(), newRequest(t, admissionv1beta1.Update, "Manager", manager, old))
+		// then
+		assert.False(t, resp.Allowed)
+		assert.Contains(t, resp.Result.Message, "spec.services.cassandras[0].spec.serviceConfiguration.storage.path")
+	})
+
+	t.Run("should reject Postgres backup with invalid schedule and retention", func(t *testing.T) {
+		dumps := int32(0)
+		postgres := &contrail.Postgres{
+			ObjectMeta: meta.ObjectMeta{Name: "postgres1", Namespace: "default"},
+			Spec: contrail.PostgresSpec{ServiceConfiguration: contrail.PostgresConfiguration{
+				Backup: &contrail.PostgresBackup{
+					DumpSchedule:       "daily",
+					BaseBackupSchedule: "@weekly",
+					Retention:          contrail.PostgresBackupRetention{Dumps: &dumps},
+				},
+			}},
+		}
+		// when
+		resp := newValidator().Handle(context.Background(), newRequest(t, admissionv1beta1.Create, "Postgres", postgres, nil))
+		// then
+		assert.False(t, resp.Allowed)
+		assert.Contains(t, resp.Result.Message, "spec.serviceConfiguration.backup.dumpSchedule")
+		assert.Contains(t, resp.Result.Message, "spec.serviceConfiguration.backup.retention.dumps")
+		assert.NotContains(t, resp.Result.Message, "baseBackupSchedule")
+	})
+
+	t.Run("should accept Keystone with LDAP domain", func(t *testing.T) {
+		keystone := newKeystone(newLDAPDomain())
+		// when
+		resp := newValidator().Handle(context.Background(), newRequest(t, admissionv1beta1.Create, "Keystone", keystone, nil))
+		// then
+		assert.True(t, resp.Allowed)
+	})
+
+	t.Run("should reject Keystone with invalid LDAP domains", func(t *testing.T) {
+		startTLS := newLDAPDomain()
+		startTLS.UseStartTLS = true
+		defaultDomain := newLDAPDomain()
+		defaultDomain.Name = "Default"
+		defaultDomain.URL = "https://ad.example.com"
+		defaultDomain.Groups = nil
+		keystone := newKeystone(startTLS, startTLS, defaultDomain)
+		// when
+		resp := newValidator().Handle(context.Background(), newRequest(t, admissionv1beta1.Create, "Keystone", keystone, nil))
+		// then
+		assert.False(t, resp.Allowed)
+		assert.Contains(t, resp.Result.Message, "spec.serviceConfiguration.ldapDomains[0].useStartTLS: Forbidden")
+		assert.Contains(t, resp.Result.Message, "spec.serviceConfiguration.ldapDomains[1].name: Duplicate value")
+		assert.Contains(t, resp.Result.Message, "spec.serviceConfiguration.ldapDomains[2].name: Invalid value")
+		assert.Contains(t, resp.Result.Message, "spec.serviceConfiguration.ldapDomains[2].url: Invalid value")
+		assert.Contains(t, resp.Result.Message, "spec.serviceConfiguration.ldapDomains[2].groupRoleMappings: Forbidden")
+	})
+
+	t.Run("should reject LDAP domains of external Keystone", func(t *testing.T) {
+		keystone := newKeystone(newLDAPDomain())
+		keystone.Spec.ServiceConfiguration.ExternalAddress = "keystone.example.com"
+		// when
+		resp := newValidator().Handle(context.Background(), newRequest(t, admissionv1beta1.Create, "Keystone", keystone, nil))
+		// then
+		assert.False(t, resp.Allowed)
+		assert.Contains(t, resp.Result.Message, "spec.serviceConfiguration.ldapDomains: Forbidden")
+	})
+}
+
+func TestValidateCassandraBackup(t *testing.T) {
+	scheme, err := contrail.SchemeBuilder.Build()
+	require.NoError(t, err)
+	decoder, err := admission.NewDecoder(scheme)
+	require.NoError(t, err)
+	validator := &webhook.Validator{}
+	require.NoError(t, validator.InjectDecoder(decoder))
+	require.NoError(t, validator.InjectClient(fake.NewFakeClientWithScheme(scheme, newCassandra())))
+	newBackup := func() *contrail.CassandraBackup {
+		return &contrail.CassandraBackup{
+			ObjectMeta: meta.ObjectMeta{Name: "backup1", Namespace: "default"},
+			Spec: contrail.CassandraBackupSpec{
+				CassandraInstance: "cassandra1",
+				Target: contrail.BackupTarget{S3: &contrail.S3BackupTarget{
+					Endpoint: "https://s3.example.com", Bucket: "backups", CredentialsSecretName: "s3-credentials",
+				}},
+			},
+		}
+	}
+
+	t.Run("should accept backup to S3", func(t *testing.T) {
+		// when
+		resp := validator.Handle(context.Background(), newRequest(t, admissionv1beta1.Create, "CassandraBackup", newBackup(), nil))
+		// then
+		assert.True(t, resp.Allowed)
+	})
+
+	t.Run("should reject backup with two targets", func(t *testing.T) {
+		backup := newBackup()
+		backup.Spec.Target.Swift = &contrail.SwiftBackupTarget{SwiftInstance: "swift1", Container: "backups"}
+		// when
+		resp := validator.Handle(context.Background(), newRequest(t, admissionv1beta1.Create, "CassandraBackup", backup, nil))
+		// then
+		assert.False(t, resp.Allowed)
+		assert.Contains(t, resp.Result.Message, "spec.target: Forbidden")
+	})
+
+	t.Run("should reject change of backup spec", func(t *testing.T) {
+		backup := newBackup()
+		old := backup.DeepCopy()
+		backup.Spec.Keyspaces = []string{"config_db_uuid"}
+		// when
+		resp := validator.Handle(context.Background(), newRequest(t, admissionv1beta1.Update, "CassandraBackup", backup, old))
+		// then
+		assert.False(t, resp.Allowed)
+		assert.Contains(t, resp.Result.Message, "field is immutable")
+	})
+
+	t.Run("should reject restore without backup", func(t *testing.T) {
+		restore := &contrail.CassandraRestore{
+			ObjectMeta: meta.ObjectMeta{Name: "restore1", Namespace: "default"},
+			Spec:       contrail.CassandraRestoreSpec{CassandraInstance: "cassandra1", Path: "backup1-20200101000000"},
+		}
+		// when
+		resp := validator.Handle(context.Background(), newRequest(t, admissionv1beta1.Create, "CassandraRestore", restore, nil))
+		// then
+		assert.False(t, resp.Allowed)
+		assert.Contains(t, resp.Result.Message, "spec.backupName: Required value")
+	})
+}
+
+func TestValidateSwiftContainer(t *testing.T) {
+	scheme, err := contrail.SchemeBuilder.Build()
+	require.NoError(t, err)
+	decoder, err := admission.NewDecoder(scheme)
+	require.NoError(t, err)
+	validator := &webhook.Validator{}
+	require.NoError(t, validator.InjectDecoder(decoder))
+	swift := &contrail.Swift{ObjectMeta: meta.ObjectMeta{Name: "swift1", Namespace: "default"}}
+	require.NoError(t, validator.InjectClient(fake.NewFakeClientWithScheme(scheme, swift)))
+	newContainer := func() *contrail.SwiftContainer {
+		return &contrail.SwiftContainer{
+			ObjectMeta: meta.ObjectMeta{Name: "images", Namespace: "default"},
+			Spec: contrail.SwiftContainerSpec{
+				SwiftInstance: "swift1",
+				ReadACL:       ".r:*",
+				Versioning:    &contrail.SwiftContainerVersioning{Location: "images-versions"},
+				Metadata:      map[string]string{"Owner": "contrail"},
+			},
+		}
+	}
+
+	t.Run("should accept container", func(t *testing.T) {
+		// when
+		resp := validator.Handle(context.Background(), newRequest(t, admissionv1beta1.Create, "SwiftContainer", newContainer(), nil))
+		// then
+		assert.True(t, resp.Allowed)
+	})
+
+	t.Run("should reject versions location equal to container", func(t *testing.T) {
+		container := newContainer()
+		container.Spec.Versioning.Location = "images"
+		// when
+		resp := validator.Handle(context.Background(), newRequest(t, admissionv1beta1.Create, "SwiftContainer", container, nil))
+		// then
+		assert.False(t, resp.Allowed)
+		assert.Contains(t, resp.Result.Message, "spec.versioning.location: Invalid value")
+	})
+
+	t.Run("should reject quota in metadata", func(t *testing.T) {
+		container := newContainer()
+		container.Spec.Metadata["quota-bytes"] = "100"
+		// when
+		resp := validator.Handle(context.Background(), newRequest(t, admissionv1beta1.Create, "SwiftContainer", container, nil))
+		// then
+		assert.False(t, resp.Allowed)
+		assert.Contains(t, resp.Result.Message, "spec.metadata[quota-bytes]: Forbidden")
+	})
+
+	t.Run("should reject change of container name", func(t *testing.T) {
+		container := newContainer()
+		old := container.DeepCopy()
+		container.Spec.ContainerName = "pictures"
+		// when
+		resp := validator.Handle(context.Background(), newRequest(t, admissionv1beta1.Update, "SwiftContainer", container, old))
+		// then
+		assert.False(t, resp.Allowed)
+		assert.Contains(t, resp.Result.Message, "spec.containerName: Invalid value")
+	})
+}
+
+func TestValidateSwiftStorage(t *testing.T) {
+	scheme, err := contrail.SchemeBuilder.Build()
+	require.NoError(t, err)
+	decoder, err := admission.NewDecoder(scheme)
+	require.NoError(t, err)
+	validator := &webhook.Validator{}
+	require.NoError(t, validator.InjectDecoder(decoder))
+	require.NoError(t, validator.InjectClient(fake.NewFakeClientWithScheme(scheme)))
+	newSwiftStorage := func() *contrail.SwiftStorage {
+		return &contrail.SwiftStorage{
+			ObjectMeta: meta.ObjectMeta{Name: "swift1-storage", Namespace: "default"},
+			Spec: contrail.SwiftStorageSpec{
+				ServiceConfiguration: contrail.SwiftStorageConfiguration{
+					Devices: []contrail.SwiftDeviceConfiguration{
+						{Name: "sdb", Rings: []contrail.SwiftRing{contrail.SwiftObjectRing}},
+						{Name: "ssd", Rings: []contrail.SwiftRing{contrail.SwiftAccountRing, contrail.SwiftContainerRing}},
+					},
+				},
+			},
+		}
+	}
+
+	t.Run("should accept devices", func(t *testing.T) {
+		// when
+		resp := validator.Handle(context.Background(), newRequest(t, admissionv1beta1.Create, "SwiftStorage", newSwiftStorage(), nil))
+		// then
+		assert.True(t, resp.Allowed)
+	})
+
+	t.Run("should reject duplicated device names", func(t *testing.T) {
+		swiftStorage := newSwiftStorage()
+		swiftStorage.Spec.ServiceConfiguration.Devices[1].Name = "sdb"
+		// when
+		resp := validator.Handle(context.Background(), newRequest(t, admissionv1beta1.Create, "SwiftStorage", swiftStorage, nil))
+		// then
+		assert.False(t, resp.Allowed)
+		assert.Contains(t, resp.Result.Message, "spec.serviceConfiguration.devices[1].name: Duplicate value")
+	})
+
+	t.Run("should reject ring without devices", func(t *testing.T) {
+		swiftStorage := newSwiftStorage()
+		swiftStorage.Spec.ServiceConfiguration.Devices[1].Rings = []contrail.SwiftRing{contrail.SwiftAccountRing}
+		// when
+		resp := validator.Handle(context.Background(), newRequest(t, admissionv1beta1.Create, "SwiftStorage", swiftStorage, nil))
+		// then
+		assert.False(t, resp.Allowed)
+		assert.Contains(t, resp.Result.Message, "spec.serviceConfiguration.devices: Required value: a device placed in the container ring is required")
+	})
+
+	t.Run("should accept change of rings and weights of devices", func(t *testing.T) {
+		swiftStorage := newSwiftStorage()
+		old := swiftStorage.DeepCopy()
+		swiftStorage.Spec.ServiceConfiguration.Devices[0].Rings = nil
+		swiftStorage.Spec.ServiceConfiguration.Devices[1].Weight = 50
+		// when
+		resp := validator.Handle(context.Background(), newRequest(t, admissionv1beta1.Update, "SwiftStorage", swiftStorage, old))
+		// then
+		assert.True(t, resp.Allowed)
+	})
+
+	t.Run("should reject added device", func(t *testing.T) {
+		swiftStorage := newSwiftStorage()
+		old := swiftStorage.DeepCopy()
+		swiftStorage.Spec.ServiceConfiguration.Devices = append(swiftStorage.Spec.ServiceConfiguration.Devices, contrail.SwiftDeviceConfiguration{Name: "sdc"})
+		// when
+		resp := validator.Handle(context.Background(), newRequest(t, admissionv1beta1.Update, "SwiftStorage", swiftStorage, old))
+		// then
+		assert.False(t, resp.Allowed)
+		assert.Contains(t, resp.Result.Message, "spec.serviceConfiguration.devices: Invalid value")
+	})
+
+	t.Run("should reject move from single device to devices", func(t *testing.T) {
+		swiftStorage := newSwiftStorage()
+		old := swiftStorage.DeepCopy()
+		old.Spec.ServiceConfiguration.Devices = nil
+		old.Spec.ServiceConfiguration.Device = "sdb"
+		// when
+		resp := validator.Handle(context.Background(), newRequest(t, admissionv1beta1.Update, "SwiftStorage", swiftStorage, old))
+		// then
+		assert.False(t, resp.Allowed)
+		assert.Contains(t, resp.Result.Message, "spec.serviceConfiguration.devices: Invalid value")
+	})
+
+	t.Run("should reject change of devices of Swift", func(t *testing.T) {
+		swift := &contrail.Swift{ObjectMeta: meta.ObjectMeta{Name: "swift1", Namespace: "default"}}
+		swift.Spec.ServiceConfiguration.SwiftStorageConfiguration = newSwiftStorage().Spec.ServiceConfiguration
+		old := swift.DeepCopy()
+		swift.Spec.ServiceConfiguration.SwiftStorageConfiguration.Devices[0].StorageClassName = "fast"
+		// when
+		resp := validator.Handle(context.Background(), newRequest(t, admissionv1beta1.Update, "Swift", swift, old))
+		// then
+		assert.False(t, resp.Allowed)
+		assert.Contains(t, resp.Result.Message, "spec.serviceConfiguration.swiftStorageConfiguration.devices: Invalid value")
+	})
+}
+
+func newControl() *contrail.Control {
+	return &contrail.Control{
+		ObjectMeta: meta.ObjectMeta{Name: "control1", Namespace: "default"},
+	}
+}
+
+func newCassandra() *contrail.Cassandra {
+	return &contrail.Cassandra{
+		ObjectMeta: meta.ObjectMeta{Name: "cassandra1", Namespace: "default"},
+	}
+}
+
+func newKeystone(domains ...contrail.KeystoneLDAPDomain) *contrail.Keystone {
+	return &contrail.Keystone{
+		ObjectMeta: meta.ObjectMeta{Name: "keystone", Namespace: "default"},
+		Spec: contrail.KeystoneSpec{
+			ServiceConfiguration: contrail.KeystoneConfiguration{LDAPDomains: domains},
+		},
+	}
+}
+
+func newLDAPDomain() contrail.KeystoneLDAPDomain {
+	return contrail.KeystoneLDAPDomain{
+		Name:              "corp",
+		URL:               "ldaps://ad.example.com",
+		BindSecretName:    "corp-bind",
+		Users:             contrail.KeystoneLDAPUsers{TreeDN: "OU=Users,DC=example,DC=com"},
+		Groups:            &contrail.KeystoneLDAPGroups{TreeDN: "OU=Groups,DC=example,DC=com"},
+		GroupRoleMappings: []contrail.KeystoneLDAPRoleMapping{{Group: "cloud-admins", Role: "admin", Project: "admin"}},
+	}
+}
+
+func newManager() *contrail.Manager {
+	return &contrail.Manager{
+		ObjectMeta: meta.ObjectMeta{Name: "cluster1", Namespace: "default"},
+		Spec: contrail.ManagerSpec{
+			Services: contrail.Services{
+				Cassandras: []*contrail.CassandraService{{
+					ObjectMeta: contrail.ObjectMeta{Name: "cassandra1"},
+				}},
+				Config: &contrail.ConfigService{
+					ObjectMeta: contrail.ObjectMeta{Name: "config1"},
+					Spec: contrail.ConfigSpec{
+						ServiceConfiguration: contrail.ConfigConfiguration{CassandraInstance: "cassandra1"},
+					},
+				},
+			},
+		},
+	}
+}
+
+func newRequest(t *testing.T, operation admissionv1beta1.Operation, kind string, obj, old runtime.Object) admission.Request {
+	raw, err := json.Marshal(obj)
+	require.NoError(t, err)
+	req := admission.Request{
+		AdmissionRequest: admissionv1beta1.AdmissionRequest{
+			Operation: operation,
+			Kind:      meta.GroupVersionKind{Group: "contrail.juniper.net", Version: "v1alpha1", Kind: kind},
+			Namespace: "default",
+			Object:    runtime.RawExtension{Raw: raw},
+		},
+	}
+	if old != nil {
+		oldRaw, err := json.Marshal(old)
+		require.NoError(t, err)
+		req.OldObject = runtime.RawExtension{Raw: oldRaw}
+	}
+	return req
+}
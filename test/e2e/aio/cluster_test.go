@@ -90,10 +90,6 @@ func TestCluster(t *testing.T) {
 				manager.Spec.Services.ProvisionManager.Spec.ServiceConfiguration.Containers).Image =
 				"registry:5000/contrail-operator/engprod-269421/contrail-operator-provisioner:" + buildTag
 
-			utils.GetContainerFromList("contrail-operator-ringcontroller",
-				manager.Spec.Services.Swift.Spec.ServiceConfiguration.Containers).Image =
-				"registry:5000/contrail-operator/engprod-269421/contrail-operator-ringcontroller:" + buildTag
-
 			err = f.Client.Create(context.TODO(), adminPassWordSecret, &test.CleanupOptions{TestContext: ctx, Timeout: cleanupTimeout, RetryInterval: cleanupRetryInterval})
 			assert.NoError(t, err)
 
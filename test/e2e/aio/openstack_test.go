@@ -200,9 +200,6 @@ func TestOpenstackServices(t *testing.T) {
 				},
 				Spec: contrail.SwiftSpec{
 					ServiceConfiguration: contrail.SwiftConfiguration{
-						Containers: []*contrail.Container{
-							{Name: "contrail-operator-ringcontroller", Image: "registry:5000/contrail-operator/engprod-269421/contrail-operator-ringcontroller:" + buildTag},
-						},
 						CredentialsSecretName: "openstacktest-swift-credentials-secret",
 						SwiftStorageConfiguration: contrail.SwiftStorageConfiguration{
 							AccountBindPort:   6001,
@@ -234,7 +231,6 @@ func TestOpenstackServices(t *testing.T) {
 							SwiftServiceName:   "contrail-swift",
 							Containers: []*contrail.Container{
 								{Name: "wait-for-ready-conf", Image: "registry:5000/common-docker-third-party/contrail/busybox:1.31"},
-								{Name: "init", Image: "registry:5000/common-docker-third-party/contrail/centos-binary-kolla-toolbox:train-2005"},
 								{Name: "api", Image: "registry:5000/common-docker-third-party/contrail/centos-binary-swift-proxy-server:train-2005"},
 							},
 							Service: contrail.Service{ServiceType: "ClusterIP"},
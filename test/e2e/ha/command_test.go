@@ -430,9 +430,6 @@ func getHACommandCluster(namespace, nodeLabel, storagePath string) *contrail.Man
 		Spec: contrail.SwiftSpec{
 			CommonConfiguration: commonConfig,
 			ServiceConfiguration: contrail.SwiftConfiguration{
-				Containers: []*contrail.Container{
-					{Name: "contrail-operator-ringcontroller", Image: "registry:5000/contrail-operator/engprod-269421/contrail-operator-ringcontroller:" + scmBranch + "." + scmRevision},
-				},
 				CredentialsSecretName: "swift-pass-secret",
 				SwiftStorageConfiguration: contrail.SwiftStorageConfiguration{
 					Storage:           contrail.Storage{Path: storagePath + "swiftstorage"},
@@ -464,7 +461,6 @@ func getHACommandCluster(namespace, nodeLabel, storagePath string) *contrail.Man
 					KeystoneSecretName: "keystone-adminpass-secret",
 					Containers: []*contrail.Container{
 						{Name: "wait-for-ready-conf", Image: "registry:5000/common-docker-third-party/contrail/busybox:1.31"},
-						{Name: "init", Image: "registry:5000/common-docker-third-party/contrail/centos-binary-kolla-toolbox:train-2005"},
 						{Name: "api", Image: "registry:5000/common-docker-third-party/contrail/centos-binary-swift-proxy-server:train-2005"},
 					},
 					Service: contrail.Service{ServiceType: "ClusterIP"},
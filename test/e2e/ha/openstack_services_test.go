@@ -315,7 +315,6 @@ func updateOpenStackManagerImages(f *test.Framework, manager *contrail.Manager)
 	swiftProxy := &manager.Spec.Services.Swift.Spec.ServiceConfiguration.SwiftProxyConfiguration
 	swiftProxy.Containers = []*contrail.Container{
 		{Name: "wait-for-ready-conf", Image: "registry:5000/common-docker-third-party/contrail/busybox:1.31"},
-		{Name: "init", Image: "registry:5000/common-docker-third-party/contrail/centos-binary-kolla-toolbox:train"},
 		{Name: "api", Image: "registry:5000/common-docker-third-party/contrail/centos-binary-swift-proxy-server:train"},
 	}
 
@@ -486,9 +485,6 @@ func getHAOpenStackCluster(namespace, nodeLabel string) *contrail.Manager {
 		},
 		Spec: contrail.SwiftSpec{
 			ServiceConfiguration: contrail.SwiftConfiguration{
-				Containers: []*contrail.Container{
-					{Name: "contrail-operator-ringcontroller", Image: "registry:5000/contrail-operator/engprod-269421/contrail-operator-ringcontroller:" + scmBranch + "." + scmRevision},
-				},
 				CredentialsSecretName: "swift-pass-secret",
 				SwiftStorageConfiguration: contrail.SwiftStorageConfiguration{
 					AccountBindPort:   6001,
@@ -519,7 +515,6 @@ func getHAOpenStackCluster(namespace, nodeLabel string) *contrail.Manager {
 					KeystoneSecretName: "keystone-adminpass-secret",
 					Containers: []*contrail.Container{
 						{Name: "wait-for-ready-conf", Image: "registry:5000/common-docker-third-party/contrail/busybox:1.31"},
-						{Name: "init", Image: "registry:5000/common-docker-third-party/contrail/centos-binary-kolla-toolbox:train-2005"},
 						{Name: "api", Image: "registry:5000/common-docker-third-party/contrail/centos-binary-swift-proxy-server:train-2005"},
 					},
 					Service: contrail.Service{ServiceType: "ClusterIP"},
@@ -14,7 +14,6 @@ pull_image()
 while read line; do
 	pull_image svl-artifactory.juniper.net "${line}"
 done <<EOF
-contrail-operator/engprod-269421/contrail-operator-ringcontroller:master.latest
 contrail-operator/engprod-269421/contrail-operator:master.latest
 contrail-operator/engprod-269421/contrail-statusmonitor:master.latest
 contrail-operator/engprod-269421/contrail-operator-provisioner:master.latest
@@ -78,14 +77,12 @@ common-docker-third-party/contrail/centos-binary-swift-object-expirer:train-2005
 common-docker-third-party/contrail/centos-binary-swift-object:train-2005
 common-docker-third-party/contrail/centos-binary-swift-proxy-server:train-2005
 common-docker-third-party/contrail/centos-binary-swift-rsyncd:train-2005
-common-docker-third-party/contrail/centos-binary-kolla-toolbox:train-2005
 common-docker-third-party/contrail/centos-binary-swift-account:train
 common-docker-third-party/contrail/centos-binary-swift-container:train
 common-docker-third-party/contrail/centos-binary-swift-object-expirer:train
 common-docker-third-party/contrail/centos-binary-swift-object:train
 common-docker-third-party/contrail/centos-binary-swift-proxy-server:train
 common-docker-third-party/contrail/centos-binary-swift-rsyncd:train
-common-docker-third-party/contrail/centos-binary-kolla-toolbox:train
 common-docker-third-party/contrail/centos-binary-memcached:train
 common-docker-third-party/contrail/centos-binary-keystone:train
 EOF
@@ -26,7 +26,7 @@ type Contrail struct {
 }
 
 // ForManagerCondition is used to wait until manager has expected condition met
-func (c Contrail) ForManagerCondition(name string, expected contrail.ManagerConditionType) error {
+func (c Contrail) ForManagerCondition(name string, expected contrail.ConditionType) error {
 	m := &contrail.Manager{}
 	err := wait.Poll(c.RetryInterval, c.Timeout, func() (done bool, err error) {
 		err = c.Client.Get(context.Background(), types.NamespacedName{